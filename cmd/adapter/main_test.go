@@ -106,6 +106,14 @@ func (m *MockPluginManager) CatalogPublisher(_ context.Context, _ definition.Key
 	return nil, nil
 }
 
+func (m *MockPluginManager) Encryptor(_ context.Context, _ *plugin.Config) (definition.Encrypter, error) {
+	return nil, nil
+}
+
+func (m *MockPluginManager) Decryptor(_ context.Context, _ *plugin.Config) (definition.Decrypter, error) {
+	return nil, nil
+}
+
 // PolicyChecker returns a mock implementation of the PolicyChecker interface.
 func (m *MockPluginManager) PolicyChecker(ctx context.Context, manifestLoader definition.ManifestLoader, cfg *plugin.Config) (definition.PolicyChecker, error) {
 	if m.policyCheckerFunc != nil {
@@ -0,0 +1,262 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+)
+
+// defaultDenyListReloadInterval bounds how stale the dynamic deny-list may be
+// when SubscriberAuthConfig.ReloadInterval is unset.
+const defaultDenyListReloadInterval = 10 * time.Second
+
+// authorizeSubscriberStep decides whether the verified signer of a request may
+// use this module. It runs after validateSign and reads the signer from
+// StepContext.SignerID rather than re-parsing the signature headers.
+type authorizeSubscriberStep struct {
+	allow              map[string]bool
+	deny               map[string]bool
+	roles              map[string]bool
+	domains            map[string]bool
+	matchContextDomain bool
+	registry           definition.RegistryLookup
+	denyList           *dynamicDenyList
+}
+
+func newAuthorizeSubscriberStep(registry definition.RegistryLookup, cache definition.Cache, cfg SubscriberAuthConfig) (definition.Step, error) {
+	s := &authorizeSubscriberStep{
+		allow:              toSet(cfg.Allow, false),
+		deny:               toSet(cfg.Deny, false),
+		roles:              toSet(cfg.Roles, true),
+		domains:            toSet(cfg.Domains, false),
+		matchContextDomain: cfg.MatchContextDomain,
+		registry:           registry,
+	}
+	if (len(s.roles) > 0 || len(s.domains) > 0 || s.matchContextDomain) && registry == nil {
+		return nil, fmt.Errorf("invalid config: Registry plugin not configured; required by subscriberAuth roles/domains checks")
+	}
+	if cfg.DenyListCacheKey != "" && cache == nil {
+		return nil, fmt.Errorf("invalid config: Cache plugin not configured; required by subscriberAuth.denyListCacheKey")
+	}
+	if cfg.DenyListFile != "" || cfg.DenyListCacheKey != "" {
+		interval := cfg.ReloadInterval
+		if interval <= 0 {
+			interval = defaultDenyListReloadInterval
+		}
+		// Read the file once up front so a typo in the path fails startup
+		// instead of every request.
+		if cfg.DenyListFile != "" {
+			if _, err := readDenyListFile(cfg.DenyListFile); err != nil {
+				return nil, fmt.Errorf("invalid config: subscriberAuth.denyListFile: %w", err)
+			}
+		}
+		s.denyList = &dynamicDenyList{file: cfg.DenyListFile, cache: cache, cacheKey: cfg.DenyListCacheKey, interval: interval}
+		s.denyList.load(context.Background())
+	}
+	return s, nil
+}
+
+// Run executes the subscriber authorization step.
+func (s *authorizeSubscriberStep) Run(ctx *model.StepContext) error {
+	signer := ctx.SignerID
+	if signer == "" {
+		return model.NewSignValidationErr("AUT_SIGNATURE_MISSING", fmt.Errorf("authorizeSubscriber: no verified signer; add validateSign before authorizeSubscriber"))
+	}
+	if s.deny[signer] || s.denyList.contains(ctx, signer) {
+		return forbidden("subscriber %s is blocked", signer)
+	}
+	if len(s.allow) > 0 && !s.allow[signer] {
+		return forbidden("subscriber %s is not on the allow list", signer)
+	}
+	if len(s.roles) == 0 && len(s.domains) == 0 && !s.matchContextDomain {
+		return nil
+	}
+
+	subs, err := s.registry.Lookup(ctx, &model.Subscription{
+		Subscriber: model.Subscriber{SubscriberID: signer},
+		KeyID:      ctx.SignerKeyID,
+	})
+	if err != nil {
+		return registryLookupErr(fmt.Errorf("authorizeSubscriber: registry lookup failed: %w", err))
+	}
+	if len(subs) == 0 {
+		return model.NewSignValidationErr("AUT_SUBSCRIBER_NOT_FOUND", fmt.Errorf("subscriber %s not found in registry", signer))
+	}
+	entry := subs[0].Subscriber
+	if len(s.roles) > 0 && !s.roles[strings.ToUpper(entry.Type)] {
+		return forbidden("subscriber %s has role %q, which may not call this module", signer, entry.Type)
+	}
+	if len(s.domains) > 0 && !s.domains[entry.Domain] {
+		return forbidden("subscriber %s is registered for domain %q, which this module does not serve", signer, entry.Domain)
+	}
+	if s.matchContextDomain {
+		if msgDomain := extractContextDomain(ctx.Body); msgDomain != entry.Domain {
+			return forbidden("subscriber %s is registered for domain %q, not message domain %q", signer, entry.Domain, msgDomain)
+		}
+	}
+	return nil
+}
+
+// forbidden builds the 403 NACK for a signer that is authenticated but not
+// permitted.
+func forbidden(format string, args ...any) error {
+	return model.NewCodedErr(http.StatusForbidden, "AUT_UNAUTHORIZED_ACTION", fmt.Errorf(format, args...))
+}
+
+func toSet(values []string, upper bool) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if upper {
+			v = strings.ToUpper(v)
+		}
+		if v != "" {
+			set[v] = true
+		}
+	}
+	return set
+}
+
+func extractContextDomain(body []byte) string {
+	var payload struct {
+		Context struct {
+			Domain string `json:"domain"`
+		} `json:"context"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+	return payload.Context.Domain
+}
+
+// dynamicDenyList is a deny-list that operators can change without a restart,
+// sourced from a file, a cache key, or both. Sources are re-read in the
+// background at most once per interval; requests only read the current
+// snapshot, so they never wait on that I/O. A source that cannot be read
+// keeps its last good entries.
+type dynamicDenyList struct {
+	file     string
+	cache    definition.Cache
+	cacheKey string
+	interval time.Duration
+
+	snapshot atomic.Pointer[denyListSnapshot]
+
+	mu        sync.Mutex // guards loadedAt and reloading
+	loadedAt  time.Time
+	reloading bool
+	wg        sync.WaitGroup
+}
+
+// denyListSnapshot is an immutable view of the deny-list sources. A reload
+// builds a new snapshot and swaps it in.
+type denyListSnapshot struct {
+	fileModTime time.Time
+	fileIDs     map[string]bool
+	cacheIDs    map[string]bool
+}
+
+// load reads the sources synchronously, so the first request already sees
+// the configured entries.
+func (d *dynamicDenyList) load(ctx context.Context) {
+	d.snapshot.Store(d.reload(ctx, &denyListSnapshot{}))
+	d.loadedAt = time.Now()
+}
+
+// contains reports whether id is on the deny-list. A nil list contains nothing.
+func (d *dynamicDenyList) contains(ctx context.Context, id string) bool {
+	if d == nil {
+		return false
+	}
+	d.refresh(ctx)
+	snap := d.snapshot.Load()
+	return snap.fileIDs[id] || snap.cacheIDs[id]
+}
+
+// refresh starts a background reload once the interval has passed, unless
+// one is already running.
+func (d *dynamicDenyList) refresh(ctx context.Context) {
+	d.mu.Lock()
+	if d.reloading || time.Since(d.loadedAt) < d.interval {
+		d.mu.Unlock()
+		return
+	}
+	d.reloading = true
+	d.mu.Unlock()
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		next := d.reload(context.WithoutCancel(ctx), d.snapshot.Load())
+		d.snapshot.Store(next)
+		d.mu.Lock()
+		d.loadedAt, d.reloading = time.Now(), false
+		d.mu.Unlock()
+	}()
+}
+
+// reload returns a snapshot with the sources re-read, keeping prev's entries
+// for any source that cannot be read.
+func (d *dynamicDenyList) reload(ctx context.Context, prev *denyListSnapshot) *denyListSnapshot {
+	next := *prev
+	if d.file != "" {
+		if fi, err := os.Stat(d.file); err != nil {
+			log.Warnf(ctx, "authorizeSubscriber: deny-list file %s unavailable, keeping previous entries: %v", d.file, err)
+		} else if !fi.ModTime().Equal(prev.fileModTime) || prev.fileIDs == nil {
+			ids, err := readDenyListFile(d.file)
+			if err != nil {
+				log.Warnf(ctx, "authorizeSubscriber: failed to reload deny-list file %s, keeping previous entries: %v", d.file, err)
+			} else {
+				next.fileIDs, next.fileModTime = ids, fi.ModTime()
+				log.Infof(ctx, "authorizeSubscriber: loaded %d deny-list entries from %s", len(ids), d.file)
+			}
+		}
+	}
+	if d.cacheKey != "" {
+		value, err := d.cache.Get(ctx, d.cacheKey)
+		if err != nil {
+			log.Warnf(ctx, "authorizeSubscriber: failed to read deny-list cache key %s, keeping previous entries: %v", d.cacheKey, err)
+		} else {
+			next.cacheIDs = parseDenyList(value)
+		}
+	}
+	return &next
+}
+
+func readDenyListFile(path string) (map[string]bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseDenyList(string(data)), nil
+}
+
+// parseDenyList reads subscriber IDs separated by newlines or commas. Blank
+// lines and lines starting with '#' are ignored.
+func parseDenyList(value string) map[string]bool {
+	ids := make(map[string]bool)
+	sc := bufio.NewScanner(strings.NewReader(value))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, id := range strings.Split(line, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				ids[id] = true
+			}
+		}
+	}
+	return ids
+}
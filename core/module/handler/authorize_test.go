@@ -0,0 +1,259 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+)
+
+// authorizeRegistry returns a fixed registry entry for any subscriber it knows.
+type authorizeRegistry struct {
+	entries map[string]model.Subscriber
+	err     error
+	lookups []model.Subscription
+}
+
+func (m *authorizeRegistry) Lookup(_ context.Context, req *model.Subscription) ([]model.Subscription, error) {
+	m.lookups = append(m.lookups, *req)
+	if m.err != nil {
+		return nil, m.err
+	}
+	sub, ok := m.entries[req.SubscriberID]
+	if !ok {
+		return nil, nil
+	}
+	return []model.Subscription{{Subscriber: sub, KeyID: req.KeyID}}, nil
+}
+
+// denyListCache serves a single mutable value for every key.
+type denyListCache struct {
+	stubCache
+	mu    sync.Mutex
+	value string
+	err   error
+}
+
+func (c *denyListCache) Get(context.Context, string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.value, c.err
+}
+
+func (c *denyListCache) set(value string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.value, c.err = value, err
+}
+
+// reloadDenyList starts a deny-list reload and waits for it to finish.
+func reloadDenyList(step definition.Step) {
+	d := step.(*authorizeSubscriberStep).denyList
+	d.wg.Wait() // let a reload started by an earlier Run finish first
+	d.refresh(context.Background())
+	d.wg.Wait()
+}
+
+func authorizeCtx(signer, body string) *model.StepContext {
+	return &model.StepContext{Context: context.Background(), Body: []byte(body), SignerID: signer, SignerKeyID: "key-1"}
+}
+
+func assertAuthorizeErr(t *testing.T, err error, wantStatus int, wantCode string) {
+	t.Helper()
+	if wantCode == "" {
+		if err != nil {
+			t.Fatalf("Run() unexpected error: %v", err)
+		}
+		return
+	}
+	var coded *model.CodedErr
+	if !errors.As(err, &coded) {
+		t.Fatalf("Run() error = %v, want coded error %s", err, wantCode)
+	}
+	if coded.Code != wantCode || coded.HTTPStatus() != wantStatus {
+		t.Errorf("Run() error = %d %s, want %d %s", coded.HTTPStatus(), coded.Code, wantStatus, wantCode)
+	}
+}
+
+func TestAuthorizeSubscriberStep(t *testing.T) {
+	const body = `{"context":{"domain":"retail","action":"search"}}`
+	registry := &authorizeRegistry{entries: map[string]model.Subscriber{
+		"bap.example.com":  {SubscriberID: "bap.example.com", Type: "BAP", Domain: "retail"},
+		"bpp.example.com":  {SubscriberID: "bpp.example.com", Type: "BPP", Domain: "retail"},
+		"bap.mobility.com": {SubscriberID: "bap.mobility.com", Type: "bap", Domain: "mobility"},
+	}}
+	tests := []struct {
+		name       string
+		cfg        SubscriberAuthConfig
+		signer     string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "no restrictions", signer: "bap.example.com"},
+		{name: "missing signer", signer: "", wantStatus: http.StatusUnauthorized, wantCode: "AUT_SIGNATURE_MISSING"},
+		{name: "denied", cfg: SubscriberAuthConfig{Deny: []string{"bap.example.com"}}, signer: "bap.example.com", wantStatus: http.StatusForbidden, wantCode: "AUT_UNAUTHORIZED_ACTION"},
+		{name: "deny wins over allow", cfg: SubscriberAuthConfig{Allow: []string{"bap.example.com"}, Deny: []string{"bap.example.com"}}, signer: "bap.example.com", wantStatus: http.StatusForbidden, wantCode: "AUT_UNAUTHORIZED_ACTION"},
+		{name: "allowed", cfg: SubscriberAuthConfig{Allow: []string{"bap.example.com"}}, signer: "bap.example.com"},
+		{name: "not on allow list", cfg: SubscriberAuthConfig{Allow: []string{"bap.example.com"}}, signer: "bpp.example.com", wantStatus: http.StatusForbidden, wantCode: "AUT_UNAUTHORIZED_ACTION"},
+		{name: "role matches case-insensitively", cfg: SubscriberAuthConfig{Roles: []string{"bap"}}, signer: "bap.mobility.com"},
+		{name: "role mismatch", cfg: SubscriberAuthConfig{Roles: []string{"BAP"}}, signer: "bpp.example.com", wantStatus: http.StatusForbidden, wantCode: "AUT_UNAUTHORIZED_ACTION"},
+		{name: "domain allowed", cfg: SubscriberAuthConfig{Domains: []string{"retail"}}, signer: "bap.example.com"},
+		{name: "domain not served", cfg: SubscriberAuthConfig{Domains: []string{"retail"}}, signer: "bap.mobility.com", wantStatus: http.StatusForbidden, wantCode: "AUT_UNAUTHORIZED_ACTION"},
+		{name: "context domain matches", cfg: SubscriberAuthConfig{MatchContextDomain: true}, signer: "bap.example.com"},
+		{name: "context domain mismatch", cfg: SubscriberAuthConfig{MatchContextDomain: true}, signer: "bap.mobility.com", wantStatus: http.StatusForbidden, wantCode: "AUT_UNAUTHORIZED_ACTION"},
+		{name: "not in registry", cfg: SubscriberAuthConfig{Roles: []string{"BAP"}}, signer: "unknown.example.com", wantStatus: http.StatusUnauthorized, wantCode: "AUT_SUBSCRIBER_NOT_FOUND"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, err := newAuthorizeSubscriberStep(registry, nil, tt.cfg)
+			if err != nil {
+				t.Fatalf("newAuthorizeSubscriberStep() error = %v", err)
+			}
+			assertAuthorizeErr(t, step.Run(authorizeCtx(tt.signer, body)), tt.wantStatus, tt.wantCode)
+		})
+	}
+}
+
+func TestAuthorizeSubscriberStep_RegistryLookupUsesSignerKey(t *testing.T) {
+	registry := &authorizeRegistry{entries: map[string]model.Subscriber{
+		"bap.example.com": {SubscriberID: "bap.example.com", Type: "BAP"},
+	}}
+	step, err := newAuthorizeSubscriberStep(registry, nil, SubscriberAuthConfig{Roles: []string{"BAP"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := step.Run(authorizeCtx("bap.example.com", `{}`)); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if len(registry.lookups) != 1 || registry.lookups[0].KeyID != "key-1" {
+		t.Errorf("lookups = %+v, want one lookup with KeyID key-1", registry.lookups)
+	}
+
+	registry.err = errors.New("registry down")
+	if err := step.Run(authorizeCtx("bap.example.com", `{}`)); err == nil || !strings.Contains(err.Error(), "registry down") {
+		t.Errorf("Run() error = %v, want registry failure", err)
+	}
+}
+
+func TestAuthorizeSubscriberStep_DenyListFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deny.txt")
+	if err := os.WriteFile(path, []byte("# blocked subscribers\nbpp.example.com\n\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	step, err := newAuthorizeSubscriberStep(nil, nil, SubscriberAuthConfig{DenyListFile: path, ReloadInterval: time.Nanosecond})
+	if err != nil {
+		t.Fatalf("newAuthorizeSubscriberStep() error = %v", err)
+	}
+	assertAuthorizeErr(t, step.Run(authorizeCtx("bpp.example.com", `{}`)), http.StatusForbidden, "AUT_UNAUTHORIZED_ACTION")
+	assertAuthorizeErr(t, step.Run(authorizeCtx("bap.example.com", `{}`)), 0, "")
+
+	// Block a second subscriber; the change is picked up once the mtime moves.
+	if err := os.WriteFile(path, []byte("bpp.example.com, bap.example.com\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	reloadDenyList(step)
+	assertAuthorizeErr(t, step.Run(authorizeCtx("bap.example.com", `{}`)), http.StatusForbidden, "AUT_UNAUTHORIZED_ACTION")
+
+	// A file that disappears keeps the last good entries.
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	reloadDenyList(step)
+	assertAuthorizeErr(t, step.Run(authorizeCtx("bap.example.com", `{}`)), http.StatusForbidden, "AUT_UNAUTHORIZED_ACTION")
+}
+
+func TestAuthorizeSubscriberStep_DenyListCacheKey(t *testing.T) {
+	cache := &denyListCache{value: "bpp.example.com"}
+	step, err := newAuthorizeSubscriberStep(nil, cache, SubscriberAuthConfig{DenyListCacheKey: "onix:deny", ReloadInterval: time.Nanosecond})
+	if err != nil {
+		t.Fatalf("newAuthorizeSubscriberStep() error = %v", err)
+	}
+	assertAuthorizeErr(t, step.Run(authorizeCtx("bpp.example.com", `{}`)), http.StatusForbidden, "AUT_UNAUTHORIZED_ACTION")
+
+	cache.set("", nil)
+	reloadDenyList(step)
+	assertAuthorizeErr(t, step.Run(authorizeCtx("bpp.example.com", `{}`)), 0, "")
+
+	cache.set("bpp.example.com", nil)
+	reloadDenyList(step)
+	assertAuthorizeErr(t, step.Run(authorizeCtx("bpp.example.com", `{}`)), http.StatusForbidden, "AUT_UNAUTHORIZED_ACTION")
+	cache.set("", errors.New("cache unavailable"))
+	reloadDenyList(step)
+	assertAuthorizeErr(t, step.Run(authorizeCtx("bpp.example.com", `{}`)), http.StatusForbidden, "AUT_UNAUTHORIZED_ACTION")
+}
+
+// blockingDenyListCache blocks every Get after the first until release is closed.
+type blockingDenyListCache struct {
+	stubCache
+	gets    atomic.Int32
+	release chan struct{}
+}
+
+func (c *blockingDenyListCache) Get(context.Context, string) (string, error) {
+	if c.gets.Add(1) > 1 {
+		<-c.release
+	}
+	return "bpp.example.com", nil
+}
+
+func TestAuthorizeSubscriberStep_DenyListReloadDoesNotBlockRequests(t *testing.T) {
+	cache := &blockingDenyListCache{release: make(chan struct{})}
+	step, err := newAuthorizeSubscriberStep(nil, cache, SubscriberAuthConfig{DenyListCacheKey: "onix:deny", ReloadInterval: time.Nanosecond})
+	if err != nil {
+		t.Fatalf("newAuthorizeSubscriberStep() error = %v", err)
+	}
+	// The first reload hangs on the cache; requests keep using the loaded snapshot.
+	done := make(chan error, 3)
+	go func() {
+		for i := 0; i < 3; i++ {
+			done <- step.Run(authorizeCtx("bpp.example.com", `{}`))
+		}
+	}()
+	for i := 0; i < 3; i++ {
+		select {
+		case err := <-done:
+			assertAuthorizeErr(t, err, http.StatusForbidden, "AUT_UNAUTHORIZED_ACTION")
+		case <-time.After(5 * time.Second):
+			t.Fatal("Run() blocked behind a deny-list reload")
+		}
+	}
+	close(cache.release)
+	step.(*authorizeSubscriberStep).denyList.wg.Wait()
+	if got := cache.gets.Load(); got != 2 {
+		t.Errorf("cache Get calls = %d, want 2 (startup load and one background reload)", got)
+	}
+}
+
+func TestNewAuthorizeSubscriberStep_ConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     SubscriberAuthConfig
+		wantErr string
+	}{
+		{name: "roles without registry", cfg: SubscriberAuthConfig{Roles: []string{"BAP"}}, wantErr: "Registry plugin not configured"},
+		{name: "context domain without registry", cfg: SubscriberAuthConfig{MatchContextDomain: true}, wantErr: "Registry plugin not configured"},
+		{name: "cache key without cache", cfg: SubscriberAuthConfig{DenyListCacheKey: "onix:deny"}, wantErr: "Cache plugin not configured"},
+		{name: "missing deny-list file", cfg: SubscriberAuthConfig{DenyListFile: filepath.Join(t.TempDir(), "absent.txt")}, wantErr: "subscriberAuth.denyListFile"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newAuthorizeSubscriberStep(nil, nil, tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("newAuthorizeSubscriberStep() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -188,12 +188,17 @@ func NewCatalogPublishHandler(ctx context.Context, mgr PluginManager, cfg *Confi
 	// longer builds one at all (see registryMetadata below), so a
 	// manifest-backed policy type (opapolicychecker's policyType
 	// "manifest") cannot resolve here -- a known, accepted limitation of
-	// this simplification, not an oversight.
+	// this simplification, not an oversight. The schemaValidator is likewise
+	// given a nil ManifestLoader, so contextIntegrity_subscriberId fails here
+	// at startup instead of at the first request.
 	schemaValidator, err := loadPlugin(ctx, "SchemaValidator", cfg.Plugins.SchemaValidator, mgr.SchemaValidator)
 	if err != nil {
 		return nil, fmt.Errorf("catalogPublish handler %s: %w", moduleName, err)
 	}
-	policyChecker, err := loadPolicyChecker(ctx, mgr, nil, cfg.Plugins.PolicyChecker)
+	if err := setSchemaValidatorManifestLoader(schemaValidator, nil, cfg.Plugins.SchemaValidator); err != nil {
+		return nil, fmt.Errorf("catalogPublish handler %s: %w", moduleName, err)
+	}
+	policyChecker, err := loadPolicyChecker(ctx, mgr, nil, registry, nil, cfg.Plugins.PolicyChecker)
 	if err != nil {
 		return nil, fmt.Errorf("catalogPublish handler %s: %w", moduleName, err)
 	}
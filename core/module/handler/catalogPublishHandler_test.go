@@ -128,6 +128,12 @@ func (m *catalogPublishTestManager) KeyManager(context.Context, definition.Regis
 func (m *catalogPublishTestManager) CatalogPublisher(context.Context, definition.KeyManager, *plugin.Config) (definition.CatalogPublisher, error) {
 	return m.publisher, nil
 }
+func (m *catalogPublishTestManager) Encryptor(context.Context, *plugin.Config) (definition.Encrypter, error) {
+	panic("unused")
+}
+func (m *catalogPublishTestManager) Decryptor(context.Context, *plugin.Config) (definition.Decrypter, error) {
+	panic("unused")
+}
 func (m *catalogPublishTestManager) Middleware(context.Context, *plugin.Config) (func(http.Handler) http.Handler, error) {
 	panic("unused")
 }
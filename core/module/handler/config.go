@@ -30,6 +30,8 @@ type PluginManager interface {
 	SchemaValidator(ctx context.Context, cfg *plugin.Config) (definition.SchemaValidator, error)
 	PayloadStore(ctx context.Context, cache definition.Cache, namespace string, cfg *plugin.Config) (definition.PayloadStore, error)
 	CatalogPublisher(ctx context.Context, km definition.KeyManager, cfg *plugin.Config) (definition.CatalogPublisher, error)
+	Encryptor(ctx context.Context, cfg *plugin.Config) (definition.Encrypter, error)
+	Decryptor(ctx context.Context, cfg *plugin.Config) (definition.Decrypter, error)
 }
 
 // Type defines different handler types for processing requests.
@@ -44,6 +46,10 @@ const (
 	// root, bypassing validateSign/signAck since the caller is the
 	// operator's own tooling, not another network participant.
 	HandlerTypeCatalogPublish Type = "catalogPublish"
+	// HandlerTypeTransactionHistory serves a read-only, token-authenticated
+	// view of the PayloadStore for support staff, with PII masked by the
+	// audit field rules, and replays stored messages to configured modules.
+	HandlerTypeTransactionHistory Type = "transactionHistory"
 )
 
 // PluginCfg holds the configuration for various plugins.
@@ -63,6 +69,8 @@ type PluginCfg struct {
 	TransportWrapper      *plugin.Config  `yaml:"transportWrapper,omitempty"`
 	PayloadStore          *plugin.Config  `yaml:"payloadStore,omitempty"`
 	CatalogPublisher      *plugin.Config  `yaml:"catalogPublisher,omitempty"`
+	Encrypter             *plugin.Config  `yaml:"encrypter,omitempty"`
+	Decrypter             *plugin.Config  `yaml:"decrypter,omitempty"`
 	Middleware            []plugin.Config `yaml:"middleware,omitempty"`
 	Steps                 []plugin.Config
 }
@@ -92,6 +100,8 @@ func (p *PluginCfg) PluginEntries() []telemetry.PluginEntry {
 	add("key_manager", p.KeyManager)
 	add("payload_store", p.PayloadStore)
 	add("catalog_publisher", p.CatalogPublisher)
+	add("encrypter", p.Encrypter)
+	add("decrypter", p.Decrypter)
 	for i := range p.Steps {
 		if p.Steps[i].ID != "" {
 			entries = append(entries, telemetry.PluginEntry{Type: "step", ID: p.Steps[i].ID})
@@ -145,4 +155,107 @@ type Config struct {
 	// deployment step, not this handler's concern. Unused by any other
 	// handler type.
 	OutputRoot string `yaml:"outputRoot,omitempty"`
+	// FieldEncryption configures the encryptFields and decryptFields steps.
+	FieldEncryption FieldEncryptionConfig `yaml:"fieldEncryption,omitempty"`
+	// GatewayAuth configures how validateSign treats X-Gateway-Authorization.
+	GatewayAuth GatewayAuthConfig `yaml:"gatewayAuth,omitempty"`
+	// SubscriberAuth configures the authorizeSubscriber step.
+	SubscriberAuth SubscriberAuthConfig `yaml:"subscriberAuth,omitempty"`
+	// TransactionFlow configures the checkTransactionFlow step.
+	TransactionFlow TransactionFlowConfig `yaml:"transactionFlow,omitempty"`
+	// History configures the transactionHistory handler. Unused by any
+	// other handler type.
+	History HistoryConfig `yaml:"history,omitempty"`
+}
+
+// HistoryConfig configures the transactionHistory handler. At least one
+// bearer token must come from TokensFile or TokensEnv.
+type HistoryConfig struct {
+	// TokensFile names a file of accepted bearer tokens, one per line.
+	// Lines starting with '#' are comments.
+	TokensFile string `yaml:"tokensFile,omitempty"`
+	// TokensEnv names an environment variable holding comma-separated
+	// accepted bearer tokens.
+	TokensEnv string `yaml:"tokensEnv,omitempty"`
+	// ReplayTokensFile and ReplayTokensEnv hold the bearer tokens accepted by
+	// the replay route, in the same formats. The read tokens above do not
+	// unlock replay; replay tokens also unlock the read routes.
+	ReplayTokensFile string `yaml:"replayTokensFile,omitempty"`
+	ReplayTokensEnv  string `yaml:"replayTokensEnv,omitempty"`
+	// ReplayTargets maps a module name to the URL a replayed message is
+	// posted to, for example bapCaller: http://localhost:8081/bap/caller/.
+	// Replay is disabled when empty, and needs at least one replay token
+	// when set.
+	ReplayTargets map[string]string `yaml:"replayTargets,omitempty"`
+	// ReplayTimeout bounds a replayed request. Defaults to 30s.
+	ReplayTimeout time.Duration `yaml:"replayTimeout,omitempty"`
+}
+
+// SubscriberAuthConfig decides which verified signers may use a module.
+// The deny checks run first, then the allow list, then the registry-backed
+// role and domain checks.
+type SubscriberAuthConfig struct {
+	// Allow, when non-empty, is the only set of subscriber IDs accepted.
+	Allow []string `yaml:"allow,omitempty"`
+	// Deny lists subscriber IDs that are always rejected.
+	Deny []string `yaml:"deny,omitempty"`
+	// Roles restricts the signer's registry type (BAP, BPP, BG).
+	Roles []string `yaml:"roles,omitempty"`
+	// Domains restricts the domain the signer is registered for.
+	Domains []string `yaml:"domains,omitempty"`
+	// MatchContextDomain requires the signer's registered domain to equal
+	// context.domain of the request.
+	MatchContextDomain bool `yaml:"matchContextDomain,omitempty"`
+	// DenyListFile names a file of subscriber IDs, one per line, that is
+	// re-read when it changes. Lines starting with '#' are comments.
+	DenyListFile string `yaml:"denyListFile,omitempty"`
+	// DenyListCacheKey names a cache key holding newline- or comma-separated
+	// subscriber IDs, so a subscriber can be blocked across all instances.
+	DenyListCacheKey string `yaml:"denyListCacheKey,omitempty"`
+	// ReloadInterval is how often the deny-list file and cache key are
+	// checked for changes. Defaults to 10s.
+	ReloadInterval time.Duration `yaml:"reloadInterval,omitempty"`
+}
+
+// TransactionFlowConfig configures the checkTransactionFlow step. Without
+// any flows, the built-in Beckn v1 or v2 flow is used for every domain.
+type TransactionFlowConfig struct {
+	// MonitorOnly logs out-of-order messages instead of rejecting them.
+	MonitorOnly bool `yaml:"monitorOnly,omitempty"`
+	// Store is where the actions of each transaction are kept: "cache"
+	// (the default when the Cache plugin is configured) or "payloadStore",
+	// which reads the requests recorded by storePayload.
+	Store string `yaml:"store,omitempty"`
+	// StateTTL is how long the cache keeps a transaction's actions.
+	// Defaults to 24h. Unused with the payloadStore store.
+	StateTTL time.Duration `yaml:"stateTTL,omitempty"`
+	// FlowsFile names a YAML file of flows in the same shape as Flows.
+	FlowsFile string `yaml:"flowsFile,omitempty"`
+	// Flows maps a context.domain, or "*" for any other domain, to its flow.
+	// Entries here replace the same domain from FlowsFile.
+	Flows map[string]TransactionFlow `yaml:"flows,omitempty"`
+}
+
+// GatewayAuthConfig controls validation of the BG proxy signature carried in
+// X-Gateway-Authorization. A gateway signature that is present is always
+// verified and its signer must be registered as a BG.
+type GatewayAuthConfig struct {
+	// RequiredActions lists the Beckn actions (e.g. "search") that must arrive
+	// through a BG. A request for one of them without X-Gateway-Authorization
+	// is rejected with a Proxy-Authenticate challenge.
+	RequiredActions []string `yaml:"requiredActions,omitempty"`
+}
+
+// FieldEncryptionConfig lists the message fields that are end-to-end
+// encrypted for the counterparty.
+type FieldEncryptionConfig struct {
+	// Fields are dot-separated paths into the request body, e.g.
+	// "message.order.billing". A numeric segment indexes an array and "*"
+	// matches every element. Paths absent from a message are skipped.
+	Fields []string `yaml:"fields"`
+	// RecipientKeyIDs maps a counterparty subscriber ID to the unique key ID
+	// of the registry entry whose encryption public key encryptFields uses.
+	// The Beckn context does not carry the receiver's key ID, so it must be
+	// configured for every counterparty this handler encrypts for.
+	RecipientKeyIDs map[string]string `yaml:"recipientKeyIds,omitempty"`
 }
@@ -37,9 +37,11 @@ func TestPluginEntries_AllNamedSlots(t *testing.T) {
 		TransportWrapper: cfg("http_wrapper"),
 		PolicyChecker:    cfg("opa_policy"),
 		KeyManager:       cfg("beckn_key_mgr"),
+		Encrypter:        cfg("encrypter"),
+		Decrypter:        cfg("decrypter"),
 	}
 	entries := p.PluginEntries()
-	assert.Len(t, entries, 10)
+	assert.Len(t, entries, 12)
 
 	byType := make(map[string]string)
 	for _, e := range entries {
@@ -55,6 +57,8 @@ func TestPluginEntries_AllNamedSlots(t *testing.T) {
 	assert.Equal(t, "http_wrapper", byType["transport_wrapper"])
 	assert.Equal(t, "opa_policy", byType["policy_checker"])
 	assert.Equal(t, "beckn_key_mgr", byType["key_manager"])
+	assert.Equal(t, "encrypter", byType["encrypter"])
+	assert.Equal(t, "decrypter", byType["decrypter"])
 }
 
 func TestPluginEntries_StepsAndMiddleware(t *testing.T) {
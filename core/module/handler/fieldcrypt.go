@@ -0,0 +1,316 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+)
+
+// encryptedField replaces the value at each configured path once encrypted.
+// KeyID names the sender's registry key ("{subscriber_id}|{unique_key_id}|x25519")
+// so the recipient can look up the matching encryption public key.
+type encryptedField struct {
+	EncryptedData string `json:"encryptedData"`
+	KeyID         string `json:"keyId"`
+}
+
+const encryptionKeyAlgorithm = "x25519"
+
+// encryptFieldsStep encrypts the configured fields of an outgoing message for
+// the counterparty. It must run before sign so the signature covers the
+// ciphertext.
+type encryptFieldsStep struct {
+	encrypter       definition.Encrypter
+	km              definition.KeyManager
+	fields          [][]string
+	recipientKeyIDs map[string]string
+}
+
+func newEncryptFieldsStep(encrypter definition.Encrypter, km definition.KeyManager, cfg FieldEncryptionConfig) (definition.Step, error) {
+	if encrypter == nil {
+		return nil, fmt.Errorf("invalid config: Encrypter plugin not configured")
+	}
+	if km == nil {
+		return nil, fmt.Errorf("invalid config: KeyManager plugin not configured")
+	}
+	fields, err := parseFieldPaths(cfg.Fields)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptFieldsStep{encrypter: encrypter, km: km, fields: fields, recipientKeyIDs: cfg.RecipientKeyIDs}, nil
+}
+
+// Run executes the field encryption step.
+func (s *encryptFieldsStep) Run(ctx *model.StepContext) error {
+	if len(ctx.SubID) == 0 {
+		return model.NewBadReqErr("", fmt.Errorf("subscriberID not set"))
+	}
+	body, reqContext, err := decodeMessage(ctx.Body)
+	if err != nil {
+		return err
+	}
+	// The recipient and keys are resolved only once a configured field is
+	// present, so messages without one (e.g. a broadcast search, which has
+	// no bpp_id) pass through untouched.
+	var keys *encryptionKeys
+	changed := false
+	for _, path := range s.fields {
+		body, err = walkFieldPath(body, path, func(v any) (any, error) {
+			if keys == nil {
+				k, err := s.resolveKeys(ctx, reqContext)
+				if err != nil {
+					return nil, err
+				}
+				keys = k
+			}
+			plaintext, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			ciphertext, err := s.encrypter.Encrypt(ctx, string(plaintext), keys.keySet.EncrPrivate, keys.recipientPublicKey)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encrypt %s: %w", strings.Join(path, "."), err)
+			}
+			changed = true
+			return encryptedField{EncryptedData: ciphertext, KeyID: keys.senderKeyID}, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if !changed {
+		return nil
+	}
+	if ctx.Body, err = json.Marshal(body); err != nil {
+		return fmt.Errorf("encryptFields: failed to encode message: %w", err)
+	}
+	log.Debugf(ctx, "encryptFields: encrypted fields for %s", keys.recipientID)
+	return nil
+}
+
+// encryptionKeys are the keys encryptFields uses for one message.
+type encryptionKeys struct {
+	recipientID        string
+	recipientPublicKey string
+	senderKeyID        string
+	keySet             *model.Keyset
+}
+
+// resolveKeys finds the counterparty's encryption public key and this node's
+// keyset.
+func (s *encryptFieldsStep) resolveKeys(ctx *model.StepContext, reqContext map[string]any) (*encryptionKeys, error) {
+	recipientID := model.ResolveCallerID(reqContext, ctx.Role)
+	if recipientID == "" {
+		return nil, model.NewBadReqErr("", fmt.Errorf("encryptFields: counterparty subscriber ID not found in context"))
+	}
+	recipientKeyID := s.recipientKeyIDs[recipientID]
+	if recipientKeyID == "" {
+		return nil, fmt.Errorf("encryptFields: no recipient key ID configured for %s", recipientID)
+	}
+	keySet, err := s.km.Keyset(ctx, ctx.SubID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get encryption key: %w", err)
+	}
+	_, recipientPublicKey, err := s.km.LookupNPKeys(ctx, recipientID, recipientKeyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get encryption key for %s: %w", recipientID, err)
+	}
+	return &encryptionKeys{
+		recipientID:        recipientID,
+		recipientPublicKey: recipientPublicKey,
+		senderKeyID:        fmt.Sprintf("%s|%s|%s", ctx.SubID, keySet.UniqueKeyID, encryptionKeyAlgorithm),
+		keySet:             keySet,
+	}, nil
+}
+
+// decryptFieldsStep decrypts the configured fields of an incoming message.
+// It should run after validateSign so the ciphertext is known to come from
+// the signer.
+type decryptFieldsStep struct {
+	decrypter definition.Decrypter
+	km        definition.KeyManager
+	fields    [][]string
+}
+
+func newDecryptFieldsStep(decrypter definition.Decrypter, km definition.KeyManager, cfg FieldEncryptionConfig) (definition.Step, error) {
+	if decrypter == nil {
+		return nil, fmt.Errorf("invalid config: Decrypter plugin not configured")
+	}
+	if km == nil {
+		return nil, fmt.Errorf("invalid config: KeyManager plugin not configured")
+	}
+	fields, err := parseFieldPaths(cfg.Fields)
+	if err != nil {
+		return nil, err
+	}
+	return &decryptFieldsStep{decrypter: decrypter, km: km, fields: fields}, nil
+}
+
+// Run executes the field decryption step.
+func (s *decryptFieldsStep) Run(ctx *model.StepContext) error {
+	if len(ctx.SubID) == 0 {
+		return model.NewBadReqErr("", fmt.Errorf("subscriberID not set"))
+	}
+	body, reqContext, err := decodeMessage(ctx.Body)
+	if err != nil {
+		return err
+	}
+	senderID := model.ResolveCallerID(reqContext, ctx.Role)
+
+	var keySet *model.Keyset
+	changed := false
+	for _, path := range s.fields {
+		body, err = walkFieldPath(body, path, func(v any) (any, error) {
+			field, err := asEncryptedField(v)
+			if err != nil {
+				return nil, model.NewBadReqErr("", fmt.Errorf("field %s: %w", strings.Join(path, "."), err))
+			}
+			keyVals, err := parseKeyID(field.KeyID)
+			if err != nil {
+				return nil, model.NewBadReqErr("", fmt.Errorf("field %s: %w", strings.Join(path, "."), err))
+			}
+			if !strings.EqualFold(keyVals.Algorithm, encryptionKeyAlgorithm) {
+				return nil, model.NewBadReqErr("", fmt.Errorf("field %s: unsupported key algorithm %q", strings.Join(path, "."), keyVals.Algorithm))
+			}
+			if senderID != "" && keyVals.SubscriberID != senderID {
+				return nil, model.NewBadReqErr("", fmt.Errorf("field %s: encrypted by %s, expected sender %s", strings.Join(path, "."), keyVals.SubscriberID, senderID))
+			}
+			if keySet == nil {
+				if keySet, err = s.km.Keyset(ctx, ctx.SubID); err != nil {
+					return nil, fmt.Errorf("failed to get decryption key: %w", err)
+				}
+			}
+			_, senderPublicKey, err := s.km.LookupNPKeys(ctx, keyVals.SubscriberID, keyVals.UniqueID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get encryption key for %s: %w", keyVals.SubscriberID, err)
+			}
+			plaintext, err := s.decrypter.Decrypt(ctx, field.EncryptedData, keySet.EncrPrivate, senderPublicKey)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decrypt %s: %w", strings.Join(path, "."), err)
+			}
+			decoded, err := decodeJSONValue([]byte(plaintext))
+			if err != nil {
+				return nil, model.NewBadReqErr("", fmt.Errorf("field %s: decrypted value is not JSON: %w", strings.Join(path, "."), err))
+			}
+			changed = true
+			return decoded, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if !changed {
+		return nil
+	}
+	if ctx.Body, err = json.Marshal(body); err != nil {
+		return fmt.Errorf("decryptFields: failed to encode message: %w", err)
+	}
+	return nil
+}
+
+// parseFieldPaths splits the configured dot-separated paths.
+func parseFieldPaths(fields []string) ([][]string, error) {
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("invalid config: fieldEncryption.fields is empty")
+	}
+	paths := make([][]string, 0, len(fields))
+	for _, f := range fields {
+		segs := strings.Split(f, ".")
+		for _, seg := range segs {
+			if seg == "" {
+				return nil, fmt.Errorf("invalid config: malformed fieldEncryption path %q", f)
+			}
+		}
+		if segs[0] == "context" {
+			return nil, fmt.Errorf("invalid config: fieldEncryption path %q must not be inside context", f)
+		}
+		paths = append(paths, segs)
+	}
+	return paths, nil
+}
+
+// walkFieldPath replaces every value matched by path with fn's result and
+// returns the updated node. Missing keys and out-of-range indices are skipped.
+func walkFieldPath(node any, path []string, fn func(any) (any, error)) (any, error) {
+	if len(path) == 0 {
+		return fn(node)
+	}
+	seg, rest := path[0], path[1:]
+	switch n := node.(type) {
+	case map[string]any:
+		child, ok := n[seg]
+		if !ok {
+			return node, nil
+		}
+		updated, err := walkFieldPath(child, rest, fn)
+		if err != nil {
+			return nil, err
+		}
+		n[seg] = updated
+	case []any:
+		if seg == "*" {
+			for i := range n {
+				updated, err := walkFieldPath(n[i], rest, fn)
+				if err != nil {
+					return nil, err
+				}
+				n[i] = updated
+			}
+			return node, nil
+		}
+		i, err := strconv.Atoi(seg)
+		if err != nil || i < 0 || i >= len(n) {
+			return node, nil
+		}
+		updated, err := walkFieldPath(n[i], rest, fn)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = updated
+	}
+	return node, nil
+}
+
+// decodeMessage parses a Beckn message body, keeping numbers verbatim so
+// re-encoding does not alter them.
+func decodeMessage(body []byte) (any, map[string]any, error) {
+	v, err := decodeJSONValue(body)
+	if err != nil {
+		return nil, nil, model.NewBadReqErr("", fmt.Errorf("invalid JSON body: %w", err))
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, nil, model.NewBadReqErr("", fmt.Errorf("request body must be a JSON object"))
+	}
+	reqContext, _ := m["context"].(map[string]any)
+	return m, reqContext, nil
+}
+
+func decodeJSONValue(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func asEncryptedField(v any) (*encryptedField, error) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected an encrypted field object")
+	}
+	data, _ := m["encryptedData"].(string)
+	keyID, _ := m["keyId"].(string)
+	if data == "" || keyID == "" {
+		return nil, fmt.Errorf("encrypted field requires encryptedData and keyId")
+	}
+	return &encryptedField{EncryptedData: data, KeyID: keyID}, nil
+}
@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	decryption "github.com/beckn-one/beckn-onix/pkg/plugin/implementation/decrypter"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/implementation/encrypter"
+)
+
+// fieldCryptKM serves keysets for a set of subscribers, acting both as the
+// local key manager and as the registry lookup for counterparties.
+type fieldCryptKM struct {
+	mockKMBasic
+	keysets map[string]*model.Keyset
+}
+
+func (m *fieldCryptKM) Keyset(_ context.Context, subID string) (*model.Keyset, error) {
+	ks, ok := m.keysets[subID]
+	if !ok {
+		return nil, fmt.Errorf("no keyset for %s", subID)
+	}
+	return ks, nil
+}
+
+func (m *fieldCryptKM) LookupNPKeys(_ context.Context, subID, ukID string) (string, string, error) {
+	ks, ok := m.keysets[subID]
+	if !ok || ks.UniqueKeyID != ukID {
+		return "", "", fmt.Errorf("subscriber %s|%s not found", subID, ukID)
+	}
+	return ks.SigningPublic, ks.EncrPublic, nil
+}
+
+func newFieldCryptKeyset(t *testing.T, subID, ukID string) *model.Keyset {
+	t.Helper()
+	k, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &model.Keyset{
+		SubscriberID: subID,
+		UniqueKeyID:  ukID,
+		EncrPrivate:  base64.StdEncoding.EncodeToString(k.Bytes()),
+		EncrPublic:   base64.StdEncoding.EncodeToString(k.PublicKey().Bytes()),
+	}
+}
+
+func newFieldCryptSteps(t *testing.T, cfg FieldEncryptionConfig, km *fieldCryptKM) (*encryptFieldsStep, *decryptFieldsStep) {
+	t.Helper()
+	e, _, _ := encrypter.New(context.Background(), &encrypter.Config{Envelope: encrypter.EnvelopeV2})
+	d, _, _ := decryption.New(context.Background())
+	enc, err := newEncryptFieldsStep(e, km, cfg)
+	if err != nil {
+		t.Fatalf("newEncryptFieldsStep() error = %v", err)
+	}
+	dec, err := newDecryptFieldsStep(d, km, cfg)
+	if err != nil {
+		t.Fatalf("newDecryptFieldsStep() error = %v", err)
+	}
+	return enc.(*encryptFieldsStep), dec.(*decryptFieldsStep)
+}
+
+const fieldCryptBody = `{"context":{"action":"init","bap_id":"bap.example.com","bpp_id":"bpp.example.com"},` +
+	`"message":{"order":{"billing":{"name":"Jane Doe","phone":"9999999999"},"quote":{"price":{"value":12.50}},` +
+	`"fulfillments":[{"id":"f1","customer":{"contact":"a"}},{"id":"f2","customer":{"contact":"b"}}]}}}`
+
+func TestFieldEncryptionRoundTrip(t *testing.T) {
+	km := &fieldCryptKM{keysets: map[string]*model.Keyset{
+		"bap.example.com": newFieldCryptKeyset(t, "bap.example.com", "bap-key"),
+		"bpp.example.com": newFieldCryptKeyset(t, "bpp.example.com", "bpp-key"),
+	}}
+	cfg := FieldEncryptionConfig{
+		Fields:          []string{"message.order.billing", "message.order.fulfillments.*.customer", "message.order.missing"},
+		RecipientKeyIDs: map[string]string{"bpp.example.com": "bpp-key"},
+	}
+	enc, dec := newFieldCryptSteps(t, cfg, km)
+
+	ctx := &model.StepContext{Context: context.Background(), Body: []byte(fieldCryptBody), SubID: "bap.example.com", Role: model.RoleBAP}
+	if err := enc.Run(ctx); err != nil {
+		t.Fatalf("encryptFields Run() error = %v", err)
+	}
+	if strings.Contains(string(ctx.Body), "Jane Doe") || strings.Contains(string(ctx.Body), `"contact"`) {
+		t.Fatalf("encrypted body still carries plaintext: %s", ctx.Body)
+	}
+	var encrypted struct {
+		Message struct {
+			Order struct {
+				Billing encryptedField `json:"billing"`
+			} `json:"order"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(ctx.Body, &encrypted); err != nil {
+		t.Fatal(err)
+	}
+	if got := encrypted.Message.Order.Billing.KeyID; got != "bap.example.com|bap-key|x25519" {
+		t.Errorf("keyId = %q", got)
+	}
+	if !strings.HasPrefix(encrypted.Message.Order.Billing.EncryptedData, "v2:") {
+		t.Errorf("encryptedData = %q, want v2 envelope", encrypted.Message.Order.Billing.EncryptedData)
+	}
+
+	// The BPP receives the message and decrypts it with its own keyset.
+	rctx := &model.StepContext{Context: context.Background(), Body: ctx.Body, SubID: "bpp.example.com", Role: model.RoleBPP}
+	if err := dec.Run(rctx); err != nil {
+		t.Fatalf("decryptFields Run() error = %v", err)
+	}
+	var got, want any
+	if err := json.Unmarshal(rctx.Body, &got); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(fieldCryptBody), &want); err != nil {
+		t.Fatal(err)
+	}
+	gotJSON, _ := json.Marshal(got)
+	wantJSON, _ := json.Marshal(want)
+	if string(gotJSON) != string(wantJSON) {
+		t.Errorf("decrypted body = %s, want %s", gotJSON, wantJSON)
+	}
+	if !strings.Contains(string(rctx.Body), "12.50") {
+		t.Errorf("decrypted body altered a number: %s", rctx.Body)
+	}
+}
+
+func TestEncryptFieldsNothingToEncrypt(t *testing.T) {
+	km := &fieldCryptKM{keysets: map[string]*model.Keyset{
+		"bap.example.com": newFieldCryptKeyset(t, "bap.example.com", "bap-key"),
+		"bpp.example.com": newFieldCryptKeyset(t, "bpp.example.com", "bpp-key"),
+	}}
+	enc, _ := newFieldCryptSteps(t, FieldEncryptionConfig{
+		Fields:          []string{"message.order.payment"},
+		RecipientKeyIDs: map[string]string{"bpp.example.com": "bpp-key"},
+	}, km)
+	ctx := &model.StepContext{Context: context.Background(), Body: []byte(fieldCryptBody), SubID: "bap.example.com", Role: model.RoleBAP}
+	if err := enc.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if string(ctx.Body) != fieldCryptBody {
+		t.Errorf("body re-encoded although no field matched: %s", ctx.Body)
+	}
+}
+
+func TestEncryptFieldsBroadcastWithoutRecipient(t *testing.T) {
+	km := &fieldCryptKM{keysets: map[string]*model.Keyset{
+		"bap.example.com": newFieldCryptKeyset(t, "bap.example.com", "bap-key"),
+	}}
+	enc, _ := newFieldCryptSteps(t, FieldEncryptionConfig{Fields: []string{"message.order.billing"}}, km)
+	const body = `{"context":{"action":"search","bap_id":"bap.example.com"},"message":{"intent":{}}}`
+	ctx := &model.StepContext{Context: context.Background(), Body: []byte(body), SubID: "bap.example.com", Role: model.RoleBAP}
+	if err := enc.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v, want nil for a message with no field to encrypt", err)
+	}
+	if string(ctx.Body) != body {
+		t.Errorf("body changed: %s", ctx.Body)
+	}
+}
+
+func TestEncryptFieldsErrors(t *testing.T) {
+	km := &fieldCryptKM{keysets: map[string]*model.Keyset{
+		"bap.example.com": newFieldCryptKeyset(t, "bap.example.com", "bap-key"),
+	}}
+	tests := []struct {
+		name    string
+		keyIDs  map[string]string
+		body    string
+		wantErr string
+	}{
+		{name: "no recipient key ID", keyIDs: nil, body: fieldCryptBody, wantErr: "no recipient key ID configured for bpp.example.com"},
+		{name: "recipient not in registry", keyIDs: map[string]string{"bpp.example.com": "bpp-key"}, body: fieldCryptBody, wantErr: "failed to get encryption key for bpp.example.com"},
+		{name: "no counterparty", keyIDs: nil, body: `{"context":{"action":"init"},"message":{"order":{"billing":{"name":"x"}}}}`, wantErr: "counterparty subscriber ID not found"},
+		{name: "invalid JSON", keyIDs: nil, body: `{`, wantErr: "invalid JSON body"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc, _ := newFieldCryptSteps(t, FieldEncryptionConfig{Fields: []string{"message.order.billing"}, RecipientKeyIDs: tt.keyIDs}, km)
+			ctx := &model.StepContext{Context: context.Background(), Body: []byte(tt.body), SubID: "bap.example.com", Role: model.RoleBAP}
+			err := enc.Run(ctx)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Run() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecryptFieldsErrors(t *testing.T) {
+	km := &fieldCryptKM{keysets: map[string]*model.Keyset{
+		"bap.example.com":  newFieldCryptKeyset(t, "bap.example.com", "bap-key"),
+		"bpp.example.com":  newFieldCryptKeyset(t, "bpp.example.com", "bpp-key"),
+		"evil.example.com": newFieldCryptKeyset(t, "evil.example.com", "evil-key"),
+	}}
+	cfg := FieldEncryptionConfig{Fields: []string{"message.order.billing"}}
+	_, dec := newFieldCryptSteps(t, cfg, km)
+
+	field := func(v string) string {
+		return `{"context":{"action":"init","bap_id":"bap.example.com","bpp_id":"bpp.example.com"},"message":{"order":{"billing":` + v + `}}}`
+	}
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{name: "plaintext value", body: field(`{"name":"Jane"}`), wantErr: "requires encryptedData and keyId"},
+		{name: "string value", body: field(`"Jane"`), wantErr: "expected an encrypted field object"},
+		{name: "bad keyId", body: field(`{"encryptedData":"v2:AAAA","keyId":"bap.example.com"}`), wantErr: "incorrect format"},
+		{name: "signing key algorithm", body: field(`{"encryptedData":"v2:AAAA","keyId":"bap.example.com|bap-key|ed25519"}`), wantErr: "unsupported key algorithm"},
+		{name: "foreign sender", body: field(`{"encryptedData":"v2:AAAA","keyId":"evil.example.com|evil-key|x25519"}`), wantErr: "expected sender bap.example.com"},
+		{name: "tampered ciphertext", body: field(`{"encryptedData":"v2:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA","keyId":"bap.example.com|bap-key|x25519"}`), wantErr: "failed to decrypt message.order.billing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &model.StepContext{Context: context.Background(), Body: []byte(tt.body), SubID: "bpp.example.com", Role: model.RoleBPP}
+			err := dec.Run(ctx)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Run() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewFieldCryptStepsConfig(t *testing.T) {
+	e, _, _ := encrypter.New(context.Background(), &encrypter.Config{Envelope: encrypter.EnvelopeV2})
+	km := &fieldCryptKM{}
+	tests := []struct {
+		name    string
+		fields  []string
+		wantErr string
+	}{
+		{name: "no fields", fields: nil, wantErr: "fieldEncryption.fields is empty"},
+		{name: "empty segment", fields: []string{"message..billing"}, wantErr: "malformed fieldEncryption path"},
+		{name: "context path", fields: []string{"context.bap_id"}, wantErr: "must not be inside context"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newEncryptFieldsStep(e, km, FieldEncryptionConfig{Fields: tt.fields})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("newEncryptFieldsStep() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+	if _, err := newEncryptFieldsStep(nil, km, FieldEncryptionConfig{Fields: []string{"message"}}); err == nil {
+		t.Error("newEncryptFieldsStep() expected error without Encrypter")
+	}
+	if _, err := newDecryptFieldsStep(nil, km, FieldEncryptionConfig{Fields: []string{"message"}}); err == nil {
+		t.Error("newDecryptFieldsStep() expected error without Decrypter")
+	}
+}
@@ -0,0 +1,572 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/plugin"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+	"github.com/beckn-one/beckn-onix/pkg/telemetry"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultReplayTimeout = 30 * time.Second
+	// maxReplayResponseBytes caps how much of a replay target's response is
+	// read back into the replay result.
+	maxReplayResponseBytes = 1 << 20
+)
+
+// compiledAuditConfig returns the loaded audit fields config; a variable so
+// tests can simulate a deployment without one.
+var compiledAuditConfig = telemetry.GetCompiledConfig
+
+// historyHandler serves the transactionHistory handler type: a read-only
+// view of the PayloadStore for support staff who have no access to its
+// backend, and replay of stored requests for reproducing incidents.
+//
+// Routes, relative to the module path:
+//
+//	GET  transactions/{transactionId}   every entry of a transaction, in time order
+//	GET  messages/{messageId}           a request and its stored response
+//	GET  messages?subscriberId=&action=&networkId=&kind=&from=&to=&limit=
+//	                                    search, when the store implements PayloadQuerier
+//	POST messages/{messageId}/replay    re-submit a stored request to a replay target
+//
+// Every route requires an "Authorization: Bearer <token>" header with one of
+// the configured tokens. Replay, which emits signed traffic, only accepts the
+// separate replay tokens; those also unlock the read routes. Bodies are masked with the audit field rules
+// (telemetry.MaskPayload) before they leave the handler; a body that cannot
+// be masked, or any body while no audit fields config is loaded, is withheld.
+type historyHandler struct {
+	store   definition.PayloadStore
+	querier definition.PayloadQuerier // nil when the store cannot search
+	tokens  [][]byte
+
+	replayTokens  [][]byte
+	replayTargets map[string]string
+	// signer signs replayed requests; nil sends them unsigned, for targets
+	// that sign themselves (caller modules).
+	signer       definition.Step
+	subscriberID string
+	role         model.Role
+	httpClient   *http.Client
+	basePath     string
+	now          func() time.Time
+}
+
+// NewTransactionHistoryHandler builds the transactionHistory handler type
+// from cfg.Plugins.PayloadStore (required) and, to sign replayed requests,
+// cfg.Plugins.Signer and KeyManager.
+func NewTransactionHistoryHandler(ctx context.Context, mgr PluginManager, cfg *Config, moduleName string) (http.Handler, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("transactionHistory handler %s: config is required", moduleName)
+	}
+	// Bodies are only ever returned masked, so the handler does not start
+	// without the audit fields config that holds the masking rules.
+	if compiledAuditConfig() == nil {
+		return nil, fmt.Errorf("transactionHistory handler %s: no audit fields config loaded; required to mask payloads (set otelsetup auditFieldsConfig)", moduleName)
+	}
+	tokens, err := loadHistoryTokens(cfg.History)
+	if err != nil {
+		return nil, fmt.Errorf("transactionHistory handler %s: %w", moduleName, err)
+	}
+	replayTokens, err := readTokens(cfg.History.ReplayTokensFile, cfg.History.ReplayTokensEnv)
+	if err != nil {
+		return nil, fmt.Errorf("transactionHistory handler %s: replayTokensFile: %w", moduleName, err)
+	}
+	if len(cfg.History.ReplayTargets) > 0 && len(replayTokens) == 0 {
+		return nil, fmt.Errorf("transactionHistory handler %s: history.replayTokensFile or history.replayTokensEnv must provide at least one bearer token when replayTargets is set", moduleName)
+	}
+
+	cache, err := loadPlugin(ctx, "Cache", cfg.Plugins.Cache, mgr.Cache)
+	if err != nil {
+		return nil, err
+	}
+	// "onix" is the namespace stdHandler stores under, so this handler reads
+	// what the std modules sharing the backend recorded.
+	store, err := loadPayloadStore(ctx, mgr, cache, "onix", cfg.Plugins.PayloadStore, cfg.Role)
+	if err != nil {
+		return nil, err
+	}
+	if store == nil {
+		return nil, fmt.Errorf("transactionHistory handler %s: payloadStore plugin not configured", moduleName)
+	}
+
+	h := &historyHandler{
+		store:         store,
+		tokens:        tokens,
+		replayTokens:  replayTokens,
+		replayTargets: cfg.History.ReplayTargets,
+		subscriberID:  cfg.SubscriberID,
+		role:          cfg.Role,
+		basePath:      cfg.BasePath,
+		now:           time.Now,
+	}
+	h.querier, _ = store.(definition.PayloadQuerier)
+
+	if cfg.Plugins.Signer != nil {
+		registry, err := loadPlugin(ctx, "Registry", cfg.Plugins.Registry, func(ctx context.Context, c *plugin.Config) (definition.RegistryLookup, error) {
+			return mgr.Registry(ctx, cache, c)
+		})
+		if err != nil {
+			return nil, err
+		}
+		km, err := loadKeyManager(ctx, mgr, registry, cfg.Plugins.KeyManager)
+		if err != nil {
+			return nil, err
+		}
+		signer, err := loadPlugin(ctx, "Signer", cfg.Plugins.Signer, mgr.Signer)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.SubscriberID == "" {
+			return nil, fmt.Errorf("transactionHistory handler %s: subscriberId is required to sign replayed requests", moduleName)
+		}
+		if h.signer, err = newSignStep(signer, km, nil); err != nil {
+			return nil, fmt.Errorf("transactionHistory handler %s: %w", moduleName, err)
+		}
+	}
+
+	timeout := cfg.History.ReplayTimeout
+	if timeout <= 0 {
+		timeout = defaultReplayTimeout
+	}
+	h.httpClient = &http.Client{Timeout: timeout}
+
+	log.Debugf(ctx, "transactionHistory handler %s initialized, %d replay target(s), search supported=%t", moduleName, len(h.replayTargets), h.querier != nil)
+	return h, nil
+}
+
+// loadHistoryTokens reads the accepted bearer tokens from cfg.TokensFile
+// and cfg.TokensEnv.
+func loadHistoryTokens(cfg HistoryConfig) ([][]byte, error) {
+	tokens, err := readTokens(cfg.TokensFile, cfg.TokensEnv)
+	if err != nil {
+		return nil, fmt.Errorf("reading tokensFile: %w", err)
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("history.tokensFile or history.tokensEnv must provide at least one bearer token")
+	}
+	return tokens, nil
+}
+
+// readTokens reads bearer tokens from file, one per line with '#' starting a
+// comment, and from the comma-separated environment variable env.
+func readTokens(file, env string) ([][]byte, error) {
+	var tokens [][]byte
+	if file != "" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			if line := strings.TrimSpace(sc.Text()); line != "" && !strings.HasPrefix(line, "#") {
+				tokens = append(tokens, []byte(line))
+			}
+		}
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+	}
+	if env != "" {
+		for _, t := range strings.Split(os.Getenv(env), ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				tokens = append(tokens, []byte(t))
+			}
+		}
+	}
+	return tokens, nil
+}
+
+// historyEntry is the API view of a definition.PayloadEntry.
+type historyEntry struct {
+	MessageID     string    `json:"messageId"`
+	TransactionID string    `json:"transactionId,omitempty"`
+	NetworkID     string    `json:"networkId,omitempty"`
+	Action        string    `json:"action,omitempty"`
+	Kind          string    `json:"kind"`
+	Direction     string    `json:"direction,omitempty"`
+	SubscriberID  string    `json:"subscriberId,omitempty"`
+	Role          string    `json:"role,omitempty"`
+	StoredAt      time.Time `json:"storedAt"`
+	ExpiresAt     time.Time `json:"expiresAt"`
+	// ElapsedMs is the time since the transaction's first entry; set only
+	// in the transaction view.
+	ElapsedMs       *int64            `json:"elapsedMs,omitempty"`
+	Signature       string            `json:"signature,omitempty"`
+	Status          string            `json:"status,omitempty"`
+	StatusCode      int               `json:"statusCode,omitempty"`
+	ResponseHeaders map[string]string `json:"responseHeaders,omitempty"`
+	Body            json.RawMessage   `json:"body,omitempty"`
+	// BodyWithheld says why a stored body is not returned.
+	BodyWithheld string `json:"bodyWithheld,omitempty"`
+}
+
+type historyResponse struct {
+	TransactionID string         `json:"transactionId,omitempty"`
+	Messages      []historyEntry `json:"messages"`
+}
+
+// replayRequest is the body of a replay call.
+type replayRequest struct {
+	// Target names an entry of history.replayTargets.
+	Target string `json:"target"`
+	// NewMessageID gives the replayed request a fresh message ID, so the
+	// target does not treat it as a duplicate.
+	NewMessageID bool `json:"newMessageId,omitempty"`
+}
+
+type replayResponse struct {
+	OriginalMessageID string          `json:"originalMessageId"`
+	MessageID         string          `json:"messageId"`
+	Target            string          `json:"target"`
+	StatusCode        int             `json:"statusCode"`
+	Response          json.RawMessage `json:"response,omitempty"`
+	ResponseWithheld  string          `json:"responseWithheld,omitempty"`
+}
+
+// ServeHTTP authenticates the request and dispatches it to its route.
+func (h *historyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token := bearerToken(r)
+	canReplay := tokenIn(token, h.replayTokens)
+	if !canReplay && !tokenIn(token, h.tokens) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="onix-history"`)
+		writeHistoryError(w, r, http.StatusUnauthorized, "missing or invalid bearer token")
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, strings.TrimSuffix(h.basePath, "/")), "/")
+	parts := strings.Split(path, "/")
+	switch {
+	case len(parts) == 2 && parts[0] == "transactions" && parts[1] != "":
+		if h.allowMethod(w, r, http.MethodGet) {
+			h.serveTransaction(w, r, parts[1])
+		}
+	case len(parts) == 1 && parts[0] == "messages":
+		if h.allowMethod(w, r, http.MethodGet) {
+			h.serveSearch(w, r)
+		}
+	case len(parts) == 2 && parts[0] == "messages" && parts[1] != "":
+		if h.allowMethod(w, r, http.MethodGet) {
+			h.serveMessage(w, r, parts[1])
+		}
+	case len(parts) == 3 && parts[0] == "messages" && parts[1] != "" && parts[2] == "replay":
+		if !canReplay {
+			writeHistoryError(w, r, http.StatusForbidden, "bearer token does not allow replay; use one of history.replayTokens")
+			return
+		}
+		if h.allowMethod(w, r, http.MethodPost) {
+			h.serveReplay(w, r, parts[1])
+		}
+	default:
+		writeHistoryError(w, r, http.StatusNotFound, "not found")
+	}
+}
+
+// bearerToken returns the bearer token of r's Authorization header, or "".
+func bearerToken(r *http.Request) string {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return ""
+	}
+	return token
+}
+
+// tokenIn reports whether token is one of tokens. Every token is compared,
+// in constant time, so the response time does not reveal which one nearly
+// matched.
+func tokenIn(token string, tokens [][]byte) bool {
+	if token == "" {
+		return false
+	}
+	match := 0
+	for _, t := range tokens {
+		match |= subtle.ConstantTimeCompare([]byte(token), t)
+	}
+	return match == 1
+}
+
+func (h *historyHandler) allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeHistoryError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+	return false
+}
+
+func (h *historyHandler) serveTransaction(w http.ResponseWriter, r *http.Request, transactionID string) {
+	entries, err := h.store.GetByTransactionID(r.Context(), transactionID)
+	if err != nil {
+		log.Errorf(r.Context(), err, "transactionHistory: reading transaction %s", transactionID)
+		writeHistoryError(w, r, http.StatusBadGateway, "payload store unavailable")
+		return
+	}
+	if len(entries) == 0 {
+		writeHistoryError(w, r, http.StatusNotFound, "transaction not found")
+		return
+	}
+	messages := historyEntries(entries)
+	for i := range messages {
+		elapsed := entries[i].StoredAt.Sub(entries[0].StoredAt).Milliseconds()
+		messages[i].ElapsedMs = &elapsed
+	}
+	writeHistoryJSON(w, r, http.StatusOK, historyResponse{TransactionID: transactionID, Messages: messages})
+}
+
+// serveMessage returns the request stored for messageID and, when it was
+// stored too, its response.
+func (h *historyHandler) serveMessage(w http.ResponseWriter, r *http.Request, messageID string) {
+	entry, err := h.store.GetByMessageID(r.Context(), messageID, "")
+	if err != nil {
+		log.Errorf(r.Context(), err, "transactionHistory: reading message %s", messageID)
+		writeHistoryError(w, r, http.StatusBadGateway, "payload store unavailable")
+		return
+	}
+	if entry == nil {
+		writeHistoryError(w, r, http.StatusNotFound, "message not found")
+		return
+	}
+	entries := []definition.PayloadEntry{*entry}
+	if entry.TransactionID != "" {
+		txn, err := h.store.GetByTransactionID(r.Context(), entry.TransactionID)
+		if err != nil {
+			log.Warnf(r.Context(), "transactionHistory: reading response for message %s: %v", messageID, err)
+		}
+		for _, e := range txn {
+			if e.MessageID == messageID && e.Kind == definition.PayloadKindResponse {
+				entries = append(entries, e)
+			}
+		}
+	}
+	writeHistoryJSON(w, r, http.StatusOK, historyResponse{TransactionID: entry.TransactionID, Messages: historyEntries(entries)})
+}
+
+func (h *historyHandler) serveSearch(w http.ResponseWriter, r *http.Request) {
+	if h.querier == nil {
+		writeHistoryError(w, r, http.StatusNotImplemented, "the configured payload store does not support search")
+		return
+	}
+	v := r.URL.Query()
+	q := definition.PayloadQuery{
+		SubscriberID:  v.Get("subscriberId"),
+		Action:        v.Get("action"),
+		NetworkID:     v.Get("networkId"),
+		TransactionID: v.Get("transactionId"),
+		Kind:          definition.PayloadKind(v.Get("kind")),
+	}
+	for _, f := range []struct {
+		name string
+		dst  *time.Time
+	}{{"from", &q.From}, {"to", &q.To}} {
+		if s := v.Get(f.name); s != "" {
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				writeHistoryError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid %s: want RFC 3339", f.name))
+				return
+			}
+			*f.dst = t
+		}
+	}
+	if s := v.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			writeHistoryError(w, r, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		q.Limit = n
+	}
+
+	entries, err := h.querier.Query(r.Context(), q)
+	if err != nil {
+		log.Errorf(r.Context(), err, "transactionHistory: search")
+		writeHistoryError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeHistoryJSON(w, r, http.StatusOK, historyResponse{Messages: historyEntries(entries)})
+}
+
+// serveReplay re-submits the request stored for messageID to a replay
+// target, with a fresh context.timestamp and, when requested, a fresh
+// message ID, signed anew when a Signer is configured.
+func (h *historyHandler) serveReplay(w http.ResponseWriter, r *http.Request, messageID string) {
+	var req replayRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<16)).Decode(&req); err != nil {
+		writeHistoryError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	targetURL, ok := h.replayTargets[req.Target]
+	if !ok {
+		writeHistoryError(w, r, http.StatusBadRequest, fmt.Sprintf("unknown replay target %q", req.Target))
+		return
+	}
+
+	entry, err := h.store.GetByMessageID(r.Context(), messageID, "")
+	if err != nil {
+		log.Errorf(r.Context(), err, "transactionHistory: reading message %s", messageID)
+		writeHistoryError(w, r, http.StatusBadGateway, "payload store unavailable")
+		return
+	}
+	if entry == nil {
+		writeHistoryError(w, r, http.StatusNotFound, "message not found")
+		return
+	}
+	body, newID, err := refreshReplayBody(entry.RequestBody, h.now(), req.NewMessageID)
+	if err != nil {
+		writeHistoryError(w, r, http.StatusConflict, fmt.Sprintf("message %s cannot be replayed: %v", messageID, err))
+		return
+	}
+	if newID == "" {
+		newID = messageID
+	}
+
+	out, err := http.NewRequestWithContext(r.Context(), http.MethodPost, targetURL, bytes.NewReader(body))
+	if err != nil {
+		writeHistoryError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	out.Header.Set("Content-Type", "application/json")
+	if h.signer != nil {
+		stepCtx := &model.StepContext{
+			Context:         r.Context(),
+			Request:         out,
+			Body:            body,
+			Role:            h.role,
+			SubID:           h.subscriberID,
+			ProtocolVersion: extractProtocolVersion(body),
+			MessageID:       newID,
+		}
+		if err := h.signer.Run(stepCtx); err != nil {
+			log.Errorf(r.Context(), err, "transactionHistory: signing replay of %s", messageID)
+			writeHistoryError(w, r, http.StatusInternalServerError, "failed to sign replayed request")
+			return
+		}
+	}
+
+	log.Infof(r.Context(), "transactionHistory: replaying message %s as %s to %s (%s)", messageID, newID, req.Target, targetURL)
+	resp, err := h.httpClient.Do(out)
+	if err != nil {
+		log.Errorf(r.Context(), err, "transactionHistory: replay of %s to %s failed", messageID, req.Target)
+		writeHistoryError(w, r, http.StatusBadGateway, fmt.Sprintf("replay target %s: %v", req.Target, err))
+		return
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxReplayResponseBytes))
+
+	result := replayResponse{OriginalMessageID: messageID, MessageID: newID, Target: req.Target, StatusCode: resp.StatusCode}
+	result.Response, result.ResponseWithheld = maskedBody(respBody)
+	writeHistoryJSON(w, r, http.StatusOK, result)
+}
+
+// refreshReplayBody returns body with context.timestamp set to now and,
+// when newMessageID is set, a fresh message ID, which it also returns.
+// Both context key spellings (message_id / messageId) are handled; a key
+// the body does not have is not added.
+func refreshReplayBody(body []byte, now time.Time, newMessageID bool) ([]byte, string, error) {
+	if len(body) == 0 {
+		return nil, "", errors.New("its body was not stored (storeBody: false)")
+	}
+	var root map[string]any
+	if err := json.Unmarshal(body, &root); err != nil {
+		return nil, "", errors.New("its stored body is not valid JSON (truncated by maxBodyBytes?)")
+	}
+	bctx, ok := root["context"].(map[string]any)
+	if !ok {
+		return nil, "", errors.New("its stored body has no context")
+	}
+	bctx["timestamp"] = now.UTC().Format("2006-01-02T15:04:05.000Z")
+	var id string
+	if newMessageID {
+		id = uuid.NewString()
+		for _, k := range []string{"message_id", "messageId"} {
+			if _, ok := bctx[k]; ok {
+				bctx[k] = id
+			}
+		}
+	}
+	out, err := json.Marshal(root)
+	if err != nil {
+		return nil, "", err
+	}
+	return out, id, nil
+}
+
+// historyEntries converts stored entries to their masked API view.
+func historyEntries(entries []definition.PayloadEntry) []historyEntry {
+	out := make([]historyEntry, 0, len(entries))
+	for _, e := range entries {
+		kind := e.Kind
+		if kind == "" {
+			kind = definition.PayloadKindRequest
+		}
+		he := historyEntry{
+			MessageID:       e.MessageID,
+			TransactionID:   e.TransactionID,
+			NetworkID:       e.NetworkID,
+			Action:          e.Action,
+			Kind:            string(kind),
+			Direction:       string(e.Direction),
+			SubscriberID:    e.SubscriberID,
+			Role:            string(e.Role),
+			StoredAt:        e.StoredAt,
+			ExpiresAt:       e.ExpiresAt,
+			Signature:       e.Signature,
+			Status:          string(e.Status),
+			StatusCode:      e.StatusCode,
+			ResponseHeaders: e.ResponseHeaders,
+		}
+		body := e.RequestBody
+		if kind == definition.PayloadKindResponse {
+			body = e.ResponseBody
+		}
+		he.Body, he.BodyWithheld = maskedBody(body)
+		out = append(out, he)
+	}
+	return out
+}
+
+// maskedBody masks body with the audit field rules, or returns why it is
+// withheld when it cannot be masked.
+func maskedBody(body []byte) (json.RawMessage, string) {
+	if len(body) == 0 {
+		return nil, ""
+	}
+	// MaskPayload passes bodies through unmasked when no config is loaded.
+	if compiledAuditConfig() == nil {
+		return nil, "no audit fields config loaded; body cannot be masked"
+	}
+	masked, err := telemetry.MaskPayload(body)
+	if err != nil {
+		return nil, "body is not a JSON object and cannot be masked (truncated by maxBodyBytes?)"
+	}
+	return masked, ""
+}
+
+func writeHistoryJSON(w http.ResponseWriter, r *http.Request, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Errorf(r.Context(), err, "transactionHistory handler: failed to encode response")
+	}
+}
+
+func writeHistoryError(w http.ResponseWriter, r *http.Request, status int, msg string) {
+	writeHistoryJSON(w, r, status, map[string]string{"error": msg})
+}
@@ -0,0 +1,372 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/plugin"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+	"github.com/beckn-one/beckn-onix/pkg/telemetry"
+)
+
+const (
+	historyToken = "s3cret"
+	replayToken  = "r3play"
+)
+
+// historyTestStore is an in-memory PayloadStore holding one transaction.
+type historyTestStore struct {
+	stubPayloadStore
+	entries []definition.PayloadEntry
+	queries []definition.PayloadQuery
+}
+
+func (s *historyTestStore) GetByTransactionID(_ context.Context, id string) ([]definition.PayloadEntry, error) {
+	var out []definition.PayloadEntry
+	for _, e := range s.entries {
+		if e.TransactionID == id {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (s *historyTestStore) GetByMessageID(_ context.Context, id, _ string) (*definition.PayloadEntry, error) {
+	for i, e := range s.entries {
+		if e.MessageID == id && e.Kind != definition.PayloadKindResponse {
+			return &s.entries[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// historyQuerierStore adds PayloadQuerier to historyTestStore.
+type historyQuerierStore struct {
+	historyTestStore
+}
+
+func (s *historyQuerierStore) Query(_ context.Context, q definition.PayloadQuery) ([]definition.PayloadEntry, error) {
+	s.queries = append(s.queries, q)
+	return s.entries, nil
+}
+
+func historyTestEntries() []definition.PayloadEntry {
+	start := time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+	return []definition.PayloadEntry{
+		{
+			MessageID: "m1", TransactionID: "t1", Action: "confirm", Kind: definition.PayloadKindRequest,
+			Direction: definition.PayloadOutbound, Signature: "Signature keyId=\"bap|k1|ed25519\"", StoredAt: start,
+			RequestBody: []byte(`{"context":{"action":"confirm","message_id":"m1","timestamp":"2026-03-14T09:30:00.000Z"},"message":{"order":{"billing":{"email":"alice@example.com"}}}}`),
+		},
+		{
+			MessageID: "m1", TransactionID: "t1", Action: "confirm", Kind: definition.PayloadKindResponse,
+			Direction: definition.PayloadInbound, Status: model.StatusACK, StatusCode: http.StatusOK,
+			StoredAt: start.Add(120 * time.Millisecond), ResponseBody: []byte(`{"message":{"ack":{"status":"ACK"}}}`),
+		},
+		{
+			MessageID: "m2", TransactionID: "t1", Action: "on_confirm", Direction: definition.PayloadInbound,
+			StoredAt: start.Add(2 * time.Second), RequestBody: []byte(`{"context":{"action":"on_con`),
+		},
+	}
+}
+
+func newTestHistoryHandler(store definition.PayloadStore) *historyHandler {
+	h := &historyHandler{
+		store:        store,
+		tokens:       [][]byte{[]byte(historyToken)},
+		replayTokens: [][]byte{[]byte(replayToken)},
+		basePath:     "/history/",
+		httpClient:   http.DefaultClient,
+		now:          func() time.Time { return time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC) },
+	}
+	h.querier, _ = store.(definition.PayloadQuerier)
+	return h
+}
+
+// loadTestAuditConfig masks "email" fields for the duration of the test.
+func loadTestAuditConfig(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	masking := write("audit.yaml", "mode: full\npatterns:\n  email:\n    mask: \"***\"\nmaskRules:\n  - keys: [email]\n    pattern: email\n")
+	if err := telemetry.LoadAuditConfig(context.Background(), masking); err != nil {
+		t.Fatalf("LoadAuditConfig() error = %v", err)
+	}
+	empty := write("empty.yaml", "mode: full\n")
+	t.Cleanup(func() { _ = telemetry.LoadAuditConfig(context.Background(), empty) })
+}
+
+func serveHistory(h http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	return serveHistoryAs(h, historyToken, method, target, body)
+}
+
+func serveHistoryAs(h http.Handler, token, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func decodeHistory(t *testing.T, rec *httptest.ResponseRecorder) historyResponse {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	var resp historyResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	return resp
+}
+
+func TestHistoryHandler_RequiresBearerToken(t *testing.T) {
+	h := newTestHistoryHandler(&historyTestStore{})
+	for _, auth := range []string{"", "Bearer wrong", historyToken, "Basic " + historyToken} {
+		req := httptest.NewRequest(http.MethodGet, "/history/transactions/t1", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("Authorization %q: status = %d, want 401 with a challenge", auth, rec.Code)
+		}
+	}
+}
+
+func TestHistoryHandler_Transaction(t *testing.T) {
+	loadTestAuditConfig(t)
+	h := newTestHistoryHandler(&historyTestStore{entries: historyTestEntries()})
+
+	resp := decodeHistory(t, serveHistory(h, http.MethodGet, "/history/transactions/t1", ""))
+	if resp.TransactionID != "t1" || len(resp.Messages) != 3 {
+		t.Fatalf("response = %+v, want 3 messages of t1", resp)
+	}
+	req, ack, callback := resp.Messages[0], resp.Messages[1], resp.Messages[2]
+	if strings.Contains(string(req.Body), "alice@example.com") || !strings.Contains(string(req.Body), `"email":"***"`) {
+		t.Errorf("request body not masked: %s", req.Body)
+	}
+	if req.Signature == "" || req.Direction != "outbound" || *req.ElapsedMs != 0 {
+		t.Errorf("unexpected request entry: %+v", req)
+	}
+	if ack.Kind != "response" || ack.Status != "ACK" || ack.StatusCode != http.StatusOK || *ack.ElapsedMs != 120 {
+		t.Errorf("unexpected response entry: %+v", ack)
+	}
+	if callback.Kind != "request" || callback.Body != nil || callback.BodyWithheld == "" {
+		t.Errorf("a truncated body should be withheld, got %+v", callback)
+	}
+
+	if rec := serveHistory(h, http.MethodGet, "/history/transactions/unknown", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown transaction: status = %d, want 404", rec.Code)
+	}
+	if rec := serveHistory(h, http.MethodDelete, "/history/transactions/t1", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("DELETE: status = %d, want 405", rec.Code)
+	}
+	if rec := serveHistory(h, http.MethodGet, "/history/other", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown route: status = %d, want 404", rec.Code)
+	}
+}
+
+func TestHistoryHandler_Message(t *testing.T) {
+	h := newTestHistoryHandler(&historyTestStore{entries: historyTestEntries()})
+
+	resp := decodeHistory(t, serveHistory(h, http.MethodGet, "/history/messages/m1", ""))
+	if len(resp.Messages) != 2 || resp.Messages[0].Kind != "request" || resp.Messages[1].Kind != "response" {
+		t.Errorf("response = %+v, want m1's request and response", resp)
+	}
+	if resp.Messages[0].ElapsedMs != nil {
+		t.Error("elapsedMs should only be set in the transaction view")
+	}
+	if rec := serveHistory(h, http.MethodGet, "/history/messages/nope", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown message: status = %d, want 404", rec.Code)
+	}
+}
+
+func TestHistoryHandler_Search(t *testing.T) {
+	if rec := serveHistory(newTestHistoryHandler(&historyTestStore{}), http.MethodGet, "/history/messages?action=confirm", ""); rec.Code != http.StatusNotImplemented {
+		t.Errorf("store without Query: status = %d, want 501", rec.Code)
+	}
+
+	store := &historyQuerierStore{historyTestStore{entries: historyTestEntries()}}
+	h := newTestHistoryHandler(store)
+	resp := decodeHistory(t, serveHistory(h, http.MethodGet, "/history/messages?subscriberId=bap&action=confirm&kind=request&from=2026-03-14T00:00:00Z&limit=5", ""))
+	if len(resp.Messages) != 3 || len(store.queries) != 1 {
+		t.Fatalf("response = %+v, queries = %+v", resp, store.queries)
+	}
+	q := store.queries[0]
+	if q.SubscriberID != "bap" || q.Action != "confirm" || q.Kind != definition.PayloadKindRequest || q.Limit != 5 ||
+		!q.From.Equal(time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)) || !q.To.IsZero() {
+		t.Errorf("query = %+v", q)
+	}
+
+	for _, bad := range []string{"from=yesterday", "limit=-1"} {
+		if rec := serveHistory(h, http.MethodGet, "/history/messages?"+bad, ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", bad, rec.Code)
+		}
+	}
+}
+
+func TestHistoryHandler_Replay(t *testing.T) {
+	loadTestAuditConfig(t)
+	var gotBody []byte
+	var gotAuth string
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotAuth = r.Header.Get(model.AuthHeaderSubscriber)
+		w.Write([]byte(`{"message":{"ack":{"status":"ACK"}}}`))
+	}))
+	defer target.Close()
+
+	h := newTestHistoryHandler(&historyTestStore{entries: historyTestEntries()})
+	h.replayTargets = map[string]string{"bppReceiver": target.URL}
+	h.subscriberID = "bap.example.com"
+	h.role = model.RoleBAP
+	signer, err := newSignStep(&mockSigner{returnSignSig: "fresh-sig"}, &mockKM{keyset: &model.Keyset{UniqueKeyID: "k1"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.signer = signer
+
+	if rec := serveHistory(h, http.MethodPost, "/history/messages/m1/replay", `{"target":"bppReceiver"}`); rec.Code != http.StatusForbidden || gotBody != nil {
+		t.Fatalf("replay with a read token: status = %d, want 403 and nothing sent", rec.Code)
+	}
+	if rec := serveHistoryAs(h, replayToken, http.MethodGet, "/history/messages/m1", ""); rec.Code != http.StatusOK {
+		t.Errorf("read with a replay token: status = %d, want 200", rec.Code)
+	}
+
+	rec := serveHistoryAs(h, replayToken, http.MethodPost, "/history/messages/m1/replay", `{"target":"bppReceiver","newMessageId":true}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	var result replayResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.OriginalMessageID != "m1" || result.MessageID == "m1" || result.StatusCode != http.StatusOK || len(result.Response) == 0 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	var sent struct {
+		Context map[string]string `json:"context"`
+	}
+	if err := json.Unmarshal(gotBody, &sent); err != nil {
+		t.Fatalf("replayed body is not JSON: %v", err)
+	}
+	if sent.Context["timestamp"] != "2026-04-01T12:00:00.000Z" || sent.Context["message_id"] != result.MessageID {
+		t.Errorf("replayed context = %v, want a fresh timestamp and message ID", sent.Context)
+	}
+	if !strings.Contains(gotAuth, `keyId="bap.example.com|k1|ed25519"`) || !strings.Contains(gotAuth, "fresh-sig") {
+		t.Errorf("replayed request not re-signed: %q", gotAuth)
+	}
+
+	tests := []struct {
+		name, path, body string
+		want             int
+	}{
+		{"unknown target", "/history/messages/m1/replay", `{"target":"elsewhere"}`, http.StatusBadRequest},
+		{"unknown message", "/history/messages/nope/replay", `{"target":"bppReceiver"}`, http.StatusNotFound},
+		{"truncated body", "/history/messages/m2/replay", `{"target":"bppReceiver"}`, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := serveHistoryAs(h, replayToken, http.MethodPost, tt.path, tt.body); rec.Code != tt.want {
+				t.Errorf("status = %d, want %d (%s)", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestLoadHistoryTokens(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "tokens")
+	if err := os.WriteFile(file, []byte("# support team\nalpha\n\nbeta\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ONIX_HISTORY_TOKENS", "gamma, delta")
+
+	tokens, err := loadHistoryTokens(HistoryConfig{TokensFile: file, TokensEnv: "ONIX_HISTORY_TOKENS"})
+	if err != nil {
+		t.Fatalf("loadHistoryTokens() error = %v", err)
+	}
+	var got []string
+	for _, tk := range tokens {
+		got = append(got, string(tk))
+	}
+	if strings.Join(got, ",") != "alpha,beta,gamma,delta" {
+		t.Errorf("tokens = %v", got)
+	}
+
+	if _, err := loadHistoryTokens(HistoryConfig{TokensEnv: "ONIX_HISTORY_UNSET"}); err == nil {
+		t.Error("expected an error when no token is configured")
+	}
+}
+
+func TestNewTransactionHistoryHandler_RequiresPayloadStore(t *testing.T) {
+	loadTestAuditConfig(t)
+	t.Setenv("ONIX_HISTORY_TOKENS", historyToken)
+	cfg := &Config{
+		History: HistoryConfig{TokensEnv: "ONIX_HISTORY_TOKENS"},
+		Plugins: PluginCfg{PayloadStore: &plugin.Config{ID: "payloadstore"}},
+	}
+	// noopPluginManager returns a nil PayloadStore.
+	if _, err := NewTransactionHistoryHandler(context.Background(), noopPluginManager{}, cfg, "history"); err == nil ||
+		!strings.Contains(err.Error(), "payloadStore plugin not configured") {
+		t.Errorf("NewTransactionHistoryHandler() error = %v", err)
+	}
+}
+
+func TestHistoryHandler_WithoutAuditConfig(t *testing.T) {
+	orig := compiledAuditConfig
+	compiledAuditConfig = func() *telemetry.CompiledConfig { return nil }
+	t.Cleanup(func() { compiledAuditConfig = orig })
+
+	t.Setenv("ONIX_HISTORY_TOKENS", historyToken)
+	cfg := &Config{History: HistoryConfig{TokensEnv: "ONIX_HISTORY_TOKENS"}}
+	if _, err := NewTransactionHistoryHandler(context.Background(), noopPluginManager{}, cfg, "history"); err == nil ||
+		!strings.Contains(err.Error(), "audit fields config") {
+		t.Errorf("NewTransactionHistoryHandler() error = %v, want a missing audit config error", err)
+	}
+
+	// A config that disappears after startup withholds every body.
+	h := newTestHistoryHandler(&historyTestStore{entries: historyTestEntries()})
+	resp := decodeHistory(t, serveHistory(h, http.MethodGet, "/history/transactions/t1", ""))
+	for _, m := range resp.Messages {
+		if m.Body != nil || m.BodyWithheld == "" {
+			t.Errorf("message %s %s: body returned without masking: %s", m.MessageID, m.Kind, m.Body)
+		}
+	}
+}
+
+func TestNewTransactionHistoryHandler_ReplayTokens(t *testing.T) {
+	loadTestAuditConfig(t)
+	t.Setenv("ONIX_HISTORY_TOKENS", historyToken)
+	cfg := &Config{History: HistoryConfig{
+		TokensEnv:     "ONIX_HISTORY_TOKENS",
+		ReplayTargets: map[string]string{"bapCaller": "http://localhost:8081/bap/caller/"},
+	}}
+	if _, err := NewTransactionHistoryHandler(context.Background(), noopPluginManager{}, cfg, "history"); err == nil ||
+		!strings.Contains(err.Error(), "replayTokensEnv") {
+		t.Errorf("NewTransactionHistoryHandler() error = %v, want replay tokens required with replayTargets", err)
+	}
+
+	cfg.History.ReplayTokensFile = "/nonexistent/replay-tokens"
+	if _, err := NewTransactionHistoryHandler(context.Background(), noopPluginManager{}, cfg, "history"); err == nil ||
+		!strings.Contains(err.Error(), "replayTokensFile") {
+		t.Errorf("NewTransactionHistoryHandler() error = %v, want an unreadable replayTokensFile error", err)
+	}
+}
@@ -1,11 +1,14 @@
 package handler
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/beckn-one/beckn-onix/pkg/log"
@@ -401,3 +404,201 @@ func (v *validateAckSignatureStep) RunOnResponse(ctx *model.StepContext, rctx *m
 	log.Debugf(ctx, "validateAckSign: Signature verified OK (status=%d)", rctx.StatusCode)
 	return nil
 }
+
+// ---------------------------------------------------------------------------
+// Response validation — validateResponseSchema / checkResponsePolicy
+// ---------------------------------------------------------------------------
+
+// invalidResponseErr is returned by a response validation step when the
+// upstream's synchronous response is invalid. Unlike other response step
+// errors, which fail the proxied call with a 502, it makes proxy replace the
+// upstream response with ONIX's own NACK for err (see replaceWithNack).
+type invalidResponseErr struct {
+	err error
+}
+
+// newInvalidResponseErr classifies err from a response validator. Only a
+// violation — a *model.SchemaValidationErr or a 4xx *model.CodedErr — means
+// the upstream sent an invalid response. It is NACKed with a 502 that blames
+// the upstream, not the caller, so the violation's own 400 code is kept in
+// the message only. Any other error means the response could not be checked
+// and is returned as is, failing the call with a 502.
+func newInvalidResponseErr(check string, err error) error {
+	var schemaErr *model.SchemaValidationErr
+	var codedErr *model.CodedErr
+	violation := errors.As(err, &schemaErr) ||
+		errors.As(err, &codedErr) && codedErr.HTTPStatus() < http.StatusInternalServerError
+	if !violation {
+		return fmt.Errorf("response %s could not run: %w", check, err)
+	}
+	return &invalidResponseErr{err: model.NewCodedErr(http.StatusBadGateway, "NET_UPSTREAM_INVALID_RESPONSE",
+		fmt.Errorf("upstream response failed %s: %v", check, err))}
+}
+
+func (e *invalidResponseErr) Error() string { return e.err.Error() }
+
+func (e *invalidResponseErr) Unwrap() error { return e.err }
+
+// replaceWithNack replaces the upstream response with the NACK sendNack would
+// write for err. The upstream's Signature header covered the discarded body,
+// so it is dropped; a signAck step later in the response steps signs the NACK.
+func replaceWithNack(ctx *model.StepContext, resp *http.Response, rctx *model.ResponseStepContext, err error) {
+	log.Warnf(ctx, "Replacing invalid upstream response (status=%d) with a NACK: %v", rctx.StatusCode, err)
+	becknErr, httpStatus, bodyStatus := nackBecknError(ctx, err)
+	body := nackBodyBytes(ctx, becknErr, bodyStatus)
+
+	resp.StatusCode = httpStatus
+	resp.Status = fmt.Sprintf("%d %s", httpStatus, http.StatusText(httpStatus))
+	resp.Header.Del("Signature")
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Set("Content-Type", "application/json")
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	resp.ContentLength = int64(len(body))
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	rctx.StatusCode = httpStatus
+	rctx.Body = body
+	rctx.Replaced = true
+}
+
+// validateResponseSchemaStep validates the upstream's synchronous ACK/NACK
+// body against the response schema for the request's action. It is the
+// response-direction counterpart of validateSchemaStep.
+//
+// No-ops:
+//   - publisher / no-route path (rctx == nil) — ONIX writes the ACK itself
+//   - a response already replaced by an earlier validation step
+type validateResponseSchemaStep struct {
+	validator definition.ResponseSchemaValidator
+}
+
+// newValidateResponseSchemaStep returns a new validateResponseSchemaStep after
+// validating its dependencies.
+func newValidateResponseSchemaStep(schemaValidator definition.SchemaValidator) (definition.ResponseStep, error) {
+	if schemaValidator == nil {
+		return nil, fmt.Errorf("invalid config: SchemaValidator plugin not configured")
+	}
+	rv, ok := schemaValidator.(definition.ResponseSchemaValidator)
+	if !ok {
+		return nil, fmt.Errorf("invalid config: SchemaValidator plugin does not implement ResponseSchemaValidator")
+	}
+	return &validateResponseSchemaStep{validator: rv}, nil
+}
+
+// RunOnResponse validates rctx.Body, returning an invalidResponseErr when the
+// body does not match the response schema.
+func (s *validateResponseSchemaStep) RunOnResponse(ctx *model.StepContext, rctx *model.ResponseStepContext) error {
+	if rctx == nil || rctx.Replaced {
+		return nil
+	}
+	if err := s.validator.ValidateResponse(ctx, extractBecknAction(ctx.Body), rctx.StatusCode, rctx.Body); err != nil {
+		return newInvalidResponseErr("schema validation", err)
+	}
+	return nil
+}
+
+// checkResponsePolicyStep checks the upstream's synchronous ACK/NACK body
+// against the network policy. It is the response-direction counterpart of
+// checkPolicyStep, with the same no-ops as validateResponseSchemaStep.
+type checkResponsePolicyStep struct {
+	checker definition.ResponsePolicyChecker
+}
+
+// newCheckResponsePolicyStep returns a new checkResponsePolicyStep after
+// validating its dependencies.
+func newCheckResponsePolicyStep(policyChecker definition.PolicyChecker) (definition.ResponseStep, error) {
+	if policyChecker == nil {
+		return nil, fmt.Errorf("invalid config: PolicyChecker plugin not configured")
+	}
+	rc, ok := policyChecker.(definition.ResponsePolicyChecker)
+	if !ok {
+		return nil, fmt.Errorf("invalid config: PolicyChecker plugin does not implement ResponsePolicyChecker")
+	}
+	return &checkResponsePolicyStep{checker: rc}, nil
+}
+
+// RunOnResponse checks rctx.Body, returning an invalidResponseErr when the
+// policy rejects it.
+func (s *checkResponsePolicyStep) RunOnResponse(ctx *model.StepContext, rctx *model.ResponseStepContext) error {
+	if rctx == nil || rctx.Replaced {
+		return nil
+	}
+	if err := s.checker.CheckResponsePolicy(ctx, rctx); err != nil {
+		return newInvalidResponseErr("policy check", err)
+	}
+	return nil
+}
+
+// transformResponseStep maps the upstream's synchronous response with the
+// PayloadTransformer plugin, when the plugin also implements ResponseStep. It
+// is the response-direction counterpart of transformPayload.
+type transformResponseStep struct {
+	transformer definition.ResponseStep
+}
+
+// newTransformResponseStep returns a new transformResponseStep after
+// validating its dependencies.
+func newTransformResponseStep(payloadTransformer definition.Step) (definition.ResponseStep, error) {
+	if payloadTransformer == nil {
+		return nil, fmt.Errorf("invalid config: PayloadTransformer plugin not configured")
+	}
+	rt, ok := payloadTransformer.(definition.ResponseStep)
+	if !ok {
+		return nil, fmt.Errorf("invalid config: PayloadTransformer plugin does not implement ResponseStep")
+	}
+	return &transformResponseStep{transformer: rt}, nil
+}
+
+// RunOnResponse maps rctx.Body. A mapped body no longer matches the
+// upstream's Signature or Content-Encoding, so both headers are dropped;
+// signAck, listed after this step, signs the mapped body.
+func (s *transformResponseStep) RunOnResponse(ctx *model.StepContext, rctx *model.ResponseStepContext) error {
+	if rctx == nil || rctx.Replaced {
+		return nil
+	}
+	body := rctx.Body
+	if err := s.transformer.RunOnResponse(ctx, rctx); err != nil {
+		return err
+	}
+	if !bytes.Equal(body, rctx.Body) && rctx.Header != nil {
+		rctx.Header.Del("Signature")
+		rctx.Header.Del("Content-Encoding")
+	}
+	return nil
+}
+
+// storeResponseStep records the synchronous response in the PayloadStore,
+// next to the request storePayload recorded. It is the response-direction
+// counterpart of storePayload.
+//
+// Listed after signAck it records the ACK as signed and sent. A replaced
+// response (the NACK for an invalid upstream response) is stored like any
+// other; store failures are logged and never fail the response.
+type storeResponseStep struct {
+	store definition.ResponsePayloadStore
+}
+
+// newStoreResponseStep returns a new storeResponseStep after validating its
+// dependencies.
+func newStoreResponseStep(payloadStore definition.PayloadStore) (definition.ResponseStep, error) {
+	if payloadStore == nil {
+		return nil, fmt.Errorf("invalid config: PayloadStore plugin not configured")
+	}
+	rs, ok := payloadStore.(definition.ResponsePayloadStore)
+	if !ok {
+		return nil, fmt.Errorf("invalid config: PayloadStore plugin does not implement ResponsePayloadStore")
+	}
+	return &storeResponseStep{store: rs}, nil
+}
+
+// RunOnResponse stores rctx. It no-ops on the publisher / no-route path
+// (rctx == nil), where there is no upstream response.
+func (s *storeResponseStep) RunOnResponse(ctx *model.StepContext, rctx *model.ResponseStepContext) error {
+	if rctx == nil {
+		return nil
+	}
+	if err := s.store.StoreResponse(ctx, rctx); err != nil {
+		log.Errorf(ctx, err, "storeResponse: failed to store response")
+	}
+	return nil
+}
@@ -7,6 +7,8 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -1077,3 +1079,384 @@ func TestInitSteps_ValidateAckSignAppendsToResponseSteps(t *testing.T) {
 		t.Errorf("expected 1 response step, got %d", len(h.responseSteps))
 	}
 }
+
+// ---------------------------------------------------------------------------
+// validateResponseSchema / checkResponsePolicy tests
+// ---------------------------------------------------------------------------
+
+// mockResponseValidator implements both SchemaValidator and
+// ResponseSchemaValidator.
+type mockResponseValidator struct {
+	err        error
+	gotAction  string
+	gotStatus  int
+	calledWith []byte
+}
+
+func (m *mockResponseValidator) Validate(_ context.Context, _ *url.URL, _ []byte) error { return nil }
+
+func (m *mockResponseValidator) ValidateResponse(_ context.Context, action string, statusCode int, payload []byte) error {
+	m.gotAction, m.gotStatus, m.calledWith = action, statusCode, payload
+	return m.err
+}
+
+// mockResponsePolicyChecker implements both PolicyChecker and
+// ResponsePolicyChecker.
+type mockResponsePolicyChecker struct {
+	err    error
+	called bool
+}
+
+func (m *mockResponsePolicyChecker) CheckPolicy(*model.StepContext) error { return nil }
+
+func (m *mockResponsePolicyChecker) CheckResponsePolicy(_ *model.StepContext, _ *model.ResponseStepContext) error {
+	m.called = true
+	return m.err
+}
+
+// requestOnlyValidator implements SchemaValidator but not ResponseSchemaValidator.
+type requestOnlyValidator struct{}
+
+func (requestOnlyValidator) Validate(context.Context, *url.URL, []byte) error { return nil }
+
+func TestValidateResponseSchemaStep(t *testing.T) {
+	sv := &mockResponseValidator{}
+	step, err := newValidateResponseSchemaStep(sv)
+	if err != nil {
+		t.Fatalf("newValidateResponseSchemaStep() unexpected error: %v", err)
+	}
+	ctx := makeStepCtx("2.0.0", "msg-001", "bpp.example.com", "")
+	ctx.Body = []byte(`{"context":{"action":"confirm"}}`)
+	rctx := makeResponseStepContext(http.StatusOK, `{"message":{"status":"ACK"}}`, "")
+
+	if err := step.RunOnResponse(ctx, rctx); err != nil {
+		t.Fatalf("RunOnResponse() unexpected error: %v", err)
+	}
+	if sv.gotAction != "confirm" || sv.gotStatus != http.StatusOK || string(sv.calledWith) != string(rctx.Body) {
+		t.Errorf("ValidateResponse called with action=%q status=%d body=%s", sv.gotAction, sv.gotStatus, sv.calledWith)
+	}
+
+	sv.err = model.NewBadReqErr("", errors.New("message.status is required"))
+	err = step.RunOnResponse(ctx, rctx)
+	var invalid *invalidResponseErr
+	if !errors.As(err, &invalid) {
+		t.Fatalf("expected invalidResponseErr, got %v", err)
+	}
+	var coded *model.CodedErr
+	if !errors.As(invalid.err, &coded) || coded.Code != "NET_UPSTREAM_INVALID_RESPONSE" || coded.HTTPStatus() != http.StatusBadGateway {
+		t.Errorf("violation = %v, want a 502 NET_UPSTREAM_INVALID_RESPONSE", invalid.err)
+	}
+
+	// A validator that cannot run says nothing about the response.
+	sv.err = errors.New("no OpenAPI spec loaded")
+	if err := step.RunOnResponse(ctx, rctx); err == nil || errors.As(err, &invalid) {
+		t.Errorf("expected a plain error for a validator failure, got %v", err)
+	}
+
+	sv.calledWith = nil
+	if err := step.RunOnResponse(ctx, nil); err != nil || sv.calledWith != nil {
+		t.Errorf("expected the publisher path to be skipped, err=%v", err)
+	}
+	rctx.Replaced = true
+	if err := step.RunOnResponse(ctx, rctx); err != nil || sv.calledWith != nil {
+		t.Errorf("expected a replaced response to be skipped, err=%v", err)
+	}
+}
+
+func TestCheckResponsePolicyStep(t *testing.T) {
+	pc := &mockResponsePolicyChecker{err: model.NewBadReqErr("POL_RESPONSE", errors.New("NACK without error"))}
+	step, err := newCheckResponsePolicyStep(pc)
+	if err != nil {
+		t.Fatalf("newCheckResponsePolicyStep() unexpected error: %v", err)
+	}
+	ctx := makeStepCtx("2.0.0", "msg-001", "bpp.example.com", "")
+	err = step.RunOnResponse(ctx, makeResponseStepContext(http.StatusBadRequest, `{"message":{"status":"NACK"}}`, ""))
+	var invalid *invalidResponseErr
+	if !errors.As(err, &invalid) || !pc.called {
+		t.Fatalf("expected invalidResponseErr from the policy checker, got %v", err)
+	}
+
+	pc.err = model.NewCodedErr(http.StatusServiceUnavailable, "NET_DOWNSTREAM_UNAVAILABLE", errors.New("registry unavailable"))
+	err = step.RunOnResponse(ctx, makeResponseStepContext(http.StatusOK, `{"message":{"status":"ACK"}}`, ""))
+	if err == nil || errors.As(err, &invalid) {
+		t.Errorf("expected a lookup failure to fail the call, not NACK the response, got %v", err)
+	}
+}
+
+func TestNewResponseValidationSteps_MissingPlugins(t *testing.T) {
+	if _, err := newValidateResponseSchemaStep(nil); err == nil {
+		t.Error("expected error for nil SchemaValidator")
+	}
+	if _, err := newValidateResponseSchemaStep(requestOnlyValidator{}); err == nil {
+		t.Error("expected error for a SchemaValidator without ValidateResponse")
+	}
+	if _, err := newCheckResponsePolicyStep(nil); err == nil {
+		t.Error("expected error for nil PolicyChecker")
+	}
+	if _, err := newCheckResponsePolicyStep(&contextAwarePolicyChecker{}); err == nil {
+		t.Error("expected error for a PolicyChecker without CheckResponsePolicy")
+	}
+}
+
+func TestInitSteps_ResponseValidationStepsOrder(t *testing.T) {
+	newHandler := func() *stdHandler {
+		return &stdHandler{
+			schemaValidator: &mockResponseValidator{},
+			policyChecker:   &mockResponsePolicyChecker{},
+			signer:          &mockSigner{},
+			km:              &mockKM{keyset: &model.Keyset{}},
+		}
+	}
+
+	h := newHandler()
+	cfg := &Config{Steps: []string{"validateResponseSchema", "checkResponsePolicy", "signAck"}}
+	if err := h.initSteps(context.Background(), noopPluginManager{}, cfg); err != nil {
+		t.Fatalf("initSteps() unexpected error: %v", err)
+	}
+	if len(h.steps) != 0 || len(h.responseSteps) != 3 {
+		t.Errorf("expected 0 inbound and 3 response steps, got %d and %d", len(h.steps), len(h.responseSteps))
+	}
+
+	h = newHandler()
+	cfg = &Config{Steps: []string{"signAck", "validateResponseSchema"}}
+	if err := h.initSteps(context.Background(), noopPluginManager{}, cfg); err == nil {
+		t.Error("expected error when validateResponseSchema is listed after signAck")
+	}
+
+	for _, step := range []string{"validateResponseSchema", "checkResponsePolicy"} {
+		h = newHandler()
+		cfg = &Config{Steps: []string{step}}
+		if err := h.initSteps(context.Background(), noopPluginManager{}, cfg); err == nil || !strings.Contains(err.Error(), "requires signAck") {
+			t.Errorf("%s without signAck: error = %v, want signAck required", step, err)
+		}
+	}
+}
+
+// TestProxy_InvalidResponseReplacedWithNack verifies that an upstream body
+// rejected by a response validation step reaches the caller as ONIX's NACK,
+// and that later response steps and the captured body see the NACK.
+func TestProxy_InvalidResponseReplacedWithNack(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Signature", testSigHeader)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message":{"status":"OK"}}`))
+	}))
+	defer upstream.Close()
+
+	upstreamURL, _ := url.Parse(upstream.URL)
+	stepCtx := makeStepCtx("2.0.0", "msg-001", "bpp.example.com", "")
+	stepCtx.Route = &model.Route{TargetType: "url", URL: upstreamURL}
+	stepCtx.Body = []byte(`{"context":{"action":"confirm"}}`)
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`))
+	rr := httptest.NewRecorder()
+
+	schemaErr := &model.SchemaValidationErr{Errors: []model.Error{{Code: "SCH_INVALID_ENUM", Message: "value is not one of the allowed values"}}}
+	schemaStep, _ := newValidateResponseSchemaStep(&mockResponseValidator{err: schemaErr})
+	policy := &mockResponsePolicyChecker{}
+	policyStep, _ := newCheckResponsePolicyStep(policy)
+	var seen *model.ResponseStepContext
+	recorder := responseStepFunc(func(_ *model.StepContext, rctx *model.ResponseStepContext) error {
+		seen = rctx
+		return nil
+	})
+
+	var responseBody []byte
+	proxy(stepCtx, req, rr, http.DefaultClient, []definition.ResponseStep{schemaStep, policyStep, recorder}, &responseBody)
+
+	if rr.Code != http.StatusBadGateway {
+		t.Errorf("status = %d, want %d", rr.Code, http.StatusBadGateway)
+	}
+	if rr.Header().Get("Signature") != "" {
+		t.Error("expected the upstream Signature header to be dropped with its body")
+	}
+	var resp model.Response
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("response is not JSON: %v: %s", err, rr.Body.String())
+	}
+	if resp.Message.Status != model.StatusNACK || resp.Message.Error == nil || resp.Message.Error.Code != "NET_UPSTREAM_INVALID_RESPONSE" ||
+		!strings.Contains(resp.Message.Error.Message, "value is not one of the allowed values") {
+		t.Errorf("unexpected NACK: %s", rr.Body.String())
+	}
+	if policy.called {
+		t.Error("expected checkResponsePolicy to skip the replaced response")
+	}
+	if seen == nil || !seen.Replaced || !bytes.Equal(seen.Body, rr.Body.Bytes()) {
+		t.Error("expected later response steps to see the NACK")
+	}
+	if !bytes.Equal(responseBody, rr.Body.Bytes()) {
+		t.Errorf("responseBody = %q, want the NACK written to the caller", responseBody)
+	}
+}
+
+// responseStepFunc adapts a function to definition.ResponseStep.
+type responseStepFunc func(*model.StepContext, *model.ResponseStepContext) error
+
+func (f responseStepFunc) RunOnResponse(ctx *model.StepContext, rctx *model.ResponseStepContext) error {
+	return f(ctx, rctx)
+}
+
+// ---------------------------------------------------------------------------
+// transformResponse tests
+// ---------------------------------------------------------------------------
+
+// mockResponseTransformer implements both Step and ResponseStep, rewriting
+// the response body to body.
+type mockResponseTransformer struct {
+	body string
+}
+
+func (m *mockResponseTransformer) Run(*model.StepContext) error { return nil }
+
+func (m *mockResponseTransformer) RunOnResponse(_ *model.StepContext, rctx *model.ResponseStepContext) error {
+	rctx.Body = []byte(m.body)
+	return nil
+}
+
+func TestNewTransformResponseStep_MissingPlugin(t *testing.T) {
+	if _, err := newTransformResponseStep(nil); err == nil {
+		t.Error("expected error for nil PayloadTransformer")
+	}
+	if _, err := newTransformResponseStep(stubStep{}); err == nil {
+		t.Error("expected error for a PayloadTransformer without RunOnResponse")
+	}
+}
+
+func TestInitSteps_TransformResponseOrder(t *testing.T) {
+	newHandler := func() *stdHandler {
+		return &stdHandler{
+			payloadTransformer: &mockResponseTransformer{},
+			signer:             &mockSigner{},
+			km:                 &mockKM{keyset: &model.Keyset{}},
+		}
+	}
+
+	h := newHandler()
+	cfg := &Config{Steps: []string{"transformPayload", "transformResponse", "signAck"}}
+	if err := h.initSteps(context.Background(), noopPluginManager{}, cfg); err != nil {
+		t.Fatalf("initSteps() unexpected error: %v", err)
+	}
+	if len(h.steps) != 1 || len(h.responseSteps) != 2 {
+		t.Errorf("expected 1 inbound and 2 response steps, got %d and %d", len(h.steps), len(h.responseSteps))
+	}
+
+	h = newHandler()
+	cfg = &Config{Steps: []string{"signAck", "transformResponse"}}
+	if err := h.initSteps(context.Background(), noopPluginManager{}, cfg); err == nil {
+		t.Error("expected error when transformResponse is listed after signAck")
+	}
+}
+
+// TestProxy_TransformedResponseWrittenBack verifies that a response body
+// rewritten by transformResponse reaches the caller with a matching
+// Content-Length and without the upstream's Signature.
+func TestProxy_TransformedResponseWrittenBack(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Signature", testSigHeader)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message":{"status":"ACK"}}`))
+	}))
+	defer upstream.Close()
+
+	upstreamURL, _ := url.Parse(upstream.URL)
+	stepCtx := makeStepCtx("2.0.0", "msg-001", "bpp.example.com", "")
+	stepCtx.Route = &model.Route{TargetType: "url", URL: upstreamURL}
+	stepCtx.Body = []byte(`{"context":{"action":"confirm"}}`)
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`))
+	rr := httptest.NewRecorder()
+
+	mapped := `{"status":"accepted","orderRef":"o-1"}`
+	transformStep, err := newTransformResponseStep(&mockResponseTransformer{body: mapped})
+	if err != nil {
+		t.Fatalf("newTransformResponseStep() unexpected error: %v", err)
+	}
+
+	var responseBody []byte
+	proxy(stepCtx, req, rr, http.DefaultClient, []definition.ResponseStep{transformStep}, &responseBody)
+
+	if rr.Body.String() != mapped {
+		t.Errorf("body = %s, want %s", rr.Body.String(), mapped)
+	}
+	if got := rr.Header().Get("Content-Length"); got != strconv.Itoa(len(mapped)) {
+		t.Errorf("Content-Length = %q, want %d", got, len(mapped))
+	}
+	if rr.Header().Get("Signature") != "" {
+		t.Error("expected the upstream Signature header to be dropped with its body")
+	}
+	if string(responseBody) != mapped {
+		t.Errorf("responseBody = %q, want the mapped body", responseBody)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// storeResponse tests
+// ---------------------------------------------------------------------------
+
+// stubResponsePayloadStore records the responses passed to StoreResponse.
+type stubResponsePayloadStore struct {
+	stubPayloadStore
+	responses []*model.ResponseStepContext
+	err       error
+}
+
+func (s *stubResponsePayloadStore) StoreResponse(_ *model.StepContext, rctx *model.ResponseStepContext) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.responses = append(s.responses, rctx)
+	return nil
+}
+
+func TestNewStoreResponseStep_MissingPlugin(t *testing.T) {
+	if _, err := newStoreResponseStep(nil); err == nil {
+		t.Error("expected error for nil PayloadStore")
+	}
+	if _, err := newStoreResponseStep(&stubPayloadStore{}); err == nil {
+		t.Error("expected error for a PayloadStore without StoreResponse")
+	}
+}
+
+func TestStoreResponseStep(t *testing.T) {
+	ps := &stubResponsePayloadStore{}
+	step, err := newStoreResponseStep(ps)
+	if err != nil {
+		t.Fatalf("newStoreResponseStep() error = %v", err)
+	}
+	ctx := &model.StepContext{Context: context.Background()}
+
+	if err := step.RunOnResponse(ctx, nil); err != nil || len(ps.responses) != 0 {
+		t.Errorf("nil rctx: err = %v, stored %d, want a no-op", err, len(ps.responses))
+	}
+
+	nack := &model.ResponseStepContext{StatusCode: http.StatusBadRequest, Body: []byte(`{}`), Replaced: true}
+	if err := step.RunOnResponse(ctx, nack); err != nil {
+		t.Fatalf("RunOnResponse() error = %v", err)
+	}
+	if len(ps.responses) != 1 || ps.responses[0] != nack {
+		t.Errorf("expected the replaced response to be stored, got %v", ps.responses)
+	}
+
+	ps.err = errors.New("database down")
+	if err := step.RunOnResponse(ctx, nack); err != nil {
+		t.Errorf("a store failure should not fail the response, got %v", err)
+	}
+}
+
+func TestInitSteps_StoreResponse(t *testing.T) {
+	h := &stdHandler{
+		payloadStore: &stubResponsePayloadStore{},
+		signer:       &mockSigner{},
+		km:           &mockKM{keyset: &model.Keyset{}},
+	}
+	cfg := &Config{Steps: []string{"storePayload", "signAck", "storeResponse"}}
+	if err := h.initSteps(context.Background(), noopPluginManager{}, cfg); err != nil {
+		t.Fatalf("initSteps() unexpected error: %v", err)
+	}
+	if len(h.steps) != 1 || len(h.responseSteps) != 2 {
+		t.Errorf("expected 1 inbound and 2 response steps, got %d and %d", len(h.steps), len(h.responseSteps))
+	}
+
+	h = &stdHandler{}
+	if err := h.initSteps(context.Background(), noopPluginManager{}, &Config{Steps: []string{"storeResponse"}}); err == nil {
+		t.Error("expected error when no PayloadStore is configured")
+	}
+}
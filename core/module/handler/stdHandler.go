@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -55,6 +56,8 @@ type stdHandler struct {
 	transportWrapper   definition.TransportWrapper
 	payloadTransformer definition.Step
 	payloadStore       definition.PayloadStore
+	encrypter          definition.Encrypter
+	decrypter          definition.Decrypter
 	// ackSigner is non-nil only when the "signAck" step is configured (Receiver
 	// modules). It is also used to sign pipeline-NACK responses so that ALL
 	// synchronous responses carry a Signature header per NFH-007 CON-004-02.
@@ -392,7 +395,10 @@ func proxy(ctx *model.StepContext, r *http.Request, w http.ResponseWriter, httpC
 	// modifyResponse pre-reads the upstream response body once, constructs a
 	// ResponseStepContext, then runs all response steps. Body restoration for
 	// ReverseProxy happens here — individual steps read from rctx.Body and do
-	// not need to touch resp.Body directly.
+	// not need to touch resp.Body directly. A response validation step that
+	// rejects the upstream body gets it replaced with ONIX's NACK, and the
+	// remaining steps see the NACK. A body a step rewrote (transformResponse)
+	// is written back once all steps have run.
 	modifyResponse := func(resp *http.Response) error {
 		body, err := io.ReadAll(resp.Body)
 		if err != nil {
@@ -406,13 +412,23 @@ func proxy(ctx *model.StepContext, r *http.Request, w http.ResponseWriter, httpC
 		}
 		for _, step := range responseSteps {
 			if err := step.RunOnResponse(ctx, rctx); err != nil {
-				return err
+				var invalid *invalidResponseErr
+				if !errors.As(err, &invalid) {
+					return err
+				}
+				replaceWithNack(ctx, resp, rctx, invalid.err)
+				body = rctx.Body
 			}
 		}
+		if !bytes.Equal(body, rctx.Body) {
+			resp.Header.Set("Content-Length", strconv.Itoa(len(rctx.Body)))
+			resp.ContentLength = int64(len(rctx.Body))
+			resp.Body = io.NopCloser(bytes.NewReader(rctx.Body))
+		}
 		// Capture only after all response steps succeed — if a step fails the
 		// ReverseProxy error handler writes a 502, so the upstream body is not
 		// what the caller received.
-		*responseBody = body
+		*responseBody = rctx.Body
 		return nil
 	}
 
@@ -489,9 +505,8 @@ func loadPayloadStore(ctx context.Context, mgr PluginManager, cache definition.C
 		log.Debug(ctx, "Skipping PayloadStore plugin: not configured")
 		return nil, nil
 	}
-	if cache == nil {
-		return nil, fmt.Errorf("failed to load PayloadStore plugin (%s): Cache plugin not configured", cfg.ID)
-	}
+	// cache may be nil: stores with their own backend (sqlpayloadstore) do
+	// not need it, and cache-backed stores reject a nil cache themselves.
 	if role == model.RoleBAP && cfg.Config["storeSignature"] == "false" {
 		log.Warnf(ctx, "PayloadStore plugin (%s): storeSignature is disabled for a BAP handler — Authorization header will not be stored", cfg.ID)
 	}
@@ -503,7 +518,9 @@ func loadPayloadStore(ctx context.Context, mgr PluginManager, cache definition.C
 	return ps, nil
 }
 
-func loadPolicyChecker(ctx context.Context, mgr PluginManager, manifestLoader definition.ManifestLoader, cfg *plugin.Config) (definition.PolicyChecker, error) {
+// loadPolicyChecker loads the PolicyChecker plugin and hands it the registry
+// and payload store when it can use them to enrich the policy input.
+func loadPolicyChecker(ctx context.Context, mgr PluginManager, manifestLoader definition.ManifestLoader, registry definition.RegistryLookup, payloadStore definition.PayloadStore, cfg *plugin.Config) (definition.PolicyChecker, error) {
 	if cfg == nil {
 		log.Debug(ctx, "Skipping PolicyChecker plugin: not configured")
 		return nil, nil
@@ -513,6 +530,16 @@ func loadPolicyChecker(ctx context.Context, mgr PluginManager, manifestLoader de
 	if err != nil {
 		return nil, fmt.Errorf("failed to load PolicyChecker plugin (%s): %w", cfg.ID, err)
 	}
+	if ra, ok := checker.(definition.RegistryAwarePolicyChecker); ok {
+		if err := ra.SetRegistryLookup(registry); err != nil {
+			return nil, fmt.Errorf("failed to initialize PolicyChecker plugin (%s): %w", cfg.ID, err)
+		}
+	}
+	if pa, ok := checker.(definition.PayloadStoreAwarePolicyChecker); ok {
+		if err := pa.SetPayloadStore(payloadStore); err != nil {
+			return nil, fmt.Errorf("failed to initialize PolicyChecker plugin (%s): %w", cfg.ID, err)
+		}
+	}
 
 	log.Debugf(ctx, "Loaded PolicyChecker plugin: %s", cfg.ID)
 	return checker, nil
@@ -534,6 +561,19 @@ func loadSchemaVersionMediator(ctx context.Context, mgr PluginManager, manifestL
 	return mediator, nil
 }
 
+// setSchemaValidatorManifestLoader passes loader (nil when none is
+// configured) to a schema validator that reads node manifests.
+func setSchemaValidatorManifestLoader(validator definition.SchemaValidator, loader definition.ManifestLoader, cfg *plugin.Config) error {
+	ma, ok := validator.(definition.ManifestAwareSchemaValidator)
+	if !ok {
+		return nil
+	}
+	if err := ma.SetManifestLoader(loader); err != nil {
+		return fmt.Errorf("failed to load SchemaValidator plugin (%s): %w", cfg.ID, err)
+	}
+	return nil
+}
+
 func loadPayloadTransformerStep(ctx context.Context, mgr PluginManager, cfg *plugin.Config) (definition.Step, error) {
 	if cfg == nil {
 		log.Debug(ctx, "Skipping PayloadTransformer plugin: not configured")
@@ -575,6 +615,9 @@ func (h *stdHandler) initPlugins(ctx context.Context, mgr PluginManager, cfg *Pl
 	if h.schemaValidator, err = loadPlugin(ctx, "SchemaValidator", cfg.SchemaValidator, mgr.SchemaValidator); err != nil {
 		return err
 	}
+	if err := setSchemaValidatorManifestLoader(h.schemaValidator, h.manifestLoader, cfg.SchemaValidator); err != nil {
+		return err
+	}
 	if h.router, err = loadPlugin(ctx, "Router", cfg.Router, mgr.Router); err != nil {
 		return err
 	}
@@ -587,7 +630,7 @@ func (h *stdHandler) initPlugins(ctx context.Context, mgr PluginManager, cfg *Pl
 	if h.transportWrapper, err = loadPlugin(ctx, "TransportWrapper", cfg.TransportWrapper, mgr.TransportWrapper); err != nil {
 		return err
 	}
-	if h.policyChecker, err = loadPolicyChecker(ctx, mgr, h.manifestLoader, cfg.PolicyChecker); err != nil {
+	if h.policyChecker, err = loadPolicyChecker(ctx, mgr, h.manifestLoader, h.registry, h.payloadStore, cfg.PolicyChecker); err != nil {
 		return err
 	}
 	if h.schemaVersionMediator, err = loadSchemaVersionMediator(ctx, mgr, h.manifestLoader, cfg.SchemaVersionMediator); err != nil {
@@ -596,6 +639,12 @@ func (h *stdHandler) initPlugins(ctx context.Context, mgr PluginManager, cfg *Pl
 	if h.payloadTransformer, err = loadPayloadTransformerStep(ctx, mgr, cfg.PayloadTransformer); err != nil {
 		return err
 	}
+	if h.encrypter, err = loadPlugin(ctx, "Encrypter", cfg.Encrypter, mgr.Encryptor); err != nil {
+		return err
+	}
+	if h.decrypter, err = loadPlugin(ctx, "Decrypter", cfg.Decrypter, mgr.Decryptor); err != nil {
+		return err
+	}
 
 	log.Debugf(ctx, "All required plugins successfully loaded for stdHandler")
 	return nil
@@ -611,10 +660,21 @@ func (h *stdHandler) initSteps(ctx context.Context, mgr PluginManager, cfg *Conf
 		if err != nil {
 			return fmt.Errorf("failed to initialize plugin step %s: %w", c.ID, err)
 		}
+		if ma, ok := step.(definition.ManifestAwareStep); ok {
+			if err := ma.SetManifestLoader(h.manifestLoader); err != nil {
+				return fmt.Errorf("failed to initialize plugin step %s: %w", c.ID, err)
+			}
+		}
+		if ca, ok := step.(definition.CacheAwareStep); ok {
+			if err := ca.SetCache(h.cache); err != nil {
+				return fmt.Errorf("failed to initialize plugin step %s: %w", c.ID, err)
+			}
+		}
 		steps[c.ID] = step
 	}
 
 	// Register processing steps
+	var responseValidation string // a response validation step, if configured
 	for _, step := range cfg.Steps {
 		var s definition.Step
 		var err error
@@ -655,10 +715,54 @@ func (h *stdHandler) initSteps(ctx context.Context, mgr PluginManager, cfg *Conf
 			}
 			h.responseSteps = append(h.responseSteps, instrumentedRS)
 			continue
+		case "storeResponse":
+			// storeResponse is a ResponseStep — records the response in the
+			// PayloadStore. Listed after signAck, it stores the signed ACK.
+			rs, rsErr := newStoreResponseStep(h.payloadStore)
+			if rsErr != nil {
+				return rsErr
+			}
+			instrumentedRS, wrapErr := NewInstrumentedResponseStep(rs, step, h.moduleName)
+			if wrapErr != nil {
+				log.Warnf(ctx, "Failed to instrument response step %s: %v", step, wrapErr)
+				h.responseSteps = append(h.responseSteps, rs)
+				continue
+			}
+			h.responseSteps = append(h.responseSteps, instrumentedRS)
+			continue
+		case "validateResponseSchema", "checkResponsePolicy", "transformResponse":
+			// These response steps must run before signAck so the NACK replacing
+			// an invalid response, or the mapped response, gets signed.
+			if h.ackSigner != nil {
+				return fmt.Errorf("invalid config: %s must be listed before signAck", step)
+			}
+			var rs definition.ResponseStep
+			var rsErr error
+			switch step {
+			case "validateResponseSchema":
+				rs, rsErr = newValidateResponseSchemaStep(h.schemaValidator)
+				responseValidation = step
+			case "checkResponsePolicy":
+				rs, rsErr = newCheckResponsePolicyStep(h.policyChecker)
+				responseValidation = step
+			default:
+				rs, rsErr = newTransformResponseStep(h.payloadTransformer)
+			}
+			if rsErr != nil {
+				return rsErr
+			}
+			instrumentedRS, wrapErr := NewInstrumentedResponseStep(rs, step, h.moduleName)
+			if wrapErr != nil {
+				log.Warnf(ctx, "Failed to instrument response step %s: %v", step, wrapErr)
+				h.responseSteps = append(h.responseSteps, rs)
+				continue
+			}
+			h.responseSteps = append(h.responseSteps, instrumentedRS)
+			continue
 		case "sign":
 			s, err = newSignStep(h.signer, h.km, h.payloadStore)
 		case "validateSign":
-			s, err = newValidateSignStep(h.signValidator, h.km, h.payloadStore)
+			s, err = newValidateSignStep(h.signValidator, h.km, h.payloadStore, newGatewayAuth(h.registry, cfg.GatewayAuth))
 		case "validateSchema":
 			s, err = newValidateSchemaStep(h.schemaValidator, h.basePath)
 		case "addRoute":
@@ -674,6 +778,14 @@ func (h *stdHandler) initSteps(ctx context.Context, mgr PluginManager, cfg *Conf
 			s = h.payloadTransformer
 		case "storePayload":
 			s, err = newStorePayloadStep(h.payloadStore)
+		case "encryptFields":
+			s, err = newEncryptFieldsStep(h.encrypter, h.km, cfg.FieldEncryption)
+		case "decryptFields":
+			s, err = newDecryptFieldsStep(h.decrypter, h.km, cfg.FieldEncryption)
+		case "authorizeSubscriber":
+			s, err = newAuthorizeSubscriberStep(h.registry, h.cache, cfg.SubscriberAuth)
+		case "checkTransactionFlow":
+			s, err = newCheckTransactionFlowStep(h.cache, h.payloadStore, cfg.TransactionFlow)
 		default:
 			if customStep, exists := steps[step]; exists {
 				s = customStep
@@ -693,6 +805,11 @@ func (h *stdHandler) initSteps(ctx context.Context, mgr PluginManager, cfg *Conf
 		}
 		h.steps = append(h.steps, instrumentedStep)
 	}
+	// The NACK that replaces an invalid upstream response has no signature
+	// of its own, so a module that validates responses must sign it.
+	if responseValidation != "" && h.ackSigner == nil {
+		return fmt.Errorf("invalid config: %s requires signAck, listed after it, to sign the NACK replacing an invalid response", responseValidation)
+	}
 	log.Infof(ctx, "Processor steps initialized: %v", cfg.Steps)
 	return nil
 }
@@ -168,6 +168,12 @@ func (noopPluginManager) PayloadStore(_ context.Context, _ definition.Cache, _ s
 func (noopPluginManager) CatalogPublisher(_ context.Context, _ definition.KeyManager, _ *plugin.Config) (definition.CatalogPublisher, error) {
 	return nil, nil
 }
+func (noopPluginManager) Encryptor(context.Context, *plugin.Config) (definition.Encrypter, error) {
+	return nil, nil
+}
+func (noopPluginManager) Decryptor(context.Context, *plugin.Config) (definition.Decrypter, error) {
+	return nil, nil
+}
 
 type registryWithoutMetadata struct{}
 
@@ -1082,3 +1088,138 @@ func TestProxy_QueryParamsForwardedToUpstream(t *testing.T) {
 		t.Errorf("upstream received RawQuery = %q, want %q", capturedRawQuery, "subscriptionId=test123&page=2")
 	}
 }
+
+// manifestAwareStep records the ManifestLoader initSteps hands it.
+type manifestAwareStep struct {
+	loader definition.ManifestLoader
+	called bool
+	err    error
+}
+
+func (s *manifestAwareStep) Run(*model.StepContext) error { return nil }
+
+func (s *manifestAwareStep) SetManifestLoader(loader definition.ManifestLoader) error {
+	s.loader, s.called = loader, true
+	return s.err
+}
+
+// stepMgr is a PluginManager stub whose Step returns a fixed step.
+type stepMgr struct {
+	noopPluginManager
+	step definition.Step
+}
+
+func (m *stepMgr) Step(context.Context, *plugin.Config) (definition.Step, error) {
+	return m.step, nil
+}
+
+func TestInitSteps_ManifestAwareStepReceivesLoader(t *testing.T) {
+	loader := &stubManifestLoader{}
+	cfg := &Config{
+		Plugins: PluginCfg{Steps: []plugin.Config{{ID: "validateVC"}}},
+		Steps:   []string{"validateVC"},
+	}
+
+	step := &manifestAwareStep{}
+	h := &stdHandler{manifestLoader: loader}
+	if err := h.initSteps(context.Background(), &stepMgr{step: step}, cfg); err != nil {
+		t.Fatalf("initSteps() unexpected error: %v", err)
+	}
+	if !step.called || step.loader != loader {
+		t.Fatalf("expected the handler's ManifestLoader to be passed to the step")
+	}
+	if len(h.steps) != 1 {
+		t.Fatalf("expected 1 step, got %d", len(h.steps))
+	}
+
+	failing := &manifestAwareStep{err: errors.New("needs a ManifestLoader")}
+	h = &stdHandler{}
+	err := h.initSteps(context.Background(), &stepMgr{step: failing}, cfg)
+	if err == nil || !strings.Contains(err.Error(), "failed to initialize plugin step validateVC") {
+		t.Fatalf("expected step initialisation error, got: %v", err)
+	}
+	if !failing.called || failing.loader != nil {
+		t.Fatalf("expected a nil ManifestLoader to be passed when none is configured")
+	}
+}
+
+// cacheAwareStep records the Cache initSteps hands it.
+type cacheAwareStep struct {
+	cache  definition.Cache
+	called bool
+}
+
+func (s *cacheAwareStep) Run(*model.StepContext) error { return nil }
+
+func (s *cacheAwareStep) SetCache(cache definition.Cache) error {
+	s.cache, s.called = cache, true
+	return nil
+}
+
+func TestInitSteps_CacheAwareStepReceivesCache(t *testing.T) {
+	cache := &stubCache{}
+	cfg := &Config{
+		Plugins: PluginCfg{Steps: []plugin.Config{{ID: "validateVC"}}},
+		Steps:   []string{"validateVC"},
+	}
+
+	step := &cacheAwareStep{}
+	h := &stdHandler{cache: cache}
+	if err := h.initSteps(context.Background(), &stepMgr{step: step}, cfg); err != nil {
+		t.Fatalf("initSteps() unexpected error: %v", err)
+	}
+	if !step.called || step.cache != cache {
+		t.Fatalf("expected the handler's Cache to be passed to the step")
+	}
+}
+
+// contextAwarePolicyChecker records the registry and payload store
+// loadPolicyChecker hands it.
+type contextAwarePolicyChecker struct {
+	registry     definition.RegistryLookup
+	payloadStore definition.PayloadStore
+	calls        int
+	err          error
+}
+
+func (c *contextAwarePolicyChecker) CheckPolicy(*model.StepContext) error { return nil }
+
+func (c *contextAwarePolicyChecker) SetRegistryLookup(r definition.RegistryLookup) error {
+	c.registry = r
+	c.calls++
+	return c.err
+}
+
+func (c *contextAwarePolicyChecker) SetPayloadStore(ps definition.PayloadStore) error {
+	c.payloadStore = ps
+	c.calls++
+	return c.err
+}
+
+type policyCheckerMgr struct {
+	noopPluginManager
+	checker definition.PolicyChecker
+}
+
+func (m *policyCheckerMgr) PolicyChecker(context.Context, definition.ManifestLoader, *plugin.Config) (definition.PolicyChecker, error) {
+	return m.checker, nil
+}
+
+func TestLoadPolicyChecker_InjectsRegistryAndPayloadStore(t *testing.T) {
+	registry := &fakeRegistry{}
+	store := &stubPayloadStore{}
+	checker := &contextAwarePolicyChecker{}
+	got, err := loadPolicyChecker(context.Background(), &policyCheckerMgr{checker: checker}, nil, registry, store, &plugin.Config{ID: "opapolicychecker"})
+	if err != nil {
+		t.Fatalf("loadPolicyChecker() unexpected error: %v", err)
+	}
+	if got != checker || checker.calls != 2 || checker.registry != registry || checker.payloadStore != store {
+		t.Fatalf("expected the handler's registry and payload store to be passed to the checker")
+	}
+
+	failing := &contextAwarePolicyChecker{err: errors.New("includeRegistryRecord requires a Registry plugin")}
+	_, err = loadPolicyChecker(context.Background(), &policyCheckerMgr{checker: failing}, nil, nil, nil, &plugin.Config{ID: "opapolicychecker"})
+	if err == nil || !strings.Contains(err.Error(), "failed to initialize PolicyChecker plugin (opapolicychecker)") {
+		t.Fatalf("expected checker initialisation error, got: %v", err)
+	}
+}
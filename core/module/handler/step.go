@@ -3,6 +3,7 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -65,11 +66,26 @@ func (s *signStep) Run(ctx *model.StepContext) error {
 		// Look up the CN's original signature before signing so we can choose
 		// Sign (3-line) vs SignAck (4-line) based on whether this is a solicited
 		// callback (NFH-004 §3.3).
-		requestSig := s.lookupRequestSignature(ctx)
+		// A BG relays messages it did not solicit, so its proxy signature
+		// always uses the 3-line signing string.
+		var requestSig string
+		if ctx.Role != model.RoleGateway {
+			requestSig = s.lookupRequestSignature(ctx)
+		}
 
 		signerCtx, signerSpan := tracer.Start(ctx.Context, "sign")
 		createdAt := time.Now().Unix()
 		validTill := time.Now().Add(5 * time.Minute).Unix()
+
+		// RFC 9421 has no counterpart to the NFH-004 request-signature
+		// binding, so solicited callbacks and gateway re-signing keep the
+		// Beckn header format.
+		if hs, ok := s.signer.(definition.HTTPMessageSigner); ok && requestSig == "" && ctx.Role != model.RoleGateway && hs.UseHTTPMessageSignatures(ctx.ProtocolVersion) {
+			err := s.signHTTPMessage(signerCtx, ctx, hs, keySet, createdAt, validTill)
+			signerSpan.End()
+			return err
+		}
+
 		var sign string
 		var err error
 		if requestSig != "" {
@@ -94,6 +110,22 @@ func (s *signStep) Run(ctx *model.StepContext) error {
 	return nil
 }
 
+// signHTTPMessage signs ctx.Request with RFC 9421 HTTP Message Signatures.
+// Derived components are computed against the routed URL, since that is the
+// request the counterparty receives.
+func (s *signStep) signHTTPMessage(signerCtx context.Context, ctx *model.StepContext, hs definition.HTTPMessageSigner, keySet *model.Keyset, createdAt, validTill int64) error {
+	target := ctx.Request.URL
+	if ctx.Route != nil && ctx.Route.URL != nil {
+		target = ctx.Route.URL
+	}
+	keyID := fmt.Sprintf("%s|%s|ed25519", ctx.SubID, keySet.UniqueKeyID)
+	if err := hs.SignHTTPMessage(signerCtx, ctx.Request.Method, target, ctx.Request.Header, ctx.Body, keyID, keySet.SigningPrivate, createdAt, validTill); err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+	log.Debugf(ctx, "RFC 9421 signature generated: %v", ctx.Request.Header.Get("Signature-Input"))
+	return nil
+}
+
 // lookupRequestSignature returns the stored outbound signature for solicited
 // callbacks. For a callback action like "on_search" it strips the "on_" prefix
 // and looks up the PayloadStore by (messageID, "search").
@@ -144,25 +176,49 @@ type validateSignStep struct {
 	km           definition.KeyManager
 	metrics      *HandlerMetrics
 	payloadStore definition.PayloadStore
+	gateway      *gatewayAuth
+}
+
+// gatewayAuth is the X-Gateway-Authorization policy of a validateSign step.
+type gatewayAuth struct {
+	// registry confirms that the proxy signer is registered as a BG. It is
+	// required; newValidateSignStep refuses a gatewayAuth without one.
+	registry        definition.RegistryLookup
+	requiredActions map[string]bool
+}
+
+// newGatewayAuth builds the gateway policy from the handler configuration.
+func newGatewayAuth(registry definition.RegistryLookup, cfg GatewayAuthConfig) *gatewayAuth {
+	g := &gatewayAuth{registry: registry, requiredActions: make(map[string]bool, len(cfg.RequiredActions))}
+	for _, a := range cfg.RequiredActions {
+		g.requiredActions[a] = true
+	}
+	return g
 }
 
 // newValidateSignStep initializes and returns a new validate sign step.
 // payloadStore may be nil; when non-nil and the incoming request is a solicited
 // callback (v2, headers declares "request-signature"), ValidateAck is used with
 // the stored outbound signature to verify the 4-line signing string (NFH-004 §3.3).
-func newValidateSignStep(signValidator definition.SignValidator, km definition.KeyManager, payloadStore definition.PayloadStore) (definition.Step, error) {
+// gateway may be nil, in which case a gateway signature is verified when
+// present but never required and its signer's role is not checked.
+func newValidateSignStep(signValidator definition.SignValidator, km definition.KeyManager, payloadStore definition.PayloadStore, gateway *gatewayAuth) (definition.Step, error) {
 	if signValidator == nil {
 		return nil, fmt.Errorf("invalid config: SignValidator plugin not configured")
 	}
 	if km == nil {
 		return nil, fmt.Errorf("invalid config: KeyManager plugin not configured")
 	}
+	if gateway != nil && gateway.registry == nil {
+		return nil, fmt.Errorf("invalid config: Registry plugin not configured; required by validateSign to check that a gateway signer is registered as a BG")
+	}
 	metrics, _ := GetHandlerMetrics(context.Background())
 	return &validateSignStep{
 		validator:    signValidator,
 		km:           km,
 		metrics:      metrics,
 		payloadStore: payloadStore,
+		gateway:      gateway,
 	}, nil
 }
 
@@ -184,6 +240,9 @@ func (s *validateSignStep) Run(ctx *model.StepContext) error {
 	}
 	err := s.validateHeaders(stepCtx)
 	s.recordMetrics(stepCtx, err)
+	if err == nil {
+		ctx.SignerID, ctx.SignerKeyID = stepCtx.SignerID, stepCtx.SignerKeyID
+	}
 	return err
 }
 
@@ -192,17 +251,32 @@ func (s *validateSignStep) validateHeaders(ctx *model.StepContext) error {
 	headerValue := ctx.Request.Header.Get(model.AuthHeaderGateway)
 	if len(headerValue) != 0 {
 		log.Debugf(ctx, "Validating %v Header", model.AuthHeaderGateway)
-		if err := s.validate(ctx, headerValue, "", false); err != nil {
+		if err := s.validateGateway(ctx, headerValue); err != nil {
 			ctx.RespHeader.Set(model.UnaAuthorizedHeaderGateway, unauthHeader)
 			// s.validate returns an already-classified 401 *model.CodedErr for
 			// most failure paths (keymanager lookup, signvalidator crypto/timestamp
 			// checks) — wrap with plain fmt.Errorf (not model.NewSignValidationErr)
 			// so errors.As still finds that inner classification instead of
-			// shadowing it with a new, default-coded wrapper. Its own header-parse
-			// and algorithm-mismatch checks still return unclassified errors that
-			// fall through to a generic 500 either way; see #864 follow-up.
+			// shadowing it with a new, default-coded wrapper.
 			return fmt.Errorf("failed to validate %s: %w", model.AuthHeaderGateway, err)
 		}
+	} else if s.gateway != nil && s.gateway.requiredActions[extractBecknAction(ctx.Body)] {
+		ctx.RespHeader.Set(model.UnaAuthorizedHeaderGateway, unauthHeader)
+		return model.NewSignValidationErr("AUT_SIGNATURE_MISSING", fmt.Errorf("%s missing", model.AuthHeaderGateway))
+	}
+
+	if hv, ok := s.validator.(definition.HTTPMessageSignValidator); ok {
+		keyID, use, err := hv.HTTPMessageKeyID(ctx)
+		if err == nil && use {
+			err = s.validateHTTPMessage(ctx, hv, keyID)
+		}
+		if err != nil {
+			ctx.RespHeader.Set(model.UnaAuthorizedHeaderSubscriber, unauthHeader)
+			return fmt.Errorf("failed to validate HTTP message signature: %w", err)
+		}
+		if use {
+			return nil
+		}
 	}
 
 	log.Debugf(ctx, "Validating %v Header", model.AuthHeaderSubscriber)
@@ -225,6 +299,39 @@ func (s *validateSignStep) validateHeaders(ctx *model.StepContext) error {
 	return nil
 }
 
+// validateGateway verifies the BG's proxy signature and that the signing key
+// belongs to a subscriber registered as a BG.
+// The gateway does not originate the message, so the signer is not matched
+// against the context's bap_id/bpp_id.
+func (s *validateSignStep) validateGateway(ctx *model.StepContext, value string) error {
+	headerVals, err := parseHeader(value)
+	if err != nil {
+		return model.NewSignValidationErr("AUT_SIGNATURE_INVALID", err)
+	}
+	if headerVals.Algorithm != "ed25519" {
+		return model.NewSignValidationErr("AUT_SIGNATURE_INVALID", fmt.Errorf("unsupported algorithm %q: only ed25519 is permitted", headerVals.Algorithm))
+	}
+	if err := s.validate(ctx, value, "", false); err != nil {
+		return err
+	}
+	if s.gateway == nil {
+		return nil
+	}
+	subs, err := s.gateway.registry.Lookup(ctx, &model.Subscription{
+		Subscriber: model.Subscriber{SubscriberID: headerVals.SubscriberID},
+		KeyID:      headerVals.UniqueID,
+	})
+	if err != nil {
+		return registryLookupErr(fmt.Errorf("gateway registry lookup failed: %w", err))
+	}
+	for _, sub := range subs {
+		if strings.EqualFold(sub.Type, "BG") {
+			return nil
+		}
+	}
+	return model.NewSignValidationErr("AUT_UNAUTHORIZED_ACTION", fmt.Errorf("%s is not registered as a BG", headerVals.SubscriberID))
+}
+
 // lookupCallbackRequestSig returns the stored CN outbound signature for solicited
 // callbacks so that validateHeaders can pass it to ValidateAck (4-line signing
 // string, NFH-004 §3.3). Returns "" when any of the following apply, degrading
@@ -252,6 +359,17 @@ func (s *validateSignStep) lookupCallbackRequestSig(ctx *model.StepContext, auth
 	return entry.Signature, nil
 }
 
+// registryLookupErr classifies a failed registry lookup. An error the
+// registry plugin already classified keeps its code; anything else means the
+// registry could not be reached, which is not the caller's fault.
+func registryLookupErr(err error) error {
+	var coded *model.CodedErr
+	if errors.As(err, &coded) {
+		return err
+	}
+	return model.NewCodedErr(http.StatusServiceUnavailable, "NET_DOWNSTREAM_UNAVAILABLE", err)
+}
+
 // validate checks the validity of the provided signature header.
 // When requestSig is non-empty (solicited callback path) it calls ValidateAck
 // to verify against the 4-line signing string (NFH-004 §3.3); otherwise it
@@ -280,6 +398,31 @@ func (s *validateSignStep) validate(ctx *model.StepContext, value, requestSig st
 	if validErr != nil {
 		return fmt.Errorf("sign validation failed: %w", validErr)
 	}
+	if checkIdentity {
+		ctx.SignerID, ctx.SignerKeyID = headerVals.SubscriberID, headerVals.UniqueID
+	}
+	return nil
+}
+
+// validateHTTPMessage checks an RFC 9421 signature whose keyid uses the
+// Beckn "{subscriber_id}|{unique_key_id}|ed25519" format.
+func (s *validateSignStep) validateHTTPMessage(ctx *model.StepContext, hv definition.HTTPMessageSignValidator, keyID string) error {
+	keyVals, err := parseKeyID(keyID)
+	if err != nil {
+		return model.NewSignValidationErr("AUT_SIGNATURE_INVALID", err)
+	}
+	if keyVals.Algorithm != "ed25519" {
+		return model.NewSignValidationErr("AUT_SIGNATURE_INVALID", fmt.Errorf("unsupported algorithm %q: only ed25519 is permitted", keyVals.Algorithm))
+	}
+	log.Debugf(ctx, "Validating RFC 9421 signature for subscriberID: %v", keyVals.SubscriberID)
+	signingPublicKey, _, err := s.km.LookupNPKeys(ctx, keyVals.SubscriberID, keyVals.UniqueID)
+	if err != nil {
+		return fmt.Errorf("failed to get validation key: %w", err)
+	}
+	if err := hv.ValidateHTTPMessage(ctx, signingPublicKey, true); err != nil {
+		return fmt.Errorf("sign validation failed: %w", err)
+	}
+	ctx.SignerID, ctx.SignerKeyID = keyVals.SubscriberID, keyVals.UniqueID
 	return nil
 }
 
@@ -339,7 +482,12 @@ func parseHeader(header string) (*authHeader, error) {
 	if keyIDPart == "" {
 		return nil, fmt.Errorf("keyId parameter not found in Authorization header")
 	}
+	return parseKeyID(keyIDPart)
+}
 
+// parseKeyID splits a "{subscriber_id}|{unique_key_id}|{algorithm}" key ID.
+// The same format is used for the RFC 9421 keyid signature parameter.
+func parseKeyID(keyIDPart string) (*authHeader, error) {
 	keyIDComponents := strings.Split(keyIDPart, "|")
 	if len(keyIDComponents) != 3 {
 		return nil, fmt.Errorf("keyId parameter has incorrect format, expected 3 components separated by '|', got %d for '%s'", len(keyIDComponents), keyIDPart)
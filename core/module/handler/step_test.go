@@ -2,6 +2,8 @@ package handler
 
 import (
 	"context"
+	"crypto/ed25519"
+	"encoding/base64"
 	"errors"
 	"net/http"
 	"net/url"
@@ -10,6 +12,8 @@ import (
 
 	"github.com/beckn-one/beckn-onix/pkg/model"
 	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/implementation/signer"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/implementation/signvalidator"
 )
 
 // ---------------------------------------------------------------------------
@@ -134,14 +138,14 @@ func makeReceiverStepCtx(protocolVersion, messageID, subID, authHeader, bodyJSON
 
 func TestNewValidateSignStep_NilSignValidator_ReturnsError(t *testing.T) {
 	km := &mockKMBasic{}
-	if _, err := newValidateSignStep(nil, km, nil); err == nil {
+	if _, err := newValidateSignStep(nil, km, nil, nil); err == nil {
 		t.Fatal("expected error for nil SignValidator")
 	}
 }
 
 func TestNewValidateSignStep_NilKM_ReturnsError(t *testing.T) {
 	sv := &mockSignValidatorBasic{}
-	if _, err := newValidateSignStep(sv, nil, nil); err == nil {
+	if _, err := newValidateSignStep(sv, nil, nil, nil); err == nil {
 		t.Fatal("expected error for nil KeyManager")
 	}
 }
@@ -149,7 +153,7 @@ func TestNewValidateSignStep_NilKM_ReturnsError(t *testing.T) {
 func TestNewValidateSignStep_NilPayloadStore_OK(t *testing.T) {
 	sv := &mockSignValidatorBasic{}
 	km := &mockKMBasic{publicKey: "pubKey=="}
-	step, err := newValidateSignStep(sv, km, nil)
+	step, err := newValidateSignStep(sv, km, nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -200,6 +204,7 @@ func TestValidateSignStep_InitSteps_WithPayloadStore(t *testing.T) {
 		signValidator: sv,
 		km:            km,
 		payloadStore:  store,
+		registry:      &mockGatewayRegistry{subType: "BG"},
 		steps:         []definition.Step{},
 		responseSteps: []definition.ResponseStep{},
 	}
@@ -213,6 +218,17 @@ func TestValidateSignStep_InitSteps_WithPayloadStore(t *testing.T) {
 	}
 }
 
+func TestValidateSignStep_InitSteps_RequiresRegistry(t *testing.T) {
+	h := &stdHandler{
+		signValidator: &mockSignValidatorBasic{},
+		km:            &mockKMBasic{publicKey: "pubKey=="},
+	}
+	err := h.initSteps(context.Background(), noopPluginManager{}, &Config{Steps: []string{"validateSign"}})
+	if err == nil || !strings.Contains(err.Error(), "Registry plugin not configured") {
+		t.Fatalf("initSteps() error = %v, want missing Registry", err)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // signStep.Run — Sign vs SignAck dispatch
 // ---------------------------------------------------------------------------
@@ -319,6 +335,147 @@ func TestSignStep_Run_SignAckError_ReturnsError(t *testing.T) {
 	}
 }
 
+// mockHTTPMessageSigner is a Signer that also implements
+// definition.HTTPMessageSigner.
+type mockHTTPMessageSigner struct {
+	mockSigner
+	useHTTPSig    bool
+	signedMethod  string
+	signedTarget  string
+	signedKeyID   string
+	signHTTPCalls int
+}
+
+func (m *mockHTTPMessageSigner) UseHTTPMessageSignatures(_ string) bool { return m.useHTTPSig }
+func (m *mockHTTPMessageSigner) SignHTTPMessage(_ context.Context, method string, targetURI *url.URL, header http.Header, _ []byte, keyID, _ string, _, _ int64) error {
+	m.signHTTPCalls++
+	m.signedMethod, m.signedTarget, m.signedKeyID = method, targetURI.String(), keyID
+	header.Set("Signature-Input", "sig1=()")
+	return nil
+}
+
+func TestSignStep_Run_HTTPMessageSignature_UsesRoutedURL(t *testing.T) {
+	signer := &mockHTTPMessageSigner{useHTTPSig: true}
+	step, _ := newSignStep(signer, &mockKMBasic{keyset: testKeyset()}, nil)
+
+	ctx := makeSignStepCtx("search", "msg-httpsig-001", "bap.example.com")
+	ctx.Route = &model.Route{TargetType: "url", URL: &url.URL{Scheme: "https", Host: "bpp.example.com", Path: "/receiver/search"}}
+	if err := step.Run(ctx); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if signer.signHTTPCalls != 1 || signer.signCalled {
+		t.Fatalf("SignHTTPMessage calls = %d, Sign called = %v; want RFC 9421 only", signer.signHTTPCalls, signer.signCalled)
+	}
+	if signer.signedTarget != "https://bpp.example.com/receiver/search" || signer.signedMethod != http.MethodPost {
+		t.Errorf("signed %s %s, want POST to the routed URL", signer.signedMethod, signer.signedTarget)
+	}
+	if signer.signedKeyID != "bap.example.com|key-1|ed25519" {
+		t.Errorf("keyid = %q", signer.signedKeyID)
+	}
+	if ctx.Request.Header.Get(model.AuthHeaderSubscriber) != "" {
+		t.Error("Authorization header must not be set when signing with RFC 9421")
+	}
+}
+
+func TestSignStep_Run_HTTPMessageSignature_NotSelected_UsesSign(t *testing.T) {
+	signer := &mockHTTPMessageSigner{useHTTPSig: false}
+	step, _ := newSignStep(signer, &mockKMBasic{keyset: testKeyset()}, nil)
+
+	ctx := makeSignStepCtx("search", "msg-httpsig-002", "bap.example.com")
+	if err := step.Run(ctx); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if signer.signHTTPCalls != 0 || !signer.signCalled {
+		t.Error("expected the Beckn Authorization header path")
+	}
+}
+
+func TestSignStep_Run_HTTPMessageSignature_SolicitedCallbackKeepsSignAck(t *testing.T) {
+	store := newMockPayloadStore()
+	store.storeEntry("msg-httpsig-003", "search", "callerSig==")
+	signer := &mockHTTPMessageSigner{useHTTPSig: true}
+	step, _ := newSignStep(signer, &mockKMBasic{keyset: testKeyset()}, store)
+
+	ctx := makeSignStepCtx("on_search", "msg-httpsig-003", "bpp.example.com")
+	if err := step.Run(ctx); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if signer.signHTTPCalls != 0 || !signer.signAckCalled {
+		t.Error("expected solicited callbacks to keep the SignAck binding")
+	}
+}
+
+// TestSignStep_RFC9421CallbackBetweenAdapters signs on_select callbacks with a
+// BPP adapter's rfc9421 signer and validates them with a BAP adapter's
+// rfc9421 validator, both with the real plugins.
+func TestSignStep_RFC9421CallbackBetweenAdapters(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bppKM := &mockKMBasic{keyset: &model.Keyset{UniqueKeyID: "key-1", SigningPrivate: base64.StdEncoding.EncodeToString(priv.Seed())}}
+	bapKM := &mockKMBasic{publicKey: base64.StdEncoding.EncodeToString(pub)}
+	rfc9421Signer, _, err := signer.New(context.Background(), &signer.Config{Scheme: signer.SchemeHTTPSig})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rfc9421Validator, _, err := signvalidator.New(context.Background(), &signvalidator.Config{Scheme: signvalidator.SchemeHTTPSig})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		requestSig   string // the select's stored Authorization header; "" when it was signed with RFC 9421
+		wantAuthHdr  bool
+		wantSigInput bool
+	}{
+		{name: "select signed with RFC 9421", wantSigInput: true},
+		{name: "select signed with the Beckn header", requestSig: `Signature keyId="bap.example.com|key-1|ed25519",signature="callerSig=="`, wantAuthHdr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Both adapters stored the select they exchanged.
+			store := newMockPayloadStore()
+			if tt.requestSig != "" {
+				store.storeEntry("msg-9421", "select", tt.requestSig)
+			}
+			sign, err := newSignStep(rfc9421Signer, bppKM, store)
+			if err != nil {
+				t.Fatal(err)
+			}
+			validate, err := newValidateSignStep(rfc9421Validator, bapKM, store, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body := `{"context":{"action":"on_select","version":"2.0.0","bap_id":"bap.example.com","bpp_id":"bpp.example.com","message_id":"msg-9421"}}`
+			req, _ := http.NewRequest(http.MethodPost, "http://bap.example.com/bap/receiver/on_select", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			out := &model.StepContext{Context: context.Background(), Request: req, Body: []byte(body), Role: model.RoleBPP,
+				SubID: "bpp.example.com", MessageID: "msg-9421", ProtocolVersion: "2.0.0", RespHeader: http.Header{}}
+			if err := sign.Run(out); err != nil {
+				t.Fatalf("sign.Run() error = %v", err)
+			}
+			if got := req.Header.Get(model.AuthHeaderSubscriber) != ""; got != tt.wantAuthHdr {
+				t.Errorf("Authorization header set = %v, want %v", got, tt.wantAuthHdr)
+			}
+			if got := req.Header.Get("Signature-Input") != ""; got != tt.wantSigInput {
+				t.Errorf("Signature-Input set = %v, want %v", got, tt.wantSigInput)
+			}
+
+			in := &model.StepContext{Context: context.Background(), Request: req, Body: []byte(body), Role: model.RoleBAP,
+				SubID: "bap.example.com", MessageID: "msg-9421", ProtocolVersion: "2.0.0", RespHeader: http.Header{}}
+			if err := validate.Run(in); err != nil {
+				t.Fatalf("validate.Run() error = %v, want the callback accepted", err)
+			}
+			if in.SignerID != "bpp.example.com" {
+				t.Errorf("SignerID = %q, want bpp.example.com", in.SignerID)
+			}
+		})
+	}
+}
+
 // ---------------------------------------------------------------------------
 // validateHeaders — 3-line vs 4-line dispatch
 // ---------------------------------------------------------------------------
@@ -343,7 +500,7 @@ func TestValidateHeaders_SolicitedCallback_UsesValidateAck(t *testing.T) {
 
 	sv := &mockSignValidatorBasic{}
 	km := &mockKMBasic{publicKey: "pubKey=="}
-	step, _ := newValidateSignStep(sv, km, store)
+	step, _ := newValidateSignStep(sv, km, store, nil)
 	vStep := step.(*validateSignStep)
 
 	ctx := makeValidateStepCtx("2.0.0", "msg-vh-001", "bap.example.com",
@@ -364,7 +521,7 @@ func TestValidateHeaders_SolicitedCallback_UsesValidateAck(t *testing.T) {
 func TestValidateHeaders_ProviderInitiated_UsesValidate(t *testing.T) {
 	sv := &mockSignValidatorBasic{}
 	km := &mockKMBasic{publicKey: "pubKey=="}
-	step, _ := newValidateSignStep(sv, km, nil)
+	step, _ := newValidateSignStep(sv, km, nil, nil)
 	vStep := step.(*validateSignStep)
 
 	ctx := makeValidateStepCtx("2.0.0", "msg-vh-002", "bap.example.com",
@@ -380,13 +537,16 @@ func TestValidateHeaders_ProviderInitiated_UsesValidate(t *testing.T) {
 	if !sv.validateCalled {
 		t.Error("expected Validate to be called for a provider-initiated callback")
 	}
+	if ctx.SignerID != "bpp.example.com" || ctx.SignerKeyID != "key-1" {
+		t.Errorf("signer = %q|%q, want bpp.example.com|key-1", ctx.SignerID, ctx.SignerKeyID)
+	}
 }
 
 func TestValidateHeaders_SolicitedCallback_NilStore_FallsBackToValidate(t *testing.T) {
 	sv := &mockSignValidatorBasic{}
 	km := &mockKMBasic{publicKey: "pubKey=="}
 	// nil payloadStore — degrade to 3-line verification
-	step, _ := newValidateSignStep(sv, km, nil)
+	step, _ := newValidateSignStep(sv, km, nil, nil)
 	vStep := step.(*validateSignStep)
 
 	ctx := makeValidateStepCtx("2.0.0", "msg-vh-003", "bap.example.com",
@@ -408,7 +568,7 @@ func TestValidateHeaders_SolicitedCallback_PreV2_FallsBackToValidate(t *testing.
 	store := newMockPayloadStore()
 	sv := &mockSignValidatorBasic{}
 	km := &mockKMBasic{publicKey: "pubKey=="}
-	step, _ := newValidateSignStep(sv, km, store)
+	step, _ := newValidateSignStep(sv, km, store, nil)
 	vStep := step.(*validateSignStep)
 
 	ctx := makeValidateStepCtx("1.1.0", "msg-vh-004", "bap.example.com",
@@ -426,6 +586,99 @@ func TestValidateHeaders_SolicitedCallback_PreV2_FallsBackToValidate(t *testing.
 	}
 }
 
+// mockHTTPMessageSignValidator is a SignValidator that also implements
+// definition.HTTPMessageSignValidator.
+type mockHTTPMessageSignValidator struct {
+	mockSignValidatorBasic
+	keyID            string
+	use              bool
+	keyIDErr         error
+	validateHTTPErr  error
+	validateHTTPCall bool
+}
+
+func (m *mockHTTPMessageSignValidator) HTTPMessageKeyID(_ *model.StepContext) (string, bool, error) {
+	return m.keyID, m.use, m.keyIDErr
+}
+func (m *mockHTTPMessageSignValidator) ValidateHTTPMessage(_ *model.StepContext, _ string, _ bool) error {
+	m.validateHTTPCall = true
+	return m.validateHTTPErr
+}
+
+func TestValidateHeaders_HTTPMessageSignature(t *testing.T) {
+	const body = `{"context":{"action":"search","messageId":"msg-vh-httpsig","version":"2.0.0"}}`
+	tests := []struct {
+		name           string
+		sv             *mockHTTPMessageSignValidator
+		authHeader     string
+		wantErrCode    string
+		wantHTTPSig    bool
+		wantValidate   bool
+		wantChallenged bool
+	}{
+		{
+			name:        "verified with RFC 9421",
+			sv:          &mockHTTPMessageSignValidator{keyID: "bap.example.com|key-1|ed25519", use: true},
+			wantHTTPSig: true,
+		},
+		{
+			name:         "falls back to Authorization header",
+			sv:           &mockHTTPMessageSignValidator{},
+			authHeader:   providerInitiatedAuthHeader("bap.example.com"),
+			wantValidate: true,
+		},
+		{
+			name:           "required but missing",
+			sv:             &mockHTTPMessageSignValidator{keyIDErr: model.NewSignValidationErr("AUT_SIGNATURE_MISSING", errors.New("Signature-Input missing"))},
+			authHeader:     providerInitiatedAuthHeader("bap.example.com"),
+			wantErrCode:    "AUT_SIGNATURE_MISSING",
+			wantChallenged: true,
+		},
+		{
+			name:           "malformed keyid",
+			sv:             &mockHTTPMessageSignValidator{keyID: "bap.example.com", use: true},
+			wantErrCode:    "AUT_SIGNATURE_INVALID",
+			wantChallenged: true,
+		},
+		{
+			name:           "verification fails",
+			sv:             &mockHTTPMessageSignValidator{keyID: "bap.example.com|key-1|ed25519", use: true, validateHTTPErr: model.NewSignValidationErr("AUT_SIGNATURE_INVALID", errors.New("bad"))},
+			wantErrCode:    "AUT_SIGNATURE_INVALID",
+			wantHTTPSig:    true,
+			wantChallenged: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, _ := newValidateSignStep(tt.sv, &mockKMBasic{publicKey: "pubKey=="}, nil, nil)
+			ctx := makeValidateStepCtx("2.0.0", "msg-vh-httpsig", "bpp.example.com", tt.authHeader, body)
+			if tt.authHeader == "" {
+				ctx.Request.Header.Del(model.AuthHeaderSubscriber)
+			}
+
+			err := step.(*validateSignStep).validateHeaders(ctx)
+			if tt.wantErrCode == "" && err != nil {
+				t.Fatalf("validateHeaders() unexpected error: %v", err)
+			}
+			if tt.wantErrCode != "" {
+				var coded *model.CodedErr
+				if !errors.As(err, &coded) || coded.Code != tt.wantErrCode {
+					t.Fatalf("validateHeaders() error = %v, want code %s", err, tt.wantErrCode)
+				}
+			}
+			if tt.sv.validateHTTPCall != tt.wantHTTPSig {
+				t.Errorf("ValidateHTTPMessage called = %v, want %v", tt.sv.validateHTTPCall, tt.wantHTTPSig)
+			}
+			if tt.sv.validateCalled != tt.wantValidate {
+				t.Errorf("Validate called = %v, want %v", tt.sv.validateCalled, tt.wantValidate)
+			}
+			if challenged := ctx.RespHeader.Get(model.UnaAuthorizedHeaderSubscriber) != ""; challenged != tt.wantChallenged {
+				t.Errorf("challenge header set = %v, want %v", challenged, tt.wantChallenged)
+			}
+		})
+	}
+}
+
 // TestValidateHeaders_PreservesClassifiedCode verifies that validateHeaders
 // propagates the AUT_* code a lower layer already classified (signvalidator's
 // Validate/ValidateAck or keymanager's LookupNPKeys) instead of shadowing it
@@ -453,7 +706,7 @@ func TestValidateHeaders_PreservesClassifiedCode(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			step, _ := newValidateSignStep(tt.sv, tt.km, nil)
+			step, _ := newValidateSignStep(tt.sv, tt.km, nil, nil)
 			vStep := step.(*validateSignStep)
 
 			ctx := makeValidateStepCtx("2.0.0", "msg-vh-code", "bap.example.com",
@@ -480,7 +733,7 @@ func TestValidateHeaders_SolicitedCallback_NoEntry_ReturnsError(t *testing.T) {
 
 	sv := &mockSignValidatorBasic{}
 	km := &mockKMBasic{publicKey: "pubKey=="}
-	step, _ := newValidateSignStep(sv, km, store)
+	step, _ := newValidateSignStep(sv, km, store, nil)
 	vStep := step.(*validateSignStep)
 
 	ctx := makeValidateStepCtx("2.0.0", "msg-vh-005", "bap.example.com",
@@ -498,7 +751,7 @@ func TestValidateHeaders_SolicitedCallback_StoreError_ReturnsError(t *testing.T)
 
 	sv := &mockSignValidatorBasic{}
 	km := &mockKMBasic{publicKey: "pubKey=="}
-	step, _ := newValidateSignStep(sv, km, store)
+	step, _ := newValidateSignStep(sv, km, store, nil)
 	vStep := step.(*validateSignStep)
 
 	ctx := makeValidateStepCtx("2.0.0", "msg-vh-006", "bap.example.com",
@@ -513,7 +766,7 @@ func TestValidateHeaders_SolicitedCallback_StoreError_ReturnsError(t *testing.T)
 func TestValidate_NonEd25519Algorithm_ReturnsError(t *testing.T) {
 	sv := &mockSignValidatorBasic{}
 	km := &mockKMBasic{publicKey: "pubKey=="}
-	step, _ := newValidateSignStep(sv, km, nil)
+	step, _ := newValidateSignStep(sv, km, nil, nil)
 	vStep := step.(*validateSignStep)
 
 	badAlgHeader := `Signature keyId="bpp.example.com|key-1|rsa",algorithm="rsa",` +
@@ -848,3 +1101,163 @@ func TestAddRouteStep_Run_NoBasePath_UsesRawAction(t *testing.T) {
 		t.Errorf("router received path %q, want %q", mr.gotURL.Path, "search")
 	}
 }
+
+// ---------------------------------------------------------------------------
+// X-Gateway-Authorization
+// ---------------------------------------------------------------------------
+
+// mockGatewayRegistry returns one subscription of the given type for any lookup.
+type mockGatewayRegistry struct {
+	subType string
+	err     error
+	lookups []model.Subscription
+}
+
+func (m *mockGatewayRegistry) Lookup(_ context.Context, req *model.Subscription) ([]model.Subscription, error) {
+	m.lookups = append(m.lookups, *req)
+	if m.err != nil {
+		return nil, m.err
+	}
+	return []model.Subscription{{Subscriber: model.Subscriber{SubscriberID: req.SubscriberID, Type: m.subType}, KeyID: req.KeyID}}, nil
+}
+
+func gatewayAuthHeader(subID string) string {
+	return `Signature keyId="` + subID + `|bg-key|ed25519",algorithm="ed25519",created="1700000000",expires="1700000300",headers="(created) (expires) digest",signature="gwSig=="`
+}
+
+func TestValidateHeaders_GatewaySignature(t *testing.T) {
+	const searchBody = `{"context":{"action":"search","messageId":"msg-gw","version":"1.1.0","bap_id":"bap.example.com"}}`
+	const selectBody = `{"context":{"action":"select","messageId":"msg-gw","version":"1.1.0","bap_id":"bap.example.com"}}`
+	tests := []struct {
+		name           string
+		body           string
+		gatewayHeader  string
+		registry       *mockGatewayRegistry
+		wantCode       string
+		wantChallenged bool
+	}{
+		{
+			name:          "BG signature accepted",
+			body:          searchBody,
+			gatewayHeader: gatewayAuthHeader("bg.example.com"),
+			registry:      &mockGatewayRegistry{subType: "BG"},
+		},
+		{
+			name:           "signer not registered as BG",
+			body:           searchBody,
+			gatewayHeader:  gatewayAuthHeader("bpp.other.com"),
+			registry:       &mockGatewayRegistry{subType: "BPP"},
+			wantCode:       "AUT_UNAUTHORIZED_ACTION",
+			wantChallenged: true,
+		},
+		{
+			name:           "missing for a required action",
+			body:           searchBody,
+			registry:       &mockGatewayRegistry{subType: "BG"},
+			wantCode:       "AUT_SIGNATURE_MISSING",
+			wantChallenged: true,
+		},
+		{
+			name:     "missing for a direct action",
+			body:     selectBody,
+			registry: &mockGatewayRegistry{subType: "BG"},
+		},
+		{
+			name:           "malformed header",
+			body:           searchBody,
+			gatewayHeader:  `Signature keyId="bg.example.com",signature="gwSig=="`,
+			registry:       &mockGatewayRegistry{subType: "BG"},
+			wantCode:       "AUT_SIGNATURE_INVALID",
+			wantChallenged: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sv := &mockSignValidatorBasic{}
+			gw := newGatewayAuth(tt.registry, GatewayAuthConfig{RequiredActions: []string{"search"}})
+			step, _ := newValidateSignStep(sv, &mockKMBasic{publicKey: "pubKey=="}, nil, gw)
+			ctx := makeValidateStepCtx("1.1.0", "msg-gw", "bpp.example.com", providerInitiatedAuthHeader("bap.example.com"), tt.body)
+			if tt.gatewayHeader != "" {
+				ctx.Request.Header.Set(model.AuthHeaderGateway, tt.gatewayHeader)
+			}
+
+			err := step.(*validateSignStep).validateHeaders(ctx)
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("validateHeaders() unexpected error: %v", err)
+				}
+				if !sv.validateCalled {
+					t.Error("expected the subscriber signature to be validated as well")
+				}
+			} else {
+				var coded *model.CodedErr
+				if !errors.As(err, &coded) || coded.Code != tt.wantCode {
+					t.Fatalf("validateHeaders() error = %v, want code %s", err, tt.wantCode)
+				}
+				if coded.HTTPStatus() != http.StatusUnauthorized {
+					t.Errorf("status = %d, want 401", coded.HTTPStatus())
+				}
+			}
+			if challenged := ctx.RespHeader.Get(model.UnaAuthorizedHeaderGateway) != ""; challenged != tt.wantChallenged {
+				t.Errorf("Proxy-Authenticate set = %v, want %v", challenged, tt.wantChallenged)
+			}
+			if ctx.RespHeader.Get(model.UnaAuthorizedHeaderSubscriber) != "" {
+				t.Error("gateway failures must not set WWW-Authenticate")
+			}
+		})
+	}
+}
+
+func TestValidateHeaders_GatewayRegistryUnavailable(t *testing.T) {
+	registry := &mockGatewayRegistry{err: errors.New("connection refused")}
+	step, _ := newValidateSignStep(&mockSignValidatorBasic{}, &mockKMBasic{publicKey: "pubKey=="}, nil, newGatewayAuth(registry, GatewayAuthConfig{}))
+	ctx := makeValidateStepCtx("1.1.0", "msg-gw", "bpp.example.com", providerInitiatedAuthHeader("bap.example.com"),
+		`{"context":{"action":"search","messageId":"msg-gw","version":"1.1.0"}}`)
+	ctx.Request.Header.Set(model.AuthHeaderGateway, gatewayAuthHeader("bg.example.com"))
+
+	err := step.(*validateSignStep).validateHeaders(ctx)
+	var coded *model.CodedErr
+	if !errors.As(err, &coded) || coded.Code != "NET_DOWNSTREAM_UNAVAILABLE" || coded.HTTPStatus() != http.StatusServiceUnavailable {
+		t.Fatalf("validateHeaders() error = %v, want 503 NET_DOWNSTREAM_UNAVAILABLE", err)
+	}
+}
+
+func TestValidateHeaders_GatewayRegistryLookupUsesKeyID(t *testing.T) {
+	registry := &mockGatewayRegistry{subType: "bg"}
+	step, _ := newValidateSignStep(&mockSignValidatorBasic{}, &mockKMBasic{publicKey: "pubKey=="}, nil, newGatewayAuth(registry, GatewayAuthConfig{}))
+	ctx := makeValidateStepCtx("1.1.0", "msg-gw", "bpp.example.com", providerInitiatedAuthHeader("bap.example.com"),
+		`{"context":{"action":"search","messageId":"msg-gw","version":"1.1.0"}}`)
+	ctx.Request.Header.Set(model.AuthHeaderGateway, gatewayAuthHeader("bg.example.com"))
+
+	if err := step.(*validateSignStep).validateHeaders(ctx); err != nil {
+		t.Fatalf("validateHeaders() unexpected error: %v", err)
+	}
+	if len(registry.lookups) != 1 || registry.lookups[0].SubscriberID != "bg.example.com" || registry.lookups[0].KeyID != "bg-key" {
+		t.Errorf("registry lookups = %+v, want one lookup for bg.example.com/bg-key", registry.lookups)
+	}
+}
+
+func TestSignStep_Run_Gateway_SetsProxySignature(t *testing.T) {
+	store := newMockPayloadStore()
+	store.storeEntry("msg-gw-sign", "search", "callerSig==")
+
+	signer := &mockSigner{returnSignSig: "bgSig==", returnSig: "ackSig=="}
+	step, _ := newSignStep(signer, &mockKMBasic{keyset: testKeyset()}, store)
+
+	ctx := makeSignStepCtx("on_search", "msg-gw-sign", "bg.example.com")
+	ctx.Role = model.RoleGateway
+	ctx.Request.Header.Set(model.AuthHeaderSubscriber, "subscriber-signature")
+	if err := step.Run(ctx); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if signer.signAckCalled || !signer.signCalled {
+		t.Error("expected a BG to sign with the 3-line signing string")
+	}
+	gw := ctx.Request.Header.Get(model.AuthHeaderGateway)
+	if !strings.Contains(gw, `keyId="bg.example.com|key-1|ed25519"`) || !strings.Contains(gw, `signature="bgSig=="`) {
+		t.Errorf("X-Gateway-Authorization = %q", gw)
+	}
+	if got := ctx.Request.Header.Get(model.AuthHeaderSubscriber); got != "subscriber-signature" {
+		t.Errorf("Authorization = %q, want the subscriber's signature untouched", got)
+	}
+}
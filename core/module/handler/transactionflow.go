@@ -0,0 +1,262 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// defaultTransactionFlowTTL bounds how long a transaction's actions are
+	// remembered in the cache when TransactionFlowConfig.StateTTL is unset.
+	defaultTransactionFlowTTL = 24 * time.Hour
+
+	// transactionFlowWildcard keys the flow used for domains without their own.
+	transactionFlowWildcard = "*"
+
+	transactionFlowStoreCache        = "cache"
+	transactionFlowStorePayloadStore = "payloadStore"
+)
+
+// TransactionFlow maps a Beckn action to the actions of which at least one
+// must already have been seen in the same transaction. Actions that are not
+// listed may arrive at any point, including as the first message.
+type TransactionFlow map[string][]string
+
+// postConfirmActions may only follow a confirmed order. Their callbacks may
+// also be sent unsolicited by the BPP once the order exists.
+var postConfirmActions = []string{"status", "track", "update", "cancel", "rating", "support"}
+
+// defaultTransactionFlows returns the flow used when none is configured for a
+// domain: the v1 action set for versions before 2.0.0, the v2 set otherwise.
+// Both start at select, since a catalog may come from an earlier transaction.
+func defaultTransactionFlows(v2 bool) TransactionFlow {
+	discovery := "search"
+	if v2 {
+		discovery = "discover"
+	}
+	flow := TransactionFlow{
+		"on_" + discovery: {discovery},
+		"on_select":       {"select"},
+		"init":            {"select", "on_select"},
+		"on_init":         {"init"},
+		"confirm":         {"init", "on_init"},
+		"on_confirm":      {"confirm"},
+	}
+	for _, action := range postConfirmActions {
+		flow[action] = []string{"confirm", "on_confirm"}
+		flow["on_"+action] = []string{action, "confirm", "on_confirm"}
+	}
+	return flow
+}
+
+// transactionFlowState records and reads the actions seen in a transaction.
+type transactionFlowState interface {
+	seen(ctx *model.StepContext, transactionID string) (map[string]bool, error)
+	record(ctx *model.StepContext, transactionID, action string, seen map[string]bool) error
+}
+
+// checkTransactionFlowStep rejects a message whose action is out of order for
+// its transaction, e.g. a confirm that was never preceded by an init.
+type checkTransactionFlowStep struct {
+	flows       map[string]TransactionFlow
+	state       transactionFlowState
+	monitorOnly bool
+}
+
+func newCheckTransactionFlowStep(cache definition.Cache, payloadStore definition.PayloadStore, cfg TransactionFlowConfig) (definition.Step, error) {
+	flows := make(map[string]TransactionFlow)
+	if cfg.FlowsFile != "" {
+		data, err := os.ReadFile(cfg.FlowsFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid config: transactionFlow.flowsFile: %w", err)
+		}
+		if err := yaml.Unmarshal(data, &flows); err != nil {
+			return nil, fmt.Errorf("invalid config: transactionFlow.flowsFile %s: %w", cfg.FlowsFile, err)
+		}
+	}
+	// Inline flows take precedence over the file, domain by domain.
+	for domain, flow := range cfg.Flows {
+		flows[domain] = flow
+	}
+
+	s := &checkTransactionFlowStep{flows: flows, monitorOnly: cfg.MonitorOnly}
+	store := cfg.Store
+	if store == "" {
+		store = transactionFlowStoreCache
+		if cache == nil && payloadStore != nil {
+			store = transactionFlowStorePayloadStore
+		}
+	}
+	switch store {
+	case transactionFlowStoreCache:
+		if cache == nil {
+			return nil, fmt.Errorf("invalid config: Cache plugin not configured; required by checkTransactionFlow")
+		}
+		ttl := cfg.StateTTL
+		if ttl <= 0 {
+			ttl = defaultTransactionFlowTTL
+		}
+		s.state = &cacheFlowState{cache: cache, ttl: ttl}
+	case transactionFlowStorePayloadStore:
+		if payloadStore == nil {
+			return nil, fmt.Errorf("invalid config: PayloadStore plugin not configured; required by checkTransactionFlow")
+		}
+		s.state = &payloadStoreFlowState{store: payloadStore}
+	default:
+		return nil, fmt.Errorf("invalid config: transactionFlow.store must be %q or %q, got %q", transactionFlowStoreCache, transactionFlowStorePayloadStore, cfg.Store)
+	}
+	return s, nil
+}
+
+// Run executes the transaction flow check.
+func (s *checkTransactionFlowStep) Run(ctx *model.StepContext) error {
+	action := extractBecknAction(ctx.Body)
+	transactionID := extractTransactionID(ctx.Body)
+	if action == "" || transactionID == "" {
+		log.Debugf(ctx, "checkTransactionFlow: action or transaction ID missing; skipping")
+		return nil
+	}
+
+	seen, err := s.state.seen(ctx, transactionID)
+	if err != nil {
+		return fmt.Errorf("checkTransactionFlow: failed to read state for transaction %s: %w", transactionID, err)
+	}
+	if required := s.flowFor(ctx).requires(action); len(required) > 0 && !anySeen(seen, required) {
+		err := fmt.Errorf("%s is not allowed in transaction %s before any of %s; seen: %s",
+			action, transactionID, strings.Join(required, ", "), describeSeen(seen))
+		if !s.monitorOnly {
+			return model.NewCodedErr(http.StatusBadRequest, "BIZ_ACTION_OUT_OF_SEQUENCE", err)
+		}
+		log.Warnf(ctx, "checkTransactionFlow: monitor-only: %v", err)
+	}
+
+	if err := s.state.record(ctx, transactionID, action, seen); err != nil {
+		log.Warnf(ctx, "checkTransactionFlow: failed to record %s for transaction %s: %v", action, transactionID, err)
+	}
+	return nil
+}
+
+// flowFor returns the flow configured for the message's domain, then the
+// wildcard flow, then the default flow for its protocol version.
+func (s *checkTransactionFlowStep) flowFor(ctx *model.StepContext) TransactionFlow {
+	if flow, ok := s.flows[extractContextDomain(ctx.Body)]; ok {
+		return flow
+	}
+	if flow, ok := s.flows[transactionFlowWildcard]; ok {
+		return flow
+	}
+	version := ctx.ProtocolVersion
+	if version == "" {
+		version = extractProtocolVersion(ctx.Body)
+	}
+	return defaultTransactionFlows(model.IsAtLeastV2(version))
+}
+
+func (f TransactionFlow) requires(action string) []string {
+	return f[action]
+}
+
+func anySeen(seen map[string]bool, actions []string) bool {
+	for _, a := range actions {
+		if seen[a] {
+			return true
+		}
+	}
+	return false
+}
+
+func describeSeen(seen map[string]bool) string {
+	if len(seen) == 0 {
+		return "none"
+	}
+	actions := make([]string, 0, len(seen))
+	for a := range seen {
+		actions = append(actions, a)
+	}
+	sort.Strings(actions)
+	return strings.Join(actions, ", ")
+}
+
+// cacheFlowState keeps the actions of each transaction as a comma-separated
+// cache value shared by every module using the same cache. Concurrent
+// messages of one transaction may race; the check is best-effort.
+type cacheFlowState struct {
+	cache definition.Cache
+	ttl   time.Duration
+}
+
+func transactionFlowKey(transactionID string) string {
+	return "onix:txnflow:" + transactionID
+}
+
+func (c *cacheFlowState) seen(ctx *model.StepContext, transactionID string) (map[string]bool, error) {
+	value, err := c.cache.Get(ctx, transactionFlowKey(transactionID))
+	if err != nil {
+		return nil, err
+	}
+	// A miss comes back as an empty value: the transaction is new.
+	return toSet(strings.Split(value, ","), false), nil
+}
+
+func (c *cacheFlowState) record(ctx *model.StepContext, transactionID, action string, seen map[string]bool) error {
+	if seen[action] {
+		return nil
+	}
+	actions := make([]string, 0, len(seen)+1)
+	for a := range seen {
+		actions = append(actions, a)
+	}
+	actions = append(actions, action)
+	sort.Strings(actions)
+	return c.cache.Set(ctx, transactionFlowKey(transactionID), strings.Join(actions, ","), c.ttl)
+}
+
+// payloadStoreFlowState reads the actions of a transaction from the requests
+// recorded by storePayload, so it records nothing itself.
+type payloadStoreFlowState struct {
+	store definition.PayloadStore
+}
+
+func (p *payloadStoreFlowState) seen(ctx *model.StepContext, transactionID string) (map[string]bool, error) {
+	entries, err := p.store.GetByTransactionID(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		if e.Kind != definition.PayloadKindResponse && e.Action != "" {
+			seen[e.Action] = true
+		}
+	}
+	return seen, nil
+}
+
+func (p *payloadStoreFlowState) record(*model.StepContext, string, string, map[string]bool) error {
+	return nil
+}
+
+func extractTransactionID(body []byte) string {
+	var payload struct {
+		Context struct {
+			TransactionID       string `json:"transactionId"`
+			LegacyTransactionID string `json:"transaction_id"`
+		} `json:"context"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+	if payload.Context.TransactionID != "" {
+		return payload.Context.TransactionID
+	}
+	return payload.Context.LegacyTransactionID
+}
@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+)
+
+// flowCache is an in-memory cache that, like the Redis cache plugin, returns
+// an empty value on a miss and getErr on a backend failure.
+type flowCache struct {
+	stubCache
+	values map[string]string
+	ttls   map[string]time.Duration
+	getErr error
+}
+
+func newFlowCache() *flowCache {
+	return &flowCache{values: map[string]string{}, ttls: map[string]time.Duration{}}
+}
+
+func (c *flowCache) Get(_ context.Context, key string) (string, error) {
+	if c.getErr != nil {
+		return "", c.getErr
+	}
+	return c.values[key], nil
+}
+
+func (c *flowCache) Set(_ context.Context, key, value string, ttl time.Duration) error {
+	c.values[key], c.ttls[key] = value, ttl
+	return nil
+}
+
+// flowPayloadStore returns fixed entries for every transaction.
+type flowPayloadStore struct {
+	stubPayloadStore
+	entries []definition.PayloadEntry
+	err     error
+}
+
+func (s *flowPayloadStore) GetByTransactionID(context.Context, string) ([]definition.PayloadEntry, error) {
+	return s.entries, s.err
+}
+
+func flowCtx(version, domain, action string) *model.StepContext {
+	body := `{"context":{"version":"` + version + `","domain":"` + domain + `","action":"` + action + `","transactionId":"txn-1"}}`
+	return &model.StepContext{Context: context.Background(), Body: []byte(body)}
+}
+
+func TestCheckTransactionFlowStep_Defaults(t *testing.T) {
+	tests := []struct {
+		name     string
+		version  string
+		sequence []string
+		wantCode string // expected NACK code for the last action
+	}{
+		{name: "v2 happy path", version: "2.0.0", sequence: []string{"discover", "on_discover", "select", "on_select", "init", "on_init", "confirm", "on_confirm", "status", "on_status"}},
+		{name: "v1 happy path", version: "1.1.0", sequence: []string{"search", "on_search", "select", "on_select", "init", "on_init", "confirm", "on_confirm", "cancel", "on_cancel"}},
+		{name: "confirm without init", version: "2.0.0", sequence: []string{"select", "confirm"}, wantCode: "BIZ_ACTION_OUT_OF_SEQUENCE"},
+		{name: "on_cancel never confirmed", version: "2.0.0", sequence: []string{"select", "init", "on_cancel"}, wantCode: "BIZ_ACTION_OUT_OF_SEQUENCE"},
+		{name: "unsolicited on_status after confirm", version: "2.0.0", sequence: []string{"select", "init", "confirm", "on_status"}},
+		{name: "v1 on_search needs search", version: "1.1.0", sequence: []string{"on_search"}, wantCode: "BIZ_ACTION_OUT_OF_SEQUENCE"},
+		{name: "v2 does not know search", version: "2.0.0", sequence: []string{"on_search"}},
+		{name: "select may start a transaction", version: "2.0.0", sequence: []string{"select"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, err := newCheckTransactionFlowStep(newFlowCache(), nil, TransactionFlowConfig{})
+			if err != nil {
+				t.Fatalf("newCheckTransactionFlowStep() error = %v", err)
+			}
+			var last error
+			for i, action := range tt.sequence {
+				last = step.Run(flowCtx(tt.version, "retail", action))
+				if last != nil && i < len(tt.sequence)-1 {
+					t.Fatalf("Run(%s) unexpected error: %v", action, last)
+				}
+			}
+			assertAuthorizeErr(t, last, http.StatusBadRequest, tt.wantCode)
+		})
+	}
+}
+
+func TestCheckTransactionFlowStep_MonitorOnly(t *testing.T) {
+	cache := newFlowCache()
+	step, err := newCheckTransactionFlowStep(cache, nil, TransactionFlowConfig{MonitorOnly: true, StateTTL: time.Hour})
+	if err != nil {
+		t.Fatalf("newCheckTransactionFlowStep() error = %v", err)
+	}
+	if err := step.Run(flowCtx("2.0.0", "retail", "confirm")); err != nil {
+		t.Fatalf("Run() error = %v, want nil in monitor-only mode", err)
+	}
+	// The out-of-order action is still recorded, so its callback passes.
+	if err := step.Run(flowCtx("2.0.0", "retail", "on_confirm")); err != nil {
+		t.Fatalf("Run(on_confirm) error = %v", err)
+	}
+	key := transactionFlowKey("txn-1")
+	if got := cache.values[key]; got != "confirm,on_confirm" {
+		t.Errorf("cached state = %q, want %q", got, "confirm,on_confirm")
+	}
+	if got := cache.ttls[key]; got != time.Hour {
+		t.Errorf("cached TTL = %v, want 1h", got)
+	}
+}
+
+func TestCheckTransactionFlowStep_ConfiguredFlows(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "flows.yaml")
+	if err := os.WriteFile(file, []byte("mobility:\n  confirm: [on_search]\nretail:\n  confirm: [select]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := TransactionFlowConfig{
+		FlowsFile: file,
+		Flows: map[string]TransactionFlow{
+			"retail": {"confirm": {"init"}},
+			"*":      {},
+		},
+	}
+	tests := []struct {
+		name     string
+		domain   string
+		sequence []string
+		wantCode string
+	}{
+		{name: "file flow", domain: "mobility", sequence: []string{"on_search", "confirm"}},
+		{name: "file flow rejects", domain: "mobility", sequence: []string{"select", "confirm"}, wantCode: "BIZ_ACTION_OUT_OF_SEQUENCE"},
+		{name: "inline replaces file", domain: "retail", sequence: []string{"select", "confirm"}, wantCode: "BIZ_ACTION_OUT_OF_SEQUENCE"},
+		{name: "wildcard replaces defaults", domain: "logistics", sequence: []string{"on_cancel"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, err := newCheckTransactionFlowStep(newFlowCache(), nil, cfg)
+			if err != nil {
+				t.Fatalf("newCheckTransactionFlowStep() error = %v", err)
+			}
+			var last error
+			for _, action := range tt.sequence {
+				last = step.Run(flowCtx("2.0.0", tt.domain, action))
+			}
+			assertAuthorizeErr(t, last, http.StatusBadRequest, tt.wantCode)
+		})
+	}
+}
+
+func TestCheckTransactionFlowStep_CacheError(t *testing.T) {
+	cache := newFlowCache()
+	step, err := newCheckTransactionFlowStep(cache, nil, TransactionFlowConfig{})
+	if err != nil {
+		t.Fatalf("newCheckTransactionFlowStep() error = %v", err)
+	}
+	if err := step.Run(flowCtx("2.0.0", "retail", "select")); err != nil {
+		t.Fatalf("Run(select) error = %v", err)
+	}
+
+	cache.getErr = errors.New("redis: connection refused")
+	err = step.Run(flowCtx("2.0.0", "retail", "init"))
+	var coded *model.CodedErr
+	if err == nil || errors.As(err, &coded) {
+		t.Fatalf("Run() error = %v, want an uncoded cache error rather than a NACK", err)
+	}
+	if got := cache.values[transactionFlowKey("txn-1")]; got != "select" {
+		t.Errorf("cached state = %q, want %q left intact", got, "select")
+	}
+}
+
+func TestCheckTransactionFlowStep_PayloadStore(t *testing.T) {
+	store := &flowPayloadStore{entries: []definition.PayloadEntry{
+		{Action: "init", Kind: definition.PayloadKindRequest},
+		{Action: "confirm", Kind: definition.PayloadKindResponse},
+	}}
+	step, err := newCheckTransactionFlowStep(nil, store, TransactionFlowConfig{})
+	if err != nil {
+		t.Fatalf("newCheckTransactionFlowStep() error = %v", err)
+	}
+	if err := step.Run(flowCtx("2.0.0", "retail", "confirm")); err != nil {
+		t.Errorf("Run(confirm) error = %v", err)
+	}
+	// A stored response does not count as the action having been sent.
+	assertAuthorizeErr(t, step.Run(flowCtx("2.0.0", "retail", "on_confirm")), http.StatusBadRequest, "BIZ_ACTION_OUT_OF_SEQUENCE")
+
+	store.err = errors.New("db down")
+	if err := step.Run(flowCtx("2.0.0", "retail", "confirm")); err == nil {
+		t.Error("Run() error = nil, want error when the PayloadStore fails")
+	}
+}
+
+func TestCheckTransactionFlowStep_SkipsWithoutTransactionID(t *testing.T) {
+	step, err := newCheckTransactionFlowStep(newFlowCache(), nil, TransactionFlowConfig{})
+	if err != nil {
+		t.Fatalf("newCheckTransactionFlowStep() error = %v", err)
+	}
+	ctx := &model.StepContext{Context: context.Background(), Body: []byte(`{"context":{"action":"confirm"}}`)}
+	if err := step.Run(ctx); err != nil {
+		t.Errorf("Run() error = %v, want nil", err)
+	}
+	legacy := &model.StepContext{Context: context.Background(), Body: []byte(`{"context":{"version":"1.1.0","action":"confirm","transaction_id":"t"}}`)}
+	assertAuthorizeErr(t, step.Run(legacy), http.StatusBadRequest, "BIZ_ACTION_OUT_OF_SEQUENCE")
+}
+
+func TestNewCheckTransactionFlowStep_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		cache definition.Cache
+		store definition.PayloadStore
+		cfg   TransactionFlowConfig
+	}{
+		{name: "no state plugin"},
+		{name: "cache store without cache", store: &stubPayloadStore{}, cfg: TransactionFlowConfig{Store: "cache"}},
+		{name: "payloadStore without PayloadStore", cache: newFlowCache(), cfg: TransactionFlowConfig{Store: "payloadStore"}},
+		{name: "unknown store", cache: newFlowCache(), cfg: TransactionFlowConfig{Store: "redis"}},
+		{name: "missing flows file", cache: newFlowCache(), cfg: TransactionFlowConfig{FlowsFile: "/nonexistent/flows.yaml"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newCheckTransactionFlowStep(tt.cache, tt.store, tt.cfg); err == nil {
+				t.Error("newCheckTransactionFlowStep() error = nil, want error")
+			}
+		})
+	}
+}
@@ -23,8 +23,9 @@ type Provider func(ctx context.Context, mgr handler.PluginManager, cfg *handler.
 
 // handlerProviders maintains a mapping of handler types to their respective providers.
 var handlerProviders = map[handler.Type]Provider{
-	handler.HandlerTypeStd:            handler.NewStdHandler,
-	handler.HandlerTypeCatalogPublish: handler.NewCatalogPublishHandler,
+	handler.HandlerTypeStd:                handler.NewStdHandler,
+	handler.HandlerTypeCatalogPublish:     handler.NewCatalogPublishHandler,
+	handler.HandlerTypeTransactionHistory: handler.NewTransactionHistoryHandler,
 }
 
 // Register initializes and registers handlers based on the provided configuration.
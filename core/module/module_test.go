@@ -93,6 +93,14 @@ func (m *mockPluginManager) CatalogPublisher(_ context.Context, _ definition.Key
 	return nil, nil
 }
 
+func (m *mockPluginManager) Encryptor(_ context.Context, _ *plugin.Config) (definition.Encrypter, error) {
+	return nil, nil
+}
+
+func (m *mockPluginManager) Decryptor(_ context.Context, _ *plugin.Config) (definition.Decrypter, error) {
+	return nil, nil
+}
+
 func (m *mockPluginManager) SchemaVersionMediator(_ context.Context, _ definition.ManifestLoader, _ *plugin.Config) (definition.SchemaVersionMediator, error) {
 	return nil, nil
 }
@@ -19,7 +19,7 @@ require (
 
 require github.com/zenazn/pkcs7pad v0.0.0-20170308005700-253a5b1f0e03
 
-require golang.org/x/text v0.40.0 // indirect
+require golang.org/x/text v0.40.0
 
 require (
 	github.com/agnivade/levenshtein v1.2.1 // indirect
@@ -101,6 +101,7 @@ require (
 	github.com/jackc/pgx/v5 v5.10.0
 	github.com/jsonata-go/jsonata v0.0.0-20250709164031-599f35f32e5f
 	github.com/lestrrat-go/jwx/v3 v3.1.0
+	github.com/mattn/go-sqlite3 v1.14.33
 	github.com/open-policy-agent/opa v1.15.2
 	github.com/prometheus/client_golang v1.23.2
 	github.com/rabbitmq/amqp091-go v1.11.0
@@ -26,6 +26,7 @@ plugins=(
     "encrypter"
     "keymanager"
     "simplekeymanager"
+    "filekeystore"
     "localcatalogblobstore"
     "publisher"
     "registry"
@@ -41,6 +42,7 @@ plugins=(
     "signvalidator"
     "opapolicychecker"
     "payloadstore"
+    "sqlpayloadstore"
     "schemaversionmediator"
     "catalogpublisher"
     "catalogcrawler"
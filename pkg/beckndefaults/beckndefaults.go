@@ -64,6 +64,12 @@ func Load(ctx context.Context, disableRemoteRefresh bool) (*BecknConstants, erro
 	return bc, nil
 }
 
+// PublicKeyPEM returns the embedded beckn signing public key, for verifying
+// other beckn-signed artifacts such as schema bundles.
+func PublicKeyPEM() []byte {
+	return append([]byte(nil), becknPublicKeyPEM...)
+}
+
 func loadAndVerify(constants, sig []byte) (*BecknConstants, error) {
 	if err := artifactverifier.VerifyDetachedArtifact(constants, sig, becknPublicKeyPEM); err != nil {
 		return nil, fmt.Errorf("signature verification: %w", err)
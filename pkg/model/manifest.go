@@ -20,15 +20,19 @@ type ManifestMetadata struct {
 
 // ManifestDocument is the cached and returned verified manifest payload.
 type ManifestDocument struct {
-	NetworkID    string    `json:"network_id,omitempty"`
-	SubscriberID string    `json:"subscriber_id,omitempty"`
-	ContentType  string    `json:"content_type,omitempty"`
-	Content      []byte    `json:"content"`
-	Digest       string    `json:"digest"`
-	SourceURL    string    `json:"source_url"`
-	SignatureURL string    `json:"signature_url"`
-	Verified     bool      `json:"verified"`
-	FetchedAt    time.Time `json:"fetched_at"`
+	NetworkID    string `json:"network_id,omitempty"`
+	SubscriberID string `json:"subscriber_id,omitempty"`
+	ContentType  string `json:"content_type,omitempty"`
+	Content      []byte `json:"content"`
+	Digest       string `json:"digest"`
+	SourceURL    string `json:"source_url"`
+	SignatureURL string `json:"signature_url"`
+	// SigningPublicKeyURL is the lookup URL of the key the signature was
+	// verified against, so other artifacts from the same signer can be
+	// checked with it.
+	SigningPublicKeyURL string    `json:"signing_public_key_url,omitempty"`
+	Verified            bool      `json:"verified"`
+	FetchedAt           time.Time `json:"fetched_at"`
 }
 
 const (
@@ -53,6 +57,7 @@ type NetworkManifest struct {
 	Publisher       NetworkManifestPublisher  `yaml:"publisher"`
 	Policies        *NetworkManifestPolicies  `yaml:"policies"`
 	Governance      NetworkManifestGovernance `yaml:"governance"`
+	CredentialTrust *CredentialTrustPolicy    `yaml:"credentialTrust"`
 }
 
 // NetworkManifestPublisher identifies the organization publishing the manifest.
@@ -198,6 +203,74 @@ func (m *NetworkManifest) Validate(expectedNetworkID string, now time.Time) erro
 		return fmt.Errorf("manifest for network %q uses unsupported policies.source %q", expectedNetworkID, m.Policies.Source)
 	}
 
+	if m.CredentialTrust != nil {
+		if err := m.CredentialTrust.Validate(); err != nil {
+			return fmt.Errorf("manifest for network %q has invalid credentialTrust: %w", expectedNetworkID, err)
+		}
+	}
+
+	return nil
+}
+
+// Subjects a required credential can be bound to.
+const (
+	// CredentialSubjectSigner binds credentialSubject.id to the subscriber that signed the request.
+	CredentialSubjectSigner = "signer"
+	// CredentialSubjectBAP binds credentialSubject.id to the context's bap_id.
+	CredentialSubjectBAP = "bap_id"
+	// CredentialSubjectBPP binds credentialSubject.id to the context's bpp_id.
+	CredentialSubjectBPP = "bpp_id"
+)
+
+// CredentialTrustPolicy says which issuers a network trusts for each
+// Verifiable Credential type, and which credentials each action must carry.
+// It is the credentialTrust section of a network manifest and, standalone,
+// the format of a validateVC trustFile.
+type CredentialTrustPolicy struct {
+	// Issuers maps a credential type to the issuer DIDs allowed to issue it.
+	// The "*" entry applies to types without an entry of their own; types
+	// covered by neither are accepted from any issuer.
+	Issuers map[string][]string `yaml:"issuers"`
+	// RequiredCredentials maps a beckn action to the credentials its
+	// payload must carry.
+	RequiredCredentials map[string][]RequiredCredential `yaml:"requiredCredentials"`
+}
+
+// RequiredCredential is one credential an action must carry. Subject, when
+// set, is one of the CredentialSubject* values and names the participant
+// the credential must be about.
+type RequiredCredential struct {
+	Type    string `yaml:"type"`
+	Subject string `yaml:"subject,omitempty"`
+}
+
+// Validate checks the policy for empty entries and unknown subjects.
+func (p *CredentialTrustPolicy) Validate() error {
+	for credType, issuers := range p.Issuers {
+		if strings.TrimSpace(credType) == "" {
+			return fmt.Errorf("issuers has an empty credential type")
+		}
+		if len(issuers) == 0 {
+			return fmt.Errorf("issuers[%q] lists no issuer DIDs", credType)
+		}
+		for _, did := range issuers {
+			if !strings.HasPrefix(did, "did:") {
+				return fmt.Errorf("issuers[%q] entry %q is not a DID", credType, did)
+			}
+		}
+	}
+	for action, reqs := range p.RequiredCredentials {
+		for i, req := range reqs {
+			if strings.TrimSpace(req.Type) == "" {
+				return fmt.Errorf("requiredCredentials[%q][%d] is missing type", action, i)
+			}
+			switch req.Subject {
+			case "", CredentialSubjectSigner, CredentialSubjectBAP, CredentialSubjectBPP:
+			default:
+				return fmt.Errorf("requiredCredentials[%q][%d] has unknown subject %q", action, i, req.Subject)
+			}
+		}
+	}
 	return nil
 }
 
@@ -113,6 +113,37 @@ func TestValidateNetworkManifest(t *testing.T) {
 			},
 			wantErrSub: "requires policies.bundle.signingPublicKeyLookupUrl",
 		},
+		{
+			name: "valid credential trust",
+			mutate: func(manifest *NetworkManifest) {
+				manifest.CredentialTrust = &CredentialTrustPolicy{
+					Issuers: map[string][]string{"GSTCredential": {"did:web:gst.example.gov"}},
+					RequiredCredentials: map[string][]RequiredCredential{
+						"confirm": {{Type: "GSTCredential", Subject: CredentialSubjectBAP}},
+					},
+				}
+			},
+		},
+		{
+			name: "credential trust issuer is not a DID",
+			mutate: func(manifest *NetworkManifest) {
+				manifest.CredentialTrust = &CredentialTrustPolicy{
+					Issuers: map[string][]string{"GSTCredential": {"gst.example.gov"}},
+				}
+			},
+			wantErrSub: `invalid credentialTrust: issuers["GSTCredential"] entry "gst.example.gov" is not a DID`,
+		},
+		{
+			name: "credential trust unknown subject",
+			mutate: func(manifest *NetworkManifest) {
+				manifest.CredentialTrust = &CredentialTrustPolicy{
+					RequiredCredentials: map[string][]RequiredCredential{
+						"confirm": {{Type: "GSTCredential", Subject: "buyer"}},
+					},
+				}
+			},
+			wantErrSub: `has unknown subject "buyer"`,
+		},
 	}
 
 	for _, tt := range tests {
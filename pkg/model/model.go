@@ -326,6 +326,8 @@ type StepContext struct {
 	MessageID            string // Message ID parsed from context.messageId in the request body
 	InboundAuthSignature string // Raw Base64 signature from the inbound Authorization header's signature="..." attribute
 	IsCallerHandler      bool   // True when the handler is a Caller (outbound); false for Receiver (inbound)
+	SignerID             string // Subscriber ID of the verified request signer, set by the validateSign step
+	SignerKeyID          string // Unique key ID the verified request signer signed with, set by the validateSign step
 }
 
 // WithContext updates the existing StepContext with a new context.
@@ -347,6 +349,7 @@ type ResponseStepContext struct {
 	StatusCode int
 	Header     http.Header // shared reference — step mutations visible to caller
 	Body       []byte      // pre-read response body; nil on publisher path
+	Replaced   bool        // true once ONIX replaced an invalid upstream body with its own NACK
 }
 
 // Status represents the acknowledgment status in a response.
@@ -375,3 +378,25 @@ type Message struct {
 type Response struct {
 	Message Message `json:"message"`
 }
+
+// ParseResponseStatus returns the ACK/NACK status of a synchronous response
+// body, reading both the v2 envelope ({"message":{"status":...}}) and the
+// pre-v2 one ({"message":{"ack":{"status":...}}}). It returns "" when the
+// body carries neither.
+func ParseResponseStatus(body []byte) Status {
+	var resp struct {
+		Message struct {
+			Status Status `json:"status"`
+			Ack    struct {
+				Status Status `json:"status"`
+			} `json:"ack"`
+		} `json:"message"`
+	}
+	if json.Unmarshal(body, &resp) != nil {
+		return ""
+	}
+	if resp.Message.Status != "" {
+		return resp.Message.Status
+	}
+	return resp.Message.Ack.Status
+}
@@ -305,3 +305,24 @@ func TestWrapExtractContextErr(t *testing.T) {
 		}
 	})
 }
+
+func TestParseResponseStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want Status
+	}{
+		{"v2 ACK", `{"message":{"status":"ACK","messageId":"m1"}}`, StatusACK},
+		{"v2 NACK", `{"message":{"status":"NACK","error":{"code":"X"}}}`, StatusNACK},
+		{"pre-v2 ACK", `{"message":{"ack":{"status":"ACK"}}}`, StatusACK},
+		{"no status", `{"message":{}}`, ""},
+		{"not JSON", `<html>`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseResponseStatus([]byte(tt.body)); got != tt.want {
+				t.Errorf("ParseResponseStatus() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
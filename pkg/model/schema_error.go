@@ -0,0 +1,264 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxSchemaErrors caps the number of errors a SchemaValidationErr built by
+// ShapeSchemaErrors carries. Failures past the cap are counted in one
+// trailing summary entry, so a badly malformed payload cannot produce an
+// unbounded NACK.
+const MaxSchemaErrors = 20
+
+// maxSchemaErrorMessageBytes bounds each shaped error's Message. Library
+// messages can embed schema fragments or values; anything longer is cut at a
+// rune boundary and marked as truncated.
+const maxSchemaErrorMessageBytes = 512
+
+// schemaFailedCode is the SCH_* code for a failure whose keyword has no more
+// specific one: composite keywords and constraints like pattern or minimum.
+const schemaFailedCode = "SCH_SCHEMA_VALIDATION_FAILED"
+
+// SchemaFailure is one failure as reported by a schema validation library,
+// before ShapeSchemaErrors ranks, deduplicates and bounds it. Each validator
+// translates its library's error tree into SchemaFailures; the shaping rules
+// are shared so both validators answer a bad payload the same way.
+type SchemaFailure struct {
+	// Location is the failing value's position in the validated document as
+	// unescaped RFC 6901 reference tokens, e.g. ["message", "order", "id"].
+	// A "required" failure points at the missing member.
+	Location []string
+	// Keyword is the JSON Schema keyword that failed, e.g. "required". It
+	// selects the SCH_* code (see SchemaKeywordCode) and the failure's rank
+	// among others at the same Location.
+	Keyword string
+	// Code, when set, is reported instead of the one Keyword maps to — for
+	// failures the validator has already classified itself.
+	Code    string
+	Message string
+	// Alternatives holds, for a failed oneOf or anyOf, the failures of each
+	// branch. ShapeSchemaErrors reports the branch that got furthest into the
+	// value in place of the composite failure, or the composite failure alone
+	// when no branch stands out.
+	Alternatives [][]SchemaFailure
+	// Cause is the library error the failure came from, kept reachable
+	// through the shaped Error's Unwrap.
+	Cause error
+}
+
+// schemaKeywordCodes maps a failed JSON Schema keyword to the corresponding
+// Beckn v2.0.0 SCH_* code.
+var schemaKeywordCodes = map[string]string{
+	"required": "SCH_REQUIRED_FIELD_MISSING",
+	// kin-openapi uses "properties" specifically for the
+	// additionalProperties-disallowed case ("property %q is unsupported").
+	"properties":            "SCH_FIELD_NOT_ALLOWED",
+	"additionalProperties":  "SCH_FIELD_NOT_ALLOWED",
+	"unevaluatedProperties": "SCH_FIELD_NOT_ALLOWED",
+	"enum":                  "SCH_INVALID_ENUM",
+	// "const" is JSON Schema's own sugar for an enum with a single allowed
+	// value — there is no dedicated SCH_* code for it, so it shares enum's.
+	"const":  "SCH_INVALID_ENUM",
+	"format": "SCH_INVALID_FORMAT",
+	"type":   "SCH_TYPE_NOT_SUPPORTED",
+}
+
+// SchemaKeywordCode returns the SCH_* code for a failed JSON Schema keyword.
+// Composite keywords (oneOf/anyOf/allOf) and any constraint without a
+// dedicated code fall back to SCH_SCHEMA_VALIDATION_FAILED.
+func SchemaKeywordCode(keyword string) string {
+	if code, ok := schemaKeywordCodes[keyword]; ok {
+		return code
+	}
+	return schemaFailedCode
+}
+
+// schemaKeywordRank orders failures at the same location from most to least
+// specific. A wrong type makes every other constraint on that value moot, a
+// missing or unexpected member is more actionable than a bad value, and an
+// unresolved oneOf/anyOf says least of all. Unlisted keywords rank just
+// above the composites.
+var schemaKeywordRank = map[string]int{
+	"type":                  0,
+	"required":              1,
+	"properties":            2,
+	"additionalProperties":  2,
+	"unevaluatedProperties": 2,
+	"enum":                  3,
+	"const":                 3,
+	"format":                4,
+	"oneOf":                 6,
+	"anyOf":                 6,
+}
+
+func keywordRank(keyword string) int {
+	if rank, ok := schemaKeywordRank[keyword]; ok {
+		return rank
+	}
+	return 5
+}
+
+// JSONPointer renders reference tokens as an RFC 6901 JSON Pointer. No tokens
+// is the whole document, "".
+func JSONPointer(tokens []string) string {
+	var sb strings.Builder
+	for _, tok := range tokens {
+		sb.WriteByte('/')
+		sb.WriteString(strings.ReplaceAll(strings.ReplaceAll(tok, "~", "~0"), "/", "~1"))
+	}
+	return sb.String()
+}
+
+// ShapeSchemaErrors reduces the failures a schema library reported to the
+// errors worth sending back:
+//   - a failed oneOf/anyOf is replaced by its most plausible branch's
+//     failures, so a payload that nearly matched one alternative is not
+//     buried under the failures of all the others;
+//   - at each location only the most specific failures are kept (see
+//     schemaKeywordRank) and exact duplicates are dropped;
+//   - errors are ordered by location, paths are RFC 6901 pointers into the
+//     validated document, and messages are bounded;
+//   - at most MaxSchemaErrors are returned, plus one entry counting the rest.
+func ShapeSchemaErrors(failures []SchemaFailure) *SchemaValidationErr {
+	resolved := resolveAlternatives(failures)
+
+	// Keep the best-ranked failures per location, in first-seen order.
+	type group struct {
+		location []string
+		rank     int
+		failures []SchemaFailure
+	}
+	var groups []*group
+	byPointer := make(map[string]*group)
+	for _, f := range resolved {
+		ptr := JSONPointer(f.Location)
+		rank := keywordRank(f.Keyword)
+		g, ok := byPointer[ptr]
+		if !ok {
+			g = &group{location: f.Location, rank: rank}
+			byPointer[ptr] = g
+			groups = append(groups, g)
+		}
+		switch {
+		case rank < g.rank:
+			g.rank, g.failures = rank, []SchemaFailure{f}
+		case rank == g.rank:
+			g.failures = append(g.failures, f)
+		}
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return compareLocations(groups[i].location, groups[j].location) < 0
+	})
+
+	var errs []Error
+	seen := make(map[string]bool)
+	for _, g := range groups {
+		ptr := JSONPointer(g.location)
+		for _, f := range g.failures {
+			code := f.Code
+			if code == "" {
+				code = SchemaKeywordCode(f.Keyword)
+			}
+			msg := truncateSchemaMessage(f.Message)
+			key := ptr + "\x00" + code + "\x00" + msg
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			errs = append(errs, *NewCodedErrorWithCause(code, msg, ptr, f.Cause))
+		}
+	}
+
+	if omitted := len(errs) - MaxSchemaErrors; omitted > 0 {
+		errs = append(errs[:MaxSchemaErrors], *NewCodedError(schemaFailedCode,
+			fmt.Sprintf("%d further schema errors not shown", omitted)))
+	}
+	return &SchemaValidationErr{Errors: errs}
+}
+
+// resolveAlternatives flattens failures, replacing each failed oneOf/anyOf
+// that carries its branches' failures with those of its best branch.
+func resolveAlternatives(failures []SchemaFailure) []SchemaFailure {
+	var out []SchemaFailure
+	for _, f := range failures {
+		if len(f.Alternatives) == 0 {
+			out = append(out, f)
+			continue
+		}
+		if best := bestAlternative(f); best != nil {
+			out = append(out, best...)
+			continue
+		}
+		f.Alternatives = nil
+		out = append(out, f)
+	}
+	return out
+}
+
+// bestAlternative picks the branch of a failed composite whose failures lie
+// deepest in the value — it matched the most structure before failing — and,
+// between equally deep branches, the one with fewer failures. It returns nil
+// when no branch goes deeper than the composite itself, or when the top two
+// branches tie, since either way the payload gives no hint which alternative
+// was meant.
+func bestAlternative(f SchemaFailure) []SchemaFailure {
+	var best []SchemaFailure
+	bestDepth, bestCount, tied := -1, 0, false
+	for _, branch := range f.Alternatives {
+		resolved := resolveAlternatives(branch)
+		if len(resolved) == 0 {
+			continue
+		}
+		depth := 0
+		for _, bf := range resolved {
+			depth = max(depth, len(bf.Location))
+		}
+		switch {
+		case depth > bestDepth || (depth == bestDepth && len(resolved) < bestCount):
+			best, bestDepth, bestCount, tied = resolved, depth, len(resolved), false
+		case depth == bestDepth && len(resolved) == bestCount:
+			tied = true
+		}
+	}
+	if best == nil || tied || bestDepth <= len(f.Location) {
+		return nil
+	}
+	return best
+}
+
+// compareLocations orders locations token by token, numerically where both
+// tokens are array indexes, so /items/2 sorts before /items/10 and a parent
+// before its children.
+func compareLocations(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] == b[i] {
+			continue
+		}
+		ai, aErr := strconv.Atoi(a[i])
+		bi, bErr := strconv.Atoi(b[i])
+		if aErr == nil && bErr == nil {
+			if ai < bi {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a[i], b[i])
+	}
+	return len(a) - len(b)
+}
+
+// truncateSchemaMessage bounds msg to maxSchemaErrorMessageBytes.
+func truncateSchemaMessage(msg string) string {
+	if len(msg) <= maxSchemaErrorMessageBytes {
+		return msg
+	}
+	cut := maxSchemaErrorMessageBytes
+	for cut > 0 && !utf8.RuneStart(msg[cut]) {
+		cut--
+	}
+	return msg[:cut] + "… (truncated)"
+}
@@ -0,0 +1,221 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestJSONPointer(t *testing.T) {
+	tests := []struct {
+		tokens []string
+		want   string
+	}{
+		{nil, ""},
+		{[]string{"message", "order"}, "/message/order"},
+		{[]string{"items", "0", "id"}, "/items/0/id"},
+		{[]string{"a/b", "m~n"}, "/a~1b/m~0n"},
+		{[]string{"~1"}, "/~01"},
+		{[]string{""}, "/"},
+	}
+	for _, tt := range tests {
+		if got := JSONPointer(tt.tokens); got != tt.want {
+			t.Errorf("JSONPointer(%q) = %q, want %q", tt.tokens, got, tt.want)
+		}
+	}
+}
+
+func TestSchemaKeywordCode(t *testing.T) {
+	tests := []struct {
+		keyword string
+		want    string
+	}{
+		{"required", "SCH_REQUIRED_FIELD_MISSING"},
+		{"properties", "SCH_FIELD_NOT_ALLOWED"},
+		{"additionalProperties", "SCH_FIELD_NOT_ALLOWED"},
+		{"enum", "SCH_INVALID_ENUM"},
+		{"const", "SCH_INVALID_ENUM"},
+		{"format", "SCH_INVALID_FORMAT"},
+		{"type", "SCH_TYPE_NOT_SUPPORTED"},
+		{"allOf", "SCH_SCHEMA_VALIDATION_FAILED"},
+		{"oneOf", "SCH_SCHEMA_VALIDATION_FAILED"},
+		{"anyOf", "SCH_SCHEMA_VALIDATION_FAILED"},
+		{"pattern", "SCH_SCHEMA_VALIDATION_FAILED"},
+		{"", "SCH_SCHEMA_VALIDATION_FAILED"},
+		{"unknown-keyword", "SCH_SCHEMA_VALIDATION_FAILED"},
+	}
+	for _, tt := range tests {
+		if got := SchemaKeywordCode(tt.keyword); got != tt.want {
+			t.Errorf("SchemaKeywordCode(%q) = %s, want %s", tt.keyword, got, tt.want)
+		}
+	}
+}
+
+func TestShapeSchemaErrors_MostSpecificPerLocation(t *testing.T) {
+	got := ShapeSchemaErrors([]SchemaFailure{
+		{Location: []string{"order"}, Keyword: "pattern", Message: "bad pattern"},
+		{Location: []string{"order"}, Keyword: "type", Message: "want object"},
+		{Location: []string{"order", "id"}, Keyword: "required", Message: `property "id" is missing`},
+		{Location: []string{"order", "id"}, Keyword: "required", Message: `property "id" is missing`},
+	}).Errors
+
+	if len(got) != 2 {
+		t.Fatalf("got %d errors, want 2: %+v", len(got), got)
+	}
+	if got[0].Code != "SCH_TYPE_NOT_SUPPORTED" || got[0].Details.Path != "/order" {
+		t.Errorf("errors[0] = %+v, want the type failure at /order", got[0])
+	}
+	if got[1].Code != "SCH_REQUIRED_FIELD_MISSING" || got[1].Details.Path != "/order/id" {
+		t.Errorf("errors[1] = %+v, want one required failure at /order/id", got[1])
+	}
+}
+
+func TestShapeSchemaErrors_KeepsAllFailuresOfBestRank(t *testing.T) {
+	got := ShapeSchemaErrors([]SchemaFailure{
+		{Location: []string{"a"}, Keyword: "required", Message: "a missing"},
+		{Location: []string{"b"}, Keyword: "required", Message: "b missing"},
+	}).Errors
+	if len(got) != 2 {
+		t.Fatalf("got %d errors, want 2: %+v", len(got), got)
+	}
+}
+
+func TestShapeSchemaErrors_PicksDeepestOneOfBranch(t *testing.T) {
+	got := ShapeSchemaErrors([]SchemaFailure{{
+		Location: []string{"message", "payment"},
+		Keyword:  "oneOf",
+		Message:  `value doesn't match any schema from "oneOf"`,
+		Alternatives: [][]SchemaFailure{
+			{{Location: []string{"message", "payment"}, Keyword: "type", Message: "want string"}},
+			{{Location: []string{"message", "payment", "amount"}, Keyword: "type", Message: "want number"}},
+			{
+				{Location: []string{"message", "payment", "uri"}, Keyword: "required", Message: "uri missing"},
+				{Location: []string{"message", "payment", "tlMethod"}, Keyword: "required", Message: "tlMethod missing"},
+			},
+		},
+	}}).Errors
+
+	if len(got) != 1 {
+		t.Fatalf("got %d errors, want only the closest branch's failure: %+v", len(got), got)
+	}
+	if got[0].Details.Path != "/message/payment/amount" || got[0].Code != "SCH_TYPE_NOT_SUPPORTED" {
+		t.Errorf("errors[0] = %+v, want the type failure at /message/payment/amount", got[0])
+	}
+}
+
+func TestShapeSchemaErrors_AmbiguousOneOfReportsComposite(t *testing.T) {
+	got := ShapeSchemaErrors([]SchemaFailure{{
+		Location: []string{"value"},
+		Keyword:  "oneOf",
+		Message:  "no alternative matched",
+		Alternatives: [][]SchemaFailure{
+			{{Location: []string{"value"}, Keyword: "type", Message: "want string"}},
+			{{Location: []string{"value"}, Keyword: "type", Message: "want object"}},
+		},
+	}}).Errors
+
+	if len(got) != 1 || got[0].Message != "no alternative matched" || got[0].Code != "SCH_SCHEMA_VALIDATION_FAILED" {
+		t.Fatalf("got %+v, want the composite failure alone", got)
+	}
+}
+
+func TestShapeSchemaErrors_NestedAlternatives(t *testing.T) {
+	inner := SchemaFailure{
+		Location: []string{"a"},
+		Keyword:  "anyOf",
+		Alternatives: [][]SchemaFailure{
+			{{Location: []string{"a"}, Keyword: "type"}},
+			{{Location: []string{"a", "b", "c"}, Keyword: "enum", Message: "deep"}},
+		},
+	}
+	got := ShapeSchemaErrors([]SchemaFailure{{
+		Keyword: "oneOf",
+		Alternatives: [][]SchemaFailure{
+			{{Location: []string{"x"}, Keyword: "required"}},
+			{inner},
+		},
+	}}).Errors
+	if len(got) != 1 || got[0].Message != "deep" || got[0].Details.Path != "/a/b/c" {
+		t.Fatalf("got %+v, want the nested branch's deep failure", got)
+	}
+}
+
+func TestShapeSchemaErrors_OrdersByLocation(t *testing.T) {
+	got := ShapeSchemaErrors([]SchemaFailure{
+		{Location: []string{"items", "10"}, Keyword: "type"},
+		{Location: []string{"items", "2"}, Keyword: "type"},
+		{Location: []string{"context"}, Keyword: "type"},
+		{Keyword: "required", Message: "root"},
+	}).Errors
+
+	var paths []string
+	for _, e := range got {
+		if e.Details == nil {
+			paths = append(paths, "")
+			continue
+		}
+		paths = append(paths, e.Details.Path)
+	}
+	want := []string{"", "/context", "/items/2", "/items/10"}
+	if strings.Join(paths, ",") != strings.Join(want, ",") {
+		t.Errorf("paths = %q, want %q", paths, want)
+	}
+}
+
+func TestShapeSchemaErrors_CapsCount(t *testing.T) {
+	var failures []SchemaFailure
+	for i := 0; i < MaxSchemaErrors+7; i++ {
+		failures = append(failures, SchemaFailure{Location: []string{"items", fmt.Sprint(i)}, Keyword: "type", Message: "want object"})
+	}
+	got := ShapeSchemaErrors(failures).Errors
+
+	if len(got) != MaxSchemaErrors+1 {
+		t.Fatalf("got %d errors, want %d plus a summary", len(got), MaxSchemaErrors)
+	}
+	last := got[len(got)-1]
+	if last.Details != nil || !strings.Contains(last.Message, "7 further schema errors") {
+		t.Errorf("summary = %+v, want a pathless count of the 7 omitted errors", last)
+	}
+}
+
+func TestShapeSchemaErrors_BoundsMessage(t *testing.T) {
+	long := strings.Repeat("é", maxSchemaErrorMessageBytes)
+	got := ShapeSchemaErrors([]SchemaFailure{{Keyword: "type", Message: long}}).Errors
+
+	msg := got[0].Message
+	if len(msg) > maxSchemaErrorMessageBytes+len("… (truncated)") {
+		t.Errorf("message is %d bytes, want at most %d plus the marker", len(msg), maxSchemaErrorMessageBytes)
+	}
+	if !strings.HasSuffix(msg, "… (truncated)") || !strings.HasPrefix(msg, "éé") {
+		t.Errorf("message = %q, want a rune-aligned prefix with a truncation marker", msg[:16])
+	}
+}
+
+func TestShapeSchemaErrors_PresetCodeAndCause(t *testing.T) {
+	cause := errors.New("domain not allowed")
+	got := ShapeSchemaErrors([]SchemaFailure{
+		{Location: []string{"message", "order"}, Code: "SCH_INVALID_JSONLD_CONTEXT", Message: cause.Error(), Cause: cause},
+	}).Errors
+
+	if got[0].Code != "SCH_INVALID_JSONLD_CONTEXT" {
+		t.Errorf("Code = %s, want the preset SCH_INVALID_JSONLD_CONTEXT", got[0].Code)
+	}
+	if !errors.Is(&got[0], cause) {
+		t.Error("shaped error should unwrap to its cause")
+	}
+}
+
+func TestShapeSchemaErrors_BecknError(t *testing.T) {
+	be := ShapeSchemaErrors([]SchemaFailure{
+		{Location: []string{"context", "action"}, Keyword: "required", Message: `property "action" is missing`},
+		{Location: []string{"message", "order", "items", "0", "id"}, Keyword: "type", Message: "want string"},
+	}).BecknError()
+
+	if be.Code != "SCH_REQUIRED_FIELD_MISSING" {
+		t.Errorf("Code = %s, want SCH_REQUIRED_FIELD_MISSING", be.Code)
+	}
+	if be.Details == nil || be.Details.Path != "/context/action;/message/order/items/0/id" {
+		t.Errorf("Details = %+v, want both pointers", be.Details)
+	}
+}
@@ -7,7 +7,29 @@ import (
 	"github.com/beckn-one/beckn-onix/pkg/model"
 )
 
+// PayloadKind tells a stored Beckn request from a stored synchronous response.
+type PayloadKind string
+
+const (
+	// PayloadKindRequest is a Beckn request, including on_* callbacks.
+	PayloadKindRequest PayloadKind = "request"
+	// PayloadKindResponse is the synchronous ACK/NACK returned for a request.
+	PayloadKindResponse PayloadKind = "response"
+)
+
+// PayloadDirection is whether this node received or sent a payload.
+type PayloadDirection string
+
+const (
+	PayloadInbound  PayloadDirection = "inbound"
+	PayloadOutbound PayloadDirection = "outbound"
+)
+
 // PayloadEntry is a single stored record for one BECKN message.
+//
+// A request and the response to it share MessageID, TransactionID and
+// Action (the request's); Kind tells them apart. Entries stored before Kind
+// existed read back with an empty Kind and are requests.
 type PayloadEntry struct {
 	MessageID     string
 	TransactionID string
@@ -15,10 +37,18 @@ type PayloadEntry struct {
 	Action        string
 	SubscriberID  string
 	Role          model.Role
-	RequestBody   []byte    // nil when StoreBody: false
-	Signature     string    // raw Authorization header; empty when StoreSignature: false
+	Kind          PayloadKind
+	Direction     PayloadDirection
+	RequestBody   []byte    // nil when StoreBody: false, and for responses
+	Signature     string    // raw Authorization header, or a response's Signature header; empty when StoreSignature: false
 	StoredAt      time.Time
 	ExpiresAt     time.Time
+
+	// Response fields, set only when Kind is PayloadKindResponse.
+	Status          model.Status      // ACK or NACK from the body; empty if it has neither
+	StatusCode      int               // HTTP status
+	ResponseBody    []byte            // nil when StoreBody: false
+	ResponseHeaders map[string]string // first value of each header, Signature excluded
 }
 
 // PayloadStore persists and retrieves payload entries indexed by message and transaction IDs.
@@ -26,11 +56,12 @@ type PayloadStore interface {
 	// Store persists an entry built from the incoming request's StepContext.
 	Store(ctx *model.StepContext) error
 
-	// GetByTransactionID returns all entries for a transaction in StoredAt ascending order.
+	// GetByTransactionID returns all entries for a transaction — requests,
+	// callbacks and stored responses — in StoredAt ascending order.
 	// Returns nil (not an error) if the transaction is unknown or expired.
 	GetByTransactionID(ctx context.Context, transactionID string) ([]PayloadEntry, error)
 
-	// GetByMessageID returns the entry for the given message ID scoped to an action.
+	// GetByMessageID returns the request entry for the given message ID scoped to an action.
 	// Returns nil (not an error) if not found or if the action does not match.
 	GetByMessageID(ctx context.Context, messageID, action string) (*PayloadEntry, error)
 
@@ -38,7 +69,38 @@ type PayloadStore interface {
 	Exists(ctx context.Context, messageID string) (bool, error)
 }
 
+// ResponsePayloadStore is implemented by payload stores that can also record
+// the synchronous response to a request, linked to it by message and
+// transaction ID.
+type ResponsePayloadStore interface {
+	// StoreResponse persists rctx as a PayloadKindResponse entry for the
+	// request in ctx.Body.
+	StoreResponse(ctx *model.StepContext, rctx *model.ResponseStepContext) error
+}
+
 // PayloadStoreProvider is the plugin constructor interface.
 type PayloadStoreProvider interface {
 	New(ctx context.Context, cache Cache, namespace string, cfg map[string]string) (PayloadStore, func() error, error)
 }
+
+// PayloadQuery selects stored entries for PayloadQuerier.Query. Empty fields
+// and zero times do not filter.
+type PayloadQuery struct {
+	SubscriberID  string
+	Action        string
+	NetworkID     string
+	TransactionID string
+	Kind          PayloadKind
+	From          time.Time // inclusive lower bound on StoredAt
+	To            time.Time // exclusive upper bound on StoredAt
+	// Limit caps the number of entries returned; zero means the store's
+	// default.
+	Limit int
+}
+
+// PayloadQuerier is implemented by payload stores that can search their
+// history beyond message and transaction ID lookups.
+type PayloadQuerier interface {
+	// Query returns the entries matching q in StoredAt ascending order.
+	Query(ctx context.Context, q PayloadQuery) ([]PayloadEntry, error)
+}
@@ -15,3 +15,28 @@ type PolicyChecker interface {
 type PolicyCheckerProvider interface {
 	New(ctx context.Context, manifestLoader ManifestLoader, config map[string]string) (PolicyChecker, func(), error)
 }
+
+// RegistryAwarePolicyChecker is implemented by policy checkers that can add
+// the sender's registry record to the policy input. The handler passes its
+// RegistryLookup (nil when none is configured) right after loading the
+// checker; an error fails handler initialisation.
+type RegistryAwarePolicyChecker interface {
+	SetRegistryLookup(RegistryLookup) error
+}
+
+// PayloadStoreAwarePolicyChecker is implemented by policy checkers that can
+// add earlier messages of the transaction to the policy input. The handler
+// passes its PayloadStore (nil when none is configured) right after loading
+// the checker; an error fails handler initialisation.
+type PayloadStoreAwarePolicyChecker interface {
+	SetPayloadStore(PayloadStore) error
+}
+
+// ResponsePolicyChecker is implemented by policy checkers that can also check
+// the synchronous ACK/NACK body an upstream returns for a request. ctx is the
+// request's StepContext; rctx carries the upstream response. A response the
+// policy rejects is reported as a 4xx *model.CodedErr; any other error means
+// the policy could not be checked.
+type ResponsePolicyChecker interface {
+	CheckResponsePolicy(ctx *model.StepContext, rctx *model.ResponseStepContext) error
+}
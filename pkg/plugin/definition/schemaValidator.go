@@ -14,3 +14,21 @@ type SchemaValidator interface {
 type SchemaValidatorProvider interface {
 	New(ctx context.Context, config map[string]string) (SchemaValidator, func() error, error)
 }
+
+// ManifestAwareSchemaValidator is implemented by schema validators that read
+// node manifests. The handler passes its ManifestLoader (nil when none is
+// configured) to such a validator right after building it; an error fails
+// handler initialisation.
+type ManifestAwareSchemaValidator interface {
+	SetManifestLoader(ManifestLoader) error
+}
+
+// ResponseSchemaValidator is implemented by schema validators that can also
+// validate the synchronous ACK/NACK body an upstream returns for a request.
+// action is the request's context.action and statusCode the upstream's HTTP
+// status; together they select the response schema. An invalid response is
+// reported as a *model.SchemaValidationErr or a 4xx *model.CodedErr; any
+// other error means the response could not be validated.
+type ResponseSchemaValidator interface {
+	ValidateResponse(ctx context.Context, action string, statusCode int, payload []byte) error
+}
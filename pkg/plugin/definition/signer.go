@@ -1,6 +1,10 @@
 package definition
 
-import "context"
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
 
 // Signer defines the method for signing.
 type Signer interface {
@@ -20,6 +24,23 @@ type Signer interface {
 	SignAck(ctx context.Context, ackBody []byte, requestSignature, privateKeyBase64 string, createdAt, expiresAt int64) (string, error)
 }
 
+// HTTPMessageSigner is implemented by Signer plugins that can also sign
+// requests with RFC 9421 HTTP Message Signatures. The sign step type-asserts
+// the configured Signer for it; plugins that do not implement it only ever
+// produce the Beckn Authorization header.
+type HTTPMessageSigner interface {
+	// UseHTTPMessageSignatures reports whether a request for the given
+	// protocol version (context.version) is signed with RFC 9421 instead of
+	// the Beckn Authorization header.
+	UseHTTPMessageSignatures(protocolVersion string) bool
+
+	// SignHTTPMessage sets the Content-Digest, Signature-Input and Signature
+	// headers on header. method and targetURI describe the request as it will
+	// be sent upstream, which is what derived components such as @path are
+	// computed from. keyID is carried in the keyid signature parameter.
+	SignHTTPMessage(ctx context.Context, method string, targetURI *url.URL, header http.Header, body []byte, keyID, privateKeyBase64 string, createdAt, expiresAt int64) error
+}
+
 // SignerProvider initializes a new signer instance with the given config.
 type SignerProvider interface {
 	// New creates a new signer instance based on the provided config.
@@ -30,6 +30,24 @@ type SignValidator interface {
 	ValidateAck(ctx *model.StepContext, body []byte, signatureHeader, outboundAuthSignature, publicKeyBase64 string, checkIdentity bool) error
 }
 
+// HTTPMessageSignValidator is implemented by SignValidator plugins that can
+// verify RFC 9421 HTTP Message Signatures. The validateSign step type-asserts
+// the configured SignValidator for it and falls back to Validate when the
+// plugin does not implement it or HTTPMessageKeyID reports ok == false.
+type HTTPMessageSignValidator interface {
+	// HTTPMessageKeyID returns the keyid parameter of the request's RFC 9421
+	// signature. ok is false when the request should be validated from the
+	// Beckn Authorization header instead. A non-nil error means the request
+	// must be rejected, e.g. because the plugin requires RFC 9421 for the
+	// request's protocol version and the request carries no Signature-Input.
+	HTTPMessageKeyID(ctx *model.StepContext) (keyID string, ok bool, err error)
+
+	// ValidateHTTPMessage verifies the RFC 9421 signature on ctx.Request,
+	// including its Content-Digest against ctx.Body. checkIdentity has the
+	// same meaning as for Validate.
+	ValidateHTTPMessage(ctx *model.StepContext, publicKeyBase64 string, checkIdentity bool) error
+}
+
 // SignValidatorProvider initializes a new Verifier instance with the given config.
 type SignValidatorProvider interface {
 	// New creates a new Verifier instance based on the provided config.
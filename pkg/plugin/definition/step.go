@@ -25,3 +25,19 @@ type ResponseStep interface {
 type StepProvider interface {
 	New(context.Context, map[string]string) (Step, func(), error)
 }
+
+// ManifestAwareStep is implemented by plugin steps that read network
+// manifests. The handler passes its ManifestLoader (nil when none is
+// configured) to such a step right after building it; an error fails
+// handler initialisation.
+type ManifestAwareStep interface {
+	SetManifestLoader(ManifestLoader) error
+}
+
+// CacheAwareStep is implemented by plugin steps that keep state in the
+// handler's Cache. The handler passes its Cache (nil when none is
+// configured) to such a step right after building it; an error fails
+// handler initialisation.
+type CacheAwareStep interface {
+	SetCache(Cache) error
+}
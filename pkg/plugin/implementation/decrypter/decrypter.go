@@ -5,15 +5,27 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/sha256"
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/zenazn/pkcs7pad"
 
 	"github.com/beckn-one/beckn-onix/pkg/model"
 )
 
+// The v2 envelope is "v2:" followed by base64(salt || nonce || ciphertext),
+// where the AES-256-GCM key is HKDF-SHA256 over the X25519 shared secret.
+// It must match the encrypter plugin byte for byte.
+const (
+	envelopeV2Prefix = "v2:"
+	envelopeV2Info   = "beckn-onix envelope v2"
+	saltSize         = 16
+)
+
 // decrypter implements the Decrypter interface and handles the decryption process.
 type decrypter struct {
 }
@@ -24,6 +36,8 @@ func New(ctx context.Context) (*decrypter, func() error, error) {
 }
 
 // Decrypt decrypts the given encryptedData using the provided privateKeyBase64 and publicKeyBase64.
+// Data carrying the "v2:" prefix is opened as an authenticated envelope;
+// anything else is treated as the legacy AES-ECB format.
 func (d *decrypter) Decrypt(ctx context.Context, encryptedData, privateKeyBase64, publicKeyBase64 string) (string, error) {
 	privateKeyBytes, err := base64.StdEncoding.DecodeString(privateKeyBase64)
 	if err != nil {
@@ -35,13 +49,21 @@ func (d *decrypter) Decrypt(ctx context.Context, encryptedData, privateKeyBase64
 		return "", model.NewBadReqErr("AUT_SIGNATURE_INVALID", fmt.Errorf("invalid public key: %w", err))
 	}
 
+	if payload, ok := strings.CutPrefix(encryptedData, envelopeV2Prefix); ok {
+		return decryptV2(payload, privateKeyBytes, publicKeyBytes)
+	}
+
 	// Decode the Base64 encoded encrypted data.
 	messageByte, err := base64.StdEncoding.DecodeString(encryptedData)
 	if err != nil {
 		return "", model.NewBadReqErr("AUT_SIGNATURE_INVALID", fmt.Errorf("failed to decode encrypted data: %w", err))
 	}
 
-	aesCipher, err := createAESCipher(privateKeyBytes, publicKeyBytes)
+	_, _, sharedSecret, err := deriveSharedSecret(privateKeyBytes, publicKeyBytes)
+	if err != nil {
+		return "", err
+	}
+	aesCipher, err := aes.NewCipher(sharedSecret)
 	if err != nil {
 		return "", fmt.Errorf("failed to create AES cipher: %w", err)
 	}
@@ -64,25 +86,61 @@ func (d *decrypter) Decrypt(ctx context.Context, encryptedData, privateKeyBase64
 	return string(messageByte), nil
 }
 
-func createAESCipher(privateKey, publicKey []byte) (cipher.Block, error) {
-	x25519Curve := ecdh.X25519()
-	x25519PrivateKey, err := x25519Curve.NewPrivateKey(privateKey)
+// decryptV2 opens a v2 envelope. privateKey belongs to the recipient and
+// publicKey to the sender, so the HKDF info is rebuilt as sender || recipient.
+func decryptV2(payload string, privateKey, publicKey []byte) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(payload)
 	if err != nil {
-		return nil, model.NewBadReqErr("AUT_SIGNATURE_INVALID", fmt.Errorf("failed to create private key: %w", err))
+		return "", model.NewBadReqErr("AUT_SIGNATURE_INVALID", fmt.Errorf("failed to decode encrypted data: %w", err))
 	}
-	x25519PublicKey, err := x25519Curve.NewPublicKey(publicKey)
+
+	recipientKey, senderKey, sharedSecret, err := deriveSharedSecret(privateKey, publicKey)
 	if err != nil {
-		return nil, model.NewBadReqErr("AUT_SIGNATURE_INVALID", fmt.Errorf("failed to create public key: %w", err))
+		return "", err
 	}
-	sharedSecret, err := x25519PrivateKey.ECDH(x25519PublicKey)
+
+	info := envelopeV2Info + string(senderKey.Bytes()) + string(recipientKey.PublicKey().Bytes())
+	if len(raw) < saltSize {
+		return "", model.NewBadReqErr("AUT_SIGNATURE_INVALID", errors.New("envelope is too short"))
+	}
+	key, err := hkdf.Key(sha256.New, sharedSecret, raw[:saltSize], info, 32)
 	if err != nil {
-		return nil, fmt.Errorf("failed to derive shared secret: %w", err)
+		return "", fmt.Errorf("failed to derive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to create AES cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", fmt.Errorf("failed to create GCM: %w", err)
 	}
 
-	aesCipher, err := aes.NewCipher(sharedSecret)
+	rest := raw[saltSize:]
+	if len(rest) < gcm.NonceSize()+gcm.Overhead() {
+		return "", model.NewBadReqErr("AUT_SIGNATURE_INVALID", errors.New("envelope is too short"))
+	}
+	nonce, ciphertext := rest[:gcm.NonceSize()], rest[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+		return "", model.NewBadReqErr("AUT_SIGNATURE_INVALID", fmt.Errorf("failed to authenticate encrypted data: %w", err))
 	}
+	return string(plaintext), nil
+}
 
-	return aesCipher, nil
+func deriveSharedSecret(privateKey, publicKey []byte) (*ecdh.PrivateKey, *ecdh.PublicKey, []byte, error) {
+	x25519Curve := ecdh.X25519()
+	x25519PrivateKey, err := x25519Curve.NewPrivateKey(privateKey)
+	if err != nil {
+		return nil, nil, nil, model.NewBadReqErr("AUT_SIGNATURE_INVALID", fmt.Errorf("failed to create private key: %w", err))
+	}
+	x25519PublicKey, err := x25519Curve.NewPublicKey(publicKey)
+	if err != nil {
+		return nil, nil, nil, model.NewBadReqErr("AUT_SIGNATURE_INVALID", fmt.Errorf("failed to create public key: %w", err))
+	}
+	sharedSecret, err := x25519PrivateKey.ECDH(x25519PublicKey)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to derive shared secret: %w", err)
+	}
+	return x25519PrivateKey, x25519PublicKey, sharedSecret, nil
 }
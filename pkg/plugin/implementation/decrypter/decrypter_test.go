@@ -11,6 +11,7 @@ import (
 
 	"github.com/zenazn/pkcs7pad"
 
+	"github.com/beckn-one/beckn-onix/pkg/plugin/implementation/encrypter"
 	"github.com/beckn-one/beckn-onix/pkg/testutil"
 )
 
@@ -265,3 +266,71 @@ func TestNewDecrypter(t *testing.T) {
 		})
 	}
 }
+
+// TestDecryptV2Envelope round-trips v2 and legacy envelopes produced by the
+// encrypter plugin.
+func TestDecryptV2Envelope(t *testing.T) {
+	senderPrivateKeyB64, senderPublicKeyB64 := generateTestKeys(t)
+	receiverPrivateKeyB64, receiverPublicKeyB64 := generateTestKeys(t)
+
+	for _, envelope := range []string{encrypter.EnvelopeV2, encrypter.EnvelopeLegacy} {
+		t.Run(envelope, func(t *testing.T) {
+			e, _, err := encrypter.New(context.Background(), &encrypter.Config{Envelope: envelope})
+			if err != nil {
+				t.Fatalf("encrypter.New() error = %v", err)
+			}
+			data := `{"billing":{"name":"Jane Doe","phone":"+91-9999999999"}}`
+			encrypted, err := e.Encrypt(context.Background(), data, senderPrivateKeyB64, receiverPublicKeyB64)
+			if err != nil {
+				t.Fatalf("Encrypt() error = %v", err)
+			}
+
+			d, _, _ := New(context.Background())
+			got, err := d.Decrypt(context.Background(), encrypted, receiverPrivateKeyB64, senderPublicKeyB64)
+			if err != nil {
+				t.Fatalf("Decrypt() error = %v", err)
+			}
+			if got != data {
+				t.Errorf("Decrypt() = %q, want %q", got, data)
+			}
+		})
+	}
+}
+
+// TestDecryptV2Failure checks that tampered or misaddressed v2 envelopes are rejected.
+func TestDecryptV2Failure(t *testing.T) {
+	senderPrivateKeyB64, senderPublicKeyB64 := generateTestKeys(t)
+	receiverPrivateKeyB64, receiverPublicKeyB64 := generateTestKeys(t)
+	otherPrivateKeyB64, _ := generateTestKeys(t)
+
+	e, _, _ := encrypter.New(context.Background(), &encrypter.Config{Envelope: encrypter.EnvelopeV2})
+	encrypted, err := e.Encrypt(context.Background(), "secret", senderPrivateKeyB64, receiverPublicKeyB64)
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	raw, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(encrypted, envelopeV2Prefix))
+	raw[len(raw)-1] ^= 0x01
+	tampered := envelopeV2Prefix + base64.StdEncoding.EncodeToString(raw)
+
+	tests := []struct {
+		name          string
+		encryptedData string
+		privateKey    string
+		expectedErr   string
+	}{
+		{name: "tampered ciphertext", encryptedData: tampered, privateKey: receiverPrivateKeyB64, expectedErr: "failed to authenticate"},
+		{name: "wrong recipient", encryptedData: encrypted, privateKey: otherPrivateKeyB64, expectedErr: "failed to authenticate"},
+		{name: "truncated envelope", encryptedData: envelopeV2Prefix + base64.StdEncoding.EncodeToString(make([]byte, 20)), privateKey: receiverPrivateKeyB64, expectedErr: "too short"},
+		{name: "invalid base64", encryptedData: envelopeV2Prefix + "!!", privateKey: receiverPrivateKeyB64, expectedErr: "failed to decode encrypted data"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, _, _ := New(context.Background())
+			_, err := d.Decrypt(context.Background(), tt.encryptedData, tt.privateKey, senderPublicKeyB64)
+			if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Fatalf("Decrypt() error = %v, want error containing %q", err, tt.expectedErr)
+			}
+			testutil.RequireBadReqCode(t, err, "AUT_SIGNATURE_INVALID")
+		})
+	}
+}
@@ -11,7 +11,11 @@ import (
 type encrypterProvider struct{}
 
 func (ep encrypterProvider) New(ctx context.Context, config map[string]string) (definition.Encrypter, func() error, error) {
-	return encrypter.New(ctx)
+	e, cleanup, err := encrypter.New(ctx, &encrypter.Config{Envelope: config["envelope"]})
+	if err != nil {
+		return nil, nil, err
+	}
+	return e, cleanup, nil
 }
 
 // Provider is the exported symbol that the plugin manager will look for.
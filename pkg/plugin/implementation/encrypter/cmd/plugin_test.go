@@ -23,6 +23,11 @@ func TestEncrypterProviderSuccess(t *testing.T) {
 				"algorithm": "AES",
 			},
 		},
+		{
+			name:   "Valid legacy envelope",
+			ctx:    context.Background(),
+			config: map[string]string{"envelope": "legacy"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -47,3 +52,14 @@ func TestEncrypterProviderSuccess(t *testing.T) {
 		})
 	}
 }
+
+func TestEncrypterProviderInvalidEnvelope(t *testing.T) {
+	provider := encrypterProvider{}
+	encrypter, _, err := provider.New(context.Background(), map[string]string{"envelope": "v3"})
+	if err == nil {
+		t.Fatal("EncrypterProvider.New() expected error for unknown envelope")
+	}
+	if encrypter != nil {
+		t.Error("EncrypterProvider.New() returned non-nil encrypter on error")
+	}
+}
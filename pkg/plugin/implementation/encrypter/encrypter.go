@@ -5,6 +5,9 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
 
@@ -12,13 +15,49 @@ import (
 	"github.com/zenazn/pkcs7pad"
 )
 
+// Envelope formats selectable through Config.Envelope.
+const (
+	// EnvelopeV2 derives a per-message AES-256-GCM key from the X25519 shared
+	// secret with HKDF-SHA256 and a random salt. Output is "v2:" followed by
+	// base64(salt || nonce || ciphertext).
+	EnvelopeV2 = "v2"
+	// EnvelopeLegacy uses the X25519 shared secret directly as an AES key in
+	// ECB mode with PKCS#7 padding. It is unauthenticated, but it is the
+	// default because peers running older releases cannot decrypt v2.
+	EnvelopeLegacy = "legacy"
+)
+
+const (
+	envelopeV2Prefix = "v2:"
+	envelopeV2Info   = "beckn-onix envelope v2"
+	saltSize         = 16
+)
+
+// Config holds the configuration for the encrypter.
+type Config struct {
+	// Envelope selects the ciphertext format; empty means EnvelopeLegacy.
+	Envelope string
+}
+
 // encrypter implements the Encrypter interface and handles the encryption process.
 type encrypter struct {
+	legacy bool
 }
 
 // New creates a new encrypter instance with the given configuration.
-func New(ctx context.Context) (*encrypter, func() error, error) {
-	return &encrypter{}, nil, nil
+func New(ctx context.Context, cfg *Config) (*encrypter, func() error, error) {
+	e := &encrypter{legacy: true}
+	if cfg == nil {
+		return e, nil, nil
+	}
+	switch cfg.Envelope {
+	case "", EnvelopeLegacy:
+	case EnvelopeV2:
+		e.legacy = false
+	default:
+		return nil, nil, fmt.Errorf("unsupported envelope %q: must be %q or %q", cfg.Envelope, EnvelopeV2, EnvelopeLegacy)
+	}
+	return e, nil, nil
 }
 
 func (e *encrypter) Encrypt(ctx context.Context, data string, privateKeyBase64, publicKeyBase64 string) (string, error) {
@@ -32,9 +71,48 @@ func (e *encrypter) Encrypt(ctx context.Context, data string, privateKeyBase64,
 		return "", model.NewBadReqErr("AUT_SIGNATURE_INVALID", fmt.Errorf("invalid public key: %w", err))
 	}
 
-	// Convert the input string to a byte slice.
-	dataByte := []byte(data)
-	aesCipher, err := createAESCipher(privateKeyBytes, publicKeyBytes)
+	privateKey, publicKey, sharedSecret, err := deriveSharedSecret(privateKeyBytes, publicKeyBytes)
+	if err != nil {
+		return "", err
+	}
+
+	if e.legacy {
+		return encryptLegacy([]byte(data), sharedSecret)
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+	// The sender's and recipient's public keys are bound into the derived key
+	// so a ciphertext cannot be replayed under a different key pair.
+	info := envelopeV2Info + string(privateKey.PublicKey().Bytes()) + string(publicKey.Bytes())
+	key, err := hkdf.Key(sha256.New, sharedSecret, salt, info, 32)
+	if err != nil {
+		return "", fmt.Errorf("failed to derive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to create AES cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", fmt.Errorf("failed to create GCM: %w", err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	out := make([]byte, 0, saltSize+len(nonce)+len(data)+gcm.Overhead())
+	out = append(out, salt...)
+	out = append(out, nonce...)
+	out = gcm.Seal(out, nonce, []byte(data), nil)
+	return envelopeV2Prefix + base64.StdEncoding.EncodeToString(out), nil
+}
+
+func encryptLegacy(dataByte, sharedSecret []byte) (string, error) {
+	aesCipher, err := aes.NewCipher(sharedSecret)
 	if err != nil {
 		return "", fmt.Errorf("failed to create AES cipher: %w", err)
 	}
@@ -47,25 +125,19 @@ func (e *encrypter) Encrypt(ctx context.Context, data string, privateKeyBase64,
 	return base64.StdEncoding.EncodeToString(dataByte), nil
 }
 
-func createAESCipher(privateKey, publicKey []byte) (cipher.Block, error) {
+func deriveSharedSecret(privateKey, publicKey []byte) (*ecdh.PrivateKey, *ecdh.PublicKey, []byte, error) {
 	x25519Curve := ecdh.X25519()
 	x25519PrivateKey, err := x25519Curve.NewPrivateKey(privateKey)
 	if err != nil {
-		return nil, model.NewBadReqErr("AUT_SIGNATURE_INVALID", fmt.Errorf("failed to create private key: %w", err))
+		return nil, nil, nil, model.NewBadReqErr("AUT_SIGNATURE_INVALID", fmt.Errorf("failed to create private key: %w", err))
 	}
 	x25519PublicKey, err := x25519Curve.NewPublicKey(publicKey)
 	if err != nil {
-		return nil, model.NewBadReqErr("AUT_SIGNATURE_INVALID", fmt.Errorf("failed to create public key: %w", err))
+		return nil, nil, nil, model.NewBadReqErr("AUT_SIGNATURE_INVALID", fmt.Errorf("failed to create public key: %w", err))
 	}
 	sharedSecret, err := x25519PrivateKey.ECDH(x25519PublicKey)
 	if err != nil {
-		return nil, fmt.Errorf("failed to derive shared secret: %w", err)
+		return nil, nil, nil, fmt.Errorf("failed to derive shared secret: %w", err)
 	}
-
-	aesCipher, err := aes.NewCipher(sharedSecret)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
-	}
-
-	return aesCipher, nil
+	return x25519PrivateKey, x25519PublicKey, sharedSecret, nil
 }
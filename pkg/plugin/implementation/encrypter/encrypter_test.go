@@ -66,8 +66,11 @@ func TestEncryptSuccess(t *testing.T) {
 				t.Errorf("Encrypt() expected no error, but got: %v", err)
 			}
 
-			// Verify the encrypted data is valid base64.
-			_, err = base64.StdEncoding.DecodeString(encrypted)
+			// Verify the encrypted data is a v2 envelope with a base64 payload.
+			if !strings.HasPrefix(encrypted, envelopeV2Prefix) {
+				t.Fatalf("Encrypt() output %q is missing the %q prefix", encrypted, envelopeV2Prefix)
+			}
+			_, err = base64.StdEncoding.DecodeString(strings.TrimPrefix(encrypted, envelopeV2Prefix))
 			if err != nil {
 				t.Errorf("Encrypt() output is not valid base64: %v", err)
 			}
@@ -182,10 +185,92 @@ func TestNew(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			encrypter, _, err := New(tt.ctx)
+			encrypter, _, err := New(tt.ctx, nil)
 			if err == nil && encrypter == nil {
 				t.Error("New() returned nil encrypter")
 			}
 		})
 	}
 }
+
+// TestNewConfig tests envelope selection through Config.
+func TestNewConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        *Config
+		wantLegacy bool
+		wantErr    bool
+	}{
+		{name: "nil config defaults to legacy", cfg: nil, wantLegacy: true},
+		{name: "empty envelope defaults to legacy", cfg: &Config{}, wantLegacy: true},
+		{name: "explicit v2", cfg: &Config{Envelope: EnvelopeV2}},
+		{name: "legacy", cfg: &Config{Envelope: EnvelopeLegacy}, wantLegacy: true},
+		{name: "unknown envelope", cfg: &Config{Envelope: "v3"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, _, err := New(context.Background(), tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && e.legacy != tt.wantLegacy {
+				t.Errorf("New() legacy = %v, want %v", e.legacy, tt.wantLegacy)
+			}
+		})
+	}
+}
+
+// TestEncryptV2RandomizedAndAuthenticated checks that repeated encryptions of
+// the same plaintext differ and carry the GCM tag.
+func TestEncryptV2RandomizedAndAuthenticated(t *testing.T) {
+	_, privateKey := generateTestKeyPair(t)
+	peerPublicKey, _ := generateTestKeyPair(t)
+	e := &encrypter{}
+
+	first, err := e.Encrypt(context.Background(), "same data", privateKey, peerPublicKey)
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	second, err := e.Encrypt(context.Background(), "same data", privateKey, peerPublicKey)
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if first == second {
+		t.Error("Encrypt() produced identical ciphertexts for repeated plaintext")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(first, envelopeV2Prefix))
+	if err != nil {
+		t.Fatalf("decode envelope: %v", err)
+	}
+	// salt(16) + nonce(12) + plaintext(9) + tag(16)
+	if want := saltSize + 12 + len("same data") + 16; len(raw) != want {
+		t.Errorf("envelope length = %d, want %d", len(raw), want)
+	}
+}
+
+// TestEncryptLegacy checks the legacy envelope is unprefixed, block-aligned base64.
+func TestEncryptLegacy(t *testing.T) {
+	_, privateKey := generateTestKeyPair(t)
+	peerPublicKey, _ := generateTestKeyPair(t)
+	e, _, err := New(context.Background(), &Config{Envelope: EnvelopeLegacy})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	encrypted, err := e.Encrypt(context.Background(), "Hello, World!", privateKey, peerPublicKey)
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if strings.HasPrefix(encrypted, envelopeV2Prefix) {
+		t.Errorf("legacy Encrypt() output %q carries the v2 prefix", encrypted)
+	}
+	raw, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		t.Fatalf("legacy output is not base64: %v", err)
+	}
+	if len(raw)%16 != 0 {
+		t.Errorf("legacy ciphertext length %d is not a multiple of the AES block size", len(raw))
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+
+	"github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/implementation/filekeystore"
+)
+
+// fileKeyStoreProvider implements the plugin provider for the FileKeyStore plugin.
+type fileKeyStoreProvider struct{}
+
+// newFileKeyStoreFunc is a function type that creates a new FileKeyStore instance.
+var newFileKeyStoreFunc = filekeystore.New
+
+// New creates and initializes a new FileKeyStore instance using the provided registry lookup and configuration.
+func (k *fileKeyStoreProvider) New(ctx context.Context, registry definition.RegistryLookup, cfg map[string]string) (definition.KeyManager, func() error, error) {
+	config := &filekeystore.Config{
+		Path:            cfg["path"],
+		PassphraseFile:  cfg["passphraseFile"],
+		PassphraseEnv:   cfg["passphraseEnv"],
+		CreateIfMissing: cfg["createIfMissing"] == "true",
+	}
+	log.Debugf(ctx, "FileKeyStore config mapped: path=%s, passphraseFile=%s, passphraseEnv=%s, createIfMissing=%v",
+		config.Path, config.PassphraseFile, config.PassphraseEnv, config.CreateIfMissing)
+
+	km, cleanup, err := newFileKeyStoreFunc(ctx, registry, config)
+	if err != nil {
+		log.Error(ctx, err, "Failed to initialize FileKeyStore")
+		return nil, nil, err
+	}
+	log.Debugf(ctx, "FileKeyStore instance created successfully")
+	return km, cleanup, nil
+}
+
+// Provider is the exported instance of fileKeyStoreProvider used for plugin registration.
+var Provider = fileKeyStoreProvider{}
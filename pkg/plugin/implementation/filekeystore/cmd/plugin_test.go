@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/implementation/filekeystore"
+)
+
+type mockRegistry struct{}
+
+func (m *mockRegistry) Lookup(ctx context.Context, sub *model.Subscription) ([]model.Subscription, error) {
+	return nil, nil
+}
+
+func TestFileKeyStoreProvider_New(t *testing.T) {
+	filekeystore.DefaultKDFParams = filekeystore.KDFParams{Time: 1, Memory: 64, Threads: 1}
+	provider := &fileKeyStoreProvider{}
+	ctx := context.Background()
+	t.Setenv(filekeystore.DefaultPassphraseEnv, "test-passphrase")
+
+	tests := []struct {
+		name    string
+		config  func(dir string) map[string]string
+		wantErr bool
+	}{
+		{
+			name:    "missing path",
+			config:  func(string) map[string]string { return map[string]string{} },
+			wantErr: true,
+		},
+		{
+			name: "missing file without createIfMissing",
+			config: func(dir string) map[string]string {
+				return map[string]string{"path": filepath.Join(dir, "keys.json")}
+			},
+			wantErr: true,
+		},
+		{
+			name: "createIfMissing",
+			config: func(dir string) map[string]string {
+				return map[string]string{"path": filepath.Join(dir, "keys.json"), "createIfMissing": "true"}
+			},
+			wantErr: false,
+		},
+		{
+			name: "passphrase env not set",
+			config: func(dir string) map[string]string {
+				return map[string]string{
+					"path":            filepath.Join(dir, "keys.json"),
+					"createIfMissing": "true",
+					"passphraseEnv":   "FILEKEYSTORE_TEST_UNSET",
+				}
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			km, cleanup, err := provider.New(ctx, &mockRegistry{}, tt.config(t.TempDir()))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("fileKeyStoreProvider.New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if km != nil || cleanup != nil {
+					t.Error("fileKeyStoreProvider.New() should return nil keymanager and cleanup on error")
+				}
+				return
+			}
+			if km == nil {
+				t.Fatal("fileKeyStoreProvider.New() returned nil keymanager")
+			}
+			if cleanup == nil {
+				t.Fatal("fileKeyStoreProvider.New() returned nil cleanup function")
+			}
+			if err := cleanup(); err != nil {
+				t.Errorf("cleanup() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestFileKeyStoreProvider_NewWithNilRegistry(t *testing.T) {
+	provider := &fileKeyStoreProvider{}
+	t.Setenv(filekeystore.DefaultPassphraseEnv, "test-passphrase")
+
+	_, _, err := provider.New(context.Background(), nil, map[string]string{
+		"path":            filepath.Join(t.TempDir(), "keys.json"),
+		"createIfMissing": "true",
+	})
+	if err == nil {
+		t.Error("fileKeyStoreProvider.New() should fail with nil registry")
+	}
+}
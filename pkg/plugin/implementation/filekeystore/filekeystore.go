@@ -0,0 +1,321 @@
+package filekeystore
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+	"github.com/beckn-one/beckn-onix/pkg/telemetry"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultPassphraseEnv is the environment variable the passphrase is read
+// from when neither PassphraseFile nor PassphraseEnv is configured.
+const DefaultPassphraseEnv = "ONIX_KEYSTORE_PASSPHRASE"
+
+// Config holds configuration parameters for FileKeyMgr.
+type Config struct {
+	// Path is the encrypted keystore file.
+	Path string `yaml:"path" json:"path"`
+	// PassphraseFile is a file holding the passphrase, typically a mounted
+	// secret. Takes precedence over PassphraseEnv. Trailing newlines are
+	// stripped.
+	PassphraseFile string `yaml:"passphraseFile" json:"passphraseFile"`
+	// PassphraseEnv names the environment variable holding the passphrase.
+	// Defaults to DefaultPassphraseEnv.
+	PassphraseEnv string `yaml:"passphraseEnv" json:"passphraseEnv"`
+	// CreateIfMissing creates an empty keystore at Path when none exists.
+	// Off by default so a mistyped path fails startup instead of silently
+	// running with no keys.
+	CreateIfMissing bool `yaml:"createIfMissing" json:"createIfMissing"`
+}
+
+// FileKeyMgr is a KeyManager whose keysets live in a passphrase-encrypted
+// file (Argon2id + AES-256-GCM). Inserted, generated-then-inserted and
+// deleted keysets persist across restarts; counterparty keys are resolved
+// through the registry like the other key managers.
+type FileKeyMgr struct {
+	Registry definition.RegistryLookup
+	store    *Store
+}
+
+var (
+	// ErrEmptyKeyID indicates that the provided key ID is empty.
+	ErrEmptyKeyID = errors.New("invalid request: keyID cannot be empty")
+
+	// ErrNilKeySet indicates that the provided keyset is nil.
+	ErrNilKeySet = errors.New("keyset cannot be nil")
+
+	// ErrEmptySubscriberID indicates that the provided subscriber ID is empty.
+	ErrEmptySubscriberID = errors.New("invalid request: subscriberID cannot be empty")
+
+	// ErrEmptyUniqueKeyID indicates that the provided unique key ID is empty.
+	ErrEmptyUniqueKeyID = errors.New("invalid request: uniqueKeyID cannot be empty")
+
+	// ErrSubscriberNotFound indicates that no subscriber was found with the provided credentials.
+	ErrSubscriberNotFound = errors.New("no subscriber found with given credentials")
+
+	// ErrNilRegistryLookup indicates that the registry lookup implementation is nil.
+	ErrNilRegistryLookup = errors.New("registry lookup implementation cannot be nil")
+
+	// ErrKeysetNotFound indicates that the requested keyset was not found.
+	ErrKeysetNotFound = errors.New("keyset not found")
+
+	// ErrInvalidConfig indicates that the configuration is invalid.
+	ErrInvalidConfig = errors.New("invalid configuration")
+
+	// ErrKeyExpiredOrRevoked indicates that the matched subscriber's key exists but
+	// is no longer usable (registry status EXPIRED, UNSUBSCRIBED, or INVALID_SSL).
+	ErrKeyExpiredOrRevoked = errors.New("subscriber key is expired or revoked")
+)
+
+// AUT_* codes reachable from LookupNPKeys.
+const (
+	// codeSubscriberNotFound is used when the registry lookup returns zero results.
+	codeSubscriberNotFound = "AUT_SUBSCRIBER_NOT_FOUND"
+	// codeKeyExpiredOrRevoked is used when a matched subscriber's key is no
+	// longer usable per model.IsKeyStatusUsable.
+	codeKeyExpiredOrRevoked = "AUT_KEY_EXPIRED_OR_REVOKED"
+)
+
+// ValidateCfg validates the FileKeyManager configuration.
+func ValidateCfg(cfg *Config) error {
+	if cfg == nil {
+		return fmt.Errorf("%w: config cannot be nil", ErrInvalidConfig)
+	}
+	if cfg.Path == "" {
+		return fmt.Errorf("%w: path is required", ErrInvalidConfig)
+	}
+	return nil
+}
+
+// ReadPassphrase resolves the keystore passphrase: from passphraseFile when
+// set, otherwise from the passphraseEnv environment variable (or
+// DefaultPassphraseEnv when that is empty).
+func ReadPassphrase(passphraseFile, passphraseEnv string) ([]byte, error) {
+	if passphraseFile != "" {
+		data, err := os.ReadFile(passphraseFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read passphrase file: %w", err)
+		}
+		passphrase := strings.TrimRight(string(data), "\r\n")
+		if passphrase == "" {
+			return nil, fmt.Errorf("%w: passphrase file %s is empty", ErrEmptyPassphrase, passphraseFile)
+		}
+		return []byte(passphrase), nil
+	}
+	if passphraseEnv == "" {
+		passphraseEnv = DefaultPassphraseEnv
+	}
+	passphrase := os.Getenv(passphraseEnv)
+	if passphrase == "" {
+		return nil, fmt.Errorf("%w: environment variable %s is not set", ErrEmptyPassphrase, passphraseEnv)
+	}
+	return []byte(passphrase), nil
+}
+
+var (
+	ed25519KeyGenFunc = ed25519.GenerateKey
+	x25519KeyGenFunc  = ecdh.X25519().GenerateKey
+	uuidGenFunc       = uuid.NewRandom
+)
+
+// New creates a new FileKeyMgr instance with the provided registry lookup and configuration.
+func New(ctx context.Context, registryLookup definition.RegistryLookup, cfg *Config) (*FileKeyMgr, func() error, error) {
+	log.Info(ctx, "Initializing FileKeyStore plugin")
+
+	if err := ValidateCfg(cfg); err != nil {
+		log.Error(ctx, err, "Invalid configuration for FileKeyStore")
+		return nil, nil, err
+	}
+
+	if registryLookup == nil {
+		log.Error(ctx, ErrNilRegistryLookup, "RegistryLookup is nil in FileKeyStore initialization")
+		return nil, nil, ErrNilRegistryLookup
+	}
+
+	passphrase, err := ReadPassphrase(cfg.PassphraseFile, cfg.PassphraseEnv)
+	if err != nil {
+		log.Error(ctx, err, "Failed to read FileKeyStore passphrase")
+		return nil, nil, err
+	}
+
+	store, err := OpenStore(cfg.Path, passphrase, cfg.CreateIfMissing)
+	if err != nil {
+		log.Errorf(ctx, err, "Failed to open keystore at %s", cfg.Path)
+		return nil, nil, err
+	}
+	log.Infof(ctx, "Opened keystore %s with %d keyset(s)", cfg.Path, len(store.IDs()))
+
+	fkm := &FileKeyMgr{
+		Registry: registryLookup,
+		store:    store,
+	}
+
+	cleanup := func() error {
+		log.Info(ctx, "Cleaning up FileKeyStore resources")
+		fkm.Registry = nil
+		fkm.store = nil
+		return nil
+	}
+
+	log.Info(ctx, "FileKeyStore plugin initialized successfully")
+	return fkm, cleanup, nil
+}
+
+// GenerateKeyset generates a new signing (Ed25519) and encryption (X25519) key pair.
+// The keyset is not stored until InsertKeyset is called with it.
+func (fkm *FileKeyMgr) GenerateKeyset() (*model.Keyset, error) {
+	return GenerateKeyset()
+}
+
+// GenerateKeyset generates a new signing (Ed25519) and encryption (X25519)
+// key pair with a random UniqueKeyID. Exported for the filekeystore CLI.
+func GenerateKeyset() (*model.Keyset, error) {
+	signingPublic, signingPrivate, err := ed25519KeyGenFunc(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key pair: %w", err)
+	}
+
+	encrPrivateKey, err := x25519KeyGenFunc(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate encryption key pair: %w", err)
+	}
+	encrPublicKey := encrPrivateKey.PublicKey().Bytes()
+	uuid, err := uuidGenFunc()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate unique key id uuid: %w", err)
+	}
+	return &model.Keyset{
+		UniqueKeyID:    uuid.String(),
+		SigningPrivate: encodeBase64(signingPrivate.Seed()),
+		SigningPublic:  encodeBase64(signingPublic),
+		EncrPrivate:    encodeBase64(encrPrivateKey.Bytes()),
+		EncrPublic:     encodeBase64(encrPublicKey),
+	}, nil
+}
+
+// InsertKeyset stores the given keyset under the specified key ID and writes
+// the keystore file.
+func (fkm *FileKeyMgr) InsertKeyset(ctx context.Context, keyID string, keys *model.Keyset) error {
+	if keyID == "" {
+		return ErrEmptyKeyID
+	}
+	if keys == nil {
+		return ErrNilKeySet
+	}
+
+	log.Debugf(ctx, "Storing keyset for keyID: %s", keyID)
+	if err := fkm.store.Put(keyID, keys); err != nil {
+		return fmt.Errorf("failed to store keyset: %w", err)
+	}
+	log.Debugf(ctx, "Successfully stored keyset for keyID: %s", keyID)
+	return nil
+}
+
+// DeleteKeyset deletes the keyset for the given key ID and writes the keystore file.
+func (fkm *FileKeyMgr) DeleteKeyset(ctx context.Context, keyID string) error {
+	if keyID == "" {
+		return ErrEmptyKeyID
+	}
+
+	log.Debugf(ctx, "Deleting keyset for keyID: %s", keyID)
+	existed, err := fkm.store.Delete(keyID)
+	if err != nil {
+		return fmt.Errorf("failed to delete keyset: %w", err)
+	}
+	if !existed {
+		log.Warnf(ctx, "Keyset not found for keyID: %s", keyID)
+		return ErrKeysetNotFound
+	}
+	log.Debugf(ctx, "Successfully deleted keyset for keyID: %s", keyID)
+	return nil
+}
+
+// Keyset retrieves a copy of the keyset for the given key ID.
+func (fkm *FileKeyMgr) Keyset(ctx context.Context, keyID string) (*model.Keyset, error) {
+	if keyID == "" {
+		return nil, ErrEmptyKeyID
+	}
+
+	keyset, ok := fkm.store.Get(keyID)
+	if !ok {
+		log.Warnf(ctx, "Keyset not found for keyID: %s", keyID)
+		return nil, ErrKeysetNotFound
+	}
+	return keyset, nil
+}
+
+// LookupNPKeys retrieves the signing and encryption public keys for the given subscriber ID and unique key ID.
+//
+// A zero-result lookup and a matched-but-unusable-status subscriber are both
+// returned already classified as a 401 *model.CodedErr, the same as the
+// other key managers, so validateSignStep can propagate them as-is.
+func (fkm *FileKeyMgr) LookupNPKeys(ctx context.Context, subscriberID, uniqueKeyID string) (string, string, error) {
+	if err := validateParams(subscriberID, uniqueKeyID); err != nil {
+		return "", "", err
+	}
+
+	tracer := otel.Tracer(telemetry.ScopeName, trace.WithInstrumentationVersion(telemetry.ScopeVersion))
+
+	// Keep *model.StepContext as the outermost type passed to Registry.Lookup
+	// so registry plugins can still type-assert it to read the request body.
+	registryCtx := ctx
+	if sc, ok := ctx.(*model.StepContext); ok && sc != nil {
+		spanCtx, span := tracer.Start(sc.Context, "registry lookup")
+		defer span.End()
+		scCopy := *sc
+		scCopy.Context = spanCtx
+		registryCtx = &scCopy
+	} else {
+		var span trace.Span
+		registryCtx, span = tracer.Start(ctx, "registry lookup")
+		defer span.End()
+	}
+
+	subscribers, err := fkm.Registry.Lookup(registryCtx, &model.Subscription{
+		Subscriber: model.Subscriber{
+			SubscriberID: subscriberID,
+		},
+		KeyID: uniqueKeyID,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to lookup registry: %w", err)
+	}
+	if len(subscribers) == 0 {
+		return "", "", model.NewSignValidationErr(codeSubscriberNotFound, ErrSubscriberNotFound)
+	}
+	if !model.IsKeyStatusUsable(subscribers[0].Status) {
+		return "", "", model.NewSignValidationErr(codeKeyExpiredOrRevoked, ErrKeyExpiredOrRevoked)
+	}
+
+	log.Debugf(ctx, "Successfully looked up keys for subscriber: %s, uniqueKeyID: %s", subscriberID, uniqueKeyID)
+	return subscribers[0].SigningPublicKey, subscribers[0].EncrPublicKey, nil
+}
+
+// validateParams checks that subscriberID and uniqueKeyID are not empty.
+func validateParams(subscriberID, uniqueKeyID string) error {
+	if subscriberID == "" {
+		return ErrEmptySubscriberID
+	}
+	if uniqueKeyID == "" {
+		return ErrEmptyUniqueKeyID
+	}
+	return nil
+}
+
+// encodeBase64 returns the base64-encoded string of the given data.
+func encodeBase64(data []byte) string {
+	return base64.StdEncoding.EncodeToString(data)
+}
@@ -0,0 +1,382 @@
+package filekeystore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+)
+
+// Cheap KDF parameters so tests don't spend 64 MiB and several hundred
+// milliseconds per store.
+func init() {
+	DefaultKDFParams = KDFParams{Time: 1, Memory: 64, Threads: 1}
+}
+
+type mockRegistry struct {
+	LookupFunc func(ctx context.Context, sub *model.Subscription) ([]model.Subscription, error)
+}
+
+func (m *mockRegistry) Lookup(ctx context.Context, sub *model.Subscription) ([]model.Subscription, error) {
+	if m.LookupFunc != nil {
+		return m.LookupFunc(ctx, sub)
+	}
+	return []model.Subscription{
+		{
+			Subscriber:       model.Subscriber{SubscriberID: sub.SubscriberID},
+			KeyID:            sub.KeyID,
+			SigningPublicKey: "test-signing-public-key",
+			EncrPublicKey:    "test-encr-public-key",
+		},
+	}, nil
+}
+
+func newTestKeyMgr(t *testing.T, path string) *FileKeyMgr {
+	t.Helper()
+	t.Setenv(DefaultPassphraseEnv, "correct horse battery staple")
+	km, _, err := New(context.Background(), &mockRegistry{}, &Config{Path: path, CreateIfMissing: true})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return km
+}
+
+func TestValidateCfg(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *Config
+		wantErr bool
+	}{
+		{name: "nil config", cfg: nil, wantErr: true},
+		{name: "missing path", cfg: &Config{}, wantErr: true},
+		{name: "valid", cfg: &Config{Path: "/tmp/keys.json"}, wantErr: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCfg(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCfg() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidConfig) {
+				t.Errorf("ValidateCfg() error = %v, want ErrInvalidConfig", err)
+			}
+		})
+	}
+}
+
+func TestReadPassphrase(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "passphrase")
+	if err := os.WriteFile(secret, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	empty := filepath.Join(dir, "empty")
+	if err := os.WriteFile(empty, []byte("\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(DefaultPassphraseEnv, "from-default-env")
+	t.Setenv("CUSTOM_PASSPHRASE", "from-custom-env")
+
+	tests := []struct {
+		name    string
+		file    string
+		env     string
+		want    string
+		wantErr error
+	}{
+		{name: "file takes precedence", file: secret, env: "CUSTOM_PASSPHRASE", want: "from-file"},
+		{name: "custom env", env: "CUSTOM_PASSPHRASE", want: "from-custom-env"},
+		{name: "default env", want: "from-default-env"},
+		{name: "unset env", env: "FILEKEYSTORE_TEST_UNSET", wantErr: ErrEmptyPassphrase},
+		{name: "empty file", file: empty, wantErr: ErrEmptyPassphrase},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadPassphrase(tt.file, tt.env)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ReadPassphrase() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadPassphrase() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ReadPassphrase() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew_MissingFile(t *testing.T) {
+	t.Setenv(DefaultPassphraseEnv, "pass")
+	path := filepath.Join(t.TempDir(), "keys.json")
+	_, _, err := New(context.Background(), &mockRegistry{}, &Config{Path: path})
+	if !errors.Is(err, ErrKeystoreNotFound) {
+		t.Fatalf("New() error = %v, want ErrKeystoreNotFound", err)
+	}
+	if _, statErr := os.Stat(path); !os.IsNotExist(statErr) {
+		t.Errorf("New() without createIfMissing must not create %s", path)
+	}
+}
+
+func TestNew_NilRegistry(t *testing.T) {
+	t.Setenv(DefaultPassphraseEnv, "pass")
+	_, _, err := New(context.Background(), nil, &Config{Path: filepath.Join(t.TempDir(), "keys.json"), CreateIfMissing: true})
+	if !errors.Is(err, ErrNilRegistryLookup) {
+		t.Fatalf("New() error = %v, want ErrNilRegistryLookup", err)
+	}
+}
+
+func TestKeysetsPersistAcrossRestarts(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "keys.json")
+	km := newTestKeyMgr(t, path)
+
+	ks, err := km.GenerateKeyset()
+	if err != nil {
+		t.Fatalf("GenerateKeyset() error = %v", err)
+	}
+	ks.SubscriberID = "bap.example.com"
+	if err := km.InsertKeyset(ctx, "bap.example.com", ks); err != nil {
+		t.Fatalf("InsertKeyset() error = %v", err)
+	}
+	if err := km.InsertKeyset(ctx, "to-delete", ks); err != nil {
+		t.Fatalf("InsertKeyset() error = %v", err)
+	}
+	if err := km.DeleteKeyset(ctx, "to-delete"); err != nil {
+		t.Fatalf("DeleteKeyset() error = %v", err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), ks.SigningPrivate) || strings.Contains(string(raw), "bap.example.com") {
+		t.Fatal("keystore file contains plaintext key material")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("keystore file permissions = %o, want 600", perm)
+	}
+
+	reopened := newTestKeyMgr(t, path)
+	got, err := reopened.Keyset(ctx, "bap.example.com")
+	if err != nil {
+		t.Fatalf("Keyset() after reopen error = %v", err)
+	}
+	if *got != *ks {
+		t.Errorf("Keyset() after reopen = %+v, want %+v", got, ks)
+	}
+	if _, err := reopened.Keyset(ctx, "to-delete"); !errors.Is(err, ErrKeysetNotFound) {
+		t.Errorf("deleted keyset survived reopen: err = %v", err)
+	}
+}
+
+func TestKeysetReturnsCopy(t *testing.T) {
+	ctx := context.Background()
+	km := newTestKeyMgr(t, filepath.Join(t.TempDir(), "keys.json"))
+	if err := km.InsertKeyset(ctx, "id", &model.Keyset{UniqueKeyID: "k1", SigningPrivate: "priv"}); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := km.Keyset(ctx, "id")
+	got.SigningPrivate = "mutated"
+	again, _ := km.Keyset(ctx, "id")
+	if again.SigningPrivate != "priv" {
+		t.Error("mutating a returned keyset changed the stored keyset")
+	}
+}
+
+func TestKeyMgrErrors(t *testing.T) {
+	ctx := context.Background()
+	km := newTestKeyMgr(t, filepath.Join(t.TempDir(), "keys.json"))
+
+	if err := km.InsertKeyset(ctx, "", &model.Keyset{}); !errors.Is(err, ErrEmptyKeyID) {
+		t.Errorf("InsertKeyset(\"\") error = %v, want ErrEmptyKeyID", err)
+	}
+	if err := km.InsertKeyset(ctx, "id", nil); !errors.Is(err, ErrNilKeySet) {
+		t.Errorf("InsertKeyset(nil) error = %v, want ErrNilKeySet", err)
+	}
+	if _, err := km.Keyset(ctx, ""); !errors.Is(err, ErrEmptyKeyID) {
+		t.Errorf("Keyset(\"\") error = %v, want ErrEmptyKeyID", err)
+	}
+	if _, err := km.Keyset(ctx, "missing"); !errors.Is(err, ErrKeysetNotFound) {
+		t.Errorf("Keyset(missing) error = %v, want ErrKeysetNotFound", err)
+	}
+	if err := km.DeleteKeyset(ctx, ""); !errors.Is(err, ErrEmptyKeyID) {
+		t.Errorf("DeleteKeyset(\"\") error = %v, want ErrEmptyKeyID", err)
+	}
+	if err := km.DeleteKeyset(ctx, "missing"); !errors.Is(err, ErrKeysetNotFound) {
+		t.Errorf("DeleteKeyset(missing) error = %v, want ErrKeysetNotFound", err)
+	}
+}
+
+func TestOpenStore_WrongPassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	s, err := OpenStore(path, []byte("right"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put("id", &model.Keyset{UniqueKeyID: "k1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenStore(path, []byte("wrong"), false); !errors.Is(err, ErrDecryptFailed) {
+		t.Fatalf("OpenStore() with wrong passphrase error = %v, want ErrDecryptFailed", err)
+	}
+}
+
+func TestOpenStore_TamperedHeader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	if _, err := OpenStore(path, []byte("pass"), true); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var env envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		t.Fatal(err)
+	}
+	// Same derived key would still be produced, but the header is authenticated.
+	env.KDFParams.Threads = 1
+	env.KDFParams.Memory = 64
+	env.KDFParams.Time = 1
+	env.Salt[0] ^= 0xff
+	raw, _ = json.Marshal(env)
+	if err := os.WriteFile(path, raw, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenStore(path, []byte("pass"), false); !errors.Is(err, ErrDecryptFailed) {
+		t.Fatalf("OpenStore() with tampered header error = %v, want ErrDecryptFailed", err)
+	}
+}
+
+func TestOpenStore_InvalidKDFParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		params KDFParams
+	}{
+		{name: "zero time", params: KDFParams{Time: 0, Memory: 64 * 1024, Threads: 4}},
+		{name: "zero threads", params: KDFParams{Time: 3, Memory: 64 * 1024, Threads: 0}},
+		{name: "memory too small", params: KDFParams{Time: 3, Memory: 16, Threads: 4}},
+		{name: "memory too large", params: KDFParams{Time: 3, Memory: maxKDFMemory + 1, Threads: 4}},
+		{name: "time too large", params: KDFParams{Time: maxKDFTime + 1, Memory: 64 * 1024, Threads: 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "keys.json")
+			raw, err := json.Marshal(envelope{
+				Version:   keystoreFormatVersion,
+				KDF:       kdfArgon2id,
+				KDFParams: tt.params,
+				Salt:      make([]byte, saltSize),
+				Cipher:    cipherAESGCM,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, raw, 0600); err != nil {
+				t.Fatal(err)
+			}
+			_, err = OpenStore(path, []byte("pass"), false)
+			if err == nil || !strings.Contains(err.Error(), "invalid keystore kdfParams") {
+				t.Fatalf("OpenStore() error = %v, want invalid kdfParams", err)
+			}
+		})
+	}
+}
+
+func TestOpenStore_UnsupportedVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	if err := os.WriteFile(path, []byte(`{"version":2}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_, err := OpenStore(path, []byte("pass"), false)
+	if err == nil || !strings.Contains(err.Error(), "unsupported keystore version") {
+		t.Fatalf("OpenStore() error = %v, want unsupported version", err)
+	}
+}
+
+func TestOpenStore_EmptyPassphrase(t *testing.T) {
+	if _, err := OpenStore(filepath.Join(t.TempDir(), "keys.json"), nil, true); !errors.Is(err, ErrEmptyPassphrase) {
+		t.Fatalf("OpenStore() error = %v, want ErrEmptyPassphrase", err)
+	}
+}
+
+func TestLookupNPKeys(t *testing.T) {
+	ctx := context.Background()
+	km := newTestKeyMgr(t, filepath.Join(t.TempDir(), "keys.json"))
+
+	signing, encr, err := km.LookupNPKeys(ctx, "bpp.example.com", "key-1")
+	if err != nil {
+		t.Fatalf("LookupNPKeys() error = %v", err)
+	}
+	if signing != "test-signing-public-key" || encr != "test-encr-public-key" {
+		t.Errorf("LookupNPKeys() = %q, %q", signing, encr)
+	}
+
+	if _, _, err := km.LookupNPKeys(ctx, "", "key-1"); !errors.Is(err, ErrEmptySubscriberID) {
+		t.Errorf("LookupNPKeys(empty subscriber) error = %v", err)
+	}
+	if _, _, err := km.LookupNPKeys(ctx, "bpp.example.com", ""); !errors.Is(err, ErrEmptyUniqueKeyID) {
+		t.Errorf("LookupNPKeys(empty key id) error = %v", err)
+	}
+}
+
+func TestLookupNPKeys_Classified(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		result   []model.Subscription
+		wantCode string
+		wantErr  error
+	}{
+		{name: "not found", result: nil, wantCode: codeSubscriberNotFound, wantErr: ErrSubscriberNotFound},
+		{name: "revoked", result: []model.Subscription{{Status: "UNSUBSCRIBED"}}, wantCode: codeKeyExpiredOrRevoked, wantErr: ErrKeyExpiredOrRevoked},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			km := newTestKeyMgr(t, filepath.Join(t.TempDir(), "keys.json"))
+			km.Registry = &mockRegistry{LookupFunc: func(context.Context, *model.Subscription) ([]model.Subscription, error) {
+				return tt.result, nil
+			}}
+			_, _, err := km.LookupNPKeys(ctx, "bpp.example.com", "key-1")
+			var coded *model.CodedErr
+			if !errors.As(err, &coded) {
+				t.Fatalf("LookupNPKeys() error = %v, want *model.CodedErr", err)
+			}
+			if coded.Code != tt.wantCode || coded.HTTPStatus() != http.StatusUnauthorized {
+				t.Errorf("LookupNPKeys() code/status = %s/%d, want %s/401", coded.Code, coded.HTTPStatus(), tt.wantCode)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("LookupNPKeys() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLookupNPKeys_PreservesStepContext(t *testing.T) {
+	km := newTestKeyMgr(t, filepath.Join(t.TempDir(), "keys.json"))
+	var gotStepCtx bool
+	km.Registry = &mockRegistry{LookupFunc: func(ctx context.Context, sub *model.Subscription) ([]model.Subscription, error) {
+		_, gotStepCtx = ctx.(*model.StepContext)
+		return []model.Subscription{{SigningPublicKey: "s"}}, nil
+	}}
+	if _, _, err := km.LookupNPKeys(&model.StepContext{Context: context.Background()}, "bpp", "k"); err != nil {
+		t.Fatal(err)
+	}
+	if !gotStepCtx {
+		t.Error("registry lookup did not receive *model.StepContext")
+	}
+}
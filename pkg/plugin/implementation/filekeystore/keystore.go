@@ -0,0 +1,364 @@
+package filekeystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"golang.org/x/crypto/argon2"
+)
+
+// keystoreFormatVersion is the on-disk envelope version. It is bumped only
+// when the envelope layout or the KDF/cipher pairing changes; a file with any
+// other version is refused rather than guessed at.
+const keystoreFormatVersion = 1
+
+const (
+	kdfArgon2id  = "argon2id"
+	cipherAESGCM = "aes-256-gcm"
+
+	saltSize = 16
+	keySize  = 32
+)
+
+// KDFParams are the Argon2id cost parameters recorded in a keystore file's
+// header. They are read back from the file on open, so a store created with
+// one set of parameters stays readable after the defaults change.
+type KDFParams struct {
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory"` // KiB
+	Threads uint8  `json:"threads"`
+}
+
+// Bounds on the KDF parameters read from a keystore file. The header is only
+// authenticated after the key is derived, so these stop an edited file from
+// panicking Argon2 (zero time or threads) or exhausting memory first.
+const (
+	maxKDFTime   = 64
+	maxKDFMemory = 4 * 1024 * 1024 // KiB, 4 GiB
+)
+
+// validate reports whether p is safe to pass to Argon2id.
+func (p KDFParams) validate() error {
+	switch {
+	case p.Time < 1 || p.Time > maxKDFTime:
+		return fmt.Errorf("kdf time %d out of range [1, %d]", p.Time, maxKDFTime)
+	case p.Threads < 1:
+		return fmt.Errorf("kdf threads must be at least 1")
+	case p.Memory < 8*uint32(p.Threads) || p.Memory > maxKDFMemory:
+		return fmt.Errorf("kdf memory %d KiB out of range [%d, %d]", p.Memory, 8*uint32(p.Threads), maxKDFMemory)
+	}
+	return nil
+}
+
+// DefaultKDFParams are the parameters used for newly created keystores:
+// RFC 9106's second recommended option (t=3, m=64 MiB, p=4).
+var DefaultKDFParams = KDFParams{Time: 3, Memory: 64 * 1024, Threads: 4}
+
+// envelope is the JSON document written to disk. Everything except
+// Ciphertext is authenticated as AES-GCM additional data, so editing the KDF
+// parameters or the salt in place fails decryption instead of silently
+// deriving a different key.
+type envelope struct {
+	Version    int       `json:"version"`
+	KDF        string    `json:"kdf"`
+	KDFParams  KDFParams `json:"kdfParams"`
+	Salt       []byte    `json:"salt"`
+	Cipher     string    `json:"cipher"`
+	Nonce      []byte    `json:"nonce"`
+	Ciphertext []byte    `json:"ciphertext"`
+}
+
+// header returns the envelope's authenticated fields, serialised the same way
+// on write and on read.
+func (e *envelope) header() ([]byte, error) {
+	return json.Marshal(struct {
+		Version   int       `json:"version"`
+		KDF       string    `json:"kdf"`
+		KDFParams KDFParams `json:"kdfParams"`
+		Salt      []byte    `json:"salt"`
+		Cipher    string    `json:"cipher"`
+	}{e.Version, e.KDF, e.KDFParams, e.Salt, e.Cipher})
+}
+
+// contents is the plaintext sealed inside the envelope.
+type contents struct {
+	Keysets map[string]*model.Keyset `json:"keysets"`
+}
+
+var (
+	// ErrKeystoreNotFound indicates that the keystore file does not exist and
+	// the caller did not ask for it to be created.
+	ErrKeystoreNotFound = errors.New("keystore file not found")
+
+	// ErrDecryptFailed indicates a wrong passphrase or a tampered keystore file.
+	// The two are deliberately indistinguishable.
+	ErrDecryptFailed = errors.New("failed to decrypt keystore: wrong passphrase or corrupted file")
+
+	// ErrEmptyPassphrase indicates that no passphrase was supplied.
+	ErrEmptyPassphrase = errors.New("keystore passphrase cannot be empty")
+)
+
+// Store is a passphrase-encrypted file of keysets, keyed by keyID. Every
+// mutation is written through to disk before it returns; the file is
+// replaced atomically, so a crash mid-write leaves the previous version.
+//
+// Store is safe for concurrent use within one process. It does not lock the
+// file against other processes — the CLI is expected to be run while the
+// adapter is stopped, or against a copy that is then swapped in.
+type Store struct {
+	path   string
+	params KDFParams
+	salt   []byte
+	key    []byte
+
+	mu      sync.RWMutex
+	keysets map[string]*model.Keyset
+}
+
+// OpenStore opens and decrypts the keystore at path. When the file does not
+// exist, it returns ErrKeystoreNotFound unless create is true, in which case
+// an empty store is written using DefaultKDFParams.
+func OpenStore(path string, passphrase []byte, create bool) (*Store, error) {
+	if len(passphrase) == 0 {
+		return nil, ErrEmptyPassphrase
+	}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		if !create {
+			return nil, fmt.Errorf("%w: %s", ErrKeystoreNotFound, path)
+		}
+		return createStore(path, passphrase)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore %s: %w", path, err)
+	}
+
+	var env envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return nil, fmt.Errorf("failed to parse keystore %s: %w", path, err)
+	}
+	if env.Version != keystoreFormatVersion {
+		return nil, fmt.Errorf("unsupported keystore version %d in %s", env.Version, path)
+	}
+	if env.KDF != kdfArgon2id || env.Cipher != cipherAESGCM {
+		return nil, fmt.Errorf("unsupported keystore kdf/cipher %q/%q in %s", env.KDF, env.Cipher, path)
+	}
+	if len(env.Salt) != saltSize {
+		return nil, fmt.Errorf("invalid keystore salt length %d in %s", len(env.Salt), path)
+	}
+	if err := env.KDFParams.validate(); err != nil {
+		return nil, fmt.Errorf("invalid keystore kdfParams in %s: %w", path, err)
+	}
+
+	s := &Store{
+		path:   path,
+		params: env.KDFParams,
+		salt:   env.Salt,
+		key:    deriveKey(passphrase, env.Salt, env.KDFParams),
+	}
+	plaintext, err := s.open(&env)
+	if err != nil {
+		return nil, err
+	}
+	var c contents
+	if err := json.Unmarshal(plaintext, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse decrypted keystore %s: %w", path, err)
+	}
+	s.keysets = c.Keysets
+	if s.keysets == nil {
+		s.keysets = make(map[string]*model.Keyset)
+	}
+	return s, nil
+}
+
+func createStore(path string, passphrase []byte) (*Store, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate keystore salt: %w", err)
+	}
+	s := &Store{
+		path:    path,
+		params:  DefaultKDFParams,
+		salt:    salt,
+		key:     deriveKey(passphrase, salt, DefaultKDFParams),
+		keysets: make(map[string]*model.Keyset),
+	}
+	if err := s.save(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func deriveKey(passphrase, salt []byte, p KDFParams) []byte {
+	return argon2.IDKey(passphrase, salt, p.Time, p.Memory, p.Threads, keySize)
+}
+
+// Get returns a copy of the keyset stored under keyID.
+func (s *Store) Get(keyID string) (*model.Keyset, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ks, ok := s.keysets[keyID]
+	if !ok {
+		return nil, false
+	}
+	cp := *ks
+	return &cp, true
+}
+
+// IDs returns the stored keyIDs in sorted order.
+func (s *Store) IDs() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ids := make([]string, 0, len(s.keysets))
+	for id := range s.keysets {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Put stores a copy of keyset under keyID, replacing any existing entry, and
+// persists the store.
+func (s *Store) Put(keyID string, keyset *model.Keyset) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev, existed := s.keysets[keyID]
+	cp := *keyset
+	s.keysets[keyID] = &cp
+	if err := s.save(); err != nil {
+		// Keep memory consistent with what is on disk.
+		if existed {
+			s.keysets[keyID] = prev
+		} else {
+			delete(s.keysets, keyID)
+		}
+		return err
+	}
+	return nil
+}
+
+// Delete removes the keyset stored under keyID and persists the store. It
+// reports whether an entry existed.
+func (s *Store) Delete(keyID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev, ok := s.keysets[keyID]
+	if !ok {
+		return false, nil
+	}
+	delete(s.keysets, keyID)
+	if err := s.save(); err != nil {
+		s.keysets[keyID] = prev
+		return true, err
+	}
+	return true, nil
+}
+
+// open decrypts env's ciphertext with the store's derived key.
+func (s *Store) open(env *envelope) ([]byte, error) {
+	aead, err := newAEAD(s.key)
+	if err != nil {
+		return nil, err
+	}
+	aad, err := env.header()
+	if err != nil {
+		return nil, err
+	}
+	if len(env.Nonce) != aead.NonceSize() {
+		return nil, ErrDecryptFailed
+	}
+	plaintext, err := aead.Open(nil, env.Nonce, env.Ciphertext, aad)
+	if err != nil {
+		return nil, ErrDecryptFailed
+	}
+	return plaintext, nil
+}
+
+// save seals the current keysets under a fresh nonce and atomically replaces
+// the keystore file. Callers must hold s.mu for writing (or own s exclusively).
+func (s *Store) save() error {
+	plaintext, err := json.Marshal(contents{Keysets: s.keysets})
+	if err != nil {
+		return fmt.Errorf("failed to encode keystore: %w", err)
+	}
+	aead, err := newAEAD(s.key)
+	if err != nil {
+		return err
+	}
+	env := envelope{
+		Version:   keystoreFormatVersion,
+		KDF:       kdfArgon2id,
+		KDFParams: s.params,
+		Salt:      s.salt,
+		Cipher:    cipherAESGCM,
+		Nonce:     make([]byte, aead.NonceSize()),
+	}
+	if _, err := rand.Read(env.Nonce); err != nil {
+		return fmt.Errorf("failed to generate keystore nonce: %w", err)
+	}
+	aad, err := env.header()
+	if err != nil {
+		return err
+	}
+	env.Ciphertext = aead.Seal(nil, env.Nonce, plaintext, aad)
+
+	data, err := json.MarshalIndent(env, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode keystore envelope: %w", err)
+	}
+	return writeFileAtomic(s.path, data)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create keystore cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create keystore cipher: %w", err)
+	}
+	return aead, nil
+}
+
+// writeFileAtomic writes data to a temporary file in path's directory with
+// owner-only permissions, syncs it, and renames it over path.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary keystore file: %w", err)
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName) // no-op once renamed
+
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to set keystore file permissions: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write keystore file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync keystore file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close keystore file: %w", err)
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		return fmt.Errorf("failed to replace keystore file: %w", err)
+	}
+	return nil
+}
@@ -226,13 +226,14 @@ func (l *Loader) getByMetadata(ctx context.Context, metadata model.ManifestMetad
 	}
 	digest := sha256.Sum256(manifestBody)
 	doc := &model.ManifestDocument{
-		ContentType:  manifestContentType,
-		Content:      manifestBody,
-		Digest:       hex.EncodeToString(digest[:]),
-		SourceURL:    metadata.ManifestURL,
-		SignatureURL: metadata.ManifestSignatureURL,
-		Verified:     verified,
-		FetchedAt:    time.Now().UTC(),
+		ContentType:         manifestContentType,
+		Content:             manifestBody,
+		Digest:              hex.EncodeToString(digest[:]),
+		SourceURL:           metadata.ManifestURL,
+		SignatureURL:        metadata.ManifestSignatureURL,
+		SigningPublicKeyURL: metadata.SigningPublicKeyLookupURL,
+		Verified:            verified,
+		FetchedAt:           time.Now().UTC(),
 	}
 	if err := l.store(ctx, cacheKey, doc); err != nil {
 		return nil, err
@@ -118,6 +118,9 @@ func TestGetByMetadata(t *testing.T) {
 	if !doc.Verified {
 		t.Fatal("expected manifest to be verified")
 	}
+	if doc.SigningPublicKeyURL != "https://example.org/pubkey" {
+		t.Fatalf("unexpected signing public key URL: %q", doc.SigningPublicKeyURL)
+	}
 	if string(doc.Content) != string(manifest) {
 		t.Fatalf("unexpected manifest content: %q", string(doc.Content))
 	}
@@ -0,0 +1,132 @@
+package opapolicychecker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/telemetry"
+	otellog "go.opentelemetry.io/otel/log"
+)
+
+// Decision log modes (config.decisionLogs).
+const (
+	decisionLogsNone = "none"
+	decisionLogsDeny = "deny" // denials and evaluation errors
+	decisionLogsAll  = "all"
+)
+
+// Decision results as recorded in a decision log.
+const (
+	decisionAllow = "allow"
+	decisionDeny  = "deny"
+	decisionError = "error"
+)
+
+// decisionOutcome is what one evaluation in CheckPolicy produced.
+type decisionOutcome struct {
+	violations  []model.Error
+	err         error
+	explanation []string
+	duration    time.Duration
+}
+
+func (o decisionOutcome) result() string {
+	switch {
+	case o.err != nil:
+		return decisionError
+	case len(o.violations) > 0:
+		return decisionDeny
+	default:
+		return decisionAllow
+	}
+}
+
+// decisionRecord is the body of a decision log record. It carries a hash of
+// the input, not the input: the audit log already has the (masked) message.
+type decisionRecord struct {
+	NetworkID   string              `json:"network_id,omitempty"`
+	Action      string              `json:"action,omitempty"`
+	Direction   string              `json:"direction"` // request, or response for responseQuery decisions
+	Policy      string              `json:"policy"`
+	PolicyType  string              `json:"policy_type"`
+	Query       string              `json:"query"`
+	Revision    string              `json:"revision"`
+	InputSHA256 string              `json:"input_sha256"`
+	Result      string              `json:"result"`
+	Violations  []decisionViolation `json:"violations,omitempty"`
+	Error       string              `json:"error,omitempty"`
+	DurationMS  float64             `json:"duration_ms"`
+	Explanation []string            `json:"explanation,omitempty"`
+}
+
+type decisionViolation struct {
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+}
+
+// logDecision emits a decision log record for out when config.decisionLogs
+// asks for it. Values the audit config masks in the message, or in the
+// earlier transaction messages given to the policy as input.onix, are
+// redacted from violation messages, the error and the explanation, since
+// policies commonly quote the offending field.
+func (e *PolicyEnforcer) logDecision(ctx context.Context, policy *loadedPolicy, ev *Evaluator, req parsedRequestContext, body []byte, onix map[string]interface{}, out decisionOutcome) {
+	result := out.result()
+	switch {
+	case e.config.DecisionLogs == decisionLogsNone,
+		e.config.DecisionLogs == decisionLogsDeny && result == decisionAllow,
+		!telemetry.LogsEnabled():
+		return
+	}
+
+	payloads := [][]byte{body}
+	if history, ok := onix["transaction"].([]transactionMessage); ok {
+		for _, msg := range history {
+			payloads = append(payloads, msg.rawBody)
+		}
+	}
+	redact := telemetry.PayloadRedactor(ctx, payloads...)
+	sum := sha256.Sum256(body)
+	direction := "request"
+	if ev == policy.responseEvaluator {
+		direction = "response"
+	}
+	record := decisionRecord{
+		NetworkID:   req.NetworkID,
+		Action:      req.Action,
+		Direction:   direction,
+		Policy:      policy.name,
+		PolicyType:  policy.config.Type,
+		Query:       ev.query,
+		Revision:    ev.Revision(),
+		InputSHA256: hex.EncodeToString(sum[:]),
+		Result:      result,
+		DurationMS:  float64(out.duration.Microseconds()) / 1000,
+	}
+	for _, v := range out.violations {
+		record.Violations = append(record.Violations, decisionViolation{Code: v.Code, Message: redact.Replace(v.Message)})
+	}
+	if out.err != nil {
+		record.Error = redact.Replace(out.err.Error())
+	}
+	for _, line := range out.explanation {
+		record.Explanation = append(record.Explanation, redact.Replace(line))
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		log.Warnf(ctx, "OPAPolicyChecker: failed to encode decision log: %v", err)
+		return
+	}
+	telemetry.EmitDecisionLogs(ctx, data,
+		otellog.String("policy.name", policy.name),
+		otellog.String("policy.revision", record.Revision),
+		otellog.String("policy.decision", result),
+		otellog.String("network.id", req.NetworkID),
+		otellog.String("beckn.action", req.Action),
+	)
+}
@@ -0,0 +1,287 @@
+package opapolicychecker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/open-policy-agent/opa/v1/bundle"
+
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+	"github.com/beckn-one/beckn-onix/pkg/telemetry"
+)
+
+const decisionLogPolicy = `
+package policy
+import rego.v1
+violations contains msg if {
+	input.context.action == "confirm"
+	trace(sprintf("billing phone %s", [input.message.order.billing.phone]))
+	msg := sprintf("phone %s is blocked", [input.message.order.billing.phone])
+}
+`
+
+const decisionLogConfirm = `{"context": {"action": "confirm", "networkId": "retail"}, "message": {"order": {"billing": {"phone": "9876543210"}}}}`
+
+// withDecisionLogs captures emitted log records and masks "phone" fields,
+// keeping the last four digits.
+func withDecisionLogs(t *testing.T) *telemetry.RecordingLogExporter {
+	t.Helper()
+	ctx := context.Background()
+	provider, exporter, err := telemetry.NewTestProviderWithLogs(ctx)
+	if err != nil {
+		t.Fatalf("NewTestProviderWithLogs: %v", err)
+	}
+	t.Cleanup(func() { _ = provider.Shutdown(ctx) })
+
+	auditCfg := filepath.Join(t.TempDir(), "audit.yaml")
+	writeAuditConfig(t, auditCfg, "mode: full\npatterns:\n  phone:\n    maskType: last4\nmaskRules:\n  - keys: [phone]\n    pattern: phone\n")
+	if err := telemetry.LoadAuditConfig(ctx, auditCfg); err != nil {
+		t.Fatalf("LoadAuditConfig: %v", err)
+	}
+	t.Cleanup(func() {
+		writeAuditConfig(t, auditCfg, "mode: full\n")
+		_ = telemetry.LoadAuditConfig(ctx, auditCfg)
+	})
+	return exporter
+}
+
+func writeAuditConfig(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write audit config: %v", err)
+	}
+}
+
+func newDecisionLogEnforcer(t *testing.T, mode, explain string) *PolicyEnforcer {
+	t.Helper()
+	dir := writePolicyDir(t, "policy.rego", decisionLogPolicy)
+	configPath := writeDefaultOnlyNetworkPolicyConfig(t, "type: dir\nlocation: "+dir+"\nquery: data.policy.violations\n")
+	enforcer, err := New(context.Background(), map[string]string{
+		"networkPolicyConfig": configPath,
+		"decisionLogs":        mode,
+		"decisionLogExplain":  explain,
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return enforcer
+}
+
+func decisionRecords(t *testing.T, exporter *telemetry.RecordingLogExporter) []decisionRecord {
+	t.Helper()
+	var records []decisionRecord
+	for _, r := range exporter.Records() {
+		if r.EventName() != "policy.decision" {
+			continue
+		}
+		var rec decisionRecord
+		if err := json.Unmarshal([]byte(r.Body().AsString()), &rec); err != nil {
+			t.Fatalf("decision log body is not a decision record: %v", err)
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+func TestDecisionLog_DenyMode(t *testing.T) {
+	exporter := withDecisionLogs(t)
+	enforcer := newDecisionLogEnforcer(t, decisionLogsDeny, explainNotes)
+
+	if err := enforcer.CheckPolicy(makeStepCtx("search", `{"context": {"action": "search"}}`)); err != nil {
+		t.Fatalf("search should be allowed: %v", err)
+	}
+	if err := enforcer.CheckPolicy(makeStepCtx("confirm", decisionLogConfirm)); err == nil {
+		t.Fatal("confirm should be denied")
+	}
+
+	records := decisionRecords(t, exporter)
+	if len(records) != 1 {
+		t.Fatalf("deny mode logged %d decisions, want only the denial", len(records))
+	}
+	rec := records[0]
+	if rec.Result != decisionDeny || rec.Policy != "default" || rec.NetworkID != "retail" || rec.Action != "confirm" {
+		t.Errorf("unexpected record: %+v", rec)
+	}
+	if !strings.HasPrefix(rec.Revision, "sha256:") || len(rec.InputSHA256) != 64 {
+		t.Errorf("revision %q / input hash %q not set", rec.Revision, rec.InputSHA256)
+	}
+	if len(rec.Violations) != 1 || rec.Violations[0].Message != "phone ******3210 is blocked" {
+		t.Errorf("violations = %+v, want the masked phone", rec.Violations)
+	}
+	if len(rec.Explanation) == 0 {
+		t.Fatal("expected an explanation in notes mode")
+	}
+	explanation := strings.Join(rec.Explanation, "\n")
+	if !strings.Contains(explanation, "billing phone ******3210") {
+		t.Errorf("explanation should carry the masked trace note:\n%s", explanation)
+	}
+	if strings.Contains(explanation, "9876543210") {
+		t.Errorf("explanation leaks the unmasked phone:\n%s", explanation)
+	}
+}
+
+func TestDecisionLog_RedactsTransactionHistory(t *testing.T) {
+	exporter := withDecisionLogs(t)
+	enforcer, err := newPolicyInputEnforcer(t, `
+package policy
+import rego.v1
+violations contains "confirm reuses an earlier phone" if {
+	input.context.action == "confirm"
+	some m in input.onix.transaction
+	trace(sprintf("earlier phone %s", [m.body.message.order.billing.phone]))
+}
+`, nil, map[string]string{
+		"includeTransactionHistory": "true",
+		"decisionLogs":              decisionLogsDeny,
+		"decisionLogExplain":        explainNotes,
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	store := &stubPayloadStore{entries: map[string][]definition.PayloadEntry{
+		"t1": {{MessageID: "m1", Action: "init", RequestBody: []byte(`{"message": {"order": {"billing": {"phone": "9123456789"}}}}`)}},
+	}}
+	if err := enforcer.SetPayloadStore(store); err != nil {
+		t.Fatalf("SetPayloadStore: %v", err)
+	}
+
+	if err := enforcer.CheckPolicy(makeStepCtx("confirm", `{"context": {"action": "confirm", "transaction_id": "t1", "message_id": "m2"}}`)); err == nil {
+		t.Fatal("confirm should be denied")
+	}
+	records := decisionRecords(t, exporter)
+	if len(records) != 1 {
+		t.Fatalf("logged %d decisions, want 1", len(records))
+	}
+	explanation := strings.Join(records[0].Explanation, "\n")
+	if !strings.Contains(explanation, "earlier phone ******6789") {
+		t.Errorf("explanation should carry the masked history phone:\n%s", explanation)
+	}
+	if strings.Contains(explanation, "9123456789") {
+		t.Errorf("explanation leaks the unmasked history phone:\n%s", explanation)
+	}
+}
+
+func TestDecisionLog_AllModeAndOff(t *testing.T) {
+	exporter := withDecisionLogs(t)
+
+	enforcer := newDecisionLogEnforcer(t, decisionLogsAll, explainOff)
+	_ = enforcer.CheckPolicy(makeStepCtx("search", `{"context": {"action": "search"}}`))
+	_ = enforcer.CheckPolicy(makeStepCtx("confirm", decisionLogConfirm))
+	records := decisionRecords(t, exporter)
+	if len(records) != 2 || records[0].Result != decisionAllow || records[1].Result != decisionDeny {
+		t.Fatalf("all mode should log both decisions, got %+v", records)
+	}
+	if records[1].Explanation != nil {
+		t.Error("explain off must not record an explanation")
+	}
+
+	enforcer = newDecisionLogEnforcer(t, decisionLogsNone, explainFull)
+	_ = enforcer.CheckPolicy(makeStepCtx("confirm", decisionLogConfirm))
+	if n := len(decisionRecords(t, exporter)); n != 2 {
+		t.Fatalf("decisionLogs none must not log, got %d records", n)
+	}
+}
+
+func TestParseConfig_DecisionLogs(t *testing.T) {
+	cfg, err := ParseConfig(map[string]string{"networkPolicyConfig": "/tmp/p.yaml"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.DecisionLogs != decisionLogsNone || cfg.DecisionLogExplain != explainOff {
+		t.Errorf("defaults = %q/%q, want none/off", cfg.DecisionLogs, cfg.DecisionLogExplain)
+	}
+
+	cfg, err = ParseConfig(map[string]string{"networkPolicyConfig": "/tmp/p.yaml", "decisionLogs": "deny", "decisionLogExplain": "fails"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, leaked := cfg.RuntimeConfig["decisionLogs"]; leaked {
+		t.Error("decisionLogs must not be forwarded as runtime config")
+	}
+
+	for _, bad := range []map[string]string{
+		{"networkPolicyConfig": "/tmp/p.yaml", "decisionLogs": "denied"},
+		{"networkPolicyConfig": "/tmp/p.yaml", "decisionLogExplain": "trace"},
+	} {
+		if _, err := ParseConfig(bad); err == nil {
+			t.Errorf("%v: expected a config error", bad)
+		}
+	}
+}
+
+func TestEvaluator_Revision(t *testing.T) {
+	modules := map[string]string{"policy.rego": decisionLogPolicy}
+	ev, err := compileAndPrepare(modules, nil, "", "data.policy.violations", nil, true)
+	if err != nil {
+		t.Fatalf("compileAndPrepare: %v", err)
+	}
+	if ev.Revision() != modulesDigest(modules) {
+		t.Errorf("revision = %q, want the module digest", ev.Revision())
+	}
+	if modulesDigest(map[string]string{"policy.rego": decisionLogPolicy + "\n"}) == ev.Revision() {
+		t.Error("a changed module must change the revision")
+	}
+
+	b := bundle.Bundle{
+		Manifest: bundle.Manifest{Revision: "2026-10-01.3"},
+		Modules:  []bundle.ModuleFile{{URL: "policy.rego", Path: "policy.rego", Raw: []byte(decisionLogPolicy)}},
+		Data:     map[string]interface{}{},
+	}
+	var buf bytes.Buffer
+	if err := bundle.Write(&buf, b); err != nil {
+		t.Fatalf("failed to write test bundle: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "policy.tar.gz")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("failed to write bundle: %v", err)
+	}
+	ev, err = NewEvaluator([]string{path}, "data.policy.violations", nil, true, 0, nil)
+	if err != nil {
+		t.Fatalf("NewEvaluator: %v", err)
+	}
+	if ev.Revision() != "2026-10-01.3" {
+		t.Errorf("bundle revision = %q, want the manifest revision", ev.Revision())
+	}
+}
+
+func TestEvaluator_Explain(t *testing.T) {
+	ev, err := compileAndPrepare(map[string]string{"policy.rego": decisionLogPolicy}, nil, "", "data.policy.violations", nil, true)
+	if err != nil {
+		t.Fatalf("compileAndPrepare: %v", err)
+	}
+	for _, mode := range []string{explainNotes, explainFull} {
+		_, explanation, err := ev.evaluate(context.Background(), []byte(decisionLogConfirm), nil, mode)
+		if err != nil {
+			t.Fatalf("%s: %v", mode, err)
+		}
+		if len(explanation) == 0 {
+			t.Errorf("%s: expected an explanation", mode)
+		}
+	}
+	if _, explanation, _ := ev.evaluate(context.Background(), []byte(decisionLogConfirm), nil, explainOff); explanation != nil {
+		t.Error("explain off must not trace")
+	}
+
+	// fails mode shows the expression that failed. The rule indexer skips
+	// rules on a plain equality mismatch, so the failure has to be computed.
+	ev, err = compileAndPrepare(map[string]string{"policy.rego": `
+package policy
+import rego.v1
+violations contains "too few items" if { count(input.message.order.items) < 2 }
+`}, nil, "", "data.policy.violations", nil, true)
+	if err != nil {
+		t.Fatalf("compileAndPrepare: %v", err)
+	}
+	_, explanation, err := ev.evaluate(context.Background(), []byte(`{"message": {"order": {"items": [1, 2, 3]}}}`), nil, explainFails)
+	if err != nil {
+		t.Fatalf("fails: %v", err)
+	}
+	if !strings.Contains(strings.Join(explanation, "\n"), "Fail") {
+		t.Errorf("fails: expected the failed expression, got %q", explanation)
+	}
+}
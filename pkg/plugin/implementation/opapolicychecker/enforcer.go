@@ -3,6 +3,7 @@ package opapolicychecker
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -13,6 +14,7 @@ import (
 	"github.com/beckn-one/beckn-onix/pkg/log"
 	"github.com/beckn-one/beckn-onix/pkg/model"
 	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+	"github.com/beckn-one/beckn-onix/pkg/telemetry"
 	"gopkg.in/yaml.v3"
 )
 
@@ -22,7 +24,14 @@ type Config struct {
 	RefreshInterval     time.Duration // 0 = disabled
 	Enabled             bool
 	DebugLogging        bool
-	RuntimeConfig       map[string]string
+	DecisionLogs        string // none, deny or all
+	DecisionLogExplain  string // off, notes, fails or full
+	// Parts of input.onix to look up for each message; see policyinput.go.
+	IncludeRegistryRecord     bool
+	IncludeTransactionHistory bool
+	TransactionHistoryLimit   int
+	IncludeManifest           bool
+	RuntimeConfig             map[string]string
 }
 
 type PolicyConfig struct {
@@ -30,6 +39,7 @@ type PolicyConfig struct {
 	Location      string
 	PolicyPaths   []string
 	Query         string
+	ResponseQuery string // optional; checks the upstream's synchronous response
 	Enabled       bool
 	FetchTimeout  time.Duration
 	IsBundle      bool
@@ -45,10 +55,16 @@ const (
 )
 
 var knownKeys = map[string]bool{
-	"networkPolicyConfig": true,
-	"enabled":             true,
-	"debugLogging":        true,
-	"refreshInterval":     true,
+	"networkPolicyConfig":       true,
+	"enabled":                   true,
+	"debugLogging":              true,
+	"refreshInterval":           true,
+	"decisionLogs":              true,
+	"decisionLogExplain":        true,
+	"includeRegistryRecord":     true,
+	"includeTransactionHistory": true,
+	"transactionHistoryLimit":   true,
+	"includeManifest":           true,
 }
 
 // policyEntryKnownKeys is matched against the post-flattening key shape produced
@@ -58,18 +74,23 @@ var policyEntryKnownKeys = map[string]bool{
 	"type":                            true,
 	"location":                        true,
 	"query":                           true,
+	"responseQuery":                   true,
 	"enabled":                         true,
 	"fetchTimeoutSeconds":             true,
 	"verification.enabled":            true,
 	"verification.publicKeyLookupUrl": true,
 	"verification.signatureLocation":  true,
 	"verification.algorithm":          true,
+	"verification.manifestLocation":   true,
 }
 
 func DefaultConfig() *Config {
 	return &Config{
-		Enabled:       true,
-		RuntimeConfig: make(map[string]string),
+		Enabled:                 true,
+		DecisionLogs:            decisionLogsNone,
+		DecisionLogExplain:      explainOff,
+		TransactionHistoryLimit: defaultTransactionHistoryLimit,
+		RuntimeConfig:           make(map[string]string),
 	}
 }
 
@@ -107,6 +128,41 @@ func ParseConfig(cfg map[string]string) (*Config, error) {
 		config.RefreshInterval = dur
 	}
 
+	if mode, ok := cfg["decisionLogs"]; ok && mode != "" {
+		switch mode {
+		case decisionLogsNone, decisionLogsDeny, decisionLogsAll:
+			config.DecisionLogs = mode
+		default:
+			return nil, fmt.Errorf("'decisionLogs' must be %s, %s or %s, got %q", decisionLogsNone, decisionLogsDeny, decisionLogsAll, mode)
+		}
+	}
+
+	if explain, ok := cfg["decisionLogExplain"]; ok && explain != "" {
+		switch explain {
+		case explainOff, explainNotes, explainFails, explainFull:
+			config.DecisionLogExplain = explain
+		default:
+			return nil, fmt.Errorf("'decisionLogExplain' must be %s, %s, %s or %s, got %q", explainOff, explainNotes, explainFails, explainFull, explain)
+		}
+	}
+
+	if v, ok := cfg["includeRegistryRecord"]; ok {
+		config.IncludeRegistryRecord = v == "true" || v == "1"
+	}
+	if v, ok := cfg["includeTransactionHistory"]; ok {
+		config.IncludeTransactionHistory = v == "true" || v == "1"
+	}
+	if v, ok := cfg["includeManifest"]; ok {
+		config.IncludeManifest = v == "true" || v == "1"
+	}
+	if limit, ok := cfg["transactionHistoryLimit"]; ok && limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("'transactionHistoryLimit' must be a positive integer, got %q", limit)
+		}
+		config.TransactionHistoryLimit = n
+	}
+
 	for k, v := range cfg {
 		if !knownKeys[k] {
 			config.RuntimeConfig[k] = v
@@ -165,6 +221,7 @@ func parsePolicyConfig(cfg map[string]string) (*PolicyConfig, error) {
 	} else if query := strings.TrimSpace(cfg["query"]); query != "" {
 		return nil, fmt.Errorf("'query' must not be set for type=%s", policyTypeManifest)
 	}
+	config.ResponseQuery = strings.TrimSpace(cfg["responseQuery"])
 
 
 	if enabled, ok := cfg["enabled"]; ok {
@@ -193,9 +250,18 @@ func parsePolicyConfig(cfg map[string]string) (*PolicyConfig, error) {
 				PublicKeyLookupURL: strings.TrimSpace(cfg["verification.publicKeyLookupUrl"]),
 				SignatureLocation:  strings.TrimSpace(cfg["verification.signatureLocation"]),
 				Algorithm:          strings.TrimSpace(cfg["verification.algorithm"]),
+				ManifestLocation:   strings.TrimSpace(cfg["verification.manifestLocation"]),
 			}
-			if config.Verification.Algorithm == "" {
-				config.Verification.Algorithm = defaultBundleVerificationAlgorithm
+			if alg := config.Verification.Algorithm; alg != "" {
+				if config.Type != policyTypeBundle {
+					return nil, fmt.Errorf("'verification.algorithm' only applies to type=%s; other sources take the algorithm from the key type", policyTypeBundle)
+				}
+				if _, ok := bundleVerificationAlgorithms[alg]; !ok {
+					return nil, fmt.Errorf("unsupported 'verification.algorithm' %q (expected ES256/384/512, RS256/384/512, PS256/384/512 or EdDSA)", alg)
+				}
+			}
+			if config.Verification.ManifestLocation != "" && config.Type != policyTypeDir {
+				return nil, fmt.Errorf("'verification.manifestLocation' only applies to type=%s", policyTypeDir)
 			}
 
 			if config.Verification.PublicKeyLookupURL == "" {
@@ -208,7 +274,8 @@ func parsePolicyConfig(cfg map[string]string) (*PolicyConfig, error) {
 					return nil, fmt.Errorf("'verification.signatureLocation' must not be set for type=%s; bundle signatures are read from inside the bundle", policyTypeBundle)
 				}
 			case policyTypeDir:
-				return nil, fmt.Errorf("verification is not supported for type=%s; package the directory as a signed bundle instead", policyTypeDir)
+				// The manifest and its signature default to manifest.json and
+				// manifest.json.sig inside the directory.
 			case policyTypeFile:
 				if config.Verification.SignatureLocation == "" {
 					return nil, fmt.Errorf("'verification.signatureLocation' is required when verification.enabled=true for type=%s", config.Type)
@@ -231,6 +298,7 @@ type loadedPolicy struct {
 	name                   string
 	config                 *PolicyConfig
 	evaluator              *Evaluator
+	responseEvaluator      *Evaluator // nil without a responseQuery
 	sourceType             string
 	manifestDeclaredSigned *bool
 	manifestVerified       bool
@@ -250,6 +318,8 @@ type networkPolicyFile struct {
 type PolicyEnforcer struct {
 	config         *Config
 	manifestLoader definition.ManifestLoader
+	registry       definition.RegistryLookup // set by SetRegistryLookup
+	payloadStore   definition.PayloadStore   // set by SetPayloadStore
 	policies       map[string]*loadedPolicy
 	defaultPolicy  *loadedPolicy
 	evaluatorMu    sync.RWMutex
@@ -391,7 +461,6 @@ func resolveManifestPolicyConfig(ctx context.Context, policyName string, baseCon
 			resolved.Verification = &ArtifactVerificationConfig{
 				Enabled:            true,
 				PublicKeyLookupURL: networkManifest.Policies.Bundle.SigningPublicKeyLookupURL,
-				Algorithm:          defaultBundleVerificationAlgorithm,
 			}
 		} else if strings.HasPrefix(networkManifest.Policies.Bundle.URL, "http://") {
 			log.Warnf(ctx, "OPAPolicyChecker: policy bundle for network %q uses cleartext HTTP and signing is disabled; a MITM can inject arbitrary Rego", policyName)
@@ -460,6 +529,11 @@ func loadPolicy(ctx context.Context, manifestLoader definition.ManifestLoader, p
 		return nil, err
 	}
 	loaded.evaluator = evaluator
+	if policyConfig.ResponseQuery != "" {
+		if loaded.responseEvaluator, err = evaluator.forQuery(policyConfig.ResponseQuery); err != nil {
+			return nil, err
+		}
+	}
 	return loaded, nil
 }
 
@@ -550,6 +624,9 @@ func NewWithManifestLoader(ctx context.Context, manifestLoader definition.Manife
 	if err != nil {
 		return nil, fmt.Errorf("opapolicychecker: config error: %w", err)
 	}
+	if config.IncludeManifest && manifestLoader == nil {
+		return nil, fmt.Errorf("opapolicychecker: includeManifest requires a ManifestLoader plugin")
+	}
 
 	enforcer := &PolicyEnforcer{
 		config:         config,
@@ -679,12 +756,41 @@ func (e *PolicyEnforcer) CheckPolicy(ctx *model.StepContext) error {
 		log.Debugf(ctx, "OPAPolicyChecker: evaluating policy for networkID=%q action=%q (modules=%v)", reqCtx.NetworkID, reqCtx.Action, ev.ModuleNames())
 	}
 
+	onix, err := e.policyContext(ctx, reqCtx)
+	if err != nil {
+		log.Errorf(ctx, err, "OPAPolicyChecker: failed to build policy input for networkID=%q%s: %v", reqCtx.NetworkID, formatRequestLogContext(reqCtx), err)
+		return model.NewBadReqErr("POL_GENERIC_ERROR", fmt.Errorf("policy input error: %w", err))
+	}
+	return e.decide(ctx, policy, ev, reqCtx, ctx.Body, onix)
+}
+
+// errPolicyEvaluation marks a decide error raised because the policy could
+// not be evaluated, rather than because it found violations.
+var errPolicyEvaluation = errors.New("policy evaluation error")
+
+// decide evaluates body with ev, logs the decision and maps the outcome to
+// CheckPolicy's errors. It is shared by the request and response checks.
+func (e *PolicyEnforcer) decide(ctx *model.StepContext, policy *loadedPolicy, ev *Evaluator, reqCtx parsedRequestContext, body []byte, onix map[string]interface{}) error {
 	requestLogCtx := formatRequestLogContext(reqCtx)
 
-	violations, err := ev.Evaluate(ctx, ctx.Body)
+	// Trace whenever a decision may be logged, so the explanation comes from
+	// the evaluation that made the decision. In "deny" mode the trace of an
+	// allowed message is discarded.
+	explain := explainOff
+	if e.config.DecisionLogs != decisionLogsNone && telemetry.LogsEnabled() {
+		explain = e.config.DecisionLogExplain
+	}
+	start := time.Now()
+	violations, explanation, err := ev.evaluate(ctx, body, onix, explain)
+	e.logDecision(ctx, policy, ev, reqCtx, body, onix, decisionOutcome{
+		violations:  violations,
+		err:         err,
+		explanation: explanation,
+		duration:    time.Since(start),
+	})
 	if err != nil {
 		log.Errorf(ctx, err, "OPAPolicyChecker: policy evaluation failed for networkID=%q%s: %v", reqCtx.NetworkID, requestLogCtx, err)
-		return model.NewBadReqErr("POL_GENERIC_ERROR", fmt.Errorf("policy evaluation error: %w", err))
+		return model.NewBadReqErr("POL_GENERIC_ERROR", fmt.Errorf("%w: %w", errPolicyEvaluation, err))
 	}
 
 	if len(violations) == 0 {
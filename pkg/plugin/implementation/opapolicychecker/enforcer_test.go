@@ -4,11 +4,16 @@ import (
 	"bytes"
 	"context"
 	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
 	"crypto/x509"
 	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"encoding/pem"
 	"errors"
 	"fmt"
@@ -1204,17 +1209,41 @@ func TestParsePolicyConfig_BundleType(t *testing.T) {
 	}
 }
 
-func TestParsePolicyConfig_VerificationForDirRejected(t *testing.T) {
-	_, err := parsePolicyConfig(map[string]string{
+func TestParsePolicyConfig_VerificationForDir(t *testing.T) {
+	cfg, err := parsePolicyConfig(map[string]string{
 		"type":                            "dir",
 		"location":                        "/tmp/policies",
 		"query":                           "data.policy.result",
 		"verification.enabled":            "true",
 		"verification.publicKeyLookupUrl": "/tmp/public.pem",
-		"verification.signatureLocation":  "/tmp/policies.sig",
+		"verification.manifestLocation":   "/tmp/policies.manifest.json",
+		"verification.signatureLocation":  "/tmp/policies.manifest.json.sig",
 	})
-	if err == nil {
-		t.Fatal("expected error when verification is enabled for dir")
+	if err != nil {
+		t.Fatalf("expected a signed directory config to parse, got: %v", err)
+	}
+	if cfg.Verification == nil || cfg.Verification.ManifestLocation != "/tmp/policies.manifest.json" ||
+		cfg.Verification.SignatureLocation != "/tmp/policies.manifest.json.sig" {
+		t.Fatalf("unexpected verification config: %+v", cfg.Verification)
+	}
+
+	for name, extra := range map[string]map[string]string{
+		"algorithm":                 {"verification.algorithm": "EdDSA"},
+		"manifestLocation for file": {"type": "file", "verification.signatureLocation": "/tmp/p.sig", "verification.manifestLocation": "/tmp/m.json"},
+	} {
+		raw := map[string]string{
+			"type":                            "dir",
+			"location":                        "/tmp/policies",
+			"query":                           "data.policy.result",
+			"verification.enabled":            "true",
+			"verification.publicKeyLookupUrl": "/tmp/public.pem",
+		}
+		for k, v := range extra {
+			raw[k] = v
+		}
+		if _, err := parsePolicyConfig(raw); err == nil {
+			t.Errorf("%s: expected a config error", name)
+		}
 	}
 }
 
@@ -2774,3 +2803,312 @@ func TestEnforcer_DisabledSkipsEvaluatorInitialization(t *testing.T) {
 		t.Fatal("expected disabled enforcer to skip policy initialization")
 	}
 }
+
+// --- EdDSA bundles and signed directories ---
+
+// ed25519TestKey returns a fresh Ed25519 key with its PKCS#8 and PKIX PEMs.
+func ed25519TestKey(t *testing.T) (ed25519.PrivateKey, string, string) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate Ed25519 key: %v", err)
+	}
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatalf("failed to marshal private key: %v", err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatalf("failed to marshal public key: %v", err)
+	}
+	return priv,
+		string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}))
+}
+
+func buildEdDSASignedTestBundle(t *testing.T, privatePEM string, modules map[string]string) []byte {
+	t.Helper()
+	b := bundle.Bundle{Data: make(map[string]interface{})}
+	for path, content := range modules {
+		b.Modules = append(b.Modules, bundle.ModuleFile{URL: path, Path: path, Raw: []byte(content)})
+	}
+	if err := b.GenerateSignature(bundle.NewSigningConfig(privatePEM, "EdDSA", ""), defaultBundleVerificationKeyID, false); err != nil {
+		t.Fatalf("failed to sign test bundle: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := bundle.Write(&buf, b); err != nil {
+		t.Fatalf("failed to write signed test bundle: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestEvaluator_EdDSABundleVerification(t *testing.T) {
+	policy := `
+package retail.validation
+
+import rego.v1
+
+default result := {"valid": true, "violations": []}
+`
+	_, privatePEM, publicPEM := ed25519TestKey(t)
+	_, _, otherPublicPEM := ed25519TestKey(t)
+	bundleData := buildEdDSASignedTestBundle(t, privatePEM, map[string]string{"retail/validation.rego": policy})
+
+	dir := t.TempDir()
+	bundlePath := filepath.Join(dir, "signed-bundle.tar.gz")
+	publicKeyPath := filepath.Join(dir, "public.pem")
+	otherKeyPath := filepath.Join(dir, "other.pem")
+	for path, data := range map[string][]byte{bundlePath: bundleData, publicKeyPath: []byte(publicPEM), otherKeyPath: []byte(otherPublicPEM)} {
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+
+	load := func(keyPath, algorithm string) error {
+		_, err := NewEvaluator([]string{bundlePath}, "data.retail.validation.result", nil, true, 0, &ArtifactVerificationConfig{
+			Enabled:            true,
+			PublicKeyLookupURL: keyPath,
+			Algorithm:          algorithm,
+		})
+		return err
+	}
+
+	if err := load(publicKeyPath, "EdDSA"); err != nil {
+		t.Fatalf("EdDSA bundle with algorithm EdDSA: %v", err)
+	}
+	if err := load(publicKeyPath, ""); err != nil {
+		t.Fatalf("EdDSA bundle with the algorithm taken from the key: %v", err)
+	}
+	if err := load(publicKeyPath, "ES256"); err == nil || !strings.Contains(err.Error(), "needs an ECDSA key") {
+		t.Fatalf("expected an algorithm/key mismatch error, got: %v", err)
+	}
+	if err := load(otherKeyPath, ""); err == nil {
+		t.Fatal("expected verification with another Ed25519 key to fail")
+	}
+}
+
+func TestBundleAlgorithm(t *testing.T) {
+	edPub, _, _ := ed25519.GenerateKey(rand.Reader)
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		configured string
+		key        any
+		want       string
+		wantErr    bool
+	}{
+		{"", edPub, "EdDSA", false},
+		{"", &p384.PublicKey, "ES384", false},
+		{"", &rsaKey.PublicKey, "RS256", false},
+		{"PS512", &rsaKey.PublicKey, "PS512", false},
+		{"EdDSA", &rsaKey.PublicKey, "", true},
+		{"HS256", edPub, "", true},
+	}
+	for _, tt := range tests {
+		got, err := bundleAlgorithm(tt.configured, tt.key)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("bundleAlgorithm(%q, %T) = %q, %v; want %q (error: %t)", tt.configured, tt.key, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParsePolicyConfig_BundleAlgorithm(t *testing.T) {
+	base := map[string]string{
+		"type":                            "bundle",
+		"location":                        "/tmp/policies.tar.gz",
+		"query":                           "data.policy.result",
+		"verification.enabled":            "true",
+		"verification.publicKeyLookupUrl": "/tmp/public.pem",
+	}
+	cfg, err := parsePolicyConfig(base)
+	if err != nil {
+		t.Fatalf("parsePolicyConfig: %v", err)
+	}
+	if cfg.Verification.Algorithm != "" {
+		t.Fatalf("an unset algorithm must be left for the key type to decide, got %q", cfg.Verification.Algorithm)
+	}
+
+	base["verification.algorithm"] = "EdDSA"
+	if _, err := parsePolicyConfig(base); err != nil {
+		t.Fatalf("EdDSA must be accepted: %v", err)
+	}
+	base["verification.algorithm"] = "HS256"
+	if _, err := parsePolicyConfig(base); err == nil {
+		t.Fatal("expected an unsupported algorithm to be rejected")
+	}
+}
+
+// signedPolicyDir writes files into dir with a manifest.json listing their
+// SHA-256 digests and a base64 Ed25519 signature over it in manifest.json.sig.
+func signedPolicyDir(t *testing.T, dir string, priv ed25519.PrivateKey, files map[string]string) {
+	t.Helper()
+	var manifest dirManifest
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+		sum := sha256.Sum256([]byte(content))
+		manifest.Files = append(manifest.Files, dirManifestFile{Name: name, Algorithm: "SHA-256", Hash: hex.EncodeToString(sum[:])})
+	}
+	manifestBytes, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatalf("failed to marshal manifest: %v", err)
+	}
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, manifestBytes))
+	if err := os.WriteFile(filepath.Join(dir, "manifest.json"), manifestBytes, 0644); err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "manifest.json.sig"), []byte(signature), 0644); err != nil {
+		t.Fatalf("failed to write manifest signature: %v", err)
+	}
+}
+
+const signedDirPolicy = `
+package retail.validation
+
+import rego.v1
+
+result := {"valid": count(violations) == 0, "violations": violations}
+`
+
+const signedDirRules = `
+package retail.validation
+
+import rego.v1
+
+violations contains "missing provider" if not input.message.order.provider
+`
+
+func TestEvaluator_SignedDirectory(t *testing.T) {
+	priv, _, publicPEM := ed25519TestKey(t)
+	keyDir := t.TempDir()
+	publicKeyPath := filepath.Join(keyDir, "public.pem")
+	if err := os.WriteFile(publicKeyPath, []byte(publicPEM), 0644); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{"policy.rego": signedDirPolicy, "rules.rego": signedDirRules}
+	verification := &ArtifactVerificationConfig{Enabled: true, PublicKeyLookupURL: publicKeyPath}
+	load := func(dir string) (*Evaluator, error) {
+		return NewEvaluator([]string{dir}, "data.retail.validation.result", nil, false, 0, verification)
+	}
+
+	dir := t.TempDir()
+	signedPolicyDir(t, dir, priv, files)
+	eval, err := load(dir)
+	if err != nil {
+		t.Fatalf("signed directory: %v", err)
+	}
+	violations, err := eval.Evaluate(context.Background(), []byte(`{"message":{"order":{}}}`))
+	if err != nil || len(violations) != 1 {
+		t.Fatalf("expected the policy from both files to apply, got %v, %v", violations, err)
+	}
+
+	tests := []struct {
+		name    string
+		tamper  func(dir string)
+		wantErr string
+	}{
+		{"modified file", func(dir string) {
+			os.WriteFile(filepath.Join(dir, "rules.rego"), []byte(strings.Replace(signedDirRules, "violations contains", "_unused contains", 1)), 0644)
+		}, "digest mismatch"},
+		{"unlisted file", func(dir string) {
+			os.WriteFile(filepath.Join(dir, "extra.rego"), []byte("package retail.validation\n"), 0644)
+		}, "not listed in the signed manifest"},
+		{"removed file", func(dir string) {
+			os.Remove(filepath.Join(dir, "rules.rego"))
+		}, "missing from"},
+		{"forged manifest", func(dir string) {
+			os.WriteFile(filepath.Join(dir, "manifest.json"), []byte(`{"files":[]}`), 0644)
+		}, "signature verification failed"},
+		{"missing signature", func(dir string) {
+			os.Remove(filepath.Join(dir, "manifest.json.sig"))
+		}, "manifest signature"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			signedPolicyDir(t, dir, priv, files)
+			tt.tamper(dir)
+			if _, err := load(dir); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	t.Run("manifest outside the directory", func(t *testing.T) {
+		dir := t.TempDir()
+		signedPolicyDir(t, dir, priv, files)
+		elsewhere := t.TempDir()
+		for _, name := range []string{"manifest.json", "manifest.json.sig"} {
+			if err := os.Rename(filepath.Join(dir, name), filepath.Join(elsewhere, name)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := load(dir); err == nil {
+			t.Fatal("expected the default manifest location to be missing")
+		}
+		moved := *verification
+		moved.ManifestLocation = filepath.Join(elsewhere, "manifest.json")
+		if _, err := NewEvaluator([]string{dir}, "data.retail.validation.result", nil, false, 0, &moved); err != nil {
+			t.Fatalf("manifestLocation: %v", err)
+		}
+	})
+}
+
+func TestEnforcer_SignedDirectoryReload(t *testing.T) {
+	priv, _, publicPEM := ed25519TestKey(t)
+	keyPath := filepath.Join(t.TempDir(), "public.pem")
+	if err := os.WriteFile(keyPath, []byte(publicPEM), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	signedPolicyDir(t, dir, priv, map[string]string{"policy.rego": signedDirPolicy, "rules.rego": signedDirRules})
+
+	configPath := writeDefaultOnlyNetworkPolicyConfig(t, fmt.Sprintf(`type: dir
+location: %s
+query: data.retail.validation.result
+verification:
+  enabled: true
+  publicKeyLookupUrl: %s
+`, dir, keyPath))
+	enforcer, err := New(context.Background(), map[string]string{"networkPolicyConfig": configPath})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer enforcer.Close()
+	loaded := enforcer.selectedPolicy("")
+
+	// A file changed without re-signing fails the reload; the verified
+	// policy stays in force.
+	if err := os.WriteFile(filepath.Join(dir, "rules.rego"), []byte("package retail.validation\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	enforcer.reloadPolicies(context.Background())
+	if enforcer.selectedPolicy("") != loaded {
+		t.Fatal("a tampered directory must not replace the loaded policy")
+	}
+	body := []byte(`{"context":{"action":"confirm"},"message":{"order":{}}}`)
+	if err := enforcer.CheckPolicy(&model.StepContext{Context: context.Background(), Body: body}); err == nil {
+		t.Fatal("expected the previously verified policy to still reject the message")
+	}
+
+	// Re-signed, the new content is picked up.
+	signedPolicyDir(t, dir, priv, map[string]string{
+		"policy.rego": signedDirPolicy,
+		"rules.rego":  "package retail.validation\n\nimport rego.v1\n\nviolations := set()\n",
+	})
+	enforcer.reloadPolicies(context.Background())
+	if enforcer.selectedPolicy("") == loaded {
+		t.Fatal("expected the re-signed directory to be reloaded")
+	}
+	if err := enforcer.CheckPolicy(&model.StepContext{Context: context.Background(), Body: body}); err != nil {
+		t.Fatalf("expected the re-signed policy to allow the message, got: %v", err)
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"crypto/ecdsa"
 	"crypto/ed25519"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
+	"encoding/hex"
 	"encoding/json"
 	"encoding/pem"
 	"fmt"
@@ -16,6 +18,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -24,6 +27,8 @@ import (
 	"github.com/open-policy-agent/opa/v1/keys"
 	"github.com/open-policy-agent/opa/v1/rego"
 	"github.com/open-policy-agent/opa/v1/storage/inmem"
+	"github.com/open-policy-agent/opa/v1/topdown"
+	"github.com/open-policy-agent/opa/v1/topdown/lineage"
 
 	"github.com/beckn-one/beckn-onix/pkg/log"
 	"github.com/beckn-one/beckn-onix/pkg/model"
@@ -34,9 +39,12 @@ import (
 // then evaluates messages against the compiled policy set.
 type Evaluator struct {
 	preparedQuery   rego.PreparedEvalQuery
+	compiler        *ast.Compiler
+	store           map[string]interface{} // data.* the query is prepared against
 	query           string
 	runtimeConfig   map[string]string
 	moduleNames     []string // names of loaded .rego modules
+	revision        string   // bundle manifest revision, or a digest of the modules
 	failOnUndefined bool     // if true, empty/undefined results are treated as violations
 }
 
@@ -45,6 +53,13 @@ func (e *Evaluator) ModuleNames() []string {
 	return e.moduleNames
 }
 
+// Revision identifies the loaded policy set in decision logs: the bundle's
+// .manifest revision when it declares one, otherwise "sha256:" and a digest
+// of the module names and sources.
+func (e *Evaluator) Revision() string {
+	return e.revision
+}
+
 // defaultPolicyFetchTimeout bounds remote policy and bundle fetches during startup
 // and refresh. This can be overridden via config.fetchTimeoutSeconds.
 const defaultPolicyFetchTimeout = 30 * time.Second
@@ -56,13 +71,22 @@ const maxPolicySize = 1 << 20
 const maxBundleSize = 10 << 20
 
 const defaultBundleVerificationKeyID = "default"
-const defaultBundleVerificationAlgorithm = "ES256"
+
+// bundleVerificationAlgorithms maps each JWS algorithm accepted for signed
+// bundles to the key type it needs.
+var bundleVerificationAlgorithms = map[string]string{
+	"ES256": "ECDSA", "ES384": "ECDSA", "ES512": "ECDSA",
+	"RS256": "RSA", "RS384": "RSA", "RS512": "RSA",
+	"PS256": "RSA", "PS384": "RSA", "PS512": "RSA",
+	"EdDSA": "Ed25519",
+}
 
 type ArtifactVerificationConfig struct {
 	Enabled            bool
 	PublicKeyLookupURL string
 	SignatureLocation  string
-	Algorithm          string
+	Algorithm          string // bundles only; "" = chosen from the key type
+	ManifestLocation   string // signed directories only
 }
 
 // NewEvaluator creates an Evaluator by loading .rego files from local paths
@@ -86,6 +110,13 @@ func newRegoEvaluator(policyPaths []string, query string, runtimeConfig map[stri
 		if len(policyPaths) != 1 {
 			return nil, fmt.Errorf("artifact verification requires exactly one policy source")
 		}
+		if info, err := os.Stat(policyPaths[0]); err == nil && info.IsDir() {
+			modules, err := loadSignedDirectory(policyPaths[0], verification, fetchTimeout)
+			if err != nil {
+				return nil, err
+			}
+			return compileAndPrepare(modules, nil, "", query, runtimeConfig, true)
+		}
 
 		name, policyBytes, err := loadSinglePolicy(policyPaths[0], fetchTimeout)
 		if err != nil {
@@ -107,7 +138,7 @@ func newRegoEvaluator(policyPaths []string, query string, runtimeConfig map[stri
 		}
 
 		modules[name] = string(policyBytes)
-		return compileAndPrepare(modules, nil, query, runtimeConfig, true)
+		return compileAndPrepare(modules, nil, "", query, runtimeConfig, true)
 	}
 
 	// Load from policyPaths (resolved locations based on config Type)
@@ -149,7 +180,7 @@ func newRegoEvaluator(policyPaths []string, query string, runtimeConfig map[stri
 		return nil, fmt.Errorf("no .rego policy files found from any configured source")
 	}
 
-	return compileAndPrepare(modules, nil, query, runtimeConfig, true)
+	return compileAndPrepare(modules, nil, "", query, runtimeConfig, true)
 }
 
 // newBundleEvaluator loads an OPA bundle (.tar.gz) from a local path or URL and compiles it.
@@ -159,7 +190,7 @@ func newBundleEvaluator(policyPaths []string, query string, runtimeConfig map[st
 	}
 
 	bundleSource := policyPaths[0]
-	modules, bundleData, err := loadBundle(bundleSource, fetchTimeout, verification)
+	modules, bundleData, revision, err := loadBundle(bundleSource, fetchTimeout, verification)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load bundle from %s: %w", bundleSource, err)
 	}
@@ -168,24 +199,24 @@ func newBundleEvaluator(policyPaths []string, query string, runtimeConfig map[st
 		return nil, fmt.Errorf("no .rego policy modules found in bundle from %s", bundleSource)
 	}
 
-	return compileAndPrepare(modules, bundleData, query, runtimeConfig, true)
+	return compileAndPrepare(modules, bundleData, revision, query, runtimeConfig, true)
 }
 
 // loadBundle downloads a .tar.gz OPA bundle from a URL, parses it using OPA's
-// bundle reader, and returns the modules and data from the bundle.
-func loadBundle(bundleSource string, fetchTimeout time.Duration, verification *ArtifactVerificationConfig) (map[string]string, map[string]interface{}, error) {
+// bundle reader, and returns the modules, data and manifest revision from the bundle.
+func loadBundle(bundleSource string, fetchTimeout time.Duration, verification *ArtifactVerificationConfig) (map[string]string, map[string]interface{}, string, error) {
 	data, err := readArtifact(bundleSource, maxBundleSize, fetchTimeout)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, "", err
 	}
 
 	return parseBundleArchive(data, verification, fetchTimeout)
 }
 
 // parseBundleArchive parses a .tar.gz OPA bundle archive and extracts
-// rego modules and data. Signature verification uses OPA's native bundle
+// rego modules, data and the manifest revision. Signature verification uses OPA's native bundle
 // verification when enabled.
-func parseBundleArchive(data []byte, verification *ArtifactVerificationConfig, fetchTimeout time.Duration) (map[string]string, map[string]interface{}, error) {
+func parseBundleArchive(data []byte, verification *ArtifactVerificationConfig, fetchTimeout time.Duration) (map[string]string, map[string]interface{}, string, error) {
 	loader := bundle.NewTarballLoaderWithBaseURL(bytes.NewReader(data), "")
 	reader := bundle.NewCustomReader(loader).
 		WithRegoVersion(ast.RegoV1)
@@ -193,27 +224,27 @@ func parseBundleArchive(data []byte, verification *ArtifactVerificationConfig, f
 	if verification != nil && verification.Enabled {
 		publicKeyBody, err := readArtifact(verification.PublicKeyLookupURL, maxPolicySize, fetchTimeout)
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed to load bundle verification public key from %s: %w", verification.PublicKeyLookupURL, err)
+			return nil, nil, "", fmt.Errorf("failed to load bundle verification public key from %s: %w", verification.PublicKeyLookupURL, err)
 		}
 
 		publicKey, err := artifactverifier.ParsePublicKeyResponse(publicKeyBody)
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed to parse bundle verification public key from %s: %w", verification.PublicKeyLookupURL, err)
+			return nil, nil, "", fmt.Errorf("failed to parse bundle verification public key from %s: %w", verification.PublicKeyLookupURL, err)
 		}
 
 		pemKey, err := publicKeyToPEM(publicKey)
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed to encode bundle verification key: %w", err)
+			return nil, nil, "", fmt.Errorf("failed to encode bundle verification key: %w", err)
 		}
 
-		algorithm := verification.Algorithm
-		if algorithm == "" {
-			algorithm = defaultBundleVerificationAlgorithm
+		algorithm, err := bundleAlgorithm(verification.Algorithm, publicKey)
+		if err != nil {
+			return nil, nil, "", err
 		}
 
 		keyConfig, err := keys.NewKeyConfig(string(pemKey), algorithm, "")
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed to parse bundle verification key: %w", err)
+			return nil, nil, "", fmt.Errorf("failed to parse bundle verification key: %w", err)
 		}
 
 		reader = reader.WithBundleVerificationConfig(
@@ -225,7 +256,7 @@ func parseBundleArchive(data []byte, verification *ArtifactVerificationConfig, f
 
 	b, err := reader.Read()
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to read bundle: %w", err)
+		return nil, nil, "", fmt.Errorf("failed to read bundle: %w", err)
 	}
 
 	modules := make(map[string]string, len(b.Modules))
@@ -233,7 +264,44 @@ func parseBundleArchive(data []byte, verification *ArtifactVerificationConfig, f
 		modules[m.Path] = string(m.Raw)
 	}
 
-	return modules, b.Data, nil
+	return modules, b.Data, b.Manifest.Revision, nil
+}
+
+// bundleAlgorithm returns the algorithm to verify a bundle signature with:
+// the configured one, checked against the key's type, or else the key type's
+// natural choice — EdDSA for Ed25519, ES256/ES384/ES512 by ECDSA curve, RS256
+// for RSA.
+func bundleAlgorithm(configured string, key any) (string, error) {
+	var keyType, natural string
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		keyType, natural = "Ed25519", "EdDSA"
+	case *ecdsa.PublicKey:
+		keyType = "ECDSA"
+		switch k.Curve.Params().BitSize {
+		case 384:
+			natural = "ES384"
+		case 521:
+			natural = "ES512"
+		default:
+			natural = "ES256"
+		}
+	case *rsa.PublicKey:
+		keyType, natural = "RSA", "RS256"
+	default:
+		return "", fmt.Errorf("unsupported bundle verification key type %T", key)
+	}
+	if configured == "" {
+		return natural, nil
+	}
+	want, ok := bundleVerificationAlgorithms[configured]
+	if !ok {
+		return "", fmt.Errorf("unsupported bundle verification algorithm %q", configured)
+	}
+	if want != keyType {
+		return "", fmt.Errorf("bundle verification algorithm %s needs an %s key, but the published key is %s", configured, want, keyType)
+	}
+	return configured, nil
 }
 
 func loadSinglePolicy(source string, fetchTimeout time.Duration) (string, []byte, error) {
@@ -317,7 +385,8 @@ func publicKeyToPEM(key any) ([]byte, error) {
 }
 
 // compileAndPrepare compiles rego modules and prepares the OPA query for evaluation.
-func compileAndPrepare(modules map[string]string, bundleData map[string]interface{}, query string, runtimeConfig map[string]string, failOnUndefined bool) (*Evaluator, error) {
+// An empty revision is replaced by a digest of the modules.
+func compileAndPrepare(modules map[string]string, bundleData map[string]interface{}, revision, query string, runtimeConfig map[string]string, failOnUndefined bool) (*Evaluator, error) {
 	// Compile modules to catch syntax errors early
 	compiler, err := ast.CompileModulesWithOpt(modules, ast.CompileOpts{ParserOptions: ast.ParserOptions{RegoVersion: ast.RegoV1}})
 	if err != nil {
@@ -331,29 +400,74 @@ func compileAndPrepare(modules map[string]string, bundleData map[string]interfac
 	}
 	store["config"] = toInterfaceMap(runtimeConfig)
 
-	pq, err := rego.New(
-		rego.Query(query),
-		rego.Compiler(compiler),
-		rego.Store(inmem.NewFromObject(store)),
-	).PrepareForEval(context.Background())
+	pq, err := prepareQuery(compiler, store, query)
 	if err != nil {
-		return nil, fmt.Errorf("failed to prepare rego query %q: %w", query, err)
+		return nil, err
 	}
 
 	names := make([]string, 0, len(modules))
 	for name := range modules {
 		names = append(names, name)
 	}
+	if revision == "" {
+		revision = modulesDigest(modules)
+	}
 
 	return &Evaluator{
 		preparedQuery:   pq,
+		compiler:        compiler,
+		store:           store,
 		query:           query,
 		runtimeConfig:   runtimeConfig,
 		moduleNames:     names,
+		revision:        revision,
 		failOnUndefined: failOnUndefined,
 	}, nil
 }
 
+func prepareQuery(compiler *ast.Compiler, store map[string]interface{}, query string) (rego.PreparedEvalQuery, error) {
+	pq, err := rego.New(
+		rego.Query(query),
+		rego.Compiler(compiler),
+		rego.Store(inmem.NewFromObject(store)),
+	).PrepareForEval(context.Background())
+	if err != nil {
+		return rego.PreparedEvalQuery{}, fmt.Errorf("failed to prepare rego query %q: %w", query, err)
+	}
+	return pq, nil
+}
+
+// forQuery returns an Evaluator for another query over the same compiled
+// modules and data, so a policy's response rules need no second load.
+func (e *Evaluator) forQuery(query string) (*Evaluator, error) {
+	pq, err := prepareQuery(e.compiler, e.store, query)
+	if err != nil {
+		return nil, err
+	}
+	ev := *e
+	ev.preparedQuery = pq
+	ev.query = query
+	return &ev, nil
+}
+
+// modulesDigest hashes the module names and sources in name order, so the
+// same policy set always gets the same revision wherever it was loaded from.
+func modulesDigest(modules map[string]string) string {
+	names := make([]string, 0, len(modules))
+	for name := range modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	h := sha256.New()
+	for _, name := range names {
+		h.Write([]byte(name))
+		h.Write([]byte{0})
+		h.Write([]byte(modules[name]))
+		h.Write([]byte{0})
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
 // isURL checks if a source string looks like a remote URL.
 func isURL(source string) bool {
 	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
@@ -409,23 +523,85 @@ func fetchPolicy(rawURL string, fetchTimeout time.Duration) (string, string, err
 // extractStructuredViolations) — otherwise it's left empty for the caller to
 // fall back to a generic classification.
 func (e *Evaluator) Evaluate(ctx context.Context, body []byte) ([]model.Error, error) {
+	violations, _, err := e.evaluate(ctx, body, nil, explainOff)
+	return violations, err
+}
+
+// evaluate is Evaluate that, unless explain is explainOff, also traces the
+// evaluation and returns the explanation in OPA's pretty-printed trace format.
+// A non-nil onix is set as input.onix, replacing any "onix" the body carries.
+func (e *Evaluator) evaluate(ctx context.Context, body []byte, onix map[string]interface{}, explain string) ([]model.Error, []string, error) {
 	var input interface{}
 	if err := json.Unmarshal(body, &input); err != nil {
-		return nil, fmt.Errorf("failed to parse message body as JSON: %w", err)
+		return nil, nil, fmt.Errorf("failed to parse message body as JSON: %w", err)
+	}
+	if obj, ok := input.(map[string]interface{}); ok && onix != nil {
+		obj[policyInputKey] = onix
 	}
 
-	rs, err := e.preparedQuery.Eval(ctx, rego.EvalInput(input))
+	opts := []rego.EvalOption{rego.EvalInput(input)}
+	var tracer *topdown.BufferTracer
+	if explain != explainOff {
+		tracer = topdown.NewBufferTracer()
+		opts = append(opts, rego.EvalQueryTracer(tracer))
+	}
+
+	rs, err := e.preparedQuery.Eval(ctx, opts...)
+	explanation := renderExplanation(tracer, explain)
 	if err != nil {
-		return nil, fmt.Errorf("rego evaluation failed: %w", err)
+		return nil, explanation, fmt.Errorf("rego evaluation failed: %w", err)
 	}
 
 	// Fail-closed for bundles: if the query returned no result, the policy_query_path
 	// is likely misconfigured or the rule doesn't exist in the bundle.
 	if e.failOnUndefined && len(rs) == 0 {
-		return []model.Error{{Message: fmt.Sprintf("policy query %q returned no result (undefined)", e.query)}}, nil
+		return []model.Error{{Message: fmt.Sprintf("policy query %q returned no result (undefined)", e.query)}}, explanation, nil
 	}
 
-	return extractViolations(ctx, rs)
+	violations, err := extractViolations(ctx, rs)
+	return violations, explanation, err
+}
+
+// Explanation modes, as accepted by decisionLogExplain and the policy test
+// harness. They match `opa eval --explain`.
+const (
+	explainOff   = "off"
+	explainNotes = "notes" // only trace() notes and the rules leading to them
+	explainFails = "fails" // only the expressions that failed
+	explainFull  = "full"  // the whole evaluation
+)
+
+// maxExplanationLines caps an explanation; a full trace of a large policy
+// runs to thousands of lines.
+const maxExplanationLines = 500
+
+// renderExplanation filters a recorded trace down to the explain mode and
+// pretty-prints it one line per event.
+func renderExplanation(tracer *topdown.BufferTracer, explain string) []string {
+	if tracer == nil {
+		return nil
+	}
+	events := []*topdown.Event(*tracer)
+	switch explain {
+	case explainNotes:
+		events = lineage.Notes(events)
+	case explainFails:
+		events = lineage.Fails(events)
+	default:
+		events = lineage.Full(events)
+	}
+
+	var buf bytes.Buffer
+	topdown.PrettyTraceWithLocation(&buf, events)
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) == 1 && lines[0] == "" {
+		return nil
+	}
+	if len(lines) > maxExplanationLines {
+		omitted := len(lines) - maxExplanationLines
+		lines = append(lines[:maxExplanationLines], fmt.Sprintf("... %d more lines", omitted))
+	}
+	return lines
 }
 
 // genericDenialMessage is used whenever a policy result clearly signals a
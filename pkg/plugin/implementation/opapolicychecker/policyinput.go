@@ -0,0 +1,227 @@
+package opapolicychecker
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+)
+
+// policyInputKey is the top-level input key the adapter's own context is
+// added under. It is reserved: a message that carries it has it replaced,
+// so a sender cannot forge its own registry record or history.
+const policyInputKey = "onix"
+
+// defaultTransactionHistoryLimit bounds input.onix.transaction when
+// transactionHistoryLimit is not set.
+const defaultTransactionHistoryLimit = 50
+
+// transactionMessage is one earlier message of the transaction as policies
+// see it under input.onix.transaction.
+type transactionMessage struct {
+	MessageID    string      `json:"message_id"`
+	Action       string      `json:"action"`
+	SubscriberID string      `json:"subscriber_id,omitempty"`
+	Role         model.Role  `json:"role,omitempty"`
+	NetworkID    string      `json:"network_id,omitempty"`
+	StoredAt     time.Time   `json:"stored_at"`
+	Body         interface{} `json:"body,omitempty"` // absent when the store keeps no bodies
+
+	rawBody []byte // Body as stored, for redacting decision logs
+}
+
+// SetRegistryLookup receives the handler's RegistryLookup, used for
+// input.onix.sender. It implements definition.RegistryAwarePolicyChecker.
+func (e *PolicyEnforcer) SetRegistryLookup(registry definition.RegistryLookup) error {
+	if registry == nil && e.config.IncludeRegistryRecord {
+		return fmt.Errorf("opapolicychecker: includeRegistryRecord requires a Registry plugin")
+	}
+	e.registry = registry
+	return nil
+}
+
+// SetPayloadStore receives the handler's PayloadStore, used for
+// input.onix.transaction. It implements
+// definition.PayloadStoreAwarePolicyChecker.
+func (e *PolicyEnforcer) SetPayloadStore(store definition.PayloadStore) error {
+	if store == nil && e.config.IncludeTransactionHistory {
+		return fmt.Errorf("opapolicychecker: includeTransactionHistory requires a PayloadStore plugin")
+	}
+	e.payloadStore = store
+	return nil
+}
+
+var (
+	_ definition.RegistryAwarePolicyChecker     = (*PolicyEnforcer)(nil)
+	_ definition.PayloadStoreAwarePolicyChecker = (*PolicyEnforcer)(nil)
+)
+
+// policyContext builds input.onix for a message: only the parts the config
+// asks for are looked up, so a policy that needs none of them costs nothing.
+// Lookup failures are returned rather than leaving a part out, since a rule
+// reading a missing part would silently not fire.
+func (e *PolicyEnforcer) policyContext(ctx *model.StepContext, req parsedRequestContext) (map[string]interface{}, error) {
+	onix := make(map[string]interface{}, 3)
+
+	if e.config.IncludeRegistryRecord {
+		sender, err := e.senderRecord(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		onix["sender"] = sender
+	}
+
+	if e.config.IncludeTransactionHistory {
+		history, err := e.transactionHistory(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		onix["transaction"] = history
+	}
+
+	if e.config.IncludeManifest {
+		manifest, err := e.networkManifest(ctx, req.NetworkID)
+		if err != nil {
+			return nil, err
+		}
+		onix["manifest"] = manifest
+	}
+
+	return onix, nil
+}
+
+// senderRecord looks up the sender's registry record. The sender is the
+// signer validateSign verified; without validateSign in the pipeline it is
+// the subscriber the message claims to come from (context.bap_id on
+// requests, context.bpp_id on on_* callbacks), and "verified" is false.
+func (e *PolicyEnforcer) senderRecord(ctx *model.StepContext, req parsedRequestContext) (map[string]interface{}, error) {
+	if e.registry == nil {
+		return nil, fmt.Errorf("includeRegistryRecord requires a Registry plugin")
+	}
+	subscriberID, verified := ctx.SignerID, ctx.SignerID != ""
+	if !verified {
+		subscriberID = req.BAPID
+		if strings.HasPrefix(req.Action, "on_") {
+			subscriberID = req.BPPID
+		}
+	}
+	sender := map[string]interface{}{
+		"subscriber_id": subscriberID,
+		"verified":      verified,
+		"record":        nil,
+	}
+	if subscriberID == "" {
+		return sender, nil
+	}
+
+	subs, err := e.registry.Lookup(ctx, &model.Subscription{
+		Subscriber: model.Subscriber{SubscriberID: subscriberID},
+		KeyID:      ctx.SignerKeyID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("registry lookup for %s failed: %w", subscriberID, err)
+	}
+	if len(subs) > 0 {
+		record, err := toInputValue(subs[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode registry record for %s: %w", subscriberID, err)
+		}
+		sender["record"] = record
+	}
+	return sender, nil
+}
+
+// transactionHistory returns the transaction's earlier messages, oldest
+// first, capped to the most recent transactionHistoryLimit. The message being
+// checked is left out even when a storePayload step ahead of checkPolicy has
+// already stored it, and so are stored ACK/NACK responses, which carry their
+// request's message ID and action but are not messages of the transaction.
+func (e *PolicyEnforcer) transactionHistory(ctx *model.StepContext, req parsedRequestContext) ([]transactionMessage, error) {
+	if e.payloadStore == nil {
+		return nil, fmt.Errorf("includeTransactionHistory requires a PayloadStore plugin")
+	}
+	history := []transactionMessage{}
+	if req.TransactionID == "" {
+		return history, nil
+	}
+
+	entries, err := e.payloadStore.GetByTransactionID(ctx, req.TransactionID)
+	if err != nil {
+		return nil, fmt.Errorf("transaction history lookup for %s failed: %w", req.TransactionID, err)
+	}
+	for _, entry := range entries {
+		if entry.Kind == definition.PayloadKindResponse {
+			continue
+		}
+		if entry.MessageID == req.MessageID && entry.Action == req.Action {
+			continue
+		}
+		msg := transactionMessage{
+			MessageID:    entry.MessageID,
+			Action:       entry.Action,
+			SubscriberID: entry.SubscriberID,
+			Role:         entry.Role,
+			NetworkID:    entry.NetworkID,
+			StoredAt:     entry.StoredAt,
+		}
+		if len(entry.RequestBody) > 0 {
+			var body interface{}
+			if err := json.Unmarshal(entry.RequestBody, &body); err == nil {
+				msg.Body, msg.rawBody = body, entry.RequestBody
+			}
+		}
+		history = append(history, msg)
+	}
+	if limit := e.config.TransactionHistoryLimit; len(history) > limit {
+		history = history[len(history)-limit:]
+	}
+	return history, nil
+}
+
+// networkManifest returns the message's network manifest: the document's
+// metadata, whether its signature was verified, and its parsed content.
+// A message without a network ID gets null.
+func (e *PolicyEnforcer) networkManifest(ctx *model.StepContext, networkID string) (map[string]interface{}, error) {
+	if networkID == "" {
+		return nil, nil
+	}
+	doc, err := e.manifestLoader.GetByNetworkID(ctx, networkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load manifest for network %q: %w", networkID, err)
+	}
+	var content interface{}
+	if err := yaml.Unmarshal(doc.Content, &content); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest for network %q: %w", networkID, err)
+	}
+	// Round-trip through JSON so YAML timestamps and integers reach Rego
+	// in the shapes a JSON manifest would have.
+	content, err = toInputValue(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest for network %q: %w", networkID, err)
+	}
+	return map[string]interface{}{
+		"network_id": doc.NetworkID,
+		"digest":     doc.Digest,
+		"verified":   doc.Verified,
+		"fetched_at": doc.FetchedAt,
+		"content":    content,
+	}, nil
+}
+
+// toInputValue converts v to the generic JSON value OPA evaluates.
+func toInputValue(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
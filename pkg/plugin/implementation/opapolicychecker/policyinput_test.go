@@ -0,0 +1,284 @@
+package opapolicychecker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+)
+
+type stubRegistry struct {
+	subs    map[string]model.Subscription
+	err     error
+	lookups []*model.Subscription
+}
+
+func (s *stubRegistry) Lookup(ctx context.Context, req *model.Subscription) ([]model.Subscription, error) {
+	s.lookups = append(s.lookups, req)
+	if s.err != nil {
+		return nil, s.err
+	}
+	if sub, ok := s.subs[req.SubscriberID]; ok {
+		return []model.Subscription{sub}, nil
+	}
+	return nil, nil
+}
+
+type stubPayloadStore struct {
+	entries map[string][]definition.PayloadEntry
+	err     error
+}
+
+func (s *stubPayloadStore) Store(ctx *model.StepContext) error { return nil }
+
+func (s *stubPayloadStore) GetByTransactionID(ctx context.Context, transactionID string) ([]definition.PayloadEntry, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.entries[transactionID], nil
+}
+
+func (s *stubPayloadStore) GetByMessageID(ctx context.Context, messageID, action string) (*definition.PayloadEntry, error) {
+	return nil, nil
+}
+
+func (s *stubPayloadStore) Exists(ctx context.Context, messageID string) (bool, error) {
+	return false, nil
+}
+
+func newPolicyInputEnforcer(t *testing.T, policy string, manifestLoader definition.ManifestLoader, cfg map[string]string) (*PolicyEnforcer, error) {
+	t.Helper()
+	dir := writePolicyDir(t, "policy.rego", policy)
+	configPath := writeDefaultOnlyNetworkPolicyConfig(t, "type: dir\nlocation: "+dir+"\nquery: data.policy.violations\n")
+	cfg["networkPolicyConfig"] = configPath
+	return NewWithManifestLoader(context.Background(), manifestLoader, cfg)
+}
+
+func TestCheckPolicy_SenderRecord(t *testing.T) {
+	enforcer, err := newPolicyInputEnforcer(t, `
+package policy
+import rego.v1
+violations contains sprintf("sender %s is not subscribed", [input.onix.sender.subscriber_id]) if {
+	input.onix.sender.record.status != "SUBSCRIBED"
+}
+violations contains sprintf("sender %s is not registered", [input.onix.sender.subscriber_id]) if {
+	input.onix.sender.record == null
+}
+`, nil, map[string]string{"includeRegistryRecord": "true"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	registry := &stubRegistry{subs: map[string]model.Subscription{
+		"bap.example.com": {Subscriber: model.Subscriber{SubscriberID: "bap.example.com"}, Status: "SUBSCRIBED"},
+		"bpp.example.com": {Subscriber: model.Subscriber{SubscriberID: "bpp.example.com"}, Status: "EXPIRED"},
+	}}
+	if err := enforcer.SetRegistryLookup(registry); err != nil {
+		t.Fatalf("SetRegistryLookup: %v", err)
+	}
+
+	if err := enforcer.CheckPolicy(makeStepCtx("search", `{"context": {"action": "search", "bap_id": "bap.example.com"}}`)); err != nil {
+		t.Errorf("subscribed sender should be allowed: %v", err)
+	}
+	// on_* callbacks come from the BPP.
+	err = enforcer.CheckPolicy(makeStepCtx("on_search", `{"context": {"action": "on_search", "bap_id": "bap.example.com", "bpp_id": "bpp.example.com"}}`))
+	if err == nil || !strings.Contains(err.Error(), "bpp.example.com is not subscribed") {
+		t.Errorf("expected the expired BPP to be denied, got %v", err)
+	}
+
+	// A verified signer wins over the claimed bap_id.
+	ctx := makeStepCtx("search", `{"context": {"action": "search", "bap_id": "bap.example.com"}}`)
+	ctx.SignerID, ctx.SignerKeyID = "unknown.example.com", "k1"
+	err = enforcer.CheckPolicy(ctx)
+	if err == nil || !strings.Contains(err.Error(), "unknown.example.com is not registered") {
+		t.Errorf("expected the unregistered signer to be denied, got %v", err)
+	}
+	if last := registry.lookups[len(registry.lookups)-1]; last.SubscriberID != "unknown.example.com" || last.KeyID != "k1" {
+		t.Errorf("lookup = %+v, want the signer and its key", last)
+	}
+
+	registry.err = errors.New("registry unavailable")
+	err = enforcer.CheckPolicy(makeStepCtx("search", `{"context": {"action": "search", "bap_id": "bap.example.com"}}`))
+	var coded *model.CodedErr
+	if !errors.As(err, &coded) || coded.Code != "POL_GENERIC_ERROR" {
+		t.Errorf("a failed lookup should NACK with POL_GENERIC_ERROR, got %v", err)
+	}
+}
+
+func TestCheckPolicy_TransactionHistory(t *testing.T) {
+	enforcer, err := newPolicyInputEnforcer(t, `
+package policy
+import rego.v1
+violations contains "confirm without a prior on_select" if {
+	input.context.action == "confirm"
+	not any_on_select
+}
+any_on_select if { some m in input.onix.transaction; m.action == "on_select" }
+violations contains sprintf("history has %d messages", [count(input.onix.transaction)]) if {
+	input.context.action == "status"
+}
+`, nil, map[string]string{"includeTransactionHistory": "true", "transactionHistoryLimit": "2"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	base := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	store := &stubPayloadStore{entries: map[string][]definition.PayloadEntry{
+		"t1": {
+			{MessageID: "m1", Action: "select", StoredAt: base},
+			{MessageID: "m1", Action: "on_select", StoredAt: base.Add(time.Second), RequestBody: []byte(`{"message": {}}`)},
+			{MessageID: "m2", Action: "init", StoredAt: base.Add(2 * time.Second)},
+			{MessageID: "m3", Action: "status", StoredAt: base.Add(3 * time.Second)},
+		},
+		"t2": {{MessageID: "m9", Action: "select", StoredAt: base}},
+	}}
+	if err := enforcer.SetPayloadStore(store); err != nil {
+		t.Fatalf("SetPayloadStore: %v", err)
+	}
+
+	if err := enforcer.CheckPolicy(makeStepCtx("confirm", `{"context": {"action": "confirm", "transaction_id": "t1", "message_id": "m4"}}`)); err == nil {
+		t.Error("the limit keeps only init and status, so confirm should be denied")
+	}
+	enforcer.config.TransactionHistoryLimit = defaultTransactionHistoryLimit
+	if err := enforcer.CheckPolicy(makeStepCtx("confirm", `{"context": {"action": "confirm", "transaction_id": "t1", "message_id": "m4"}}`)); err != nil {
+		t.Errorf("confirm after on_select should be allowed: %v", err)
+	}
+	if err := enforcer.CheckPolicy(makeStepCtx("confirm", `{"context": {"action": "confirm", "transaction_id": "t2", "message_id": "m4"}}`)); err == nil {
+		t.Error("confirm without on_select should be denied")
+	}
+	// The message being checked is not part of its own history.
+	err = enforcer.CheckPolicy(makeStepCtx("status", `{"context": {"action": "status", "transaction_id": "t1", "message_id": "m3"}}`))
+	if err == nil || !strings.Contains(err.Error(), "history has 3 messages") {
+		t.Errorf("expected 3 earlier messages, got %v", err)
+	}
+}
+
+func TestCheckPolicy_TransactionHistorySkipsResponses(t *testing.T) {
+	enforcer, err := newPolicyInputEnforcer(t, `
+package policy
+import rego.v1
+violations contains sprintf("history is %v", [[m.action | some m in input.onix.transaction]]) if {
+	input.context.action == "confirm"
+}
+`, nil, map[string]string{"includeTransactionHistory": "true", "transactionHistoryLimit": "2"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	base := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	store := &stubPayloadStore{entries: map[string][]definition.PayloadEntry{
+		"t1": {
+			{MessageID: "m1", Action: "init", Kind: definition.PayloadKindRequest, StoredAt: base},
+			{MessageID: "m1", Action: "init", Kind: definition.PayloadKindResponse, StoredAt: base.Add(time.Second)},
+			{MessageID: "m1", Action: "on_init", Kind: definition.PayloadKindRequest, StoredAt: base.Add(2 * time.Second)},
+			{MessageID: "m1", Action: "on_init", Kind: definition.PayloadKindResponse, StoredAt: base.Add(3 * time.Second)},
+		},
+	}}
+	if err := enforcer.SetPayloadStore(store); err != nil {
+		t.Fatalf("SetPayloadStore: %v", err)
+	}
+
+	// Response rows neither appear in the history nor use up the limit.
+	err = enforcer.CheckPolicy(makeStepCtx("confirm", `{"context": {"action": "confirm", "transaction_id": "t1", "message_id": "m2"}}`))
+	if err == nil || !strings.Contains(err.Error(), `history is ["init", "on_init"]`) {
+		t.Errorf("expected only the init and on_init requests, got %v", err)
+	}
+}
+
+func TestCheckPolicy_Manifest(t *testing.T) {
+	policy := `
+package policy
+import rego.v1
+violations contains "manifest not verified" if { not input.onix.manifest.verified }
+violations contains sprintf("%s is not offered on this network", [input.context.domain]) if {
+	not input.context.domain in input.onix.manifest.content.domains
+}
+`
+	if _, err := newPolicyInputEnforcer(t, policy, nil, map[string]string{"includeManifest": "true"}); err == nil {
+		t.Fatal("includeManifest without a manifest loader should fail")
+	}
+	loader := stubManifestLoader{docs: map[string]*model.ManifestDocument{
+		"retail": {NetworkID: "retail", Verified: true, Content: []byte("domains:\n  - ONDC:RET10\n  - ONDC:RET11\n")},
+	}}
+	enforcer, err := newPolicyInputEnforcer(t, policy, loader, map[string]string{"includeManifest": "true"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	if err := enforcer.CheckPolicy(makeStepCtx("search", `{"context": {"action": "search", "networkId": "retail", "domain": "ONDC:RET10"}}`)); err != nil {
+		t.Errorf("listed domain should be allowed: %v", err)
+	}
+	if err := enforcer.CheckPolicy(makeStepCtx("search", `{"context": {"action": "search", "networkId": "retail", "domain": "ONDC:TRV10"}}`)); err == nil {
+		t.Error("unlisted domain should be denied")
+	}
+	if err := enforcer.CheckPolicy(makeStepCtx("search", `{"context": {"action": "search", "networkId": "mobility", "domain": "ONDC:RET10"}}`)); err == nil {
+		t.Error("a network without a manifest should NACK")
+	}
+}
+
+func TestCheckPolicy_ReservedInputKey(t *testing.T) {
+	enforcer, err := newPolicyInputEnforcer(t, `
+package policy
+import rego.v1
+violations contains "sender not subscribed" if { not input.onix.sender.record.status == "SUBSCRIBED" }
+`, nil, map[string]string{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	spoofed := `{"context": {"action": "search"}, "onix": {"sender": {"record": {"status": "SUBSCRIBED"}}}}`
+	if err := enforcer.CheckPolicy(makeStepCtx("search", spoofed)); err == nil {
+		t.Error("an onix object in the message must not reach the policy")
+	}
+
+	// Evaluate (and with it the policy test harness) leaves the body as is,
+	// so test payloads can supply input.onix themselves.
+	violations, err := enforcer.defaultPolicy.evaluator.Evaluate(context.Background(), []byte(spoofed))
+	if err != nil || len(violations) != 0 {
+		t.Errorf("Evaluate = %v, %v; want the body's onix to be used", violations, err)
+	}
+}
+
+func TestPolicyInput_Dependencies(t *testing.T) {
+	policy := "package policy\nimport rego.v1\nviolations := set()\n"
+	enforcer, err := newPolicyInputEnforcer(t, policy, nil, map[string]string{"includeRegistryRecord": "true", "includeTransactionHistory": "true"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if err := enforcer.SetRegistryLookup(nil); err == nil {
+		t.Error("includeRegistryRecord without a registry should fail")
+	}
+	if err := enforcer.SetPayloadStore(nil); err == nil {
+		t.Error("includeTransactionHistory without a payload store should fail")
+	}
+	if err := enforcer.CheckPolicy(makeStepCtx("search", `{"context": {"action": "search", "bap_id": "bap.example.com"}}`)); err == nil {
+		t.Error("CheckPolicy without the injected dependencies should NACK")
+	}
+
+	plain, err := newPolicyInputEnforcer(t, policy, nil, map[string]string{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if err := plain.SetRegistryLookup(nil); err != nil {
+		t.Errorf("no registry is fine when the record is not included: %v", err)
+	}
+	if err := plain.SetPayloadStore(nil); err != nil {
+		t.Errorf("no payload store is fine when history is not included: %v", err)
+	}
+
+	for _, limit := range []string{"0", "-1", "ten"} {
+		if _, err := ParseConfig(map[string]string{"networkPolicyConfig": "/tmp/p.yaml", "transactionHistoryLimit": limit}); err == nil {
+			t.Errorf("transactionHistoryLimit=%s: expected a config error", limit)
+		}
+	}
+	cfg, err := ParseConfig(map[string]string{"networkPolicyConfig": "/tmp/p.yaml", "includeManifest": "true"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.IncludeManifest || cfg.TransactionHistoryLimit != defaultTransactionHistoryLimit {
+		t.Errorf("config = %+v", cfg)
+	}
+	if _, leaked := cfg.RuntimeConfig["includeManifest"]; leaked {
+		t.Errorf("includeManifest must not be forwarded as runtime config: %v", cfg.RuntimeConfig)
+	}
+}
@@ -0,0 +1,163 @@
+package opapolicychecker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+)
+
+// PolicyTestResult is the outcome of running one sample payload through
+// RunPolicyTests.
+type PolicyTestResult struct {
+	Name        string        // path of the payload relative to the cases directory
+	Expect      string        // "allow" or "deny", from the sub-directory it sits in
+	Got         string        // "allow", "deny" or "error"
+	Policy      string        // network policy that decided; empty when none applied
+	Revision    string        // revision of that policy
+	Violations  []model.Error // violations the policy reported
+	Err         error         // evaluation error, when Got is "error"
+	Explanation []string      // evaluation trace, when an explain mode was requested
+}
+
+// Passed reports whether the payload got the expected outcome.
+func (r PolicyTestResult) Passed() bool {
+	return r.Got == r.Expect
+}
+
+// policyTestOnixSuffix names the optional file beside a test case that
+// supplies its input.onix: cases/deny/confirm.onix.json for
+// cases/deny/confirm.json.
+const policyTestOnixSuffix = ".onix.json"
+
+// RunPolicyTests evaluates sample Beckn payloads against a network policy
+// config, as checkPolicy would, so NFOs can test policies before publishing
+// them. cfg is the plugin config (networkPolicyConfig is required; refresh
+// is ignored). casesDir holds the payloads as .json files in two
+// sub-directories named for the outcome they expect:
+//
+//	cases/allow/search.json
+//	cases/deny/confirm_without_billing.json
+//
+// Each payload picks its policy by context.networkId, falling back to the
+// default policy; a payload no enabled policy applies to is allowed, as it
+// is by checkPolicy. The harness looks nothing up, so input.onix comes from
+// the case's .onix.json file, or is empty without one; an onix key in the
+// payload itself is replaced, as it is by checkPolicy. explain is an
+// explanation mode (off, notes, fails, full) for
+// PolicyTestResult.Explanation.
+func RunPolicyTests(ctx context.Context, cfg map[string]string, casesDir, explain string) ([]PolicyTestResult, error) {
+	switch explain {
+	case "":
+		explain = explainOff
+	case explainOff, explainNotes, explainFails, explainFull:
+	default:
+		return nil, fmt.Errorf("unsupported explain mode %q (expected %s, %s, %s or %s)", explain, explainOff, explainNotes, explainFails, explainFull)
+	}
+
+	runCfg := make(map[string]string, len(cfg))
+	for k, v := range cfg {
+		runCfg[k] = v
+	}
+	delete(runCfg, "refreshInterval")
+	enforcer, err := New(ctx, runCfg)
+	if err != nil {
+		return nil, err
+	}
+	defer enforcer.Close()
+
+	var results []PolicyTestResult
+	for _, expect := range []string{decisionAllow, decisionDeny} {
+		paths, err := filepath.Glob(filepath.Join(casesDir, expect, "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			if strings.HasSuffix(path, policyTestOnixSuffix) {
+				continue
+			}
+			body, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read test case %s: %w", path, err)
+			}
+			onix, err := readPolicyTestOnix(path)
+			if err != nil {
+				return nil, err
+			}
+			result := enforcer.runPolicyTest(ctx, body, onix, explain)
+			result.Name = filepath.ToSlash(filepath.Join(expect, filepath.Base(path)))
+			result.Expect = expect
+			results = append(results, result)
+		}
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no test cases found in %s (expected .json payloads under %s/ and %s/)", casesDir, decisionAllow, decisionDeny)
+	}
+	return results, nil
+}
+
+// readPolicyTestOnix reads the input.onix of the test case at path from its
+// .onix.json file, returning an empty object when there is none.
+func readPolicyTestOnix(path string) (map[string]interface{}, error) {
+	onixPath := strings.TrimSuffix(path, ".json") + policyTestOnixSuffix
+	data, err := os.ReadFile(onixPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]interface{}{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read test case onix %s: %w", onixPath, err)
+	}
+	var onix map[string]interface{}
+	if err := json.Unmarshal(data, &onix); err != nil {
+		return nil, fmt.Errorf("test case onix %s must be a JSON object: %w", onixPath, err)
+	}
+	if onix == nil {
+		return nil, fmt.Errorf("test case onix %s must be a JSON object, got null", onixPath)
+	}
+	return onix, nil
+}
+
+// runPolicyTest makes CheckPolicy's decision for body, with onix as its
+// input.onix, without its logging.
+func (e *PolicyEnforcer) runPolicyTest(ctx context.Context, body []byte, onix map[string]interface{}, explain string) PolicyTestResult {
+	if !e.config.Enabled {
+		return PolicyTestResult{Got: decisionAllow}
+	}
+	policy := e.selectedPolicy(parseRequestContext(body).NetworkID)
+	if policy == nil || !policy.config.Enabled {
+		return PolicyTestResult{Got: decisionAllow}
+	}
+	result := PolicyTestResult{Policy: policy.name}
+	if policy.evaluator == nil {
+		result.Got, result.Err = decisionError, fmt.Errorf("policy evaluator is not initialized")
+		return result
+	}
+	result.Revision = policy.evaluator.Revision()
+
+	violations, explanation, err := policy.evaluator.evaluate(ctx, body, onix, explain)
+	result.Violations, result.Explanation = violations, explanation
+	result.Got = decisionOutcome{violations: violations, err: err}.result()
+	result.Err = err
+	return result
+}
+
+// Summary describes the result in one line, e.g.
+// "expected deny, got allow" or "expected allow, got deny: <violations>".
+func (r PolicyTestResult) Summary() string {
+	msg := fmt.Sprintf("expected %s, got %s", r.Expect, r.Got)
+	switch {
+	case r.Err != nil:
+		msg += ": " + r.Err.Error()
+	case len(r.Violations) > 0:
+		msg += ": " + strings.Join(violationMessages(r.Violations), "; ")
+	}
+	return msg
+}
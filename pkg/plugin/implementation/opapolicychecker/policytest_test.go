@@ -0,0 +1,129 @@
+package opapolicychecker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestCase(t *testing.T, dir, name, body string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create case dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+		t.Fatalf("failed to write test case: %v", err)
+	}
+}
+
+func TestRunPolicyTests(t *testing.T) {
+	retail := writePolicyDir(t, "retail.rego", `
+package retail
+import rego.v1
+violations contains "confirm needs billing" if { input.context.action == "confirm"; not input.message.order.billing }
+`)
+	fallback := writePolicyDir(t, "default.rego", `
+package fallback
+import rego.v1
+violations contains "unknown network" if { true }
+`)
+	configPath := writeNetworkPolicyConfig(t, "networkPolicies:\n"+
+		"  retail:\n    type: dir\n    location: "+retail+"\n    query: data.retail.violations\n"+
+		"  default:\n    type: dir\n    location: "+fallback+"\n    query: data.fallback.violations\n")
+
+	cases := t.TempDir()
+	writeTestCase(t, cases, "allow/confirm.json", `{"context": {"networkId": "retail", "action": "confirm"}, "message": {"order": {"billing": {}}}}`)
+	writeTestCase(t, cases, "allow/search.json", `{"context": {"networkId": "retail", "action": "search"}}`)
+	writeTestCase(t, cases, "deny/confirm_without_billing.json", `{"context": {"networkId": "retail", "action": "confirm"}}`)
+	writeTestCase(t, cases, "deny/other_network.json", `{"context": {"networkId": "mobility", "action": "search"}}`)
+	// Expected to be denied but allowed: the one failing case.
+	writeTestCase(t, cases, "deny/search.json", `{"context": {"networkId": "retail", "action": "search"}}`)
+	writeTestCase(t, cases, "deny/notes.txt", "not a payload")
+
+	results, err := RunPolicyTests(context.Background(), map[string]string{"networkPolicyConfig": configPath}, cases, explainFails)
+	if err != nil {
+		t.Fatalf("RunPolicyTests: %v", err)
+	}
+
+	got := map[string]PolicyTestResult{}
+	for _, r := range results {
+		got[r.Name] = r
+	}
+	if len(got) != 5 {
+		t.Fatalf("ran %d cases, want the 5 .json payloads: %v", len(got), results)
+	}
+	for name, wantPass := range map[string]bool{
+		"allow/confirm.json":                true,
+		"allow/search.json":                 true,
+		"deny/confirm_without_billing.json": true,
+		"deny/other_network.json":           true,
+		"deny/search.json":                  false,
+	} {
+		if r := got[name]; r.Passed() != wantPass {
+			t.Errorf("%s: passed = %t, want %t (%s)", name, r.Passed(), wantPass, r.Summary())
+		}
+	}
+	if r := got["deny/other_network.json"]; r.Policy != "default" {
+		t.Errorf("an unknown network should fall back to the default policy, got %q", r.Policy)
+	}
+	if r := got["deny/confirm_without_billing.json"]; r.Policy != "retail" || !strings.HasPrefix(r.Revision, "sha256:") || len(r.Explanation) == 0 {
+		t.Errorf("unexpected result: %+v", r)
+	}
+	if s := got["deny/search.json"].Summary(); s != "expected deny, got allow" {
+		t.Errorf("Summary = %q", s)
+	}
+}
+
+func TestRunPolicyTests_Onix(t *testing.T) {
+	dir := writePolicyDir(t, "policy.rego", `
+package policy
+import rego.v1
+violations contains "sender not verified" if not input.onix.sender.verified
+`)
+	configPath := writeDefaultOnlyNetworkPolicyConfig(t, "type: dir\nlocation: "+dir+"\nquery: data.policy.violations\n")
+	cfg := map[string]string{"networkPolicyConfig": configPath}
+
+	cases := t.TempDir()
+	writeTestCase(t, cases, "allow/verified.json", `{"context": {"action": "confirm"}}`)
+	writeTestCase(t, cases, "allow/verified.onix.json", `{"sender": {"verified": true}}`)
+	writeTestCase(t, cases, "deny/unverified.json", `{"context": {"action": "confirm"}}`)
+	// The payload's own onix is replaced, as checkPolicy replaces it.
+	writeTestCase(t, cases, "deny/forged.json", `{"context": {"action": "confirm"}, "onix": {"sender": {"verified": true}}}`)
+
+	results, err := RunPolicyTests(context.Background(), cfg, cases, "")
+	if err != nil {
+		t.Fatalf("RunPolicyTests: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("ran %d cases, want 3 without the .onix.json files: %v", len(results), results)
+	}
+	for _, r := range results {
+		if !r.Passed() {
+			t.Errorf("%s: %s", r.Name, r.Summary())
+		}
+	}
+
+	writeTestCase(t, cases, "deny/unverified.onix.json", `["not", "an", "object"]`)
+	if _, err := RunPolicyTests(context.Background(), cfg, cases, ""); err == nil || !strings.Contains(err.Error(), "must be a JSON object") {
+		t.Errorf("expected an error for an onix file that is not an object, got %v", err)
+	}
+}
+
+func TestRunPolicyTests_Errors(t *testing.T) {
+	dir := writePolicyDir(t, "policy.rego", "package policy\nimport rego.v1\nviolations := set()\n")
+	configPath := writeDefaultOnlyNetworkPolicyConfig(t, "type: dir\nlocation: "+dir+"\nquery: data.policy.violations\n")
+	cfg := map[string]string{"networkPolicyConfig": configPath}
+
+	if _, err := RunPolicyTests(context.Background(), cfg, t.TempDir(), ""); err == nil {
+		t.Error("expected an error for a directory without cases")
+	}
+	if _, err := RunPolicyTests(context.Background(), cfg, t.TempDir(), "verbose"); err == nil {
+		t.Error("expected an error for an unknown explain mode")
+	}
+	if _, err := RunPolicyTests(context.Background(), map[string]string{"networkPolicyConfig": filepath.Join(t.TempDir(), "missing.yaml")}, t.TempDir(), ""); err == nil {
+		t.Error("expected an error for a missing policy config")
+	}
+}
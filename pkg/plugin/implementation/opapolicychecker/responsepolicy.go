@@ -0,0 +1,65 @@
+package opapolicychecker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+)
+
+var _ definition.ResponsePolicyChecker = (*PolicyEnforcer)(nil)
+
+// CheckResponsePolicy evaluates the upstream's synchronous ACK/NACK body
+// against the responseQuery of the policy selected for the request. A policy
+// without a responseQuery leaves responses unchecked. Violations are returned
+// as in CheckPolicy; the handler's checkResponsePolicy step turns them into
+// the NACK that replaces the upstream response. A policy input that cannot be
+// built or a policy that cannot be evaluated says nothing about the response,
+// so those errors are returned unclassified.
+//
+// The response body is the input. input.onix carries what CheckPolicy would
+// add for the request, plus the request itself as input.onix.request and the
+// upstream's HTTP status as input.onix.response_status.
+func (e *PolicyEnforcer) CheckResponsePolicy(ctx *model.StepContext, rctx *model.ResponseStepContext) error {
+	if !e.config.Enabled || rctx == nil {
+		return nil
+	}
+
+	reqCtx := parseRequestContext(ctx.Body)
+	policy := e.selectedPolicy(reqCtx.NetworkID)
+	if policy == nil || !policy.config.Enabled || policy.responseEvaluator == nil {
+		log.Debugf(ctx, "OPAPolicyChecker: no response policy for networkID=%q, skipping", reqCtx.NetworkID)
+		return nil
+	}
+	ev := policy.responseEvaluator
+
+	if e.config.DebugLogging {
+		log.Debugf(ctx, "OPAPolicyChecker: evaluating response policy for networkID=%q action=%q status=%d", reqCtx.NetworkID, reqCtx.Action, rctx.StatusCode)
+	}
+
+	// A body the policy cannot parse is the upstream's fault, not a failed
+	// evaluation.
+	if !json.Valid(rctx.Body) {
+		return model.NewBadReqErr("POL_GENERIC_ERROR", fmt.Errorf("response body is not valid JSON"))
+	}
+
+	onix, err := e.policyContext(ctx, reqCtx)
+	if err != nil {
+		log.Errorf(ctx, err, "OPAPolicyChecker: failed to build response policy input for networkID=%q%s: %v", reqCtx.NetworkID, formatRequestLogContext(reqCtx), err)
+		return fmt.Errorf("policy input error: %w", err)
+	}
+	var request interface{}
+	if err := json.Unmarshal(ctx.Body, &request); err == nil {
+		onix["request"] = request
+	}
+	onix["response_status"] = rctx.StatusCode
+
+	err = e.decide(ctx, policy, ev, reqCtx, rctx.Body, onix)
+	if errors.Is(err, errPolicyEvaluation) {
+		return errors.Unwrap(err)
+	}
+	return err
+}
@@ -0,0 +1,126 @@
+package opapolicychecker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+)
+
+const responsePolicy = `
+package policy
+import rego.v1
+violations contains "confirm needs billing" if {
+	input.context.action == "confirm"
+	not input.message.order.billing
+}
+response_violations contains {"code": "POL_RESPONSE_STATUS", "message": sprintf("NACK for %s without an error", [input.onix.request.context.action])} if {
+	input.message.status == "NACK"
+	not input.message.error
+}
+response_violations contains "ACK with a non-200 status" if {
+	input.message.status == "ACK"
+	input.onix.response_status != 200
+}
+`
+
+func newResponsePolicyEnforcer(t *testing.T, entry string) *PolicyEnforcer {
+	t.Helper()
+	dir := writePolicyDir(t, "policy.rego", responsePolicy)
+	configPath := writeDefaultOnlyNetworkPolicyConfig(t, "type: dir\nlocation: "+dir+"\nquery: data.policy.violations\n"+entry)
+	enforcer, err := New(context.Background(), map[string]string{"networkPolicyConfig": configPath})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return enforcer
+}
+
+func TestCheckResponsePolicy(t *testing.T) {
+	enforcer := newResponsePolicyEnforcer(t, "responseQuery: data.policy.response_violations\n")
+	ctx := makeStepCtx("confirm", `{"context": {"action": "confirm"}, "message": {"order": {"billing": {}}}}`)
+
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		wantCode string
+	}{
+		{"ACK", 200, `{"message": {"status": "ACK"}}`, ""},
+		{"NACK with error", 400, `{"message": {"status": "NACK", "error": {"code": "POL_X"}}}`, ""},
+		{"NACK without error", 400, `{"message": {"status": "NACK"}}`, "POL_RESPONSE_STATUS"},
+		{"ACK with 500", 500, `{"message": {"status": "ACK"}}`, "POL_GENERIC_ERROR"},
+		{"not JSON", 502, `bad gateway`, "POL_GENERIC_ERROR"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := enforcer.CheckResponsePolicy(ctx, &model.ResponseStepContext{StatusCode: tt.status, Body: []byte(tt.body)})
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("expected the response to pass, got %v", err)
+				}
+				return
+			}
+			var coded *model.CodedErr
+			if !errors.As(err, &coded) || coded.Code != tt.wantCode {
+				t.Fatalf("error = %v, want code %s", err, tt.wantCode)
+			}
+		})
+	}
+
+	err := enforcer.CheckResponsePolicy(ctx, &model.ResponseStepContext{StatusCode: 400, Body: []byte(`{"message": {"status": "NACK"}}`)})
+	if err == nil || !strings.Contains(err.Error(), "NACK for confirm without an error") {
+		t.Errorf("the policy should see the request as input.onix.request, got %v", err)
+	}
+	if err := enforcer.CheckResponsePolicy(ctx, nil); err != nil {
+		t.Errorf("the publisher path has no response to check: %v", err)
+	}
+}
+
+func TestCheckResponsePolicy_InputErrorIsNotAViolation(t *testing.T) {
+	dir := writePolicyDir(t, "policy.rego", responsePolicy)
+	configPath := writeDefaultOnlyNetworkPolicyConfig(t, "type: dir\nlocation: "+dir+"\nquery: data.policy.violations\nresponseQuery: data.policy.response_violations\n")
+	enforcer, err := New(context.Background(), map[string]string{"networkPolicyConfig": configPath, "includeRegistryRecord": "true"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if err := enforcer.SetRegistryLookup(&stubRegistry{err: errors.New("registry unavailable")}); err != nil {
+		t.Fatalf("SetRegistryLookup: %v", err)
+	}
+
+	ctx := makeStepCtx("confirm", `{"context": {"action": "confirm", "bap_id": "bap.example.com"}}`)
+	err = enforcer.CheckResponsePolicy(ctx, &model.ResponseStepContext{StatusCode: 200, Body: []byte(`{"message": {"status": "ACK"}}`)})
+	var coded *model.CodedErr
+	if err == nil || errors.As(err, &coded) {
+		t.Errorf("error = %v, want an unclassified error: the response was never checked", err)
+	}
+}
+
+func TestCheckResponsePolicy_NoResponseQuery(t *testing.T) {
+	enforcer := newResponsePolicyEnforcer(t, "")
+	ctx := makeStepCtx("confirm", `{"context": {"action": "confirm"}}`)
+	if err := enforcer.CheckResponsePolicy(ctx, &model.ResponseStepContext{StatusCode: 500, Body: []byte(`not json`)}); err != nil {
+		t.Errorf("a policy without responseQuery must leave responses unchecked, got %v", err)
+	}
+	if enforcer.defaultPolicy.responseEvaluator != nil {
+		t.Error("no response evaluator should be prepared without responseQuery")
+	}
+}
+
+func TestResponseQuery_Config(t *testing.T) {
+	dir := writePolicyDir(t, "policy.rego", responsePolicy)
+	configPath := writeDefaultOnlyNetworkPolicyConfig(t, "type: dir\nlocation: "+dir+"\nquery: data.policy.violations\nresponseQuery: \"data.policy[\"\n")
+	if _, err := New(context.Background(), map[string]string{"networkPolicyConfig": configPath}); err == nil {
+		t.Error("an invalid responseQuery should fail at startup")
+	}
+
+	enforcer := newResponsePolicyEnforcer(t, "responseQuery: data.policy.response_violations\n")
+	policy := enforcer.defaultPolicy
+	if _, leaked := policy.config.RuntimeConfig["responseQuery"]; leaked {
+		t.Error("responseQuery must not be forwarded as runtime config")
+	}
+	if policy.responseEvaluator.Revision() != policy.evaluator.Revision() {
+		t.Error("the response query should share the request query's policy set")
+	}
+}
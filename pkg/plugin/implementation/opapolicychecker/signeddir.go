@@ -0,0 +1,154 @@
+package opapolicychecker
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/beckn/catalog-core/pkg/security/artifactverifier"
+)
+
+// defaultDirManifestName is the manifest file looked for inside a signed
+// policy directory when verification.manifestLocation is not set.
+const defaultDirManifestName = "manifest.json"
+
+// dirManifest is the detached manifest of a signed policy directory: one
+// digest per policy file. The manifest is signed once as a whole, so the
+// per-file digests are what bind each .rego file to that signature.
+//
+//	{"files": [{"name": "retail.rego", "algorithm": "SHA-256", "hash": "<hex>"}]}
+type dirManifest struct {
+	Files []dirManifestFile `json:"files"`
+}
+
+type dirManifestFile struct {
+	Name      string `json:"name"`
+	Algorithm string `json:"algorithm"` // SHA-256 (default), SHA-384 or SHA-512
+	Hash      string `json:"hash"`
+}
+
+// dirManifestLocations returns where the manifest and its detached signature
+// for a signed directory are read from.
+func dirManifestLocations(dir string, verification *ArtifactVerificationConfig) (manifest, signature string) {
+	manifest = verification.ManifestLocation
+	if manifest == "" {
+		manifest = filepath.Join(dir, defaultDirManifestName)
+	}
+	signature = verification.SignatureLocation
+	if signature == "" {
+		signature = manifest + ".sig"
+	}
+	return manifest, signature
+}
+
+// loadSignedDirectory loads the .rego files of dir after verifying the
+// manifest's detached signature and every file's digest against it. The set
+// of files must match the manifest exactly: an unlisted file could add rules
+// and a missing one could drop them, so either fails the load.
+func loadSignedDirectory(dir string, verification *ArtifactVerificationConfig, fetchTimeout time.Duration) (map[string]string, error) {
+	manifestLocation, signatureLocation := dirManifestLocations(dir, verification)
+
+	manifestBytes, err := readArtifact(manifestLocation, maxPolicySize, fetchTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load policy directory manifest from %s: %w", manifestLocation, err)
+	}
+	signatureBytes, err := readArtifact(signatureLocation, maxPolicySize, fetchTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load policy directory manifest signature from %s: %w", signatureLocation, err)
+	}
+	publicKeyBody, err := readArtifact(verification.PublicKeyLookupURL, maxPolicySize, fetchTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load verification public key from %s: %w", verification.PublicKeyLookupURL, err)
+	}
+	if err := artifactverifier.VerifyDetachedArtifact(manifestBytes, signatureBytes, publicKeyBody); err != nil {
+		return nil, fmt.Errorf("policy directory manifest signature verification failed: %w", err)
+	}
+
+	var manifest dirManifest
+	dec := json.NewDecoder(bytes.NewReader(manifestBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse policy directory manifest %s: %w", manifestLocation, err)
+	}
+	listed := make(map[string]dirManifestFile, len(manifest.Files))
+	for _, f := range manifest.Files {
+		if f.Name == "" || f.Name != filepath.Base(f.Name) {
+			return nil, fmt.Errorf("policy directory manifest entry %q must be a plain file name", f.Name)
+		}
+		if _, dup := listed[f.Name]; dup {
+			return nil, fmt.Errorf("policy directory manifest lists %q more than once", f.Name)
+		}
+		listed[f.Name] = f
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy directory %s: %w", dir, err)
+	}
+	modules := make(map[string]string)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".rego") || strings.HasSuffix(entry.Name(), "_test.rego") {
+			continue
+		}
+		f, ok := listed[entry.Name()]
+		if !ok {
+			return nil, fmt.Errorf("policy file %s is not listed in the signed manifest", entry.Name())
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read policy file %s: %w", entry.Name(), err)
+		}
+		if err := f.verify(data); err != nil {
+			return nil, err
+		}
+		modules[entry.Name()] = string(data)
+	}
+
+	var missing []string
+	for name := range listed {
+		if _, ok := modules[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("policy files listed in the signed manifest are missing from %s: %s", dir, strings.Join(missing, ", "))
+	}
+	if len(modules) == 0 {
+		return nil, fmt.Errorf("no .rego policy files found in %s", dir)
+	}
+	return modules, nil
+}
+
+// verify checks data against the manifest entry's digest.
+func (f dirManifestFile) verify(data []byte) error {
+	var h hash.Hash
+	switch strings.ToUpper(f.Algorithm) {
+	case "", "SHA-256":
+		h = sha256.New()
+	case "SHA-384":
+		h = sha512.New384()
+	case "SHA-512":
+		h = sha512.New()
+	default:
+		return fmt.Errorf("policy file %s: unsupported digest algorithm %q", f.Name, f.Algorithm)
+	}
+	want, err := hex.DecodeString(f.Hash)
+	if err != nil {
+		return fmt.Errorf("policy file %s: invalid digest in manifest: %w", f.Name, err)
+	}
+	h.Write(data)
+	if !bytes.Equal(h.Sum(nil), want) {
+		return fmt.Errorf("policy file %s: digest mismatch with signed manifest", f.Name)
+	}
+	return nil
+}
@@ -8,6 +8,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
+	"sort"
 	"strconv"
 	"time"
 
@@ -113,6 +115,44 @@ func msgKey(namespace, messageID string) string {
 	return "payload:" + namespace + ":msg:" + messageID
 }
 
+// responseSuffix distinguishes a response's entry ID from its request's.
+const responseSuffix = ":response"
+
+// entryID is the ID an entry is keyed and indexed under: the message ID for
+// requests, and the message ID with responseSuffix for responses, so a
+// response does not overwrite the request it answers.
+func entryID(entry definition.PayloadEntry) string {
+	if entry.Kind == definition.PayloadKindResponse {
+		return entry.MessageID + responseSuffix
+	}
+	return entry.MessageID
+}
+
+// direction returns the direction of a request handled by ctx's handler,
+// or of the response to it when response is true: a caller handler sends
+// requests and receives their responses, a receiver the opposite.
+func direction(ctx *model.StepContext, response bool) definition.PayloadDirection {
+	if ctx.IsCallerHandler != response {
+		return definition.PayloadOutbound
+	}
+	return definition.PayloadInbound
+}
+
+// responseHeaders returns the first value of each response header except
+// Signature, which is stored in the entry's Signature field.
+func responseHeaders(h http.Header) map[string]string {
+	if len(h) == 0 {
+		return nil
+	}
+	headers := make(map[string]string, len(h))
+	for k, v := range h {
+		if len(v) > 0 && k != "Signature" {
+			headers[k] = v[0]
+		}
+	}
+	return headers
+}
+
 func txnIndexKey(namespace, transactionID string) string {
 	return "payload:" + namespace + ":txn:" + transactionID + ":index"
 }
@@ -260,12 +300,42 @@ func (s *store) Store(ctx *model.StepContext) error {
 		Action:        bCtx.Action,
 		SubscriberID:  ctx.SubID,
 		Role:          ctx.Role,
+		Kind:          definition.PayloadKindRequest,
+		Direction:     direction(ctx, false),
 		RequestBody:   ctx.Body,
 		Signature:     ctx.Request.Header.Get(model.AuthHeaderSubscriber),
 	}
 	return s.persist(ctx, entry)
 }
 
+// StoreResponse persists the synchronous response in rctx as a response
+// entry for the request in ctx.Body, indexed under the request's
+// transaction so GetByTransactionID returns the whole exchange.
+func (s *store) StoreResponse(ctx *model.StepContext, rctx *model.ResponseStepContext) error {
+	bCtx := parseBecknCtx(ctx.Body)
+	if bCtx.MessageID == "" {
+		log.Warnf(ctx, "payloadstore: message_id absent from request body — response store skipped")
+		return nil
+	}
+
+	entry := definition.PayloadEntry{
+		MessageID:       bCtx.MessageID,
+		TransactionID:   bCtx.TransactionID,
+		NetworkID:       bCtx.NetworkID,
+		Action:          bCtx.Action,
+		SubscriberID:    ctx.SubID,
+		Role:            ctx.Role,
+		Kind:            definition.PayloadKindResponse,
+		Direction:       direction(ctx, true),
+		Signature:       rctx.Header.Get("Signature"),
+		Status:          model.ParseResponseStatus(rctx.Body),
+		StatusCode:      rctx.StatusCode,
+		ResponseBody:    rctx.Body,
+		ResponseHeaders: responseHeaders(rctx.Header),
+	}
+	return s.persist(ctx, entry)
+}
+
 // persist applies config policies (storeBody, storeSignature, maxBodyBytes, compress)
 // and writes the entry to the cache, then updates the transaction index.
 //
@@ -276,6 +346,7 @@ func (s *store) Store(ctx *model.StepContext) error {
 func (s *store) persist(ctx context.Context, entry definition.PayloadEntry) error {
 	if !s.config.StoreBody {
 		entry.RequestBody = nil
+		entry.ResponseBody = nil
 	}
 	if !s.config.StoreSignature {
 		entry.Signature = ""
@@ -283,6 +354,9 @@ func (s *store) persist(ctx context.Context, entry definition.PayloadEntry) erro
 	if s.config.MaxBodyBytes > 0 && int64(len(entry.RequestBody)) > s.config.MaxBodyBytes {
 		entry.RequestBody = entry.RequestBody[:s.config.MaxBodyBytes]
 	}
+	if s.config.MaxBodyBytes > 0 && int64(len(entry.ResponseBody)) > s.config.MaxBodyBytes {
+		entry.ResponseBody = entry.ResponseBody[:s.config.MaxBodyBytes]
+	}
 
 	now := time.Now().UTC()
 	entry.StoredAt = now
@@ -292,7 +366,8 @@ func (s *store) persist(ctx context.Context, entry definition.PayloadEntry) erro
 	if err != nil {
 		return err
 	}
-	if err := s.cache.Set(ctx, msgKey(s.namespace, entry.MessageID), serialized, s.config.TTL); err != nil {
+	id := entryID(entry)
+	if err := s.cache.Set(ctx, msgKey(s.namespace, id), serialized, s.config.TTL); err != nil {
 		return fmt.Errorf("payloadstore: set msg key: %w", err)
 	}
 
@@ -300,7 +375,7 @@ func (s *store) persist(ctx context.Context, entry definition.PayloadEntry) erro
 		log.Warnf(ctx, "payloadstore: transaction_id absent — skipping transaction index update for message %s", entry.MessageID)
 		return nil
 	}
-	return s.appendToIndex(ctx, entry.TransactionID, entry.MessageID)
+	return s.appendToIndex(ctx, entry.TransactionID, id)
 }
 
 func (s *store) appendToIndex(ctx context.Context, transactionID, messageID string) error {
@@ -331,7 +406,8 @@ func (s *store) appendToIndex(ctx context.Context, transactionID, messageID stri
 	return nil
 }
 
-// GetByTransactionID returns all entries for a transaction in insertion (StoredAt ascending) order.
+// GetByTransactionID returns all entries for a transaction, requests and
+// responses, in StoredAt ascending order.
 func (s *store) GetByTransactionID(ctx context.Context, transactionID string) ([]definition.PayloadEntry, error) {
 	raw, err := s.cache.Get(ctx, txnIndexKey(s.namespace, transactionID))
 	if err != nil || raw == "" {
@@ -355,10 +431,13 @@ func (s *store) GetByTransactionID(ctx context.Context, transactionID string) ([
 		}
 		entries = append(entries, entry)
 	}
+	// The index is in insertion order, but a response can be stored after a
+	// callback that arrived before it completed.
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].StoredAt.Before(entries[j].StoredAt) })
 	return entries, nil
 }
 
-// GetByMessageID returns the entry for the given message ID, optionally filtered by action.
+// GetByMessageID returns the request entry for the given message ID, optionally filtered by action.
 func (s *store) GetByMessageID(ctx context.Context, messageID, action string) (*definition.PayloadEntry, error) {
 	raw, err := s.cache.Get(ctx, msgKey(s.namespace, messageID))
 	if err != nil || raw == "" {
@@ -584,3 +584,94 @@ func TestStore_ContinuesAfterExistsCacheError(t *testing.T) {
 		t.Error("expected msg key to be stored even when Exists returned a cache error")
 	}
 }
+
+// StoreResponse tests
+
+func sampleResponseCtx() *model.ResponseStepContext {
+	h := http.Header{}
+	h.Set("Content-Type", "application/json")
+	h.Set("Signature", "ack-sig")
+	return &model.ResponseStepContext{
+		StatusCode: http.StatusOK,
+		Header:     h,
+		Body:       []byte(`{"message":{"ack":{"status":"ACK"}}}`),
+	}
+}
+
+func TestStoreResponse_StoredAlongsideRequest(t *testing.T) {
+	s, _ := newTestStore(t, nil)
+	ctx := sampleStepCtx("msgR", "txnR")
+	ctx.IsCallerHandler = true
+
+	if err := s.Store(ctx); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	if err := s.StoreResponse(ctx, sampleResponseCtx()); err != nil {
+		t.Fatalf("StoreResponse: %v", err)
+	}
+
+	entries, err := s.GetByTransactionID(context.Background(), "txnR")
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("GetByTransactionID = %+v, %v; want request and response", entries, err)
+	}
+	req, resp := entries[0], entries[1]
+	if req.Kind != definition.PayloadKindRequest || req.Direction != definition.PayloadOutbound {
+		t.Errorf("request entry: kind %q direction %q", req.Kind, req.Direction)
+	}
+	if resp.Kind != definition.PayloadKindResponse || resp.Direction != definition.PayloadInbound {
+		t.Errorf("response entry: kind %q direction %q", resp.Kind, resp.Direction)
+	}
+	if resp.MessageID != "msgR" || resp.Action != "search" || resp.Status != model.StatusACK || resp.StatusCode != http.StatusOK {
+		t.Errorf("unexpected response entry: %+v", resp)
+	}
+	if resp.Signature != "ack-sig" || resp.ResponseHeaders["Content-Type"] != "application/json" {
+		t.Errorf("unexpected response signature/headers: %q %v", resp.Signature, resp.ResponseHeaders)
+	}
+	if _, ok := resp.ResponseHeaders["Signature"]; ok {
+		t.Error("Signature should not be duplicated into ResponseHeaders")
+	}
+
+	// GetByMessageID still returns the request.
+	got, err := s.GetByMessageID(context.Background(), "msgR", "")
+	if err != nil || got == nil || got.Kind != definition.PayloadKindRequest {
+		t.Errorf("GetByMessageID = %+v, %v; want the request entry", got, err)
+	}
+}
+
+func TestStoreResponse_ReceiverDirectionAndNack(t *testing.T) {
+	s, _ := newTestStore(t, map[string]string{"storeBody": "false"})
+	rctx := &model.ResponseStepContext{
+		StatusCode: http.StatusBadRequest,
+		Header:     http.Header{},
+		Body:       []byte(`{"message":{"ack":{"status":"NACK"}},"error":{"code":"400"}}`),
+	}
+	if err := s.StoreResponse(sampleStepCtx("msgN", "txnN"), rctx); err != nil {
+		t.Fatalf("StoreResponse: %v", err)
+	}
+	entries, _ := s.GetByTransactionID(context.Background(), "txnN")
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.Direction != definition.PayloadOutbound || e.Status != model.StatusNACK || e.StatusCode != http.StatusBadRequest {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+	if e.ResponseBody != nil {
+		t.Errorf("ResponseBody should be dropped when storeBody is false, got %q", e.ResponseBody)
+	}
+}
+
+func TestStoreResponse_MissingMessageID_Skips(t *testing.T) {
+	s, cache := newTestStore(t, nil)
+	ctx := &model.StepContext{
+		Context: context.Background(),
+		Request: httptest.NewRequest(http.MethodPost, "/", nil),
+		Body:    []byte(`{"context":{"transaction_id":"txn1"}}`),
+	}
+	if err := s.StoreResponse(ctx, sampleResponseCtx()); err != nil {
+		t.Fatalf("StoreResponse: %v", err)
+	}
+	if idx, _ := cache.Get(context.Background(), txnIndexKey(testNamespace, "txn1")); idx != "" {
+		t.Errorf("expected no index entry, got %q", idx)
+	}
+}
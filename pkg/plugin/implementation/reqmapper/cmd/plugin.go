@@ -10,8 +10,15 @@ import (
 type provider struct{}
 
 func (p provider) New(ctx context.Context, c map[string]string) (definition.Step, func(), error) {
-	step, err := reqmapper.NewReqMapperStep(reqmapper.BuildConfig(c))
-	return step, nil, err
+	cfg, err := reqmapper.BuildConfig(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	step, closer, err := reqmapper.New(ctx, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return step, closer, nil
 }
 
 var Provider = provider{}
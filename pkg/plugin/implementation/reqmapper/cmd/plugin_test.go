@@ -31,3 +31,26 @@ func TestProviderNew_InvalidRole(t *testing.T) {
 	})
 	require.Error(t, err)
 }
+
+func TestProviderNew_ReloadIntervalReturnsCloser(t *testing.T) {
+	step, closer, err := (provider{}).New(context.Background(), map[string]string{
+		"role":           "bap",
+		"mappingsFile":   "../testdata/mappings.yaml",
+		"reloadInterval": "30",
+	})
+	require.NoError(t, err)
+	require.NotNil(t, step)
+	require.NotNil(t, closer)
+	closer()
+}
+
+func TestProviderNew_InvalidReloadInterval(t *testing.T) {
+	for _, v := range []string{"-1", "30s", "abc"} {
+		_, _, err := (provider{}).New(context.Background(), map[string]string{
+			"role":           "bap",
+			"mappingsFile":   "../testdata/mappings.yaml",
+			"reloadInterval": v,
+		})
+		require.Error(t, err, "reloadInterval %q", v)
+	}
+}
@@ -0,0 +1,231 @@
+package reqmapper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jsonata-go/jsonata"
+	"gopkg.in/yaml.v3"
+)
+
+// Function is a custom JSONata function every mapping can call as $Name.
+type Function struct {
+	Name string
+	// Signature is the JSONata signature the arguments are checked against,
+	// e.g. "<s:s>" for one string in and a string out.
+	Signature string
+	// Impl receives the evaluated arguments as decoded JSON values. A nil
+	// result is JSONata's undefined, so the field it is assigned to is left
+	// out of the output.
+	Impl func(args []interface{}) (interface{}, error)
+}
+
+// functionRegistry holds the functions registered with RegisterFunction.
+var functionRegistry = struct {
+	mu  sync.RWMutex
+	fns map[string]Function
+}{fns: make(map[string]Function)}
+
+// lookupFunctionName is reserved for the per-engine lookup table function.
+const lookupFunctionName = "lookup"
+
+func init() {
+	for _, fn := range []Function{
+		{Name: "formatDate", Signature: "<s-ss?:s>", Impl: formatDate},
+		{Name: "uuid", Signature: "<:s>", Impl: func([]interface{}) (interface{}, error) {
+			return uuid.NewString(), nil
+		}},
+		{Name: "prefixedId", Signature: "<s:s>", Impl: prefixedID},
+	} {
+		if err := RegisterFunction(fn); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterFunction makes fn available to every mapping compiled afterwards,
+// including on reload. Names are unique and "lookup" is reserved.
+func RegisterFunction(fn Function) error {
+	if fn.Name == "" || fn.Impl == nil {
+		return errors.New("function name and implementation are required")
+	}
+	if fn.Name == lookupFunctionName {
+		return fmt.Errorf("function name %q is reserved", fn.Name)
+	}
+	functionRegistry.mu.Lock()
+	defer functionRegistry.mu.Unlock()
+	if _, exists := functionRegistry.fns[fn.Name]; exists {
+		return fmt.Errorf("function %q is already registered", fn.Name)
+	}
+	functionRegistry.fns[fn.Name] = fn
+	return nil
+}
+
+// registeredFunctions returns the registered functions ordered by name.
+func registeredFunctions() []Function {
+	functionRegistry.mu.RLock()
+	defer functionRegistry.mu.RUnlock()
+	fns := make([]Function, 0, len(functionRegistry.fns))
+	for _, fn := range functionRegistry.fns {
+		fns = append(fns, fn)
+	}
+	sort.Slice(fns, func(i, j int) bool { return fns[i].Name < fns[j].Name })
+	return fns
+}
+
+// bindFunctions registers the registry's functions and $lookup over tables
+// on a compiled expression.
+func bindFunctions(expr jsonata.Expression, tables lookupTables) error {
+	for _, fn := range registeredFunctions() {
+		if err := expr.RegisterFunction(fn.Name, fn.Impl, fn.Signature); err != nil {
+			return fmt.Errorf("failed to register function $%s: %w", fn.Name, err)
+		}
+	}
+	if err := expr.RegisterFunction(lookupFunctionName, tables.lookup, "<sj?j?:j>"); err != nil {
+		return fmt.Errorf("failed to register function $%s: %w", lookupFunctionName, err)
+	}
+	return nil
+}
+
+// prefixedID implements $prefixedId(prefix): prefix followed by a UUID
+// without dashes. An undefined prefix gives undefined.
+func prefixedID(args []interface{}) (interface{}, error) {
+	if len(args) == 0 || args[0] == nil {
+		return nil, nil
+	}
+	prefix, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("$prefixedId: prefix must be a string, got %T", args[0])
+	}
+	return prefix + strings.ReplaceAll(uuid.NewString(), "-", ""), nil
+}
+
+// dateLayouts names the layouts formatDate accepts besides Go reference
+// layouts.
+var dateLayouts = map[string]string{
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"date":        time.DateOnly,
+	"datetime":    time.DateTime,
+}
+
+// formatDate implements $formatDate(value, toLayout[, fromLayout]): it parses
+// value with fromLayout (RFC3339 by default) and renders it with toLayout.
+// Layouts are Go reference layouts or one of dateLayouts' names; "unix" and
+// "unixMillis" convert to and from epoch timestamps.
+func formatDate(args []interface{}) (interface{}, error) {
+	value, _ := args[0].(string)
+	to, _ := args[1].(string)
+	from := "RFC3339"
+	if len(args) > 2 {
+		if s, ok := args[2].(string); ok && s != "" {
+			from = s
+		}
+	}
+
+	var t time.Time
+	switch from {
+	case "unix", "unixMillis":
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("$formatDate: %q is not a %s timestamp", value, from)
+		}
+		if from == "unix" {
+			t = time.Unix(n, 0).UTC()
+		} else {
+			t = time.UnixMilli(n).UTC()
+		}
+	default:
+		layout := from
+		if named, ok := dateLayouts[from]; ok {
+			layout = named
+		}
+		var err error
+		if t, err = time.Parse(layout, value); err != nil {
+			return nil, fmt.Errorf("$formatDate: %w", err)
+		}
+	}
+
+	switch to {
+	case "unix":
+		return strconv.FormatInt(t.Unix(), 10), nil
+	case "unixMillis":
+		return strconv.FormatInt(t.UnixMilli(), 10), nil
+	}
+	if named, ok := dateLayouts[to]; ok {
+		to = named
+	}
+	return t.Format(to), nil
+}
+
+// lookupTables maps a table name to its key → value entries.
+type lookupTables map[string]map[string]interface{}
+
+// loadLookupTables reads each named table from its JSON or YAML file. Paths
+// are relative to the mappings file's directory.
+func loadLookupTables(files map[string]string, baseDir string) (lookupTables, error) {
+	tables := make(lookupTables, len(files))
+	for name, file := range files {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(baseDir, file)
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read lookup table %s: %w", name, err)
+		}
+		var entries map[string]interface{}
+		if filepath.Ext(file) == ".json" {
+			err = json.Unmarshal(data, &entries)
+		} else {
+			err = yaml.Unmarshal(data, &entries)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse lookup table %s from %s: %w", name, file, err)
+		}
+		tables[name] = entries
+	}
+	return tables, nil
+}
+
+// lookup implements $lookup(table, key[, default]). A key missing from the
+// table yields default, or undefined when none is given. An unknown table is
+// an error, so a typo in a mapping fails loudly instead of dropping fields.
+func (t lookupTables) lookup(args []interface{}) (interface{}, error) {
+	name, _ := args[0].(string)
+	table, ok := t[name]
+	if !ok {
+		return nil, fmt.Errorf("$lookup: unknown lookup table %q", name)
+	}
+	if len(args) < 2 || args[1] == nil {
+		return nil, nil
+	}
+	if v, ok := table[lookupKey(args[1])]; ok {
+		return v, nil
+	}
+	if len(args) > 2 {
+		return args[2], nil
+	}
+	return nil, nil
+}
+
+// lookupKey renders a JSON scalar as a table key; integral numbers drop
+// their fraction so 110001 finds the key "110001".
+func lookupKey(v interface{}) string {
+	switch k := v.(type) {
+	case string:
+		return k
+	case float64:
+		return strconv.FormatFloat(k, 'f', -1, 64)
+	default:
+		return fmt.Sprint(k)
+	}
+}
@@ -0,0 +1,93 @@
+package reqmapper
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatDate(t *testing.T) {
+	tests := []struct {
+		name string
+		args []interface{}
+		want string
+	}{
+		{"RFC3339 to date", []interface{}{"2026-03-14T09:30:00Z", "date"}, "2026-03-14"},
+		{"date to RFC3339", []interface{}{"2026-03-14", "RFC3339", "date"}, "2026-03-14T00:00:00Z"},
+		{"Go layout", []interface{}{"2026-03-14T09:30:00Z", "02/01/2006 15:04"}, "14/03/2026 09:30"},
+		{"to unix", []interface{}{"2026-03-14T09:30:00Z", "unix"}, "1773480600"},
+		{"from unixMillis", []interface{}{"1773480600000", "RFC3339", "unixMillis"}, "2026-03-14T09:30:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := formatDate(tt.args)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := formatDate([]interface{}{"14 March", "date"})
+	require.ErrorContains(t, err, "$formatDate")
+	_, err = formatDate([]interface{}{"soon", "date", "unix"})
+	require.ErrorContains(t, err, "not a unix timestamp")
+}
+
+func TestPrefixedID(t *testing.T) {
+	got, err := prefixedID([]interface{}{"txn-"})
+	require.NoError(t, err)
+	require.Regexp(t, `^txn-[0-9a-f]{32}$`, got)
+
+	got, err = prefixedID([]interface{}{nil})
+	require.NoError(t, err)
+	require.Nil(t, got, "an undefined prefix is undefined")
+
+	_, err = prefixedID([]interface{}{float64(7)})
+	require.ErrorContains(t, err, "$prefixedId")
+}
+
+func TestLookupTables(t *testing.T) {
+	tables := lookupTables{"city": {"110001": "std:011", "560001": "std:080"}}
+
+	got, err := tables.lookup([]interface{}{"city", float64(110001)})
+	require.NoError(t, err)
+	require.Equal(t, "std:011", got)
+
+	got, err = tables.lookup([]interface{}{"city", "999999"})
+	require.NoError(t, err)
+	require.Nil(t, got, "a missing key without a default is undefined")
+
+	got, err = tables.lookup([]interface{}{"city", "999999", "std:000"})
+	require.NoError(t, err)
+	require.Equal(t, "std:000", got)
+
+	_, err = tables.lookup([]interface{}{"ctiy", "110001"})
+	require.ErrorContains(t, err, `unknown lookup table "ctiy"`)
+}
+
+func TestRegisterFunction(t *testing.T) {
+	impl := func([]interface{}) (interface{}, error) { return "x", nil }
+
+	require.Error(t, RegisterFunction(Function{Name: "", Impl: impl}))
+	require.Error(t, RegisterFunction(Function{Name: "noImpl"}))
+	require.ErrorContains(t, RegisterFunction(Function{Name: "lookup", Impl: impl}), "reserved")
+	require.ErrorContains(t, RegisterFunction(Function{Name: "formatDate", Impl: impl}), "already registered")
+
+	require.NoError(t, RegisterFunction(Function{Name: "testConstant", Signature: "<:s>", Impl: impl}))
+	t.Cleanup(func() {
+		functionRegistry.mu.Lock()
+		delete(functionRegistry.fns, "testConstant")
+		functionRegistry.mu.Unlock()
+	})
+
+	engine, err := initMappingEngine(&Config{Role: "bap", MappingsFile: writeMappings(t, `
+mappings:
+  search:
+    bapMappings: '{"value": $testConstant()}'
+    bppMappings: '$'
+`)})
+	require.NoError(t, err)
+	out, err := engine.Transform(context.Background(), "search", map[string]interface{}{}, "bap")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"value":"x"}`, string(out))
+}
@@ -0,0 +1,247 @@
+package reqmapper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Fixture file suffixes. A fixture is the set of files sharing a name within
+// a <role>/<action> directory of the cases directory.
+const (
+	fixtureInput            = ".input.json"
+	fixtureExpected         = ".expected.json"
+	fixtureResponse         = ".response.json"
+	fixtureExpectedResponse = ".expected-response.json"
+)
+
+// anyValue, as an expected string value, matches any value the mapping
+// produces there, for fields such as $uuid() that differ on every run.
+const anyValue = "<any>"
+
+// MappingTestResult is the outcome of running one fixture through
+// RunMappingTests.
+type MappingTestResult struct {
+	Name   string // <role>/<action>/<fixture>, relative to the cases directory
+	Role   string
+	Action string
+	// Diffs lists the differences between the expected and mapped payloads,
+	// one per line, each prefixed with the JSON Pointer it was found at.
+	Diffs []string
+	Err   error // load or evaluation error
+}
+
+// Passed reports whether the mapping produced the expected payloads.
+func (r MappingTestResult) Passed() bool {
+	return r.Err == nil && len(r.Diffs) == 0
+}
+
+// RunMappingTests compiles mappingsFile as the plugin does and runs every
+// fixture in casesDir through it. Fixtures sit in a directory per role and
+// action:
+//
+//	cases/bap/search/by_category.input.json
+//	cases/bap/search/by_category.expected.json
+//	cases/bap/confirm/accepted.input.json
+//	cases/bap/confirm/accepted.expected.json
+//	cases/bap/confirm/accepted.response.json
+//	cases/bap/confirm/accepted.expected-response.json
+//
+// The input is mapped with the role's request mapping for the action and
+// compared with expected. When a fixture also has a response, it is mapped
+// with the response mapping, with the input bound as $request, and compared
+// with expected-response.
+func RunMappingTests(ctx context.Context, mappingsFile, casesDir string) ([]MappingTestResult, error) {
+	engine, err := initMappingEngine(&Config{MappingsFile: mappingsFile})
+	if err != nil {
+		return nil, err
+	}
+
+	var results []MappingTestResult
+	for _, role := range []string{"bap", "bpp"} {
+		inputs, err := filepath.Glob(filepath.Join(casesDir, role, "*", "*"+fixtureInput))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(inputs)
+		for _, input := range inputs {
+			action := filepath.Base(filepath.Dir(input))
+			fixture := strings.TrimSuffix(input, fixtureInput)
+			result := engine.runMappingTest(ctx, role, action, fixture)
+			result.Name = role + "/" + action + "/" + filepath.Base(fixture)
+			results = append(results, result)
+		}
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no fixtures found in %s (expected <role>/<action>/<name>%s files, role bap or bpp)", casesDir, fixtureInput)
+	}
+	return results, nil
+}
+
+// runMappingTest runs the fixture whose files start with the path prefix
+// fixture.
+func (e *MappingEngine) runMappingTest(ctx context.Context, role, action, fixture string) MappingTestResult {
+	result := MappingTestResult{Role: role, Action: action}
+	if !e.hasMapping(action, role) {
+		result.Err = fmt.Errorf("no %sMappings for action %s", role, action)
+		return result
+	}
+
+	var req map[string]interface{}
+	if err := readFixture(fixture+fixtureInput, &req); err != nil {
+		result.Err = err
+		return result
+	}
+	var want interface{}
+	if err := readFixture(fixture+fixtureExpected, &want); err != nil {
+		result.Err = err
+		return result
+	}
+	mapped, err := e.Transform(ctx, action, req, role)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Diffs = append(result.Diffs, diffMapped(want, mapped)...)
+
+	response, err := os.ReadFile(fixture + fixtureResponse)
+	if os.IsNotExist(err) {
+		return result
+	}
+	if err != nil {
+		result.Err = fmt.Errorf("failed to read fixture: %w", err)
+		return result
+	}
+	var wantResponse interface{}
+	if err := readFixture(fixture+fixtureExpectedResponse, &wantResponse); err != nil {
+		result.Err = err
+		return result
+	}
+	mappedResponse, err := e.TransformResponse(ctx, action, role, response, req)
+	if err != nil {
+		result.Err = fmt.Errorf("response: %w", err)
+		return result
+	}
+	if mappedResponse == nil {
+		result.Err = fmt.Errorf("no %sResponseMappings for action %s", role, action)
+		return result
+	}
+	for _, d := range diffMapped(wantResponse, mappedResponse) {
+		result.Diffs = append(result.Diffs, "response "+d)
+	}
+	return result
+}
+
+// readFixture decodes the JSON file at path into v.
+func readFixture(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read fixture: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse fixture %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// diffMapped compares a mapping's output with the expected payload.
+func diffMapped(want interface{}, mapped []byte) []string {
+	var got interface{}
+	if err := json.Unmarshal(mapped, &got); err != nil {
+		return []string{fmt.Sprintf("mapping output is not JSON: %v", err)}
+	}
+	return diffJSON("", want, got)
+}
+
+// diffJSON lists the structural differences between two decoded JSON values,
+// each as "<pointer>: <difference>". Objects are compared key by key and
+// arrays element by element, so one wrong field is reported as that field
+// rather than as its whole enclosing object.
+func diffJSON(ptr string, want, got interface{}) []string {
+	if s, ok := want.(string); ok && s == anyValue {
+		if got == nil {
+			return []string{ptr + ": expected a value, got null"}
+		}
+		return nil
+	}
+
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: expected an object, got %s", ptr, renderJSON(got))}
+		}
+		var diffs []string
+		for _, key := range sortedKeys(w, g) {
+			child := ptr + "/" + escapePointerToken(key)
+			wv, inWant := w[key]
+			gv, inGot := g[key]
+			switch {
+			case !inGot:
+				diffs = append(diffs, fmt.Sprintf("%s: missing, expected %s", child, renderJSON(wv)))
+			case !inWant:
+				diffs = append(diffs, fmt.Sprintf("%s: unexpected %s", child, renderJSON(gv)))
+			default:
+				diffs = append(diffs, diffJSON(child, wv, gv)...)
+			}
+		}
+		return diffs
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: expected an array, got %s", ptr, renderJSON(got))}
+		}
+		var diffs []string
+		for i := 0; i < len(w) || i < len(g); i++ {
+			child := ptr + "/" + strconv.Itoa(i)
+			switch {
+			case i >= len(g):
+				diffs = append(diffs, fmt.Sprintf("%s: missing, expected %s", child, renderJSON(w[i])))
+			case i >= len(w):
+				diffs = append(diffs, fmt.Sprintf("%s: unexpected %s", child, renderJSON(g[i])))
+			default:
+				diffs = append(diffs, diffJSON(child, w[i], g[i])...)
+			}
+		}
+		return diffs
+	}
+	if !reflect.DeepEqual(want, got) {
+		return []string{fmt.Sprintf("%s: expected %s, got %s", ptr, renderJSON(want), renderJSON(got))}
+	}
+	return nil
+}
+
+// sortedKeys returns the union of both objects' keys, sorted.
+func sortedKeys(a, b map[string]interface{}) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// escapePointerToken escapes a key for use as a JSON Pointer token.
+func escapePointerToken(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+// renderJSON renders a decoded JSON value compactly for a diff line.
+func renderJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,90 @@
+package reqmapper
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeFixture(t *testing.T, dir, name, body string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(body), 0644))
+}
+
+func TestRunMappingTests(t *testing.T) {
+	mappings := writeMappings(t, `
+mappings:
+  confirm:
+    bapMappings: '{"orderId": message.order.id, "ref": $uuid(), "items": message.order.items.id}'
+    bppMappings: '$'
+    bapResponseMappings: '{"ref": $request.message.order.id, "accepted": message.status = "ACK"}'
+`)
+
+	cases := t.TempDir()
+	input := `{"message": {"order": {"id": "o-1", "items": [{"id": "i-1"}, {"id": "i-2"}]}}}`
+	writeFixture(t, cases, "bap/confirm/ok.input.json", input)
+	writeFixture(t, cases, "bap/confirm/ok.expected.json", `{"orderId": "o-1", "ref": "<any>", "items": ["i-1", "i-2"]}`)
+	writeFixture(t, cases, "bap/confirm/ok.response.json", `{"message": {"status": "ACK"}}`)
+	writeFixture(t, cases, "bap/confirm/ok.expected-response.json", `{"ref": "o-1", "accepted": true}`)
+	writeFixture(t, cases, "bap/confirm/wrong.input.json", input)
+	writeFixture(t, cases, "bap/confirm/wrong.expected.json", `{"orderId": "o-2", "ref": "<any>", "items": ["i-1"], "extra": 1}`)
+	writeFixture(t, cases, "bap/confirm/wrong.response.json", `{"message": {"status": "NACK"}}`)
+	writeFixture(t, cases, "bap/confirm/wrong.expected-response.json", `{"ref": "o-1", "accepted": true}`)
+	writeFixture(t, cases, "bpp/search/unmapped.input.json", `{}`)
+	writeFixture(t, cases, "bpp/search/unmapped.expected.json", `{}`)
+	writeFixture(t, cases, "bap/confirm/notes.txt", "not a fixture")
+
+	results, err := RunMappingTests(context.Background(), mappings, cases)
+	require.NoError(t, err)
+
+	got := map[string]MappingTestResult{}
+	for _, r := range results {
+		got[r.Name] = r
+	}
+	require.Len(t, got, 3)
+	require.True(t, got["bap/confirm/ok"].Passed(), "%v %v", got["bap/confirm/ok"].Diffs, got["bap/confirm/ok"].Err)
+
+	wrong := got["bap/confirm/wrong"]
+	require.NoError(t, wrong.Err)
+	require.Equal(t, []string{
+		`/extra: missing, expected 1`,
+		`/items/1: unexpected "i-2"`,
+		`/orderId: expected "o-2", got "o-1"`,
+		`response /accepted: expected true, got false`,
+	}, wrong.Diffs)
+
+	unmapped := got["bpp/search/unmapped"]
+	require.False(t, unmapped.Passed())
+	require.ErrorContains(t, unmapped.Err, "no bppMappings for action search")
+}
+
+func TestRunMappingTests_Errors(t *testing.T) {
+	mappings := writeMappings(t, "mappings:\n  search:\n    bapMappings: '$'\n    bppMappings: '$'\n")
+
+	_, err := RunMappingTests(context.Background(), mappings, t.TempDir())
+	require.ErrorContains(t, err, "no fixtures found")
+
+	_, err = RunMappingTests(context.Background(), filepath.Join(t.TempDir(), "missing.yaml"), t.TempDir())
+	require.ErrorContains(t, err, "failed to read mappings file")
+
+	cases := t.TempDir()
+	writeFixture(t, cases, "bap/search/bad.input.json", `{"context": {}}`)
+	results, err := RunMappingTests(context.Background(), mappings, cases)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.True(t, strings.Contains(results[0].Err.Error(), "failed to read fixture"), results[0].Err)
+}
+
+func TestDiffJSON(t *testing.T) {
+	require.Empty(t, diffJSON("", map[string]interface{}{"a": "<any>"}, map[string]interface{}{"a": 3.0}))
+	require.Equal(t, []string{"/a: expected a value, got null"},
+		diffJSON("", map[string]interface{}{"a": "<any>"}, map[string]interface{}{"a": nil}))
+	require.Equal(t, []string{"/a~1b: expected an object, got [1]"},
+		diffJSON("", map[string]interface{}{"a/b": map[string]interface{}{}}, map[string]interface{}{"a/b": []interface{}{1.0}}))
+}
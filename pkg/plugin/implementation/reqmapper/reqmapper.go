@@ -3,12 +3,19 @@ package reqmapper
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
+	"path"
+	"path/filepath"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/beckn-one/beckn-onix/pkg/log"
 	"github.com/beckn-one/beckn-onix/pkg/model"
@@ -21,6 +28,9 @@ import (
 type Config struct {
 	Role         string `yaml:"role"`         // "bap" or "bpp"
 	MappingsFile string `yaml:"mappingsFile"` // required path to mappings YAML
+	// ReloadInterval is how often, in seconds, the mappings file and its
+	// lookup tables are checked for changes. Zero disables reloading.
+	ReloadInterval int `yaml:"reloadInterval"`
 }
 
 // MappingEngine handles JSONata-based transformations
@@ -29,50 +39,112 @@ type MappingEngine struct {
 	jsonataInstance jsonata.JSONataInstance
 	bapMaps         map[string]jsonata.Expression
 	bppMaps         map[string]jsonata.Expression
+	bapRespMaps     map[string]jsonata.Expression // response mappings, compiled only where declared
+	bppRespMaps     map[string]jsonata.Expression
 	mappings        map[string]builtinMapping
 	mappingSource   string
-	mutex           sync.RWMutex
-	initialized     bool
+	// fingerprint identifies the mappings and lookup files last loaded.
+	fingerprint string
+	mutex       sync.RWMutex
+	initialized bool
 }
 
 type builtinMapping struct {
 	BAP string `yaml:"bapMappings"`
 	BPP string `yaml:"bppMappings"`
+	// BAPResponse and BPPResponse map the synchronous response the upstream
+	// returns for the action. Both are optional.
+	BAPResponse string `yaml:"bapResponseMappings"`
+	BPPResponse string `yaml:"bppResponseMappings"`
 }
 
 type mappingFile struct {
+	// Lookups names the lookup tables $lookup reads, each a JSON or YAML
+	// object file relative to the mappings file.
+	Lookups  map[string]string         `yaml:"lookups"`
 	Mappings map[string]builtinMapping `yaml:"mappings"`
 }
 
+// compiledMappings is one load of the mappings file, swapped into the engine
+// as a whole.
+type compiledMappings struct {
+	bapMaps, bppMaps         map[string]jsonata.Expression
+	bapRespMaps, bppRespMaps map[string]jsonata.Expression
+	mappings                 map[string]builtinMapping
+	source                   string
+	files                    []string // mappings file and lookup tables, for change detection
+}
+
 type reqMapperStep struct {
 	engine *MappingEngine
 	role   string
 }
 
+// exchangeKey is the context key under which Run records the request it
+// mapped, for RunOnResponse to map the response to that request.
+type exchangeKey struct{}
+
+// exchange is the request side of a mapped exchange.
+type exchange struct {
+	action string
+	// request is the request as received, before mapping.
+	request map[string]interface{}
+}
+
 type parsedRequest struct {
 	req    map[string]interface{}
 	action string
 }
 
 // NewReqMapperStep returns a handler step that applies the same reqmapper transformation logic.
+// The step is also a definition.ResponseStep that maps the upstream's
+// synchronous response.
 func NewReqMapperStep(cfg *Config) (definition.Step, error) {
+	step, _, err := New(context.Background(), cfg)
+	return step, err
+}
+
+// New builds the step and, when cfg.ReloadInterval is set, starts reloading
+// the mappings file in the background. The returned func, nil when reloading
+// is disabled, stops it.
+func New(ctx context.Context, cfg *Config) (*reqMapperStep, func(), error) {
 	if err := validateConfig(cfg); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	engine, err := initMappingEngine(cfg)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return &reqMapperStep{
+	step := &reqMapperStep{
 		engine: engine,
 		role:   cfg.Role,
+	}
+	if cfg.ReloadInterval <= 0 {
+		return step, nil, nil
+	}
+
+	reloadCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		engine.reloadLoop(reloadCtx, time.Duration(cfg.ReloadInterval)*time.Second)
+	}()
+	return step, func() {
+		cancel()
+		<-done
 	}, nil
 }
 
 // Run transforms the current request body and updates the step context in place.
 func (s *reqMapperStep) Run(ctx *model.StepContext) error {
-	mappedBody, err := s.transformBody(ctx.Context, ctx.Body)
+	parsed, err := s.parseBody(ctx.Request, ctx.Body)
+	if err != nil {
+		return err
+	}
+	ctx.WithContext(context.WithValue(ctx.Context, exchangeKey{}, &exchange{action: parsed.action, request: parsed.req}))
+
+	mappedBody, err := s.transformBody(ctx.Context, parsed, ctx.Body)
 	if err != nil {
 		return err
 	}
@@ -90,12 +162,7 @@ func (s *reqMapperStep) Run(ctx *model.StepContext) error {
 	return nil
 }
 
-func (s *reqMapperStep) transformBody(ctx context.Context, body []byte) ([]byte, error) {
-	parsed, err := parseRequestBody(body)
-	if err != nil {
-		return nil, err
-	}
-
+func (s *reqMapperStep) transformBody(ctx context.Context, parsed *parsedRequest, body []byte) ([]byte, error) {
 	mappedBody, err := s.engine.Transform(ctx, parsed.action, parsed.req, s.role)
 	if err != nil {
 		log.Errorf(ctx, err, "Transformation failed for action %s", parsed.action)
@@ -105,6 +172,66 @@ func (s *reqMapperStep) transformBody(ctx context.Context, body []byte) ([]byte,
 	return mappedBody, nil
 }
 
+// parseBody parses a Beckn request body. A body without context.action —
+// such as an on_* callback a backend posts in its own format — is accepted
+// when the last segment of the request path names an action that has a
+// mapping for this role, so the mapping can produce the Beckn payload.
+func (s *reqMapperStep) parseBody(r *http.Request, body []byte) (*parsedRequest, error) {
+	parsed, err := parseRequestBody(body)
+	if err == nil || r == nil || r.URL == nil {
+		return parsed, err
+	}
+	action := path.Base(r.URL.Path)
+	if !s.engine.hasMapping(action, s.role) {
+		return nil, err
+	}
+	var req map[string]interface{}
+	if json.Unmarshal(body, &req) != nil || req == nil {
+		return nil, err
+	}
+	return &parsedRequest{req: req, action: action}, nil
+}
+
+// RunOnResponse maps the upstream's synchronous response with the response
+// mapping for the request's action and role. The mapping sees the response
+// as $ and the request as received, before mapping, as $request.
+//
+// No-ops:
+//   - publisher path (rctx == nil) or an empty response body
+//   - a response already replaced with ONIX's own NACK
+//   - an action without a response mapping for this role
+//
+// A failed mapping is logged and the response passed through, as for
+// requests.
+func (s *reqMapperStep) RunOnResponse(ctx *model.StepContext, rctx *model.ResponseStepContext) error {
+	if rctx == nil || rctx.Replaced || len(rctx.Body) == 0 {
+		return nil
+	}
+	ex, _ := ctx.Value(exchangeKey{}).(*exchange)
+	if ex == nil {
+		parsed, err := s.parseBody(ctx.Request, ctx.Body)
+		if err != nil {
+			log.Debugf(ctx, "reqmapper: no action for response mapping: %v", err)
+			return nil
+		}
+		ex = &exchange{action: parsed.action, request: parsed.req}
+	}
+
+	mapped, err := s.engine.TransformResponse(ctx, ex.action, s.role, rctx.Body, ex.request)
+	if err != nil {
+		log.Errorf(ctx, err, "Response transformation failed for action %s", ex.action)
+		return nil
+	}
+	if mapped == nil {
+		return nil
+	}
+	rctx.Body = mapped
+	if rctx.Header != nil {
+		rctx.Header.Set("Content-Type", "application/json")
+	}
+	return nil
+}
+
 // parseRequestBody parses the incoming request body and extracts the fields
 // the mapping engine needs. Failures are classified onto the Beckn v2.0.0
 // ErrorCode taxonomy at the point each cause is known, rather than being
@@ -127,7 +254,7 @@ func parseRequestBody(body []byte) (*parsedRequest, error) {
 }
 
 // BuildConfig parses the generic plugin config map into a strongly typed reqmapper Config.
-func BuildConfig(c map[string]string) *Config {
+func BuildConfig(c map[string]string) (*Config, error) {
 	cfg := &Config{}
 	if role, ok := c["role"]; ok {
 		cfg.Role = role
@@ -135,7 +262,14 @@ func BuildConfig(c map[string]string) *Config {
 	if mappingsFile, ok := c["mappingsFile"]; ok {
 		cfg.MappingsFile = mappingsFile
 	}
-	return cfg
+	if v, ok := c["reloadInterval"]; ok {
+		interval, err := strconv.Atoi(v)
+		if err != nil || interval < 0 {
+			return nil, fmt.Errorf("reloadInterval must be a non-negative number of seconds, got %q", v)
+		}
+		cfg.ReloadInterval = interval
+	}
+	return cfg, nil
 }
 
 // initMappingEngine initializes a mapping engine for the provided config.
@@ -145,9 +279,11 @@ func initMappingEngine(cfg *Config) (*MappingEngine, error) {
 	}
 
 	engine := &MappingEngine{
-		config:  cfg,
-		bapMaps: make(map[string]jsonata.Expression),
-		bppMaps: make(map[string]jsonata.Expression),
+		config:      cfg,
+		bapMaps:     make(map[string]jsonata.Expression),
+		bppMaps:     make(map[string]jsonata.Expression),
+		bapRespMaps: make(map[string]jsonata.Expression),
+		bppRespMaps: make(map[string]jsonata.Expression),
 	}
 
 	instance, err := jsonata.OpenLatest()
@@ -164,7 +300,7 @@ func initMappingEngine(cfg *Config) (*MappingEngine, error) {
 	return engine, nil
 }
 
-func (e *MappingEngine) loadMappingsFromConfig() (map[string]builtinMapping, string, error) {
+func (e *MappingEngine) loadMappingsFromConfig() (*mappingFile, string, error) {
 	if e.config == nil || e.config.MappingsFile == "" {
 		return nil, "", errors.New("mappingsFile must be provided in config")
 	}
@@ -184,47 +320,170 @@ func (e *MappingEngine) loadMappingsFromConfig() (map[string]builtinMapping, str
 		return nil, "", fmt.Errorf("no mappings found in %s", source)
 	}
 
-	return parsed.Mappings, source, nil
+	return &parsed, source, nil
 }
 
-// loadBuiltinMappings compiles JSONata expressions for every action/direction pair from the configured mappings file.
-func (e *MappingEngine) loadBuiltinMappings() error {
-	mappings, source, err := e.loadMappingsFromConfig()
+// compileMappings compiles JSONata expressions for every action/direction
+// pair from the configured mappings file, binding the registered functions
+// and the file's lookup tables to each.
+func (e *MappingEngine) compileMappings() (*compiledMappings, error) {
+	parsed, source, err := e.loadMappingsFromConfig()
 	if err != nil {
-		return err
+		return nil, err
+	}
+	baseDir := filepath.Dir(source)
+	tables, err := loadLookupTables(parsed.Lookups, baseDir)
+	if err != nil {
+		return nil, err
 	}
 
-	e.bapMaps = make(map[string]jsonata.Expression, len(mappings))
-	e.bppMaps = make(map[string]jsonata.Expression, len(mappings))
+	c := &compiledMappings{
+		bapMaps:     make(map[string]jsonata.Expression, len(parsed.Mappings)),
+		bppMaps:     make(map[string]jsonata.Expression, len(parsed.Mappings)),
+		bapRespMaps: make(map[string]jsonata.Expression),
+		bppRespMaps: make(map[string]jsonata.Expression),
+		mappings:    parsed.Mappings,
+		source:      source,
+		files:       []string{source},
+	}
+	for _, file := range parsed.Lookups {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(baseDir, file)
+		}
+		c.files = append(c.files, file)
+	}
 
-	for action, mapping := range mappings {
-		bapExpr, err := e.jsonataInstance.Compile(mapping.BAP, false)
+	compile := func(expr, what, action string) (jsonata.Expression, error) {
+		compiled, err := e.jsonataInstance.Compile(expr, false)
 		if err != nil {
-			return fmt.Errorf("failed to compile BAP mapping for action %s: %w", action, err)
+			return nil, fmt.Errorf("failed to compile %s mapping for action %s: %w", what, action, err)
 		}
-		bppExpr, err := e.jsonataInstance.Compile(mapping.BPP, false)
-		if err != nil {
-			return fmt.Errorf("failed to compile BPP mapping for action %s: %w", action, err)
+		if err := bindFunctions(compiled, tables); err != nil {
+			return nil, fmt.Errorf("%s mapping for action %s: %w", what, action, err)
+		}
+		return compiled, nil
+	}
+
+	for action, mapping := range parsed.Mappings {
+		if c.bapMaps[action], err = compile(mapping.BAP, "BAP", action); err != nil {
+			return nil, err
+		}
+		if c.bppMaps[action], err = compile(mapping.BPP, "BPP", action); err != nil {
+			return nil, err
+		}
+		if mapping.BAPResponse != "" {
+			if c.bapRespMaps[action], err = compile(mapping.BAPResponse, "BAP response", action); err != nil {
+				return nil, err
+			}
+		}
+		if mapping.BPPResponse != "" {
+			if c.bppRespMaps[action], err = compile(mapping.BPPResponse, "BPP response", action); err != nil {
+				return nil, err
+			}
 		}
+	}
+	return c, nil
+}
 
-		e.bapMaps[action] = bapExpr
-		e.bppMaps[action] = bppExpr
+// loadBuiltinMappings compiles the configured mappings file and, only if all
+// of it compiles, replaces the engine's mappings with it.
+func (e *MappingEngine) loadBuiltinMappings() error {
+	fingerprint, _ := fileFingerprint(e.mappingFiles())
+	c, err := e.compileMappings()
+	if err != nil {
+		return err
+	}
+	if fingerprint == "" {
+		fingerprint, _ = fileFingerprint(c.files)
 	}
 
-	e.mappings = mappings
-	e.mappingSource = source
+	e.bapMaps = c.bapMaps
+	e.bppMaps = c.bppMaps
+	e.bapRespMaps = c.bapRespMaps
+	e.bppRespMaps = c.bppRespMaps
+	e.mappings = c.mappings
+	e.mappingSource = c.source
+	e.fingerprint = fingerprint
 
 	log.Infof(
 		context.Background(),
-		"Loaded %d BAP mappings and %d BPP mappings from %s",
+		"Loaded %d BAP mappings and %d BPP mappings (%d BAP and %d BPP response mappings) from %s",
 		len(e.bapMaps),
 		len(e.bppMaps),
-		source,
+		len(e.bapRespMaps),
+		len(e.bppRespMaps),
+		c.source,
 	)
 
 	return nil
 }
 
+// mappingFiles lists the mappings file and the lookup tables it last
+// loaded. Callers hold the mutex or own the engine.
+func (e *MappingEngine) mappingFiles() []string {
+	if e.mappingSource == "" {
+		return nil
+	}
+	files := []string{e.mappingSource}
+	if data, err := os.ReadFile(e.mappingSource); err == nil {
+		var parsed mappingFile
+		if yaml.Unmarshal(data, &parsed) == nil {
+			for _, file := range parsed.Lookups {
+				if !filepath.IsAbs(file) {
+					file = filepath.Join(filepath.Dir(e.mappingSource), file)
+				}
+				files = append(files, file)
+			}
+		}
+	}
+	return files
+}
+
+// fileFingerprint summarises the names, sizes and modification times of
+// files.
+func fileFingerprint(files []string) (string, error) {
+	if len(files) == 0 {
+		return "", nil
+	}
+	h := sha256.New()
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s %d %d\n", file, info.Size(), info.ModTime().UnixNano())
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// reloadLoop reloads the mappings whenever the mappings file or one of its
+// lookup tables changes. A reload that fails keeps the current mappings.
+func (e *MappingEngine) reloadLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var failed string
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		e.mutex.RLock()
+		files, current := e.mappingFiles(), e.fingerprint
+		e.mutex.RUnlock()
+		fingerprint, err := fileFingerprint(files)
+		if err != nil || fingerprint == current || fingerprint == failed {
+			continue
+		}
+		if err := e.ReloadMappings(); err != nil {
+			log.Errorf(ctx, err, "Failed to reload mappings — keeping previously loaded mappings")
+			failed = fingerprint
+			continue
+		}
+		failed = ""
+	}
+}
+
 // Transform applies the appropriate mapping based on role and action
 func (e *MappingEngine) Transform(ctx context.Context, action string, req map[string]interface{}, role string) ([]byte, error) {
 	e.mutex.RLock()
@@ -265,7 +524,49 @@ func (e *MappingEngine) Transform(ctx context.Context, action string, req map[st
 	return result, nil
 }
 
-// ReloadMappings reloads all mapping files (useful for hot-reload scenarios)
+// TransformResponse applies the response mapping for role and action to a
+// synchronous response body, with the request bound as $request. It returns
+// nil when there is no response mapping, leaving the response unchanged.
+func (e *MappingEngine) TransformResponse(ctx context.Context, action, role string, body []byte, request map[string]interface{}) ([]byte, error) {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	var expr jsonata.Expression
+	switch role {
+	case "bap":
+		expr = e.bapRespMaps[action]
+	case "bpp":
+		expr = e.bppRespMaps[action]
+	}
+	if expr == nil {
+		log.Debugf(ctx, "No response mapping found for action: %s, role: %s", action, role)
+		return nil, nil
+	}
+
+	result, err := expr.Evaluate(body, map[string]interface{}{"request": request})
+	if err != nil {
+		return nil, fmt.Errorf("JSONata evaluation failed: %w", err)
+	}
+
+	log.Debugf(ctx, "Successfully transformed %s response using %s mapping, %s", action, role, result)
+	return result, nil
+}
+
+// hasMapping reports whether role has a request mapping for action.
+func (e *MappingEngine) hasMapping(action, role string) bool {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	switch role {
+	case "bap":
+		return e.bapMaps[action] != nil
+	case "bpp":
+		return e.bppMaps[action] != nil
+	}
+	return false
+}
+
+// ReloadMappings reloads all mapping files (useful for hot-reload scenarios).
+// If the file no longer compiles, the current mappings are kept.
 func (e *MappingEngine) ReloadMappings() error {
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
@@ -288,11 +589,23 @@ func (e *MappingEngine) GetMappingInfo() map[string]interface{} {
 		bppActions = append(bppActions, action)
 	}
 
+	bapResponseActions := make([]string, 0, len(e.bapRespMaps))
+	for action := range e.bapRespMaps {
+		bapResponseActions = append(bapResponseActions, action)
+	}
+
+	bppResponseActions := make([]string, 0, len(e.bppRespMaps))
+	for action := range e.bppRespMaps {
+		bppResponseActions = append(bppResponseActions, action)
+	}
+
 	return map[string]interface{}{
-		"bap_mappings":    bapActions,
-		"bpp_mappings":    bppActions,
-		"mappings_source": e.mappingSource,
-		"action_count":    len(e.mappings),
+		"bap_mappings":          bapActions,
+		"bpp_mappings":          bppActions,
+		"bap_response_mappings": bapResponseActions,
+		"bpp_response_mappings": bppResponseActions,
+		"mappings_source":       e.mappingSource,
+		"action_count":          len(e.mappings),
 	}
 }
 
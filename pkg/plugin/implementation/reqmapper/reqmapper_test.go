@@ -7,9 +7,11 @@ import (
 	"errors"
 	"io"
 	"net/http"
+	"os"
 	"path/filepath"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/beckn-one/beckn-onix/pkg/model"
 	"github.com/beckn-one/beckn-onix/pkg/testutil"
@@ -279,3 +281,152 @@ func TestMappingEngineReloadMappings(t *testing.T) {
 	require.Equal(t, originalBAP, len(engine.bapMaps))
 	require.Equal(t, originalBPP, len(engine.bppMaps))
 }
+
+// writeMappings writes a mappings file, and the given lookup table files
+// beside it, to a temporary directory.
+func writeMappings(t *testing.T, content string, files ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for i := 0; i+1 < len(files); i += 2 {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, files[i]), []byte(files[i+1]), 0644))
+	}
+	path := filepath.Join(dir, "mappings.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+const responseMappings = `
+lookups:
+  status: status.json
+mappings:
+  confirm:
+    bapMappings: '$'
+    bppMappings: '$'
+    bapResponseMappings: |
+      {
+        "orderRef": $request.message.order.id,
+        "status": $lookup("status", message.status, "unknown"),
+        "acceptedOn": $formatDate($request.context.timestamp, "date")
+      }
+`
+
+func TestReqMapperStep_ResponseMapping(t *testing.T) {
+	step, _, err := New(context.Background(), &Config{
+		Role:         "bap",
+		MappingsFile: writeMappings(t, responseMappings, "status.json", `{"ACK":"accepted","NACK":"rejected"}`),
+	})
+	require.NoError(t, err)
+
+	body := []byte(`{"context":{"action":"confirm","timestamp":"2026-03-14T09:30:00Z"},"message":{"order":{"id":"o-1"}}}`)
+	req, err := http.NewRequest(http.MethodPost, "http://example.com/confirm", bytes.NewReader(body))
+	require.NoError(t, err)
+	ctx := &model.StepContext{Context: context.Background(), Request: req, Body: body}
+	require.NoError(t, step.Run(ctx))
+
+	rctx := &model.ResponseStepContext{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{},
+		Body:       []byte(`{"message":{"status":"ACK"}}`),
+	}
+	require.NoError(t, step.RunOnResponse(ctx, rctx))
+	require.JSONEq(t, `{"orderRef":"o-1","status":"accepted","acceptedOn":"2026-03-14"}`, string(rctx.Body))
+	require.Equal(t, "application/json", rctx.Header.Get("Content-Type"))
+
+	t.Run("replaced response is skipped", func(t *testing.T) {
+		nack := []byte(`{"message":{"status":"NACK"}}`)
+		rctx := &model.ResponseStepContext{Header: http.Header{}, Body: nack, Replaced: true}
+		require.NoError(t, step.RunOnResponse(ctx, rctx))
+		require.Equal(t, string(nack), string(rctx.Body))
+	})
+
+	t.Run("action without response mapping passes through", func(t *testing.T) {
+		search := []byte(`{"context":{"action":"confirm_unknown"}}`)
+		ack := []byte(`{"message":{"status":"ACK"}}`)
+		rctx := &model.ResponseStepContext{Header: http.Header{}, Body: ack}
+		require.NoError(t, step.RunOnResponse(&model.StepContext{Context: context.Background(), Body: search}, rctx))
+		require.Equal(t, string(ack), string(rctx.Body))
+	})
+
+	t.Run("publisher path is skipped", func(t *testing.T) {
+		require.NoError(t, step.RunOnResponse(ctx, nil))
+	})
+}
+
+func TestReqMapperStepRun_ActionFromPath(t *testing.T) {
+	step, _, err := New(context.Background(), &Config{
+		Role: "bpp",
+		MappingsFile: writeMappings(t, `
+mappings:
+  on_status:
+    bapMappings: '$'
+    bppMappings: '{"context": {"action": "on_status"}, "message": {"order": {"id": orderId}}}'
+`),
+	})
+	require.NoError(t, err)
+
+	body := []byte(`{"orderId":"o-1"}`)
+	req, err := http.NewRequest(http.MethodPost, "http://example.com/bpp/caller/on_status", bytes.NewReader(body))
+	require.NoError(t, err)
+	ctx := &model.StepContext{Context: context.Background(), Request: req, Body: body}
+	require.NoError(t, step.Run(ctx))
+	require.JSONEq(t, `{"context":{"action":"on_status"},"message":{"order":{"id":"o-1"}}}`, string(ctx.Body))
+
+	req, err = http.NewRequest(http.MethodPost, "http://example.com/bpp/caller/on_search", bytes.NewReader(body))
+	require.NoError(t, err)
+	err = step.Run(&model.StepContext{Context: context.Background(), Request: req, Body: body})
+	testutil.RequireBadReqCode(t, err, "SCH_REQUIRED_FIELD_MISSING")
+}
+
+func TestMappingEngineReloadMappings_KeepsMappingsOnFailure(t *testing.T) {
+	path := writeMappings(t, `
+mappings:
+  search:
+    bapMappings: '{"version": 1}'
+    bppMappings: '$'
+`)
+	engine, err := initMappingEngine(&Config{Role: "bap", MappingsFile: path})
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte(`
+mappings:
+  search:
+    bapMappings: '{"version": 2}'
+    bppMappings: '$'
+  select:
+    bapMappings: '{"version": '
+    bppMappings: '$'
+`), 0644))
+	require.Error(t, engine.ReloadMappings())
+
+	out, err := engine.Transform(context.Background(), "search", map[string]interface{}{}, "bap")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"version":1}`, string(out), "a failed reload must keep every previous mapping")
+	require.NotContains(t, engine.bapMaps, "select")
+}
+
+func TestNew_ReloadsChangedMappings(t *testing.T) {
+	path := writeMappings(t, `
+lookups:
+  tier: tier.yaml
+mappings:
+  search:
+    bapMappings: '{"tier": $lookup("tier", "gold")}'
+    bppMappings: '$'
+`, "tier.yaml", "gold: 1\n")
+	step, closer, err := New(context.Background(), &Config{Role: "bap", MappingsFile: path, ReloadInterval: 1})
+	require.NoError(t, err)
+	require.NotNil(t, closer)
+	defer closer()
+
+	transform := func() string {
+		out, err := step.engine.Transform(context.Background(), "search", map[string]interface{}{}, "bap")
+		require.NoError(t, err)
+		return string(out)
+	}
+	require.JSONEq(t, `{"tier":1}`, transform())
+
+	// Change the lookup table only; its size changes so the fingerprint does
+	// even on filesystems with coarse modification times.
+	require.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(path), "tier.yaml"), []byte("gold: 100\n"), 0644))
+	require.Eventually(t, func() bool { return transform() == `{"tier":100}` }, 5*time.Second, 100*time.Millisecond)
+}
@@ -0,0 +1,397 @@
+package schemav2validator
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/beckn-one/beckn-onix/pkg/beckndefaults"
+	"github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn/catalog-core/pkg/security/artifactverifier"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Schema bundle layout. A bundle is a gzipped tar archive:
+//
+//	bundle.json                         manifest (BundleManifest)
+//	core/<name>.yaml                    primary OpenAPI spec
+//	auxiliary/<name>.yaml               auxiliary OpenAPI specs, if any
+//	schemas/<Type>/<version>/attributes.yaml
+//
+// The archive is signed as a whole with a detached Ed25519 signature kept
+// next to it at <location>.sig.
+const (
+	bundleManifestName = "bundle.json"
+	bundleCoreDir      = "core"
+	bundleAuxDir       = "auxiliary"
+	bundleSchemasDir   = "schemas"
+	bundleSigSuffix    = ".sig"
+)
+
+// maxBundleUncompressedBytes caps the total size of a bundle's extracted
+// files, guarding against decompression bombs. The archive itself is capped
+// by maxSpecBodyBytes.
+var maxBundleUncompressedBytes int64 = 128 * 1024 * 1024 // 128 MB
+
+// verifyBundleSignature checks a bundle archive's detached signature against
+// the beckn public key embedded in beckndefaults. A package-level variable so
+// tests can sign bundles with their own key.
+var verifyBundleSignature = func(archive, signature []byte) error {
+	return artifactverifier.VerifyDetachedArtifact(archive, signature, beckndefaults.PublicKeyPEM())
+}
+
+// BundleManifest is the bundle.json entry of a schema bundle. Core and
+// Auxiliary are archive paths.
+type BundleManifest struct {
+	Version   string   `json:"version"`
+	Core      string   `json:"core"`
+	Auxiliary []string `json:"auxiliary,omitempty"`
+}
+
+// BundleSource lists the files BuildBundle packs: the core spec, auxiliary
+// specs and a directory of domain schemas laid out as
+// <Type>/<version>/attributes.yaml, e.g. the schema/ directory of a
+// protocol-specifications checkout. Files the specs reach through relative
+// $refs are packed as well.
+type BundleSource struct {
+	Version    string
+	Core       string
+	Auxiliary  []string
+	SchemasDir string
+}
+
+// BuildBundle writes a schema bundle built from src to w and returns the
+// number of domain schema files it holds. The archive is reproducible:
+// entries are sorted and carry no timestamps, so the same inputs always
+// produce the same bytes and the same signature.
+func BuildBundle(w io.Writer, src BundleSource) (int, error) {
+	if src.Core == "" {
+		return 0, errors.New("core spec is required")
+	}
+	files := map[string]string{} // archive path → source file
+	manifest := BundleManifest{Version: src.Version, Core: bundleCoreDir + "/" + filepath.Base(src.Core)}
+	if err := addBundleSpec(files, manifest.Core, src.Core); err != nil {
+		return 0, err
+	}
+	for _, aux := range src.Auxiliary {
+		name := bundleAuxDir + "/" + filepath.Base(aux)
+		if _, dup := files[name]; dup {
+			return 0, fmt.Errorf("auxiliary spec %s: another file is already bundled as %s", aux, name)
+		}
+		if err := addBundleSpec(files, name, aux); err != nil {
+			return 0, err
+		}
+		manifest.Auxiliary = append(manifest.Auxiliary, name)
+	}
+	schemas := 0
+	if src.SchemasDir != "" {
+		err := filepath.WalkDir(src.SchemasDir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || !strings.HasSuffix(p, ".yaml") {
+				return nil
+			}
+			rel, err := filepath.Rel(src.SchemasDir, p)
+			if err != nil {
+				return err
+			}
+			abs, err := filepath.Abs(p)
+			if err != nil {
+				return err
+			}
+			name := bundleSchemasDir + "/" + filepath.ToSlash(rel)
+			if prev, dup := files[name]; dup && prev != abs {
+				return fmt.Errorf("%s: %s, referenced by a spec, is already bundled as %s", p, prev, name)
+			}
+			files[name] = abs
+			schemas++
+			return nil
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to read schemas directory %s: %w", src.SchemasDir, err)
+		}
+	}
+
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	if err := writeBundleEntry(tw, bundleManifestName, manifestBytes); err != nil {
+		return 0, err
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		data, err := os.ReadFile(files[name])
+		if err != nil {
+			return 0, err
+		}
+		if err := writeBundleEntry(tw, name, data); err != nil {
+			return 0, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return 0, err
+	}
+	return schemas, gz.Close()
+}
+
+// addBundleSpec records the spec file at archive path name, along with every
+// local file it reaches through $refs. A referenced file keeps its path
+// relative to the spec, so the refs resolve the same way inside the bundle
+// as they did on disk. Refs to URLs, and refs that would land outside the
+// archive root, fail the build rather than the load.
+func addBundleSpec(files map[string]string, name, file string) error {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(abs)
+	if err != nil {
+		return err
+	}
+	files[name] = abs
+	specDir := filepath.Dir(abs)
+
+	loader := openapi3.NewLoader()
+	loader.IsExternalRefsAllowed = true
+	loader.ReadFromURIFunc = func(_ *openapi3.Loader, u *url.URL) ([]byte, error) {
+		if u.Scheme != "" || u.Host != "" {
+			return nil, fmt.Errorf("spec %s references %s; bundled specs may only reference local files", file, u)
+		}
+		ref := filepath.FromSlash(u.Path)
+		rel, err := filepath.Rel(specDir, ref)
+		if err != nil {
+			return nil, err
+		}
+		entry := path.Clean(path.Dir(name) + "/" + filepath.ToSlash(rel))
+		if entry == ".." || strings.HasPrefix(entry, "../") || entry == bundleManifestName {
+			return nil, fmt.Errorf("spec %s references %s, which cannot be placed inside the bundle", file, ref)
+		}
+		if prev, ok := files[entry]; ok && prev != ref {
+			return nil, fmt.Errorf("spec %s references %s, but %s is already bundled as %s", file, ref, prev, entry)
+		}
+		files[entry] = ref
+		return os.ReadFile(ref)
+	}
+	if _, err := loader.LoadFromDataWithPath(data, &url.URL{Path: filepath.ToSlash(abs)}); err != nil {
+		return fmt.Errorf("failed to load spec %s: %w", file, err)
+	}
+	return nil
+}
+
+func writeBundleEntry(tw *tar.Writer, name string, data []byte) error {
+	hdr := &tar.Header{
+		Name:     name,
+		Mode:     0o644,
+		Size:     int64(len(data)),
+		Typeflag: tar.TypeReg,
+		ModTime:  time.Unix(0, 0),
+		Format:   tar.FormatPAX,
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("failed to write bundle entry %s: %w", name, err)
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// schemaBundle is a verified, parsed schema bundle.
+type schemaBundle struct {
+	digest    string // SHA-256 of the archive; an unchanged bundle is not reparsed
+	manifest  BundleManifest
+	core      *cachedSpec
+	auxiliary []*cachedSpec
+	schemas   map[string][]byte // rawSchemaKey → attributes.yaml bytes
+}
+
+// loadBundle reads, verifies and parses the bundle at the configured
+// location. When the archive is byte-for-byte the one already loaded, that
+// bundle is returned as-is.
+func (v *schemav2Validator) loadBundle(ctx context.Context, current *schemaBundle) (*schemaBundle, error) {
+	archive, err := readBundleFile(v.config.Location)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(archive)
+	digest := hex.EncodeToString(sum[:])
+	if current != nil && current.digest == digest {
+		log.Debugf(ctx, "schemav2validator: bundle %s unchanged (digest %s)", v.config.Location, digest)
+		return current, nil
+	}
+
+	signature, err := readBundleFile(v.config.Location + bundleSigSuffix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle signature: %w", err)
+	}
+	if err := verifyBundleSignature(archive, signature); err != nil {
+		return nil, fmt.Errorf("bundle %s signature verification failed: %w", v.config.Location, err)
+	}
+
+	files, err := extractBundle(archive)
+	if err != nil {
+		return nil, fmt.Errorf("bundle %s: %w", v.config.Location, err)
+	}
+	b, err := v.parseBundle(ctx, files)
+	if err != nil {
+		return nil, fmt.Errorf("bundle %s: %w", v.config.Location, err)
+	}
+	b.digest = digest
+	log.Infof(ctx, "schemav2validator: loaded signed bundle %s version=%q digest=%s auxiliary=%d schemas=%d",
+		v.config.Location, b.manifest.Version, digest, len(b.auxiliary), len(b.schemas))
+	return b, nil
+}
+
+func readBundleFile(location string) ([]byte, error) {
+	f, err := os.Open(location)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxSpecBodyBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSpecBodyBytes {
+		return nil, fmt.Errorf("%s exceeds %d MB limit", location, maxSpecBodyBytes/(1024*1024))
+	}
+	return data, nil
+}
+
+// extractBundle unpacks a bundle archive into memory, keyed by cleaned
+// archive path. Only regular files are kept; paths that escape the archive
+// root are rejected.
+func extractBundle(archive []byte) (map[string][]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, fmt.Errorf("not a gzip archive: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	files := make(map[string][]byte)
+	var total int64
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid archive: %w", err)
+		}
+		if hdr.Typeflag == tar.TypeDir {
+			continue
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("entry %q is not a regular file", hdr.Name)
+		}
+		name := path.Clean(hdr.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return nil, fmt.Errorf("entry %q escapes the bundle root", hdr.Name)
+		}
+		total += hdr.Size
+		if total > maxBundleUncompressedBytes {
+			return nil, fmt.Errorf("extracted contents exceed %d MB limit", maxBundleUncompressedBytes/(1024*1024))
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, hdr.Size))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read entry %q: %w", hdr.Name, err)
+		}
+		files[name] = data
+	}
+	return files, nil
+}
+
+// parseBundle loads the specs and domain schemas of an extracted bundle.
+func (v *schemav2Validator) parseBundle(ctx context.Context, files map[string][]byte) (*schemaBundle, error) {
+	raw, ok := files[bundleManifestName]
+	if !ok {
+		return nil, fmt.Errorf("missing %s", bundleManifestName)
+	}
+	b := &schemaBundle{schemas: make(map[string][]byte)}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&b.manifest); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", bundleManifestName, err)
+	}
+	if b.manifest.Core == "" {
+		return nil, fmt.Errorf("%s names no core spec", bundleManifestName)
+	}
+
+	var err error
+	if b.core, err = v.loadBundleSpec(ctx, files, b.manifest.Core); err != nil {
+		return nil, err
+	}
+	for _, name := range b.manifest.Auxiliary {
+		spec, err := v.loadBundleSpec(ctx, files, name)
+		if err != nil {
+			return nil, err
+		}
+		b.auxiliary = append(b.auxiliary, spec)
+	}
+
+	prefix := bundleSchemasDir + "/"
+	for name, data := range files {
+		if rel, ok := strings.CutPrefix(name, prefix); ok && strings.HasSuffix(rel, ".yaml") {
+			b.schemas[rawSchemaKey(rel)] = data
+		}
+	}
+	return b, nil
+}
+
+// loadBundleSpec loads one OpenAPI document from the bundle. $refs resolve
+// against other bundle entries only; a bundle never reaches the network.
+func (v *schemav2Validator) loadBundleSpec(ctx context.Context, files map[string][]byte, name string) (*cachedSpec, error) {
+	data, ok := files[path.Clean(name)]
+	if !ok {
+		return nil, fmt.Errorf("spec %q listed in %s is missing from the archive", name, bundleManifestName)
+	}
+	loader := openapi3.NewLoader()
+	loader.Context = ctx
+	loader.IsExternalRefsAllowed = true
+	loader.ReadFromURIFunc = func(_ *openapi3.Loader, u *url.URL) ([]byte, error) {
+		if u.Scheme != "" || u.Host != "" {
+			return nil, fmt.Errorf("bundle spec %q references %s outside the bundle", name, u)
+		}
+		data, ok := files[path.Clean(strings.TrimPrefix(u.Path, "/"))]
+		if !ok {
+			return nil, fmt.Errorf("bundle spec %q references %s, which is not in the bundle", name, u.Path)
+		}
+		return data, nil
+	}
+	doc, err := loader.LoadFromDataWithPath(data, &url.URL{Path: name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load spec %q: %w", name, err)
+	}
+	if err := doc.Validate(ctx); err != nil {
+		log.Debugf(ctx, "Spec validation warnings (non-fatal) for bundle spec %s: %v", name, err)
+	}
+	actionSchemas, bodylessActions := v.buildActionIndex(ctx, doc)
+	return &cachedSpec{
+		doc:             doc,
+		actionSchemas:   actionSchemas,
+		bodylessActions: bodylessActions,
+		responses:       v.buildResponseIndex(doc),
+		loadedAt:        time.Now(),
+	}, nil
+}
@@ -0,0 +1,375 @@
+package schemav2validator
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+const testBundleAuxSpec = `openapi: 3.1.0
+info:
+  title: Aux API
+  version: 1.0.0
+paths:
+  /rate:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                context:
+                  type: object
+                  properties:
+                    action:
+                      const: rate
+`
+
+const testBundleOrderSchema = `openapi: 3.1.0
+info:
+  title: Order
+  version: 2.0.0
+components:
+  schemas:
+    Order:
+      type: object
+      required: [id]
+      properties:
+        id:
+          type: string
+`
+
+// signBundlesWithTestKey replaces the beckn key check for the duration of a
+// test with one against a fresh key, returning its signing function.
+func signBundlesWithTestKey(t *testing.T) func(archive []byte) []byte {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := verifyBundleSignature
+	verifyBundleSignature = func(archive, signature []byte) error {
+		sig, err := base64.StdEncoding.DecodeString(string(signature))
+		if err != nil || !ed25519.Verify(pub, archive, sig) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	}
+	t.Cleanup(func() { verifyBundleSignature = orig })
+	return func(archive []byte) []byte {
+		return []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, archive)))
+	}
+}
+
+// writeTestBundle builds a bundle from a core spec, optional auxiliary spec
+// and the Order domain schema, writes it to dir/name with its signature and
+// returns the bundle path.
+func writeTestBundle(t *testing.T, sign func([]byte) []byte, dir, name, core, aux string) string {
+	t.Helper()
+	src := t.TempDir()
+	write := func(rel, content string) string {
+		p := filepath.Join(src, rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	bs := BundleSource{
+		Version:    "test",
+		Core:       write("beckn.yaml", core),
+		SchemasDir: filepath.Join(src, "schema"),
+	}
+	if aux != "" {
+		bs.Auxiliary = []string{write("aux.yaml", aux)}
+	}
+	write("schema/Order/v2.0/attributes.yaml", testBundleOrderSchema)
+
+	var buf bytes.Buffer
+	if _, err := BuildBundle(&buf, bs); err != nil {
+		t.Fatalf("BuildBundle: %v", err)
+	}
+	location := filepath.Join(dir, name)
+	if err := os.WriteFile(location, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(location+bundleSigSuffix, sign(buf.Bytes()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return location
+}
+
+func TestBuildBundle_Reproducible(t *testing.T) {
+	dir := t.TempDir()
+	core := filepath.Join(dir, "beckn.yaml")
+	if err := os.WriteFile(core, []byte(testSpec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var a, b bytes.Buffer
+	if _, err := BuildBundle(&a, BundleSource{Core: core}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := BuildBundle(&b, BundleSource{Core: core}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(a.Bytes(), b.Bytes()) {
+		t.Error("BuildBundle produced different archives from the same inputs")
+	}
+}
+
+const testBundleRefSpec = `openapi: 3.1.0
+info:
+  title: Ref API
+  version: 1.0.0
+paths:
+  /search:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [context]
+              properties:
+                context:
+                  $ref: 'common/context.yaml#/Context'
+`
+
+func TestBuildBundle_PacksReferencedFiles(t *testing.T) {
+	sign := signBundlesWithTestKey(t)
+	src := t.TempDir()
+	for rel, content := range map[string]string{
+		"beckn.yaml": testBundleRefSpec,
+		"common/context.yaml": `Context:
+  type: object
+  required: [action]
+  properties:
+    action:
+      $ref: 'action.yaml#/Action'
+`,
+		"common/action.yaml": `Action:
+  const: search
+`,
+	} {
+		p := filepath.Join(src, rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var buf bytes.Buffer
+	if _, err := BuildBundle(&buf, BundleSource{Core: filepath.Join(src, "beckn.yaml")}); err != nil {
+		t.Fatalf("BuildBundle: %v", err)
+	}
+	files, err := extractBundle(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"core/beckn.yaml", "core/common/context.yaml", "core/common/action.yaml"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("bundle is missing %s", name)
+		}
+	}
+
+	location := filepath.Join(t.TempDir(), "schemas.tar.gz")
+	if err := os.WriteFile(location, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(location+bundleSigSuffix, sign(buf.Bytes()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	v, _, err := New(ctx, &Config{Type: "bundle", Location: location})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := v.Validate(ctx, nil, []byte(`{"context":{"action":"search"},"message":{}}`)); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+}
+
+func TestBuildBundle_RejectsRemoteRefs(t *testing.T) {
+	dir := t.TempDir()
+	core := filepath.Join(dir, "beckn.yaml")
+	spec := strings.Replace(testBundleRefSpec, "common/context.yaml", "https://example.com/context.yaml", 1)
+	if err := os.WriteFile(core, []byte(spec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := BuildBundle(&bytes.Buffer{}, BundleSource{Core: core})
+	if err == nil || !strings.Contains(err.Error(), "may only reference local files") {
+		t.Fatalf("BuildBundle error = %v, want remote ref rejected", err)
+	}
+}
+
+func TestBundle_LoadsCoreAndAuxiliarySpecs(t *testing.T) {
+	sign := signBundlesWithTestKey(t)
+	location := writeTestBundle(t, sign, t.TempDir(), "schemas.tar.gz", testSpec, testBundleAuxSpec)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	v, _, err := New(ctx, &Config{Type: "bundle", Location: location})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for _, action := range []string{"search", "rate"} {
+		if v.actionSchemas[action] == nil {
+			t.Errorf("action %q not indexed from bundle", action)
+		}
+	}
+	if err := v.Validate(ctx, nil, []byte(`{"context":{"action":"search"},"message":{}}`)); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+}
+
+func TestBundle_BadSignatureRejected(t *testing.T) {
+	sign := signBundlesWithTestKey(t)
+	location := writeTestBundle(t, sign, t.TempDir(), "schemas.tar.gz", testSpec, "")
+	if err := os.WriteFile(location+bundleSigSuffix, sign([]byte("another archive")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if _, _, err := New(ctx, &Config{Type: "bundle", Location: location}); err == nil {
+		t.Fatal("expected New to fail for a bundle with a bad signature")
+	}
+}
+
+func TestBundle_MissingSignatureRejected(t *testing.T) {
+	sign := signBundlesWithTestKey(t)
+	location := writeTestBundle(t, sign, t.TempDir(), "schemas.tar.gz", testSpec, "")
+	if err := os.Remove(location + bundleSigSuffix); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if _, _, err := New(ctx, &Config{Type: "bundle", Location: location}); err == nil {
+		t.Fatal("expected New to fail for a bundle without a signature")
+	}
+}
+
+func TestBundle_DomainSchemasServedOffline(t *testing.T) {
+	sign := signBundlesWithTestKey(t)
+	location := writeTestBundle(t, sign, t.TempDir(), "schemas.tar.gz", testSpec, "")
+
+	var fetches int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		w.Write([]byte(testBundleOrderSchema))
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	v, _, err := New(ctx, &Config{Type: "bundle", Location: location, EnableExtendedSchema: true})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	order := func(typ, id string) []byte {
+		return []byte(`{"context":{"action":"search"},"message":{"order":{"@context":"` + srv.URL + `/schema/` + typ + `/v2.0/context.jsonld","@type":"` + typ + `"` + id + `}}}`)
+	}
+	if err := v.Validate(ctx, nil, order("Order", `,"id":"o1"`)); err != nil {
+		t.Errorf("valid Order rejected: %v", err)
+	}
+	if err := v.Validate(ctx, nil, order("Order", "")); err == nil {
+		t.Error("Order without id should fail the bundled schema")
+	}
+	if err := v.Validate(ctx, nil, order("Fulfillment", "")); err == nil {
+		t.Error("type missing from the bundle should fail")
+	}
+	if n := atomic.LoadInt32(&fetches); n != 0 {
+		t.Errorf("validator fetched %d schemas over the network in bundle mode", n)
+	}
+}
+
+func TestBundle_ReloadSwapsAtomically(t *testing.T) {
+	sign := signBundlesWithTestKey(t)
+	dir := t.TempDir()
+	location := writeTestBundle(t, sign, dir, "schemas.tar.gz", testSpec, "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	v, _, err := New(ctx, &Config{Type: "bundle", Location: location, EnableExtendedSchema: true})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	firstCache, firstBundle := v.schemaCache, v.bundle
+
+	// An unchanged bundle keeps its parsed state and schema cache.
+	if err := v.loadAllSpecs(ctx, true); err != nil {
+		t.Fatalf("reload unchanged: %v", err)
+	}
+	if v.schemaCache != firstCache || v.bundle != firstBundle {
+		t.Error("unchanged bundle should not replace the schema cache")
+	}
+
+	// A new bundle replaces specs and schema cache together.
+	writeTestBundle(t, sign, dir, "schemas.tar.gz", testSpec, testBundleAuxSpec)
+	if err := v.loadAllSpecs(ctx, true); err != nil {
+		t.Fatalf("reload new bundle: %v", err)
+	}
+	if v.actionSchemas["rate"] == nil {
+		t.Error("new bundle's auxiliary action not indexed")
+	}
+	if v.schemaCache == firstCache || !v.schemaCache.offline {
+		t.Error("new bundle should swap in a fresh offline schema cache")
+	}
+
+	// A bundle failing verification leaves the last good one in place.
+	if err := os.WriteFile(location+bundleSigSuffix, []byte("bogus"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	writeTestBundle(t, func([]byte) []byte { return []byte("bogus") }, dir, "schemas.tar.gz", testSpecBodyless, "")
+	if err := v.loadAllSpecs(ctx, true); err == nil {
+		t.Fatal("expected reload of an unverified bundle to fail")
+	}
+	if v.actionSchemas["rate"] == nil {
+		t.Error("failed reload should retain the previous index")
+	}
+}
+
+func TestNew_BundleWithLocalSchemaPathRejected(t *testing.T) {
+	_, _, err := New(context.Background(), &Config{
+		Type:                 "bundle",
+		Location:             "schemas.tar.gz",
+		EnableExtendedSchema: true,
+		ExtendedSchemaConfig: ExtendedSchemaConfig{LocalSchemaPath: t.TempDir()},
+	})
+	if err == nil || !strings.Contains(err.Error(), "localSchemaPath") {
+		t.Fatalf("expected localSchemaPath conflict error, got %v", err)
+	}
+}
+
+func TestExtractBundle_RejectsEscapingPaths(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	if err := writeBundleEntry(tw, "../../etc/passwd", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	tw.Close()
+	gz.Close()
+	if _, err := extractBundle(buf.Bytes()); err == nil {
+		t.Fatal("expected an entry outside the bundle root to be rejected")
+	}
+}
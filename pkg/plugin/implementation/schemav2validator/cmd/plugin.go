@@ -76,6 +76,13 @@ func (vp schemav2ValidatorProvider) New(ctx context.Context, config map[string]s
 		}
 	}
 
+	if path, ok := config["contextIntegrity_manifestPath"]; ok {
+		cfg.ContextManifestPath = strings.TrimSpace(path)
+	}
+	if id, ok := config["contextIntegrity_subscriberId"]; ok {
+		cfg.ContextSubscriberID = strings.TrimSpace(id)
+	}
+
 	// NEW: Parse extendedSchema_enabled
 	if enableStr, ok := config["extendedSchema_enabled"]; ok {
 		cfg.EnableExtendedSchema = enableStr == "true"
@@ -0,0 +1,267 @@
+package schemav2validator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+)
+
+// Codes for @context/@type integrity failures.
+const (
+	codeContextNotDeclared       = "SCH_INVALID_JSONLD_CONTEXT"
+	codeTypeNotDeclared          = "SCH_INVALID_ENTITY_TYPE"
+	codeSchemaVersionUnsupported = "SCH_SCHEMA_VERSION_NOT_SUPPORTED"
+)
+
+// contextFileName is the last segment of a schema object's @context URL:
+// {baseUrl}/{version}/context.jsonld.
+const contextFileName = "context.jsonld"
+
+// loadContextManifest reads the schemaObjects that @context/@type pairs are
+// checked against from the node manifest file at path.
+func loadContextManifest(path string) ([]model.SchemaObject, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read context manifest: %w", err)
+	}
+	return parseContextManifest(content, path)
+}
+
+// fetchContextManifest reads the schemaObjects from the node manifest of
+// subscriberID through loader, which verifies the manifest's signature.
+func fetchContextManifest(ctx context.Context, loader definition.ManifestLoader, subscriberID string) ([]model.SchemaObject, error) {
+	doc, err := loader.GetBySubscriberID(ctx, subscriberID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch node manifest of %s: %w", subscriberID, err)
+	}
+	if doc == nil {
+		return nil, fmt.Errorf("no node manifest returned for %s", subscriberID)
+	}
+	return parseContextManifest(doc.Content, subscriberID)
+}
+
+// parseContextManifest extracts and checks the schemaObjects of a node
+// manifest; source names the manifest in errors.
+func parseContextManifest(content []byte, source string) ([]model.SchemaObject, error) {
+	manifest, err := model.ParseNodeManifest(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse context manifest %s: %w", source, err)
+	}
+	objects := manifest.Schema.SchemaObjects
+	if len(objects) == 0 {
+		return nil, fmt.Errorf("context manifest %s declares no schemaObjects", source)
+	}
+	for i, obj := range objects {
+		if strings.TrimSpace(obj.Type) == "" || strings.TrimSpace(obj.BaseURL) == "" || len(obj.SupportedVersions) == 0 {
+			return nil, fmt.Errorf("context manifest %s: schema object at index %d must have type, baseUrl and supportedVersions", source, i)
+		}
+		objects[i].BaseURL = strings.TrimSuffix(obj.BaseURL, "/")
+	}
+	return objects, nil
+}
+
+// SetManifestLoader receives the handler's ManifestLoader and, when
+// ContextSubscriberID is set, loads the schemaObjects from that node's
+// manifest through it. It implements definition.ManifestAwareSchemaValidator.
+func (v *schemav2Validator) SetManifestLoader(loader definition.ManifestLoader) error {
+	id := v.config.ContextSubscriberID
+	if id == "" {
+		return nil
+	}
+	if loader == nil {
+		return fmt.Errorf("contextIntegrity_subscriberId requires the ManifestLoader plugin to be configured")
+	}
+	ctx := context.Background()
+	objects, err := fetchContextManifest(ctx, loader, id)
+	if err != nil {
+		return fmt.Errorf("failed to load context manifest: %w", err)
+	}
+	v.specMutex.Lock()
+	v.manifestLoader = loader
+	v.contextObjects = objects
+	v.specMutex.Unlock()
+	log.Infof(ctx, "Loaded %d schema objects for @context/@type integrity checks from the node manifest of %s", len(objects), id)
+	return nil
+}
+
+var _ definition.ManifestAwareSchemaValidator = (*schemav2Validator)(nil)
+
+// reloadContextManifest re-reads the context manifest from its configured
+// source, keeping the previous schemaObjects if it can no longer be loaded.
+func (v *schemav2Validator) reloadContextManifest(ctx context.Context) {
+	var objects []model.SchemaObject
+	var err error
+	if id := v.config.ContextSubscriberID; id != "" {
+		v.specMutex.RLock()
+		loader := v.manifestLoader
+		v.specMutex.RUnlock()
+		if loader == nil {
+			return // not yet handed a ManifestLoader; SetManifestLoader loads it
+		}
+		objects, err = fetchContextManifest(ctx, loader, id)
+	} else {
+		objects, err = loadContextManifest(v.config.ContextManifestPath)
+	}
+	if err != nil {
+		log.Errorf(ctx, err, "Failed to reload context manifest — keeping previously loaded schemaObjects")
+		return
+	}
+	v.specMutex.Lock()
+	v.contextObjects = objects
+	v.specMutex.Unlock()
+}
+
+// validateContextIntegrity cross-checks every object with an @context under
+// message against the manifest's schemaObjects: the @context must be a single
+// URL under a declared baseUrl, the object must have an @type declared for
+// that baseUrl, and the version in the @context must be one of that type's
+// supportedVersions. All failing objects are reported together.
+func validateContextIntegrity(body interface{}, objects []model.SchemaObject) error {
+	bodyMap, ok := body.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("body is not a valid JSON object")
+	}
+	message, ok := bodyMap["message"]
+	if !ok {
+		return nil
+	}
+
+	if failures := collectIntegrityFailures(message, []string{"message"}, objects); len(failures) > 0 {
+		return model.ShapeSchemaErrors(failures)
+	}
+	return nil
+}
+
+// collectIntegrityFailures checks every object under data that carries an
+// @context. Unlike findReferencedObjects, it does not skip objects whose
+// @context is an array or object, or that have no string @type: such an
+// object would otherwise escape the check altogether.
+func collectIntegrityFailures(data interface{}, location []string, objects []model.SchemaObject) []model.SchemaFailure {
+	var failures []model.SchemaFailure
+	switch v := data.(type) {
+	case map[string]interface{}:
+		if contextVal, hasContext := v["@context"]; hasContext {
+			if f := checkContextMember(v, contextVal, location, objects); f != nil {
+				failures = append(failures, *f)
+			}
+		}
+		for key, val := range v {
+			failures = append(failures, collectIntegrityFailures(val, appendToken(location, key), objects)...)
+		}
+	case []interface{}:
+		for i, item := range v {
+			failures = append(failures, collectIntegrityFailures(item, appendToken(location, strconv.Itoa(i)), objects)...)
+		}
+	}
+	return failures
+}
+
+// checkContextMember checks the shape of an object's @context and @type
+// before checking the pair against the manifest.
+func checkContextMember(v map[string]interface{}, contextVal interface{}, location []string, objects []model.SchemaObject) *model.SchemaFailure {
+	contextURL, ok := contextVal.(string)
+	if !ok {
+		return &model.SchemaFailure{
+			Location: appendToken(location, "@context"),
+			Code:     codeContextNotDeclared,
+			Message:  fmt.Sprintf("@context must be a single context URL, got %s", jsonKind(contextVal)),
+		}
+	}
+	typeName, ok := v["@type"].(string)
+	if !ok || typeName == "" {
+		return &model.SchemaFailure{
+			Location: appendToken(location, "@type"),
+			Code:     codeTypeNotDeclared,
+			Message:  fmt.Sprintf("object with @context %q must have a string @type", contextURL),
+		}
+	}
+	return checkContextIntegrity(referencedObject{Location: location, Context: contextURL, Type: typeName, Data: v}, objects)
+}
+
+// jsonKind names the JSON type of a decoded value for error messages.
+func jsonKind(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	case float64:
+		return "a number"
+	case bool:
+		return "a boolean"
+	default:
+		return "null"
+	}
+}
+
+// checkContextIntegrity returns the failure for one referenced object, or
+// nil if its @context and @type agree with the manifest.
+func checkContextIntegrity(obj referencedObject, objects []model.SchemaObject) *model.SchemaFailure {
+	fail := func(member, code, format string, args ...any) *model.SchemaFailure {
+		return &model.SchemaFailure{
+			Location: appendToken(obj.Location, member),
+			Code:     code,
+			Message:  fmt.Sprintf(format, args...),
+		}
+	}
+
+	// Schema objects whose baseUrl the @context sits under.
+	var declared []model.SchemaObject
+	for _, so := range objects {
+		if strings.HasPrefix(obj.Context, so.BaseURL+"/") {
+			declared = append(declared, so)
+		}
+	}
+	if len(declared) == 0 {
+		return fail("@context", codeContextNotDeclared,
+			"@context %q is not under the baseUrl of any schema object in the manifest", obj.Context)
+	}
+
+	var match *model.SchemaObject
+	var types []string
+	for i := range declared {
+		if sameType(declared[i].Type, obj.Type) {
+			match = &declared[i]
+			break
+		}
+		types = append(types, declared[i].Type)
+	}
+	if match == nil {
+		return fail("@type", codeTypeNotDeclared,
+			"@type %q is not declared for @context %q; expected one of: %s", obj.Type, obj.Context, strings.Join(types, ", "))
+	}
+
+	version, file, ok := strings.Cut(strings.TrimPrefix(obj.Context, match.BaseURL+"/"), "/")
+	if !ok || version == "" || file != contextFileName {
+		return fail("@context", codeContextNotDeclared,
+			"@context %q does not follow %s/{version}/%s", obj.Context, match.BaseURL, contextFileName)
+	}
+	if !slices.Contains(match.SupportedVersions, version) {
+		return fail("@context", codeSchemaVersionUnsupported,
+			"schema version %q of %s is not supported; supported versions: %s", version, match.Type, strings.Join(match.SupportedVersions, ", "))
+	}
+	return nil
+}
+
+// sameType reports whether the @type of an object names the declared type.
+// A term without a prefix is resolved by the object's own @context, which is
+// already known to be the declared one, so "Order" names "beckn:Order". A
+// compact IRI must match in full: "foo:Order" expands through another prefix
+// and names a different type.
+func sameType(declared, got string) bool {
+	if declared == got {
+		return true
+	}
+	if strings.Contains(got, ":") {
+		return false
+	}
+	_, local, _ := strings.Cut(declared, ":")
+	return local == got
+}
@@ -0,0 +1,317 @@
+package schemav2validator
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+)
+
+const testContextManifest = `manifestVersion: "1.0"
+manifestType: node
+subscriberId: nfh.global/subscribers.beckn.one/bpp.example.com
+schema:
+  schemaObjects:
+    - type: beckn:RetailOffer
+      baseUrl: https://schema.example.org/schema/RetailOffer
+      supportedVersions: ["v2.0", "v2.1"]
+    - type: beckn:RetailItem
+      baseUrl: https://schema.example.org/schema/RetailItem/
+      supportedVersions: ["v2.1"]
+`
+
+func writeContextManifest(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "node-manifest.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+	return path
+}
+
+func TestLoadContextManifest(t *testing.T) {
+	objects, err := loadContextManifest(writeContextManifest(t, testContextManifest))
+	if err != nil {
+		t.Fatalf("loadContextManifest() error = %v", err)
+	}
+	if len(objects) != 2 {
+		t.Fatalf("got %d schema objects, want 2", len(objects))
+	}
+	if objects[1].BaseURL != "https://schema.example.org/schema/RetailItem" {
+		t.Errorf("BaseURL = %q, want the trailing slash trimmed", objects[1].BaseURL)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"no schema objects", "manifestType: node\nschema: {}\n", "declares no schemaObjects"},
+		{"missing baseUrl", "schema:\n  schemaObjects:\n    - type: A\n      supportedVersions: [v1.0]\n", "must have type, baseUrl and supportedVersions"},
+		{"invalid yaml", "schema: [", "failed to parse context manifest"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadContextManifest(writeContextManifest(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("loadContextManifest() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateContextIntegrity(t *testing.T) {
+	objects, err := loadContextManifest(writeContextManifest(t, testContextManifest))
+	if err != nil {
+		t.Fatalf("loadContextManifest() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		context  interface{}
+		typ      interface{}
+		wantCode string
+		wantPath string
+	}{
+		{
+			name:    "declared pair",
+			context: "https://schema.example.org/schema/RetailOffer/v2.1/context.jsonld",
+			typ:     "beckn:RetailOffer",
+		},
+		{
+			name:    "type without prefix",
+			context: "https://schema.example.org/schema/RetailOffer/v2.0/context.jsonld",
+			typ:     "RetailOffer",
+		},
+		{
+			name:     "context not declared",
+			context:  "https://evil.example.com/schema/RetailOffer/v2.1/context.jsonld",
+			typ:      "beckn:RetailOffer",
+			wantCode: "SCH_INVALID_JSONLD_CONTEXT",
+			wantPath: "/message/offer/@context",
+		},
+		{
+			name:     "type not declared for context",
+			context:  "https://schema.example.org/schema/RetailOffer/v2.1/context.jsonld",
+			typ:      "beckn:RetailItem",
+			wantCode: "SCH_INVALID_ENTITY_TYPE",
+			wantPath: "/message/offer/@type",
+		},
+		{
+			name:     "version not supported",
+			context:  "https://schema.example.org/schema/RetailItem/v2.0/context.jsonld",
+			typ:      "beckn:RetailItem",
+			wantCode: "SCH_SCHEMA_VERSION_NOT_SUPPORTED",
+			wantPath: "/message/offer/@context",
+		},
+		{
+			name:     "context not in wire format",
+			context:  "https://schema.example.org/schema/RetailOffer/v2.1/attributes.yaml",
+			typ:      "beckn:RetailOffer",
+			wantCode: "SCH_INVALID_JSONLD_CONTEXT",
+			wantPath: "/message/offer/@context",
+		},
+		{
+			name:     "type under another prefix",
+			context:  "https://schema.example.org/schema/RetailOffer/v2.1/context.jsonld",
+			typ:      "foo:RetailOffer",
+			wantCode: "SCH_INVALID_ENTITY_TYPE",
+			wantPath: "/message/offer/@type",
+		},
+		{
+			name:     "context without type",
+			context:  "https://schema.example.org/schema/RetailOffer/v2.1/context.jsonld",
+			wantCode: "SCH_INVALID_ENTITY_TYPE",
+			wantPath: "/message/offer/@type",
+		},
+		{
+			name:     "type is not a string",
+			context:  "https://schema.example.org/schema/RetailOffer/v2.1/context.jsonld",
+			typ:      []interface{}{"beckn:RetailOffer"},
+			wantCode: "SCH_INVALID_ENTITY_TYPE",
+			wantPath: "/message/offer/@type",
+		},
+		{
+			name:     "context array",
+			context:  []interface{}{"https://evil.example.com/context.jsonld"},
+			typ:      "beckn:RetailOffer",
+			wantCode: "SCH_INVALID_JSONLD_CONTEXT",
+			wantPath: "/message/offer/@context",
+		},
+		{
+			name:     "inline context object",
+			context:  map[string]interface{}{"beckn": "https://evil.example.com/"},
+			typ:      "beckn:RetailOffer",
+			wantCode: "SCH_INVALID_JSONLD_CONTEXT",
+			wantPath: "/message/offer/@context",
+		},
+		{
+			name:     "base URL is not a path prefix",
+			context:  "https://schema.example.org/schema/RetailOfferV2/v2.1/context.jsonld",
+			typ:      "beckn:RetailOffer",
+			wantCode: "SCH_INVALID_JSONLD_CONTEXT",
+			wantPath: "/message/offer/@context",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offer := map[string]interface{}{"@context": tt.context}
+			if tt.typ != nil {
+				offer["@type"] = tt.typ
+			}
+			body := map[string]interface{}{"message": map[string]interface{}{"offer": offer}}
+			err := validateContextIntegrity(body, objects)
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("validateContextIntegrity() = %v, want nil", err)
+				}
+				return
+			}
+			var schemaErr *model.SchemaValidationErr
+			if !errors.As(err, &schemaErr) || len(schemaErr.Errors) != 1 {
+				t.Fatalf("validateContextIntegrity() = %v, want one schema error", err)
+			}
+			got := schemaErr.Errors[0]
+			if got.Code != tt.wantCode {
+				t.Errorf("Code = %s, want %s", got.Code, tt.wantCode)
+			}
+			if got.Details == nil || got.Details.Path != tt.wantPath {
+				t.Errorf("Details = %+v, want Path=%s", got.Details, tt.wantPath)
+			}
+		})
+	}
+}
+
+// TestValidate_ContextIntegrity confirms Validate reports every failing
+// object, each at its own pointer.
+func TestValidate_ContextIntegrity(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testSpec))
+	}))
+	defer server.Close()
+
+	validator, _, err := New(context.Background(), &Config{
+		Type:                "url",
+		Location:            server.URL,
+		CacheTTL:            3600,
+		ContextManifestPath: writeContextManifest(t, testContextManifest),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+
+	valid := []byte(`{"context":{"action":"search"},"message":{"offers":[
+		{"@context":"https://schema.example.org/schema/RetailOffer/v2.1/context.jsonld","@type":"beckn:RetailOffer"}
+	]}}`)
+	if err := validator.Validate(context.Background(), nil, valid); err != nil {
+		t.Fatalf("Validate() = %v, want nil", err)
+	}
+
+	invalid := []byte(`{"context":{"action":"search"},"message":{"offers":[
+		{"@context":"https://schema.example.org/schema/RetailOffer/v1.0/context.jsonld","@type":"beckn:RetailOffer"},
+		{"@context":"https://schema.example.org/schema/RetailOffer/v2.1/context.jsonld","@type":"beckn:Unknown"}
+	]}}`)
+	err = validator.Validate(context.Background(), nil, invalid)
+	var schemaErr *model.SchemaValidationErr
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("expected *model.SchemaValidationErr, got %T: %v", err, err)
+	}
+	var paths []string
+	for _, e := range schemaErr.Errors {
+		paths = append(paths, e.Details.Path)
+	}
+	want := "/message/offers/0/@context,/message/offers/1/@type"
+	if strings.Join(paths, ",") != want {
+		t.Errorf("paths = %v, want %s", paths, want)
+	}
+}
+
+func TestNew_ContextManifestMissing(t *testing.T) {
+	_, _, err := New(context.Background(), &Config{ContextManifestPath: filepath.Join(t.TempDir(), "missing.yaml")})
+	if err == nil || !strings.Contains(err.Error(), "failed to load context manifest") {
+		t.Errorf("New() error = %v, want failed to load context manifest", err)
+	}
+}
+
+// stubManifestLoader serves node manifests by subscriber ID.
+type stubManifestLoader struct {
+	manifests map[string]string
+}
+
+func (l *stubManifestLoader) GetByNetworkID(context.Context, string) (*model.ManifestDocument, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (l *stubManifestLoader) GetByMetadata(context.Context, model.ManifestMetadata) (*model.ManifestDocument, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (l *stubManifestLoader) GetBySubscriberID(_ context.Context, subscriberID string) (*model.ManifestDocument, error) {
+	content, ok := l.manifests[subscriberID]
+	if !ok {
+		return nil, errors.New("manifest not found")
+	}
+	return &model.ManifestDocument{Content: []byte(content)}, nil
+}
+
+func TestValidate_ContextManifestFromLoader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testSpec))
+	}))
+	defer server.Close()
+
+	const subscriberID = "nfh.global/subscribers.beckn.one/bpp.example.com"
+	newValidator := func(t *testing.T) *schemav2Validator {
+		t.Helper()
+		validator, _, err := New(context.Background(), &Config{
+			Type:                "url",
+			Location:            server.URL,
+			CacheTTL:            3600,
+			ContextSubscriberID: subscriberID,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create validator: %v", err)
+		}
+		return validator
+	}
+	body := []byte(`{"context":{"action":"search"},"message":{"offer":
+		{"@context":"https://schema.example.org/schema/RetailOffer/v1.0/context.jsonld","@type":"beckn:RetailOffer"}
+	}}`)
+
+	validator := newValidator(t)
+	if err := validator.Validate(context.Background(), nil, body); err == nil || !strings.Contains(err.Error(), "never supplied") {
+		t.Fatalf("Validate() before SetManifestLoader = %v, want an error", err)
+	}
+	if err := validator.SetManifestLoader(nil); err == nil {
+		t.Fatal("SetManifestLoader(nil) error = nil, want an error")
+	}
+	if err := validator.SetManifestLoader(&stubManifestLoader{}); err == nil || !strings.Contains(err.Error(), "failed to load context manifest") {
+		t.Fatalf("SetManifestLoader() error = %v, want failed to load context manifest", err)
+	}
+
+	loader := &stubManifestLoader{manifests: map[string]string{subscriberID: testContextManifest}}
+	if err := validator.SetManifestLoader(loader); err != nil {
+		t.Fatalf("SetManifestLoader() error = %v", err)
+	}
+	err := validator.Validate(context.Background(), nil, body)
+	var schemaErr *model.SchemaValidationErr
+	if !errors.As(err, &schemaErr) || schemaErr.Errors[0].Code != "SCH_SCHEMA_VERSION_NOT_SUPPORTED" {
+		t.Fatalf("Validate() = %v, want SCH_SCHEMA_VERSION_NOT_SUPPORTED", err)
+	}
+}
+
+func TestNew_ContextManifestSources(t *testing.T) {
+	_, _, err := New(context.Background(), &Config{
+		ContextManifestPath: writeContextManifest(t, testContextManifest),
+		ContextSubscriberID: "nfh.global/subscribers.beckn.one/bpp.example.com",
+	})
+	if err == nil || !strings.Contains(err.Error(), "cannot both be set") {
+		t.Errorf("New() error = %v, want cannot both be set", err)
+	}
+}
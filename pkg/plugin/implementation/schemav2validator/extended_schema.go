@@ -9,6 +9,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -75,10 +76,12 @@ type ExtendedSchemaConfig struct {
 
 // referencedObject represents a domain-specific object with @context.
 type referencedObject struct {
-	Path    string
-	Context string
-	Type    string
-	Data    map[string]interface{}
+	Path string
+	// Location is Path as RFC 6901 reference tokens, for error pointers.
+	Location []string
+	Context  string
+	Type     string
+	Data     map[string]interface{}
 }
 
 // schemaCache caches loaded domain schemas with LRU eviction.
@@ -87,6 +90,9 @@ type schemaCache struct {
 	schemas    map[string]*cachedDomainSchema
 	rawSchemas map[string][]byte // preloaded raw YAML bytes
 	maxSize    int
+	// offline restricts lookups to rawSchemas: a schema or $ref not held
+	// there fails instead of being fetched. Set for bundle-loaded caches.
+	offline bool
 }
 
 // cachedDomainSchema holds a cached domain schema with metadata.
@@ -144,6 +150,15 @@ func preloadSchemasToCache(ctx context.Context, c *schemaCache, baseDir string)
 
 // validateExtendedSchemas validates all objects with @context against their schemas.
 func (v *schemav2Validator) validateExtendedSchemas(ctx context.Context, body interface{}) error {
+	v.specMutex.RLock()
+	c := v.schemaCache
+	v.specMutex.RUnlock()
+	return v.validateExtendedSchemasWith(ctx, c, body)
+}
+
+// validateExtendedSchemasWith validates all objects with @context against
+// the schemas held by or fetched through c.
+func (v *schemav2Validator) validateExtendedSchemasWith(ctx context.Context, c *schemaCache, body interface{}) error {
 	// Extract "message" object - scan inside message
 	bodyMap, ok := body.(map[string]interface{})
 	if !ok {
@@ -182,43 +197,25 @@ func (v *schemav2Validator) validateExtendedSchemas(ctx context.Context, body in
 	log.Debugf(ctx, "Extended Schema config: ttl=%v, timeout=%v, allowedDomains=%v, localSchemaPath=%v",
 		ttl, timeout, allowedDomains, refConfig.LocalSchemaPath)
 
-	localSchema := refConfig.LocalSchemaPath != ""
+	localSchema := refConfig.LocalSchemaPath != "" || c.offline
 
-	// Validate each object
+	// Validate every object and report their failures together.
+	var failures []model.SchemaFailure
 	for _, obj := range objects {
 		log.Debugf(ctx, "Validating object at path: %s, @context: %s, @type: %s",
 			obj.Path, obj.Context, obj.Type)
 
-		if err := v.schemaCache.validateReferencedObject(ctx, obj, ttl, timeout, allowedDomains, localSchema); err != nil {
-			var schemaErrors []model.Error
-			v.extractSchemaErrors(err, &schemaErrors)
-
-			prefixSchemaErrorPaths(schemaErrors, obj.Path)
-
-			return &model.SchemaValidationErr{Errors: schemaErrors}
+		if err := c.validateReferencedObject(ctx, obj, ttl, timeout, allowedDomains, localSchema); err != nil {
+			failures = append(failures, schemaFailures(err, obj.Location)...)
 		}
 	}
+	if len(failures) > 0 {
+		return model.ShapeSchemaErrors(failures)
+	}
 
 	return nil
 }
 
-// prefixSchemaErrorPaths prefixes objPath onto each error's Details.Path,
-// updating it in place when Details already exists so any other field (e.g.
-// a chained Cause) set upstream is preserved rather than discarded.
-func prefixSchemaErrorPaths(schemaErrors []model.Error, objPath string) {
-	for i := range schemaErrors {
-		prefixed := objPath
-		if schemaErrors[i].Details != nil && schemaErrors[i].Details.Path != "" {
-			prefixed = objPath + "." + schemaErrors[i].Details.Path
-		}
-		if schemaErrors[i].Details != nil {
-			schemaErrors[i].Details.Path = prefixed
-		} else {
-			schemaErrors[i].Details = &model.ErrorDetails{Path: prefixed}
-		}
-	}
-}
-
 // newSchemaCache creates a new schema cache.
 func newSchemaCache(maxSize int) *schemaCache {
 	return &schemaCache{
@@ -350,6 +347,9 @@ func (c *schemaCache) loadSchemaFromPath(ctx context.Context, schemaPath string,
 					log.Debugf(ctx, "$ref from memory: %s -> %s", refURL.String(), refRelPath)
 					return data, nil
 				}
+				if c.offline {
+					return nil, fmt.Errorf("$ref %s is not in the schema bundle", refURL.String())
+				}
 				log.Debugf(ctx, "$ref not in memory, fetching from network: %s", refURL.String())
 				return freshReadFromURI(l, refURL)
 			}
@@ -375,6 +375,9 @@ func (c *schemaCache) loadSchemaFromPath(ctx context.Context, schemaPath string,
 	}
 
 	// Step 3: Network — fetch from @context URL.
+	if c.offline {
+		return nil, fmt.Errorf("schema %s is not in the schema bundle and network fetches are disabled", schemaPath)
+	}
 	log.Debugf(ctx, "Schema not in memory or cache, fetching from network: %s", schemaPath)
 	if !isValidSchemaPath(schemaPath) {
 		return nil, fmt.Errorf("invalid schema path: %s", schemaPath)
@@ -409,7 +412,17 @@ func (c *schemaCache) loadSchemaFromPath(ctx context.Context, schemaPath string,
 }
 
 // findReferencedObjects recursively finds domain-specific objects with @context.
+// path names data's own position and is the first token of each object's
+// Location.
 func findReferencedObjects(data interface{}, path string) []referencedObject {
+	var location []string
+	if path != "" {
+		location = []string{path}
+	}
+	return collectReferencedObjects(data, path, location)
+}
+
+func collectReferencedObjects(data interface{}, path string, location []string) []referencedObject {
 	var results []referencedObject
 
 	switch v := data.(type) {
@@ -418,10 +431,11 @@ func findReferencedObjects(data interface{}, path string) []referencedObject {
 		if contextVal, hasContext := v["@context"].(string); hasContext {
 			if typeVal, hasType := v["@type"].(string); hasType {
 				results = append(results, referencedObject{
-					Path:    path,
-					Context: contextVal,
-					Type:    typeVal,
-					Data:    v,
+					Path:     path,
+					Location: location,
+					Context:  contextVal,
+					Type:     typeVal,
+					Data:     v,
 				})
 			}
 		}
@@ -432,20 +446,26 @@ func findReferencedObjects(data interface{}, path string) []referencedObject {
 			if path != "" {
 				newPath = path + "." + key
 			}
-			results = append(results, findReferencedObjects(val, newPath)...)
+			results = append(results, collectReferencedObjects(val, newPath, appendToken(location, key))...)
 		}
 
 	case []interface{}:
 		// Recurse into arrays
 		for i, item := range v {
 			newPath := fmt.Sprintf("%s[%d]", path, i)
-			results = append(results, findReferencedObjects(item, newPath)...)
+			results = append(results, collectReferencedObjects(item, newPath, appendToken(location, strconv.Itoa(i)))...)
 		}
 	}
 
 	return results
 }
 
+// appendToken returns location extended by tok without sharing location's
+// backing array, since sibling objects extend the same parent location.
+func appendToken(location []string, tok string) []string {
+	return append(location[:len(location):len(location)], tok)
+}
+
 // transformContextToSchemaURL transforms @context URL to schema URL.
 func transformContextToSchemaURL(contextURL string) string {
 	// transformation: context.jsonld -> attributes.yaml
@@ -564,6 +584,7 @@ func (c *schemaCache) validateReferencedObject(
 	opts := []openapi3.SchemaValidationOption{
 		openapi3.VisitAsRequest(),
 		openapi3.EnableFormatValidation(),
+		openapi3.MultiErrors(),
 	}
 	if err := schema.Value.VisitJSON(domainData, opts...); err != nil {
 		log.Debugf(ctx, "Validation failed for @type: %s at path: %s: %v", obj.Type, obj.Path, err)
@@ -8,7 +8,6 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
-	"reflect"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -663,8 +662,7 @@ components:
 	err = cache.validateReferencedObject(ctx, obj, 1*time.Hour, 30*time.Second, nil, false)
 	assert.Error(t, err)
 
-	schemaErrors := []model.Error{}
-	(&schemav2Validator{}).extractSchemaErrors(err, &schemaErrors)
+	schemaErrors := model.ShapeSchemaErrors(schemaFailures(err, nil)).Errors
 	if len(schemaErrors) == 0 || schemaErrors[0].Code != "SCH_REQUIRED_FIELD_MISSING" {
 		t.Errorf("Code = %+v, want SCH_REQUIRED_FIELD_MISSING", schemaErrors)
 	}
@@ -906,10 +904,10 @@ func TestValidateExtendedSchemas_MissingMessage(t *testing.T) {
 
 // TestValidateExtendedSchemas_DomainNotAllowed_PropagatesCode drives a real
 // domain-object validation failure through the full production path
-// (validateExtendedSchemas -> validateReferencedObject -> extractSchemaErrors'
-// *model.Error passthrough branch -> prefixSchemaErrorPaths), confirming the
+// (validateExtendedSchemas -> validateReferencedObject -> schemaFailures'
+// *model.Error passthrough branch -> model.ShapeSchemaErrors), confirming the
 // classified code and path both survive end-to-end — not just at the unit
-// level of validateReferencedObject or extractSchemaErrors individually.
+// level of validateReferencedObject or schemaFailures individually.
 func TestValidateExtendedSchemas_DomainNotAllowed_PropagatesCode(t *testing.T) {
 	v := &schemav2Validator{
 		config: &Config{
@@ -943,89 +941,71 @@ func TestValidateExtendedSchemas_DomainNotAllowed_PropagatesCode(t *testing.T) {
 	if len(schemaErr.Errors) != 1 || schemaErr.Errors[0].Code != "SCH_INVALID_JSONLD_CONTEXT" {
 		t.Errorf("Errors = %+v, want one entry with Code=SCH_INVALID_JSONLD_CONTEXT", schemaErr.Errors)
 	}
-	if schemaErr.Errors[0].Details == nil || schemaErr.Errors[0].Details.Path == "" {
-		t.Errorf("Details = %+v, want a non-empty Path from prefixSchemaErrorPaths", schemaErr.Errors[0].Details)
+	if schemaErr.Errors[0].Details == nil || schemaErr.Errors[0].Details.Path != "/message/order" {
+		t.Errorf("Details = %+v, want Path=/message/order", schemaErr.Errors[0].Details)
 	}
 }
 
-func TestPrefixSchemaErrorPaths(t *testing.T) {
-	tests := []struct {
-		name         string
-		schemaErrors []model.Error
-		objPath      string
-		want         []model.Error
-	}{
-		{
-			name:         "no existing details gets object path",
-			schemaErrors: []model.Error{{Message: "m1"}},
-			objPath:      "message.order",
-			want: []model.Error{
-				{Message: "m1", Details: &model.ErrorDetails{Path: "message.order"}},
-			},
-		},
-		{
-			name: "existing path is prefixed with object path",
-			schemaErrors: []model.Error{
-				{Message: "m1", Details: &model.ErrorDetails{Path: "items[0].id"}},
-			},
-			objPath: "message.order",
-			want: []model.Error{
-				{Message: "m1", Details: &model.ErrorDetails{Path: "message.order.items[0].id"}},
-			},
-		},
-		{
-			name: "existing cause is preserved after prefixing",
-			schemaErrors: []model.Error{
-				{
-					Message: "m1",
-					Details: &model.ErrorDetails{
-						Path:  "items[0].id",
-						Cause: &model.Error{Code: "NET_DOWNSTREAM_UNAVAILABLE", Message: "registry lookup failed"},
+func TestFindReferencedObjects_Location(t *testing.T) {
+	data := map[string]interface{}{
+		"order": map[string]interface{}{
+			"beckn:items": []interface{}{
+				map[string]interface{}{},
+				map[string]interface{}{
+					"a/b": map[string]interface{}{
+						"@context": "https://example.com/schema/Item/v1/context.jsonld",
+						"@type":    "Item",
 					},
 				},
 			},
-			objPath: "message.order",
-			want: []model.Error{
-				{
-					Message: "m1",
-					Details: &model.ErrorDetails{
-						Path:  "message.order.items[0].id",
-						Cause: &model.Error{Code: "NET_DOWNSTREAM_UNAVAILABLE", Message: "registry lookup failed"},
-					},
-				},
+		},
+	}
+
+	objects := findReferencedObjects(data, "message")
+	if len(objects) != 1 {
+		t.Fatalf("found %d objects, want 1", len(objects))
+	}
+	if got := model.JSONPointer(objects[0].Location); got != "/message/order/beckn:items/1/a~1b" {
+		t.Errorf("Location pointer = %q, want /message/order/beckn:items/1/a~1b", got)
+	}
+}
+
+// TestValidateExtendedSchemas_AggregatesObjects confirms failures from every
+// domain object are reported together, each at its own pointer.
+func TestValidateExtendedSchemas_AggregatesObjects(t *testing.T) {
+	v := &schemav2Validator{
+		config: &Config{
+			EnableExtendedSchema: true,
+			ExtendedSchemaConfig: ExtendedSchemaConfig{
+				AllowedDomains: []string{"trusted.com"},
 			},
 		},
-		{
-			name: "existing details with no path yet gets object path, cause still preserved",
-			schemaErrors: []model.Error{
-				{
-					Message: "m1",
-					Details: &model.ErrorDetails{
-						Cause: &model.Error{Code: "NET_DOWNSTREAM_UNAVAILABLE", Message: "registry lookup failed"},
-					},
-				},
+		schemaCache: newSchemaCache(10),
+	}
+
+	body := map[string]interface{}{
+		"message": map[string]interface{}{
+			"order": map[string]interface{}{
+				"@context": "https://malicious.com/schema.yaml",
+				"@type":    "SomeType",
 			},
-			objPath: "message.order",
-			want: []model.Error{
-				{
-					Message: "m1",
-					Details: &model.ErrorDetails{
-						Path:  "message.order",
-						Cause: &model.Error{Code: "NET_DOWNSTREAM_UNAVAILABLE", Message: "registry lookup failed"},
-					},
-				},
+			"fulfillment": map[string]interface{}{
+				"@context": "ftp://trusted.com/schema.yaml",
+				"@type":    "OtherType",
 			},
 		},
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			prefixSchemaErrorPaths(tt.schemaErrors, tt.objPath)
-			if !reflect.DeepEqual(tt.schemaErrors, tt.want) {
-				t.Errorf("prefixSchemaErrorPaths() = %+v, want %+v", tt.schemaErrors, tt.want)
-			}
-		})
+	err := v.validateExtendedSchemas(context.Background(), body)
+	var schemaErr *model.SchemaValidationErr
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("expected *model.SchemaValidationErr, got %T: %v", err, err)
+	}
+	var paths []string
+	for _, e := range schemaErr.Errors {
+		paths = append(paths, e.Details.Path)
 	}
+	assert.Equal(t, []string{"/message/fulfillment", "/message/order"}, paths)
 }
 
 func TestIsSchemaVersionSegment(t *testing.T) {
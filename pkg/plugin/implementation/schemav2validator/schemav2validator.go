@@ -3,6 +3,7 @@ package schemav2validator
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -15,6 +16,7 @@ import (
 
 	"github.com/beckn-one/beckn-onix/pkg/log"
 	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
 
 	"github.com/getkin/kin-openapi/openapi3"
 )
@@ -30,17 +32,22 @@ type payload struct {
 type schemav2Validator struct {
 	config          *Config
 	specMutex       sync.RWMutex
-	specsLoaded     bool                            // true once at least one successful loadAllSpecs has completed
-	actionSchemas   map[string]*openapi3.SchemaRef  // merged across primary + all auxiliary specs
-	bodylessActions map[string]struct{}              // merged across primary + all auxiliary specs
-	schemaCache     *schemaCache                     // cache for extended schemas
+	specsLoaded     bool                           // true once at least one successful loadAllSpecs has completed
+	actionSchemas   map[string]*openapi3.SchemaRef // merged across primary + all auxiliary specs
+	bodylessActions map[string]struct{}            // merged across primary + all auxiliary specs
+	responses       map[string]*openapi3.Responses // action → declared responses, merged like actionSchemas
+	schemaCache     *schemaCache                   // cache for extended schemas; replaced, not mutated, when a new bundle loads
+	bundle          *schemaBundle                  // last committed bundle; nil unless Type is "bundle"
+	contextObjects  []model.SchemaObject           // declared @context/@type pairs; nil unless a context manifest is configured
+	manifestLoader  definition.ManifestLoader      // source of the context manifest when ContextSubscriberID is set
 }
 
 // cachedSpec holds a cached OpenAPI spec.
 type cachedSpec struct {
 	doc             *openapi3.T
 	actionSchemas   map[string]*openapi3.SchemaRef // body operations: action → schema (O(1) lookup)
-	bodylessActions map[string]struct{}             // bodyless operations: path without leading slash → exists
+	bodylessActions map[string]struct{}            // bodyless operations: path without leading slash → exists
+	responses       map[string]*openapi3.Responses // body operations: action → declared responses
 	loadedAt        time.Time
 }
 
@@ -52,8 +59,8 @@ type AuxSpec struct {
 
 // Config struct for Schemav2Validator.
 type Config struct {
-	Type     string // "url", "file", or "dir" — primary spec
-	Location string // URL, file path, or directory path — primary spec
+	Type     string // "url", "file", "dir", or "bundle" — primary spec
+	Location string // URL, file path, directory path, or signed bundle path — primary spec
 	CacheTTL int
 
 	// Auxiliary specs — operator-defined, unsigned, additive only.
@@ -63,6 +70,15 @@ type Config struct {
 	// Extended Schema configuration
 	EnableExtendedSchema bool
 	ExtendedSchemaConfig ExtendedSchemaConfig
+
+	// ContextManifestPath, when set, names a node manifest whose
+	// schemaObjects every @context/@type pair in a payload is checked against.
+	ContextManifestPath string
+
+	// ContextSubscriberID, when set instead, names the subscriber whose node
+	// manifest supplies those schemaObjects. It is fetched and verified
+	// through the handler's ManifestLoader; see SetManifestLoader.
+	ContextSubscriberID string
 }
 
 // New creates a new Schemav2Validator instance.
@@ -80,10 +96,17 @@ func New(ctx context.Context, config *Config) (*schemav2Validator, func() error,
 		if config.Location == "" {
 			return nil, nil, fmt.Errorf("config location cannot be empty when type is set")
 		}
-		if config.Type != "url" && config.Type != "file" && config.Type != "dir" {
-			return nil, nil, fmt.Errorf("config type must be 'url', 'file', or 'dir'")
+		if config.Type != "url" && config.Type != "file" && config.Type != "dir" && config.Type != "bundle" {
+			return nil, nil, fmt.Errorf("config type must be 'url', 'file', 'dir', or 'bundle'")
 		}
 	}
+	if config.Type == "bundle" && config.ExtendedSchemaConfig.LocalSchemaPath != "" {
+		return nil, nil, fmt.Errorf("extended schema localSchemaPath cannot be combined with a bundle — the bundle carries the domain schemas")
+	}
+
+	if config.ContextManifestPath != "" && config.ContextSubscriberID != "" {
+		return nil, nil, fmt.Errorf("contextIntegrity_manifestPath and contextIntegrity_subscriberId cannot both be set")
+	}
 
 	if config.CacheTTL == 0 {
 		config.CacheTTL = 3600
@@ -93,6 +116,7 @@ func New(ctx context.Context, config *Config) (*schemav2Validator, func() error,
 		config:          config,
 		actionSchemas:   make(map[string]*openapi3.SchemaRef),
 		bodylessActions: make(map[string]struct{}),
+		responses:       make(map[string]*openapi3.Responses),
 	}
 
 	// Initialize extended schema cache if enabled
@@ -114,6 +138,15 @@ func New(ctx context.Context, config *Config) (*schemav2Validator, func() error,
 		log.Infof(ctx, "Initialized extended schema cache with max size: %d", maxSize)
 	}
 
+	if p := config.ContextManifestPath; p != "" {
+		objects, err := loadContextManifest(p)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load context manifest: %w", err)
+		}
+		v.contextObjects = objects
+		log.Infof(ctx, "Loaded %d schema objects for @context/@type integrity checks from %s", len(objects), p)
+	}
+
 	if err := v.initialise(ctx); err != nil {
 		return nil, nil, fmt.Errorf("failed to initialise schemav2Validator: %v", err)
 	}
@@ -170,11 +203,19 @@ func (v *schemav2Validator) Validate(ctx context.Context, reqURL *url.URL, data
 		return model.NewBadReqErr("", fmt.Errorf("missing field Action in context"))
 	}
 
+	// Snapshot the index and schema cache together so a bundle swapped in
+	// mid-request cannot pair one bundle's specs with another's schemas.
 	v.specMutex.RLock()
 	specsLoaded := v.specsLoaded
 	actionSchemas := v.actionSchemas
+	domainSchemas := v.schemaCache
+	contextObjects := v.contextObjects
 	v.specMutex.RUnlock()
 
+	if contextObjects == nil && v.config.ContextSubscriberID != "" {
+		return fmt.Errorf("no schemaObjects loaded for @context/@type integrity checks: the node manifest of %s was never supplied through a ManifestLoader", v.config.ContextSubscriberID)
+	}
+
 	if !specsLoaded {
 		return model.NewBadReqErr("", fmt.Errorf("no OpenAPI spec loaded"))
 	}
@@ -199,6 +240,7 @@ func (v *schemav2Validator) Validate(ctx context.Context, reqURL *url.URL, data
 	opts := []openapi3.SchemaValidationOption{
 		openapi3.VisitAsRequest(),
 		openapi3.EnableFormatValidation(),
+		openapi3.MultiErrors(),
 	}
 	if err := schema.Value.VisitJSON(jsonData, opts...); err != nil {
 		log.Debugf(ctx, "Schema validation failed: %v", err)
@@ -207,10 +249,20 @@ func (v *schemav2Validator) Validate(ctx context.Context, reqURL *url.URL, data
 
 	log.Debugf(ctx, "base schema validation passed for action: %s", action)
 
+	// @context/@type integrity (if a context manifest is configured) runs
+	// before Extended Schema validation, so no schema is fetched for a
+	// @context the network does not declare.
+	if contextObjects != nil {
+		if err := validateContextIntegrity(jsonData, contextObjects); err != nil {
+			log.Debugf(ctx, "@context/@type integrity check failed for action %s: %v", action, err)
+			return err
+		}
+	}
+
 	// Extended Schema validation (if enabled)
-	if v.config.EnableExtendedSchema && v.schemaCache != nil {
+	if v.config.EnableExtendedSchema && domainSchemas != nil {
 		log.Debugf(ctx, "Starting Extended Schema validation for action: %s", action)
-		if err := v.validateExtendedSchemas(ctx, jsonData); err != nil {
+		if err := v.validateExtendedSchemasWith(ctx, domainSchemas, jsonData); err != nil {
 			// Extended Schema failure - return error
 			log.Debugf(ctx, "Extended Schema validation failed for action %s: %v", action, err)
 			return err
@@ -221,6 +273,60 @@ func (v *schemav2Validator) Validate(ctx context.Context, reqURL *url.URL, data
 	return nil
 }
 
+// ValidateResponse validates the synchronous ACK/NACK body an upstream
+// returned for action against the response the spec declares for statusCode
+// (exact code, then its NXX range, then default). An action whose operation
+// declares no JSON response schemas is not checked; a status it does not
+// declare is rejected. Rejections are a model.SchemaValidationErr or a 400
+// CodedErr; any other error means the response could not be validated.
+func (v *schemav2Validator) ValidateResponse(ctx context.Context, action string, statusCode int, data []byte) error {
+	v.specMutex.RLock()
+	specsLoaded := v.specsLoaded
+	responses := v.responses[action]
+	v.specMutex.RUnlock()
+
+	if !specsLoaded {
+		return fmt.Errorf("no OpenAPI spec loaded")
+	}
+	if responses == nil {
+		log.Debugf(ctx, "no response schemas declared for action %q: skipping response validation", action)
+		return nil
+	}
+
+	ref := responses.Status(statusCode)
+	if ref == nil {
+		ref = responses.Default()
+	}
+	if ref == nil || ref.Value == nil {
+		return model.NewBadReqErr("", fmt.Errorf("response status %d is not declared for action: %s", statusCode, action))
+	}
+	content := ref.Value.Content.Get("application/json")
+	if content == nil || content.Schema == nil || content.Schema.Value == nil {
+		log.Debugf(ctx, "no JSON schema declared for action %q status %d: skipping response validation", action, statusCode)
+		return nil
+	}
+
+	var jsonData any
+	if err := json.Unmarshal(data, &jsonData); err != nil {
+		return &model.SchemaValidationErr{Errors: []model.Error{
+			*model.NewCodedError("SCH_INVALID_JSON", fmt.Sprintf("invalid JSON: %v", err)),
+		}}
+	}
+
+	opts := []openapi3.SchemaValidationOption{
+		openapi3.VisitAsResponse(),
+		openapi3.EnableFormatValidation(),
+		openapi3.MultiErrors(),
+	}
+	if err := content.Schema.Value.VisitJSON(jsonData, opts...); err != nil {
+		log.Debugf(ctx, "Response schema validation failed: %v", err)
+		return v.formatValidationError(err)
+	}
+
+	log.Debugf(ctx, "response schema validation passed for action: %s, status: %d", action, statusCode)
+	return nil
+}
+
 // initialise loads all specs (primary + auxiliary) from the configuration.
 // Auxiliary load failures are skipped at startup — the adapter starts with whatever loaded.
 func (v *schemav2Validator) initialise(ctx context.Context) error {
@@ -241,6 +347,7 @@ func (v *schemav2Validator) initialise(ctx context.Context) error {
 func (v *schemav2Validator) loadAllSpecs(ctx context.Context, failOnAuxError bool) error {
 	mergedActionSchemas := make(map[string]*openapi3.SchemaRef)
 	mergedBodylessActions := make(map[string]struct{})
+	mergedResponses := make(map[string]*openapi3.Responses)
 
 	hasPrimary := v.config.Type != "" && v.config.Location != ""
 
@@ -248,9 +355,26 @@ func (v *schemav2Validator) loadAllSpecs(ctx context.Context, failOnAuxError boo
 		log.Warnf(ctx, "schemav2validator: no primary spec configured — operating without a signed trust anchor")
 	}
 
-	// Load primary spec first.
+	// Load primary spec first. A bundle supplies the primary spec and its
+	// own auxiliary specs, merged below ahead of the configured ones.
+	var bundle *schemaBundle
+	var auxSpecs []*cachedSpec
+	var auxLabels []string
 	if hasPrimary {
-		spec, err := v.loadSingleSpec(ctx, v.config.Type, v.config.Location)
+		var spec *cachedSpec
+		var err error
+		if v.config.Type == "bundle" {
+			v.specMutex.RLock()
+			current := v.bundle
+			v.specMutex.RUnlock()
+			if bundle, err = v.loadBundle(ctx, current); err == nil {
+				spec = bundle.core
+				auxSpecs = bundle.auxiliary
+				auxLabels = bundle.manifest.Auxiliary
+			}
+		} else {
+			spec, err = v.loadSingleSpec(ctx, v.config.Type, v.config.Location)
+		}
 		if err != nil {
 			return fmt.Errorf("failed to load primary spec: %w", err)
 		}
@@ -260,9 +384,21 @@ func (v *schemav2Validator) loadAllSpecs(ctx context.Context, failOnAuxError boo
 		for action := range spec.bodylessActions {
 			mergedBodylessActions[action] = struct{}{}
 		}
+		for action, responses := range spec.responses {
+			mergedResponses[action] = responses
+		}
 		log.Debugf(ctx, "Primary spec loaded: %d body actions, %d bodyless actions", len(spec.actionSchemas), len(spec.bodylessActions))
 	}
 
+	// Bundled auxiliary specs were verified with the bundle, so they merge
+	// first, under the same collision rule as configured ones.
+	for i, spec := range auxSpecs {
+		if err := mergeAuxiliarySpec(spec, auxLabels[i], mergedActionSchemas, mergedBodylessActions, mergedResponses); err != nil {
+			return err
+		}
+		log.Debugf(ctx, "Bundle auxiliary spec %s loaded: %d body actions, %d bodyless actions", auxLabels[i], len(spec.actionSchemas), len(spec.bodylessActions))
+	}
+
 	// Load each auxiliary spec and merge — hard-reject on action collision.
 	for i, aux := range v.config.Auxiliary {
 		spec, err := v.loadSingleSpec(ctx, aux.Type, aux.Location)
@@ -291,6 +427,10 @@ func (v *schemav2Validator) loadAllSpecs(ctx context.Context, failOnAuxError boo
 			}
 			mergedBodylessActions[action] = struct{}{}
 		}
+		// Response entries follow their actions, whose collisions were rejected above.
+		for action, responses := range spec.responses {
+			mergedResponses[action] = responses
+		}
 		log.Debugf(ctx, "Auxiliary spec[%d] loaded from %s: %d body actions, %d bodyless actions", i, aux.Location, len(spec.actionSchemas), len(spec.bodylessActions))
 	}
 
@@ -302,6 +442,19 @@ func (v *schemav2Validator) loadAllSpecs(ctx context.Context, failOnAuxError boo
 	v.specsLoaded = true
 	v.actionSchemas = mergedActionSchemas
 	v.bodylessActions = mergedBodylessActions
+	v.responses = mergedResponses
+	if bundle != nil && bundle != v.bundle {
+		// A new bundle brings its own domain schemas: swap in a fresh cache
+		// holding only them, together with the specs, so no request sees
+		// one bundle's specs with another's schemas.
+		if v.schemaCache != nil {
+			c := newSchemaCache(v.schemaCache.maxSize)
+			c.rawSchemas = bundle.schemas
+			c.offline = true
+			v.schemaCache = c
+		}
+		v.bundle = bundle
+	}
 	v.specMutex.Unlock()
 
 	log.Debugf(ctx, "schemav2validator: merged index ready — %d body actions, %d bodyless actions",
@@ -309,6 +462,27 @@ func (v *schemav2Validator) loadAllSpecs(ctx context.Context, failOnAuxError boo
 	return nil
 }
 
+// mergeAuxiliarySpec adds a bundled auxiliary spec's actions to the merged
+// index. Like configured auxiliary specs, it may only add new actions.
+func mergeAuxiliarySpec(spec *cachedSpec, label string, actionSchemas map[string]*openapi3.SchemaRef, bodylessActions map[string]struct{}, responses map[string]*openapi3.Responses) error {
+	for action, schema := range spec.actionSchemas {
+		if _, exists := actionSchemas[action]; exists {
+			return fmt.Errorf("bundle auxiliary spec %s defines action %q which is already defined in a previously loaded spec — auxiliary specs may only add new actions", label, action)
+		}
+		actionSchemas[action] = schema
+	}
+	for action := range spec.bodylessActions {
+		if _, exists := bodylessActions[action]; exists {
+			return fmt.Errorf("bundle auxiliary spec %s defines bodyless action %q which is already defined in a previously loaded spec — auxiliary specs may only add new actions", label, action)
+		}
+		bodylessActions[action] = struct{}{}
+	}
+	for action, r := range spec.responses {
+		responses[action] = r
+	}
+	return nil
+}
+
 // specHTTPClient is used by freshReadFromURI for all remote spec fetches.
 // The 30 s timeout prevents a hanging server from stalling the reload goroutine
 // indefinitely; caller context deadlines further constrain it when set.
@@ -406,6 +580,7 @@ func (v *schemav2Validator) loadSingleSpec(ctx context.Context, specType, locati
 	}
 
 	actionSchemas, bodylessActions := v.buildActionIndex(ctx, doc)
+	responses := v.buildResponseIndex(doc)
 
 	log.Debugf(ctx, "Loaded spec from %s: %s — %d body actions, %d bodyless actions",
 		specType, location, len(actionSchemas), len(bodylessActions))
@@ -414,6 +589,7 @@ func (v *schemav2Validator) loadSingleSpec(ctx context.Context, specType, locati
 		doc:             doc,
 		actionSchemas:   actionSchemas,
 		bodylessActions: bodylessActions,
+		responses:       responses,
 		loadedAt:        time.Now(),
 	}, nil
 }
@@ -429,6 +605,7 @@ func (v *schemav2Validator) loadSpecFromDir(ctx context.Context, dir string) (*c
 	merged := &cachedSpec{
 		actionSchemas:   make(map[string]*openapi3.SchemaRef),
 		bodylessActions: make(map[string]struct{}),
+		responses:       make(map[string]*openapi3.Responses),
 		loadedAt:        time.Now(),
 	}
 
@@ -459,6 +636,9 @@ func (v *schemav2Validator) loadSpecFromDir(ctx context.Context, dir string) (*c
 			}
 			merged.bodylessActions[action] = struct{}{}
 		}
+		for action, responses := range spec.responses {
+			merged.responses[action] = responses
+		}
 		loaded++
 	}
 
@@ -495,8 +675,11 @@ func (v *schemav2Validator) refreshLoop(ctx context.Context) {
 		case <-coreTicker.C:
 			v.reloadAllSpecs(ctx)
 		case <-refTickerCh:
-			if v.schemaCache != nil {
-				count := v.schemaCache.cleanupExpired()
+			v.specMutex.RLock()
+			domainSchemas := v.schemaCache
+			v.specMutex.RUnlock()
+			if domainSchemas != nil {
+				count := domainSchemas.cleanupExpired()
 				if count > 0 {
 					log.Debugf(ctx, "Cleaned up %d expired extended schemas", count)
 				}
@@ -508,7 +691,11 @@ func (v *schemav2Validator) refreshLoop(ctx context.Context) {
 // reloadAllSpecs rebuilds the merged action index from all specs on TTL expiry.
 // Any failure (primary, auxiliary, or collision) causes the previous valid index
 // to be retained — the new index is only committed when all specs load cleanly.
+// The context manifest, if configured, is re-read on the same cycle.
 func (v *schemav2Validator) reloadAllSpecs(ctx context.Context) {
+	if v.config.ContextManifestPath != "" || v.config.ContextSubscriberID != "" {
+		v.reloadContextManifest(ctx)
+	}
 	if err := v.loadAllSpecs(ctx, true); err != nil {
 		log.Errorf(ctx, err, "Failed to reload specs — serving stale action index until next TTL cycle")
 	} else {
@@ -516,100 +703,74 @@ func (v *schemav2Validator) reloadAllSpecs(ctx context.Context) {
 	}
 }
 
-// formatValidationError converts kin-openapi validation errors to ONIX error format.
+// formatValidationError converts kin-openapi validation errors to ONIX error
+// format: ranked, bounded and pointed into the payload by
+// model.ShapeSchemaErrors.
 func (v *schemav2Validator) formatValidationError(err error) error {
-	var schemaErrors []model.Error
-
-	// Check if it's a MultiError (collection of errors)
-	if multiErr, ok := err.(openapi3.MultiError); ok {
-		for _, e := range multiErr {
-			v.extractSchemaErrors(e, &schemaErrors)
-		}
-	} else {
-		v.extractSchemaErrors(err, &schemaErrors)
-	}
-
-	return &model.SchemaValidationErr{Errors: schemaErrors}
+	return model.ShapeSchemaErrors(schemaFailures(err, nil))
 }
 
-// schemaFieldCodes maps an openapi3.SchemaError's SchemaField (the JSON-schema
-// keyword that failed) to the corresponding Beckn v2.0.0 SCH_* code.
-var schemaFieldCodes = map[string]string{
-	"required": "SCH_REQUIRED_FIELD_MISSING",
-	// kin-openapi uses SchemaField "properties" specifically for the
-	// additionalProperties-disallowed case ("property %q is unsupported").
-	"properties": "SCH_FIELD_NOT_ALLOWED",
-	"enum":       "SCH_INVALID_ENUM",
-	// "const" is JSON Schema's own sugar for an enum with a single allowed
-	// value — there is no dedicated SCH_* code for it, so it shares enum's.
-	"const":  "SCH_INVALID_ENUM",
-	"format": "SCH_INVALID_FORMAT",
-	"type":   "SCH_TYPE_NOT_SUPPORTED",
-}
-
-// schemaFieldToCode looks up schemaFieldCodes. Composite keywords
-// (oneOf/anyOf/allOf) and any constraint without a dedicated code fall back
-// to SCH_SCHEMA_VALIDATION_FAILED, since kin-openapi doesn't attribute those
-// to one single underlying cause.
-func schemaFieldToCode(schemaField string) string {
-	if code, ok := schemaFieldCodes[schemaField]; ok {
-		return code
-	}
-	return "SCH_SCHEMA_VALIDATION_FAILED"
-}
-
-// extractSchemaErrors recursively extracts detailed error information from SchemaError.
-func (v *schemav2Validator) extractSchemaErrors(err error, schemaErrors *[]model.Error) {
-	if becknErr, ok := err.(*model.Error); ok {
+// schemaFailures translates a kin-openapi validation error tree into
+// model.SchemaFailures. prefix is prepended to every location, so errors
+// from validating a sub-document (an extended-schema object) point into the
+// whole payload. A failed allOf contributes all its branches' failures; a
+// failed oneOf keeps them per branch for ShapeSchemaErrors to choose from.
+func schemaFailures(err error, prefix []string) []model.SchemaFailure {
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		var failures []model.SchemaFailure
+		for _, cause := range e {
+			failures = append(failures, schemaFailures(cause, prefix)...)
+		}
+		return failures
+	case *model.Error:
 		// Already a fully-classified error (e.g. from validateReferencedObject's
-		// domain/JSON-LD checks) — pass it through as-is.
-		*schemaErrors = append(*schemaErrors, *becknErr)
-	} else if schemaErr, ok := err.(*openapi3.SchemaError); ok {
-		// Extract path from current error and message from Origin if available
-		pathParts := schemaErr.JSONPointer()
-		path := strings.Join(pathParts, "/")
-		if path == "" {
-			path = schemaErr.SchemaField
-		}
-
-		message := schemaErr.Reason
-		if schemaErr.Origin != nil {
-			originMsg := schemaErr.Origin.Error()
-			// Extract specific field error from nested message
-			if strings.Contains(originMsg, "Error at \"/") {
-				// Find last "Error at" which has the specific field error
-				parts := strings.Split(originMsg, "Error at \"")
-				if len(parts) > 1 {
-					lastPart := parts[len(parts)-1]
-					// Extract field path and update both path and message
-					if idx := strings.Index(lastPart, "\":"); idx > 0 {
-						fieldPath := lastPart[:idx]
-						fieldMsg := strings.TrimSpace(lastPart[idx+2:])
-						path = strings.TrimPrefix(fieldPath, "/")
-						message = fieldMsg
-					}
+		// domain/JSON-LD checks) — keep its code.
+		return []model.SchemaFailure{{Location: prefix, Code: e.Code, Message: e.Message, Cause: e}}
+	case *openapi3.SchemaError:
+		location := append(prefix[:len(prefix):len(prefix)], e.JSONPointer()...)
+		switch e.SchemaField {
+		case "allOf":
+			if branches := compositeBranches(e); branches != nil {
+				return schemaFailures(branches, prefix)
+			}
+		case "oneOf":
+			if branches := compositeBranches(e); branches != nil {
+				f := model.SchemaFailure{Location: location, Keyword: e.SchemaField, Message: e.Reason, Cause: e}
+				for _, branch := range branches {
+					f.Alternatives = append(f.Alternatives, schemaFailures(branch, prefix))
 				}
-			} else {
-				message = originMsg
+				return []model.SchemaFailure{f}
 			}
 		}
-
-		errItem := model.NewCodedError(schemaFieldToCode(schemaErr.SchemaField), message)
-		if path != "" {
-			errItem.Details = &model.ErrorDetails{Path: path}
-		}
-		*schemaErrors = append(*schemaErrors, *errItem)
-	} else if multiErr, ok := err.(openapi3.MultiError); ok {
-		// Nested MultiError
-		for _, e := range multiErr {
-			v.extractSchemaErrors(e, schemaErrors)
+		message := e.Reason
+		if e.Origin != nil && e.Origin != openapi3.ErrOneOfConflict {
+			// e.g. the format checker's own explanation of a format failure.
+			message = e.Origin.Error()
 		}
-	} else {
+		return []model.SchemaFailure{{Location: location, Keyword: e.SchemaField, Message: message, Cause: e}}
+	default:
 		// Generic error — no schema keyword to classify against.
-		*schemaErrors = append(*schemaErrors, *model.NewCodedError("SCH_SCHEMA_VALIDATION_FAILED", err.Error()))
+		return []model.SchemaFailure{{Location: prefix, Message: err.Error(), Cause: err}}
 	}
 }
 
+// compositeBranches returns the per-branch errors kin-openapi wraps in a
+// failed allOf's or oneOf's Origin, or nil when it recorded none (anyOf, or a
+// oneOf that matched more than one branch). Branch errors already carry their
+// full path from the validated root.
+func compositeBranches(e *openapi3.SchemaError) openapi3.MultiError {
+	if e.Origin == nil {
+		return nil
+	}
+	wrapped := errors.Unwrap(e.Origin)
+	if wrapped == nil {
+		return nil
+	}
+	branches, _ := errors.Unwrap(wrapped).(openapi3.MultiError)
+	return branches
+}
+
 // buildActionIndex builds two indexes from the loaded OpenAPI spec:
 //   - actionSchemas: body-bearing operations (POST/PUT/PATCH) keyed by context.action value
 //   - bodylessActions: GET/DELETE operations without a requestBody, keyed by path without leading slash
@@ -660,6 +821,31 @@ func (v *schemav2Validator) buildActionIndex(ctx context.Context, doc *openapi3.
 	return actionSchemas, bodylessActions
 }
 
+// buildResponseIndex maps each body-bearing operation's action to the
+// responses it declares, for ValidateResponse. Operations without declared
+// responses are left out, which leaves their responses unchecked.
+func (v *schemav2Validator) buildResponseIndex(doc *openapi3.T) map[string]*openapi3.Responses {
+	responses := make(map[string]*openapi3.Responses)
+	for _, item := range doc.Paths.Map() {
+		if item == nil {
+			continue
+		}
+		for _, op := range []*openapi3.Operation{item.Post, item.Get, item.Put, item.Patch, item.Delete} {
+			if op == nil || op.RequestBody == nil || op.RequestBody.Value == nil || op.Responses == nil || op.Responses.Len() == 0 {
+				continue
+			}
+			content := op.RequestBody.Value.Content.Get("application/json")
+			if content == nil || content.Schema == nil || content.Schema.Value == nil {
+				continue
+			}
+			if action := v.extractActionFromSchema(content.Schema.Value); action != "" {
+				responses[action] = op.Responses
+			}
+		}
+	}
+	return responses
+}
+
 // extractActionFromSchema extracts the action value from a schema.
 func (v *schemav2Validator) extractActionFromSchema(schema *openapi3.Schema) string {
 	// Check direct properties
@@ -270,7 +270,7 @@ func TestValidate_NestedValidation(t *testing.T) {
 	}
 }
 
-// TestValidate_SchemaErrorDetails confirms extractSchemaErrors only attaches
+// TestValidate_SchemaErrorDetails confirms schemaFailures only attaches
 // Details when the underlying validation cause has a non-empty path — never
 // a non-nil Details with an empty Path (the fix for issue #862's finding #5).
 func TestValidate_SchemaErrorDetails(t *testing.T) {
@@ -320,33 +320,6 @@ func TestValidate_SchemaErrorDetails(t *testing.T) {
 	}
 }
 
-func TestSchemaFieldToCode(t *testing.T) {
-	tests := []struct {
-		field string
-		want  string
-	}{
-		{"required", "SCH_REQUIRED_FIELD_MISSING"},
-		{"properties", "SCH_FIELD_NOT_ALLOWED"},
-		{"enum", "SCH_INVALID_ENUM"},
-		{"const", "SCH_INVALID_ENUM"},
-		{"format", "SCH_INVALID_FORMAT"},
-		{"type", "SCH_TYPE_NOT_SUPPORTED"},
-		{"allOf", "SCH_SCHEMA_VALIDATION_FAILED"},
-		{"oneOf", "SCH_SCHEMA_VALIDATION_FAILED"},
-		{"anyOf", "SCH_SCHEMA_VALIDATION_FAILED"},
-		{"pattern", "SCH_SCHEMA_VALIDATION_FAILED"},
-		{"", "SCH_SCHEMA_VALIDATION_FAILED"},
-		{"unknown-keyword", "SCH_SCHEMA_VALIDATION_FAILED"},
-	}
-	for _, tt := range tests {
-		t.Run(tt.field, func(t *testing.T) {
-			if got := schemaFieldToCode(tt.field); got != tt.want {
-				t.Errorf("schemaFieldToCode(%q) = %s, want %s", tt.field, got, tt.want)
-			}
-		})
-	}
-}
-
 func TestValidate_InvalidJSON(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte(testSpec))
@@ -402,139 +375,159 @@ func TestValidate_NumericOverflowFailsSecondUnmarshal(t *testing.T) {
 	}
 }
 
-// TestExtractSchemaErrors_CompositeOriginFallsBackToGeneric exercises the
-// Origin != nil branch (oneOf/anyOf/allOf composite failures), previously
-// uncovered by any test. Since kin-openapi attributes the failure to the
-// composite keyword itself (not the nested cause), classification correctly
-// falls back to the generic SCH_SCHEMA_VALIDATION_FAILED rather than
-// guessing at whichever nested branch failed.
-func TestExtractSchemaErrors_CompositeOriginFallsBackToGeneric(t *testing.T) {
+// shapeKinError runs err through the same translation formatValidationError
+// uses and returns the shaped errors.
+func shapeKinError(t *testing.T, err error) []model.Error {
+	t.Helper()
+	if err == nil {
+		t.Fatal("expected a validation error")
+	}
+	return model.ShapeSchemaErrors(schemaFailures(err, nil)).Errors
+}
+
+// TestSchemaFailures_AllOfReportsNestedCause confirms a failed allOf is
+// reported as the failures of its branches, classified by the keyword that
+// actually failed, instead of one generic composite error.
+func TestSchemaFailures_AllOfReportsNestedCause(t *testing.T) {
 	sub := openapi3.NewObjectSchema().WithProperty("name", openapi3.NewStringSchema())
 	sub.Required = []string{"name"}
 
 	parent := openapi3.NewObjectSchema()
 	parent.AllOf = openapi3.SchemaRefs{openapi3.NewSchemaRef("", sub)}
 
-	err := parent.VisitJSON(map[string]interface{}{})
-	if err == nil {
-		t.Fatal("expected a validation error")
-	}
-	schemaErr, ok := err.(*openapi3.SchemaError)
-	if !ok {
-		t.Fatalf("expected *openapi3.SchemaError, got %T: %v", err, err)
-	}
-	if schemaErr.SchemaField != "allOf" || schemaErr.Origin == nil {
-		t.Fatalf("expected SchemaField=allOf with a non-nil Origin, got SchemaField=%s, Origin=%v", schemaErr.SchemaField, schemaErr.Origin)
-	}
-
-	v := &schemav2Validator{}
-	var schemaErrors []model.Error
-	v.extractSchemaErrors(err, &schemaErrors)
+	err := parent.VisitJSON(map[string]interface{}{}, openapi3.MultiErrors())
+	got := shapeKinError(t, err)
 
-	if len(schemaErrors) != 1 {
-		t.Fatalf("expected exactly one extracted error, got %d: %+v", len(schemaErrors), schemaErrors)
+	if len(got) != 1 {
+		t.Fatalf("expected exactly one error, got %d: %+v", len(got), got)
 	}
-	got := schemaErrors[0]
-	t.Logf("extracted = %+v", got)
-	if got.Code != "SCH_SCHEMA_VALIDATION_FAILED" {
-		t.Errorf("Code = %s, want SCH_SCHEMA_VALIDATION_FAILED", got.Code)
+	if got[0].Code != "SCH_REQUIRED_FIELD_MISSING" {
+		t.Errorf("Code = %s, want SCH_REQUIRED_FIELD_MISSING", got[0].Code)
 	}
-	if got.Message == "" {
-		t.Error("Message = \"\", want a non-empty message describing the nested cause")
+	if got[0].Details == nil || got[0].Details.Path != "/name" {
+		t.Errorf("Details = %+v, want Path=/name", got[0].Details)
 	}
 }
 
-// TestExtractSchemaErrors_CompositeOriginParsesNestedFieldPath exercises the
-// "Error at \"/path\": reason" string-parsing branch specifically — triggered
-// when the nested cause inside an allOf/oneOf failure occurred on a property
-// deep enough to have a tagged reversePath. Previously uncovered by any test.
-func TestExtractSchemaErrors_CompositeOriginParsesNestedFieldPath(t *testing.T) {
+// TestSchemaFailures_NestedFieldPointer confirms the path of a failure inside
+// a composite is an RFC 6901 pointer to the failing member.
+func TestSchemaFailures_NestedFieldPointer(t *testing.T) {
 	innerSub := openapi3.NewObjectSchema().WithProperty("name", openapi3.NewStringSchema())
 	innerSub.Required = []string{"name"}
-	sub := openapi3.NewObjectSchema().WithProperty("nested", innerSub)
+	sub := openapi3.NewObjectSchema().WithProperty("a/b", innerSub)
 
 	parent := openapi3.NewObjectSchema()
 	parent.AllOf = openapi3.SchemaRefs{openapi3.NewSchemaRef("", sub)}
 
-	err := parent.VisitJSON(map[string]interface{}{"nested": map[string]interface{}{}})
-	if err == nil {
-		t.Fatal("expected a validation error")
+	err := parent.VisitJSON(map[string]interface{}{"a/b": map[string]interface{}{}}, openapi3.MultiErrors())
+	got := shapeKinError(t, err)
+
+	if len(got) != 1 {
+		t.Fatalf("expected exactly one error, got %d: %+v", len(got), got)
 	}
-	schemaErr, ok := err.(*openapi3.SchemaError)
-	if !ok {
-		t.Fatalf("expected *openapi3.SchemaError, got %T: %v", err, err)
+	if got[0].Details == nil || got[0].Details.Path != "/a~1b/name" {
+		t.Errorf("Details = %+v, want Path=/a~1b/name", got[0].Details)
 	}
-	if schemaErr.Origin == nil || !strings.Contains(schemaErr.Origin.Error(), `Error at "`) {
-		t.Fatalf(`expected Origin.Error() to contain 'Error at "', got: %v`, schemaErr.Origin)
+	if !strings.HasPrefix(got[0].Message, `property "name" is missing`) {
+		t.Errorf("Message = %q, want it to start with %q", got[0].Message, `property "name" is missing`)
 	}
+}
 
-	v := &schemav2Validator{}
-	var schemaErrors []model.Error
-	v.extractSchemaErrors(err, &schemaErrors)
-
-	if len(schemaErrors) != 1 {
-		t.Fatalf("expected exactly one extracted error, got %d: %+v", len(schemaErrors), schemaErrors)
-	}
-	got := schemaErrors[0]
-	t.Logf("extracted = %+v", got)
-	if got.Details == nil || got.Details.Path != "nested/name" {
-		t.Errorf("Details = %+v, want Path=nested/name", got.Details)
-	}
-	// kin-openapi's SchemaError.Error() appends a verbose schema/value dump
-	// after the reason — check the extracted reason is the prefix, not an
-	// exact match against that library-formatted tail.
-	if !strings.HasPrefix(got.Message, `property "name" is missing`) {
-		t.Errorf("Message = %q, want it to start with %q", got.Message, `property "name" is missing`)
+// TestSchemaFailures_OneOfReportsClosestBranch confirms a failed oneOf is
+// reported as the failures of the branch the value came closest to, not the
+// generic composite error or every branch's failures.
+func TestSchemaFailures_OneOfReportsClosestBranch(t *testing.T) {
+	card := openapi3.NewObjectSchema().
+		WithProperty("type", &openapi3.Schema{Type: &openapi3.Types{"string"}, Enum: []any{"card"}}).
+		WithProperty("number", openapi3.NewStringSchema())
+	card.Required = []string{"type", "number"}
+	upi := openapi3.NewObjectSchema().
+		WithProperty("type", &openapi3.Schema{Type: &openapi3.Types{"string"}, Enum: []any{"upi"}}).
+		WithProperty("vpa", openapi3.NewStringSchema())
+	upi.Required = []string{"type", "vpa"}
+
+	payment := &openapi3.Schema{OneOf: openapi3.SchemaRefs{
+		openapi3.NewSchemaRef("", openapi3.NewStringSchema()),
+		openapi3.NewSchemaRef("", card),
+		openapi3.NewSchemaRef("", upi),
+	}}
+	root := openapi3.NewObjectSchema().WithProperty("payment", payment)
+
+	err := root.VisitJSON(map[string]interface{}{
+		"payment": map[string]interface{}{"type": "card"},
+	}, openapi3.MultiErrors())
+	got := shapeKinError(t, err)
+
+	if len(got) != 1 {
+		t.Fatalf("expected only the card branch's failure, got %d: %+v", len(got), got)
+	}
+	if got[0].Code != "SCH_REQUIRED_FIELD_MISSING" || got[0].Details == nil || got[0].Details.Path != "/payment/number" {
+		t.Errorf("error = %+v, want SCH_REQUIRED_FIELD_MISSING at /payment/number", got[0])
 	}
 }
 
-// TestExtractSchemaErrors_ConstViolation confirms a JSON-schema "const"
+// TestSchemaFailures_ConstViolation confirms a JSON-schema "const"
 // violation — SchemaField "const", no dedicated SCH_* code in the taxonomy —
 // classifies as SCH_INVALID_ENUM, since const is enum-of-one.
-func TestExtractSchemaErrors_ConstViolation(t *testing.T) {
+func TestSchemaFailures_ConstViolation(t *testing.T) {
 	sub := openapi3.NewStringSchema()
 	sub.Const = "search"
 
-	err := sub.VisitJSON("select")
-	if err == nil {
-		t.Fatal("expected a validation error")
-	}
-	schemaErr, ok := err.(*openapi3.SchemaError)
-	if !ok {
-		t.Fatalf("expected *openapi3.SchemaError, got %T: %v", err, err)
+	got := shapeKinError(t, sub.VisitJSON("select"))
+
+	if len(got) != 1 {
+		t.Fatalf("expected exactly one error, got %d: %+v", len(got), got)
 	}
-	if schemaErr.SchemaField != "const" {
-		t.Fatalf("expected SchemaField=const, got %s", schemaErr.SchemaField)
+	if got[0].Code != "SCH_INVALID_ENUM" {
+		t.Errorf("Code = %s, want SCH_INVALID_ENUM", got[0].Code)
 	}
+}
 
-	v := &schemav2Validator{}
-	var schemaErrors []model.Error
-	v.extractSchemaErrors(err, &schemaErrors)
+// TestSchemaFailures_BecknErrorPassthrough confirms an already-classified
+// *model.Error (validateReferencedObject's domain/JSON-LD/@type errors) keeps
+// its code and message and takes the location it is reported at.
+func TestSchemaFailures_BecknErrorPassthrough(t *testing.T) {
+	original := model.NewCodedError("SCH_INVALID_JSONLD_CONTEXT", "domain not allowed: malicious.com")
 
-	if len(schemaErrors) != 1 {
-		t.Fatalf("expected exactly one extracted error, got %d: %+v", len(schemaErrors), schemaErrors)
+	got := model.ShapeSchemaErrors(schemaFailures(original, []string{"message", "order"})).Errors
+
+	if len(got) != 1 {
+		t.Fatalf("expected exactly one error, got %d: %+v", len(got), got)
+	}
+	if got[0].Code != original.Code || got[0].Message != original.Message {
+		t.Errorf("got %+v, want code and message of %+v", got[0], *original)
 	}
-	if got := schemaErrors[0].Code; got != "SCH_INVALID_ENUM" {
-		t.Errorf("Code = %s, want SCH_INVALID_ENUM", got)
+	if got[0].Details == nil || got[0].Details.Path != "/message/order" {
+		t.Errorf("Details = %+v, want Path=/message/order", got[0].Details)
 	}
 }
 
-// TestExtractSchemaErrors_BecknErrorPassthrough closes the coverage gap on
-// extractSchemaErrors' *model.Error passthrough branch (used to carry
-// validateReferencedObject's already-classified domain/JSON-LD/@type errors
-// into the aggregate SchemaValidationErr) — previously exercised by no test.
-func TestExtractSchemaErrors_BecknErrorPassthrough(t *testing.T) {
-	original := model.NewCodedError("SCH_INVALID_JSONLD_CONTEXT", "domain not allowed: malicious.com")
+// TestValidate_AggregatesErrors confirms every failure in a payload is
+// reported, not only the first one kin-openapi finds.
+func TestValidate_AggregatesErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testSpec))
+	}))
+	defer server.Close()
+
+	validator, _, err := New(context.Background(), &Config{Type: "url", Location: server.URL, CacheTTL: 3600})
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
 
-	v := &schemav2Validator{}
-	var schemaErrors []model.Error
-	v.extractSchemaErrors(original, &schemaErrors)
+	err = validator.Validate(context.Background(), nil, []byte(`{"context":{"action":"search","domain":7},"message":"x"}`))
 
-	if len(schemaErrors) != 1 {
-		t.Fatalf("expected exactly one extracted error, got %d: %+v", len(schemaErrors), schemaErrors)
+	var schemaErr *model.SchemaValidationErr
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("expected *model.SchemaValidationErr, got %T: %v", err, err)
 	}
-	if schemaErrors[0] != *original {
-		t.Errorf("extractSchemaErrors did not pass through *model.Error as-is: got %+v, want %+v", schemaErrors[0], *original)
+	if len(schemaErr.Errors) < 2 {
+		t.Fatalf("expected every failure to be reported, got %+v", schemaErr.Errors)
+	}
+	for _, e := range schemaErr.Errors {
+		if e.Details == nil || !strings.HasPrefix(e.Details.Path, "/") {
+			t.Errorf("error %+v has no JSON Pointer path", e)
+		}
 	}
 }
 
@@ -1190,3 +1183,102 @@ func contains(s, substr string) bool {
 	}
 	return false
 }
+
+const testSpecResponses = `openapi: 3.1.0
+info:
+  title: Test API
+  version: 1.0.0
+paths:
+  /confirm:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                context:
+                  type: object
+                  properties:
+                    action:
+                      const: confirm
+      responses:
+        "200":
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [message]
+                properties:
+                  message:
+                    type: object
+                    required: [status]
+                    properties:
+                      status:
+                        enum: [ACK, NACK]
+        "4XX":
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [message]
+  /search:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                context:
+                  type: object
+                  properties:
+                    action:
+                      const: search
+`
+
+func TestValidateResponse(t *testing.T) {
+	specFile := filepath.Join(t.TempDir(), "spec.yaml")
+	if err := os.WriteFile(specFile, []byte(testSpecResponses), 0644); err != nil {
+		t.Fatalf("failed to write spec: %v", err)
+	}
+	validator, _, err := New(context.Background(), &Config{Type: "file", Location: specFile})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		action   string
+		status   int
+		body     string
+		wantErr  bool
+		wantCode string
+	}{
+		{"valid ACK", "confirm", 200, `{"message": {"status": "ACK"}}`, false, ""},
+		{"bad status value", "confirm", 200, `{"message": {"status": "OK"}}`, true, "SCH_INVALID_ENUM"},
+		{"missing message", "confirm", 200, `{"ack": true}`, true, "SCH_REQUIRED_FIELD_MISSING"},
+		{"range match", "confirm", 401, `{"message": {}}`, false, ""},
+		{"range mismatch", "confirm", 400, `{}`, true, "SCH_REQUIRED_FIELD_MISSING"},
+		{"not JSON", "confirm", 200, `<html>bad gateway</html>`, true, "SCH_INVALID_JSON"},
+		{"undeclared status", "confirm", 502, `{}`, true, ""},
+		{"no responses declared", "search", 200, `anything`, false, ""},
+		{"unknown action", "rate", 200, `anything`, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.ValidateResponse(ctx, tt.action, tt.status, []byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateResponse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantCode == "" {
+				return
+			}
+			var schemaErr *model.SchemaValidationErr
+			if !errors.As(err, &schemaErr) || len(schemaErr.Errors) == 0 || schemaErr.Errors[0].Code != tt.wantCode {
+				t.Errorf("error = %v, want code %s", err, tt.wantCode)
+			}
+		})
+	}
+}
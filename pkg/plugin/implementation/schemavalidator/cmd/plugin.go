@@ -3,11 +3,17 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strconv"
 
 	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
 	"github.com/beckn-one/beckn-onix/pkg/plugin/implementation/schemavalidator"
 )
 
+// defaultReloadInterval is how often, in seconds, the schema source is
+// checked for changes when reloadInterval is not configured.
+const defaultReloadInterval = 30
+
 // schemaValidatorProvider provides instances of schemaValidator.
 type schemaValidatorProvider struct{}
 
@@ -17,16 +23,30 @@ func (vp schemaValidatorProvider) New(ctx context.Context, config map[string]str
 		return nil, nil, errors.New("context cannot be nil")
 	}
 
-	// Extract schemaDir from the config map
-	schemaDir, ok := config["schemaDir"]
-	if !ok || schemaDir == "" {
-		return nil, nil, errors.New("config must contain 'schemaDir'")
+	// Extract schemaDir or schemaArchive from the config map
+	schemaDir, schemaArchive := config["schemaDir"], config["schemaArchive"]
+	if schemaDir == "" && schemaArchive == "" {
+		return nil, nil, errors.New("config must contain 'schemaDir' or 'schemaArchive'")
+	}
+	if schemaDir != "" && schemaArchive != "" {
+		return nil, nil, errors.New("config must contain only one of 'schemaDir' and 'schemaArchive'")
+	}
+
+	cfg := &schemavalidator.Config{
+		SchemaDir:      schemaDir,
+		SchemaArchive:  schemaArchive,
+		ReloadInterval: defaultReloadInterval,
+	}
+	if v, ok := config["reloadInterval"]; ok {
+		interval, err := strconv.Atoi(v)
+		if err != nil || interval < 0 {
+			return nil, nil, fmt.Errorf("reloadInterval must be a non-negative number of seconds, got %q", v)
+		}
+		cfg.ReloadInterval = interval
 	}
 
 	// Create a new schemaValidator instance with the provided configuration
-	return schemavalidator.New(ctx, &schemavalidator.Config{
-		SchemaDir: schemaDir,
-	})
+	return schemavalidator.New(ctx, cfg)
 }
 
 // Provider is the exported symbol that the plugin manager will look for.
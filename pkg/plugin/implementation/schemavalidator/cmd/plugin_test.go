@@ -66,19 +66,28 @@ func TestValidatorProviderSuccess(t *testing.T) {
 			config:        map[string]string{"schemaDir": schemaDir},
 			expectedError: "",
 		},
+		{
+			name:          "Reload disabled",
+			ctx:           context.Background(),
+			config:        map[string]string{"schemaDir": schemaDir, "reloadInterval": "0"},
+			expectedError: "",
+		},
 	}
 
 	// Test using table-driven tests
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			vp := schemaValidatorProvider{}
-			schemaValidator, _, err := vp.New(tt.ctx, tt.config)
+			schemaValidator, closer, err := vp.New(tt.ctx, tt.config)
 
 			// Ensure no error occurred
 			if err != nil {
 				t.Errorf("unexpected error: %v", err)
 				return
 			}
+			if closer != nil {
+				defer closer()
+			}
 
 			// Ensure the schemaValidator is not nil
 			if schemaValidator == nil {
@@ -118,6 +127,18 @@ func TestValidatorProviderFailure(t *testing.T) {
 			config:        map[string]string{"schemaDir": "/invalid/dir"},
 			expectedError: "failed to initialise schemaValidator: schema directory does not exist: /invalid/dir",
 		},
+		{
+			name:          "Both schemaDir and schemaArchive",
+			ctx:           context.Background(),
+			config:        map[string]string{"schemaDir": schemaDir, "schemaArchive": "schemas.zip"},
+			expectedError: "config must contain only one of 'schemaDir' and 'schemaArchive'",
+		},
+		{
+			name:          "Invalid reloadInterval",
+			ctx:           context.Background(),
+			config:        map[string]string{"schemaDir": schemaDir, "reloadInterval": "soon"},
+			expectedError: "reloadInterval must be a non-negative number of seconds",
+		},
 		{
 			name:          "Nil context",
 			ctx:           nil, // Nil context
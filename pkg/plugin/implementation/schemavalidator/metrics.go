@@ -0,0 +1,126 @@
+package schemavalidator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// loadMetrics exposes the schema load instruments.
+type loadMetrics struct {
+	loads            metric.Int64Counter
+	compileErrors    metric.Int64Counter
+	versionFallbacks metric.Int64Counter
+}
+
+// Attribute keys for the schema load instruments.
+var (
+	attrStatus   = attribute.Key("status")  // success | failure
+	attrTrigger  = attribute.Key("trigger") // startup | reload
+	attrDomain   = attribute.Key("domain")
+	attrVersion  = attribute.Key("version")
+	attrResolved = attribute.Key("resolved_version")
+)
+
+// loadMetricsCache caches the loadMetrics for the current global
+// MeterProvider. Instruments are rebound only when otel.SetMeterProvider
+// changes the provider pointer.
+var loadMetricsCache struct {
+	mu       sync.RWMutex
+	provider metric.MeterProvider
+	m        *loadMetrics
+}
+
+// getLoadMetrics returns loadMetrics bound to the current global
+// MeterProvider, rebuilding only when the provider has been replaced since
+// the last call.
+func getLoadMetrics(_ context.Context) (*loadMetrics, error) {
+	current := otel.GetMeterProvider()
+
+	loadMetricsCache.mu.RLock()
+	if loadMetricsCache.provider == current && loadMetricsCache.m != nil {
+		m := loadMetricsCache.m
+		loadMetricsCache.mu.RUnlock()
+		return m, nil
+	}
+	loadMetricsCache.mu.RUnlock()
+
+	loadMetricsCache.mu.Lock()
+	defer loadMetricsCache.mu.Unlock()
+	if loadMetricsCache.provider == current && loadMetricsCache.m != nil {
+		return loadMetricsCache.m, nil
+	}
+	m, err := newLoadMetrics()
+	if err != nil {
+		return nil, err
+	}
+	loadMetricsCache.provider = current
+	loadMetricsCache.m = m
+	return m, nil
+}
+
+func newLoadMetrics() (*loadMetrics, error) {
+	meter := otel.GetMeterProvider().Meter(
+		"github.com/beckn-one/beckn-onix/schemavalidator",
+		metric.WithInstrumentationVersion("1.0.0"),
+	)
+
+	m := &loadMetrics{}
+	var err error
+
+	if m.loads, err = meter.Int64Counter(
+		"onix_schema_loads_total",
+		metric.WithDescription("Schema tree loads at startup and on reload, by outcome"),
+		metric.WithUnit("{load}"),
+	); err != nil {
+		return nil, fmt.Errorf("onix_schema_loads_total: %w", err)
+	}
+
+	if m.compileErrors, err = meter.Int64Counter(
+		"onix_schema_compile_errors_total",
+		metric.WithDescription("Schema files that failed to compile, by domain and version"),
+		metric.WithUnit("{error}"),
+	); err != nil {
+		return nil, fmt.Errorf("onix_schema_compile_errors_total: %w", err)
+	}
+
+	if m.versionFallbacks, err = meter.Int64Counter(
+		"onix_schema_version_fallbacks_total",
+		metric.WithDescription("Requests validated against a schema version other than the one they declared"),
+		metric.WithUnit("{request}"),
+	); err != nil {
+		return nil, fmt.Errorf("onix_schema_version_fallbacks_total: %w", err)
+	}
+
+	return m, nil
+}
+
+// recordLoad counts one schema tree load. It is a no-op without metrics.
+func (m *loadMetrics) recordLoad(ctx context.Context, status, trigger string) {
+	if m == nil {
+		return
+	}
+	m.loads.Add(ctx, 1, metric.WithAttributes(attrStatus.String(status), attrTrigger.String(trigger)))
+}
+
+// recordCompileError counts one schema file that failed to compile.
+func (m *loadMetrics) recordCompileError(ctx context.Context, domain, version string) {
+	if m == nil {
+		return
+	}
+	m.compileErrors.Add(ctx, 1, metric.WithAttributes(attrDomain.String(domain), attrVersion.String(version)))
+}
+
+// recordFallback counts one request validated against resolved instead of
+// the version it declared.
+func (m *loadMetrics) recordFallback(ctx context.Context, domain, version, resolved string) {
+	if m == nil {
+		return
+	}
+	m.versionFallbacks.Add(ctx, 1, metric.WithAttributes(
+		attrDomain.String(domain), attrVersion.String(version), attrResolved.String(resolved)))
+}
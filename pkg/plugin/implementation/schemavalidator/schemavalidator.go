@@ -1,18 +1,26 @@
 package schemavalidator
 
 import (
+	"bytes"
+	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
-	"os"
-	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/beckn-one/beckn-onix/pkg/log"
 	"github.com/beckn-one/beckn-onix/pkg/model"
 
 	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
 )
 
 // Payload represents the structure of the data payload with context information.
@@ -25,13 +33,33 @@ type payload struct {
 
 // schemaValidator implements the Validator interface.
 type schemaValidator struct {
-	config      *Config
+	config *Config
+
+	// mu guards schemaCache and versions, which a reload replaces together.
+	mu          sync.RWMutex
 	schemaCache map[string]*jsonschema.Schema
+	// versions lists each domain's version directories, for version fallback.
+	versions map[string][]string
+
+	// fingerprint identifies the source contents last loaded successfully;
+	// failedFingerprint the last contents that failed to load, so a broken
+	// tree is reported once rather than on every watch tick.
+	fingerprint       string
+	failedFingerprint string
+
+	metrics *loadMetrics
 }
 
 // Config struct for SchemaValidator.
 type Config struct {
+	// SchemaDir is the root of the domain/version/endpoint.json tree.
 	SchemaDir string
+	// SchemaArchive is a zip archive of the same tree, used instead of
+	// SchemaDir. Exactly one of the two must be set.
+	SchemaArchive string
+	// ReloadInterval is how often, in seconds, the source is checked for
+	// changes and recompiled. Zero disables reloading.
+	ReloadInterval int
 }
 
 // New creates a new ValidatorProvider instance.
@@ -40,16 +68,38 @@ func New(ctx context.Context, config *Config) (*schemaValidator, func() error, e
 	if config == nil {
 		return nil, nil, fmt.Errorf("config cannot be nil")
 	}
+	if (config.SchemaDir == "") == (config.SchemaArchive == "") {
+		return nil, nil, fmt.Errorf("exactly one of SchemaDir and SchemaArchive must be set")
+	}
 	v := &schemaValidator{
 		config:      config,
 		schemaCache: make(map[string]*jsonschema.Schema),
 	}
+	m, err := getLoadMetrics(ctx)
+	if err != nil {
+		log.Warnf(ctx, "schemavalidator: metrics unavailable: %v", err)
+	}
+	v.metrics = m
 
 	// Call Initialise function to load schemas and get validators
 	if err := v.initialise(); err != nil {
 		return nil, nil, fmt.Errorf("failed to initialise schemaValidator: %v", err)
 	}
-	return v, nil, nil
+	if config.ReloadInterval <= 0 {
+		return v, nil, nil
+	}
+
+	watchCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		v.watchLoop(watchCtx)
+	}()
+	return v, func() error {
+		cancel()
+		<-done
+		return nil
+	}, nil
 }
 
 // Validate validates the given data against the schema.
@@ -79,14 +129,15 @@ func (v *schemaValidator) Validate(ctx context.Context, reqURL *url.URL, data []
 	domain := strings.ToLower(cxtDomain)
 	domain = strings.ReplaceAll(domain, ":", "_")
 
-	// Construct the schema file name.
-	schemaFileName := fmt.Sprintf("%s_%s_%s", domain, version, endpoint)
-
-	// Retrieve the schema from the cache.
-	schema, exists := v.schemaCache[schemaFileName]
+	// Retrieve the schema from the cache, falling back to a compatible version.
+	schema, resolved, exists := v.lookup(domain, version, endpoint)
 	if !exists {
 		return model.NewBadReqErr("", fmt.Errorf("schema not found for domain: %s", domain))
 	}
+	if resolved != version {
+		log.Debugf(ctx, "No %s schema for %s/%s, validating against %s", version, domain, endpoint, resolved)
+		v.metrics.recordFallback(ctx, domain, version, resolved)
+	}
 
 	var jsonData any
 	if err := json.Unmarshal(data, &jsonData); err != nil {
@@ -96,22 +147,7 @@ func (v *schemaValidator) Validate(ctx context.Context, reqURL *url.URL, data []
 	if err != nil {
 		// Handle schema validation errors
 		if validationErr, ok := err.(*jsonschema.ValidationError); ok {
-			// Convert validation errors into an array of SchemaValError
-			var schemaErrors []model.Error
-			for _, cause := range validationErr.Causes {
-				// Extract the path and message from the validation error
-				path := strings.Join(cause.InstanceLocation, ".") // JSON path to the invalid field
-				message := cause.Error()                          // Validation error message
-
-				// Append the error to the schemaErrors array
-				errItem := model.Error{Message: message}
-				if path != "" {
-					errItem.Details = &model.ErrorDetails{Path: path}
-				}
-				schemaErrors = append(schemaErrors, errItem)
-			}
-			// Return the array of schema validation errors
-			return &model.SchemaValidationErr{Errors: schemaErrors}
+			return model.ShapeSchemaErrors(schemaFailures(validationErr))
 		}
 		return fmt.Errorf("validation failed: %v", err)
 	}
@@ -120,83 +156,278 @@ func (v *schemaValidator) Validate(ctx context.Context, reqURL *url.URL, data []
 	return nil
 }
 
+// messagePrinter renders jsonschema error kinds as English messages.
+var messagePrinter = message.NewPrinter(language.English)
+
+// schemaFailures translates a jsonschema validation error tree into
+// model.SchemaFailures. Wrapper kinds ($ref, allOf, the schema itself)
+// contribute their causes; a failed oneOf or anyOf keeps its causes per
+// branch for model.ShapeSchemaErrors to choose from; a missing-properties
+// failure becomes one failure per missing property, located at it.
+func schemaFailures(err *jsonschema.ValidationError) []model.SchemaFailure {
+	switch k := err.ErrorKind.(type) {
+	case *kind.OneOf, *kind.AnyOf:
+		if len(err.Causes) > 0 {
+			f := leafFailure(err, err.InstanceLocation, k)
+			for _, cause := range err.Causes {
+				f.Alternatives = append(f.Alternatives, schemaFailures(cause))
+			}
+			return []model.SchemaFailure{f}
+		}
+	case *kind.Required:
+		failures := make([]model.SchemaFailure, 0, len(k.Missing))
+		for _, prop := range k.Missing {
+			location := append(err.InstanceLocation[:len(err.InstanceLocation):len(err.InstanceLocation)], prop)
+			failures = append(failures, leafFailure(err, location, &kind.Required{Missing: []string{prop}}))
+		}
+		return failures
+	}
+	if len(err.Causes) > 0 {
+		var failures []model.SchemaFailure
+		for _, cause := range err.Causes {
+			failures = append(failures, schemaFailures(cause)...)
+		}
+		return failures
+	}
+	return []model.SchemaFailure{leafFailure(err, err.InstanceLocation, err.ErrorKind)}
+}
+
+// leafFailure builds the SchemaFailure for one error kind at location.
+func leafFailure(err *jsonschema.ValidationError, location []string, k jsonschema.ErrorKind) model.SchemaFailure {
+	var keyword string
+	if path := k.KeywordPath(); len(path) > 0 {
+		keyword = path[0]
+	}
+	return model.SchemaFailure{
+		Location: location,
+		Keyword:  keyword,
+		Message:  k.LocalizedString(messagePrinter),
+		Cause:    err,
+	}
+}
+
 // Initialise initialises the validator provider by compiling all the JSON schema files
-// from the specified directory and storing them in a cache indexed by their schema filenames.
+// from the configured directory or archive and storing them in a cache indexed by their
+// schema filenames.
 func (v *schemaValidator) initialise() error {
-	schemaDir := v.config.SchemaDir
-	// Check if the directory exists and is accessible.
-	info, err := os.Stat(schemaDir)
+	fingerprint, _ := v.sourceFingerprint()
+	if err := v.load(context.Background(), "startup"); err != nil {
+		return err
+	}
+	v.fingerprint = fingerprint
+	return nil
+}
+
+// load compiles the whole schema tree and, only if every file compiles,
+// swaps it in for the current one. A failed load leaves the current schemas
+// in place.
+func (v *schemaValidator) load(ctx context.Context, trigger string) error {
+	schemas, versions, err := v.compile(ctx)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return fmt.Errorf("schema directory does not exist: %s", schemaDir)
-		}
-		return fmt.Errorf("failed to access schema directory: %v", err)
+		v.metrics.recordLoad(ctx, "failure", trigger)
+		return err
+	}
+	v.mu.Lock()
+	v.schemaCache = schemas
+	v.versions = versions
+	v.mu.Unlock()
+	v.metrics.recordLoad(ctx, "success", trigger)
+	return nil
+}
+
+// compile reads the schema source and compiles every schema in it. All
+// compile failures are reported together.
+func (v *schemaValidator) compile(ctx context.Context) (map[string]*jsonschema.Schema, map[string][]string, error) {
+	var files []schemaFile
+	var err error
+	if v.config.SchemaArchive != "" {
+		files, err = readSchemaArchive(v.config.SchemaArchive)
+	} else {
+		files, err = readSchemaDir(v.config.SchemaDir)
+	}
+	if err != nil {
+		return nil, nil, err
 	}
-	if !info.IsDir() {
-		return fmt.Errorf("provided schema path is not a directory: %s", schemaDir)
+	source := "schema directory"
+	if v.config.SchemaArchive != "" {
+		source = "schema archive"
 	}
 
+	type entry struct {
+		file                      schemaFile
+		domain, version, endpoint string
+	}
+	entries := make([]entry, 0, len(files))
 	compiler := jsonschema.NewCompiler()
+	var compileErrs []error
+	for _, f := range files {
+		// Split the relative path to get domain, version, and schema.
+		parts := strings.Split(f.rel, "/")
+
+		// Ensure that the file path has at least 3 parts: domain, version, and schema file.
+		if len(parts) < 3 {
+			return nil, nil, fmt.Errorf("failed to read %s: invalid schema file structure, expected domain/version/schema.json but got: %s", source, f.rel)
+		}
+
+		// Extract domain, version, and schema filename from the parts.
+		// Validate that the extracted parts are non-empty.
+		e := entry{
+			file:     f,
+			domain:   strings.TrimSpace(parts[0]),
+			version:  strings.TrimSpace(parts[1]),
+			endpoint: strings.TrimSuffix(strings.TrimSpace(parts[2]), ".json"),
+		}
+		if e.domain == "" || e.version == "" || e.endpoint == "" {
+			return nil, nil, fmt.Errorf("failed to read %s: invalid schema file structure, one or more components are empty. Relative path: %s", source, f.rel)
+		}
+
+		// Register every file before compiling any, so $refs between files
+		// resolve whatever order they are read in.
+		doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(f.data))
+		if err == nil {
+			err = compiler.AddResource(f.url, doc)
+		}
+		if err != nil {
+			compileErrs = append(compileErrs, fmt.Errorf("failed to compile JSON schema from file %s: %v", f.rel, err))
+			v.metrics.recordCompileError(ctx, e.domain, e.version)
+			continue
+		}
+		entries = append(entries, e)
+	}
 
-	// Helper function to process directories recursively.
-	var processDir func(dir string) error
-	processDir = func(dir string) error {
-		entries, err := os.ReadDir(dir)
+	schemas := make(map[string]*jsonschema.Schema, len(entries))
+	versions := make(map[string][]string)
+	for _, e := range entries {
+		compiledSchema, err := compiler.Compile(e.file.url)
 		if err != nil {
-			return fmt.Errorf("failed to read directory: %v", err)
-		}
-
-		for _, entry := range entries {
-			path := filepath.Join(dir, entry.Name())
-			if entry.IsDir() {
-				// Recursively process subdirectories.
-				if err := processDir(path); err != nil {
-					return err
-				}
-			} else if filepath.Ext(entry.Name()) == ".json" {
-				// Process JSON files.
-				compiledSchema, err := compiler.Compile(path)
-				if err != nil {
-					return fmt.Errorf("failed to compile JSON schema from file %s: %v", entry.Name(), err)
-				}
-
-				// Use relative path from schemaDir to avoid absolute paths and make schema keys domain/version specific.
-				relativePath, err := filepath.Rel(schemaDir, path)
-				if err != nil {
-					return fmt.Errorf("failed to get relative path for file %s: %v", entry.Name(), err)
-				}
-				// Split the relative path to get domain, version, and schema.
-				parts := strings.Split(relativePath, string(os.PathSeparator))
-
-				// Ensure that the file path has at least 3 parts: domain, version, and schema file.
-				if len(parts) < 3 {
-					return fmt.Errorf("invalid schema file structure, expected domain/version/schema.json but got: %s", relativePath)
-				}
-
-				// Extract domain, version, and schema filename from the parts.
-				// Validate that the extracted parts are non-empty.
-				domain := strings.TrimSpace(parts[0])
-				version := strings.TrimSpace(parts[1])
-				schemaFileName := strings.TrimSpace(parts[2])
-				schemaFileName = strings.TrimSuffix(schemaFileName, ".json")
-
-				if domain == "" || version == "" || schemaFileName == "" {
-					return fmt.Errorf("invalid schema file structure, one or more components are empty. Relative path: %s", relativePath)
-				}
-
-				// Construct a unique key combining domain, version, and schema name (e.g., ondc_trv10_v2.0.0_schema).
-				uniqueKey := fmt.Sprintf("%s_%s_%s", domain, version, schemaFileName)
-				// Store the compiled schema in the SchemaCache using the unique key.
-				v.schemaCache[uniqueKey] = compiledSchema
+			compileErrs = append(compileErrs, fmt.Errorf("failed to compile JSON schema from file %s: %v", e.file.rel, err))
+			v.metrics.recordCompileError(ctx, e.domain, e.version)
+			continue
+		}
+		// Construct a unique key combining domain, version, and schema name (e.g., ondc_trv10_v2.0.0_schema).
+		schemas[schemaKey(e.domain, e.version, e.endpoint)] = compiledSchema
+		if !slices.Contains(versions[e.domain], e.version) {
+			versions[e.domain] = append(versions[e.domain], e.version)
+		}
+	}
+	if len(compileErrs) > 0 {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", source, errors.Join(compileErrs...))
+	}
+	for _, vs := range versions {
+		slices.SortFunc(vs, func(a, b string) int { return compareVersions(b, a) })
+	}
+	return schemas, versions, nil
+}
+
+// schemaKey is the cache key for one endpoint's schema.
+func schemaKey(domain, version, endpoint string) string {
+	return fmt.Sprintf("%s_%s_%s", domain, version, endpoint)
+}
+
+// lookup returns the schema for an endpoint and the version directory it came
+// from. A request for version vX.Y.Z with no exact match falls back to vX.Y
+// and then to the newest loaded vX.Y.* that has the endpoint, so a patch
+// release can be served by its minor version's schemas.
+func (v *schemaValidator) lookup(domain, version, endpoint string) (*jsonschema.Schema, string, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if schema, ok := v.schemaCache[schemaKey(domain, version, endpoint)]; ok {
+		return schema, version, true
+	}
+	minor, ok := minorVersion(version)
+	if !ok {
+		return nil, "", false
+	}
+	if schema, ok := v.schemaCache[schemaKey(domain, minor, endpoint)]; ok {
+		return schema, minor, true
+	}
+	// versions is sorted newest first.
+	for _, candidate := range v.versions[domain] {
+		if !strings.HasPrefix(candidate, minor+".") {
+			continue
+		}
+		if schema, ok := v.schemaCache[schemaKey(domain, candidate, endpoint)]; ok {
+			return schema, candidate, true
+		}
+	}
+	return nil, "", false
+}
+
+// minorVersion returns the "vX.Y" a version directory name falls back to.
+func minorVersion(version string) (string, bool) {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", false
+	}
+	return parts[0] + "." + parts[1], true
+}
+
+// compareVersions orders version directory names component by component,
+// numerically where both components are numbers, so v1.1.10 > v1.1.9.
+func compareVersions(a, b string) int {
+	pa := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	pb := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		na, errA := strconv.Atoi(pa[i])
+		nb, errB := strconv.Atoi(pb[i])
+		if errA == nil && errB == nil {
+			if na != nb {
+				return cmp.Compare(na, nb)
 			}
+			continue
+		}
+		if c := strings.Compare(pa[i], pb[i]); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(pa), len(pb))
+}
+
+// watchLoop recompiles the schema tree whenever its source changes.
+func (v *schemaValidator) watchLoop(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(v.config.ReloadInterval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			v.reloadIfChanged(ctx)
 		}
-		return nil
 	}
+}
 
-	// Start processing from the root schema directory.
-	if err := processDir(schemaDir); err != nil {
-		return fmt.Errorf("failed to read schema directory: %v", err)
+// reloadIfChanged reloads the schemas if the source differs from what was
+// last loaded. A failed reload keeps serving the previous schemas.
+func (v *schemaValidator) reloadIfChanged(ctx context.Context) {
+	fingerprint, err := v.sourceFingerprint()
+	if err != nil {
+		unreadable := "unreadable: " + err.Error()
+		if v.failedFingerprint != unreadable {
+			log.Errorf(ctx, err, "Failed to check schema source for changes — serving previously loaded schemas")
+			v.metrics.recordLoad(ctx, "failure", "reload")
+		}
+		v.failedFingerprint = unreadable
+		return
 	}
+	if fingerprint == v.fingerprint || fingerprint == v.failedFingerprint {
+		return
+	}
+	if err := v.load(ctx, "reload"); err != nil {
+		log.Errorf(ctx, err, "Failed to reload schemas — serving previously loaded schemas")
+		v.failedFingerprint = fingerprint
+		return
+	}
+	v.fingerprint = fingerprint
+	v.failedFingerprint = ""
+	log.Infof(ctx, "Reloaded schemas from %s", v.sourcePath())
+}
 
-	return nil
+// sourcePath is the configured schema directory or archive.
+func (v *schemaValidator) sourcePath() string {
+	if v.config.SchemaArchive != "" {
+		return v.config.SchemaArchive
+	}
+	return v.config.SchemaDir
 }
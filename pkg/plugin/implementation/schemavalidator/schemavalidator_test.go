@@ -1,6 +1,7 @@
 package schemavalidator
 
 import (
+	"archive/zip"
 	"context"
 	"errors"
 	"net/url"
@@ -108,7 +109,7 @@ func TestValidator_Validate_Failure(t *testing.T) {
 			name:           "Schema validation failure",
 			endpointAction: "endpoint",
 			payload:        `{"context": {"domain": "example", "version": "1.0"}}`,
-			wantErr:        "context: at '/context': missing property 'action'",
+			wantErr:        "/context/action: missing property 'action'",
 		},
 		{
 			name:           "Schema not found",
@@ -151,8 +152,7 @@ func TestValidator_Validate_Failure(t *testing.T) {
 }
 
 // TestValidator_Validate_SchemaErrorDetails confirms Validate() attaches
-// Details with the JSON-schema cause's path through the real code path
-// (extractSchemaErrors is inlined here, not a separately-testable helper).
+// Details with the JSON-schema cause's path through the real code path.
 // Note: setupTestSchema's schema only requires "context" at the root, so a
 // root-level (empty-path) cause never occurs for THIS test's schema — that is
 // not a general property of Validate() itself. Validate() never independently
@@ -193,6 +193,35 @@ func TestValidator_Validate_SchemaErrorDetails(t *testing.T) {
 	}
 }
 
+// TestValidator_Validate_ShapedErrors confirms every missing property is
+// reported at its own RFC 6901 pointer with the SCH_* code for its keyword.
+func TestValidator_Validate_ShapedErrors(t *testing.T) {
+	schemaDir := setupTestSchema(t)
+	defer os.RemoveAll(schemaDir)
+
+	v, _, err := New(context.Background(), &Config{SchemaDir: schemaDir})
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+
+	err = v.Validate(context.Background(), &url.URL{Path: "endpoint"}, []byte(`{"context": {"domain": "example", "version": "1.0"}}`))
+
+	var schemaErr *model.SchemaValidationErr
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("expected *model.SchemaValidationErr, got %T: %v", err, err)
+	}
+	if len(schemaErr.Errors) != 1 {
+		t.Fatalf("expected one error, got %+v", schemaErr.Errors)
+	}
+	got := schemaErr.Errors[0]
+	if got.Code != "SCH_REQUIRED_FIELD_MISSING" {
+		t.Errorf("Code = %s, want SCH_REQUIRED_FIELD_MISSING", got.Code)
+	}
+	if got.Details == nil || got.Details.Path != "/context/action" {
+		t.Errorf("Details = %+v, want Path=/context/action", got.Details)
+	}
+}
+
 func TestValidator_Initialise(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -394,3 +423,207 @@ func TestValidatorNewFailure(t *testing.T) {
 		})
 	}
 }
+
+// writeSchema writes a schema requiring the given top-level properties at
+// rel under dir.
+func writeSchema(t *testing.T, dir, rel string, required ...string) {
+	t.Helper()
+	p := filepath.Join(dir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	quoted := make([]string, len(required))
+	for i, r := range required {
+		quoted[i] = `"` + r + `"`
+	}
+	schema := `{"type": "object", "required": [` + strings.Join(quoted, ", ") + `]}`
+	if err := os.WriteFile(p, []byte(schema), 0644); err != nil {
+		t.Fatalf("Failed to write schema file: %v", err)
+	}
+}
+
+func TestValidator_Validate_VersionFallback(t *testing.T) {
+	schemaDir := t.TempDir()
+	writeSchema(t, schemaDir, "example/v1.1/search.json", "context", "v1.1")
+	writeSchema(t, schemaDir, "example/v1.2.0/search.json", "context", "v1.2.0")
+	writeSchema(t, schemaDir, "example/v1.2.10/search.json", "context", "v1.2.10")
+	writeSchema(t, schemaDir, "example/v1.2.9/search.json", "context", "v1.2.9")
+	writeSchema(t, schemaDir, "example/v1.2.11/select.json", "context")
+
+	v, _, err := New(context.Background(), &Config{SchemaDir: schemaDir})
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+
+	tests := []struct {
+		version string
+		want    string // the version whose schema is used, "" for none
+	}{
+		{"1.1", "v1.1"},
+		{"1.1.4", "v1.1"},
+		{"1.2.9", "v1.2.9"},
+		{"1.2", "v1.2.10"},
+		{"1.2.12", "v1.2.10"},
+		{"1.3.0", ""},
+		{"2", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			payload := []byte(`{"context": {"domain": "example", "version": "` + tt.version + `"}}`)
+			err := v.Validate(context.Background(), &url.URL{Path: "search"}, payload)
+			if tt.want == "" {
+				if err == nil || !strings.Contains(err.Error(), "schema not found for domain: example") {
+					t.Fatalf("Validate() error = %v, want schema not found", err)
+				}
+				return
+			}
+			// Each schema requires a property named after its version, so the
+			// missing property identifies the schema that was used.
+			if err == nil || !strings.Contains(err.Error(), "/"+tt.want+":") {
+				t.Errorf("Validate() error = %v, want the %s schema's failure", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReloadIfChanged_SwapsSchemas(t *testing.T) {
+	schemaDir := setupTestSchema(t)
+	defer os.RemoveAll(schemaDir)
+
+	v, _, err := New(context.Background(), &Config{SchemaDir: schemaDir})
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+	payload := []byte(`{"context": {"domain": "example", "version": "1.0", "action": "endpoint"}}`)
+	if err := v.Validate(context.Background(), &url.URL{Path: "endpoint"}, payload); err != nil {
+		t.Fatalf("Validate() before reload = %v, want nil", err)
+	}
+
+	writeSchema(t, schemaDir, "example/v1.0/endpoint.json", "context", "message")
+	writeSchema(t, schemaDir, "example/v1.0/select.json", "context")
+	v.reloadIfChanged(context.Background())
+
+	err = v.Validate(context.Background(), &url.URL{Path: "endpoint"}, payload)
+	if err == nil || !strings.Contains(err.Error(), "/message:") {
+		t.Errorf("Validate() after reload = %v, want the reloaded schema's missing message", err)
+	}
+	if err := v.Validate(context.Background(), &url.URL{Path: "select"}, payload); err != nil {
+		t.Errorf("Validate() against added schema = %v, want nil", err)
+	}
+}
+
+func TestReloadIfChanged_KeepsSchemasOnFailure(t *testing.T) {
+	schemaDir := setupTestSchema(t)
+	defer os.RemoveAll(schemaDir)
+
+	v, _, err := New(context.Background(), &Config{SchemaDir: schemaDir})
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+	writeSchema(t, schemaDir, "example/v1.0/select.json", "context")
+	broken := filepath.Join(schemaDir, "example", "v1.0", "endpoint.json")
+	if err := os.WriteFile(broken, []byte(`{invalid json}`), 0644); err != nil {
+		t.Fatalf("Failed to write schema file: %v", err)
+	}
+	v.reloadIfChanged(context.Background())
+
+	payload := []byte(`{"context": {"domain": "example", "version": "1.0", "action": "endpoint"}}`)
+	if err := v.Validate(context.Background(), &url.URL{Path: "endpoint"}, payload); err != nil {
+		t.Errorf("Validate() after failed reload = %v, want the previous schema to still pass", err)
+	}
+	if err := v.Validate(context.Background(), &url.URL{Path: "select"}, payload); err == nil {
+		t.Error("Validate() against a schema from the failed reload succeeded, want schema not found")
+	}
+	if v.failedFingerprint == "" {
+		t.Error("failedFingerprint not recorded, the broken tree would be recompiled on every tick")
+	}
+}
+
+func TestInitialise_ReportsAllCompileErrors(t *testing.T) {
+	schemaDir := t.TempDir()
+	for _, rel := range []string{"a/v1.0/search.json", "b/v1.0/select.json"} {
+		p := filepath.Join(schemaDir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(`{"type": 7}`), 0644); err != nil {
+			t.Fatalf("Failed to write schema file: %v", err)
+		}
+	}
+	v := &schemaValidator{config: &Config{SchemaDir: schemaDir}}
+
+	err := v.initialise()
+	if err == nil {
+		t.Fatal("initialise() = nil, want compile errors")
+	}
+	for _, want := range []string{"a/v1.0/search.json", "b/v1.0/select.json"} {
+		if !strings.Contains(err.Error(), "failed to compile JSON schema from file "+want) {
+			t.Errorf("initialise() error = %v, want a compile error for %s", err, want)
+		}
+	}
+}
+
+// writeArchive writes a zip holding the given entries to a temp file.
+func writeArchive(t *testing.T, entries map[string]string) string {
+	t.Helper()
+	archive := filepath.Join(t.TempDir(), "schemas.zip")
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatalf("Failed to create archive: %v", err)
+	}
+	zw := zip.NewWriter(f)
+	for name, content := range entries {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to add %s: %v", name, err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Failed to close archive: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Failed to close archive: %v", err)
+	}
+	return archive
+}
+
+func TestNew_SchemaArchive(t *testing.T) {
+	archive := writeArchive(t, map[string]string{
+		"schemas/example/v1.0/search.json":            `{"type": "object", "required": ["context", "message"]}`,
+		"schemas/example/v1.0/select.json":            `{"$ref": "search.json"}`,
+		"schemas/.DS_Store":                           "junk",
+		"__MACOSX/schemas/example/v1.0/._search.json": "junk",
+	})
+
+	v, _, err := New(context.Background(), &Config{SchemaArchive: archive})
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+	payload := []byte(`{"context": {"domain": "example", "version": "1.0"}}`)
+	for _, endpoint := range []string{"search", "select"} {
+		err := v.Validate(context.Background(), &url.URL{Path: endpoint}, payload)
+		if err == nil || !strings.Contains(err.Error(), "/message:") {
+			t.Errorf("Validate(%s) = %v, want the archived schema's missing message", endpoint, err)
+		}
+	}
+}
+
+func TestReadSchemaArchive_RejectsEscapingPaths(t *testing.T) {
+	archive := writeArchive(t, map[string]string{
+		"../example/v1.0/search.json": `{}`,
+	})
+	if _, err := readSchemaArchive(archive); err == nil || !strings.Contains(err.Error(), "invalid entry path") {
+		t.Errorf("readSchemaArchive() error = %v, want invalid entry path", err)
+	}
+}
+
+func TestNew_RequiresOneSource(t *testing.T) {
+	for _, config := range []*Config{{}, {SchemaDir: "a", SchemaArchive: "b.zip"}} {
+		if _, _, err := New(context.Background(), config); err == nil || !strings.Contains(err.Error(), "exactly one of SchemaDir and SchemaArchive") {
+			t.Errorf("New(%+v) error = %v, want exactly one source", config, err)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package schemavalidator
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// maxArchiveFileBytes bounds each schema file read from a zip archive.
+const maxArchiveFileBytes = 16 * 1024 * 1024 // 16 MB
+
+// schemaFile is one JSON schema read from the schema source.
+type schemaFile struct {
+	// rel is the file's path within the domain tree, slash-separated:
+	// domain/version/endpoint.json.
+	rel string
+	// url is the resource URL the schema is compiled under. Relative $refs
+	// between schemas resolve against it.
+	url  string
+	data []byte
+}
+
+// readSchemaDir reads every .json file under dir.
+func readSchemaDir(dir string) ([]schemaFile, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("schema directory does not exist: %s", dir)
+		}
+		return nil, fmt.Errorf("failed to access schema directory: %v", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("provided schema path is not a directory: %s", dir)
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access schema directory: %v", err)
+	}
+
+	var files []schemaFile
+	var processDir func(dir string) error
+	processDir = func(dir string) error {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return fmt.Errorf("failed to read directory: %v", err)
+		}
+		for _, entry := range entries {
+			p := filepath.Join(dir, entry.Name())
+			if entry.IsDir() {
+				if err := processDir(p); err != nil {
+					return err
+				}
+				continue
+			}
+			if filepath.Ext(entry.Name()) != ".json" {
+				continue
+			}
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return fmt.Errorf("failed to read schema file %s: %v", entry.Name(), err)
+			}
+			rel, err := filepath.Rel(absDir, p)
+			if err != nil {
+				return fmt.Errorf("failed to get relative path for file %s: %v", entry.Name(), err)
+			}
+			files = append(files, schemaFile{
+				rel:  filepath.ToSlash(rel),
+				url:  "file://" + filepath.ToSlash(p),
+				data: data,
+			})
+		}
+		return nil
+	}
+	if err := processDir(absDir); err != nil {
+		return nil, fmt.Errorf("failed to read schema directory: %v", err)
+	}
+	return files, nil
+}
+
+// readSchemaArchive reads every .json file in a zip archive of the domain
+// tree. Archives made by zipping the tree's own directory (schemas.zip holds
+// schemas/<domain>/...) are accepted as-is: a single top-level directory
+// shared by every file is stripped. macOS resource forks (__MACOSX/) and
+// dotfiles are ignored.
+func readSchemaArchive(archive string) ([]schemaFile, error) {
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("schema archive does not exist: %s", archive)
+		}
+		return nil, fmt.Errorf("failed to open schema archive %s: %v", archive, err)
+	}
+	defer zr.Close()
+	absArchive, err := filepath.Abs(archive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open schema archive %s: %v", archive, err)
+	}
+
+	var files []schemaFile
+	for _, f := range zr.File {
+		name := f.Name
+		if f.FileInfo().IsDir() || strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(path.Base(name), ".") || path.Ext(name) != ".json" {
+			continue
+		}
+		if !fs.ValidPath(name) {
+			return nil, fmt.Errorf("schema archive %s: invalid entry path %q", archive, name)
+		}
+		data, err := readArchiveFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("schema archive %s: %v", archive, err)
+		}
+		files = append(files, schemaFile{rel: name, data: data})
+	}
+
+	if root := commonRoot(files); root != "" {
+		for i := range files {
+			files[i].rel = strings.TrimPrefix(files[i].rel, root+"/")
+		}
+	}
+	for i := range files {
+		files[i].url = "file://" + filepath.ToSlash(absArchive) + "/" + files[i].rel
+	}
+	return files, nil
+}
+
+// readArchiveFile reads one zip entry, bounded by maxArchiveFileBytes.
+func readArchiveFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", f.Name, err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxArchiveFileBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", f.Name, err)
+	}
+	if len(data) > maxArchiveFileBytes {
+		return nil, fmt.Errorf("%s exceeds %d bytes", f.Name, maxArchiveFileBytes)
+	}
+	return data, nil
+}
+
+// commonRoot returns the top-level directory every file sits under when
+// stripping it still leaves domain/version/endpoint.json paths, or "".
+func commonRoot(files []schemaFile) string {
+	if len(files) == 0 {
+		return ""
+	}
+	root, _, ok := strings.Cut(files[0].rel, "/")
+	if !ok {
+		return ""
+	}
+	for _, f := range files {
+		if !strings.HasPrefix(f.rel, root+"/") || strings.Count(f.rel, "/") < 3 {
+			return ""
+		}
+	}
+	return root
+}
+
+// sourceFingerprint summarises the schema source's file names, sizes and
+// modification times, so the watcher can tell when to recompile without
+// reading every file.
+func (v *schemaValidator) sourceFingerprint() (string, error) {
+	h := sha256.New()
+	if v.config.SchemaArchive != "" {
+		info, err := os.Stat(v.config.SchemaArchive)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%d %d", info.Size(), info.ModTime().UnixNano())
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+
+	var lines []string
+	err := filepath.WalkDir(v.config.SchemaDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(p) != ".json" {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		lines = append(lines, fmt.Sprintf("%s %d %d", p, info.Size(), info.ModTime().UnixNano()))
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(lines)
+	for _, line := range lines {
+		fmt.Fprintln(h, line)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,393 @@
+package schemaversionmediator
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+	"github.com/beckn/catalog-core/pkg/security/artifactverifier"
+)
+
+// Local mirror sidecar suffixes. A mirrored artifact at <file> may carry its
+// detached signature at <file>.sig and its Content-Type at
+// <file>.content-type; artifacts without a content-type sidecar are JSONata.
+const (
+	signatureSuffix   = ".sig"
+	contentTypeSuffix = ".content-type"
+)
+
+// verifyDetachedArtifact is a package-level variable so tests can substitute
+// the signature check. Matches httpClientFunc's pattern.
+var verifyDetachedArtifact = artifactverifier.VerifyDetachedArtifact
+
+// artifactSourceConfig holds the config keys that control where translation
+// artifacts come from and how they are trusted.
+type artifactSourceConfig struct {
+	// Dir is the local artifact mirror checked before the artifact host.
+	Dir string
+	// Verify requires a valid detached signature on every artifact.
+	Verify bool
+	// PublicKeyURL is the signing key lookup URL used for verification. When
+	// empty, the key the manifest loader verified NetworkID's manifest with
+	// is used.
+	PublicKeyURL string
+	NetworkID    string
+	// PrefetchSubscribers lists the counterparties whose manifests are loaded
+	// at startup so every artifact between them and this node is fetched
+	// before the first request needs it.
+	PrefetchSubscribers []string
+}
+
+// loadArtifactSourceConfig parses the artifact source keys from the plugin
+// config map: artifactDir, verifyArtifacts, artifactPublicKeyLookupUrl,
+// networkId and prefetchSubscribers.
+func loadArtifactSourceConfig(config map[string]string) (artifactSourceConfig, error) {
+	cfg := artifactSourceConfig{
+		Dir:          strings.TrimSpace(config["artifactDir"]),
+		PublicKeyURL: strings.TrimSpace(config["artifactPublicKeyLookupUrl"]),
+		NetworkID:    strings.TrimSpace(config["networkId"]),
+	}
+	if cfg.Dir != "" {
+		info, err := os.Stat(cfg.Dir)
+		if err != nil {
+			return artifactSourceConfig{}, fmt.Errorf("schemaversionmediator: invalid artifactDir %q: %w", cfg.Dir, err)
+		}
+		if !info.IsDir() {
+			return artifactSourceConfig{}, fmt.Errorf("schemaversionmediator: invalid artifactDir %q: not a directory", cfg.Dir)
+		}
+	}
+	if v, ok := config["verifyArtifacts"]; ok {
+		verify, err := strconv.ParseBool(v)
+		if err != nil {
+			return artifactSourceConfig{}, fmt.Errorf("schemaversionmediator: invalid verifyArtifacts %q: must be true or false", v)
+		}
+		cfg.Verify = verify
+	}
+	if cfg.Verify && cfg.PublicKeyURL == "" && cfg.NetworkID == "" {
+		return artifactSourceConfig{}, fmt.Errorf("schemaversionmediator: verifyArtifacts requires artifactPublicKeyLookupUrl or networkId")
+	}
+	for _, id := range strings.Split(config["prefetchSubscribers"], ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			cfg.PrefetchSubscribers = append(cfg.PrefetchSubscribers, id)
+		}
+	}
+	return cfg, nil
+}
+
+// artifactDigest returns the hex SHA-256 digest of an artifact's content.
+func artifactDigest(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// mirrorPath maps an artifact URL to its location in the local mirror:
+// {artifactDir}/{host}/{path}. The URL path is cleaned as an absolute path
+// first so it cannot climb out of the mirror.
+func mirrorPath(dir, artifactURL string) (string, error) {
+	u, err := url.Parse(artifactURL)
+	if err != nil {
+		return "", fmt.Errorf("schemaversionmediator: invalid artifact URL %q: %w", artifactURL, err)
+	}
+	if u.Host == "" || u.Host == "." || u.Host == ".." || strings.ContainsAny(u.Host, `/\`) {
+		return "", fmt.Errorf("schemaversionmediator: artifact URL %q has no usable host", artifactURL)
+	}
+	return filepath.Join(dir, u.Host, filepath.FromSlash(path.Clean("/"+u.Path))), nil
+}
+
+// loadMirroredArtifact reads the artifact for artifactURL from the local
+// mirror. It returns a nil artifact and no error when the mirror is not
+// configured or does not hold the artifact, so the caller falls back to the
+// artifact host. signature is nil when the mirror holds none.
+func (m *mediator) loadMirroredArtifact(artifactURL string) (artifact *TranslationArtifact, signature []byte, err error) {
+	if m.artifactDir == "" {
+		return nil, nil, nil
+	}
+	file, err := mirrorPath(m.artifactDir, artifactURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	content, err := readMirrorFile(file)
+	if err != nil || content == nil {
+		return nil, nil, err
+	}
+	if len(content) == 0 {
+		return nil, nil, fmt.Errorf("schemaversionmediator: mirrored artifact %q is empty", file)
+	}
+	contentType := contentTypeJSONata
+	if raw, err := readMirrorFile(file + contentTypeSuffix); err != nil {
+		return nil, nil, err
+	} else if raw != nil {
+		contentType = strings.TrimSpace(string(raw))
+	}
+	if signature, err = readMirrorFile(file + signatureSuffix); err != nil {
+		return nil, nil, err
+	}
+	return &TranslationArtifact{Content: content, ContentType: contentType}, signature, nil
+}
+
+// readMirrorFile reads a mirror file, returning nil content and no error
+// when the file does not exist.
+func readMirrorFile(file string) ([]byte, error) {
+	f, err := os.Open(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("schemaversionmediator: read mirrored artifact %q: %w", file, err)
+	}
+	defer f.Close()
+	body, err := io.ReadAll(io.LimitReader(f, maxArtifactBodySize+1))
+	if err != nil {
+		return nil, fmt.Errorf("schemaversionmediator: read mirrored artifact %q: %w", file, err)
+	}
+	if len(body) > maxArtifactBodySize {
+		return nil, fmt.Errorf("schemaversionmediator: mirrored artifact %q exceeds %d bytes", file, maxArtifactBodySize)
+	}
+	return body, nil
+}
+
+// signedContentType infers a verified artifact's format from its content,
+// since a detached signature does not cover the Content-Type it was served
+// with: a JSON array is a JSON Patch, a JSON object with "mappings" a field
+// mapping, and anything else a JSONata expression. A JSONata expression that
+// is also valid JSON has no paths in it and could only produce a constant,
+// so it is never a translation.
+func signedContentType(content []byte) string {
+	var doc interface{}
+	if json.Unmarshal(content, &doc) == nil {
+		switch v := doc.(type) {
+		case []interface{}:
+			return contentTypeJSONPatch
+		case map[string]interface{}:
+			if _, ok := v["mappings"]; ok {
+				return contentTypeFieldMapping
+			}
+		}
+	}
+	return contentTypeJSONata
+}
+
+// Signing key lifetimes. A resolved key is used for signingKeyTTL, then
+// resolved again so a rotated NFO key is picked up. A signature that does not
+// verify also re-resolves the key, at most once per signingKeyRetryAfter so
+// forged artifacts cannot make every request refetch it.
+const (
+	signingKeyTTL        = time.Hour
+	signingKeyRetryAfter = time.Minute
+)
+
+// artifactVerifier checks translation artifacts against detached signatures
+// made with the NFO signing key. The key is resolved on first use and again
+// once it is signingKeyTTL old or a signature fails against it; a failed
+// resolution is retried on the next artifact.
+type artifactVerifier struct {
+	publicKeyURL string
+	networkID    string
+	loader       definition.ManifestLoader
+	httpClient   *http.Client
+	now          func() time.Time // nil means time.Now
+
+	mu         sync.Mutex
+	publicKey  []byte
+	resolvedAt time.Time
+}
+
+// verify checks signature against content. A nil signature is fetched from
+// artifactURL + ".sig".
+func (v *artifactVerifier) verify(ctx context.Context, artifactURL string, content, signature []byte) error {
+	if signature == nil {
+		var err error
+		if signature, err = httpGet(ctx, v.httpClient, artifactURL+signatureSuffix); err != nil {
+			return fmt.Errorf("schemaversionmediator: fetch artifact signature: %w", err)
+		}
+	}
+	publicKey, err := v.key(ctx)
+	if err != nil {
+		return err
+	}
+	err = verifyDetachedArtifact(content, signature, publicKey)
+	if err != nil {
+		if rotated, ok := v.rotatedKey(ctx, publicKey); ok {
+			err = verifyDetachedArtifact(content, signature, rotated)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("schemaversionmediator: artifact %q signature verification failed: %w", artifactURL, err)
+	}
+	return nil
+}
+
+// key returns the signing public key body, resolving it on first use and
+// once it is signingKeyTTL old. A key that cannot be re-resolved stays in
+// use, and resolution is retried after signingKeyRetryAfter.
+func (v *artifactVerifier) key(ctx context.Context) ([]byte, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.publicKey != nil && v.clock().Sub(v.resolvedAt) < signingKeyTTL {
+		return v.publicKey, nil
+	}
+	body, err := v.resolveLocked(ctx)
+	if err != nil {
+		if v.publicKey == nil {
+			return nil, err
+		}
+		log.Warnf(ctx, "schemaversionmediator: keeping the current artifact signing key: %v", err)
+		v.resolvedAt = v.clock().Add(signingKeyRetryAfter - signingKeyTTL)
+		return v.publicKey, nil
+	}
+	return body, nil
+}
+
+// rotatedKey re-resolves the signing key after a signature failed to verify
+// against failed, in case the NFO has rotated it. ok is false when there is
+// no other key to try: the key was resolved too recently, could not be
+// resolved, or has not changed.
+func (v *artifactVerifier) rotatedKey(ctx context.Context, failed []byte) (key []byte, ok bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if !bytes.Equal(v.publicKey, failed) {
+		return v.publicKey, v.publicKey != nil // already re-resolved by another request
+	}
+	if v.clock().Sub(v.resolvedAt) < signingKeyRetryAfter {
+		return nil, false
+	}
+	body, err := v.resolveLocked(ctx)
+	if err != nil {
+		log.Warnf(ctx, "schemaversionmediator: re-resolving the artifact signing key: %v", err)
+		v.resolvedAt = v.clock()
+		return nil, false
+	}
+	return body, !bytes.Equal(body, failed)
+}
+
+// resolveLocked fetches the signing key and records it. v.mu must be held.
+func (v *artifactVerifier) resolveLocked(ctx context.Context) ([]byte, error) {
+	keyURL := v.publicKeyURL
+	if keyURL == "" {
+		doc, err := v.loader.GetByNetworkID(ctx, v.networkID)
+		if err != nil {
+			return nil, fmt.Errorf("schemaversionmediator: resolve artifact signing key: network manifest %q: %w", v.networkID, err)
+		}
+		if doc == nil || doc.SigningPublicKeyURL == "" {
+			return nil, fmt.Errorf("schemaversionmediator: resolve artifact signing key: network manifest %q carries no signing key URL", v.networkID)
+		}
+		keyURL = doc.SigningPublicKeyURL
+	}
+	body, err := httpGet(ctx, v.httpClient, keyURL)
+	if err != nil {
+		return nil, fmt.Errorf("schemaversionmediator: fetch artifact signing key: %w", err)
+	}
+	v.publicKey, v.resolvedAt = body, v.clock()
+	return body, nil
+}
+
+func (v *artifactVerifier) clock() time.Time {
+	if v.now != nil {
+		return v.now()
+	}
+	return time.Now()
+}
+
+// httpGet fetches a small supporting resource (signature or key) that must
+// be present: any non-200 status is an error.
+func httpGet(ctx context.Context, client *http.Client, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%q: unexpected status %d", rawURL, resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxArtifactBodySize))
+	if err != nil {
+		return nil, fmt.Errorf("%q: %w", rawURL, err)
+	}
+	return body, nil
+}
+
+// referencedArtifacts returns the artifact URLs that translate objects of
+// from's supported versions into to's canonical versions: one per shared
+// type and per version from supports that to does not.
+func referencedArtifacts(from, to *model.NodeManifest) []string {
+	fromVersions := make(map[string][]string, len(from.Schema.SchemaObjects))
+	for _, obj := range from.Schema.SchemaObjects {
+		fromVersions[obj.Type] = append(fromVersions[obj.Type], obj.SupportedVersions...)
+	}
+	var urls []string
+	for i := range to.Schema.SchemaObjects {
+		obj := &to.Schema.SchemaObjects[i]
+		canonical, err := obj.CanonicalVersion(to.Schema.DefaultVersionPolicy)
+		if err != nil {
+			continue
+		}
+		for _, v := range fromVersions[obj.Type] {
+			if !containsVersion(obj.SupportedVersions, v) {
+				urls = append(urls, buildArtifactURL(obj.BaseURL, canonical, obj.Type, v))
+			}
+		}
+	}
+	return urls
+}
+
+// prefetchArtifacts loads the manifests of subscriberIDs and fetches every
+// artifact that translates between them and the local manifest, in both
+// directions, into the artifact cache. Failures are logged and counted; they
+// do not stop the prefetch and are retried on demand by Mediate.
+func (m *mediator) prefetchArtifacts(ctx context.Context, subscriberIDs []string) (fetched, failed int) {
+	seen := make(map[string]bool)
+	for _, id := range subscriberIDs {
+		if ctx.Err() != nil {
+			break
+		}
+		doc, err := m.loader.GetBySubscriberID(ctx, id)
+		if err != nil || doc == nil {
+			log.Warnf(ctx, "schemaversionmediator: prefetch manifestUnavailable subscriber=%q reason=%v", id, err)
+			failed++
+			continue
+		}
+		peer, err := parseNodeManifest(doc)
+		if err != nil {
+			log.Warnf(ctx, "schemaversionmediator: prefetch manifestInvalid subscriber=%q reason=%v", id, err)
+			failed++
+			continue
+		}
+		urls := append(referencedArtifacts(peer, m.localManifest), referencedArtifacts(m.localManifest, peer)...)
+		for _, u := range urls {
+			if seen[u] || ctx.Err() != nil {
+				continue
+			}
+			seen[u] = true
+			if _, err := m.fetchArtifactByURL(ctx, u); err != nil {
+				log.Warnf(ctx, "schemaversionmediator: prefetch artifactFetchFailed subscriber=%q url=%q reason=%v", id, u, err)
+				failed++
+				continue
+			}
+			fetched++
+		}
+	}
+	log.Infof(ctx, "schemaversionmediator: prefetch complete subscribers=%d fetched=%d failed=%d", len(subscriberIDs), fetched, failed)
+	return fetched, failed
+}
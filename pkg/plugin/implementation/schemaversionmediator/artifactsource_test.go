@@ -0,0 +1,420 @@
+package schemaversionmediator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+)
+
+// stubVerifyDetachedArtifact replaces the signature check for the duration of
+// a test: a signature is valid when it equals "sig:" + content.
+func stubVerifyDetachedArtifact(t *testing.T) {
+	t.Helper()
+	orig := verifyDetachedArtifact
+	verifyDetachedArtifact = func(payload, signature, publicKey []byte) error {
+		if string(publicKey) != "nfo-key" {
+			return errors.New("unknown key")
+		}
+		if !bytes.Equal(signature, append([]byte("sig:"), payload...)) {
+			return errors.New("signature mismatch")
+		}
+		return nil
+	}
+	t.Cleanup(func() { verifyDetachedArtifact = orig })
+}
+
+// writeMirrorFile writes content to the mirror location of artifactURL plus suffix.
+func writeMirrorFile(t *testing.T, dir, artifactURL, suffix, content string) {
+	t.Helper()
+	file, err := mirrorPath(dir, artifactURL)
+	if err != nil {
+		t.Fatalf("mirrorPath: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file+suffix, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newSourceTestMediator(client *http.Client, dir string) *mediator {
+	return &mediator{
+		httpClient:  client,
+		cache:       newArtifactCache(defaultPositiveTTL, defaultNegativeTTL, defaultMaxCacheEntries),
+		artifactDir: dir,
+	}
+}
+
+// --- loadArtifactSourceConfig tests ---
+
+func TestLoadArtifactSourceConfig_Defaults(t *testing.T) {
+	cfg, err := loadArtifactSourceConfig(map[string]string{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Dir != "" || cfg.Verify || len(cfg.PrefetchSubscribers) != 0 {
+		t.Errorf("unexpected defaults: %+v", cfg)
+	}
+}
+
+func TestLoadArtifactSourceConfig_Valid(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := loadArtifactSourceConfig(map[string]string{
+		"artifactDir":         dir,
+		"verifyArtifacts":     "true",
+		"networkId":           "nfh.global/subscribers.beckn.one",
+		"prefetchSubscribers": " a/b/c , ,d/e/f",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Dir != dir || !cfg.Verify || cfg.NetworkID != "nfh.global/subscribers.beckn.one" {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	if len(cfg.PrefetchSubscribers) != 2 || cfg.PrefetchSubscribers[0] != "a/b/c" || cfg.PrefetchSubscribers[1] != "d/e/f" {
+		t.Errorf("unexpected prefetchSubscribers: %v", cfg.PrefetchSubscribers)
+	}
+}
+
+func TestLoadArtifactSourceConfig_Invalid(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]map[string]string{
+		"missing dir":        {"artifactDir": filepath.Join(t.TempDir(), "absent")},
+		"dir is a file":      {"artifactDir": file},
+		"bad verify flag":    {"verifyArtifacts": "sometimes"},
+		"verify without key": {"verifyArtifacts": "true"},
+	}
+	for name, c := range cases {
+		if _, err := loadArtifactSourceConfig(c); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestNew_VerifyArtifactsWithoutKeyRejected(t *testing.T) {
+	_, _, err := New(context.Background(), &mockManifestLoader{}, map[string]string{"verifyArtifacts": "true"})
+	if err == nil {
+		t.Fatal("expected error when verifyArtifacts has no key source")
+	}
+}
+
+// --- Local mirror tests ---
+
+func TestMirrorPath_StaysInsideMirror(t *testing.T) {
+	dir := t.TempDir()
+	got, err := mirrorPath(dir, "https://schema.beckn.io/retail/../../../etc/passwd")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(got, filepath.Join(dir, "schema.beckn.io")+string(filepath.Separator)) {
+		t.Errorf("path %q escapes the mirror", got)
+	}
+	if _, err := mirrorPath(dir, "/relative/only.jsonata"); err == nil {
+		t.Error("expected error for URL without host")
+	}
+}
+
+func TestFetchArtifact_MirrorHitSkipsHost(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	url := srv.URL + "/retail/v2.0/Order_from_v1.1.jsonata"
+	writeMirrorFile(t, dir, url, "", `{"mappings":[{"op":"rename","from":"a","to":"b"}]}`)
+	writeMirrorFile(t, dir, url, contentTypeSuffix, contentTypeFieldMapping+"\n")
+
+	m := newSourceTestMediator(srv.Client(), dir)
+	got, err := m.fetchArtifactByURL(context.Background(), url)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.ContentType != contentTypeFieldMapping {
+		t.Errorf("ContentType = %q, want %q", got.ContentType, contentTypeFieldMapping)
+	}
+	if atomic.LoadInt32(&calls) != 0 {
+		t.Errorf("artifact host called %d times for a mirrored artifact", calls)
+	}
+}
+
+func TestFetchArtifact_MirrorDefaultsToJSONata(t *testing.T) {
+	dir := t.TempDir()
+	url := "https://schema.beckn.io/retail/v2.0/Order_from_v1.1.jsonata"
+	writeMirrorFile(t, dir, url, "", `$`)
+
+	got, err := newSourceTestMediator(&http.Client{}, dir).fetchArtifactByURL(context.Background(), url)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.ContentType != contentTypeJSONata {
+		t.Errorf("ContentType = %q, want %q", got.ContentType, contentTypeJSONata)
+	}
+}
+
+func TestFetchArtifact_MirrorMissFallsBackToHost(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentTypeJSONata)
+		w.Write([]byte(`$.id`))
+	}))
+	defer srv.Close()
+
+	m := newSourceTestMediator(srv.Client(), t.TempDir())
+	got, err := m.fetchArtifactByURL(context.Background(), srv.URL+"/retail/v2.0/Order_from_v1.1.jsonata")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(got.Content) != `$.id` {
+		t.Errorf("unexpected Content: %s", got.Content)
+	}
+}
+
+// --- Signature verification tests ---
+
+func TestFetchArtifact_VerifiesMirrorSignature(t *testing.T) {
+	stubVerifyDetachedArtifact(t)
+	keySrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("nfo-key"))
+	}))
+	defer keySrv.Close()
+
+	dir := t.TempDir()
+	good := "https://schema.beckn.io/retail/v2.0/Order_from_v1.1.jsonata"
+	writeMirrorFile(t, dir, good, "", `$`)
+	writeMirrorFile(t, dir, good, signatureSuffix, `sig:$`)
+	tampered := "https://schema.beckn.io/retail/v2.0/Item_from_v1.1.jsonata"
+	writeMirrorFile(t, dir, tampered, "", `$ ~> |$|{"price": 0}|`)
+	writeMirrorFile(t, dir, tampered, signatureSuffix, `sig:$`)
+
+	m := newSourceTestMediator(keySrv.Client(), dir)
+	m.verifier = &artifactVerifier{publicKeyURL: keySrv.URL, httpClient: keySrv.Client()}
+
+	if _, err := m.fetchArtifactByURL(context.Background(), good); err != nil {
+		t.Fatalf("signed artifact rejected: %v", err)
+	}
+	if _, err := m.fetchArtifactByURL(context.Background(), tampered); err == nil {
+		t.Fatal("expected tampered artifact to be rejected")
+	}
+	if _, found := m.cache.get(tampered); found {
+		t.Error("rejected artifact must not be cached")
+	}
+}
+
+func TestFetchArtifact_VerifiesHostSignatureWithNetworkKey(t *testing.T) {
+	stubVerifyDetachedArtifact(t)
+	var keyFetches int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/keys/nfo":
+			atomic.AddInt32(&keyFetches, 1)
+			w.Write([]byte("nfo-key"))
+		case strings.HasSuffix(r.URL.Path, ".jsonata.sig"):
+			w.Write([]byte("sig:$.id"))
+		default:
+			w.Header().Set("Content-Type", contentTypeJSONata)
+			w.Write([]byte(`$.id`))
+		}
+	}))
+	defer srv.Close()
+
+	loader := &mockManifestLoader{
+		byNetworkID: func(_ context.Context, networkID string) (*model.ManifestDocument, error) {
+			if networkID != "nfh.global/subscribers.beckn.one" {
+				t.Errorf("unexpected networkID %q", networkID)
+			}
+			return &model.ManifestDocument{SigningPublicKeyURL: srv.URL + "/keys/nfo"}, nil
+		},
+	}
+	m := newSourceTestMediator(srv.Client(), "")
+	m.verifier = &artifactVerifier{networkID: "nfh.global/subscribers.beckn.one", loader: loader, httpClient: srv.Client()}
+
+	for _, url := range []string{srv.URL + "/retail/v2.0/Order_from_v1.1.jsonata", srv.URL + "/retail/v2.0/Item_from_v1.1.jsonata"} {
+		if _, err := m.fetchArtifactByURL(context.Background(), url); err != nil {
+			t.Fatalf("fetch %s: %v", url, err)
+		}
+	}
+	if n := atomic.LoadInt32(&keyFetches); n != 1 {
+		t.Errorf("signing key fetched %d times, want 1", n)
+	}
+}
+
+func TestFetchArtifact_MissingSignatureRejected(t *testing.T) {
+	stubVerifyDetachedArtifact(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".sig") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", contentTypeJSONata)
+		w.Write([]byte(`$`))
+	}))
+	defer srv.Close()
+
+	m := newSourceTestMediator(srv.Client(), "")
+	m.verifier = &artifactVerifier{publicKeyURL: srv.URL + "/key", httpClient: srv.Client()}
+	if _, err := m.fetchArtifactByURL(context.Background(), srv.URL+"/retail/v2.0/Order_from_v1.1.jsonata"); err == nil {
+		t.Fatal("expected error for artifact without signature")
+	}
+}
+
+func TestFetchArtifact_VerifiedFormatComesFromContent(t *testing.T) {
+	stubVerifyDetachedArtifact(t)
+	artifacts := map[string]string{
+		"/retail/v2.0/Order_from_v1.1.jsonata": `$.id`,
+		"/retail/v2.0/Item_from_v1.1.jsonata":  `[{"op": "remove", "path": "/code"}]`,
+		"/retail/v2.0/Offer_from_v1.1.jsonata": `{"mappings": [{"op": "rename", "from": "a", "to": "b"}]}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/key":
+			w.Write([]byte("nfo-key"))
+		case strings.HasSuffix(r.URL.Path, signatureSuffix):
+			w.Write([]byte("sig:" + artifacts[strings.TrimSuffix(r.URL.Path, signatureSuffix)]))
+		default:
+			// An unsigned header claiming another format, or none at all.
+			if r.URL.Path != "/retail/v2.0/Offer_from_v1.1.jsonata" {
+				w.Header().Set("Content-Type", contentTypeJSONata)
+			}
+			w.Write([]byte(artifacts[r.URL.Path]))
+		}
+	}))
+	defer srv.Close()
+
+	m := newSourceTestMediator(srv.Client(), "")
+	m.verifier = &artifactVerifier{publicKeyURL: srv.URL + "/key", httpClient: srv.Client()}
+	for path, want := range map[string]string{
+		"/retail/v2.0/Order_from_v1.1.jsonata": contentTypeJSONata,
+		"/retail/v2.0/Item_from_v1.1.jsonata":  contentTypeJSONPatch,
+		"/retail/v2.0/Offer_from_v1.1.jsonata": contentTypeFieldMapping,
+	} {
+		artifact, err := m.fetchArtifactByURL(context.Background(), srv.URL+path)
+		if err != nil {
+			t.Fatalf("fetch %s: %v", path, err)
+		}
+		if artifact.ContentType != want {
+			t.Errorf("%s: ContentType = %q, want %q", path, artifact.ContentType, want)
+		}
+	}
+}
+
+func TestArtifactVerifier_ReResolvesKey(t *testing.T) {
+	stubVerifyDetachedArtifact(t)
+	var key atomic.Value
+	key.Store("old-key")
+	var keyFetches int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/key" {
+			http.NotFound(w, r)
+			return
+		}
+		atomic.AddInt32(&keyFetches, 1)
+		w.Write([]byte(key.Load().(string)))
+	}))
+	defer srv.Close()
+
+	now := time.Date(2026, 3, 14, 12, 0, 0, 0, time.UTC)
+	v := &artifactVerifier{publicKeyURL: srv.URL + "/key", httpClient: srv.Client(), now: func() time.Time { return now }}
+	verify := func() error {
+		return v.verify(context.Background(), srv.URL+"/a.jsonata", []byte(`$`), []byte(`sig:$`))
+	}
+
+	if err := verify(); err == nil {
+		t.Fatal("expected the old key to fail verification")
+	}
+	key.Store("nfo-key")
+	if err := verify(); err == nil {
+		t.Fatal("a key resolved moments ago must not be re-resolved on failure")
+	}
+	now = now.Add(signingKeyRetryAfter)
+	if err := verify(); err != nil {
+		t.Fatalf("a failed signature should pick up the rotated key: %v", err)
+	}
+	if n := atomic.LoadInt32(&keyFetches); n != 2 {
+		t.Errorf("key fetched %d times, want 2", n)
+	}
+
+	// The key is resolved again once it is signingKeyTTL old.
+	now = now.Add(signingKeyTTL)
+	if err := verify(); err != nil {
+		t.Fatalf("verify after TTL: %v", err)
+	}
+	if n := atomic.LoadInt32(&keyFetches); n != 3 {
+		t.Errorf("key fetched %d times, want 3 after the TTL", n)
+	}
+
+	// A key that cannot be re-resolved stays in use.
+	srv.Config.Handler = http.NotFoundHandler()
+	now = now.Add(signingKeyTTL)
+	if err := verify(); err != nil {
+		t.Fatalf("verify with the key host down: %v", err)
+	}
+}
+
+// --- Prefetch tests ---
+
+func TestReferencedArtifacts(t *testing.T) {
+	peer := localManifestWith(
+		model.SchemaObject{Type: "Order", BaseURL: "https://peer/retail", SupportedVersions: []string{"v1.1", "v2.0"}},
+		model.SchemaObject{Type: "Rating", BaseURL: "https://peer/rating", SupportedVersions: []string{"v1.0"}},
+	)
+	local := localManifestWith(
+		model.SchemaObject{Type: "Order", BaseURL: "https://local/retail", SupportedVersions: []string{"v2.0", "v2.1"}},
+	)
+	got := referencedArtifacts(peer, local)
+	if len(got) != 1 || got[0] != "https://local/retail/v2.1/Order_from_v1.1.jsonata" {
+		t.Errorf("referencedArtifacts(peer, local) = %v", got)
+	}
+	got = referencedArtifacts(local, peer)
+	if len(got) != 1 || got[0] != "https://peer/retail/v2.0/Order_from_v2.1.jsonata" {
+		t.Errorf("referencedArtifacts(local, peer) = %v", got)
+	}
+}
+
+func TestPrefetchArtifacts_FillsCache(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentTypeJSONata)
+		w.Write([]byte(`$`))
+	}))
+	defer srv.Close()
+
+	loader := &mockManifestLoader{
+		bySubscriberID: func(_ context.Context, id string) (*model.ManifestDocument, error) {
+			if id != "peer/reg/bpp" {
+				return nil, errors.New("unknown subscriber")
+			}
+			return nodeManifestDoc(model.SchemaObject{Type: "Order", BaseURL: srv.URL + "/peer", SupportedVersions: []string{"v1.1"}}), nil
+		},
+	}
+	m := newSourceTestMediator(srv.Client(), "")
+	m.loader = loader
+	m.localManifest = localManifestWith(model.SchemaObject{Type: "Order", BaseURL: srv.URL + "/local", SupportedVersions: []string{"v2.0"}})
+
+	fetched, failed := m.prefetchArtifacts(context.Background(), []string{"peer/reg/bpp", "gone/reg/bpp"})
+	if fetched != 2 || failed != 1 {
+		t.Errorf("prefetch fetched=%d failed=%d, want 2 and 1", fetched, failed)
+	}
+	for _, url := range []string{
+		srv.URL + "/local/v2.0/Order_from_v1.1.jsonata",
+		srv.URL + "/peer/v1.1/Order_from_v2.0.jsonata",
+	} {
+		if a, found := m.cache.get(url); !found || a == nil {
+			t.Errorf("expected %s to be cached after prefetch", url)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package schemaversionmediator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Field-mapping rule operations.
+const (
+	fieldMappingRename  = "rename"
+	fieldMappingMove    = "move"
+	fieldMappingDefault = "default"
+)
+
+// fieldMapping is a declarative translation artifact: an ordered list of
+// rename, move and default rules applied to the schema object. Paths are
+// dot-notation and relative to the object, e.g.
+//
+//	{"mappings": [
+//	  {"op": "rename",  "from": "status", "to": "state"},
+//	  {"op": "move",    "from": "price.value", "to": "pricing.amount"},
+//	  {"op": "default", "to": "pricing.currency", "value": "INR"}
+//	]}
+type fieldMapping struct {
+	Mappings []fieldMappingRule `json:"mappings"`
+}
+
+// fieldMappingRule is a single rule of a fieldMapping.
+//
+//   - rename: renames the field at From to the key To, in the same parent.
+//   - move: moves the field at From to the path To, creating parents.
+//   - default: sets To to Value when To is absent.
+//
+// rename and move skip a source field the payload does not carry, and fail
+// rather than overwrite a field already at the target.
+type fieldMappingRule struct {
+	Op    string          `json:"op"`
+	From  string          `json:"from,omitempty"`
+	To    string          `json:"to"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// parseFieldMapping decodes and validates a field-mapping artifact.
+func parseFieldMapping(artifact []byte) (*fieldMapping, error) {
+	var fm fieldMapping
+	dec := json.NewDecoder(bytes.NewReader(artifact))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&fm); err != nil {
+		return nil, fmt.Errorf("schemaversionmediator: invalid field mapping: %w", err)
+	}
+	if len(fm.Mappings) == 0 {
+		return nil, fmt.Errorf("schemaversionmediator: invalid field mapping: no mappings")
+	}
+	for i, r := range fm.Mappings {
+		if r.To == "" {
+			return nil, fmt.Errorf("schemaversionmediator: invalid field mapping: rule %d (%s) has no \"to\"", i, r.Op)
+		}
+		switch r.Op {
+		case fieldMappingRename:
+			if r.From == "" {
+				return nil, fmt.Errorf("schemaversionmediator: invalid field mapping: rule %d (rename) has no \"from\"", i)
+			}
+			if strings.Contains(r.To, ".") {
+				return nil, fmt.Errorf("schemaversionmediator: invalid field mapping: rule %d (rename) \"to\" must be a key, not a path; use move", i)
+			}
+		case fieldMappingMove:
+			if r.From == "" {
+				return nil, fmt.Errorf("schemaversionmediator: invalid field mapping: rule %d (move) has no \"from\"", i)
+			}
+		case fieldMappingDefault:
+			if r.Value == nil {
+				return nil, fmt.Errorf("schemaversionmediator: invalid field mapping: rule %d (default) has no \"value\"", i)
+			}
+		default:
+			return nil, fmt.Errorf("schemaversionmediator: invalid field mapping: rule %d has unknown op %q: must be rename, move or default", i, r.Op)
+		}
+	}
+	return &fm, nil
+}
+
+// fieldMappingTranslator applies field-mapping artifacts.
+type fieldMappingTranslator struct{}
+
+// Translate applies the artifact's rules to payload in order.
+func (fieldMappingTranslator) Translate(_ context.Context, artifact []byte, payload []byte) ([]byte, error) {
+	fm, err := parseFieldMapping(artifact)
+	if err != nil {
+		return nil, err
+	}
+	var obj map[string]any
+	if err := json.Unmarshal(payload, &obj); err != nil {
+		return nil, fmt.Errorf("schemaversionmediator: field mapping applies to objects: %w", err)
+	}
+
+	for i, r := range fm.Mappings {
+		switch r.Op {
+		case fieldMappingRename, fieldMappingMove:
+			to := r.To
+			if r.Op == fieldMappingRename {
+				if dot := strings.LastIndex(r.From, "."); dot >= 0 {
+					to = r.From[:dot+1] + r.To
+				}
+			}
+			value, ok := removeDotPath(obj, r.From)
+			if !ok {
+				continue
+			}
+			if _, exists := lookupDotPath(obj, to); exists {
+				return nil, fmt.Errorf("schemaversionmediator: field mapping rule %d: %s %q to %q would overwrite an existing field", i, r.Op, r.From, to)
+			}
+			if err := putDotPath(obj, to, value); err != nil {
+				return nil, fmt.Errorf("schemaversionmediator: field mapping rule %d: %w", i, err)
+			}
+		case fieldMappingDefault:
+			if _, exists := lookupDotPath(obj, r.To); exists {
+				continue
+			}
+			var value any
+			if err := json.Unmarshal(r.Value, &value); err != nil {
+				return nil, fmt.Errorf("schemaversionmediator: field mapping rule %d: invalid value: %w", i, err)
+			}
+			if err := putDotPath(obj, r.To, value); err != nil {
+				return nil, fmt.Errorf("schemaversionmediator: field mapping rule %d: %w", i, err)
+			}
+		}
+	}
+	return json.Marshal(obj)
+}
+
+// ConsumedPaths returns the source paths of the artifact's rename and move
+// rules.
+func (fieldMappingTranslator) ConsumedPaths(artifact []byte) ([]string, error) {
+	fm, err := parseFieldMapping(artifact)
+	if err != nil {
+		return nil, err
+	}
+	var consumed []string
+	for _, r := range fm.Mappings {
+		if r.Op == fieldMappingRename || r.Op == fieldMappingMove {
+			consumed = append(consumed, r.From)
+		}
+	}
+	return consumed, nil
+}
+
+// lookupDotPath returns the value at a dot-notation path of nested objects.
+func lookupDotPath(obj map[string]any, path string) (any, bool) {
+	parts := strings.Split(path, ".")
+	cur := obj
+	for _, p := range parts[:len(parts)-1] {
+		next, ok := cur[p].(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		cur = next
+	}
+	v, ok := cur[parts[len(parts)-1]]
+	return v, ok
+}
+
+// removeDotPath deletes and returns the value at a dot-notation path.
+func removeDotPath(obj map[string]any, path string) (any, bool) {
+	parts := strings.Split(path, ".")
+	cur := obj
+	for _, p := range parts[:len(parts)-1] {
+		next, ok := cur[p].(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		cur = next
+	}
+	leaf := parts[len(parts)-1]
+	v, ok := cur[leaf]
+	if ok {
+		delete(cur, leaf)
+	}
+	return v, ok
+}
+
+// putDotPath sets the value at a dot-notation path, creating missing parent
+// objects. It fails when a parent exists but is not an object.
+func putDotPath(obj map[string]any, path string, value any) error {
+	parts := strings.Split(path, ".")
+	cur := obj
+	for i, p := range parts[:len(parts)-1] {
+		child, exists := cur[p]
+		if !exists {
+			next := make(map[string]any)
+			cur[p] = next
+			cur = next
+			continue
+		}
+		next, ok := child.(map[string]any)
+		if !ok {
+			return fmt.Errorf("cannot set %q: %q is not an object", path, strings.Join(parts[:i+1], "."))
+		}
+		cur = next
+	}
+	cur[parts[len(parts)-1]] = value
+	return nil
+}
@@ -0,0 +1,304 @@
+package schemaversionmediator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// jsonPatchOp is a single RFC 6902 operation. Value is nil when the member
+// is absent and "null" when it is JSON null.
+type jsonPatchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// parseJSONPatch decodes and validates a JSON Patch document.
+func parseJSONPatch(artifact []byte) ([]jsonPatchOp, error) {
+	var ops []jsonPatchOp
+	if err := json.Unmarshal(artifact, &ops); err != nil {
+		return nil, fmt.Errorf("schemaversionmediator: invalid JSON patch: %w", err)
+	}
+	for i, op := range ops {
+		if _, err := parsePointer(op.Path); err != nil {
+			return nil, fmt.Errorf("schemaversionmediator: invalid JSON patch: operation %d: path: %w", i, err)
+		}
+		switch op.Op {
+		case "add", "replace", "test":
+			if op.Value == nil {
+				return nil, fmt.Errorf("schemaversionmediator: invalid JSON patch: operation %d (%s) has no value", i, op.Op)
+			}
+		case "remove":
+		case "move", "copy":
+			if _, err := parsePointer(op.From); err != nil {
+				return nil, fmt.Errorf("schemaversionmediator: invalid JSON patch: operation %d: from: %w", i, err)
+			}
+			if op.Op == "move" && op.Path != op.From && (op.From == "" || strings.HasPrefix(op.Path, op.From+"/")) {
+				return nil, fmt.Errorf("schemaversionmediator: invalid JSON patch: operation %d moves %q into its own child %q", i, op.From, op.Path)
+			}
+		default:
+			return nil, fmt.Errorf("schemaversionmediator: invalid JSON patch: operation %d has unknown op %q", i, op.Op)
+		}
+	}
+	return ops, nil
+}
+
+// jsonPatchTranslator applies RFC 6902 JSON Patch artifacts.
+type jsonPatchTranslator struct{}
+
+// Translate applies the patch to payload. Operations apply in order and the
+// first failing one, including a failed test, fails the translation.
+func (jsonPatchTranslator) Translate(_ context.Context, artifact []byte, payload []byte) ([]byte, error) {
+	ops, err := parseJSONPatch(artifact)
+	if err != nil {
+		return nil, err
+	}
+	var doc any
+	if err := json.Unmarshal(payload, &doc); err != nil {
+		return nil, fmt.Errorf("schemaversionmediator: JSON patch target: %w", err)
+	}
+	for i, op := range ops {
+		if doc, err = applyPatchOp(doc, op); err != nil {
+			return nil, fmt.Errorf("schemaversionmediator: JSON patch operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return json.Marshal(doc)
+}
+
+// ConsumedPaths returns the locations the patch removes or overwrites on
+// purpose: remove and replace paths, and move sources.
+func (jsonPatchTranslator) ConsumedPaths(artifact []byte) ([]string, error) {
+	ops, err := parseJSONPatch(artifact)
+	if err != nil {
+		return nil, err
+	}
+	var consumed []string
+	for _, op := range ops {
+		pointer := op.Path
+		switch op.Op {
+		case "remove", "replace":
+		case "move":
+			pointer = op.From
+		default:
+			continue
+		}
+		tokens, _ := parsePointer(pointer)
+		consumed = append(consumed, strings.Join(tokens, "."))
+	}
+	return consumed, nil
+}
+
+func applyPatchOp(doc any, op jsonPatchOp) (any, error) {
+	path, _ := parsePointer(op.Path)
+	switch op.Op {
+	case "add":
+		return patchAdd(doc, path, decodePatchValue(op.Value))
+	case "remove":
+		doc, _, err := patchRemove(doc, path)
+		return doc, err
+	case "replace":
+		if _, err := patchGet(doc, path); err != nil {
+			return nil, err
+		}
+		if len(path) == 0 {
+			return decodePatchValue(op.Value), nil
+		}
+		doc, _, err := patchRemove(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		return patchAdd(doc, path, decodePatchValue(op.Value))
+	case "move":
+		from, _ := parsePointer(op.From)
+		doc, value, err := patchRemove(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return patchAdd(doc, path, value)
+	case "copy":
+		from, _ := parsePointer(op.From)
+		value, err := patchGet(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return patchAdd(doc, path, deepCopyJSON(value))
+	case "test":
+		value, err := patchGet(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(value, decodePatchValue(op.Value)) {
+			return nil, fmt.Errorf("test failed: value differs")
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("unknown op %q", op.Op)
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into unescaped reference
+// tokens. The empty pointer refers to the whole document.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("JSON pointer %q must start with /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func decodePatchValue(raw json.RawMessage) any {
+	var v any
+	_ = json.Unmarshal(raw, &v) // validated by json.Unmarshal of the whole patch
+	return v
+}
+
+func deepCopyJSON(v any) any {
+	b, _ := json.Marshal(v)
+	var out any
+	_ = json.Unmarshal(b, &out)
+	return out
+}
+
+// arrayIndex parses an array reference token. allowEnd admits "-" and len,
+// the positions an add may insert at.
+func arrayIndex(token string, length int, allowEnd bool) (int, error) {
+	if allowEnd && token == "-" {
+		return length, nil
+	}
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	idx, err := strconv.Atoi(token)
+	if err != nil || idx < 0 {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	last := length - 1
+	if allowEnd {
+		last = length
+	}
+	if idx > last {
+		return 0, fmt.Errorf("array index %d out of range (len %d)", idx, length)
+	}
+	return idx, nil
+}
+
+func patchGet(doc any, path []string) (any, error) {
+	cur := doc
+	for _, token := range path {
+		switch node := cur.(type) {
+		case map[string]any:
+			v, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("path member %q not found", token)
+			}
+			cur = v
+		case []any:
+			idx, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			cur = node[idx]
+		default:
+			return nil, fmt.Errorf("cannot index %T with %q", cur, token)
+		}
+	}
+	return cur, nil
+}
+
+// patchAt descends to the container holding path's last token and replaces
+// it with the result of leaf.
+func patchAt(doc any, path []string, leaf func(container any, token string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return leaf(doc, path[0])
+	}
+	token := path[0]
+	switch node := doc.(type) {
+	case map[string]any:
+		child, ok := node[token]
+		if !ok {
+			return nil, fmt.Errorf("path member %q not found", token)
+		}
+		updated, err := patchAt(child, path[1:], leaf)
+		if err != nil {
+			return nil, err
+		}
+		node[token] = updated
+		return node, nil
+	case []any:
+		idx, err := arrayIndex(token, len(node), false)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := patchAt(node[idx], path[1:], leaf)
+		if err != nil {
+			return nil, err
+		}
+		node[idx] = updated
+		return node, nil
+	default:
+		return nil, fmt.Errorf("cannot index %T with %q", doc, token)
+	}
+}
+
+func patchAdd(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return patchAt(doc, path, func(container any, token string) (any, error) {
+		switch node := container.(type) {
+		case map[string]any:
+			node[token] = value
+			return node, nil
+		case []any:
+			idx, err := arrayIndex(token, len(node), true)
+			if err != nil {
+				return nil, err
+			}
+			node = append(node, nil)
+			copy(node[idx+1:], node[idx:])
+			node[idx] = value
+			return node, nil
+		default:
+			return nil, fmt.Errorf("cannot add %q to %T", token, container)
+		}
+	})
+}
+
+func patchRemove(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("cannot remove the whole document")
+	}
+	var removed any
+	doc, err := patchAt(doc, path, func(container any, token string) (any, error) {
+		switch node := container.(type) {
+		case map[string]any:
+			v, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("path member %q not found", token)
+			}
+			removed = v
+			delete(node, token)
+			return node, nil
+		case []any:
+			idx, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			removed = node[idx]
+			return append(node[:idx], node[idx+1:]...), nil
+		default:
+			return nil, fmt.Errorf("cannot remove %q from %T", token, container)
+		}
+	})
+	return doc, removed, err
+}
@@ -244,7 +244,7 @@ var httpClientFunc = func(timeout time.Duration) *http.Client {
 
 // TranslationArtifact holds a fetched translation artifact and the Content-Type
 // returned by the server. ContentType determines which Translator implementation
-// the mediation loop dispatches to (e.g. "application/jsonata").
+// the mediation loop dispatches to (see translatorFor).
 type TranslationArtifact struct {
 	Content     []byte
 	ContentType string
@@ -332,7 +332,9 @@ func (c *artifactCache) set(key string, artifact *TranslationArtifact) {
 // When the cap is reached, new expressions are compiled and returned but not cached.
 const defaultMaxExprCacheEntries = 200
 
-// exprCache stores compiled JSONata expressions keyed by the raw expression string.
+// exprCache stores compiled JSONata expressions keyed by the SHA-256 digest of
+// the expression, so a large artifact is not retained twice as a map key and
+// identical artifacts served from different URLs share one compilation.
 // Entries never expire — expressions are deterministic and there are very few
 // unique ones in practice (bounded by the set of schema version pairs deployed
 // on a given node). See defaultMaxExprCacheEntries for the size cap.
@@ -356,6 +358,8 @@ type mediator struct {
 	exprs           *exprCache
 	notOnboarded    bool                // set at New() when local manifest is absent or has no schemaObjects
 	localManifest   *model.NodeManifest // local node manifest loaded at startup; nil when notOnboarded
+	artifactDir     string              // local artifact mirror checked before the artifact host; "" when unset
+	verifier        *artifactVerifier   // nil when artifact signatures are not verified
 }
 
 // New is the package-level constructor used by the plugin entrypoint.
@@ -383,6 +387,11 @@ func (p *provider) New(ctx context.Context, loader definition.ManifestLoader, cf
 		return nil, nil, err
 	}
 
+	sources, err := loadArtifactSourceConfig(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	instance, err := jsonata.OpenLatest()
 	if err != nil {
 		return nil, nil, fmt.Errorf("schemaversionmediator: open jsonata: %w", err)
@@ -395,6 +404,15 @@ func (p *provider) New(ctx context.Context, loader definition.ManifestLoader, cf
 		cache:           newArtifactCache(positiveTTL, negativeTTL, maxEntries),
 		jsonataInstance: instance,
 		exprs:           newExprCache(),
+		artifactDir:     sources.Dir,
+	}
+	if sources.Verify {
+		m.verifier = &artifactVerifier{
+			publicKeyURL: sources.PublicKeyURL,
+			networkID:    sources.NetworkID,
+			loader:       loader,
+			httpClient:   m.httpClient,
+		}
 	}
 
 	// Cold-start check: attempt to load the local node manifest. If it is
@@ -421,7 +439,22 @@ func (p *provider) New(ctx context.Context, loader definition.ManifestLoader, cf
 		}
 	}
 
-	return m, func() error { return nil }, nil
+	// Prefetch runs in the background so an artifact host outage cannot
+	// delay startup; the closer stops it and waits for it to return.
+	if m.localManifest == nil || len(sources.PrefetchSubscribers) == 0 {
+		return m, func() error { return nil }, nil
+	}
+	prefetchCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.prefetchArtifacts(prefetchCtx, sources.PrefetchSubscribers)
+	}()
+	return m, func() error {
+		cancel()
+		<-done
+		return nil
+	}, nil
 }
 
 // Mediate runs the full inbound schema version mediation sequence on ctx.Body.
@@ -512,7 +545,10 @@ func (m *mediator) Mediate(ctx *model.StepContext) error {
 	}
 	sort.Strings(sortedPaths)
 
-	// Apply each artifact against its specific schema object subtree.
+	// Apply each artifact against its specific schema object subtree, with
+	// the Translator its Content-Type selects. Fields a translator drops
+	// without its artifact declaring so are collected across all objects.
+	var lost []string
 	for _, jsonataPath := range sortedPaths {
 		fa := artifacts[jsonataPath]
 		relPath := strings.TrimPrefix(jsonataPath, "$.message.")
@@ -535,16 +571,34 @@ func (m *mediator) Mediate(ctx *model.StepContext) error {
 			}
 		}
 
-		resultBytes, err := m.Execute(ctx, string(fa.Artifact.Content), subtreeBytes)
+		translator, err := m.translatorFor(fa.Artifact.ContentType)
+		if err != nil {
+			return err
+		}
+		resultBytes, err := translator.Translate(ctx, fa.Artifact.Content, subtreeBytes)
 		if err != nil {
 			return fmt.Errorf("schemaversionmediator: execute translation at %q: %w", relPath, err)
 		}
+		if ct, ok := translator.(consumingTranslator); ok {
+			drops, err := unexplainedDrops(ct, fa.Artifact.Content, subtreeBytes, resultBytes, relPath)
+			if err != nil {
+				return fmt.Errorf("schemaversionmediator: data-loss check at %q: %w", relPath, err)
+			}
+			lost = append(lost, drops...)
+		}
 
 		targetContextURL := fa.Need.ToContextURL()
 		if relPath == "" {
-			if err := json.Unmarshal(resultBytes, &msgObj); err != nil {
+			// Decode into a fresh map: unmarshalling into msgObj would merge,
+			// keeping keys the artifact renamed, moved or removed.
+			var translated map[string]any
+			if err := json.Unmarshal(resultBytes, &translated); err != nil {
 				return fmt.Errorf("schemaversionmediator: unmarshal translated message: %w", err)
 			}
+			if translated == nil {
+				return fmt.Errorf("schemaversionmediator: translated message is not an object")
+			}
+			msgObj = translated
 			msgObj["@context"] = targetContextURL
 		} else {
 			var result any
@@ -561,6 +615,16 @@ func (m *mediator) Mediate(ctx *model.StepContext) error {
 		}
 	}
 
+	if len(lost) > 0 {
+		sort.Strings(lost)
+		log.Warnf(ctx, "schemaversionmediator: dataLoss counterparty=%q dropped=%v", counterpartyID, lost)
+		return &MediationError{
+			Code:          "SCH_SCHEMA_ADAPTATION_FAILED",
+			Message:       "translation dropped fields present in the source payload",
+			DroppedFields: lost,
+		}
+	}
+
 	finalMsg, err := json.Marshal(msgObj)
 	if err != nil {
 		return fmt.Errorf("schemaversionmediator: marshal translated message: %w", err)
@@ -729,6 +793,11 @@ func (m *mediator) fetchArtifact(ctx context.Context, need TranslationNeeded) (*
 // fetchArtifactByURL is the URL-scoped fetch primitive used by both fetchArtifact
 // and fetchAllArtifacts. Callers that have already derived the URL use this
 // directly to avoid a second derivation.
+//
+// The local artifact mirror, when configured, is consulted before the
+// artifact host. When signature verification is enabled an artifact is only
+// cached and returned once its detached signature verifies, wherever it came
+// from, and its format is taken from its signed content.
 func (m *mediator) fetchArtifactByURL(ctx context.Context, artifactURL string) (*TranslationArtifact, error) {
 	if artifact, found := m.cache.get(artifactURL); found {
 		if artifact == nil {
@@ -737,14 +806,31 @@ func (m *mediator) fetchArtifactByURL(ctx context.Context, artifactURL string) (
 		return artifact, nil
 	}
 
-	artifact, err := m.doFetch(ctx, artifactURL)
+	artifact, signature, err := m.loadMirroredArtifact(artifactURL)
 	if err != nil {
-		if errors.Is(err, ErrArtifactNotFound) {
-			m.cache.set(artifactURL, nil) // negative cache
-		}
 		return nil, err
 	}
+	source := "mirror"
+	if artifact == nil {
+		source = "host"
+		artifact, err = m.doFetch(ctx, artifactURL)
+		if err != nil {
+			if errors.Is(err, ErrArtifactNotFound) {
+				m.cache.set(artifactURL, nil) // negative cache
+			}
+			return nil, err
+		}
+	}
 
+	if m.verifier != nil {
+		if err := m.verifier.verify(ctx, artifactURL, artifact.Content, signature); err != nil {
+			return nil, err
+		}
+		// The signature covers the content only, so the format is read from
+		// it rather than from the unsigned Content-Type or sidecar.
+		artifact.ContentType = signedContentType(artifact.Content)
+	}
+	log.Debugf(ctx, "schemaversionmediator: artifactLoaded url=%q source=%s digest=%s verified=%t", artifactURL, source, artifactDigest(artifact.Content), m.verifier != nil)
 	m.cache.set(artifactURL, artifact)
 	return artifact, nil
 }
@@ -784,7 +870,7 @@ func (m *mediator) httpFetch(ctx context.Context, artifactURL string) (*Translat
 		return nil, fmt.Errorf("schemaversionmediator: artifact %q: unexpected status %d", artifactURL, resp.StatusCode)
 	}
 	contentType := resp.Header.Get("Content-Type")
-	if contentType == "" {
+	if contentType == "" && m.verifier == nil {
 		// Artifact URL convention omits file extensions, so Content-Type is the
 		// only reliable signal for which Translator to dispatch to.
 		return nil, fmt.Errorf("schemaversionmediator: artifact %q: missing Content-Type header", artifactURL)
@@ -813,7 +899,12 @@ func deriveArtifactURL(need TranslationNeeded) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	return need.To.BaseURL + "/" + need.CanonicalVersion + "/" + need.From.Type + "_from_" + fromVersion + ".jsonata", nil
+	return buildArtifactURL(need.To.BaseURL, need.CanonicalVersion, need.From.Type, fromVersion), nil
+}
+
+// buildArtifactURL applies the artifact URL convention described on deriveArtifactURL.
+func buildArtifactURL(baseURL, canonicalVersion, fromType, fromVersion string) string {
+	return baseURL + "/" + canonicalVersion + "/" + fromType + "_from_" + fromVersion + ".jsonata"
 }
 
 // extractVersionSegment walks the path segments of a context URL and returns
@@ -1029,8 +1120,9 @@ func ComposeExpression(entries []MappingEntry) (string, error) {
 // compiledExpr returns a cached compiled JSONata expression for the given
 // expression string, compiling and caching it on the first call.
 func (m *mediator) compiledExpr(expression string) (jsonata.Expression, error) {
+	digest := artifactDigest([]byte(expression))
 	m.exprs.mu.RLock()
-	if expr, ok := m.exprs.entries[expression]; ok {
+	if expr, ok := m.exprs.entries[digest]; ok {
 		m.exprs.mu.RUnlock()
 		return expr, nil
 	}
@@ -1043,7 +1135,7 @@ func (m *mediator) compiledExpr(expression string) (jsonata.Expression, error) {
 
 	m.exprs.mu.Lock()
 	if len(m.exprs.entries) < m.exprs.max {
-		m.exprs.entries[expression] = expr
+		m.exprs.entries[digest] = expr
 	}
 	m.exprs.mu.Unlock()
 	return expr, nil
@@ -1088,6 +1180,7 @@ type ArtifactFetchFailure struct {
 //     no artifact URL can be derived. Reason will describe the missing type.
 //  2. fetchArtifact returned an error: URL was derived but the fetch failed
 //     (ErrArtifactNotFound or a transient network error).
+//  3. The artifact's Content-Type selects no Translator.
 //
 // Returns a nil error slice only when ALL fetches succeed. The caller must check
 // for failures before calling ComposeExpression and Execute.
@@ -1117,6 +1210,11 @@ func (m *mediator) fetchAllArtifacts(ctx context.Context, needs []TranslationNee
 			failures = append(failures, ArtifactFetchFailure{Need: need, URL: artifactURL, Reason: err})
 			continue
 		}
+		if _, err := m.translatorFor(artifact.ContentType); err != nil {
+			log.Warnf(ctx, "schemaversionmediator: artifactUnsupported type=%q url=%q reason=%v", need.From.Type, artifactURL, err)
+			failures = append(failures, ArtifactFetchFailure{Need: need, URL: artifactURL, Reason: err})
+			continue
+		}
 		artifacts[need.JSONataPath] = fetchedArtifact{Artifact: artifact, Need: need}
 	}
 	return artifacts, failures
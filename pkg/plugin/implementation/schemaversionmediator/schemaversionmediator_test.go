@@ -17,6 +17,7 @@ import (
 // mockManifestLoader is a test double for definition.ManifestLoader.
 type mockManifestLoader struct {
 	bySubscriberID func(ctx context.Context, subscriberID string) (*model.ManifestDocument, error)
+	byNetworkID    func(ctx context.Context, networkID string) (*model.ManifestDocument, error)
 }
 
 func (m *mockManifestLoader) GetBySubscriberID(ctx context.Context, subscriberID string) (*model.ManifestDocument, error) {
@@ -27,6 +28,9 @@ func (m *mockManifestLoader) GetBySubscriberID(ctx context.Context, subscriberID
 }
 
 func (m *mockManifestLoader) GetByNetworkID(ctx context.Context, networkID string) (*model.ManifestDocument, error) {
+	if m.byNetworkID != nil {
+		return m.byNetworkID(ctx, networkID)
+	}
 	return nil, nil
 }
 
@@ -1005,7 +1009,7 @@ func TestExecute_ExpressionCacheHit(t *testing.T) {
 		t.Fatalf("second Execute: %v", err)
 	}
 	m.exprs.mu.RLock()
-	_, cached := m.exprs.entries[expr]
+	_, cached := m.exprs.entries[artifactDigest([]byte(expr))]
 	m.exprs.mu.RUnlock()
 	if !cached {
 		t.Error("expression should be in cache after first Execute call")
@@ -1569,12 +1573,11 @@ func TestMediate_TranslationUpdatesContextURL_Nested(t *testing.T) {
 	}
 }
 
-// TestMediate_DataLoss note: the JSONata $merge executor is additive by design —
-// it cannot drop fields present in the source. Data-loss detection in Mediate is
-// therefore not reachable through the current JSONata executor path. The
-// droppedFields function is tested directly in TestDroppedFields_* below. A
-// Mediate-level integration test requires a replacement (non-merge) executor and
-// will be added when non-JSONata translator support is introduced.
+// TestMediate_DataLoss note: JSONata expressions do not declare which fields
+// they drop on purpose, so Mediate runs data-loss detection only for
+// field-mapping and JSON Patch artifacts. The droppedFields function is tested
+// directly in TestDroppedFields_* below; the Mediate-level test is
+// TestMediate_DataLossRejected in translator_test.go.
 
 // --- Nested-path translation tests ---
 // These tests verify that artifact expressions are evaluated against the specific
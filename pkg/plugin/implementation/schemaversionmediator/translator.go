@@ -0,0 +1,98 @@
+package schemaversionmediator
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"strings"
+
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+)
+
+// Artifact content types the mediator dispatches on. Parameters such as
+// charset are ignored when matching.
+const (
+	contentTypeJSONata      = "application/jsonata"
+	contentTypeFieldMapping = "application/vnd.beckn.field-mapping+json"
+	contentTypeJSONPatch    = "application/json-patch+json"
+)
+
+// consumingTranslator is implemented by translators whose artifacts declare
+// the source fields they intentionally remove (a rename's old key, a JSON
+// Patch remove). Mediate runs data-loss detection on their output and excuses
+// only those paths; any other source field missing from the output rejects
+// the request. JSONata expressions declare nothing and are not checked.
+type consumingTranslator interface {
+	definition.Translator
+	// ConsumedPaths returns the dot-notation paths, relative to the
+	// translated object, that the artifact removes on purpose. Fields nested
+	// under a consumed path are consumed with it.
+	ConsumedPaths(artifact []byte) ([]string, error)
+}
+
+// translatorFor returns the Translator for an artifact's Content-Type.
+func (m *mediator) translatorFor(contentType string) (definition.Translator, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("schemaversionmediator: invalid artifact content type %q: %w", contentType, err)
+	}
+	switch mediaType {
+	case contentTypeJSONata:
+		return jsonataTranslator{m: m}, nil
+	case contentTypeFieldMapping:
+		return fieldMappingTranslator{}, nil
+	case contentTypeJSONPatch:
+		return jsonPatchTranslator{}, nil
+	default:
+		return nil, fmt.Errorf("schemaversionmediator: unsupported artifact content type %q", mediaType)
+	}
+}
+
+// jsonataTranslator runs a JSONata expression artifact through the mediator's
+// compiled-expression cache.
+type jsonataTranslator struct {
+	m *mediator
+}
+
+// Translate evaluates artifact as a JSONata expression against payload.
+func (t jsonataTranslator) Translate(ctx context.Context, artifact []byte, payload []byte) ([]byte, error) {
+	return t.m.Execute(ctx, string(artifact), payload)
+}
+
+// unexplainedDrops returns the source fields missing from the translated
+// output that the artifact did not declare as consumed, prefixed with
+// relPath so they name a location in the message.
+func unexplainedDrops(t consumingTranslator, artifact, src, dst []byte, relPath string) ([]string, error) {
+	dropped, err := droppedFields(src, dst)
+	if err != nil {
+		return nil, err
+	}
+	if len(dropped) == 0 {
+		return nil, nil
+	}
+	consumed, err := t.ConsumedPaths(artifact)
+	if err != nil {
+		return nil, err
+	}
+	var lost []string
+	for _, k := range dropped {
+		if isConsumed(k, consumed) {
+			continue
+		}
+		if relPath != "" {
+			k = relPath + "." + k
+		}
+		lost = append(lost, k)
+	}
+	return lost, nil
+}
+
+// isConsumed reports whether path is one of consumed or nested under one.
+func isConsumed(path string, consumed []string) bool {
+	for _, c := range consumed {
+		if c == "" || path == c || strings.HasPrefix(path, c+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,350 @@
+package schemaversionmediator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+)
+
+// typedArtifactServer serves each artifact with its own Content-Type.
+func typedArtifactServer(t *testing.T, artifacts map[string][2]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a, ok := artifacts[r.URL.Path]; ok {
+			w.Header().Set("Content-Type", a[0])
+			w.Write([]byte(a[1]))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func decodeObject(t *testing.T, b []byte) map[string]any {
+	t.Helper()
+	var v map[string]any
+	if err := json.Unmarshal(b, &v); err != nil {
+		t.Fatalf("invalid JSON %s: %v", b, err)
+	}
+	return v
+}
+
+func TestTranslatorFor(t *testing.T) {
+	m := &mediator{}
+	tests := []struct {
+		contentType string
+		want        any
+	}{
+		{"application/jsonata", jsonataTranslator{}},
+		{"application/vnd.beckn.field-mapping+json", fieldMappingTranslator{}},
+		{"application/json-patch+json; charset=utf-8", jsonPatchTranslator{}},
+	}
+	for _, tt := range tests {
+		got, err := m.translatorFor(tt.contentType)
+		if err != nil {
+			t.Fatalf("translatorFor(%q): %v", tt.contentType, err)
+		}
+		if reflect.TypeOf(got) != reflect.TypeOf(tt.want) {
+			t.Errorf("translatorFor(%q) = %T, want %T", tt.contentType, got, tt.want)
+		}
+	}
+	for _, ct := range []string{"application/shacl+turtle", "text/plain", ""} {
+		if _, err := m.translatorFor(ct); err == nil {
+			t.Errorf("translatorFor(%q): expected an error", ct)
+		}
+	}
+}
+
+func TestFieldMappingTranslator(t *testing.T) {
+	artifact := `{"mappings": [
+		{"op": "rename", "from": "status", "to": "state"},
+		{"op": "rename", "from": "price.curr", "to": "currency"},
+		{"op": "move", "from": "price.value", "to": "pricing.amount"},
+		{"op": "move", "from": "absent", "to": "elsewhere"},
+		{"op": "default", "to": "pricing.taxIncluded", "value": true},
+		{"op": "default", "to": "state", "value": "UNKNOWN"}
+	]}`
+	payload := `{"@type": "Offer", "status": "ACTIVE", "price": {"value": 100, "curr": "INR"}}`
+
+	out, err := fieldMappingTranslator{}.Translate(context.Background(), []byte(artifact), []byte(payload))
+	if err != nil {
+		t.Fatalf("Translate: %v", err)
+	}
+	want := decodeObject(t, []byte(`{"@type": "Offer", "state": "ACTIVE", "price": {"currency": "INR"}, "pricing": {"amount": 100, "taxIncluded": true}}`))
+	if got := decodeObject(t, out); !reflect.DeepEqual(got, want) {
+		t.Errorf("Translate = %v, want %v", got, want)
+	}
+
+	consumed, err := fieldMappingTranslator{}.ConsumedPaths([]byte(artifact))
+	if err != nil {
+		t.Fatalf("ConsumedPaths: %v", err)
+	}
+	if want := []string{"status", "price.curr", "price.value", "absent"}; !reflect.DeepEqual(consumed, want) {
+		t.Errorf("ConsumedPaths = %v, want %v", consumed, want)
+	}
+}
+
+func TestFieldMappingTranslator_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		artifact string
+		payload  string
+	}{
+		{"not JSON", `rename status`, `{}`},
+		{"no mappings", `{"mappings": []}`, `{}`},
+		{"unknown op", `{"mappings": [{"op": "drop", "to": "x"}]}`, `{}`},
+		{"unknown member", `{"mappings": [{"op": "move", "from": "a", "to": "b", "path": "c"}]}`, `{}`},
+		{"rename to a path", `{"mappings": [{"op": "rename", "from": "a", "to": "b.c"}]}`, `{}`},
+		{"default without value", `{"mappings": [{"op": "default", "to": "a"}]}`, `{}`},
+		{"overwrite", `{"mappings": [{"op": "move", "from": "a", "to": "b"}]}`, `{"a": 1, "b": 2}`},
+		{"parent not an object", `{"mappings": [{"op": "move", "from": "a", "to": "b.c"}]}`, `{"a": 1, "b": 2}`},
+		{"payload not an object", `{"mappings": [{"op": "default", "to": "a", "value": 1}]}`, `[1]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := (fieldMappingTranslator{}).Translate(context.Background(), []byte(tt.artifact), []byte(tt.payload)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestJSONPatchTranslator(t *testing.T) {
+	tests := []struct {
+		name    string
+		patch   string
+		payload string
+		want    string
+	}{
+		{"add member", `[{"op": "add", "path": "/state", "value": "ACTIVE"}]`, `{"a": 1}`, `{"a": 1, "state": "ACTIVE"}`},
+		{"add to array", `[{"op": "add", "path": "/tags/1", "value": "x"}, {"op": "add", "path": "/tags/-", "value": "z"}]`, `{"tags": ["a", "b"]}`, `{"tags": ["a", "x", "b", "z"]}`},
+		{"remove", `[{"op": "remove", "path": "/a/b"}]`, `{"a": {"b": 1, "c": 2}}`, `{"a": {"c": 2}}`},
+		{"replace", `[{"op": "replace", "path": "/tags/0", "value": "y"}]`, `{"tags": ["a"]}`, `{"tags": ["y"]}`},
+		{"move", `[{"op": "move", "from": "/status", "path": "/descriptor/state"}]`, `{"status": "ACTIVE", "descriptor": {}}`, `{"descriptor": {"state": "ACTIVE"}}`},
+		{"copy", `[{"op": "copy", "from": "/a", "path": "/b"}]`, `{"a": {"x": 1}}`, `{"a": {"x": 1}, "b": {"x": 1}}`},
+		{"test passes", `[{"op": "test", "path": "/a", "value": {"x": 1}}, {"op": "remove", "path": "/a"}]`, `{"a": {"x": 1}}`, `{}`},
+		{"escaped pointer", `[{"op": "add", "path": "/a~1b~0c", "value": 1}]`, `{}`, `{"a/b~c": 1}`},
+		{"null value", `[{"op": "add", "path": "/a", "value": null}]`, `{}`, `{"a": null}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := jsonPatchTranslator{}.Translate(context.Background(), []byte(tt.patch), []byte(tt.payload))
+			if err != nil {
+				t.Fatalf("Translate: %v", err)
+			}
+			if got, want := decodeObject(t, out), decodeObject(t, []byte(tt.want)); !reflect.DeepEqual(got, want) {
+				t.Errorf("Translate = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestJSONPatchTranslator_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		patch   string
+		payload string
+	}{
+		{"not an array", `{"op": "add"}`, `{}`},
+		{"unknown op", `[{"op": "merge", "path": "/a"}]`, `{}`},
+		{"add without value", `[{"op": "add", "path": "/a"}]`, `{}`},
+		{"relative pointer", `[{"op": "remove", "path": "a"}]`, `{"a": 1}`},
+		{"move into own child", `[{"op": "move", "from": "/a", "path": "/a/b"}]`, `{"a": {}}`},
+		{"remove missing", `[{"op": "remove", "path": "/a"}]`, `{}`},
+		{"replace missing", `[{"op": "replace", "path": "/a", "value": 1}]`, `{}`},
+		{"add under missing parent", `[{"op": "add", "path": "/a/b", "value": 1}]`, `{}`},
+		{"index out of range", `[{"op": "add", "path": "/tags/3", "value": 1}]`, `{"tags": []}`},
+		{"leading zero index", `[{"op": "remove", "path": "/tags/01"}]`, `{"tags": [1, 2]}`},
+		{"test fails", `[{"op": "test", "path": "/a", "value": 2}]`, `{"a": 1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := (jsonPatchTranslator{}).Translate(context.Background(), []byte(tt.patch), []byte(tt.payload)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestJSONPatchTranslator_ConsumedPaths(t *testing.T) {
+	patch := `[
+		{"op": "remove", "path": "/discountCode"},
+		{"op": "replace", "path": "/price", "value": 100},
+		{"op": "move", "from": "/a~1b", "path": "/c"},
+		{"op": "add", "path": "/d", "value": 1},
+		{"op": "copy", "from": "/e", "path": "/f"}
+	]`
+	consumed, err := jsonPatchTranslator{}.ConsumedPaths([]byte(patch))
+	if err != nil {
+		t.Fatalf("ConsumedPaths: %v", err)
+	}
+	if want := []string{"discountCode", "price", "a/b"}; !reflect.DeepEqual(consumed, want) {
+		t.Errorf("ConsumedPaths = %v, want %v", consumed, want)
+	}
+}
+
+// TestMediate_MixedTranslators verifies that JSONata, field-mapping and JSON
+// Patch artifacts for different schema objects apply in one mediation pass.
+func TestMediate_MixedTranslators(t *testing.T) {
+	srv := typedArtifactServer(t, map[string][2]string{
+		"/retail/v2.0/Offer_from_v1.0.jsonata":    {contentTypeJSONata, `$merge([$, {"state": status}])`},
+		"/retail/v2.0/Item_from_v1.0.jsonata":     {contentTypeFieldMapping, `{"mappings": [{"op": "rename", "from": "name", "to": "label"}, {"op": "default", "to": "quantity", "value": 1}]}`},
+		"/retail/v2.0/Provider_from_v1.0.jsonata": {contentTypeJSONPatch + "; charset=utf-8", `[{"op": "move", "from": "/id", "path": "/descriptor/id"}]`},
+	})
+
+	lm := localManifestWith(
+		model.SchemaObject{BaseURL: srv.URL + "/retail", Type: "Offer", SupportedVersions: []string{"v2.0"}},
+		model.SchemaObject{BaseURL: srv.URL + "/retail", Type: "Item", SupportedVersions: []string{"v2.0"}},
+		model.SchemaObject{BaseURL: srv.URL + "/retail", Type: "Provider", SupportedVersions: []string{"v2.0"}},
+	)
+	m := newTestMediatorFull(t, &mockManifestLoader{}, map[string]string{}, lm)
+	m.httpClient = srv.Client()
+
+	body := []byte(`{"context":{"network_id":"net1"},"message":{` +
+		`"offer":{"@context":"` + srv.URL + `/retail/v1.0/Offer.jsonld","@type":"Offer","status":"ACTIVE"},` +
+		`"items":[{"@context":"` + srv.URL + `/retail/v1.0/Item.jsonld","@type":"Item","name":"Flask"}],` +
+		`"provider":{"@context":"` + srv.URL + `/retail/v1.0/Provider.jsonld","@type":"Provider","id":"p1","descriptor":{}}` +
+		`}}`)
+	ctx := stepCtxWithRemoteID(body, "bap.example.com")
+
+	if err := m.Mediate(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var envelope map[string]json.RawMessage
+	json.Unmarshal(ctx.Body, &envelope)
+	msg := decodeObject(t, envelope["message"])
+
+	offer := msg["offer"].(map[string]any)
+	if offer["state"] != "ACTIVE" {
+		t.Errorf("expected offer.state=ACTIVE from JSONata, got %v", offer["state"])
+	}
+	item := msg["items"].([]any)[0].(map[string]any)
+	if item["label"] != "Flask" || item["quantity"] != float64(1) {
+		t.Errorf("expected the field mapping applied to items[0], got %v", item)
+	}
+	if item["@context"] != srv.URL+"/retail/v2.0/context.jsonld" {
+		t.Errorf("expected items[0].@context updated, got %v", item["@context"])
+	}
+	provider := msg["provider"].(map[string]any)
+	if provider["descriptor"].(map[string]any)["id"] != "p1" {
+		t.Errorf("expected the JSON patch applied to provider, got %v", provider)
+	}
+	if _, ok := provider["id"]; ok {
+		t.Error("expected provider.id moved by the JSON patch")
+	}
+}
+
+// TestMediate_WholeMessageTranslation verifies that an artifact applied to
+// $.message itself replaces the message, so renamed, moved and removed keys
+// do not survive next to their new ones.
+func TestMediate_WholeMessageTranslation(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		artifact    string
+		want        map[string]any
+	}{
+		{
+			name:        "field mapping rename and move",
+			contentType: contentTypeFieldMapping,
+			artifact:    `{"mappings": [{"op": "rename", "from": "status", "to": "state"}, {"op": "move", "from": "legacy", "to": "flags.legacy"}]}`,
+			want:        map[string]any{"@type": "Order", "state": "ACTIVE", "id": "o1", "flags": map[string]any{"legacy": true}},
+		},
+		{
+			name:        "JSON patch move and remove",
+			contentType: contentTypeJSONPatch,
+			artifact:    `[{"op": "move", "from": "/status", "path": "/state"}, {"op": "remove", "path": "/legacy"}]`,
+			want:        map[string]any{"@type": "Order", "state": "ACTIVE", "id": "o1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := typedArtifactServer(t, map[string][2]string{
+				"/retail/v2.0/Order_from_v1.0.jsonata": {tt.contentType, tt.artifact},
+			})
+			lm := localManifestWith(model.SchemaObject{BaseURL: srv.URL + "/retail", Type: "Order", SupportedVersions: []string{"v2.0"}})
+			m := newTestMediatorFull(t, &mockManifestLoader{}, map[string]string{}, lm)
+			m.httpClient = srv.Client()
+
+			body := []byte(`{"context":{"network_id":"net1"},"message":{"@context":"` + srv.URL +
+				`/retail/v1.0/Order.jsonld","@type":"Order","status":"ACTIVE","id":"o1","legacy":true}}`)
+			ctx := stepCtxWithRemoteID(body, "bap.example.com")
+			if err := m.Mediate(ctx); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var envelope map[string]json.RawMessage
+			json.Unmarshal(ctx.Body, &envelope)
+			msg := decodeObject(t, envelope["message"])
+			tt.want["@context"] = srv.URL + "/retail/v2.0/context.jsonld"
+			if !reflect.DeepEqual(msg, tt.want) {
+				t.Errorf("message = %v, want %v", msg, tt.want)
+			}
+		})
+	}
+}
+
+func TestMediate_DataLossRejected(t *testing.T) {
+	// The patch replaces the whole descriptor by adding over it, which drops
+	// descriptor.code without the artifact declaring it.
+	srv := typedArtifactServer(t, map[string][2]string{
+		"/retail/v2.0/Provider_from_v1.0.jsonata": {contentTypeJSONPatch, `[{"op": "add", "path": "/descriptor", "value": {"name": "Shop"}}, {"op": "remove", "path": "/legacy"}]`},
+	})
+	lm := localManifestWith(model.SchemaObject{BaseURL: srv.URL + "/retail", Type: "Provider", SupportedVersions: []string{"v2.0"}})
+	m := newTestMediatorFull(t, &mockManifestLoader{}, map[string]string{}, lm)
+	m.httpClient = srv.Client()
+
+	body := []byte(`{"context":{"network_id":"net1"},"message":{"order":{"provider":{` +
+		`"@context":"` + srv.URL + `/retail/v1.0/Provider.jsonld","@type":"Provider",` +
+		`"legacy":{"flag":true},"descriptor":{"code":"P1"}}}}}`)
+	original := string(body)
+	ctx := stepCtxWithRemoteID(body, "bap.example.com")
+
+	err := m.Mediate(ctx)
+	var me *MediationError
+	if !errors.As(err, &me) || me.Code != "SCH_SCHEMA_ADAPTATION_FAILED" {
+		t.Fatalf("expected SCH_SCHEMA_ADAPTATION_FAILED, got %v", err)
+	}
+	if want := []string{"order.provider.descriptor.code"}; !reflect.DeepEqual(me.DroppedFields, want) {
+		t.Errorf("DroppedFields = %v, want %v", me.DroppedFields, want)
+	}
+	if string(ctx.Body) != original {
+		t.Error("the payload must not be patched when translation loses data")
+	}
+}
+
+func TestMediate_UnsupportedArtifactContentType(t *testing.T) {
+	srv := typedArtifactServer(t, map[string][2]string{
+		"/retail/v2.0/Offer_from_v1.0.jsonata": {"text/turtle", `ex:Offer a sh:NodeShape .`},
+	})
+	lm := localManifestWith(model.SchemaObject{BaseURL: srv.URL + "/retail", Type: "Offer", SupportedVersions: []string{"v2.0"}})
+	body := []byte(`{"context":{"network_id":"net1"},"message":{"offer":{` +
+		`"@context":"` + srv.URL + `/retail/v1.0/Offer.jsonld","@type":"Offer","status":"ACTIVE"}}}`)
+
+	m := newTestMediatorFull(t, &mockManifestLoader{}, map[string]string{}, lm)
+	m.httpClient = srv.Client()
+	err := m.Mediate(stepCtxWithRemoteID(body, "bap.example.com"))
+	var me *MediationError
+	if !errors.As(err, &me) || !strings.Contains(errors.Unwrap(err).Error(), "unsupported artifact content type") {
+		t.Fatalf("expected an unsupported content type to apply onFailure=reject, got %v", err)
+	}
+
+	m = newTestMediatorFull(t, &mockManifestLoader{}, map[string]string{"onFailure": "passThrough"}, lm)
+	m.httpClient = srv.Client()
+	ctx := stepCtxWithRemoteID(body, "bap.example.com")
+	if err := m.Mediate(ctx); err != nil {
+		t.Fatalf("expected passThrough, got %v", err)
+	}
+	if string(ctx.Body) != string(body) {
+		t.Error("passThrough must leave the payload untouched")
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"errors"
+	"strings"
 
 	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
 	"github.com/beckn-one/beckn-onix/pkg/plugin/implementation/signer"
@@ -17,7 +18,31 @@ func (p SignerProvider) New(ctx context.Context, config map[string]string) (defi
 		return nil, nil, errors.New("context cannot be nil")
 	}
 
-	return signer.New(ctx, &signer.Config{})
+	s, closer, err := signer.New(ctx, parseConfig(config))
+	if err != nil {
+		return nil, nil, err
+	}
+	return s, closer, nil
+}
+
+// parseConfig maps the plugin config keys onto signer.Config:
+//
+//	scheme:            beckn | rfc9421 | auto
+//	coveredComponents: comma-separated RFC 9421 components
+//	label:             RFC 9421 signature label
+//	digestAlgorithm:   sha-256 | sha-512
+func parseConfig(config map[string]string) *signer.Config {
+	cfg := &signer.Config{
+		Scheme:          config["scheme"],
+		Label:           config["label"],
+		DigestAlgorithm: config["digestAlgorithm"],
+	}
+	for _, c := range strings.Split(config["coveredComponents"], ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			cfg.CoveredComponents = append(cfg.CoveredComponents, c)
+		}
+	}
+	return cfg
 }
 
 // Provider is the exported symbol that the plugin manager will look for.
@@ -44,6 +44,21 @@ func TestSignerProviderSuccess(t *testing.T) {
 			ctx:    context.Background(),
 			config: map[string]string{"ttl": "not_a_number"},
 		},
+		{
+			name: "RFC 9421 Config",
+			ctx:  context.Background(),
+			config: map[string]string{
+				"scheme":            "rfc9421",
+				"coveredComponents": "@method, @target-uri, content-digest",
+				"label":             "beckn",
+				"digestAlgorithm":   "sha-512",
+			},
+		},
+		{
+			name:   "Auto Scheme",
+			ctx:    context.Background(),
+			config: map[string]string{"scheme": "auto"},
+		},
 	}
 
 	for _, tt := range successTests {
@@ -81,6 +96,24 @@ func TestSignerProviderFailure(t *testing.T) {
 			config:  map[string]string{},
 			wantErr: true,
 		},
+		{
+			name:    "Unknown Scheme",
+			ctx:     context.Background(),
+			config:  map[string]string{"scheme": "hmac"},
+			wantErr: true,
+		},
+		{
+			name:    "Covered Components Without Content-Digest",
+			ctx:     context.Background(),
+			config:  map[string]string{"scheme": "rfc9421", "coveredComponents": "@method,@path"},
+			wantErr: true,
+		},
+		{
+			name:    "Unsupported Digest Algorithm",
+			ctx:     context.Background(),
+			config:  map[string]string{"digestAlgorithm": "md5"},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range failureTests {
@@ -0,0 +1,243 @@
+package signer
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+)
+
+// Signature schemes selectable through Config.Scheme.
+const (
+	// SchemeBeckn signs every request with the Beckn Authorization header.
+	SchemeBeckn = "beckn"
+	// SchemeHTTPSig signs every request with RFC 9421 HTTP Message Signatures.
+	SchemeHTTPSig = "rfc9421"
+	// SchemeAuto signs Beckn v2.0.0+ requests with RFC 9421 and older ones
+	// with the Beckn Authorization header.
+	SchemeAuto = "auto"
+)
+
+// Digest algorithms for the Content-Digest header (RFC 9530).
+const (
+	DigestSHA256 = "sha-256"
+	DigestSHA512 = "sha-512"
+)
+
+const (
+	defaultLabel = "sig1"
+	// componentContentDigest must always be covered: it is the only component
+	// that binds the signature to the request body.
+	componentContentDigest = "content-digest"
+)
+
+// DefaultCoveredComponents are the RFC 9421 components signed when
+// Config.CoveredComponents is empty.
+var DefaultCoveredComponents = []string{"@method", "@path", "content-type", componentContentDigest}
+
+// derivedComponents are the RFC 9421 §2.2 derived components this signer can
+// compute. @query-param and @status are not supported.
+var derivedComponents = map[string]bool{
+	"@method":         true,
+	"@target-uri":     true,
+	"@authority":      true,
+	"@scheme":         true,
+	"@request-target": true,
+	"@path":           true,
+	"@query":          true,
+}
+
+// validateHTTPSigConfig checks the RFC 9421 settings and fills in defaults.
+func validateHTTPSigConfig(cfg *Config) error {
+	switch cfg.Scheme {
+	case "":
+		cfg.Scheme = SchemeBeckn
+	case SchemeBeckn, SchemeHTTPSig, SchemeAuto:
+	default:
+		return fmt.Errorf("unsupported signature scheme %q: expected %s, %s or %s", cfg.Scheme, SchemeBeckn, SchemeHTTPSig, SchemeAuto)
+	}
+
+	switch cfg.DigestAlgorithm {
+	case "":
+		cfg.DigestAlgorithm = DigestSHA256
+	case DigestSHA256, DigestSHA512:
+	default:
+		return fmt.Errorf("unsupported digest algorithm %q: expected %s or %s", cfg.DigestAlgorithm, DigestSHA256, DigestSHA512)
+	}
+
+	if cfg.Label == "" {
+		cfg.Label = defaultLabel
+	}
+	if !isSFKey(cfg.Label) {
+		return fmt.Errorf("invalid signature label %q: must be a lowercase structured field key", cfg.Label)
+	}
+
+	if len(cfg.CoveredComponents) == 0 {
+		cfg.CoveredComponents = DefaultCoveredComponents
+	}
+	seen := make(map[string]bool, len(cfg.CoveredComponents))
+	for _, c := range cfg.CoveredComponents {
+		if strings.HasPrefix(c, "@") {
+			if !derivedComponents[c] {
+				return fmt.Errorf("unsupported covered component %q", c)
+			}
+		} else if c == "" || c != strings.ToLower(c) || strings.ContainsAny(c, " \t\"") {
+			return fmt.Errorf("invalid covered component %q: header names must be lowercase", c)
+		}
+		if seen[c] {
+			return fmt.Errorf("duplicate covered component %q", c)
+		}
+		seen[c] = true
+	}
+	if !seen[componentContentDigest] {
+		return fmt.Errorf("covered components must include %q so the signature protects the body", componentContentDigest)
+	}
+	return nil
+}
+
+// UseHTTPMessageSignatures reports whether a request for protocolVersion is
+// signed with RFC 9421.
+func (s *Signer) UseHTTPMessageSignatures(protocolVersion string) bool {
+	switch s.config.Scheme {
+	case SchemeHTTPSig:
+		return true
+	case SchemeAuto:
+		return model.IsAtLeastV2(protocolVersion)
+	default:
+		return false
+	}
+}
+
+// SignHTTPMessage signs the request described by method, targetURI and header
+// per RFC 9421 with Ed25519, covering the configured components, and sets the
+// Content-Digest, Signature-Input and Signature headers on header.
+func (s *Signer) SignHTTPMessage(ctx context.Context, method string, targetURI *url.URL, header http.Header, body []byte, keyID, privateKeyBase64 string, createdAt, expiresAt int64) error {
+	if targetURI == nil {
+		return fmt.Errorf("target URI is required for RFC 9421 signing")
+	}
+	header.Set("Content-Digest", contentDigest(s.config.DigestAlgorithm, body))
+
+	params := signatureParams(s.config.CoveredComponents, keyID, createdAt, expiresAt)
+	base, err := signatureBase(s.config.CoveredComponents, params, method, targetURI, header)
+	if err != nil {
+		return err
+	}
+
+	signature, err := generateSignature([]byte(base), privateKeyBase64)
+	if err != nil {
+		return err
+	}
+
+	header.Set("Signature-Input", s.config.Label+"="+params)
+	header.Set("Signature", s.config.Label+"=:"+base64.StdEncoding.EncodeToString(signature)+":")
+	return nil
+}
+
+// contentDigest returns the RFC 9530 Content-Digest field value for body.
+func contentDigest(alg string, body []byte) string {
+	var sum []byte
+	if alg == DigestSHA512 {
+		s := sha512.Sum512(body)
+		sum = s[:]
+	} else {
+		s := sha256.Sum256(body)
+		sum = s[:]
+	}
+	return alg + "=:" + base64.StdEncoding.EncodeToString(sum) + ":"
+}
+
+// signatureParams serialises the @signature-params inner list, e.g.
+// ("@method" "content-digest");created=1;expires=2;keyid="k";alg="ed25519".
+func signatureParams(components []string, keyID string, createdAt, expiresAt int64) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for i, c := range components {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(sfString(c))
+	}
+	b.WriteByte(')')
+	fmt.Fprintf(&b, ";created=%d;expires=%d;keyid=%s;alg=\"ed25519\"", createdAt, expiresAt, sfString(keyID))
+	return b.String()
+}
+
+// signatureBase builds the RFC 9421 §2.5 signature base.
+func signatureBase(components []string, params, method string, targetURI *url.URL, header http.Header) (string, error) {
+	var b strings.Builder
+	for _, c := range components {
+		v, err := componentValue(c, method, targetURI, header)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "%s: %s\n", sfString(c), v)
+	}
+	fmt.Fprintf(&b, "\"@signature-params\": %s", params)
+	return b.String(), nil
+}
+
+// componentValue resolves a covered component against the outgoing request.
+func componentValue(name, method string, u *url.URL, header http.Header) (string, error) {
+	switch name {
+	case "@method":
+		return method, nil
+	case "@target-uri":
+		return u.String(), nil
+	case "@authority":
+		return strings.ToLower(u.Host), nil
+	case "@scheme":
+		return strings.ToLower(u.Scheme), nil
+	case "@request-target":
+		return u.RequestURI(), nil
+	case "@path":
+		if p := u.EscapedPath(); p != "" {
+			return p, nil
+		}
+		return "/", nil
+	case "@query":
+		return "?" + u.RawQuery, nil
+	}
+	values := header.Values(name)
+	if len(values) == 0 {
+		return "", fmt.Errorf("covered component %q is not present in the request", name)
+	}
+	trimmed := make([]string, len(values))
+	for i, v := range values {
+		trimmed[i] = strings.TrimSpace(v)
+	}
+	return strings.Join(trimmed, ", "), nil
+}
+
+// sfString serialises s as a structured field string (RFC 8941 §3.3.3).
+func sfString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		if s[i] == '"' || s[i] == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// isSFKey reports whether s is a valid structured field dictionary key.
+func isSFKey(s string) bool {
+	if s == "" || !(s[0] == '*' || (s[0] >= 'a' && s[0] <= 'z')) {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		if !((c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '_' || c == '-' || c == '.' || c == '*') {
+			return false
+		}
+	}
+	return true
+}
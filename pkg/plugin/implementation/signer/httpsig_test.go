@@ -0,0 +1,136 @@
+package signer
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestUseHTTPMessageSignatures(t *testing.T) {
+	tests := []struct {
+		scheme  string
+		version string
+		want    bool
+	}{
+		{scheme: "", version: "2.0.0", want: false},
+		{scheme: SchemeBeckn, version: "2.0.0", want: false},
+		{scheme: SchemeHTTPSig, version: "1.1.0", want: true},
+		{scheme: SchemeAuto, version: "1.1.0", want: false},
+		{scheme: SchemeAuto, version: "2.0.0", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scheme+"/"+tt.version, func(t *testing.T) {
+			s, _, err := New(context.Background(), &Config{Scheme: tt.scheme})
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if got := s.UseHTTPMessageSignatures(tt.version); got != tt.want {
+				t.Errorf("UseHTTPMessageSignatures(%q) = %v, want %v", tt.version, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewHTTPSigConfigErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{name: "unknown scheme", cfg: Config{Scheme: "hmac"}},
+		{name: "unknown digest", cfg: Config{DigestAlgorithm: "md5"}},
+		{name: "invalid label", cfg: Config{Label: "Sig 1"}},
+		{name: "no content-digest", cfg: Config{CoveredComponents: []string{"@method"}}},
+		{name: "unsupported derived component", cfg: Config{CoveredComponents: []string{"@status", "content-digest"}}},
+		{name: "uppercase header", cfg: Config{CoveredComponents: []string{"Content-Type", "content-digest"}}},
+		{name: "duplicate component", cfg: Config{CoveredComponents: []string{"content-digest", "content-digest"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := New(context.Background(), &tt.cfg); err == nil {
+				t.Error("New() expected error")
+			}
+		})
+	}
+}
+
+func TestNewDoesNotMutateConfig(t *testing.T) {
+	cfg := &Config{}
+	if _, _, err := New(context.Background(), cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Scheme != "" || cfg.Label != "" || cfg.CoveredComponents != nil {
+		t.Errorf("New() mutated caller config: %+v", cfg)
+	}
+}
+
+func TestSignHTTPMessage(t *testing.T) {
+	privateKey, publicKey := generateTestKeys()
+	pub, _ := base64.StdEncoding.DecodeString(publicKey)
+	body := []byte(`{"hello": "world"}`)
+
+	s, _, err := New(context.Background(), &Config{
+		Scheme:            SchemeHTTPSig,
+		CoveredComponents: []string{"@method", "@authority", "@path", "content-type", "content-digest"},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	target, _ := url.Parse("https://bpp.example.com/bpp/receiver/search?x=1")
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	if err := s.SignHTTPMessage(context.Background(), http.MethodPost, target, header, body, "bap.example.com|key-1|ed25519", privateKey, 1700000000, 1700000300); err != nil {
+		t.Fatalf("SignHTTPMessage() error = %v", err)
+	}
+
+	if got, want := header.Get("Content-Digest"), "sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:"; got != want {
+		t.Errorf("Content-Digest = %q, want %q", got, want)
+	}
+	params := `("@method" "@authority" "@path" "content-type" "content-digest");created=1700000000;expires=1700000300;keyid="bap.example.com|key-1|ed25519";alg="ed25519"`
+	if got := header.Get("Signature-Input"); got != "sig1="+params {
+		t.Errorf("Signature-Input = %q", got)
+	}
+
+	base := strings.Join([]string{
+		`"@method": POST`,
+		`"@authority": bpp.example.com`,
+		`"@path": /bpp/receiver/search`,
+		`"content-type": application/json`,
+		`"content-digest": sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:`,
+		`"@signature-params": ` + params,
+	}, "\n")
+	sigHeader := header.Get("Signature")
+	if !strings.HasPrefix(sigHeader, "sig1=:") || !strings.HasSuffix(sigHeader, ":") {
+		t.Fatalf("Signature = %q", sigHeader)
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSuffix(strings.TrimPrefix(sigHeader, "sig1=:"), ":"))
+	if err != nil {
+		t.Fatalf("decode signature: %v", err)
+	}
+	if !ed25519.Verify(pub, []byte(base), sig) {
+		t.Error("signature does not verify against the expected RFC 9421 signature base")
+	}
+}
+
+func TestSignHTTPMessageMissingComponent(t *testing.T) {
+	privateKey, _ := generateTestKeys()
+	s, _, _ := New(context.Background(), &Config{Scheme: SchemeHTTPSig})
+	target, _ := url.Parse("https://bpp.example.com/search")
+	// Content-Type is a default covered component but absent here.
+	err := s.SignHTTPMessage(context.Background(), http.MethodPost, target, http.Header{}, []byte(`{}`), "a|b|ed25519", privateKey, 1, 2)
+	if err == nil || !strings.Contains(err.Error(), "content-type") {
+		t.Errorf("SignHTTPMessage() error = %v, want missing content-type", err)
+	}
+}
+
+func TestContentDigestSHA512(t *testing.T) {
+	got := contentDigest(DigestSHA512, []byte(`{"hello": "world"}`))
+	want := "sha-512=:WZDPaVn/7XgHaAy8pmojAkGWoRx2UFChF41A2svX+TaPm+AbwAgBWnrIiYllu7BNNyealdVLvRwEmTHWXvJwew==:"
+	if got != want {
+		t.Errorf("contentDigest() = %q, want %q", got, want)
+	}
+}
@@ -12,6 +12,18 @@ import (
 
 // Config holds the configuration for the signing process.
 type Config struct {
+	// Scheme selects the signature format: SchemeBeckn (default),
+	// SchemeHTTPSig or SchemeAuto.
+	Scheme string
+	// CoveredComponents lists the RFC 9421 components to sign, e.g.
+	// "@method", "@path", "content-digest". Must include "content-digest".
+	// Defaults to DefaultCoveredComponents.
+	CoveredComponents []string
+	// Label is the RFC 9421 signature label. Defaults to "sig1".
+	Label string
+	// DigestAlgorithm is the Content-Digest algorithm: DigestSHA256 (default)
+	// or DigestSHA512.
+	DigestAlgorithm string
 }
 
 // Signer implements the Signer interface and handles the signing process.
@@ -20,8 +32,16 @@ type Signer struct {
 }
 
 // New creates a new Signer instance with the given configuration.
+// The caller's Config is never mutated.
 func New(ctx context.Context, config *Config) (*Signer, func() error, error) {
-	s := &Signer{config: config}
+	cfg := Config{}
+	if config != nil {
+		cfg = *config
+	}
+	if err := validateHTTPSigConfig(&cfg); err != nil {
+		return nil, nil, fmt.Errorf("invalid signer config: %w", err)
+	}
+	s := &Signer{config: &cfg}
 
 	return s, nil, nil
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
@@ -29,7 +30,19 @@ func (vp provider) New(ctx context.Context, config map[string]string) (definitio
 		cfg.ClockSkewTolerance = &d
 	}
 
-	return signvalidator.New(ctx, cfg)
+	cfg.Scheme = config["scheme"]
+	cfg.Label = config["label"]
+	for _, c := range strings.Split(config["requiredComponents"], ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			cfg.RequiredComponents = append(cfg.RequiredComponents, c)
+		}
+	}
+
+	v, closer, err := signvalidator.New(ctx, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return v, closer, nil
 }
 
 // Provider is the exported symbol that the plugin manager will look for.
@@ -29,6 +29,15 @@ func TestVerifierProviderSuccess(t *testing.T) {
 			ctx:    context.Background(),
 			config: map[string]string{},
 		},
+		{
+			name: "RFC 9421 config",
+			ctx:  context.Background(),
+			config: map[string]string{
+				"scheme":             "rfc9421",
+				"requiredComponents": "@method, @authority, content-digest",
+				"label":              "sig1",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -107,6 +116,18 @@ func TestVerifierProviderFailure(t *testing.T) {
 			config:  map[string]string{},
 			wantErr: true,
 		},
+		{
+			name:    "Unknown scheme",
+			ctx:     context.Background(),
+			config:  map[string]string{"scheme": "hmac"},
+			wantErr: true,
+		},
+		{
+			name:    "Required components without content-digest",
+			ctx:     context.Background(),
+			config:  map[string]string{"scheme": "auto", "requiredComponents": "@method,@path"},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,366 @@
+package signvalidator
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+)
+
+// Signature schemes selectable through Config.Scheme.
+const (
+	// SchemeBeckn validates only the Beckn Authorization header; RFC 9421
+	// headers are ignored.
+	SchemeBeckn = "beckn"
+	// SchemeAuto validates RFC 9421 HTTP Message Signatures when the request
+	// carries Signature-Input, and the Beckn Authorization header otherwise.
+	SchemeAuto = "auto"
+	// SchemeHTTPSig requires RFC 9421 from Beckn v2.0.0+ peers. Requests for
+	// older protocol versions are still validated from the Authorization header.
+	SchemeHTTPSig = "rfc9421"
+)
+
+// DefaultRequiredComponents are the RFC 9421 components a signature must
+// cover when Config.RequiredComponents is empty.
+var DefaultRequiredComponents = []string{"@method", "@path", "content-digest"}
+
+// httpSignature is a parsed RFC 9421 signature selected from the request.
+type httpSignature struct {
+	label      string
+	components []string
+	params     string // serialised @signature-params value, verbatim
+	keyID      string
+	created    int64
+	expires    int64
+	signature  []byte
+}
+
+// usesHTTPSig decides whether ctx is validated as an RFC 9421 message.
+func (v *validator) usesHTTPSig(ctx *model.StepContext) (bool, error) {
+	if v.scheme == SchemeBeckn {
+		return false, nil
+	}
+	if ctx.Request.Header.Get("Signature-Input") != "" {
+		return true, nil
+	}
+	if v.scheme == SchemeHTTPSig && model.IsAtLeastV2(ctx.ProtocolVersion) {
+		// RFC 9421 has no counterpart to the NFH-004 request-signature
+		// binding, so signers under every scheme sign solicited callbacks
+		// with the Beckn header; accept it for those.
+		if isSolicitedCallback(ctx) {
+			return false, nil
+		}
+		return false, model.NewSignValidationErr(codeSignatureMissing, fmt.Errorf("Signature-Input missing: RFC 9421 HTTP Message Signatures are required for protocol version %s", ctx.ProtocolVersion))
+	}
+	return false, nil
+}
+
+// isSolicitedCallback reports whether ctx is an on_* callback whose Beckn
+// Authorization header covers request-signature (NFH-004 §3.3).
+func isSolicitedCallback(ctx *model.StepContext) bool {
+	header := ctx.Request.Header.Get(model.AuthHeaderSubscriber)
+	_, rest, ok := strings.Cut(header, `headers="`)
+	if !ok {
+		return false
+	}
+	covered, _, ok := strings.Cut(rest, `"`)
+	if !ok || !slices.Contains(strings.Fields(covered), "request-signature") {
+		return false
+	}
+	var payload struct {
+		Context struct {
+			Action string `json:"action"`
+		} `json:"context"`
+	}
+	if err := json.Unmarshal(ctx.Body, &payload); err != nil {
+		return false
+	}
+	return strings.HasPrefix(payload.Context.Action, "on_")
+}
+
+// HTTPMessageKeyID returns the keyid of the request's RFC 9421 signature, or
+// ok == false when the request is to be validated from the Authorization header.
+func (v *validator) HTTPMessageKeyID(ctx *model.StepContext) (string, bool, error) {
+	use, err := v.usesHTTPSig(ctx)
+	if err != nil || !use {
+		return "", false, err
+	}
+	sig, err := v.parseHTTPSignature(ctx.Request.Header)
+	if err != nil {
+		return "", true, err
+	}
+	return sig.keyID, true, nil
+}
+
+// ValidateHTTPMessage verifies the request's RFC 9421 signature and its
+// Content-Digest against ctx.Body.
+func (v *validator) ValidateHTTPMessage(ctx *model.StepContext, publicKeyBase64 string, checkIdentity bool) error {
+	sig, err := v.parseHTTPSignature(ctx.Request.Header)
+	if err != nil {
+		return err
+	}
+
+	if err := checkTimestampWindow("signature", sig.created, sig.expires, v.clockSkewTolerance); err != nil {
+		return err
+	}
+
+	if err := verifyContentDigest(ctx.Request.Header.Get("Content-Digest"), ctx.Body); err != nil {
+		return err
+	}
+
+	base, err := httpSignatureBase(sig, ctx.Request)
+	if err != nil {
+		return model.NewSignValidationErr(codeSignatureInvalid, err)
+	}
+
+	decodedPublicKey, err := base64.StdEncoding.DecodeString(publicKeyBase64)
+	if err != nil {
+		return model.NewSignValidationErr(codeSignatureInvalid, fmt.Errorf("error decoding public key: %w", err))
+	}
+	if len(decodedPublicKey) != ed25519.PublicKeySize {
+		return model.NewSignValidationErr(codeSignatureInvalid, fmt.Errorf("invalid public key length %d", len(decodedPublicKey)))
+	}
+
+	if !ed25519.Verify(ed25519.PublicKey(decodedPublicKey), []byte(base), sig.signature) {
+		return model.NewSignValidationErr(codeSignatureInvalid, fmt.Errorf("signature verification failed"))
+	}
+
+	if checkIdentity {
+		var subscriberID string
+		if p := strings.SplitN(sig.keyID, "|", 2); len(p) >= 2 {
+			subscriberID = strings.TrimSpace(p[0])
+		}
+		if err := checkSubscriberIdentity(ctx, ctx.Body, subscriberID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseHTTPSignature selects the signature to verify (the configured label,
+// or the first Signature-Input member) and checks its parameters and covered
+// components against the validator's requirements.
+func (v *validator) parseHTTPSignature(header http.Header) (*httpSignature, error) {
+	inputs, err := parseSFDictionary(strings.Join(header.Values("Signature-Input"), ", "))
+	if err != nil {
+		return nil, model.NewSignValidationErr(codeSignatureInvalid, fmt.Errorf("malformed Signature-Input: %w", err))
+	}
+	signatures, err := parseSFDictionary(strings.Join(header.Values("Signature"), ", "))
+	if err != nil {
+		return nil, model.NewSignValidationErr(codeSignatureInvalid, fmt.Errorf("malformed Signature: %w", err))
+	}
+
+	var input *sfMember
+	for i := range inputs {
+		if v.label == "" || inputs[i].key == v.label {
+			input = &inputs[i]
+			break
+		}
+	}
+	if input == nil {
+		return nil, model.NewSignValidationErr(codeSignatureMissing, fmt.Errorf("no signature labelled %q in Signature-Input", v.label))
+	}
+	if !input.isList {
+		return nil, model.NewSignValidationErr(codeSignatureInvalid, fmt.Errorf("Signature-Input %q is not an inner list", input.key))
+	}
+
+	sig := &httpSignature{label: input.key, params: input.raw}
+	for _, s := range signatures {
+		if s.key != input.key {
+			continue
+		}
+		if s.item == nil {
+			break
+		}
+		if b, ok := s.item.value.([]byte); ok {
+			sig.signature = b
+		}
+		break
+	}
+	if len(sig.signature) == 0 {
+		return nil, model.NewSignValidationErr(codeSignatureMissing, fmt.Errorf("signature %q missing in Signature header", input.key))
+	}
+
+	for _, it := range input.list {
+		name, ok := it.value.(string)
+		if !ok {
+			return nil, model.NewSignValidationErr(codeSignatureInvalid, fmt.Errorf("covered component must be a string"))
+		}
+		if len(it.params) > 0 {
+			return nil, model.NewSignValidationErr(codeSignatureInvalid, fmt.Errorf("component parameters are not supported (component %q)", name))
+		}
+		sig.components = append(sig.components, name)
+	}
+
+	if alg, ok := input.params.get("alg"); ok && alg != "ed25519" {
+		return nil, model.NewSignValidationErr(codeSignatureInvalid, fmt.Errorf("unsupported algorithm %v: only ed25519 is permitted", alg))
+	}
+	keyID, _ := paramString(input.params, "keyid")
+	if keyID == "" {
+		return nil, model.NewSignValidationErr(codeSignatureInvalid, fmt.Errorf("keyid parameter missing in Signature-Input"))
+	}
+	sig.keyID = keyID
+	created, ok := paramInt(input.params, "created")
+	if !ok {
+		return nil, model.NewSignValidationErr(codeSignatureInvalid, fmt.Errorf("created parameter missing in Signature-Input"))
+	}
+	expires, ok := paramInt(input.params, "expires")
+	if !ok {
+		return nil, model.NewSignValidationErr(codeSignatureInvalid, fmt.Errorf("expires parameter missing in Signature-Input"))
+	}
+	sig.created, sig.expires = created, expires
+
+	covered := make(map[string]bool, len(sig.components))
+	for _, c := range sig.components {
+		covered[c] = true
+	}
+	for _, c := range v.requiredComponents {
+		if !covered[c] {
+			return nil, model.NewSignValidationErr(codeSignatureInvalid, fmt.Errorf("signature does not cover required component %q", c))
+		}
+	}
+	return sig, nil
+}
+
+func paramString(ps sfParams, key string) (string, bool) {
+	v, ok := ps.get(key)
+	if !ok {
+		return "", false
+	}
+	s, ok := v.(string)
+	return s, ok
+}
+
+func paramInt(ps sfParams, key string) (int64, bool) {
+	v, ok := ps.get(key)
+	if !ok {
+		return 0, false
+	}
+	n, ok := v.(int64)
+	return n, ok
+}
+
+// verifyContentDigest checks every supported digest in the Content-Digest
+// header against body. At least one supported digest must be present.
+func verifyContentDigest(value string, body []byte) error {
+	if value == "" {
+		return model.NewSignValidationErr(codeSignatureInvalid, fmt.Errorf("Content-Digest header missing"))
+	}
+	digests, err := parseSFDictionary(value)
+	if err != nil {
+		return model.NewSignValidationErr(codeSignatureInvalid, fmt.Errorf("malformed Content-Digest: %w", err))
+	}
+	checked := 0
+	for _, d := range digests {
+		var sum []byte
+		switch d.key {
+		case "sha-256":
+			s := sha256.Sum256(body)
+			sum = s[:]
+		case "sha-512":
+			s := sha512.Sum512(body)
+			sum = s[:]
+		default:
+			continue
+		}
+		var got []byte
+		if d.item != nil {
+			got, _ = d.item.value.([]byte)
+		}
+		if !bytes.Equal(got, sum) {
+			return model.NewSignValidationErr(codeSignatureInvalid, fmt.Errorf("Content-Digest %s does not match the request body", d.key))
+		}
+		checked++
+	}
+	if checked == 0 {
+		return model.NewSignValidationErr(codeSignatureInvalid, fmt.Errorf("Content-Digest carries no supported algorithm (sha-256, sha-512)"))
+	}
+	return nil
+}
+
+// httpSignatureBase rebuilds the RFC 9421 §2.5 signature base from the
+// received request.
+func httpSignatureBase(sig *httpSignature, r *http.Request) (string, error) {
+	var b strings.Builder
+	for _, c := range sig.components {
+		v, err := requestComponentValue(c, r)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "%s: %s\n", sfString(c), v)
+	}
+	fmt.Fprintf(&b, "\"@signature-params\": %s", sig.params)
+	return b.String(), nil
+}
+
+// requestComponentValue resolves a covered component against the received
+// request. @scheme and @target-uri honour X-Forwarded-Proto so that a
+// TLS-terminating ingress in front of the adapter does not break verification.
+func requestComponentValue(name string, r *http.Request) (string, error) {
+	switch name {
+	case "@method":
+		return r.Method, nil
+	case "@target-uri":
+		return requestScheme(r) + "://" + strings.ToLower(r.Host) + r.URL.RequestURI(), nil
+	case "@authority":
+		return strings.ToLower(r.Host), nil
+	case "@scheme":
+		return requestScheme(r), nil
+	case "@request-target":
+		return r.URL.RequestURI(), nil
+	case "@path":
+		if p := r.URL.EscapedPath(); p != "" {
+			return p, nil
+		}
+		return "/", nil
+	case "@query":
+		return "?" + r.URL.RawQuery, nil
+	}
+	if strings.HasPrefix(name, "@") {
+		return "", fmt.Errorf("unsupported covered component %q", name)
+	}
+	values := r.Header.Values(name)
+	if len(values) == 0 {
+		return "", fmt.Errorf("covered component %q is not present in the request", name)
+	}
+	trimmed := make([]string, len(values))
+	for i, v := range values {
+		trimmed[i] = strings.TrimSpace(v)
+	}
+	return strings.Join(trimmed, ", "), nil
+}
+
+func requestScheme(r *http.Request) string {
+	if p := r.Header.Get("X-Forwarded-Proto"); p != "" {
+		return strings.ToLower(p)
+	}
+	if r.URL.Scheme != "" {
+		return strings.ToLower(r.URL.Scheme)
+	}
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// sfString serialises s as a structured field string (RFC 8941 §3.3.3).
+func sfString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		if s[i] == '"' || s[i] == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	b.WriteByte('"')
+	return b.String()
+}
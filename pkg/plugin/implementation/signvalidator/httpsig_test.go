@@ -0,0 +1,296 @@
+package signvalidator
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+)
+
+// TestHTTPSignatureBase_RFC9421Vector checks signature base construction and
+// verification against RFC 9421 Appendix B.2.6 (Ed25519).
+func TestHTTPSignatureBase_RFC9421Vector(t *testing.T) {
+	seed, _ := hex.DecodeString("9f8362f87a484a954e6e740c5b4c0e84229139a20aa8ab56ff66586f6a7d29c5")
+	pub := ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey)
+
+	r := httptest.NewRequest(http.MethodPost, "http://example.com/foo?param=Value&Pet=dog", strings.NewReader(`{"hello": "world"}`))
+	r.Header.Set("Date", "Tue, 20 Apr 2021 02:07:55 GMT")
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Content-Length", "18")
+
+	inputs, err := parseSFDictionary(`sig-b26=("date" "@method" "@path" "@authority" "content-type" "content-length");created=1618884473;keyid="test-key-ed25519"`)
+	if err != nil {
+		t.Fatalf("parseSFDictionary() error = %v", err)
+	}
+	sig := &httpSignature{params: inputs[0].raw}
+	for _, it := range inputs[0].list {
+		sig.components = append(sig.components, it.value.(string))
+	}
+
+	base, err := httpSignatureBase(sig, r)
+	if err != nil {
+		t.Fatalf("httpSignatureBase() error = %v", err)
+	}
+	want := `"date": Tue, 20 Apr 2021 02:07:55 GMT
+"@method": POST
+"@path": /foo
+"@authority": example.com
+"content-type": application/json
+"content-length": 18
+"@signature-params": ("date" "@method" "@path" "@authority" "content-type" "content-length");created=1618884473;keyid="test-key-ed25519"`
+	if base != want {
+		t.Fatalf("httpSignatureBase() =\n%s\nwant\n%s", base, want)
+	}
+
+	signature, _ := base64.StdEncoding.DecodeString("wqcAqbmYJ2ji2glfAMaRy4gruYYnx2nEFN2HN6jrnDnQCK1u02Gb04v9EDgwUPiu4A0w6vuQv5lIp5WPpBKRCw==")
+	if !ed25519.Verify(pub, []byte(base), signature) {
+		t.Error("RFC 9421 B.2.6 signature did not verify")
+	}
+}
+
+func TestVerifyContentDigest(t *testing.T) {
+	body := []byte(`{"hello": "world"}`)
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{name: "sha-256", value: "sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:"},
+		{name: "sha-512", value: "sha-512=:WZDPaVn/7XgHaAy8pmojAkGWoRx2UFChF41A2svX+TaPm+AbwAgBWnrIiYllu7BNNyealdVLvRwEmTHWXvJwew==:"},
+		{name: "unknown algorithm ignored", value: "md5=:AAAA:, sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:"},
+		{name: "mismatch", value: "sha-256=:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=:", wantErr: true},
+		{name: "only unsupported", value: "md5=:AAAA:", wantErr: true},
+		{name: "missing", value: "", wantErr: true},
+		{name: "malformed", value: "sha-256=X48E", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyContentDigest(tt.value, body)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("verifyContentDigest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// signHTTPRequest signs r the way the signer plugin does, for round-trip tests.
+func signHTTPRequest(t *testing.T, r *http.Request, body []byte, priv ed25519.PrivateKey, components, keyID string, created, expires int64) {
+	t.Helper()
+	sum := sha256.Sum256(body)
+	r.Header.Set("Content-Digest", "sha-256=:"+base64.StdEncoding.EncodeToString(sum[:])+":")
+	input := fmt.Sprintf(`sig1=(%s);created=%d;expires=%d;keyid="%s";alg="ed25519"`, components, created, expires, keyID)
+	members, err := parseSFDictionary(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := &httpSignature{params: members[0].raw}
+	for _, it := range members[0].list {
+		sig.components = append(sig.components, it.value.(string))
+	}
+	base, err := httpSignatureBase(sig, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Header.Set("Signature-Input", input)
+	r.Header.Set("Signature", "sig1=:"+base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(base)))+":")
+}
+
+func newHTTPSigValidator(t *testing.T, cfg *Config) *validator {
+	t.Helper()
+	v, _, err := New(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return v
+}
+
+func TestValidateHTTPMessage(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	pubB64 := base64.StdEncoding.EncodeToString(pub)
+	body := []byte(`{"context":{"bap_id":"bap.example.com","version":"2.0.0"},"message":{}}`)
+	now := time.Now().Unix()
+	const defaultComponents = `"@method" "@path" "content-type" "content-digest"`
+
+	tests := []struct {
+		name       string
+		components string
+		keyID      string
+		created    int64
+		expires    int64
+		tamper     func(r *http.Request, ctx *model.StepContext)
+		wantCode   string
+	}{
+		{name: "valid"},
+		{
+			name:   "valid behind TLS ingress",
+			tamper: func(r *http.Request, _ *model.StepContext) { r.Header.Set("X-Forwarded-Proto", "https") },
+		},
+		{
+			name:     "body tampered",
+			tamper:   func(_ *http.Request, ctx *model.StepContext) { ctx.Body = []byte(`{"tampered":true}`) },
+			wantCode: codeSignatureInvalid,
+		},
+		{
+			name:     "path tampered",
+			tamper:   func(r *http.Request, _ *model.StepContext) { r.URL.Path = "/bpp/receiver/confirm" },
+			wantCode: codeSignatureInvalid,
+		},
+		{
+			name:     "covered header removed",
+			tamper:   func(r *http.Request, _ *model.StepContext) { r.Header.Del("Content-Type") },
+			wantCode: codeSignatureInvalid,
+		},
+		{
+			name:       "required component not covered",
+			components: `"@method" "content-digest"`,
+			wantCode:   codeSignatureInvalid,
+		},
+		{
+			name:     "expired",
+			created:  now - 600,
+			expires:  now - 300,
+			wantCode: codeSignatureInvalid,
+		},
+		{
+			name:     "signature header missing",
+			tamper:   func(r *http.Request, _ *model.StepContext) { r.Header.Del("Signature") },
+			wantCode: codeSignatureMissing,
+		},
+		{
+			name:     "identity mismatch",
+			keyID:    "bap.other.com|key-1|ed25519",
+			wantCode: codeUnauthorizedAction,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			components, keyID, created, expires := tt.components, tt.keyID, tt.created, tt.expires
+			if components == "" {
+				components = defaultComponents
+			}
+			if keyID == "" {
+				keyID = "bap.example.com|key-1|ed25519"
+			}
+			if created == 0 {
+				created, expires = now, now+300
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "http://bpp.example.com/bpp/receiver/search", strings.NewReader(string(body)))
+			r.Header.Set("Content-Type", "application/json")
+			signHTTPRequest(t, r, body, priv, components, keyID, created, expires)
+			ctx := &model.StepContext{Context: context.Background(), Request: r, Body: body, Role: model.RoleBPP, ProtocolVersion: "2.0.0"}
+			if tt.tamper != nil {
+				tt.tamper(r, ctx)
+			}
+
+			v := newHTTPSigValidator(t, &Config{Scheme: SchemeAuto, RequiredComponents: []string{"@method", "@path", "content-digest"}})
+			err := v.ValidateHTTPMessage(ctx, pubB64, true)
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("ValidateHTTPMessage() error = %v", err)
+				}
+				return
+			}
+			var coded *model.CodedErr
+			if !errors.As(err, &coded) || coded.Code != tt.wantCode {
+				t.Fatalf("ValidateHTTPMessage() error = %v, want code %s", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestHTTPMessageKeyID(t *testing.T) {
+	const input = `sig1=("@method" "content-digest");created=1;expires=2;keyid="bap.example.com|key-1|ed25519";alg="ed25519"`
+	const ackAuth = `Signature keyId="bpp.example.com|key-1|ed25519",algorithm="ed25519",created="1",expires="2",headers="(created) (expires) digest request-signature",signature="AAAA"`
+	const plainAuth = `Signature keyId="bpp.example.com|key-1|ed25519",algorithm="ed25519",created="1",expires="2",headers="(created) (expires) digest",signature="AAAA"`
+	tests := []struct {
+		name      string
+		scheme    string
+		version   string
+		input     string
+		auth      string
+		action    string
+		wantKeyID string
+		wantUse   bool
+		wantErr   bool
+	}{
+		{name: "beckn scheme ignores RFC 9421 headers", scheme: SchemeBeckn, version: "2.0.0", input: input},
+		{name: "auto with Signature-Input", scheme: SchemeAuto, version: "1.1.0", input: input, wantKeyID: "bap.example.com|key-1|ed25519", wantUse: true},
+		{name: "auto without Signature-Input", scheme: SchemeAuto, version: "2.0.0"},
+		{name: "rfc9421 requires it from v2 peers", scheme: SchemeHTTPSig, version: "2.0.0", wantErr: true},
+		{name: "rfc9421 accepts legacy from v1 peers", scheme: SchemeHTTPSig, version: "1.1.0"},
+		{name: "rfc9421 accepts a Beckn solicited callback", scheme: SchemeHTTPSig, version: "2.0.0", auth: ackAuth, action: "on_select"},
+		{name: "rfc9421 rejects request-signature on a request", scheme: SchemeHTTPSig, version: "2.0.0", auth: ackAuth, action: "select", wantErr: true},
+		{name: "rfc9421 rejects a Beckn unsolicited callback", scheme: SchemeHTTPSig, version: "2.0.0", auth: plainAuth, action: "on_status", wantErr: true},
+		{name: "unsupported algorithm", scheme: SchemeAuto, version: "2.0.0", input: strings.Replace(input, `alg="ed25519"`, `alg="rsa-pss-sha512"`, 1), wantUse: true, wantErr: true},
+		{name: "missing keyid", scheme: SchemeAuto, version: "2.0.0", input: `sig1=("@method" "content-digest");created=1;expires=2`, wantUse: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/search", nil)
+			if tt.input != "" {
+				r.Header.Set("Signature-Input", tt.input)
+				r.Header.Set("Signature", "sig1=:AAAA:")
+			}
+			if tt.auth != "" {
+				r.Header.Set(model.AuthHeaderSubscriber, tt.auth)
+			}
+			body := []byte(`{"context":{"action":"` + tt.action + `"}}`)
+			v := newHTTPSigValidator(t, &Config{Scheme: tt.scheme, RequiredComponents: []string{"content-digest"}})
+			keyID, use, err := v.HTTPMessageKeyID(&model.StepContext{Context: context.Background(), Request: r, Body: body, ProtocolVersion: tt.version})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("HTTPMessageKeyID() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if use != tt.wantUse || keyID != tt.wantKeyID {
+				t.Errorf("HTTPMessageKeyID() = %q, %v, want %q, %v", keyID, use, tt.wantKeyID, tt.wantUse)
+			}
+		})
+	}
+}
+
+func TestParseSFDictionary(t *testing.T) {
+	members, err := parseSFDictionary(`a=(), b=("x" "y";p);q=1, c=:AQID:, d, e="s\"q";k=tok`)
+	if err != nil {
+		t.Fatalf("parseSFDictionary() error = %v", err)
+	}
+	if len(members) != 5 {
+		t.Fatalf("got %d members, want 5", len(members))
+	}
+	if !members[0].isList || len(members[0].list) != 0 {
+		t.Errorf("a = %+v, want empty inner list", members[0])
+	}
+	if members[1].raw != `("x" "y";p);q=1` {
+		t.Errorf("b raw = %q", members[1].raw)
+	}
+	if q, _ := members[1].params.get("q"); q != int64(1) {
+		t.Errorf("b;q = %v, want 1", q)
+	}
+	if b, _ := members[2].item.value.([]byte); string(b) != "\x01\x02\x03" {
+		t.Errorf("c = %v", members[2].item.value)
+	}
+	if members[3].item.value != true {
+		t.Errorf("d = %v, want true", members[3].item.value)
+	}
+	if members[4].item.value != "s\"q" {
+		t.Errorf("e = %v", members[4].item.value)
+	}
+	if k, _ := members[4].item.params.get("k"); k != sfToken("tok") {
+		t.Errorf("e;k = %v", k)
+	}
+
+	for _, bad := range []string{`a=(`, `a="unterminated`, `A=1`, `a=1,`, `a=:!!:`} {
+		if _, err := parseSFDictionary(bad); err == nil {
+			t.Errorf("parseSFDictionary(%q) expected error", bad)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package signvalidator
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// This file holds the subset of RFC 8941 Structured Field Values needed to
+// read Signature-Input, Signature and Content-Digest: dictionaries whose
+// members are items or inner lists, with parameters.
+
+// sfToken distinguishes a bare token from a quoted string.
+type sfToken string
+
+// sfParam is one ;key=value parameter. A parameter without a value is true.
+type sfParam struct {
+	key   string
+	value any
+}
+
+type sfParams []sfParam
+
+// get returns the value of parameter key.
+func (ps sfParams) get(key string) (any, bool) {
+	for _, p := range ps {
+		if p.key == key {
+			return p.value, true
+		}
+	}
+	return nil, false
+}
+
+// sfItem is a bare item (int64, float64, string, sfToken, []byte or bool)
+// with its parameters.
+type sfItem struct {
+	value  any
+	params sfParams
+}
+
+// sfMember is one dictionary member. For inner lists, list holds the items
+// and params the list's parameters; otherwise item is set. raw is the member
+// value exactly as it appeared on the wire, which RFC 9421 requires for the
+// @signature-params line.
+type sfMember struct {
+	key    string
+	item   *sfItem
+	list   []sfItem
+	isList bool
+	params sfParams
+	raw    string
+}
+
+type sfParser struct {
+	s string
+	i int
+}
+
+// parseSFDictionary parses a structured field dictionary.
+func parseSFDictionary(s string) ([]sfMember, error) {
+	p := &sfParser{s: s}
+	p.skipSP()
+	var members []sfMember
+	for p.i < len(p.s) {
+		key, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		m := sfMember{key: key}
+		start := p.i
+		if p.peek() == '=' {
+			p.i++
+			start = p.i
+			if p.peek() == '(' {
+				m.isList = true
+				m.list, m.params, err = p.innerList()
+			} else {
+				var it sfItem
+				it, err = p.item()
+				m.item = &it
+			}
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			params, err := p.parameters()
+			if err != nil {
+				return nil, err
+			}
+			m.item = &sfItem{value: true, params: params}
+		}
+		m.raw = p.s[start:p.i]
+		members = append(members, m)
+
+		p.skipOWS()
+		if p.i >= len(p.s) {
+			break
+		}
+		if p.s[p.i] != ',' {
+			return nil, fmt.Errorf("expected ',' at offset %d", p.i)
+		}
+		p.i++
+		p.skipOWS()
+		if p.i >= len(p.s) {
+			return nil, fmt.Errorf("trailing ',' in dictionary")
+		}
+	}
+	return members, nil
+}
+
+func (p *sfParser) peek() byte {
+	if p.i < len(p.s) {
+		return p.s[p.i]
+	}
+	return 0
+}
+
+func (p *sfParser) skipSP() {
+	for p.i < len(p.s) && p.s[p.i] == ' ' {
+		p.i++
+	}
+}
+
+func (p *sfParser) skipOWS() {
+	for p.i < len(p.s) && (p.s[p.i] == ' ' || p.s[p.i] == '\t') {
+		p.i++
+	}
+}
+
+func (p *sfParser) key() (string, error) {
+	start := p.i
+	c := p.peek()
+	if !(c == '*' || (c >= 'a' && c <= 'z')) {
+		return "", fmt.Errorf("invalid key at offset %d", p.i)
+	}
+	for p.i < len(p.s) {
+		c := p.s[p.i]
+		if !((c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '_' || c == '-' || c == '.' || c == '*') {
+			break
+		}
+		p.i++
+	}
+	return p.s[start:p.i], nil
+}
+
+func (p *sfParser) innerList() ([]sfItem, sfParams, error) {
+	p.i++ // '('
+	var items []sfItem
+	for {
+		p.skipSP()
+		if p.peek() == ')' {
+			p.i++
+			params, err := p.parameters()
+			return items, params, err
+		}
+		if p.i >= len(p.s) {
+			return nil, nil, fmt.Errorf("unterminated inner list")
+		}
+		it, err := p.item()
+		if err != nil {
+			return nil, nil, err
+		}
+		items = append(items, it)
+		if c := p.peek(); c != ' ' && c != ')' {
+			return nil, nil, fmt.Errorf("expected ' ' or ')' at offset %d", p.i)
+		}
+	}
+}
+
+func (p *sfParser) item() (sfItem, error) {
+	v, err := p.bareItem()
+	if err != nil {
+		return sfItem{}, err
+	}
+	params, err := p.parameters()
+	if err != nil {
+		return sfItem{}, err
+	}
+	return sfItem{value: v, params: params}, nil
+}
+
+func (p *sfParser) parameters() (sfParams, error) {
+	var params sfParams
+	for p.peek() == ';' {
+		p.i++
+		p.skipSP()
+		key, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		var v any = true
+		if p.peek() == '=' {
+			p.i++
+			if v, err = p.bareItem(); err != nil {
+				return nil, err
+			}
+		}
+		params = append(params, sfParam{key: key, value: v})
+	}
+	return params, nil
+}
+
+func (p *sfParser) bareItem() (any, error) {
+	c := p.peek()
+	switch {
+	case c == '"':
+		return p.str()
+	case c == ':':
+		return p.byteSeq()
+	case c == '?':
+		if p.i+1 < len(p.s) && (p.s[p.i+1] == '0' || p.s[p.i+1] == '1') {
+			p.i += 2
+			return p.s[p.i-1] == '1', nil
+		}
+		return nil, fmt.Errorf("invalid boolean at offset %d", p.i)
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.number()
+	case c == '*' || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z'):
+		start := p.i
+		for p.i < len(p.s) {
+			c := p.s[p.i]
+			if c <= ' ' || c >= 0x7f || strings.IndexByte(`"(),;<=>?@[\]{}`, c) >= 0 {
+				break
+			}
+			p.i++
+		}
+		return sfToken(p.s[start:p.i]), nil
+	}
+	return nil, fmt.Errorf("unexpected character at offset %d", p.i)
+}
+
+func (p *sfParser) str() (string, error) {
+	p.i++ // opening quote
+	var b strings.Builder
+	for p.i < len(p.s) {
+		c := p.s[p.i]
+		p.i++
+		switch {
+		case c == '\\':
+			if p.i >= len(p.s) || (p.s[p.i] != '"' && p.s[p.i] != '\\') {
+				return "", fmt.Errorf("invalid escape in string")
+			}
+			b.WriteByte(p.s[p.i])
+			p.i++
+		case c == '"':
+			return b.String(), nil
+		case c < 0x20 || c >= 0x7f:
+			return "", fmt.Errorf("invalid character in string")
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("unterminated string")
+}
+
+func (p *sfParser) byteSeq() ([]byte, error) {
+	p.i++ // opening colon
+	end := strings.IndexByte(p.s[p.i:], ':')
+	if end < 0 {
+		return nil, fmt.Errorf("unterminated byte sequence")
+	}
+	raw := p.s[p.i : p.i+end]
+	p.i += end + 1
+	b, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid byte sequence: %w", err)
+	}
+	return b, nil
+}
+
+func (p *sfParser) number() (any, error) {
+	start := p.i
+	if p.peek() == '-' {
+		p.i++
+	}
+	decimal := false
+	for p.i < len(p.s) {
+		c := p.s[p.i]
+		if c == '.' && !decimal {
+			decimal = true
+		} else if c < '0' || c > '9' {
+			break
+		}
+		p.i++
+	}
+	if decimal {
+		return strconv.ParseFloat(p.s[start:p.i], 64)
+	}
+	return strconv.ParseInt(p.s[start:p.i], 10, 64)
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -43,11 +44,25 @@ type Config struct {
 	// per-subnet via the plugin config key "clockSkewToleranceSeconds".
 	// The `expires` field always uses zero tolerance regardless of this value.
 	ClockSkewTolerance *time.Duration
+
+	// Scheme selects which signature formats are accepted: SchemeBeckn
+	// (default), SchemeAuto or SchemeHTTPSig.
+	Scheme string
+	// RequiredComponents lists the RFC 9421 components an HTTP Message
+	// Signature must cover. Must include "content-digest". Defaults to
+	// DefaultRequiredComponents.
+	RequiredComponents []string
+	// Label selects the RFC 9421 signature to verify when a request carries
+	// several. Empty means the first Signature-Input member.
+	Label string
 }
 
 // validator implements the validator interface.
 type validator struct {
 	clockSkewTolerance time.Duration // resolved at construction; never changes
+	scheme             string
+	requiredComponents []string
+	label              string
 }
 
 // New creates a new Verifier instance.
@@ -61,7 +76,30 @@ func New(ctx context.Context, config *Config) (*validator, func() error, error)
 		log.Warnf(ctx, "signvalidator: clockSkewToleranceSeconds=%ds exceeds recommended maximum of %ds; large tolerances widen the replay window",
 			int(tolerance.Seconds()), int(maxClockSkewTolerance.Seconds()))
 	}
-	return &validator{clockSkewTolerance: tolerance}, nil, nil
+
+	scheme := config.Scheme
+	switch scheme {
+	case "":
+		scheme = SchemeBeckn
+	case SchemeBeckn, SchemeAuto, SchemeHTTPSig:
+	default:
+		return nil, nil, fmt.Errorf("signvalidator: unsupported signature scheme %q: expected %s, %s or %s", scheme, SchemeBeckn, SchemeAuto, SchemeHTTPSig)
+	}
+
+	required := config.RequiredComponents
+	if len(required) == 0 {
+		required = DefaultRequiredComponents
+	}
+	if !slices.Contains(required, "content-digest") {
+		return nil, nil, fmt.Errorf("signvalidator: required components must include \"content-digest\" so the signature protects the body")
+	}
+
+	return &validator{
+		clockSkewTolerance: tolerance,
+		scheme:             scheme,
+		requiredComponents: required,
+		label:              config.Label,
+	}, nil, nil
 }
 
 // Validate verifies the 3-line signing string for inbound requests.
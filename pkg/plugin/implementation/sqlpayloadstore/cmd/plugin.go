@@ -0,0 +1,22 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/implementation/sqlpayloadstore"
+)
+
+type provider struct{}
+
+// New ignores cache: entries are kept in the configured database.
+func (p provider) New(ctx context.Context, _ definition.Cache, namespace string, cfg map[string]string) (definition.PayloadStore, func() error, error) {
+	if ctx == nil {
+		return nil, nil, errors.New("context cannot be nil")
+	}
+	return sqlpayloadstore.New(ctx, namespace, cfg)
+}
+
+// Provider is the plugin entry point looked up by the plugin manager.
+var Provider = provider{}
@@ -0,0 +1,208 @@
+package sqlpayloadstore
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // pgx database/sql driver ("pgx")
+	_ "github.com/mattn/go-sqlite3"    // SQLite database/sql driver ("sqlite3")
+)
+
+//go:embed migrations/postgres/*.sql migrations/sqlite/*.sql
+var migrationFS embed.FS
+
+// Supported values of the driver config key.
+const (
+	driverPostgres = "postgres"
+	driverSQLite   = "sqlite"
+)
+
+// partitionPrefix names the daily Postgres partitions: onix_payloads_p20260314.
+const partitionPrefix = "onix_payloads_p"
+
+// partitionsAhead is how many days past today the maintenance loop keeps
+// partitions created for, so a write never waits on DDL.
+const partitionsAhead = 2
+
+// dialect holds what differs between the Postgres and SQLite backends.
+type dialect struct {
+	driver string
+	// sqlDriver is the database/sql driver name registered for the backend.
+	sqlDriver string
+	// partitioned is true when onix_payloads is partitioned by day.
+	partitioned bool
+	// expiryTable is where maintenance deletes expired rows. On Postgres
+	// that is only the default partition: daily partitions are dropped
+	// whole, and the partitioned parent has no expires_at index to scan.
+	expiryTable string
+}
+
+var dialects = map[string]dialect{
+	driverPostgres: {driver: driverPostgres, sqlDriver: "pgx", partitioned: true, expiryTable: "onix_payloads_default"},
+	driverSQLite:   {driver: driverSQLite, sqlDriver: "sqlite3", expiryTable: "onix_payloads"},
+}
+
+// rebind rewrites a query written with ? placeholders for the backend:
+// Postgres takes $1, $2, ...; SQLite takes ? as written.
+func (d dialect) rebind(query string) string {
+	if d.driver != driverPostgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// open opens the backend's *sql.DB for dsn. SQLite allows one writer at a
+// time, so its pool is limited to a single connection.
+func (d dialect) open(dsn string) (*sql.DB, error) {
+	db, err := sql.Open(d.sqlDriver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if d.driver == driverSQLite {
+		db.SetMaxOpenConns(1)
+	}
+	return db, nil
+}
+
+// migrate applies the backend's embedded migrations in filename order,
+// recording each in onix_payload_migrations so it runs once. SQLite cannot
+// add a column only if it is missing, so unlike the crawler store's
+// migrations these are not re-run on every startup.
+func (d dialect) migrate(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS onix_payload_migrations (name TEXT PRIMARY KEY)`); err != nil {
+		return fmt.Errorf("sqlpayloadstore: creating migrations table: %w", err)
+	}
+	dir := "migrations/" + d.driver
+	entries, err := migrationFS.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("sqlpayloadstore: reading migrations: %w", err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		var applied int
+		if err := db.QueryRowContext(ctx, d.rebind(`SELECT COUNT(*) FROM onix_payload_migrations WHERE name = ?`), n).Scan(&applied); err != nil {
+			return fmt.Errorf("sqlpayloadstore: checking %s: %w", n, err)
+		}
+		if applied > 0 {
+			continue
+		}
+		b, err := migrationFS.ReadFile(dir + "/" + n)
+		if err != nil {
+			return fmt.Errorf("sqlpayloadstore: reading %s: %w", n, err)
+		}
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("sqlpayloadstore: applying %s: %w", n, err)
+		}
+		if _, err := tx.ExecContext(ctx, string(b)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("sqlpayloadstore: applying %s: %w", n, err)
+		}
+		if _, err := tx.ExecContext(ctx, d.rebind(`INSERT INTO onix_payload_migrations (name) VALUES (?)`), n); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("sqlpayloadstore: recording %s: %w", n, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("sqlpayloadstore: applying %s: %w", n, err)
+		}
+	}
+	return nil
+}
+
+// partitionName returns the name of the partition holding day's rows.
+func partitionName(day time.Time) string {
+	return partitionPrefix + day.UTC().Format("20060102")
+}
+
+// ensurePartitions creates the daily partitions from today through
+// partitionsAhead days ahead. It is a no-op for unpartitioned backends.
+func (d dialect) ensurePartitions(ctx context.Context, db *sql.DB, now time.Time) error {
+	if !d.partitioned {
+		return nil
+	}
+	today := now.UTC().Truncate(24 * time.Hour)
+	for i := 0; i <= partitionsAhead; i++ {
+		day := today.AddDate(0, 0, i)
+		stmt := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s PARTITION OF onix_payloads FOR VALUES FROM ('%s') TO ('%s')`,
+			partitionName(day), day.Format(time.RFC3339), day.AddDate(0, 0, 1).Format(time.RFC3339))
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("sqlpayloadstore: creating partition %s: %w", partitionName(day), err)
+		}
+	}
+	return nil
+}
+
+// dropPartitions drops the daily partitions that end on or before cutoff
+// and returns how many it dropped. It is a no-op for unpartitioned backends.
+func (d dialect) dropPartitions(ctx context.Context, db *sql.DB, cutoff time.Time) (int, error) {
+	if !d.partitioned {
+		return 0, nil
+	}
+	rows, err := db.QueryContext(ctx, `
+		SELECT c.relname FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		JOIN pg_class p ON p.oid = i.inhparent
+		WHERE p.relname = 'onix_payloads'`)
+	if err != nil {
+		return 0, fmt.Errorf("sqlpayloadstore: listing partitions: %w", err)
+	}
+	var expired []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("sqlpayloadstore: listing partitions: %w", err)
+		}
+		if partitionExpired(name, cutoff) {
+			expired = append(expired, name)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("sqlpayloadstore: listing partitions: %w", err)
+	}
+
+	for _, name := range expired {
+		if _, err := db.ExecContext(ctx, `DROP TABLE IF EXISTS `+name); err != nil {
+			return 0, fmt.Errorf("sqlpayloadstore: dropping partition %s: %w", name, err)
+		}
+	}
+	return len(expired), nil
+}
+
+// partitionExpired reports whether name is a daily partition whose day ends
+// on or before cutoff. Other partitions, such as the default one, never
+// expire as a whole.
+func partitionExpired(name string, cutoff time.Time) bool {
+	suffix, ok := strings.CutPrefix(name, partitionPrefix)
+	if !ok {
+		return false
+	}
+	day, err := time.Parse("20060102", suffix)
+	if err != nil {
+		return false
+	}
+	return !day.AddDate(0, 0, 1).After(cutoff)
+}
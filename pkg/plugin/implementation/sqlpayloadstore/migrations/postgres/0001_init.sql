@@ -0,0 +1,33 @@
+-- Payload history, range-partitioned by stored_at. Daily partitions are
+-- created ahead of time and dropped past retention by the store's
+-- maintenance loop; the default partition only catches rows outside them,
+-- and is the only place maintenance deletes expired rows. Applied once and
+-- recorded in onix_payload_migrations.
+
+CREATE TABLE IF NOT EXISTS onix_payloads (
+  namespace      text NOT NULL,
+  message_id     text NOT NULL,
+  transaction_id text NOT NULL DEFAULT '',
+  network_id     text NOT NULL DEFAULT '',
+  action         text NOT NULL DEFAULT '',
+  subscriber_id  text NOT NULL DEFAULT '',
+  role           text NOT NULL DEFAULT '',
+  request_body   bytea,
+  signature      text NOT NULL DEFAULT '',
+  stored_at      timestamptz NOT NULL,
+  expires_at     timestamptz NOT NULL,
+  PRIMARY KEY (namespace, message_id, stored_at)
+) PARTITION BY RANGE (stored_at);
+
+CREATE TABLE IF NOT EXISTS onix_payloads_default PARTITION OF onix_payloads DEFAULT;
+
+CREATE INDEX IF NOT EXISTS ix_onix_payloads_message
+  ON onix_payloads (namespace, message_id);
+CREATE INDEX IF NOT EXISTS ix_onix_payloads_transaction
+  ON onix_payloads (namespace, transaction_id, stored_at);
+CREATE INDEX IF NOT EXISTS ix_onix_payloads_subscriber
+  ON onix_payloads (namespace, subscriber_id, stored_at);
+CREATE INDEX IF NOT EXISTS ix_onix_payloads_action
+  ON onix_payloads (namespace, action, stored_at);
+CREATE INDEX IF NOT EXISTS ix_onix_payloads_network
+  ON onix_payloads (namespace, network_id, stored_at);
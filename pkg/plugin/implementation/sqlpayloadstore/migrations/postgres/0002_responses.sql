@@ -0,0 +1,13 @@
+-- Synchronous responses are stored as their own rows next to the request
+-- they answer, so the key gains kind: a request and its response share a
+-- message_id and can share a stored_at.
+
+ALTER TABLE onix_payloads ADD COLUMN IF NOT EXISTS kind text NOT NULL DEFAULT 'request';
+ALTER TABLE onix_payloads ADD COLUMN IF NOT EXISTS direction text NOT NULL DEFAULT '';
+ALTER TABLE onix_payloads ADD COLUMN IF NOT EXISTS status text NOT NULL DEFAULT '';
+ALTER TABLE onix_payloads ADD COLUMN IF NOT EXISTS status_code integer NOT NULL DEFAULT 0;
+ALTER TABLE onix_payloads ADD COLUMN IF NOT EXISTS response_body bytea;
+ALTER TABLE onix_payloads ADD COLUMN IF NOT EXISTS response_headers text NOT NULL DEFAULT '';
+
+ALTER TABLE onix_payloads DROP CONSTRAINT IF EXISTS onix_payloads_pkey;
+ALTER TABLE onix_payloads ADD PRIMARY KEY (namespace, message_id, kind, stored_at);
@@ -0,0 +1,31 @@
+-- Payload history for single-node use. SQLite has no partitioning, so the
+-- maintenance loop deletes rows past retention instead of dropping
+-- partitions. Applied once and recorded in onix_payload_migrations.
+
+CREATE TABLE IF NOT EXISTS onix_payloads (
+  namespace      TEXT NOT NULL,
+  message_id     TEXT NOT NULL,
+  transaction_id TEXT NOT NULL DEFAULT '',
+  network_id     TEXT NOT NULL DEFAULT '',
+  action         TEXT NOT NULL DEFAULT '',
+  subscriber_id  TEXT NOT NULL DEFAULT '',
+  role           TEXT NOT NULL DEFAULT '',
+  request_body   BLOB,
+  signature      TEXT NOT NULL DEFAULT '',
+  stored_at      TIMESTAMP NOT NULL,
+  expires_at     TIMESTAMP NOT NULL,
+  PRIMARY KEY (namespace, message_id, stored_at)
+);
+
+CREATE INDEX IF NOT EXISTS ix_onix_payloads_message
+  ON onix_payloads (namespace, message_id);
+CREATE INDEX IF NOT EXISTS ix_onix_payloads_transaction
+  ON onix_payloads (namespace, transaction_id, stored_at);
+CREATE INDEX IF NOT EXISTS ix_onix_payloads_subscriber
+  ON onix_payloads (namespace, subscriber_id, stored_at);
+CREATE INDEX IF NOT EXISTS ix_onix_payloads_action
+  ON onix_payloads (namespace, action, stored_at);
+CREATE INDEX IF NOT EXISTS ix_onix_payloads_network
+  ON onix_payloads (namespace, network_id, stored_at);
+CREATE INDEX IF NOT EXISTS ix_onix_payloads_expires
+  ON onix_payloads (expires_at);
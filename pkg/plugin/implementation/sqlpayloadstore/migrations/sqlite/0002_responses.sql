@@ -0,0 +1,45 @@
+-- Synchronous responses are stored as their own rows next to the request
+-- they answer, so the key gains kind: a request and its response share a
+-- message_id and can share a stored_at. SQLite cannot change a primary key
+-- in place, so the table is rebuilt.
+
+CREATE TABLE onix_payloads_new (
+  namespace        TEXT NOT NULL,
+  message_id       TEXT NOT NULL,
+  transaction_id   TEXT NOT NULL DEFAULT '',
+  network_id       TEXT NOT NULL DEFAULT '',
+  action           TEXT NOT NULL DEFAULT '',
+  subscriber_id    TEXT NOT NULL DEFAULT '',
+  role             TEXT NOT NULL DEFAULT '',
+  kind             TEXT NOT NULL DEFAULT 'request',
+  direction        TEXT NOT NULL DEFAULT '',
+  request_body     BLOB,
+  signature        TEXT NOT NULL DEFAULT '',
+  status           TEXT NOT NULL DEFAULT '',
+  status_code      INTEGER NOT NULL DEFAULT 0,
+  response_body    BLOB,
+  response_headers TEXT NOT NULL DEFAULT '',
+  stored_at        TIMESTAMP NOT NULL,
+  expires_at       TIMESTAMP NOT NULL,
+  PRIMARY KEY (namespace, message_id, kind, stored_at)
+);
+
+INSERT INTO onix_payloads_new (namespace, message_id, transaction_id, network_id, action, subscriber_id, role, request_body, signature, stored_at, expires_at)
+  SELECT namespace, message_id, transaction_id, network_id, action, subscriber_id, role, request_body, signature, stored_at, expires_at
+  FROM onix_payloads;
+
+DROP TABLE onix_payloads;
+ALTER TABLE onix_payloads_new RENAME TO onix_payloads;
+
+CREATE INDEX ix_onix_payloads_message
+  ON onix_payloads (namespace, message_id);
+CREATE INDEX ix_onix_payloads_transaction
+  ON onix_payloads (namespace, transaction_id, stored_at);
+CREATE INDEX ix_onix_payloads_subscriber
+  ON onix_payloads (namespace, subscriber_id, stored_at);
+CREATE INDEX ix_onix_payloads_action
+  ON onix_payloads (namespace, action, stored_at);
+CREATE INDEX ix_onix_payloads_network
+  ON onix_payloads (namespace, network_id, stored_at);
+CREATE INDEX ix_onix_payloads_expires
+  ON onix_payloads (expires_at);
@@ -0,0 +1,498 @@
+// Package sqlpayloadstore is a definition.PayloadStore backed by Postgres,
+// or SQLite for single-node use. Unlike the cache-backed payloadstore,
+// history outlives cache TTLs, bodies stay out of Redis memory, and entries
+// can be queried by subscriber, action, network and time range.
+package sqlpayloadstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+)
+
+const (
+	defaultRetention           = 30 * 24 * time.Hour
+	defaultMaxBodyBytes        = int64(1 << 20) // 1 MiB
+	defaultMaintenanceInterval = time.Hour
+	defaultQueryLimit          = 100
+	maxQueryLimit              = 1000
+)
+
+// Config holds all configuration for the SQL PayloadStore plugin.
+type Config struct {
+	Driver    string // "postgres" or "sqlite"
+	DSN       string
+	Retention time.Duration
+	// MaintenanceInterval is how often partitions are created ahead and
+	// entries past Retention removed.
+	MaintenanceInterval time.Duration
+	MaxBodyBytes        int64
+	StoreBody           bool
+	StoreSignature      bool
+}
+
+// ParseConfig parses a map[string]string config into a Config, applying defaults for absent keys.
+func ParseConfig(cfg map[string]string) (*Config, error) {
+	c := &Config{
+		Driver:              driverPostgres,
+		DSN:                 strings.TrimSpace(cfg["dsn"]),
+		Retention:           defaultRetention,
+		MaintenanceInterval: defaultMaintenanceInterval,
+		MaxBodyBytes:        defaultMaxBodyBytes,
+		StoreBody:           true,
+		StoreSignature:      true,
+	}
+
+	if raw := strings.TrimSpace(cfg["driver"]); raw != "" {
+		c.Driver = raw
+	}
+	if _, ok := dialects[c.Driver]; !ok {
+		return nil, fmt.Errorf("sqlpayloadstore: unsupported driver %q (expected %s or %s)", c.Driver, driverPostgres, driverSQLite)
+	}
+	if c.DSN == "" {
+		return nil, fmt.Errorf("sqlpayloadstore: dsn is required")
+	}
+
+	durations := []struct {
+		key string
+		dst *time.Duration
+	}{
+		{"retention", &c.Retention},
+		{"maintenanceInterval", &c.MaintenanceInterval},
+	}
+	for _, d := range durations {
+		raw := cfg[d.key]
+		if raw == "" {
+			continue
+		}
+		v, err := time.ParseDuration(raw)
+		if err != nil {
+			return nil, fmt.Errorf("sqlpayloadstore: invalid %s %q: %w", d.key, raw, err)
+		}
+		if v <= 0 {
+			return nil, fmt.Errorf("sqlpayloadstore: %s must be positive", d.key)
+		}
+		*d.dst = v
+	}
+
+	if raw := cfg["maxBodyBytes"]; raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("sqlpayloadstore: invalid maxBodyBytes %q: %w", raw, err)
+		}
+		if n < 0 {
+			return nil, fmt.Errorf("sqlpayloadstore: maxBodyBytes must be >= 0, got %d", n)
+		}
+		c.MaxBodyBytes = n
+	}
+
+	for key, dst := range map[string]*bool{"storeBody": &c.StoreBody, "storeSignature": &c.StoreSignature} {
+		raw := cfg[key]
+		if raw == "" {
+			continue
+		}
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("sqlpayloadstore: invalid %s %q: %w", key, raw, err)
+		}
+		*dst = b
+	}
+
+	return c, nil
+}
+
+// becknCtxFields holds the four Beckn context fields extracted from a request body.
+type becknCtxFields struct {
+	MessageID     string
+	TransactionID string
+	NetworkID     string
+	Action        string
+}
+
+// parseBecknCtx extracts the four context fields from a Beckn request body,
+// accepting snake_case and camelCase keys as payloadstore does.
+func parseBecknCtx(body []byte) becknCtxFields {
+	var wrapper struct {
+		Context map[string]json.RawMessage `json:"context"`
+	}
+	if err := json.Unmarshal(body, &wrapper); err != nil || len(wrapper.Context) == 0 {
+		return becknCtxFields{}
+	}
+	c := wrapper.Context
+	return becknCtxFields{
+		MessageID:     firstJSONString(c, "message_id", "messageId"),
+		TransactionID: firstJSONString(c, "transaction_id", "transactionId"),
+		NetworkID:     firstJSONString(c, "network_id", "networkId"),
+		Action:        firstJSONString(c, "action"),
+	}
+}
+
+// firstJSONString returns the first non-empty string found under any of the given keys.
+func firstJSONString(m map[string]json.RawMessage, keys ...string) string {
+	for _, k := range keys {
+		if raw, ok := m[k]; ok {
+			var s string
+			if json.Unmarshal(raw, &s) == nil && s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+type store struct {
+	db        *sql.DB
+	dialect   dialect
+	config    *Config
+	namespace string
+	// now is the clock, replaced in tests.
+	now func() time.Time
+}
+
+// New opens the configured database, applies migrations and starts the
+// maintenance loop. The returned closer stops the loop and closes the
+// database.
+func New(ctx context.Context, namespace string, cfg map[string]string) (*store, func() error, error) {
+	if namespace == "" {
+		return nil, nil, fmt.Errorf("sqlpayloadstore: namespace cannot be empty")
+	}
+	config, err := ParseConfig(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	d := dialects[config.Driver]
+	db, err := d.open(config.DSN)
+	if err != nil {
+		return nil, nil, fmt.Errorf("sqlpayloadstore: opening database: %w", err)
+	}
+	if err := d.migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+
+	s := &store{db: db, dialect: d, config: config, namespace: namespace, now: time.Now}
+	if err := s.maintain(ctx); err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+
+	loopCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.maintenanceLoop(loopCtx)
+	}()
+	return s, func() error {
+		cancel()
+		wg.Wait()
+		return db.Close()
+	}, nil
+}
+
+// maintenanceLoop runs maintain every MaintenanceInterval until ctx ends.
+func (s *store) maintenanceLoop(ctx context.Context) {
+	ticker := time.NewTicker(s.config.MaintenanceInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.maintain(ctx); err != nil {
+				log.Errorf(ctx, err, "sqlpayloadstore: maintenance failed")
+			}
+		}
+	}
+}
+
+// maintain creates upcoming daily partitions and removes entries past
+// retention: whole partitions on Postgres, rows elsewhere and in the
+// Postgres default partition.
+func (s *store) maintain(ctx context.Context) error {
+	now := s.now().UTC()
+	if err := s.dialect.ensurePartitions(ctx, s.db, now); err != nil {
+		return err
+	}
+	dropped, err := s.dialect.dropPartitions(ctx, s.db, now.Add(-s.config.Retention))
+	if err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(ctx, s.dialect.rebind(`DELETE FROM `+s.dialect.expiryTable+` WHERE expires_at <= ?`), now)
+	if err != nil {
+		return fmt.Errorf("sqlpayloadstore: deleting expired entries: %w", err)
+	}
+	if deleted, _ := res.RowsAffected(); deleted > 0 || dropped > 0 {
+		log.Infof(ctx, "sqlpayloadstore: removed %d expired entries and %d partitions", deleted, dropped)
+	}
+	return nil
+}
+
+// Store builds a PayloadEntry from the incoming request's StepContext and
+// persists it. A duplicate message_id replaces the earlier entry, as it
+// does in payloadstore.
+func (s *store) Store(ctx *model.StepContext) error {
+	bCtx := parseBecknCtx(ctx.Body)
+	if bCtx.MessageID == "" {
+		log.Warnf(ctx, "sqlpayloadstore: message_id absent from request body — store skipped")
+		return nil
+	}
+
+	entry := definition.PayloadEntry{
+		MessageID:     bCtx.MessageID,
+		TransactionID: bCtx.TransactionID,
+		NetworkID:     bCtx.NetworkID,
+		Action:        bCtx.Action,
+		SubscriberID:  ctx.SubID,
+		Role:          ctx.Role,
+		Kind:          definition.PayloadKindRequest,
+		Direction:     direction(ctx, false),
+		RequestBody:   ctx.Body,
+	}
+	if ctx.Request != nil {
+		entry.Signature = ctx.Request.Header.Get(model.AuthHeaderSubscriber)
+	}
+	return s.persist(ctx, entry)
+}
+
+// StoreResponse persists the synchronous response in rctx as a response
+// row for the request in ctx.Body.
+func (s *store) StoreResponse(ctx *model.StepContext, rctx *model.ResponseStepContext) error {
+	bCtx := parseBecknCtx(ctx.Body)
+	if bCtx.MessageID == "" {
+		log.Warnf(ctx, "sqlpayloadstore: message_id absent from request body — response store skipped")
+		return nil
+	}
+
+	entry := definition.PayloadEntry{
+		MessageID:     bCtx.MessageID,
+		TransactionID: bCtx.TransactionID,
+		NetworkID:     bCtx.NetworkID,
+		Action:        bCtx.Action,
+		SubscriberID:  ctx.SubID,
+		Role:          ctx.Role,
+		Kind:          definition.PayloadKindResponse,
+		Direction:     direction(ctx, true),
+		Signature:     rctx.Header.Get("Signature"),
+		Status:        model.ParseResponseStatus(rctx.Body),
+		StatusCode:    rctx.StatusCode,
+		ResponseBody:  rctx.Body,
+	}
+	if len(rctx.Header) > 0 {
+		entry.ResponseHeaders = make(map[string]string, len(rctx.Header))
+		for k, v := range rctx.Header {
+			if len(v) > 0 && k != "Signature" {
+				entry.ResponseHeaders[k] = v[0]
+			}
+		}
+	}
+	return s.persist(ctx, entry)
+}
+
+// direction returns the direction of a request handled by ctx's handler,
+// or of the response to it when response is true.
+func direction(ctx *model.StepContext, response bool) definition.PayloadDirection {
+	if ctx.IsCallerHandler != response {
+		return definition.PayloadOutbound
+	}
+	return definition.PayloadInbound
+}
+
+// persist applies config policies (storeBody, storeSignature, maxBodyBytes)
+// and replaces any entry with the same message ID and kind in one
+// transaction.
+func (s *store) persist(ctx context.Context, entry definition.PayloadEntry) error {
+	if !s.config.StoreBody {
+		entry.RequestBody = nil
+		entry.ResponseBody = nil
+	}
+	if !s.config.StoreSignature {
+		entry.Signature = ""
+	}
+	if s.config.MaxBodyBytes > 0 && int64(len(entry.RequestBody)) > s.config.MaxBodyBytes {
+		entry.RequestBody = entry.RequestBody[:s.config.MaxBodyBytes]
+	}
+	if s.config.MaxBodyBytes > 0 && int64(len(entry.ResponseBody)) > s.config.MaxBodyBytes {
+		entry.ResponseBody = entry.ResponseBody[:s.config.MaxBodyBytes]
+	}
+	var headers string
+	if len(entry.ResponseHeaders) > 0 {
+		b, err := json.Marshal(entry.ResponseHeaders)
+		if err != nil {
+			return fmt.Errorf("sqlpayloadstore: marshal response headers: %w", err)
+		}
+		headers = string(b)
+	}
+
+	// Microseconds: the precision both backends keep.
+	now := s.now().UTC().Truncate(time.Microsecond)
+	entry.StoredAt = now
+	entry.ExpiresAt = now.Add(s.config.Retention)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("sqlpayloadstore: begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, s.dialect.rebind(`DELETE FROM onix_payloads WHERE namespace = ? AND message_id = ? AND kind = ?`),
+		s.namespace, entry.MessageID, string(entry.Kind))
+	if err != nil {
+		return fmt.Errorf("sqlpayloadstore: replace entry: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		log.Warnf(ctx, "sqlpayloadstore: duplicate %s for message_id %s — overwriting existing entry", entry.Kind, entry.MessageID)
+	}
+	if _, err := tx.ExecContext(ctx, s.dialect.rebind(`
+		INSERT INTO onix_payloads (namespace, message_id, transaction_id, network_id, action, subscriber_id, role, kind, direction,
+			request_body, signature, status, status_code, response_body, response_headers, stored_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		s.namespace, entry.MessageID, entry.TransactionID, entry.NetworkID, entry.Action, entry.SubscriberID,
+		string(entry.Role), string(entry.Kind), string(entry.Direction), entry.RequestBody, entry.Signature,
+		string(entry.Status), entry.StatusCode, entry.ResponseBody, headers, entry.StoredAt, entry.ExpiresAt,
+	); err != nil {
+		return fmt.Errorf("sqlpayloadstore: insert entry: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("sqlpayloadstore: commit: %w", err)
+	}
+	return nil
+}
+
+// entryColumns is the column list scanEntries reads, in order.
+const entryColumns = `message_id, transaction_id, network_id, action, subscriber_id, role, kind, direction,
+	request_body, signature, status, status_code, response_body, response_headers, stored_at, expires_at`
+
+// scanEntries reads rows selected with entryColumns.
+func scanEntries(rows *sql.Rows) ([]definition.PayloadEntry, error) {
+	defer rows.Close()
+	var entries []definition.PayloadEntry
+	for rows.Next() {
+		var e definition.PayloadEntry
+		var role, kind, direction, status, headers string
+		if err := rows.Scan(&e.MessageID, &e.TransactionID, &e.NetworkID, &e.Action, &e.SubscriberID,
+			&role, &kind, &direction, &e.RequestBody, &e.Signature, &status, &e.StatusCode, &e.ResponseBody,
+			&headers, &e.StoredAt, &e.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("sqlpayloadstore: scan entry: %w", err)
+		}
+		e.Role = model.Role(role)
+		e.Kind = definition.PayloadKind(kind)
+		e.Direction = definition.PayloadDirection(direction)
+		e.Status = model.Status(status)
+		if headers != "" {
+			if err := json.Unmarshal([]byte(headers), &e.ResponseHeaders); err != nil {
+				return nil, fmt.Errorf("sqlpayloadstore: decode response headers of %s: %w", e.MessageID, err)
+			}
+		}
+		e.StoredAt, e.ExpiresAt = e.StoredAt.UTC(), e.ExpiresAt.UTC()
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlpayloadstore: read entries: %w", err)
+	}
+	return entries, nil
+}
+
+// GetByTransactionID returns all unexpired entries for a transaction,
+// requests and responses, in StoredAt ascending order.
+func (s *store) GetByTransactionID(ctx context.Context, transactionID string) ([]definition.PayloadEntry, error) {
+	if transactionID == "" {
+		return nil, nil
+	}
+	rows, err := s.db.QueryContext(ctx, s.dialect.rebind(`
+		SELECT `+entryColumns+` FROM onix_payloads
+		WHERE namespace = ? AND transaction_id = ? AND expires_at > ?
+		ORDER BY stored_at, message_id, kind`),
+		s.namespace, transactionID, s.now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("sqlpayloadstore: query transaction: %w", err)
+	}
+	return scanEntries(rows)
+}
+
+// GetByMessageID returns the request entry for the given message ID, optionally filtered by action.
+func (s *store) GetByMessageID(ctx context.Context, messageID, action string) (*definition.PayloadEntry, error) {
+	rows, err := s.db.QueryContext(ctx, s.dialect.rebind(`
+		SELECT `+entryColumns+` FROM onix_payloads
+		WHERE namespace = ? AND message_id = ? AND kind = ? AND expires_at > ?
+		ORDER BY stored_at DESC LIMIT 1`),
+		s.namespace, messageID, string(definition.PayloadKindRequest), s.now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("sqlpayloadstore: query message: %w", err)
+	}
+	entries, err := scanEntries(rows)
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	if action != "" && entries[0].Action != action {
+		return nil, nil
+	}
+	return &entries[0], nil
+}
+
+// Exists returns true if an unexpired request with the given message ID is present in the store.
+func (s *store) Exists(ctx context.Context, messageID string) (bool, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, s.dialect.rebind(`
+		SELECT COUNT(*) FROM onix_payloads WHERE namespace = ? AND message_id = ? AND kind = ? AND expires_at > ?`),
+		s.namespace, messageID, string(definition.PayloadKindRequest), s.now().UTC()).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("sqlpayloadstore: exists: %w", err)
+	}
+	return n > 0, nil
+}
+
+// Query returns the unexpired entries matching q in StoredAt ascending
+// order, at most q.Limit of them (default 100, capped at 1000).
+func (s *store) Query(ctx context.Context, q definition.PayloadQuery) ([]definition.PayloadEntry, error) {
+	if !q.From.IsZero() && !q.To.IsZero() && !q.From.Before(q.To) {
+		return nil, errors.New("sqlpayloadstore: query From must be before To")
+	}
+	where := []string{"namespace = ?", "expires_at > ?"}
+	args := []any{s.namespace, s.now().UTC()}
+	for _, f := range []struct{ column, value string }{
+		{"subscriber_id", q.SubscriberID},
+		{"action", q.Action},
+		{"network_id", q.NetworkID},
+		{"transaction_id", q.TransactionID},
+		{"kind", string(q.Kind)},
+	} {
+		if f.value != "" {
+			where = append(where, f.column+" = ?")
+			args = append(args, f.value)
+		}
+	}
+	if !q.From.IsZero() {
+		where = append(where, "stored_at >= ?")
+		args = append(args, q.From.UTC())
+	}
+	if !q.To.IsZero() {
+		where = append(where, "stored_at < ?")
+		args = append(args, q.To.UTC())
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultQueryLimit
+	}
+	if limit > maxQueryLimit {
+		limit = maxQueryLimit
+	}
+
+	rows, err := s.db.QueryContext(ctx, s.dialect.rebind(
+		`SELECT `+entryColumns+` FROM onix_payloads WHERE `+strings.Join(where, " AND ")+
+			` ORDER BY stored_at, message_id, kind LIMIT `+strconv.Itoa(limit)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlpayloadstore: query: %w", err)
+	}
+	return scanEntries(rows)
+}
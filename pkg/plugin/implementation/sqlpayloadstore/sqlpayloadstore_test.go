@@ -0,0 +1,365 @@
+package sqlpayloadstore
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+)
+
+// newTestStore opens a SQLite store in a temporary directory with a clock
+// the test controls.
+func newTestStore(t *testing.T, cfg map[string]string) (*store, *time.Time) {
+	t.Helper()
+	full := map[string]string{"driver": "sqlite", "dsn": filepath.Join(t.TempDir(), "payloads.db")}
+	for k, v := range cfg {
+		full[k] = v
+	}
+	s, closer, err := New(context.Background(), "onix", full)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() {
+		if err := closer(); err != nil {
+			t.Errorf("closer() error = %v", err)
+		}
+	})
+	now := time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	return s, &now
+}
+
+func stepCtx(body, subID string, role model.Role) *model.StepContext {
+	req, _ := http.NewRequest(http.MethodPost, "http://example.com", strings.NewReader(body))
+	req.Header.Set(model.AuthHeaderSubscriber, "Signature keyId=\"k\"")
+	return &model.StepContext{Context: context.Background(), Request: req, Body: []byte(body), SubID: subID, Role: role}
+}
+
+func body(messageID, transactionID, networkID, action string) string {
+	return `{"context":{"message_id":"` + messageID + `","transaction_id":"` + transactionID +
+		`","networkId":"` + networkID + `","action":"` + action + `"}}`
+}
+
+func TestParseConfig(t *testing.T) {
+	c, err := ParseConfig(map[string]string{"dsn": "postgres://localhost/onix"})
+	if err != nil {
+		t.Fatalf("ParseConfig() error = %v", err)
+	}
+	if c.Driver != driverPostgres || c.Retention != defaultRetention || !c.StoreBody || !c.StoreSignature {
+		t.Errorf("unexpected defaults: %+v", c)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     map[string]string
+		wantErr string
+	}{
+		{"missing dsn", map[string]string{}, "dsn is required"},
+		{"unknown driver", map[string]string{"dsn": "x", "driver": "mysql"}, "unsupported driver"},
+		{"invalid retention", map[string]string{"dsn": "x", "retention": "forever"}, "invalid retention"},
+		{"zero retention", map[string]string{"dsn": "x", "retention": "0s"}, "retention must be positive"},
+		{"negative maxBodyBytes", map[string]string{"dsn": "x", "maxBodyBytes": "-1"}, "maxBodyBytes must be >= 0"},
+		{"invalid storeBody", map[string]string{"dsn": "x", "storeBody": "maybe"}, "invalid storeBody"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseConfig(tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseConfig() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStoreAndGet(t *testing.T) {
+	s, now := newTestStore(t, nil)
+	ctx := context.Background()
+
+	if err := s.Store(stepCtx(body("m1", "t1", "retail", "search"), "bap.example.com", model.RoleBAP)); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+	*now = now.Add(time.Second)
+	if err := s.Store(stepCtx(body("m2", "t1", "retail", "on_search"), "bpp.example.com", model.RoleBPP)); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+
+	entry, err := s.GetByMessageID(ctx, "m1", "search")
+	if err != nil || entry == nil {
+		t.Fatalf("GetByMessageID() = %v, %v", entry, err)
+	}
+	if entry.TransactionID != "t1" || entry.NetworkID != "retail" || entry.SubscriberID != "bap.example.com" ||
+		entry.Role != model.RoleBAP || entry.Signature == "" || string(entry.RequestBody) != body("m1", "t1", "retail", "search") {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+	if !entry.ExpiresAt.Equal(entry.StoredAt.Add(defaultRetention)) {
+		t.Errorf("ExpiresAt = %v, want StoredAt + retention", entry.ExpiresAt)
+	}
+	if entry, _ := s.GetByMessageID(ctx, "m1", "confirm"); entry != nil {
+		t.Error("GetByMessageID() with another action should return nil")
+	}
+
+	entries, err := s.GetByTransactionID(ctx, "t1")
+	if err != nil || len(entries) != 2 || entries[0].MessageID != "m1" || entries[1].MessageID != "m2" {
+		t.Fatalf("GetByTransactionID() = %+v, %v", entries, err)
+	}
+	if entries, _ := s.GetByTransactionID(ctx, "unknown"); entries != nil {
+		t.Errorf("GetByTransactionID(unknown) = %+v, want nil", entries)
+	}
+
+	if ok, err := s.Exists(ctx, "m2"); err != nil || !ok {
+		t.Errorf("Exists(m2) = %t, %v", ok, err)
+	}
+	if ok, _ := s.Exists(ctx, "m3"); ok {
+		t.Error("Exists(m3) = true, want false")
+	}
+}
+
+func TestStore_DuplicateOverwritesAndSkipsMissingMessageID(t *testing.T) {
+	s, now := newTestStore(t, map[string]string{"storeSignature": "false", "maxBodyBytes": "10"})
+	ctx := context.Background()
+
+	if err := s.Store(stepCtx(`{"context":{"action":"search"}}`, "bap", model.RoleBAP)); err != nil {
+		t.Fatalf("Store() without message_id error = %v", err)
+	}
+	if err := s.Store(stepCtx(body("m1", "t1", "retail", "search"), "bap", model.RoleBAP)); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+	*now = now.Add(time.Minute)
+	if err := s.Store(stepCtx(body("m1", "t1", "retail", "select"), "bap", model.RoleBAP)); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+
+	entries, err := s.GetByTransactionID(ctx, "t1")
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("GetByTransactionID() = %+v, %v, want the one overwritten entry", entries, err)
+	}
+	if entries[0].Action != "select" || entries[0].Signature != "" || len(entries[0].RequestBody) != 10 {
+		t.Errorf("unexpected entry: %+v", entries[0])
+	}
+}
+
+func TestQuery(t *testing.T) {
+	s, now := newTestStore(t, nil)
+	ctx := context.Background()
+	start := *now
+
+	for i, b := range []struct{ body, sub string }{
+		{body("m1", "t1", "retail", "search"), "bap-a"},
+		{body("m2", "t1", "retail", "on_search"), "bpp-a"},
+		{body("m3", "t2", "mobility", "search"), "bap-a"},
+		{body("m4", "t3", "retail", "confirm"), "bap-b"},
+	} {
+		*now = start.Add(time.Duration(i) * time.Hour)
+		if err := s.Store(stepCtx(b.body, b.sub, model.RoleBAP)); err != nil {
+			t.Fatalf("Store() error = %v", err)
+		}
+	}
+
+	ids := func(q definition.PayloadQuery) string {
+		t.Helper()
+		entries, err := s.Query(ctx, q)
+		if err != nil {
+			t.Fatalf("Query(%+v) error = %v", q, err)
+		}
+		var out []string
+		for _, e := range entries {
+			out = append(out, e.MessageID)
+		}
+		return strings.Join(out, ",")
+	}
+
+	tests := []struct {
+		name string
+		q    definition.PayloadQuery
+		want string
+	}{
+		{"all", definition.PayloadQuery{}, "m1,m2,m3,m4"},
+		{"subscriber", definition.PayloadQuery{SubscriberID: "bap-a"}, "m1,m3"},
+		{"action", definition.PayloadQuery{Action: "search"}, "m1,m3"},
+		{"network", definition.PayloadQuery{NetworkID: "retail"}, "m1,m2,m4"},
+		{"time range", definition.PayloadQuery{From: start.Add(time.Hour), To: start.Add(3 * time.Hour)}, "m2,m3"},
+		{"combined", definition.PayloadQuery{NetworkID: "retail", SubscriberID: "bap-a"}, "m1"},
+		{"limit", definition.PayloadQuery{Limit: 2}, "m1,m2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ids(tt.q); got != tt.want {
+				t.Errorf("Query() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := s.Query(ctx, definition.PayloadQuery{From: start, To: start}); err == nil {
+		t.Error("expected an error for an empty time range")
+	}
+}
+
+func TestMaintain_RemovesEntriesPastRetention(t *testing.T) {
+	s, now := newTestStore(t, map[string]string{"retention": "24h"})
+	ctx := context.Background()
+
+	if err := s.Store(stepCtx(body("old", "t1", "retail", "search"), "bap", model.RoleBAP)); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+	*now = now.Add(20 * time.Hour)
+	if err := s.Store(stepCtx(body("new", "t1", "retail", "on_search"), "bpp", model.RoleBPP)); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+
+	*now = now.Add(5 * time.Hour)
+	if ok, _ := s.Exists(ctx, "old"); ok {
+		t.Error("an entry past retention should not be returned before maintenance removes it")
+	}
+	if err := s.maintain(ctx); err != nil {
+		t.Fatalf("maintain() error = %v", err)
+	}
+	var n int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM onix_payloads`).Scan(&n); err != nil || n != 1 {
+		t.Errorf("rows after maintenance = %d, %v, want 1", n, err)
+	}
+	if ok, _ := s.Exists(ctx, "new"); !ok {
+		t.Error("an entry within retention should be kept")
+	}
+}
+
+func TestNew_MigratesOnce(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "payloads.db")
+	for i := 0; i < 2; i++ {
+		_, closer, err := New(context.Background(), "onix", map[string]string{"driver": "sqlite", "dsn": dsn})
+		if err != nil {
+			t.Fatalf("New() #%d error = %v", i+1, err)
+		}
+		if err := closer(); err != nil {
+			t.Fatalf("closer() error = %v", err)
+		}
+	}
+}
+
+func TestRebind(t *testing.T) {
+	q := `SELECT a FROM t WHERE b = ? AND c = ?`
+	if got := dialects[driverPostgres].rebind(q); got != `SELECT a FROM t WHERE b = $1 AND c = $2` {
+		t.Errorf("postgres rebind = %s", got)
+	}
+	if got := dialects[driverSQLite].rebind(q); got != q {
+		t.Errorf("sqlite rebind = %s", got)
+	}
+}
+
+func TestPartitionExpired(t *testing.T) {
+	cutoff := time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+	if name := partitionName(cutoff); name != "onix_payloads_p20260314" {
+		t.Errorf("partitionName() = %s", name)
+	}
+	tests := map[string]bool{
+		"onix_payloads_p20260313": true,  // ends at the start of the 14th
+		"onix_payloads_p20260314": false, // still holds rows newer than cutoff
+		"onix_payloads_default":   false,
+		"onix_payloads_pnotadate": false,
+	}
+	for name, want := range tests {
+		if got := partitionExpired(name, cutoff); got != want {
+			t.Errorf("partitionExpired(%s) = %t, want %t", name, got, want)
+		}
+	}
+}
+
+func TestStoreResponse(t *testing.T) {
+	s, now := newTestStore(t, map[string]string{"maxBodyBytes": "20"})
+	ctx := context.Background()
+
+	req := stepCtx(body("m1", "t1", "retail", "search"), "bap", model.RoleBAP)
+	req.IsCallerHandler = true
+	if err := s.Store(req); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+	h := http.Header{}
+	h.Set("Content-Type", "application/json")
+	h.Set("Signature", "ack-sig")
+	rctx := &model.ResponseStepContext{StatusCode: http.StatusOK, Header: h, Body: []byte(`{"message":{"ack":{"status":"ACK"}}}`)}
+	// Same instant as the request: the two rows must not collide.
+	if err := s.StoreResponse(req, rctx); err != nil {
+		t.Fatalf("StoreResponse() error = %v", err)
+	}
+	*now = now.Add(time.Second)
+	if err := s.Store(stepCtx(body("m2", "t1", "retail", "on_search"), "bpp", model.RoleBAP)); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+
+	entries, err := s.GetByTransactionID(ctx, "t1")
+	if err != nil || len(entries) != 3 {
+		t.Fatalf("GetByTransactionID() = %+v, %v, want 3 entries", entries, err)
+	}
+	if entries[0].Kind != definition.PayloadKindRequest || entries[0].Direction != definition.PayloadOutbound {
+		t.Errorf("entries[0] = %+v, want the outbound request", entries[0])
+	}
+	resp := entries[1]
+	if resp.Kind != definition.PayloadKindResponse || resp.Direction != definition.PayloadInbound ||
+		resp.MessageID != "m1" || resp.Status != model.StatusACK || resp.StatusCode != http.StatusOK {
+		t.Errorf("entries[1] = %+v, want the inbound ACK for m1", resp)
+	}
+	if resp.Signature != "ack-sig" || resp.ResponseHeaders["Content-Type"] != "application/json" || len(resp.ResponseBody) != 20 {
+		t.Errorf("unexpected response fields: %q %v %q", resp.Signature, resp.ResponseHeaders, resp.ResponseBody)
+	}
+	if entries[2].MessageID != "m2" || entries[2].Direction != definition.PayloadInbound {
+		t.Errorf("entries[2] = %+v, want the inbound callback", entries[2])
+	}
+
+	if entry, _ := s.GetByMessageID(ctx, "m1", ""); entry == nil || entry.Kind != definition.PayloadKindRequest {
+		t.Errorf("GetByMessageID() = %+v, want the request", entry)
+	}
+	responses, err := s.Query(ctx, definition.PayloadQuery{Kind: definition.PayloadKindResponse})
+	if err != nil || len(responses) != 1 || responses[0].MessageID != "m1" {
+		t.Errorf("Query(kind=response) = %+v, %v", responses, err)
+	}
+
+	// A second response for the same message replaces the first.
+	if err := s.StoreResponse(req, rctx); err != nil {
+		t.Fatalf("StoreResponse() error = %v", err)
+	}
+	if responses, _ := s.Query(ctx, definition.PayloadQuery{Kind: definition.PayloadKindResponse}); len(responses) != 1 {
+		t.Errorf("responses after a duplicate = %d, want 1", len(responses))
+	}
+}
+
+func TestNew_MigratesExistingRows(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "payloads.db")
+	d := dialects[driverSQLite]
+	db, err := d.open(dsn)
+	if err != nil {
+		t.Fatalf("open() error = %v", err)
+	}
+	initSQL, err := migrationFS.ReadFile("migrations/sqlite/0001_init.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := time.Now().UTC().Truncate(time.Microsecond)
+	for _, stmt := range []string{
+		string(initSQL),
+		`CREATE TABLE onix_payload_migrations (name TEXT PRIMARY KEY)`,
+		`INSERT INTO onix_payload_migrations (name) VALUES ('0001_init.sql')`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("setup error = %v", err)
+		}
+	}
+	if _, err := db.Exec(`INSERT INTO onix_payloads (namespace, message_id, transaction_id, stored_at, expires_at) VALUES (?, ?, ?, ?, ?)`,
+		"onix", "old", "t1", stored, stored.Add(time.Hour)); err != nil {
+		t.Fatalf("insert error = %v", err)
+	}
+	db.Close()
+
+	s, closer, err := New(context.Background(), "onix", map[string]string{"driver": "sqlite", "dsn": dsn})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer closer()
+	entry, err := s.GetByMessageID(context.Background(), "old", "")
+	if err != nil || entry == nil || entry.Kind != definition.PayloadKindRequest {
+		t.Errorf("GetByMessageID(old) = %+v, %v, want the migrated request", entry, err)
+	}
+}
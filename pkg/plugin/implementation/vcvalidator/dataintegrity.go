@@ -0,0 +1,348 @@
+// dataintegrity.go verifies JSON-LD Data Integrity proofs (proof.proofValue)
+// for the Ed25519 cryptosuites: eddsa-rdfc-2022, eddsa-jcs-2022 and the
+// older Ed25519Signature2020 proof type. The JSON-LD contexts the RDF suites
+// need are served from copies bundled into the plugin, never fetched.
+package vcvalidator
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// Supported Data Integrity proof types and cryptosuites.
+const (
+	proofTypeDataIntegrity        = "DataIntegrityProof"
+	proofTypeEd25519Signature2020 = "Ed25519Signature2020"
+	suiteEddsaRDFC2022            = "eddsa-rdfc-2022"
+	suiteEddsaJCS2022             = "eddsa-jcs-2022"
+)
+
+// dataIntegritySuite returns the suite that verifies p, or "" if this plugin
+// cannot verify it.
+func dataIntegritySuite(p *proof) string {
+	switch {
+	case p.Type == proofTypeDataIntegrity && (p.Cryptosuite == suiteEddsaRDFC2022 || p.Cryptosuite == suiteEddsaJCS2022):
+		return p.Cryptosuite
+	case p.Type == proofTypeEd25519Signature2020:
+		return proofTypeEd25519Signature2020
+	}
+	return ""
+}
+
+// verifyDataIntegrityProof verifies an Ed25519 Data Integrity proof against
+// the issuer's resolved DID key and enforces that the signer is the issuer.
+func (v *verifier) verifyDataIntegrityProof(ctx context.Context, raw json.RawMessage, cred *credential, issuer, suite string) error {
+	p := cred.Proof
+	vm := p.VerificationMethod
+	if vm == "" {
+		return failf(failProof, codeAutSignatureMissing, "proof type %q has no verificationMethod", p.Type)
+	}
+	if p.ProofPurpose != "assertionMethod" {
+		return failf(failProof, codeAutSignatureInvalid, "proofPurpose %q is not assertionMethod", p.ProofPurpose)
+	}
+	if signer := didOfKID(vm); signer == "" || signer != base(issuer) {
+		return failf(failIssuer, codeAutUnauthorizedAction,
+			"proof verificationMethod %q does not belong to issuer %q", vm, issuer)
+	}
+	if v.cfg.CheckExpiry && p.Expires != "" {
+		if t, err := time.Parse(time.RFC3339, p.Expires); err == nil && v.now().After(t) {
+			return failf(failExpired, codeAutKeyExpiredOrRevoked, "proof expired (expires=%s)", p.Expires)
+		}
+	}
+	sig, err := decodeMultibase(p.ProofValue)
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return failf(failProof, codeAutSignatureInvalid, "proofValue is not a base58btc multibase Ed25519 signature")
+	}
+
+	data, err := v.dataIntegrityHashData(raw, suite)
+	if err != nil {
+		return failf(failProof, codeAutSignatureInvalid, "%s: %v", suite, err)
+	}
+
+	key, err := resolveDID(ctx, vm, "", v.cfg, v.fetch)
+	if err != nil {
+		if isNetErr(err) && v.cfg.FailOpen {
+			return nil
+		}
+		return failf(failResolution, resolutionCode(err), "resolve %q: %v", vm, err)
+	}
+	pub, ok := key.pub.(ed25519.PublicKey)
+	if !ok {
+		return failf(failProof, codeAutSignatureInvalid, "verificationMethod %q is not an Ed25519 key", vm)
+	}
+	if !ed25519.Verify(pub, data, sig) {
+		return failf(failProof, codeAutSignatureInvalid, "signature verification failed")
+	}
+	return nil
+}
+
+// dataIntegrityHashData builds the bytes a Data Integrity proof signs: the
+// SHA-256 of the canonical proof configuration (the proof without its
+// proofValue, under the document's @context) followed by the SHA-256 of the
+// canonical document without its proof.
+func (v *verifier) dataIntegrityHashData(raw json.RawMessage, suite string) ([]byte, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	proofOpts, ok := doc["proof"].(map[string]any)
+	if !ok {
+		return nil, errors.New("proof is not an object")
+	}
+	unsecured := maps.Clone(doc)
+	delete(unsecured, "proof")
+	config := maps.Clone(proofOpts)
+	delete(config, "proofValue")
+	delete(config, "@context")
+	if c, ok := doc["@context"]; ok {
+		config["@context"] = c
+	}
+
+	canon := v.canonicalRDF
+	if suite == suiteEddsaJCS2022 {
+		canon = canonicalJSON
+	}
+	configBytes, err := canon(config)
+	if err != nil {
+		return nil, fmt.Errorf("canonicalize proof configuration: %w", err)
+	}
+	docBytes, err := canon(unsecured)
+	if err != nil {
+		return nil, fmt.Errorf("canonicalize credential: %w", err)
+	}
+	configHash := sha256.Sum256(configBytes)
+	docHash := sha256.Sum256(docBytes)
+	return append(configHash[:], docHash[:]...), nil
+}
+
+// canonicalRDF expands doc as JSON-LD and returns its RDFC-1.0 canonical
+// N-Quads.
+func (v *verifier) canonicalRDF(doc any) ([]byte, error) {
+	p := &jsonldProcessor{contexts: v.contexts}
+	expanded, err := p.expandDocument(doc)
+	if err != nil {
+		return nil, err
+	}
+	quads, err := toRDF(expanded)
+	if err != nil {
+		return nil, err
+	}
+	nquads, err := canonicalize(quads)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(nquads), nil
+}
+
+// decodeMultibase decodes a base58btc ('z'-prefixed) multibase string.
+func decodeMultibase(s string) ([]byte, error) {
+	if !strings.HasPrefix(s, "z") {
+		return nil, errors.New("unsupported multibase encoding")
+	}
+	return base58Decode(s[1:])
+}
+
+// ---------------------------------------------------------------------------
+// Offline JSON-LD contexts
+// ---------------------------------------------------------------------------
+
+//go:embed contexts/*.jsonld
+var contextFiles embed.FS
+
+// bundledContextFiles maps the context URLs Data Integrity credentials
+// commonly reference to the copies under contexts/.
+var bundledContextFiles = map[string]string{
+	"https://www.w3.org/2018/credentials/v1":           "contexts/credentials-v1.jsonld",
+	"https://www.w3.org/ns/credentials/v2":             "contexts/credentials-v2.jsonld",
+	"https://w3id.org/security/data-integrity/v2":      "contexts/data-integrity-v2.jsonld",
+	"https://w3id.org/security/suites/ed25519-2020/v1": "contexts/ed25519-2020-v1.jsonld",
+}
+
+// contextStore resolves context URLs to parsed @context values. It never
+// touches the network: an unknown URL is an error.
+type contextStore struct {
+	docs map[string]any
+}
+
+var bundledContexts = mustLoadBundledContexts()
+
+func mustLoadBundledContexts() *contextStore {
+	s := &contextStore{docs: map[string]any{}}
+	for url, name := range bundledContextFiles {
+		data, err := contextFiles.ReadFile(name)
+		if err == nil {
+			err = s.add(url, data)
+		}
+		if err != nil {
+			panic(fmt.Sprintf("vcvalidator: bundled context %s: %v", name, err))
+		}
+	}
+	return s
+}
+
+// newContextStore returns the bundled contexts overlaid with the operator's
+// url -> file mappings, which take precedence.
+func newContextStore(files map[string]string) (*contextStore, error) {
+	if len(files) == 0 {
+		return bundledContexts, nil
+	}
+	s := &contextStore{docs: maps.Clone(bundledContexts.docs)}
+	for url, path := range files {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			err = s.add(url, data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("jsonldContexts %s: %w", url, err)
+		}
+	}
+	return s, nil
+}
+
+func (s *contextStore) add(url string, data []byte) error {
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	ctx, ok := doc["@context"]
+	if !ok {
+		return errors.New("document has no @context")
+	}
+	s.docs[url] = ctx
+	return nil
+}
+
+func (s *contextStore) load(url string) (any, error) {
+	if doc, ok := s.docs[url]; ok {
+		return doc, nil
+	}
+	return nil, fmt.Errorf("JSON-LD context %q is not available offline; map it to a local file with jsonldContexts", url)
+}
+
+// ---------------------------------------------------------------------------
+// JSON Canonicalization Scheme (RFC 8785)
+// ---------------------------------------------------------------------------
+
+// canonicalJSON serialises a decoded JSON value per RFC 8785: object members
+// sorted by UTF-16 code units, no whitespace, ECMAScript number formatting.
+func canonicalJSON(v any) ([]byte, error) {
+	var b bytes.Buffer
+	if err := writeCanonicalJSON(&b, v); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func writeCanonicalJSON(b *bytes.Buffer, v any) error {
+	switch x := v.(type) {
+	case nil:
+		b.WriteString("null")
+	case bool:
+		b.WriteString(strconv.FormatBool(x))
+	case float64:
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return errors.New("non-finite number")
+		}
+		b.WriteString(formatESNumber(x))
+	case string:
+		writeCanonicalString(b, x)
+	case []any:
+		b.WriteByte('[')
+		for i, item := range x {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if err := writeCanonicalJSON(b, item); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(']')
+	case map[string]any:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool { return lessUTF16(keys[i], keys[j]) })
+		b.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writeCanonicalString(b, k)
+			b.WriteByte(':')
+			if err := writeCanonicalJSON(b, x[k]); err != nil {
+				return err
+			}
+		}
+		b.WriteByte('}')
+	default:
+		return fmt.Errorf("unsupported JSON value of type %T", v)
+	}
+	return nil
+}
+
+func writeCanonicalString(b *bytes.Buffer, s string) {
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(b, `\u%04x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+}
+
+func lessUTF16(a, b string) bool {
+	ua, ub := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return ua[i] < ub[i]
+		}
+	}
+	return len(ua) < len(ub)
+}
+
+// formatESNumber formats f as ECMAScript's Number.prototype.toString does.
+func formatESNumber(f float64) string {
+	if f == 0 {
+		return "0"
+	}
+	if abs := math.Abs(f); abs >= 1e21 || abs < 1e-6 {
+		s := strconv.FormatFloat(f, 'e', -1, 64)
+		mantissa, exp, _ := strings.Cut(s, "e")
+		return mantissa + "e" + exp[:1] + strings.TrimLeft(exp[1:], "0")
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
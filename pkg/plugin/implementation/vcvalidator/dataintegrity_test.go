@@ -0,0 +1,402 @@
+package vcvalidator
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The key pair published with the W3C Data Integrity EdDSA test vectors.
+const (
+	specKeyDID  = "did:key:z6MkrJVnaZkeFzdQyMZu1cgjg7k1pZZ6pvBQ7XJPt4swbTQ2"
+	specKeyVM   = specKeyDID + "#z6MkrJVnaZkeFzdQyMZu1cgjg7k1pZZ6pvBQ7XJPt4swbTQ2"
+	specKeySeed = "c96ef9ea10c5e414c471723aff9de72c35fa5b70fae97e8832ecac7d2e2b8ed6"
+)
+
+func specKey(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	seed, err := hex.DecodeString(specKeySeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ed25519.NewKeyFromSeed(seed)
+}
+
+// examplesVerifier is a testVerifier that also knows the VC examples context.
+func examplesVerifier(t *testing.T) *verifier {
+	t.Helper()
+	v := testVerifier(nil)
+	store, err := newContextStore(map[string]string{
+		"https://www.w3.org/ns/credentials/examples/v2": filepath.Join("testdata", "contexts", "credentials-examples-v2.jsonld"),
+	})
+	if err != nil {
+		t.Fatalf("newContextStore: %v", err)
+	}
+	v.contexts = store
+	return v
+}
+
+// TestEddsaRDFC2022SpecVector checks expansion, canonicalisation and hashing
+// against the published eddsa-rdfc-2022 vector.
+func TestEddsaRDFC2022SpecVector(t *testing.T) {
+	v := examplesVerifier(t)
+	vc := readVCFile(t, "testdata/eddsa-rdfc-2022-alumni.json")
+	raw := vcBytes(t, vc)
+
+	data, err := v.dataIntegrityHashData(raw, suiteEddsaRDFC2022)
+	if err != nil {
+		t.Fatalf("dataIntegrityHashData: %v", err)
+	}
+	const want = "bea7b7acfbad0126b135104024a5f1733e705108f42d59668b05c0c50004c6b0" +
+		"517744132ae165a5349155bef0bb0cf2258fff99dfe1dbd914b938d775a36017"
+	if got := hex.EncodeToString(data); got != want {
+		t.Fatalf("hash data = %s, want %s", got, want)
+	}
+	sig, err := decodeMultibase(vc["proof"].(map[string]any)["proofValue"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.Verify(specKey(t).Public().(ed25519.PublicKey), data, sig) {
+		t.Fatal("published signature does not verify")
+	}
+
+	// The vector's issuer is an https URL, not the did:key that signed it, so
+	// the full verification rejects it on issuer binding.
+	assertClassCode(t, v.verify(context.Background(), raw), failIssuer, codeAutUnauthorizedAction)
+}
+
+func dataIntegrityVC(suite string) map[string]any {
+	proof := map[string]any{
+		"type":               proofTypeDataIntegrity,
+		"cryptosuite":        suite,
+		"created":            "2026-06-01T00:00:00Z",
+		"verificationMethod": specKeyVM,
+		"proofPurpose":       "assertionMethod",
+	}
+	ctx := []any{"https://www.w3.org/ns/credentials/v2"}
+	if suite == proofTypeEd25519Signature2020 {
+		proof["type"] = proofTypeEd25519Signature2020
+		delete(proof, "cryptosuite")
+		ctx = []any{"https://www.w3.org/2018/credentials/v1", "https://w3id.org/security/suites/ed25519-2020/v1"}
+	}
+	return map[string]any{
+		"@context":          ctx,
+		"id":                "urn:uuid:1f0e3c52-4a3b-4c0e-9f51-6d0c5b6f4a10",
+		"type":              []any{"VerifiableCredential"},
+		"issuer":            specKeyDID,
+		"credentialSubject": map[string]any{"id": "did:example:subject"},
+		"proof":             proof,
+	}
+}
+
+// signDataIntegrity sets proof.proofValue on vc, signed with priv.
+func signDataIntegrity(t *testing.T, v *verifier, priv ed25519.PrivateKey, vc map[string]any, suite string) {
+	t.Helper()
+	data, err := v.dataIntegrityHashData(vcBytes(t, vc), suite)
+	if err != nil {
+		t.Fatalf("dataIntegrityHashData: %v", err)
+	}
+	vc["proof"].(map[string]any)["proofValue"] = "z" + base58Encode(ed25519.Sign(priv, data))
+}
+
+func TestDataIntegrityRoundTrip(t *testing.T) {
+	for _, suite := range []string{suiteEddsaRDFC2022, suiteEddsaJCS2022, proofTypeEd25519Signature2020} {
+		t.Run(suite, func(t *testing.T) {
+			v := testVerifier(nil)
+			vc := dataIntegrityVC(suite)
+			signDataIntegrity(t, v, specKey(t), vc, suite)
+			if err := v.verify(context.Background(), vcBytes(t, vc)); err != nil {
+				t.Fatalf("verify signed credential: %v", err)
+			}
+
+			tampered := dataIntegrityVC(suite)
+			tampered["proof"] = vc["proof"]
+			tampered["credentialSubject"] = map[string]any{"id": "did:example:someone-else"}
+			assertClassCode(t, v.verify(context.Background(), vcBytes(t, tampered)), failProof, codeAutSignatureInvalid)
+
+			proof := vc["proof"].(map[string]any)
+			proof["created"] = "2026-06-02T00:00:00Z"
+			assertClassCode(t, v.verify(context.Background(), vcBytes(t, vc)), failProof, codeAutSignatureInvalid)
+		})
+	}
+}
+
+func TestDataIntegrityChecks(t *testing.T) {
+	_, otherPriv, _ := ed25519.GenerateKey(nil)
+	tests := []struct {
+		name      string
+		mutate    func(vc map[string]any)
+		priv      ed25519.PrivateKey
+		wantClass failClass
+		wantCode  string
+		wantMsg   string
+	}{
+		{
+			name: "verification method of another DID",
+			mutate: func(vc map[string]any) {
+				vc["proof"].(map[string]any)["verificationMethod"] = "did:key:z6MkOther#z6MkOther"
+			},
+			wantClass: failIssuer, wantCode: codeAutUnauthorizedAction,
+		},
+		{
+			name:      "signed with a different key",
+			priv:      otherPriv,
+			wantClass: failProof, wantCode: codeAutSignatureInvalid, wantMsg: "signature verification failed",
+		},
+		{
+			name:      "authentication proof purpose",
+			mutate:    func(vc map[string]any) { vc["proof"].(map[string]any)["proofPurpose"] = "authentication" },
+			wantClass: failProof, wantCode: codeAutSignatureInvalid, wantMsg: "proofPurpose",
+		},
+		{
+			name:      "expired proof",
+			mutate:    func(vc map[string]any) { vc["proof"].(map[string]any)["expires"] = "2026-06-02T00:00:00Z" },
+			wantClass: failExpired, wantCode: codeAutKeyExpiredOrRevoked,
+		},
+		{
+			name: "unknown remote context",
+			mutate: func(vc map[string]any) {
+				vc["@context"] = []any{"https://www.w3.org/ns/credentials/v2", "https://contexts.example.com/energy/v1"}
+			},
+			wantClass: failProof, wantCode: codeAutSignatureInvalid, wantMsg: "not available offline",
+		},
+		{
+			name: "term the context does not define",
+			mutate: func(vc map[string]any) {
+				vc["@context"] = []any{"https://www.w3.org/2018/credentials/v1", "https://w3id.org/security/data-integrity/v2"}
+				vc["credentialSubject"] = map[string]any{"id": "did:example:subject", "meterId": "M-1"}
+			},
+			wantClass: failProof, wantCode: codeAutSignatureInvalid, wantMsg: `"meterId" is not defined`,
+		},
+		{
+			name:      "malformed proofValue",
+			mutate:    func(vc map[string]any) { vc["proof"].(map[string]any)["proofValue"] = "uAAAA" },
+			wantClass: failProof, wantCode: codeAutSignatureInvalid, wantMsg: "multibase",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := testVerifier(nil)
+			vc := dataIntegrityVC(suiteEddsaRDFC2022)
+			priv := tt.priv
+			if priv == nil {
+				priv = specKey(t)
+			}
+			if tt.mutate != nil {
+				tt.mutate(vc)
+			}
+			if _, ok := vc["proof"].(map[string]any)["proofValue"]; !ok {
+				data, err := v.dataIntegrityHashData(vcBytes(t, vc), suiteEddsaRDFC2022)
+				if err == nil {
+					vc["proof"].(map[string]any)["proofValue"] = "z" + base58Encode(ed25519.Sign(priv, data))
+				} else {
+					vc["proof"].(map[string]any)["proofValue"] = "z" + base58Encode(make([]byte, ed25519.SignatureSize))
+				}
+			}
+			err := v.verify(context.Background(), vcBytes(t, vc))
+			assertClassCode(t, err, tt.wantClass, tt.wantCode)
+			if tt.wantMsg != "" && !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("error = %v, want it to mention %q", err, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestJSONLDContextsConfig(t *testing.T) {
+	if _, err := ParseConfig(map[string]string{"actions": "confirm", "jsonldContexts": "https://example.com/ctx"}); err == nil {
+		t.Error("ParseConfig accepted a jsonldContexts entry without a path")
+	}
+	cfg, err := ParseConfig(map[string]string{
+		"actions":        "confirm",
+		"jsonldContexts": "https://example.com/a=/etc/onix/a.jsonld, https://example.com/b=/etc/onix/b.jsonld",
+	})
+	if err != nil {
+		t.Fatalf("ParseConfig: %v", err)
+	}
+	if cfg.JSONLDContexts["https://example.com/b"] != "/etc/onix/b.jsonld" || len(cfg.JSONLDContexts) != 2 {
+		t.Errorf("JSONLDContexts = %v", cfg.JSONLDContexts)
+	}
+	if _, err := New(map[string]string{"actions": "confirm", "jsonldContexts": "https://example.com/a=" + filepath.Join(t.TempDir(), "missing.jsonld")}); err == nil {
+		t.Error("New accepted a jsonldContexts file that does not exist")
+	}
+}
+
+func TestCanonicalizeSpecExamples(t *testing.T) {
+	iri := func(s string) rdfTerm { return rdfTerm{kind: rdfIRI, value: "http://example.com/#" + s} }
+	bn := func(s string) rdfTerm { return rdfTerm{kind: rdfBlank, value: s} }
+	tests := []struct {
+		name  string
+		quads []rdfQuad
+		want  string
+	}{
+		{
+			name: "unique hashes",
+			quads: []rdfQuad{
+				{subject: iri("p"), predicate: iri("q"), object: bn("e0")},
+				{subject: iri("p"), predicate: iri("r"), object: bn("e1")},
+				{subject: bn("e0"), predicate: iri("s"), object: iri("u")},
+				{subject: bn("e1"), predicate: iri("t"), object: iri("u")},
+			},
+			want: "<http://example.com/#p> <http://example.com/#q> _:c14n0 .\n" +
+				"<http://example.com/#p> <http://example.com/#r> _:c14n1 .\n" +
+				"_:c14n0 <http://example.com/#s> <http://example.com/#u> .\n" +
+				"_:c14n1 <http://example.com/#t> <http://example.com/#u> .\n",
+		},
+		{
+			name: "shared hashes",
+			quads: []rdfQuad{
+				{subject: iri("p"), predicate: iri("q"), object: bn("e0")},
+				{subject: iri("p"), predicate: iri("q"), object: bn("e1")},
+				{subject: bn("e0"), predicate: iri("p"), object: bn("e2")},
+				{subject: bn("e1"), predicate: iri("p"), object: bn("e3")},
+				{subject: bn("e2"), predicate: iri("r"), object: bn("e3")},
+			},
+			want: "<http://example.com/#p> <http://example.com/#q> _:c14n2 .\n" +
+				"<http://example.com/#p> <http://example.com/#q> _:c14n3 .\n" +
+				"_:c14n0 <http://example.com/#r> _:c14n1 .\n" +
+				"_:c14n2 <http://example.com/#p> _:c14n1 .\n" +
+				"_:c14n3 <http://example.com/#p> _:c14n0 .\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := canonicalize(tt.quads)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("canonicalize() =\n%s\nwant\n%s", got, tt.want)
+			}
+
+			// Relabelling blank nodes and reordering quads must not change
+			// the canonical form.
+			relabelled := make([]rdfQuad, len(tt.quads))
+			for i, q := range tt.quads {
+				for _, term := range []*rdfTerm{&q.subject, &q.object} {
+					if term.kind == rdfBlank {
+						term.value = "x" + strings.Repeat("y", int(term.value[1]-'0'))
+					}
+				}
+				relabelled[len(tt.quads)-1-i] = q
+			}
+			if got, _ := canonicalize(relabelled); got != tt.want {
+				t.Errorf("canonicalize(relabelled) =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalizeRejectsPoisonGraph(t *testing.T) {
+	// A clique of blank nodes that all hash alike forces the N-degree step to
+	// try every permutation.
+	var quads []rdfQuad
+	p := rdfTerm{kind: rdfIRI, value: "http://example.com/#p"}
+	for i := 0; i < 12; i++ {
+		for j := 0; j < 12; j++ {
+			if i != j {
+				quads = append(quads, rdfQuad{
+					subject:   rdfTerm{kind: rdfBlank, value: "n" + string(rune('a'+i))},
+					predicate: p,
+					object:    rdfTerm{kind: rdfBlank, value: "n" + string(rune('a'+j))},
+				})
+			}
+		}
+	}
+	if _, err := canonicalize(quads); err != errCanonicalizationTooComplex {
+		t.Fatalf("canonicalize() error = %v, want %v", err, errCanonicalizationTooComplex)
+	}
+}
+
+func TestToRDFLiterals(t *testing.T) {
+	v := testVerifier(nil)
+	var doc any
+	if err := json.Unmarshal([]byte(`{
+		"@context": {"@vocab": "http://example.com/#", "meta": {"@type": "@json"}},
+		"@id": "http://example.com/s",
+		"n": 5, "d": 5.3, "big": 1e21, "b": true,
+		"s": "line\nbreak \"quoted\"",
+		"l": {"@value": "hallo", "@language": "DE"},
+		"meta": {"b": 1, "a": [true, null]},
+		"list": {"@list": [1, "two"]}
+	}`), &doc); err != nil {
+		t.Fatal(err)
+	}
+	got, err := v.canonicalRDF(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<http://example.com/s> <http://example.com/#n> "5"^^<http://www.w3.org/2001/XMLSchema#integer> .`,
+		`<http://example.com/s> <http://example.com/#d> "5.3E0"^^<http://www.w3.org/2001/XMLSchema#double> .`,
+		`<http://example.com/s> <http://example.com/#big> "1.0E21"^^<http://www.w3.org/2001/XMLSchema#double> .`,
+		`<http://example.com/s> <http://example.com/#b> "true"^^<http://www.w3.org/2001/XMLSchema#boolean> .`,
+		`<http://example.com/s> <http://example.com/#s> "line\nbreak \"quoted\"" .`,
+		`<http://example.com/s> <http://example.com/#l> "hallo"@de .`,
+		`<http://example.com/s> <http://example.com/#meta> "{\"a\":[true,null],\"b\":1}"^^<http://www.w3.org/1999/02/22-rdf-syntax-ns#JSON> .`,
+		`<http://example.com/s> <http://example.com/#list> _:c14n0 .`,
+		`_:c14n0 <http://www.w3.org/1999/02/22-rdf-syntax-ns#first> "1"^^<http://www.w3.org/2001/XMLSchema#integer> .`,
+		`_:c14n0 <http://www.w3.org/1999/02/22-rdf-syntax-ns#rest> _:c14n1 .`,
+		`_:c14n1 <http://www.w3.org/1999/02/22-rdf-syntax-ns#first> "two" .`,
+		`_:c14n1 <http://www.w3.org/1999/02/22-rdf-syntax-ns#rest> <http://www.w3.org/1999/02/22-rdf-syntax-ns#nil> .`,
+	} {
+		if !strings.Contains(string(got), want+"\n") {
+			t.Errorf("canonical form missing %s\ngot:\n%s", want, got)
+		}
+	}
+}
+
+func TestProtectedTermRedefinition(t *testing.T) {
+	v := testVerifier(nil)
+	doc := map[string]any{
+		"@context": []any{"https://www.w3.org/ns/credentials/v2", map[string]any{"VerifiableCredential": "http://example.com/#Fake"}},
+		"type":     "VerifiableCredential",
+	}
+	if _, err := v.canonicalRDF(doc); err == nil || !strings.Contains(err.Error(), "protected term redefinition") {
+		t.Fatalf("canonicalRDF() error = %v, want protected term redefinition", err)
+	}
+}
+
+func TestCanonicalJSON(t *testing.T) {
+	// The example from RFC 8785 section 3.2.2.
+	in := `{"numbers":[333333333.33333329,1E30,4.50,2e-3,0.000000000000000000000000001],` +
+		`"string":"€$\u000F\u000aA'B\"\\\\\"\/","literals":[null,true,false]}`
+	want := `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],` +
+		`"string":"€$\u000f\nA'B\"\\\\\"/"}`
+	var v any
+	if err := json.Unmarshal([]byte(in), &v); err != nil {
+		t.Fatal(err)
+	}
+	got, err := canonicalJSON(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("canonicalJSON() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func base58Encode(b []byte) string {
+	const alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	n := new(big.Int).SetBytes(b)
+	var out []byte
+	mod := new(big.Int)
+	for n.Sign() > 0 {
+		n.DivMod(n, big.NewInt(58), mod)
+		out = append(out, alphabet[mod.Int64()])
+	}
+	for _, c := range b {
+		if c != 0 {
+			break
+		}
+		out = append(out, '1')
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
@@ -0,0 +1,242 @@
+// fetchcache.go keeps fetched DID documents and revocation lists in the
+// handler's Cache, so a busy issuer's did.json and status list are fetched
+// once per lifetime instead of once per credential.
+package vcvalidator
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	fetchCacheKeyPrefix = "validateVC:fetch:"
+
+	// An entry is refreshed in the background once it is hit with less than
+	// 1/refreshAheadDivisor of its lifetime left.
+	refreshAheadDivisor = 5
+
+	// refreshRetryAfter spaces out refresh attempts for an entry whose
+	// origin is failing; the cached copy stays in use until it expires.
+	refreshRetryAfter = 30 * time.Second
+)
+
+// fetchCache is a fetcher that reads through the handler's Cache. Entries
+// live as long as the response's Cache-Control / Expires headers allow, or
+// cacheTTL when it sends neither, capped at cacheMaxTTL. Failed fetches are
+// never cached.
+type fetchCache struct {
+	cache   definition.Cache
+	origin  originFetcher
+	ttl     time.Duration
+	maxTTL  time.Duration
+	timeout time.Duration
+	now     func() time.Time
+	metrics *fetchMetrics // nil when instruments could not be created
+
+	mu         sync.Mutex
+	refreshing map[string]bool
+	nextTry    map[string]time.Time // after a failed refresh
+	wg         sync.WaitGroup       // in-flight refreshes
+}
+
+// cachedFetch is the value stored under a fetch cache key.
+type cachedFetch struct {
+	Body      []byte    `json:"body"`
+	FetchedAt time.Time `json:"fetchedAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func newFetchCache(ctx context.Context, cache definition.Cache, origin originFetcher, cfg *Config) *fetchCache {
+	m, err := getFetchMetrics(ctx)
+	if err != nil {
+		log.Warnf(ctx, "validateVC: fetch cache metrics unavailable: %v", err)
+	}
+	return &fetchCache{
+		cache:      cache,
+		origin:     origin,
+		ttl:        cfg.CacheTTL,
+		maxTTL:     cfg.CacheMaxTTL,
+		timeout:    cfg.HTTPTimeout,
+		now:        time.Now,
+		metrics:    m,
+		refreshing: map[string]bool{},
+		nextTry:    map[string]time.Time{},
+	}
+}
+
+// fetch returns url's body from the cache, or fetches and caches it. A cache
+// that cannot be read or written is bypassed, never an error.
+func (c *fetchCache) fetch(ctx context.Context, url string) ([]byte, error) {
+	key := fetchCacheKeyPrefix + url
+	kind := attrKind.String(fetchKind(url))
+	if raw, err := c.cache.Get(ctx, key); err == nil && raw != "" {
+		var e cachedFetch
+		if json.Unmarshal([]byte(raw), &e) == nil && c.now().Before(e.ExpiresAt) {
+			if c.metrics != nil {
+				c.metrics.cacheHits.Add(ctx, 1, metric.WithAttributes(kind))
+			}
+			if c.dueForRefresh(e) {
+				c.refresh(ctx, key, url)
+			}
+			return e.Body, nil
+		}
+	}
+	if c.metrics != nil {
+		c.metrics.cacheMisses.Add(ctx, 1, metric.WithAttributes(kind))
+	}
+	return c.load(ctx, key, url)
+}
+
+// load fetches url from the origin and stores it under key.
+func (c *fetchCache) load(ctx context.Context, key, url string) ([]byte, error) {
+	body, header, err := c.origin(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	ttl := c.lifetime(header)
+	if ttl <= 0 {
+		return body, nil
+	}
+	now := c.now()
+	data, err := json.Marshal(cachedFetch{Body: body, FetchedAt: now, ExpiresAt: now.Add(ttl)})
+	if err == nil {
+		err = c.cache.Set(ctx, key, string(data), ttl)
+	}
+	if err != nil {
+		log.Warnf(ctx, "validateVC: could not cache %s: %v", url, err)
+	}
+	return body, nil
+}
+
+// dueForRefresh reports whether e is in the last part of its lifetime.
+func (c *fetchCache) dueForRefresh(e cachedFetch) bool {
+	life := e.ExpiresAt.Sub(e.FetchedAt)
+	return e.ExpiresAt.Sub(c.now()) < life/refreshAheadDivisor
+}
+
+// refresh reloads key in the background, at most once at a time per key.
+// Only entries that are hit late in their lifetime get here, so a refresh
+// keeps hot entries warm without reloading ones nobody asks for.
+func (c *fetchCache) refresh(ctx context.Context, key, url string) {
+	c.mu.Lock()
+	if c.refreshing[key] || c.now().Before(c.nextTry[key]) {
+		c.mu.Unlock()
+		return
+	}
+	c.refreshing[key] = true
+	c.mu.Unlock()
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		rctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.timeout)
+		defer cancel()
+		_, err := c.load(rctx, key, url)
+
+		status := "success"
+		c.mu.Lock()
+		delete(c.refreshing, key)
+		if err != nil {
+			status = "error"
+			c.nextTry[key] = c.now().Add(refreshRetryAfter)
+		} else {
+			delete(c.nextTry, key)
+		}
+		c.mu.Unlock()
+		if err != nil {
+			log.Warnf(rctx, "validateVC: background refresh of %s failed: %v", url, err)
+		}
+		if c.metrics != nil {
+			c.metrics.cacheRefreshes.Add(rctx, 1,
+				metric.WithAttributes(attrKind.String(fetchKind(url)), attrStatus.String(status)))
+		}
+	}()
+}
+
+// lifetime is how long a response may be cached: s-maxage or max-age (less
+// Age), else Expires, else the configured TTL — capped at maxTTL. no-store
+// and no-cache responses are not cached, since the cache never revalidates.
+func (c *fetchCache) lifetime(h http.Header) time.Duration {
+	ttl, ok := headerLifetime(h, c.now())
+	if !ok {
+		ttl = c.ttl
+	}
+	if c.maxTTL > 0 && ttl > c.maxTTL {
+		ttl = c.maxTTL
+	}
+	return ttl
+}
+
+// headerLifetime reads the freshness lifetime from the response headers;
+// ok is false when they do not specify one.
+func headerLifetime(h http.Header, now time.Time) (ttl time.Duration, ok bool) {
+	maxAge, sMaxAge := -1, -1
+	for _, directive := range strings.Split(h.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-store", "no-cache", "private":
+			return 0, true
+		case "max-age":
+			maxAge = atoiOr(value, -1)
+		case "s-maxage":
+			sMaxAge = atoiOr(value, -1)
+		}
+	}
+	if sMaxAge >= 0 {
+		maxAge = sMaxAge
+	}
+	if maxAge >= 0 {
+		age := time.Duration(atoiOr(h.Get("Age"), 0)) * time.Second
+		return max(time.Duration(maxAge)*time.Second-age, 0), true
+	}
+	if v := h.Get("Expires"); v != "" {
+		expires, err := http.ParseTime(v)
+		if err != nil {
+			return 0, true // an invalid Expires means already expired
+		}
+		if date, err := http.ParseTime(h.Get("Date")); err == nil {
+			now = date
+		}
+		return max(expires.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+func atoiOr(v string, def int) int {
+	n, err := strconv.Atoi(strings.Trim(strings.TrimSpace(v), `"`))
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// fetchKind labels a fetched URL for metrics: did:web documents always end
+// in did.json; everything else the verifier fetches is a revocation list.
+func fetchKind(url string) string {
+	if strings.HasSuffix(url, "/did.json") {
+		return "did_document"
+	}
+	return "status_list"
+}
+
+// SetCache receives the handler's Cache and routes DID document and
+// revocation list fetches through it. Without a Cache, or with cacheTTL 0,
+// every fetch goes to the origin. It implements definition.CacheAwareStep.
+func (s *step) SetCache(cache definition.Cache) error {
+	if cache == nil || s.cfg.CacheTTL <= 0 || s.origin == nil {
+		return nil
+	}
+	s.v.fetch = newFetchCache(context.Background(), cache, s.origin, s.cfg).fetch
+	return nil
+}
+
+var _ definition.CacheAwareStep = (*step)(nil)
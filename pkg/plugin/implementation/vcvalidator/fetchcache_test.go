@@ -0,0 +1,311 @@
+package vcvalidator
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// mapCache is an in-memory definition.Cache that ignores TTLs.
+type mapCache struct {
+	mu   sync.Mutex
+	data map[string]string
+	ttls map[string]time.Duration
+}
+
+func newMapCache() *mapCache {
+	return &mapCache{data: map[string]string{}, ttls: map[string]time.Duration{}}
+}
+
+func (c *mapCache) Get(_ context.Context, key string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.data[key]
+	if !ok {
+		return "", errors.New("miss")
+	}
+	return v, nil
+}
+
+func (c *mapCache) Set(_ context.Context, key, value string, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data[key], c.ttls[key] = value, ttl
+	return nil
+}
+
+func (c *mapCache) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.data, key)
+	return nil
+}
+
+func (c *mapCache) Clear(context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data = map[string]string{}
+	return nil
+}
+
+// countingOrigin serves body with header, or err, and counts calls.
+type countingOrigin struct {
+	mu     sync.Mutex
+	calls  int
+	body   string
+	header http.Header
+	err    error
+}
+
+func (o *countingOrigin) fetch(context.Context, string) ([]byte, http.Header, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.calls++
+	if o.err != nil {
+		return nil, nil, o.err
+	}
+	return []byte(o.body), o.header, nil
+}
+
+func (o *countingOrigin) set(body string, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.body, o.err = body, err
+}
+
+func (o *countingOrigin) count() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.calls
+}
+
+func testFetchCache(t *testing.T, origin *countingOrigin, now *time.Time) (*fetchCache, *mapCache) {
+	t.Helper()
+	cache := newMapCache()
+	cfg := DefaultConfig()
+	fc := newFetchCache(context.Background(), cache, origin.fetch, cfg)
+	fc.now = func() time.Time { return *now }
+	return fc, cache
+}
+
+const statusURL = "https://status.example.com/lists/1"
+
+func TestFetchCacheHitAndMiss(t *testing.T) {
+	now := fixedNow()
+	origin := &countingOrigin{body: "v1", header: http.Header{"Cache-Control": {"public, max-age=600"}}}
+	fc, cache := testFetchCache(t, origin, &now)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		body, err := fc.fetch(ctx, statusURL)
+		if err != nil || string(body) != "v1" {
+			t.Fatalf("fetch %d = %q, %v", i, body, err)
+		}
+	}
+	if origin.count() != 1 {
+		t.Fatalf("origin fetched %d times, want 1", origin.count())
+	}
+	if ttl := cache.ttls[fetchCacheKeyPrefix+statusURL]; ttl != 10*time.Minute {
+		t.Fatalf("cached for %v, want max-age 10m", ttl)
+	}
+
+	now = now.Add(11 * time.Minute)
+	origin.set("v2", nil)
+	if body, _ := fc.fetch(ctx, statusURL); string(body) != "v2" {
+		t.Fatalf("an expired entry must be refetched, got %q", body)
+	}
+}
+
+func TestFetchCacheNotCached(t *testing.T) {
+	now := fixedNow()
+	ctx := context.Background()
+
+	origin := &countingOrigin{body: "v1", header: http.Header{"Cache-Control": {"no-store"}}}
+	fc, _ := testFetchCache(t, origin, &now)
+	_, _ = fc.fetch(ctx, statusURL)
+	_, _ = fc.fetch(ctx, statusURL)
+	if origin.count() != 2 {
+		t.Fatalf("a no-store response must not be cached, origin fetched %d times", origin.count())
+	}
+
+	origin = &countingOrigin{err: errors.New("connection refused")}
+	fc, cache := testFetchCache(t, origin, &now)
+	if _, err := fc.fetch(ctx, statusURL); err == nil {
+		t.Fatal("expected the origin error")
+	}
+	if len(cache.data) != 0 {
+		t.Fatalf("a failed fetch must not be cached: %v", cache.data)
+	}
+}
+
+func TestFetchCacheBackgroundRefresh(t *testing.T) {
+	now := fixedNow()
+	origin := &countingOrigin{body: "v1", header: http.Header{"Cache-Control": {"max-age=100"}}}
+	fc, _ := testFetchCache(t, origin, &now)
+	ctx := context.Background()
+
+	_, _ = fc.fetch(ctx, statusURL)
+	now = now.Add(50 * time.Second)
+	_, _ = fc.fetch(ctx, statusURL)
+	fc.wg.Wait()
+	if origin.count() != 1 {
+		t.Fatalf("an entry early in its lifetime must not be refreshed, origin fetched %d times", origin.count())
+	}
+
+	// Hit in the last fifth of its lifetime: served from the cache, and
+	// reloaded in the background.
+	now = now.Add(35 * time.Second)
+	origin.set("v2", nil)
+	if body, _ := fc.fetch(ctx, statusURL); string(body) != "v1" {
+		t.Fatalf("refresh must not block the hit, got %q", body)
+	}
+	fc.wg.Wait()
+	if origin.count() != 2 {
+		t.Fatalf("expected a background refresh, origin fetched %d times", origin.count())
+	}
+	if body, _ := fc.fetch(ctx, statusURL); string(body) != "v2" {
+		t.Fatalf("expected the refreshed body, got %q", body)
+	}
+
+	// A failing origin is retried after refreshRetryAfter, not on every hit;
+	// the cached copy keeps being served.
+	now = now.Add(85 * time.Second)
+	origin.set("", errors.New("connection refused"))
+	for i := 0; i < 3; i++ {
+		if body, err := fc.fetch(ctx, statusURL); err != nil || string(body) != "v2" {
+			t.Fatalf("fetch = %q, %v", body, err)
+		}
+		fc.wg.Wait()
+	}
+	if origin.count() != 3 {
+		t.Fatalf("expected one failed refresh, origin fetched %d times", origin.count())
+	}
+}
+
+func TestHeaderLifetime(t *testing.T) {
+	now := fixedNow()
+	cases := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+		ok     bool
+	}{
+		{"none", http.Header{}, 0, false},
+		{"max-age", http.Header{"Cache-Control": {"max-age=300"}}, 5 * time.Minute, true},
+		{"max-age less age", http.Header{"Cache-Control": {"max-age=300"}, "Age": {"100"}}, 200 * time.Second, true},
+		{"s-maxage wins", http.Header{"Cache-Control": {"max-age=300, s-maxage=60"}}, time.Minute, true},
+		{"no-cache", http.Header{"Cache-Control": {"no-cache"}}, 0, true},
+		{"private", http.Header{"Cache-Control": {"private, max-age=300"}}, 0, true},
+		{"expires", http.Header{
+			"Date":    {now.UTC().Format(http.TimeFormat)},
+			"Expires": {now.Add(time.Hour).UTC().Format(http.TimeFormat)},
+		}, time.Hour, true},
+		{"invalid expires", http.Header{"Expires": {"0"}}, 0, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := headerLifetime(tc.header, now)
+			if got != tc.want || ok != tc.ok {
+				t.Fatalf("headerLifetime = %v, %v; want %v, %v", got, ok, tc.want, tc.ok)
+			}
+		})
+	}
+
+	fc := &fetchCache{ttl: 5 * time.Minute, maxTTL: time.Hour, now: func() time.Time { return now }}
+	if got := fc.lifetime(http.Header{}); got != 5*time.Minute {
+		t.Errorf("no headers: lifetime = %v, want cacheTTL", got)
+	}
+	if got := fc.lifetime(http.Header{"Cache-Control": {"max-age=86400"}}); got != time.Hour {
+		t.Errorf("long max-age: lifetime = %v, want cacheMaxTTL", got)
+	}
+}
+
+func TestFetchCacheMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	prev := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	t.Cleanup(func() { otel.SetMeterProvider(prev) })
+
+	now := fixedNow()
+	origin := &countingOrigin{body: "{}", header: http.Header{"Cache-Control": {"max-age=600"}}}
+	fc, _ := testFetchCache(t, origin, &now)
+	ctx := context.Background()
+	_, _ = fc.fetch(ctx, "https://issuer.example.com/did.json")
+	_, _ = fc.fetch(ctx, "https://issuer.example.com/did.json")
+	_, _ = fc.fetch(ctx, "https://issuer.example.com/did.json")
+	_, _ = fc.fetch(ctx, statusURL)
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatalf("collect: %v", err)
+	}
+	got := map[string]int64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			if !ok {
+				continue
+			}
+			for _, dp := range sum.DataPoints {
+				kind, _ := dp.Attributes.Value(attrKind)
+				got[m.Name+"/"+kind.AsString()] += dp.Value
+			}
+		}
+	}
+	want := map[string]int64{
+		"onix_vc_cache_hits_total/did_document":   2,
+		"onix_vc_cache_misses_total/did_document": 1,
+		"onix_vc_cache_misses_total/status_list":  1,
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %d, want %d (all: %v)", k, got[k], v, got)
+		}
+	}
+}
+
+func TestStepSetCache(t *testing.T) {
+	st, err := New(map[string]string{"actions": "confirm", "cacheTTL": "2m", "cacheMaxTTL": "30m"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	s := st.(*step)
+	if s.cfg.CacheTTL != 2*time.Minute || s.cfg.CacheMaxTTL != 30*time.Minute {
+		t.Fatalf("config = %+v", s.cfg)
+	}
+	origin := &countingOrigin{body: "{}"}
+	s.origin = origin.fetch
+	if err := s.SetCache(newMapCache()); err != nil {
+		t.Fatalf("SetCache: %v", err)
+	}
+	_, _ = s.v.fetch(context.Background(), statusURL)
+	_, _ = s.v.fetch(context.Background(), statusURL)
+	if origin.count() != 1 {
+		t.Fatalf("fetches must go through the cache, origin fetched %d times", origin.count())
+	}
+
+	st, _ = New(map[string]string{"actions": "confirm", "cacheTTL": "0"})
+	s = st.(*step)
+	s.origin = origin.fetch
+	_ = s.SetCache(newMapCache())
+	_, _ = s.v.fetch(context.Background(), "https://127.0.0.1/list")
+	if origin.count() != 1 {
+		t.Fatal("cacheTTL 0 must leave fetches uncached")
+	}
+
+	for _, cfg := range []map[string]string{
+		{"actions": "confirm", "cacheTTL": "-1s"},
+		{"actions": "confirm", "cacheMaxTTL": "0"},
+	} {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("%v: expected a config error", cfg)
+		}
+	}
+}
@@ -0,0 +1,1176 @@
+// jsonld.go implements the part of JSON-LD 1.1 that Data Integrity proofs
+// depend on: context processing (including protected, type-scoped and
+// property-scoped contexts) and document expansion. rdf.go turns the expanded
+// form into an RDF dataset for canonicalisation.
+//
+// Remote contexts are never fetched. Every context URL is resolved through a
+// contextStore, which serves bundled copies of the W3C contexts plus any files
+// the operator maps in with jsonldContexts.
+//
+// Expansion runs in "safe mode": a property that does not expand to an
+// absolute IRI, or a type that does not, is an error rather than being
+// silently dropped. Dropped data is not covered by the signature, so
+// accepting it would let an attacker add unsigned claims to a credential.
+package vcvalidator
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// maxContextDepth bounds nested remote context inclusion, so a context that
+// (directly or indirectly) includes itself fails instead of recursing forever.
+const maxContextDepth = 16
+
+// termDef is a processed JSON-LD term definition.
+type termDef struct {
+	id          string // expanded IRI, blank node or keyword; "" when null
+	reverse     bool
+	typ         string // type mapping: @id, @vocab, @json, @none or an IRI
+	container   map[string]bool
+	context     any // raw scoped context, valid when hasContext
+	hasContext  bool
+	language    string
+	hasLanguage bool
+	protected   bool
+	prefix      bool
+	index       string
+	nest        string
+}
+
+// sameAs reports whether d and o are the same definition apart from their
+// protected flag, which is what a protected term may be redefined to.
+func (d *termDef) sameAs(o *termDef) bool {
+	return d.id == o.id && d.reverse == o.reverse && d.typ == o.typ &&
+		reflect.DeepEqual(d.container, o.container) &&
+		d.hasContext == o.hasContext && reflect.DeepEqual(d.context, o.context) &&
+		d.language == o.language && d.hasLanguage == o.hasLanguage &&
+		d.prefix == o.prefix && d.index == o.index && d.nest == o.nest
+}
+
+// activeContext is the JSON-LD active context.
+type activeContext struct {
+	terms       map[string]*termDef
+	vocab       string
+	hasVocab    bool
+	base        string
+	language    string
+	hasLanguage bool
+	// previous is the context to revert to when a type-scoped context, which
+	// does not propagate, goes out of scope.
+	previous *activeContext
+}
+
+func newActiveContext() *activeContext {
+	return &activeContext{terms: map[string]*termDef{}}
+}
+
+func (c *activeContext) clone() *activeContext {
+	out := *c
+	out.terms = make(map[string]*termDef, len(c.terms))
+	for k, v := range c.terms {
+		out.terms[k] = v
+	}
+	return &out
+}
+
+// jsonldProcessor expands JSON-LD documents against an offline context store.
+type jsonldProcessor struct {
+	contexts *contextStore
+}
+
+// termScope carries the state of one local context while its term
+// definitions are being created.
+type termScope struct {
+	local             map[string]any
+	defined           map[string]bool
+	protected         bool
+	overrideProtected bool
+	remote            []string
+}
+
+// processContext applies a local context (a URL, an object, null, or an array
+// of those) to active and returns the resulting context.
+func (p *jsonldProcessor) processContext(active *activeContext, local any, remote []string, overrideProtected, propagate bool) (*activeContext, error) {
+	result := active.clone()
+	if m, ok := local.(map[string]any); ok {
+		if v, ok := m["@propagate"]; ok {
+			b, ok := v.(bool)
+			if !ok {
+				return nil, errors.New("invalid @propagate value")
+			}
+			propagate = b
+		}
+	}
+	if !propagate && result.previous == nil {
+		result.previous = active
+	}
+	for _, ctx := range asArray(local) {
+		switch c := ctx.(type) {
+		case nil:
+			if !overrideProtected {
+				for term, d := range result.terms {
+					if d.protected {
+						return nil, fmt.Errorf("invalid context nullification: term %q is protected", term)
+					}
+				}
+			}
+			previous := result.previous
+			result = newActiveContext()
+			if !propagate {
+				result.previous = previous
+			}
+		case string:
+			if len(remote) >= maxContextDepth {
+				return nil, fmt.Errorf("context %q: too many nested contexts", c)
+			}
+			doc, err := p.contexts.load(c)
+			if err != nil {
+				return nil, err
+			}
+			nested := append(append([]string(nil), remote...), c)
+			if result, err = p.processContext(result, doc, nested, false, true); err != nil {
+				return nil, fmt.Errorf("context %q: %w", c, err)
+			}
+		case map[string]any:
+			if err := p.processContextMap(result, c, remote, overrideProtected); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("invalid local context of type %T", ctx)
+		}
+	}
+	return result, nil
+}
+
+func (p *jsonldProcessor) processContextMap(result *activeContext, c map[string]any, remote []string, overrideProtected bool) error {
+	if v, ok := c["@version"]; ok {
+		if f, ok := v.(float64); !ok || f != 1.1 {
+			return fmt.Errorf("invalid @version value %v", v)
+		}
+	}
+	if _, ok := c["@import"]; ok {
+		return errors.New("@import is not supported")
+	}
+	if v, ok := c["@base"]; ok && len(remote) == 0 {
+		switch b := v.(type) {
+		case nil:
+			result.base = ""
+		case string:
+			result.base = resolveIRI(result.base, b)
+		default:
+			return errors.New("invalid @base value")
+		}
+	}
+	if v, ok := c["@vocab"]; ok {
+		switch s := v.(type) {
+		case nil:
+			result.vocab, result.hasVocab = "", false
+		case string:
+			vocab, err := p.expandIRI(result, s, true, true, nil)
+			if err != nil {
+				return err
+			}
+			if !isAbsoluteIRI(vocab) && !isBlankNode(vocab) {
+				return fmt.Errorf("invalid @vocab mapping %q", s)
+			}
+			result.vocab, result.hasVocab = vocab, true
+		default:
+			return errors.New("invalid @vocab value")
+		}
+	}
+	if v, ok := c["@language"]; ok {
+		switch s := v.(type) {
+		case nil:
+			result.language, result.hasLanguage = "", false
+		case string:
+			result.language, result.hasLanguage = strings.ToLower(s), true
+		default:
+			return errors.New("invalid default language")
+		}
+	}
+	scope := &termScope{local: c, defined: map[string]bool{}, overrideProtected: overrideProtected, remote: remote}
+	if v, ok := c["@protected"]; ok {
+		b, ok := v.(bool)
+		if !ok {
+			return errors.New("invalid @protected value")
+		}
+		scope.protected = b
+	}
+	for _, key := range sortedKeys(c) {
+		switch key {
+		case "@base", "@direction", "@import", "@language", "@propagate", "@protected", "@version", "@vocab":
+			continue
+		}
+		if err := p.createTermDefinition(result, key, scope); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createTermDefinition creates the definition of term from scope.local in
+// active, first creating any terms it depends on.
+func (p *jsonldProcessor) createTermDefinition(active *activeContext, term string, scope *termScope) error {
+	if done, ok := scope.defined[term]; ok {
+		if done {
+			return nil
+		}
+		return fmt.Errorf("cyclic IRI mapping for term %q", term)
+	}
+	if term == "" {
+		return errors.New("invalid term definition: empty term")
+	}
+	scope.defined[term] = false
+	value := scope.local[term]
+
+	if term == "@type" {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return errors.New("keyword redefinition: @type")
+		}
+		for k, v := range m {
+			if (k != "@container" || v != "@set") && k != "@protected" {
+				return errors.New("keyword redefinition: @type")
+			}
+		}
+	} else if isKeyword(term) {
+		return fmt.Errorf("keyword redefinition: %s", term)
+	} else if looksLikeKeyword(term) {
+		scope.defined[term] = true
+		return nil
+	}
+
+	previous := active.terms[term]
+	delete(active.terms, term)
+
+	var m map[string]any
+	simple := false
+	switch v := value.(type) {
+	case nil:
+		m = map[string]any{"@id": nil}
+	case string:
+		m = map[string]any{"@id": v}
+		simple = true
+	case map[string]any:
+		m = v
+	default:
+		return fmt.Errorf("invalid term definition for %q", term)
+	}
+
+	def := &termDef{protected: scope.protected}
+	if v, ok := m["@protected"]; ok {
+		b, ok := v.(bool)
+		if !ok {
+			return fmt.Errorf("invalid @protected value for term %q", term)
+		}
+		def.protected = b
+	}
+	if v, ok := m["@type"]; ok {
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("invalid type mapping for term %q", term)
+		}
+		t, err := p.expandIRI(active, s, true, false, scope)
+		if err != nil {
+			return err
+		}
+		switch {
+		case t == "@id", t == "@vocab", t == "@json", t == "@none", isAbsoluteIRI(t):
+			def.typ = t
+		default:
+			return fmt.Errorf("invalid type mapping %q for term %q", s, term)
+		}
+	}
+
+	if v, ok := m["@reverse"]; ok {
+		if _, ok := m["@id"]; ok {
+			return fmt.Errorf("invalid reverse property for term %q", term)
+		}
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("invalid IRI mapping for reverse term %q", term)
+		}
+		if looksLikeKeyword(s) {
+			scope.defined[term] = true
+			return nil
+		}
+		id, err := p.expandIRI(active, s, true, false, scope)
+		if err != nil {
+			return err
+		}
+		if !isAbsoluteIRI(id) && !isBlankNode(id) {
+			return fmt.Errorf("invalid IRI mapping for reverse term %q", term)
+		}
+		def.id, def.reverse = id, true
+		if c, ok := m["@container"]; ok {
+			if c != nil && c != "@set" && c != "@index" {
+				return fmt.Errorf("invalid reverse property container for term %q", term)
+			}
+			if s, ok := c.(string); ok {
+				def.container = map[string]bool{s: true}
+			}
+		}
+		active.terms[term] = def
+		scope.defined[term] = true
+		return nil
+	}
+
+	if v, ok := m["@id"]; ok && v != any(term) {
+		switch id := v.(type) {
+		case nil:
+		case string:
+			if !isKeyword(id) && looksLikeKeyword(id) {
+				scope.defined[term] = true
+				return nil
+			}
+			exp, err := p.expandIRI(active, id, true, false, scope)
+			if err != nil {
+				return err
+			}
+			if exp == "@context" || (!isKeyword(exp) && !isAbsoluteIRI(exp) && !isBlankNode(exp)) {
+				return fmt.Errorf("invalid IRI mapping %q for term %q", id, term)
+			}
+			def.id = exp
+			inner := term
+			if len(inner) > 2 {
+				inner = inner[1 : len(inner)-1]
+			}
+			if simple && !strings.ContainsAny(inner, ":/") && (isBlankNode(exp) || endsWithGenDelim(exp)) {
+				def.prefix = true
+			}
+		default:
+			return fmt.Errorf("invalid IRI mapping for term %q", term)
+		}
+	} else if i := strings.Index(term, ":"); i > 0 {
+		prefix, suffix := term[:i], term[i+1:]
+		if _, ok := scope.local[prefix]; ok {
+			if err := p.createTermDefinition(active, prefix, scope); err != nil {
+				return err
+			}
+		}
+		if pd := active.terms[prefix]; pd != nil && pd.id != "" {
+			def.id = pd.id + suffix
+		} else {
+			def.id = term
+		}
+	} else if strings.Contains(term, "/") {
+		exp, err := p.expandIRI(active, term, true, false, scope)
+		if err != nil {
+			return err
+		}
+		if !isAbsoluteIRI(exp) {
+			return fmt.Errorf("invalid IRI mapping for term %q", term)
+		}
+		def.id = exp
+	} else if term == "@type" {
+		def.id = "@type"
+	} else if active.hasVocab {
+		def.id = active.vocab + term
+	} else {
+		return fmt.Errorf("invalid IRI mapping: term %q has no IRI and no @vocab is set", term)
+	}
+
+	if v, ok := m["@container"]; ok {
+		def.container = map[string]bool{}
+		for _, c := range asArray(v) {
+			s, _ := c.(string)
+			switch s {
+			case "@list", "@set", "@index", "@language", "@graph", "@id", "@type":
+				def.container[s] = true
+			default:
+				return fmt.Errorf("invalid container mapping %v for term %q", v, term)
+			}
+		}
+		if def.container["@type"] {
+			switch def.typ {
+			case "":
+				def.typ = "@id"
+			case "@id", "@vocab":
+			default:
+				return fmt.Errorf("invalid type mapping for @type container term %q", term)
+			}
+		}
+	}
+	if v, ok := m["@index"]; ok {
+		s, ok := v.(string)
+		if !ok || !def.container["@index"] {
+			return fmt.Errorf("invalid term definition: @index on term %q", term)
+		}
+		def.index = s
+	}
+	if v, ok := m["@context"]; ok {
+		def.context, def.hasContext = v, true
+	}
+	if v, ok := m["@language"]; ok {
+		if _, typed := m["@type"]; !typed {
+			switch s := v.(type) {
+			case nil:
+				def.hasLanguage = true
+			case string:
+				def.language, def.hasLanguage = strings.ToLower(s), true
+			default:
+				return fmt.Errorf("invalid language mapping for term %q", term)
+			}
+		}
+	}
+	if v, ok := m["@nest"]; ok {
+		s, ok := v.(string)
+		if !ok || (isKeyword(s) && s != "@nest") {
+			return fmt.Errorf("invalid @nest value for term %q", term)
+		}
+		def.nest = s
+	}
+	if v, ok := m["@prefix"]; ok {
+		b, ok := v.(bool)
+		if !ok || strings.ContainsAny(term, ":/") {
+			return fmt.Errorf("invalid @prefix value for term %q", term)
+		}
+		def.prefix = b
+	}
+
+	if !scope.overrideProtected && previous != nil && previous.protected {
+		if !def.sameAs(previous) {
+			return fmt.Errorf("protected term redefinition: %q", term)
+		}
+		def = previous
+	}
+	active.terms[term] = def
+	scope.defined[term] = true
+	return nil
+}
+
+// expandIRI expands value to an IRI, blank node identifier or keyword. It
+// returns "" when value maps to null. scope is non-nil only while a local
+// context is being processed, so terms it defines can be created on demand.
+func (p *jsonldProcessor) expandIRI(active *activeContext, value string, vocab, documentRelative bool, scope *termScope) (string, error) {
+	if isKeyword(value) {
+		return value, nil
+	}
+	if looksLikeKeyword(value) {
+		return "", nil
+	}
+	if scope != nil {
+		if _, ok := scope.local[value]; ok && !scope.defined[value] {
+			if err := p.createTermDefinition(active, value, scope); err != nil {
+				return "", err
+			}
+		}
+	}
+	if d := active.terms[value]; d != nil {
+		if isKeyword(d.id) {
+			return d.id, nil
+		}
+		if vocab {
+			return d.id, nil
+		}
+	}
+	if i := strings.Index(value, ":"); i > 0 {
+		prefix, suffix := value[:i], value[i+1:]
+		if prefix == "_" || strings.HasPrefix(suffix, "//") {
+			return value, nil
+		}
+		if scope != nil {
+			if _, ok := scope.local[prefix]; ok && !scope.defined[prefix] {
+				if err := p.createTermDefinition(active, prefix, scope); err != nil {
+					return "", err
+				}
+			}
+		}
+		if d := active.terms[prefix]; d != nil && d.id != "" && d.prefix {
+			return d.id + suffix, nil
+		}
+		if isAbsoluteIRI(value) {
+			return value, nil
+		}
+	}
+	if vocab && active.hasVocab {
+		return active.vocab + value, nil
+	}
+	if documentRelative {
+		return resolveIRI(active.base, value), nil
+	}
+	return value, nil
+}
+
+// expandDocument expands a JSON-LD document to its expanded form: an array of
+// node objects.
+func (p *jsonldProcessor) expandDocument(doc any) ([]any, error) {
+	out, err := p.expand(newActiveContext(), "", doc, false)
+	if err != nil {
+		return nil, err
+	}
+	if m, ok := out.(map[string]any); ok && len(m) == 1 {
+		if g, ok := m["@graph"]; ok {
+			out = g
+		}
+	}
+	if out == nil {
+		return []any{}, nil
+	}
+	return asArray(out), nil
+}
+
+// expand implements the JSON-LD 1.1 expansion algorithm. activeProp is ""
+// at the top level.
+func (p *jsonldProcessor) expand(active *activeContext, activeProp string, element any, fromMap bool) (any, error) {
+	if element == nil {
+		return nil, nil
+	}
+	def := active.terms[activeProp]
+	switch el := element.(type) {
+	case []any:
+		out := []any{}
+		for _, item := range el {
+			ex, err := p.expand(active, activeProp, item, fromMap)
+			if err != nil {
+				return nil, err
+			}
+			if def != nil && def.container["@list"] {
+				if arr, ok := ex.([]any); ok {
+					ex = map[string]any{"@list": arr}
+				}
+			}
+			switch x := ex.(type) {
+			case nil:
+			case []any:
+				out = append(out, x...)
+			default:
+				out = append(out, x)
+			}
+		}
+		return out, nil
+	case map[string]any:
+		return p.expandObject(active, activeProp, el, fromMap)
+	default:
+		if activeProp == "" || activeProp == "@graph" {
+			return nil, nil
+		}
+		if def != nil && def.hasContext {
+			var err error
+			if active, err = p.processContext(active, def.context, nil, true, true); err != nil {
+				return nil, err
+			}
+		}
+		return p.expandValue(active, activeProp, el)
+	}
+}
+
+func (p *jsonldProcessor) expandObject(active *activeContext, activeProp string, el map[string]any, fromMap bool) (any, error) {
+	keys := sortedKeys(el)
+
+	// A type-scoped context does not propagate into nested node objects.
+	if active.previous != nil && !fromMap {
+		revert := true
+		for _, k := range keys {
+			exp, err := p.expandIRI(active, k, true, false, nil)
+			if err != nil {
+				return nil, err
+			}
+			if exp == "@value" || (exp == "@id" && len(el) == 1) {
+				revert = false
+				break
+			}
+		}
+		if revert {
+			active = active.previous
+		}
+	}
+	var err error
+	if def := active.terms[activeProp]; def != nil && def.hasContext {
+		if active, err = p.processContext(active, def.context, nil, true, true); err != nil {
+			return nil, err
+		}
+	}
+	if c, ok := el["@context"]; ok {
+		if active, err = p.processContext(active, c, nil, false, true); err != nil {
+			return nil, err
+		}
+	}
+
+	typeScoped := active
+	inputType := ""
+	for _, k := range keys {
+		exp, err := p.expandIRI(active, k, true, false, nil)
+		if err != nil {
+			return nil, err
+		}
+		if exp != "@type" {
+			continue
+		}
+		var types []string
+		for _, t := range asArray(el[k]) {
+			if s, ok := t.(string); ok {
+				types = append(types, s)
+			}
+		}
+		sort.Strings(types)
+		for _, t := range types {
+			if d := typeScoped.terms[t]; d != nil && d.hasContext {
+				if active, err = p.processContext(active, d.context, nil, false, false); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if inputType == "" && len(types) > 0 {
+			if inputType, err = p.expandIRI(active, types[len(types)-1], true, true, nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	result := map[string]any{}
+	if err := p.expandProperties(active, typeScoped, activeProp, el, keys, inputType, result); err != nil {
+		return nil, err
+	}
+
+	if v, ok := result["@value"]; ok {
+		for k := range result {
+			switch k {
+			case "@value", "@type", "@language", "@direction", "@index":
+			default:
+				return nil, fmt.Errorf("invalid value object: unexpected %s", k)
+			}
+		}
+		if t, ok := result["@type"].([]any); ok {
+			if len(t) != 1 {
+				return nil, errors.New("invalid typed value: more than one @type")
+			}
+			result["@type"] = t[0]
+		}
+		_, hasLang := result["@language"]
+		_, hasDir := result["@direction"]
+		if _, typed := result["@type"]; typed && (hasLang || hasDir) {
+			return nil, errors.New("invalid value object: both @type and @language")
+		}
+		if result["@type"] == "@json" {
+			return result, nil
+		}
+		if v == nil {
+			return nil, nil
+		}
+		if _, isString := v.(string); !isString && hasLang {
+			return nil, errors.New("invalid language-tagged value")
+		}
+		if t, ok := result["@type"].(string); ok && !isAbsoluteIRI(t) {
+			return nil, fmt.Errorf("invalid typed value: %q is not an absolute IRI", t)
+		}
+		return result, nil
+	}
+	_, hasSet := result["@set"]
+	_, hasList := result["@list"]
+	if hasSet || hasList {
+		for k := range result {
+			if k != "@set" && k != "@list" && k != "@index" {
+				return nil, fmt.Errorf("invalid set or list object: unexpected %s", k)
+			}
+		}
+		if hasSet {
+			return result["@set"], nil
+		}
+	}
+	if _, ok := result["@language"]; ok && len(result) == 1 {
+		return nil, nil
+	}
+	if activeProp == "" || activeProp == "@graph" {
+		_, onlyID := result["@id"]
+		if len(result) == 0 || hasList || (len(result) == 1 && onlyID) {
+			return nil, nil
+		}
+	}
+	return result, nil
+}
+
+// expandProperties expands the entries of el (in keys order) into result.
+// It is also used for the values of @nest entries.
+func (p *jsonldProcessor) expandProperties(active, typeScoped *activeContext, activeProp string, el map[string]any, keys []string, inputType string, result map[string]any) error {
+	var nests []string
+	for _, key := range keys {
+		if key == "@context" {
+			continue
+		}
+		value := el[key]
+		exp, err := p.expandIRI(active, key, true, false, nil)
+		if err != nil {
+			return err
+		}
+		if exp == "" || (!strings.Contains(exp, ":") && !isKeyword(exp)) {
+			return fmt.Errorf("property %q is not defined by the active context", key)
+		}
+
+		if isKeyword(exp) {
+			if activeProp == "@reverse" {
+				return errors.New("invalid reverse property map")
+			}
+			if _, dup := result[exp]; dup && exp != "@included" && exp != "@type" {
+				return fmt.Errorf("colliding keywords: %s", exp)
+			}
+			var ev any
+			switch exp {
+			case "@id":
+				s, ok := value.(string)
+				if !ok {
+					return errors.New("invalid @id value")
+				}
+				if ev, err = p.expandIRI(active, s, false, true, nil); err != nil {
+					return err
+				}
+			case "@type":
+				var types []any
+				if prev, ok := result["@type"].([]any); ok {
+					types = prev
+				}
+				for _, t := range asArray(value) {
+					s, ok := t.(string)
+					if !ok {
+						return errors.New("invalid type value")
+					}
+					it, err := p.expandIRI(typeScoped, s, true, true, nil)
+					if err != nil {
+						return err
+					}
+					if it != "@json" && !isAbsoluteIRI(it) && !isBlankNode(it) {
+						return fmt.Errorf("type %q is not defined by the active context", s)
+					}
+					types = append(types, it)
+				}
+				ev = types
+			case "@graph":
+				if ev, err = p.expand(active, "@graph", value, false); err != nil {
+					return err
+				}
+				ev = asArray(ev)
+			case "@included":
+				if ev, err = p.expand(active, "", value, false); err != nil {
+					return err
+				}
+				ev = asArray(ev)
+				if prev, ok := result["@included"].([]any); ok {
+					ev = append(prev, ev.([]any)...)
+				}
+			case "@value":
+				switch value.(type) {
+				case nil, string, bool, float64:
+				default:
+					if inputType != "@json" {
+						return errors.New("invalid value object value")
+					}
+				}
+				result["@value"] = value
+				continue
+			case "@language":
+				s, ok := value.(string)
+				if !ok {
+					return errors.New("invalid language-tagged string")
+				}
+				ev = strings.ToLower(s)
+			case "@direction":
+				s, ok := value.(string)
+				if !ok || (s != "ltr" && s != "rtl") {
+					return errors.New("invalid base direction")
+				}
+				ev = s
+			case "@index":
+				s, ok := value.(string)
+				if !ok {
+					return errors.New("invalid @index value")
+				}
+				ev = s
+			case "@list":
+				if activeProp == "" || activeProp == "@graph" {
+					continue
+				}
+				if ev, err = p.expand(active, activeProp, value, false); err != nil {
+					return err
+				}
+				ev = asArray(ev)
+			case "@set":
+				if ev, err = p.expand(active, activeProp, value, false); err != nil {
+					return err
+				}
+			case "@reverse":
+				if _, ok := value.(map[string]any); !ok {
+					return errors.New("invalid @reverse value")
+				}
+				rev, err := p.expand(active, "@reverse", value, false)
+				if err != nil {
+					return err
+				}
+				if err := mergeReverse(result, rev); err != nil {
+					return err
+				}
+				continue
+			case "@nest":
+				nests = append(nests, key)
+				continue
+			default:
+				continue
+			}
+			if ev != nil {
+				result[exp] = ev
+			}
+			continue
+		}
+
+		def := active.terms[key]
+		var container map[string]bool
+		if def != nil {
+			container = def.container
+		}
+		if def != nil && def.nest != "" {
+			nests = append(nests, key)
+			continue
+		}
+		var ev any
+		vm, isMap := value.(map[string]any)
+		switch {
+		case def != nil && def.typ == "@json":
+			ev = map[string]any{"@value": value, "@type": "@json"}
+		case container["@language"] && isMap:
+			if ev, err = expandLanguageMap(active, def, vm); err != nil {
+				return err
+			}
+		case (container["@index"] || container["@type"] || container["@id"]) && isMap:
+			if ev, err = p.expandIndexMap(active, key, def, vm); err != nil {
+				return err
+			}
+		default:
+			if ev, err = p.expand(active, key, value, false); err != nil {
+				return err
+			}
+		}
+		if ev == nil {
+			continue
+		}
+		if container["@list"] && !isListObject(ev) {
+			ev = map[string]any{"@list": asArray(ev)}
+		}
+		if container["@graph"] && !container["@id"] && !container["@index"] {
+			items := asArray(ev)
+			wrapped := make([]any, 0, len(items))
+			for _, item := range items {
+				wrapped = append(wrapped, map[string]any{"@graph": asArray(item)})
+			}
+			ev = wrapped
+		}
+		if def != nil && def.reverse {
+			rev, _ := result["@reverse"].(map[string]any)
+			if rev == nil {
+				rev = map[string]any{}
+				result["@reverse"] = rev
+			}
+			for _, item := range asArray(ev) {
+				if isValueObject(item) || isListObject(item) {
+					return errors.New("invalid reverse property value")
+				}
+				rev[exp] = append(asArray(rev[exp]), item)
+			}
+			continue
+		}
+		result[exp] = append(asArray(result[exp]), asArray(ev)...)
+	}
+
+	for _, nk := range nests {
+		for _, nv := range asArray(el[nk]) {
+			nm, ok := nv.(map[string]any)
+			if !ok {
+				return errors.New("invalid @nest value")
+			}
+			for k := range nm {
+				if exp, _ := p.expandIRI(active, k, true, false, nil); exp == "@value" {
+					return errors.New("invalid @nest value")
+				}
+			}
+			if err := p.expandProperties(active, typeScoped, activeProp, nm, sortedKeys(nm), inputType, result); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// expandIndexMap expands the value of a property whose container is @index,
+// @id or @type.
+func (p *jsonldProcessor) expandIndexMap(active *activeContext, key string, def *termDef, value map[string]any) (any, error) {
+	container := def.container
+	mapCtx := active
+	if (container["@type"] || container["@id"]) && active.previous != nil {
+		mapCtx = active.previous
+	}
+	indexKey := "@index"
+	if def.index != "" {
+		indexKey = def.index
+	}
+	var out []any
+	for _, index := range sortedKeys(value) {
+		ictx := active
+		if container["@type"] {
+			ictx = mapCtx
+			if td := mapCtx.terms[index]; td != nil && td.hasContext {
+				var err error
+				if ictx, err = p.processContext(mapCtx, td.context, nil, false, true); err != nil {
+					return nil, err
+				}
+			}
+		}
+		expandedIndex, err := p.expandIRI(active, index, true, false, nil)
+		if err != nil {
+			return nil, err
+		}
+		items, err := p.expand(ictx, key, asArray(value[index]), true)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range asArray(items) {
+			m, _ := item.(map[string]any)
+			if container["@graph"] && !isGraphObject(item) {
+				m = map[string]any{"@graph": asArray(item)}
+			}
+			if m == nil {
+				out = append(out, item)
+				continue
+			}
+			switch {
+			case expandedIndex == "@none":
+			case container["@index"] && indexKey != "@index":
+				if isValueObject(m) {
+					return nil, errors.New("invalid value object in property-valued index")
+				}
+				iv, err := p.expandValue(active, indexKey, index)
+				if err != nil {
+					return nil, err
+				}
+				prop, err := p.expandIRI(active, indexKey, true, false, nil)
+				if err != nil {
+					return nil, err
+				}
+				m[prop] = append([]any{iv}, asArray(m[prop])...)
+			case container["@index"]:
+				if _, ok := m["@index"]; !ok {
+					m["@index"] = index
+				}
+			case container["@id"]:
+				if _, ok := m["@id"]; !ok {
+					if m["@id"], err = p.expandIRI(active, index, false, true, nil); err != nil {
+						return nil, err
+					}
+				}
+			case container["@type"]:
+				m["@type"] = append([]any{expandedIndex}, asArray(m["@type"])...)
+			}
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
+
+func expandLanguageMap(active *activeContext, def *termDef, value map[string]any) (any, error) {
+	var out []any
+	for _, lang := range sortedKeys(value) {
+		for _, item := range asArray(value[lang]) {
+			switch s := item.(type) {
+			case nil:
+			case string:
+				v := map[string]any{"@value": s}
+				if lang != "@none" {
+					v["@language"] = strings.ToLower(lang)
+				}
+				out = append(out, v)
+			default:
+				return nil, errors.New("invalid language map value")
+			}
+		}
+	}
+	return out, nil
+}
+
+// expandValue expands a scalar value of activeProp.
+func (p *jsonldProcessor) expandValue(active *activeContext, activeProp string, value any) (any, error) {
+	def := active.terms[activeProp]
+	if s, ok := value.(string); ok && def != nil {
+		switch def.typ {
+		case "@id":
+			id, err := p.expandIRI(active, s, false, true, nil)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]any{"@id": id}, nil
+		case "@vocab":
+			id, err := p.expandIRI(active, s, true, true, nil)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]any{"@id": id}, nil
+		}
+	}
+	result := map[string]any{"@value": value}
+	if def != nil && def.typ != "" && def.typ != "@id" && def.typ != "@vocab" && def.typ != "@none" {
+		result["@type"] = def.typ
+	} else if _, ok := value.(string); ok {
+		if def != nil && def.hasLanguage {
+			if def.language != "" {
+				result["@language"] = def.language
+			}
+		} else if active.hasLanguage {
+			result["@language"] = active.language
+		}
+	}
+	return result, nil
+}
+
+// mergeReverse folds an expanded @reverse map into result.
+func mergeReverse(result map[string]any, rev any) error {
+	m, ok := rev.(map[string]any)
+	if !ok {
+		return nil
+	}
+	if inner, ok := m["@reverse"].(map[string]any); ok {
+		for prop, items := range inner {
+			result[prop] = append(asArray(result[prop]), asArray(items)...)
+		}
+	}
+	for prop, items := range m {
+		if prop == "@reverse" {
+			continue
+		}
+		out, _ := result["@reverse"].(map[string]any)
+		if out == nil {
+			out = map[string]any{}
+			result["@reverse"] = out
+		}
+		for _, item := range asArray(items) {
+			if isValueObject(item) || isListObject(item) {
+				return errors.New("invalid reverse property value")
+			}
+			out[prop] = append(asArray(out[prop]), item)
+		}
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// Helpers
+// ---------------------------------------------------------------------------
+
+var jsonldKeywords = map[string]bool{
+	"@base": true, "@container": true, "@context": true, "@default": true,
+	"@direction": true, "@embed": true, "@explicit": true, "@graph": true,
+	"@id": true, "@import": true, "@included": true, "@index": true,
+	"@json": true, "@language": true, "@list": true, "@nest": true,
+	"@none": true, "@omitDefault": true, "@prefix": true, "@preserve": true,
+	"@propagate": true, "@protected": true, "@requireAll": true,
+	"@reverse": true, "@set": true, "@type": true, "@value": true,
+	"@version": true, "@vocab": true,
+}
+
+func isKeyword(s string) bool { return jsonldKeywords[s] }
+
+// looksLikeKeyword matches the @[A-Za-z]+ form reserved for future keywords.
+func looksLikeKeyword(s string) bool {
+	if len(s) < 2 || s[0] != '@' {
+		return false
+	}
+	for _, r := range s[1:] {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// isAbsoluteIRI reports whether s starts with an IRI scheme.
+func isAbsoluteIRI(s string) bool {
+	i := strings.IndexByte(s, ':')
+	if i < 1 {
+		return false
+	}
+	for j, r := range s[:i] {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case j > 0 && (r >= '0' && r <= '9' || r == '+' || r == '-' || r == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func isBlankNode(s string) bool { return strings.HasPrefix(s, "_:") }
+
+func endsWithGenDelim(s string) bool {
+	return s != "" && strings.ContainsRune(":/?#[]@", rune(s[len(s)-1]))
+}
+
+// resolveIRI resolves ref against base. With no base, ref is returned as is.
+func resolveIRI(base, ref string) string {
+	if base == "" || isAbsoluteIRI(ref) {
+		return ref
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return b.ResolveReference(r).String()
+}
+
+func asArray(v any) []any {
+	switch x := v.(type) {
+	case nil:
+		return nil
+	case []any:
+		return x
+	default:
+		return []any{x}
+	}
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func isValueObject(v any) bool {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return false
+	}
+	_, ok = m["@value"]
+	return ok
+}
+
+func isListObject(v any) bool {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return false
+	}
+	_, ok = m["@list"]
+	return ok
+}
+
+func isGraphObject(v any) bool {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return false
+	}
+	if _, ok := m["@graph"]; !ok {
+		return false
+	}
+	for k := range m {
+		if k != "@graph" && k != "@id" && k != "@index" && k != "@context" {
+			return false
+		}
+	}
+	return true
+}
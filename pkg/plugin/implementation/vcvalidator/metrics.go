@@ -0,0 +1,98 @@
+package vcvalidator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// fetchMetrics exposes the fetch cache instruments. The cache-hit ratio is
+// hits / (hits + misses), per kind.
+type fetchMetrics struct {
+	cacheHits      metric.Int64Counter
+	cacheMisses    metric.Int64Counter
+	cacheRefreshes metric.Int64Counter
+}
+
+// Attribute keys for the fetch cache instruments.
+var (
+	attrKind   = attribute.Key("kind") // did_document | status_list
+	attrStatus = attribute.Key("status")
+)
+
+// fetchMetricsCache caches the fetchMetrics for the current global
+// MeterProvider. Instruments are rebound only when otel.SetMeterProvider
+// changes the provider pointer.
+var fetchMetricsCache struct {
+	mu       sync.RWMutex
+	provider metric.MeterProvider
+	m        *fetchMetrics
+}
+
+// getFetchMetrics returns fetchMetrics bound to the current global
+// MeterProvider, rebuilding only when the provider has been replaced since
+// the last call.
+func getFetchMetrics(_ context.Context) (*fetchMetrics, error) {
+	current := otel.GetMeterProvider()
+
+	fetchMetricsCache.mu.RLock()
+	if fetchMetricsCache.provider == current && fetchMetricsCache.m != nil {
+		m := fetchMetricsCache.m
+		fetchMetricsCache.mu.RUnlock()
+		return m, nil
+	}
+	fetchMetricsCache.mu.RUnlock()
+
+	fetchMetricsCache.mu.Lock()
+	defer fetchMetricsCache.mu.Unlock()
+	if fetchMetricsCache.provider == current && fetchMetricsCache.m != nil {
+		return fetchMetricsCache.m, nil
+	}
+	m, err := newFetchMetrics()
+	if err != nil {
+		return nil, err
+	}
+	fetchMetricsCache.provider = current
+	fetchMetricsCache.m = m
+	return m, nil
+}
+
+func newFetchMetrics() (*fetchMetrics, error) {
+	meter := otel.GetMeterProvider().Meter(
+		"github.com/beckn-one/beckn-onix/vcvalidator",
+		metric.WithInstrumentationVersion("1.0.0"),
+	)
+
+	m := &fetchMetrics{}
+	var err error
+
+	if m.cacheHits, err = meter.Int64Counter(
+		"onix_vc_cache_hits_total",
+		metric.WithDescription("DID documents and status lists served from the cache"),
+		metric.WithUnit("{hit}"),
+	); err != nil {
+		return nil, fmt.Errorf("onix_vc_cache_hits_total: %w", err)
+	}
+
+	if m.cacheMisses, err = meter.Int64Counter(
+		"onix_vc_cache_misses_total",
+		metric.WithDescription("DID documents and status lists fetched because they were not cached"),
+		metric.WithUnit("{miss}"),
+	); err != nil {
+		return nil, fmt.Errorf("onix_vc_cache_misses_total: %w", err)
+	}
+
+	if m.cacheRefreshes, err = meter.Int64Counter(
+		"onix_vc_cache_refreshes_total",
+		metric.WithDescription("Background refreshes of cached DID documents and status lists"),
+		metric.WithUnit("{refresh}"),
+	); err != nil {
+		return nil, fmt.Errorf("onix_vc_cache_refreshes_total: %w", err)
+	}
+
+	return m, nil
+}
@@ -0,0 +1,326 @@
+// rdf.go converts expanded JSON-LD to an RDF dataset and serialises quads as
+// canonical N-Quads, the input and output forms of RDF canonicalisation.
+package vcvalidator
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	rdfType    = "http://www.w3.org/1999/02/22-rdf-syntax-ns#type"
+	rdfFirst   = "http://www.w3.org/1999/02/22-rdf-syntax-ns#first"
+	rdfRest    = "http://www.w3.org/1999/02/22-rdf-syntax-ns#rest"
+	rdfNil     = "http://www.w3.org/1999/02/22-rdf-syntax-ns#nil"
+	rdfJSON    = "http://www.w3.org/1999/02/22-rdf-syntax-ns#JSON"
+	rdfLangStr = "http://www.w3.org/1999/02/22-rdf-syntax-ns#langString"
+	xsdString  = "http://www.w3.org/2001/XMLSchema#string"
+	xsdBoolean = "http://www.w3.org/2001/XMLSchema#boolean"
+	xsdInteger = "http://www.w3.org/2001/XMLSchema#integer"
+	xsdDouble  = "http://www.w3.org/2001/XMLSchema#double"
+)
+
+type rdfTermKind uint8
+
+const (
+	rdfNone rdfTermKind = iota
+	rdfIRI
+	rdfBlank
+	rdfLiteral
+)
+
+// rdfTerm is an IRI, blank node or literal. The zero value is "no term",
+// which stands for the default graph in a quad's graph position.
+type rdfTerm struct {
+	kind     rdfTermKind
+	value    string // IRI, blank node label without "_:", or lexical form
+	datatype string
+	language string
+}
+
+type rdfQuad struct {
+	subject, predicate, object, graph rdfTerm
+}
+
+// nquad serialises q as one canonical N-Quads line, including the newline.
+func (q rdfQuad) nquad() string {
+	var b strings.Builder
+	b.WriteString(q.subject.nquad())
+	b.WriteByte(' ')
+	b.WriteString(q.predicate.nquad())
+	b.WriteByte(' ')
+	b.WriteString(q.object.nquad())
+	if q.graph.kind != rdfNone {
+		b.WriteByte(' ')
+		b.WriteString(q.graph.nquad())
+	}
+	b.WriteString(" .\n")
+	return b.String()
+}
+
+func (t rdfTerm) nquad() string {
+	switch t.kind {
+	case rdfIRI:
+		return "<" + t.value + ">"
+	case rdfBlank:
+		return "_:" + t.value
+	case rdfLiteral:
+		s := `"` + escapeNQuadLiteral(t.value) + `"`
+		if t.language != "" {
+			return s + "@" + t.language
+		}
+		if t.datatype != "" && t.datatype != xsdString {
+			return s + "^^<" + t.datatype + ">"
+		}
+		return s
+	}
+	return ""
+}
+
+// escapeNQuadLiteral applies the canonical N-Quads string escapes.
+func escapeNQuadLiteral(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	return b.String()
+}
+
+// rdfBuilder turns expanded JSON-LD node objects into quads.
+type rdfBuilder struct {
+	quads  []rdfQuad
+	seen   map[string]bool
+	labels map[string]string
+	next   int
+}
+
+// toRDF converts an expanded JSON-LD document to a deduplicated list of
+// quads. Blank node labels in the document are replaced by fresh ones, which
+// canonicalisation relabels again anyway.
+func toRDF(expanded []any) ([]rdfQuad, error) {
+	b := &rdfBuilder{seen: map[string]bool{}, labels: map[string]string{}}
+	for _, item := range expanded {
+		node, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		if _, err := b.node(node, rdfTerm{}); err != nil {
+			return nil, err
+		}
+	}
+	return b.quads, nil
+}
+
+func (b *rdfBuilder) blank(label string) rdfTerm {
+	if label != "" {
+		if l, ok := b.labels[label]; ok {
+			return rdfTerm{kind: rdfBlank, value: l}
+		}
+	}
+	l := "b" + strconv.Itoa(b.next)
+	b.next++
+	if label != "" {
+		b.labels[label] = l
+	}
+	return rdfTerm{kind: rdfBlank, value: l}
+}
+
+// resource maps an expanded @id or @type value to a term. Relative IRIs,
+// which have no RDF representation, yield the zero term.
+func (b *rdfBuilder) resource(id string) rdfTerm {
+	if isBlankNode(id) {
+		return b.blank(id)
+	}
+	if isAbsoluteIRI(id) {
+		return rdfTerm{kind: rdfIRI, value: id}
+	}
+	return rdfTerm{}
+}
+
+func (b *rdfBuilder) emit(s, p, o, g rdfTerm) {
+	if s.kind == rdfNone || p.kind == rdfNone || o.kind == rdfNone {
+		return
+	}
+	q := rdfQuad{subject: s, predicate: p, object: o, graph: g}
+	key := q.nquad()
+	if b.seen[key] {
+		return
+	}
+	b.seen[key] = true
+	b.quads = append(b.quads, q)
+}
+
+// node emits the quads for a node object in graph and returns its subject.
+func (b *rdfBuilder) node(n map[string]any, graph rdfTerm) (rdfTerm, error) {
+	var subject rdfTerm
+	if id, ok := n["@id"].(string); ok {
+		subject = b.resource(id)
+	} else {
+		subject = b.blank("")
+	}
+	if g, ok := n["@graph"]; ok && subject.kind != rdfNone {
+		for _, item := range asArray(g) {
+			if m, ok := item.(map[string]any); ok && !isValueObject(m) {
+				if _, err := b.node(m, subject); err != nil {
+					return rdfTerm{}, err
+				}
+			}
+		}
+	}
+	for _, t := range asArray(n["@type"]) {
+		if s, ok := t.(string); ok {
+			b.emit(subject, rdfTerm{kind: rdfIRI, value: rdfType}, b.resource(s), graph)
+		}
+	}
+	for _, prop := range sortedKeys(n) {
+		if isKeyword(prop) || !isAbsoluteIRI(prop) {
+			continue
+		}
+		predicate := rdfTerm{kind: rdfIRI, value: prop}
+		for _, v := range asArray(n[prop]) {
+			object, err := b.object(v, graph)
+			if err != nil {
+				return rdfTerm{}, err
+			}
+			b.emit(subject, predicate, object, graph)
+		}
+	}
+	if rev, ok := n["@reverse"].(map[string]any); ok {
+		for _, prop := range sortedKeys(rev) {
+			if !isAbsoluteIRI(prop) {
+				continue
+			}
+			for _, v := range asArray(rev[prop]) {
+				m, ok := v.(map[string]any)
+				if !ok {
+					continue
+				}
+				s, err := b.node(m, graph)
+				if err != nil {
+					return rdfTerm{}, err
+				}
+				b.emit(s, rdfTerm{kind: rdfIRI, value: prop}, subject, graph)
+			}
+		}
+	}
+	for _, v := range asArray(n["@included"]) {
+		if m, ok := v.(map[string]any); ok {
+			if _, err := b.node(m, graph); err != nil {
+				return rdfTerm{}, err
+			}
+		}
+	}
+	return subject, nil
+}
+
+func (b *rdfBuilder) object(v any, graph rdfTerm) (rdfTerm, error) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return rdfTerm{}, nil
+	}
+	if _, ok := m["@value"]; ok {
+		return literal(m)
+	}
+	if l, ok := m["@list"]; ok {
+		return b.list(asArray(l), graph)
+	}
+	return b.node(m, graph)
+}
+
+func (b *rdfBuilder) list(items []any, graph rdfTerm) (rdfTerm, error) {
+	if len(items) == 0 {
+		return rdfTerm{kind: rdfIRI, value: rdfNil}, nil
+	}
+	nodes := make([]rdfTerm, len(items))
+	for i := range items {
+		nodes[i] = b.blank("")
+	}
+	for i, item := range items {
+		object, err := b.object(item, graph)
+		if err != nil {
+			return rdfTerm{}, err
+		}
+		b.emit(nodes[i], rdfTerm{kind: rdfIRI, value: rdfFirst}, object, graph)
+		rest := rdfTerm{kind: rdfIRI, value: rdfNil}
+		if i+1 < len(nodes) {
+			rest = nodes[i+1]
+		}
+		b.emit(nodes[i], rdfTerm{kind: rdfIRI, value: rdfRest}, rest, graph)
+	}
+	return nodes[0], nil
+}
+
+// literal converts an expanded value object to an RDF literal.
+func literal(m map[string]any) (rdfTerm, error) {
+	value := m["@value"]
+	datatype, _ := m["@type"].(string)
+	language, _ := m["@language"].(string)
+	if datatype == "@json" {
+		s, err := canonicalJSON(value)
+		if err != nil {
+			return rdfTerm{}, err
+		}
+		return rdfTerm{kind: rdfLiteral, value: string(s), datatype: rdfJSON}, nil
+	}
+	if datatype != "" && !isAbsoluteIRI(datatype) {
+		return rdfTerm{}, nil
+	}
+	var lexical, implied string
+	switch x := value.(type) {
+	case bool:
+		lexical, implied = strconv.FormatBool(x), xsdBoolean
+	case float64:
+		if datatype == xsdDouble || x != math.Trunc(x) || math.Abs(x) >= 1e21 {
+			lexical, implied = canonicalDouble(x), xsdDouble
+		} else {
+			lexical, implied = strconv.FormatFloat(x, 'f', 0, 64), xsdInteger
+		}
+	case string:
+		lexical, implied = x, xsdString
+		if language != "" && datatype == "" {
+			return rdfTerm{kind: rdfLiteral, value: x, datatype: rdfLangStr, language: language}, nil
+		}
+	default:
+		return rdfTerm{}, nil
+	}
+	if datatype == "" {
+		datatype = implied
+	}
+	return rdfTerm{kind: rdfLiteral, value: lexical, datatype: datatype}, nil
+}
+
+// canonicalDouble formats f in the canonical xsd:double form JSON-LD uses,
+// e.g. 5.3 -> "5.3E0".
+func canonicalDouble(f float64) string {
+	s := strconv.FormatFloat(f, 'E', 15, 64)
+	mantissa, exp, _ := strings.Cut(s, "E")
+	mantissa = strings.TrimRight(mantissa, "0")
+	if strings.HasSuffix(mantissa, ".") {
+		mantissa += "0"
+	}
+	e, _ := strconv.Atoi(exp)
+	return mantissa + "E" + strconv.Itoa(e)
+}
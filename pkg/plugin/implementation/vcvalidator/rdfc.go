@@ -0,0 +1,302 @@
+// rdfc.go implements RDF Dataset Canonicalization (RDFC-1.0, formerly
+// URDNA2015), which the eddsa-rdfc-2022 and Ed25519Signature2020 suites hash.
+package vcvalidator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxCanonicalizationWork bounds the N-degree hashing work for one dataset.
+// Crafted "poison" graphs make that step factorial in the number of blank
+// nodes; a credential never needs anywhere near this much.
+const maxCanonicalizationWork = 100000
+
+var errCanonicalizationTooComplex = errors.New("dataset too complex to canonicalize")
+
+// idIssuer issues sequential blank node identifiers with a fixed prefix.
+type idIssuer struct {
+	prefix string
+	issued map[string]string
+	order  []string
+}
+
+func newIDIssuer(prefix string) *idIssuer {
+	return &idIssuer{prefix: prefix, issued: map[string]string{}}
+}
+
+func (i *idIssuer) issue(id string) string {
+	if v, ok := i.issued[id]; ok {
+		return v
+	}
+	v := i.prefix + strconv.Itoa(len(i.order))
+	i.issued[id] = v
+	i.order = append(i.order, id)
+	return v
+}
+
+func (i *idIssuer) clone() *idIssuer {
+	out := &idIssuer{prefix: i.prefix, issued: make(map[string]string, len(i.issued)), order: append([]string(nil), i.order...)}
+	for k, v := range i.issued {
+		out.issued[k] = v
+	}
+	return out
+}
+
+// canonicalizer holds the state of one RDFC-1.0 run. Blank node identifiers
+// carry their "_:" prefix throughout, as the algorithm's hash inputs do.
+type canonicalizer struct {
+	quads      []rdfQuad
+	bnodeQuads map[string][]int
+	canonical  *idIssuer
+	firstHash  map[string]string
+	work       int
+}
+
+// canonicalize returns the canonical N-Quads serialisation of quads.
+func canonicalize(quads []rdfQuad) (string, error) {
+	c := &canonicalizer{
+		quads:      quads,
+		bnodeQuads: map[string][]int{},
+		canonical:  newIDIssuer("_:c14n"),
+		firstHash:  map[string]string{},
+	}
+	for i, q := range quads {
+		for _, t := range []rdfTerm{q.subject, q.object, q.graph} {
+			if t.kind != rdfBlank {
+				continue
+			}
+			id := "_:" + t.value
+			if list := c.bnodeQuads[id]; len(list) == 0 || list[len(list)-1] != i {
+				c.bnodeQuads[id] = append(list, i)
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(c.bnodeQuads))
+	for id := range c.bnodeQuads {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	byHash := map[string][]string{}
+	for _, id := range ids {
+		h := c.hashFirstDegree(id)
+		byHash[h] = append(byHash[h], id)
+	}
+	hashes := make([]string, 0, len(byHash))
+	for h := range byHash {
+		hashes = append(hashes, h)
+	}
+	sort.Strings(hashes)
+
+	var shared []string
+	for _, h := range hashes {
+		if len(byHash[h]) == 1 {
+			c.canonical.issue(byHash[h][0])
+		} else {
+			shared = append(shared, h)
+		}
+	}
+	for _, h := range shared {
+		type result struct {
+			hash   string
+			issuer *idIssuer
+		}
+		var results []result
+		for _, id := range byHash[h] {
+			if _, done := c.canonical.issued[id]; done {
+				continue
+			}
+			temp := newIDIssuer("_:b")
+			temp.issue(id)
+			hash, issuer, err := c.hashNDegree(id, temp)
+			if err != nil {
+				return "", err
+			}
+			results = append(results, result{hash, issuer})
+		}
+		sort.SliceStable(results, func(i, j int) bool { return results[i].hash < results[j].hash })
+		for _, r := range results {
+			for _, id := range r.issuer.order {
+				c.canonical.issue(id)
+			}
+		}
+	}
+
+	lines := make([]string, len(quads))
+	for i, q := range quads {
+		q.subject = c.relabel(q.subject)
+		q.object = c.relabel(q.object)
+		q.graph = c.relabel(q.graph)
+		lines[i] = q.nquad()
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, ""), nil
+}
+
+func (c *canonicalizer) relabel(t rdfTerm) rdfTerm {
+	if t.kind == rdfBlank {
+		t.value = strings.TrimPrefix(c.canonical.issued["_:"+t.value], "_:")
+	}
+	return t
+}
+
+// hashFirstDegree hashes the quads that mention id, with id written as _:a
+// and every other blank node as _:z.
+func (c *canonicalizer) hashFirstDegree(id string) string {
+	if h, ok := c.firstHash[id]; ok {
+		return h
+	}
+	mask := func(t rdfTerm) rdfTerm {
+		if t.kind == rdfBlank {
+			if "_:"+t.value == id {
+				t.value = "a"
+			} else {
+				t.value = "z"
+			}
+		}
+		return t
+	}
+	var lines []string
+	for _, i := range c.bnodeQuads[id] {
+		q := c.quads[i]
+		q.subject, q.object, q.graph = mask(q.subject), mask(q.object), mask(q.graph)
+		lines = append(lines, q.nquad())
+	}
+	sort.Strings(lines)
+	h := sha256Hex(strings.Join(lines, ""))
+	c.firstHash[id] = h
+	return h
+}
+
+// hashRelated hashes a blank node related to the one being hashed, together
+// with its position and, outside the graph position, the predicate.
+func (c *canonicalizer) hashRelated(related string, q rdfQuad, issuer *idIssuer, position string) string {
+	input := position
+	if position != "g" {
+		input += "<" + q.predicate.value + ">"
+	}
+	if id, ok := c.canonical.issued[related]; ok {
+		input += id
+	} else if id, ok := issuer.issued[related]; ok {
+		input += id
+	} else {
+		input += c.hashFirstDegree(related)
+	}
+	return sha256Hex(input)
+}
+
+// hashNDegree disambiguates blank nodes whose first-degree hashes collide by
+// exploring every labelling of their neighbours.
+func (c *canonicalizer) hashNDegree(id string, issuer *idIssuer) (string, *idIssuer, error) {
+	if c.work++; c.work > maxCanonicalizationWork {
+		return "", nil, errCanonicalizationTooComplex
+	}
+	related := map[string][]string{}
+	for _, i := range c.bnodeQuads[id] {
+		q := c.quads[i]
+		for _, pc := range []struct {
+			term     rdfTerm
+			position string
+		}{{q.subject, "s"}, {q.object, "o"}, {q.graph, "g"}} {
+			if pc.term.kind != rdfBlank || "_:"+pc.term.value == id {
+				continue
+			}
+			r := "_:" + pc.term.value
+			h := c.hashRelated(r, q, issuer, pc.position)
+			related[h] = append(related[h], r)
+		}
+	}
+	hashes := make([]string, 0, len(related))
+	for h := range related {
+		hashes = append(hashes, h)
+	}
+	sort.Strings(hashes)
+
+	var data strings.Builder
+	for _, h := range hashes {
+		data.WriteString(h)
+		chosenPath := ""
+		var chosenIssuer *idIssuer
+		err := permute(related[h], func(perm []string) (bool, error) {
+			if c.work++; c.work > maxCanonicalizationWork {
+				return false, errCanonicalizationTooComplex
+			}
+			issuerCopy := issuer.clone()
+			path := ""
+			var recursion []string
+			for _, r := range perm {
+				if id, ok := c.canonical.issued[r]; ok {
+					path += id
+				} else {
+					if _, ok := issuerCopy.issued[r]; !ok {
+						recursion = append(recursion, r)
+					}
+					path += issuerCopy.issue(r)
+				}
+				if chosenPath != "" && len(path) >= len(chosenPath) && path > chosenPath {
+					return true, nil
+				}
+			}
+			for _, r := range recursion {
+				hash, resultIssuer, err := c.hashNDegree(r, issuerCopy)
+				if err != nil {
+					return false, err
+				}
+				path += issuerCopy.issue(r) + "<" + hash + ">"
+				issuerCopy = resultIssuer
+				if chosenPath != "" && len(path) >= len(chosenPath) && path > chosenPath {
+					return true, nil
+				}
+			}
+			if chosenPath == "" || path < chosenPath {
+				chosenPath, chosenIssuer = path, issuerCopy
+			}
+			return true, nil
+		})
+		if err != nil {
+			return "", nil, err
+		}
+		data.WriteString(chosenPath)
+		issuer = chosenIssuer
+	}
+	return sha256Hex(data.String()), issuer, nil
+}
+
+// permute calls fn with every permutation of items (Heap's algorithm) until
+// fn returns false or an error.
+func permute(items []string, fn func([]string) (bool, error)) error {
+	a := append([]string(nil), items...)
+	n := len(a)
+	idx := make([]int, n)
+	if cont, err := fn(a); err != nil || !cont {
+		return err
+	}
+	for i := 0; i < n; {
+		if idx[i] < i {
+			if i%2 == 0 {
+				a[0], a[i] = a[i], a[0]
+			} else {
+				a[idx[i]], a[i] = a[i], a[idx[i]]
+			}
+			if cont, err := fn(a); err != nil || !cont {
+				return err
+			}
+			idx[i]++
+			i = 0
+		} else {
+			idx[i] = 0
+			i++
+		}
+	}
+	return nil
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
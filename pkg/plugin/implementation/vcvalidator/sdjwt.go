@@ -0,0 +1,479 @@
+// sdjwt.go verifies SD-JWT VCs (media type vc+sd-jwt / dc+sd-jwt): the
+// issuer-signed JWT, its selective disclosures, the optional key-binding JWT
+// and Token Status List revocation. Only the disclosed claims reach the
+// expiry and trust checks.
+package vcvalidator
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v3/jws"
+)
+
+// SD-JWT header typ values.
+const (
+	typVCSDJWT = "vc+sd-jwt"
+	typDCSDJWT = "dc+sd-jwt"
+	typKBJWT   = "kb+jwt"
+)
+
+// sdJWTDataURLPrefixes are the data: URL forms a VCDM 2.0
+// EnvelopedVerifiableCredential wraps an SD-JWT VC in.
+var sdJWTDataURLPrefixes = []string{"data:application/vc+sd-jwt,", "data:application/dc+sd-jwt,"}
+
+// maxStatusListSize bounds the inflated Token Status List.
+const maxStatusListSize = 16 << 20
+
+// asSDJWT reports whether s is an SD-JWT VC — a compact JWS typed vc+sd-jwt
+// or dc+sd-jwt followed by "~"-separated disclosures — and returns its bare
+// serialisation.
+func asSDJWT(s string) (string, bool) {
+	for _, p := range sdJWTDataURLPrefixes {
+		if strings.HasPrefix(s, p) {
+			s = s[len(p):]
+			break
+		}
+	}
+	issuerJWT, _, ok := strings.Cut(s, "~")
+	if !ok {
+		return "", false
+	}
+	h, err := decodeJWTHeader(issuerJWT)
+	if err != nil || (h.Typ != typVCSDJWT && h.Typ != typDCSDJWT) {
+		return "", false
+	}
+	return s, true
+}
+
+// sdJWTOf returns the SD-JWT VC an extracted credential holds, if it is one.
+func sdJWTOf(raw json.RawMessage) (string, bool) {
+	var s string
+	if json.Unmarshal(raw, &s) != nil {
+		return "", false
+	}
+	return asSDJWT(s)
+}
+
+// keyBinding is what a key-binding JWT must carry for the current request.
+type keyBinding struct {
+	audience string
+	nonce    string
+}
+
+// verifySDJWT verifies an SD-JWT VC and returns the claims its disclosures
+// reveal.
+func (v *verifier) verifySDJWT(ctx context.Context, token string, kb keyBinding) (*credentialClaims, error) {
+	parts := strings.Split(token, "~")
+	issuerJWT, disclosures, kbJWT := parts[0], parts[1:len(parts)-1], parts[len(parts)-1]
+
+	unverified, err := jwtPayload(issuerJWT)
+	if err != nil {
+		return nil, failf(failStructure, codeSchInvalidJSON, "SD-JWT: %v", err)
+	}
+	var head struct {
+		Iss   string `json:"iss"`
+		SDAlg string `json:"_sd_alg"`
+	}
+	if err := json.Unmarshal(unverified, &head); err != nil {
+		return nil, failf(failStructure, codeSchInvalidJSON, "SD-JWT payload: %v", err)
+	}
+	if head.Iss == "" {
+		return nil, failf(failStructure, codeSchRequiredFieldMissing, "SD-JWT VC has no iss")
+	}
+	if !strings.HasPrefix(head.Iss, "did:") {
+		return nil, failf(failResolution, codeAutKeyNotFound,
+			"SD-JWT VC issuer %q is not a DID; JWT VC issuer metadata is not supported", head.Iss)
+	}
+	hash, err := sdHash(head.SDAlg)
+	if err != nil {
+		return nil, failf(failProof, codeAutSignatureInvalid, "SD-JWT: %v", err)
+	}
+
+	payload, err := v.verifyIssuerJWS(ctx, issuerJWT, head.Iss)
+	if err != nil {
+		return nil, err
+	}
+	if payload == nil {
+		payload = unverified // issuer key unreachable and FailOpen is set
+	}
+	var signed map[string]any
+	if err := json.Unmarshal(payload, &signed); err != nil {
+		return nil, failf(failStructure, codeSchInvalidJSON, "SD-JWT payload: %v", err)
+	}
+	claims, err := disclose(signed, disclosures, hash)
+	if err != nil {
+		return nil, failf(failProof, codeAutSignatureInvalid, "SD-JWT: %v", err)
+	}
+
+	if v.cfg.CheckExpiry {
+		disclosed, _ := json.Marshal(claims)
+		if err := v.checkJWTClaims(disclosed); err != nil {
+			return nil, err
+		}
+	}
+
+	if kbJWT != "" {
+		presented := token[:len(token)-len(kbJWT)]
+		if err := v.verifyKeyBinding(claims, presented, kbJWT, hash, kb); err != nil {
+			return nil, err
+		}
+	} else if v.cfg.RequireKeyBinding {
+		return nil, failf(failKeyBinding, codeAutSignatureMissing, "SD-JWT VC has no key-binding JWT")
+	}
+
+	if v.cfg.CheckRevocation {
+		if err := v.checkTokenStatus(ctx, claims["status"]); err != nil {
+			return nil, err
+		}
+	}
+
+	c := &credentialClaims{issuer: base(head.Iss)}
+	if vct, ok := claims["vct"].(string); ok && vct != "" {
+		c.types = []string{vct}
+	}
+	if sub, ok := claims["sub"].(string); ok && sub != "" {
+		c.subjects = []string{sub}
+	}
+	return c, nil
+}
+
+// jwtPayload decodes the payload of a compact JWS without verifying it.
+func jwtPayload(token string) ([]byte, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed JWT: expected 3 segments, got %d", len(parts))
+	}
+	b, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("decode JWT payload: %w", err)
+	}
+	return b, nil
+}
+
+type hashFunc func([]byte) []byte
+
+// sdHash returns the digest function named by _sd_alg (sha-256 by default).
+func sdHash(alg string) (hashFunc, error) {
+	switch alg {
+	case "", "sha-256":
+		return func(b []byte) []byte { h := sha256.Sum256(b); return h[:] }, nil
+	case "sha-384":
+		return func(b []byte) []byte { h := sha512.Sum384(b); return h[:] }, nil
+	case "sha-512":
+		return func(b []byte) []byte { h := sha512.Sum512(b); return h[:] }, nil
+	}
+	return nil, fmt.Errorf("unsupported _sd_alg %q", alg)
+}
+
+// disclosure is one decoded "salt, [name,] value" disclosure.
+type disclosure struct {
+	name  string
+	value any
+	array bool // an array element disclosure, which has no name
+	used  bool
+}
+
+// discloser rebuilds the claim set from the signed payload and the
+// disclosures presented with it.
+type discloser struct {
+	byDigest map[string]*disclosure
+	seen     map[string]bool // digests met in the payload
+}
+
+// disclose replaces every _sd digest and {"...": digest} array element that
+// a presented disclosure matches with the disclosed claim, and drops the
+// rest. Every disclosure must be referenced exactly once.
+func disclose(payload map[string]any, disclosures []string, hash hashFunc) (map[string]any, error) {
+	d := &discloser{byDigest: map[string]*disclosure{}, seen: map[string]bool{}}
+	for _, enc := range disclosures {
+		if enc == "" {
+			return nil, errors.New("empty disclosure")
+		}
+		digest := base64.RawURLEncoding.EncodeToString(hash([]byte(enc)))
+		if _, dup := d.byDigest[digest]; dup {
+			return nil, errors.New("disclosure presented twice")
+		}
+		raw, err := base64.RawURLEncoding.DecodeString(enc)
+		if err != nil {
+			return nil, fmt.Errorf("decode disclosure: %w", err)
+		}
+		var arr []any
+		if err := json.Unmarshal(raw, &arr); err != nil {
+			return nil, fmt.Errorf("parse disclosure: %w", err)
+		}
+		switch len(arr) {
+		case 2:
+			d.byDigest[digest] = &disclosure{value: arr[1], array: true}
+		case 3:
+			name, ok := arr[1].(string)
+			if !ok || name == "_sd" || name == "..." {
+				return nil, fmt.Errorf("disclosure has invalid claim name %v", arr[1])
+			}
+			d.byDigest[digest] = &disclosure{name: name, value: arr[2]}
+		default:
+			return nil, fmt.Errorf("disclosure has %d elements, want 2 or 3", len(arr))
+		}
+	}
+
+	out, err := d.object(payload)
+	if err != nil {
+		return nil, err
+	}
+	delete(out, "_sd_alg")
+	for _, dis := range d.byDigest {
+		if !dis.used {
+			return nil, errors.New("disclosure is not referenced by the issuer-signed JWT")
+		}
+	}
+	return out, nil
+}
+
+func (d *discloser) value(v any) (any, error) {
+	switch x := v.(type) {
+	case map[string]any:
+		return d.object(x)
+	case []any:
+		return d.array(x)
+	}
+	return v, nil
+}
+
+func (d *discloser) object(m map[string]any) (map[string]any, error) {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		if k == "_sd" {
+			continue
+		}
+		val, err := d.value(v)
+		if err != nil {
+			return nil, err
+		}
+		out[k] = val
+	}
+	sd, ok := m["_sd"]
+	if !ok {
+		return out, nil
+	}
+	digests, ok := sd.([]any)
+	if !ok {
+		return nil, errors.New("_sd is not an array")
+	}
+	for _, item := range digests {
+		digest, ok := item.(string)
+		if !ok {
+			return nil, errors.New("_sd holds a non-string digest")
+		}
+		dis, err := d.lookup(digest)
+		if err != nil {
+			return nil, err
+		}
+		if dis == nil {
+			continue // not disclosed, or a decoy
+		}
+		if dis.array {
+			return nil, errors.New("array element disclosure referenced from _sd")
+		}
+		if _, exists := out[dis.name]; exists {
+			return nil, fmt.Errorf("disclosed claim %q is already present", dis.name)
+		}
+		if out[dis.name], err = d.value(dis.value); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func (d *discloser) array(a []any) ([]any, error) {
+	out := make([]any, 0, len(a))
+	for _, item := range a {
+		if m, ok := item.(map[string]any); ok && len(m) == 1 {
+			if ref, ok := m["..."]; ok {
+				digest, ok := ref.(string)
+				if !ok {
+					return nil, errors.New(`array element "..." is not a digest`)
+				}
+				dis, err := d.lookup(digest)
+				if err != nil {
+					return nil, err
+				}
+				if dis == nil {
+					continue // not disclosed
+				}
+				if !dis.array {
+					return nil, errors.New("object property disclosure referenced from an array")
+				}
+				val, err := d.value(dis.value)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, val)
+				continue
+			}
+		}
+		val, err := d.value(item)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, val)
+	}
+	return out, nil
+}
+
+// lookup returns the disclosure for digest, or nil when it was not
+// disclosed (or is a decoy). A digest may appear only once in the payload.
+func (d *discloser) lookup(digest string) (*disclosure, error) {
+	if d.seen[digest] {
+		return nil, errors.New("digest appears more than once in the issuer-signed JWT")
+	}
+	d.seen[digest] = true
+	dis := d.byDigest[digest]
+	if dis != nil {
+		dis.used = true
+	}
+	return dis, nil
+}
+
+// verifyKeyBinding checks the key-binding JWT: signed by the holder key in
+// cnf.jwk, over exactly the presented SD-JWT (sd_hash), addressed to this
+// node (aud), bound to this message (nonce) and recently issued (iat).
+func (v *verifier) verifyKeyBinding(claims map[string]any, presented, kbJWT string, hash hashFunc, kb keyBinding) error {
+	header, err := decodeJWTHeader(kbJWT)
+	if err != nil {
+		return failf(failKeyBinding, codeAutSignatureInvalid, "key-binding JWT: %v", err)
+	}
+	if header.Typ != typKBJWT {
+		return failf(failKeyBinding, codeAutSignatureInvalid, "key-binding JWT typ %q is not %s", header.Typ, typKBJWT)
+	}
+	cnf, _ := claims["cnf"].(map[string]any)
+	if cnf["jwk"] == nil {
+		return failf(failKeyBinding, codeAutSignatureMissing, "credential has no cnf.jwk to verify the key-binding JWT with")
+	}
+	jwkBytes, _ := json.Marshal(cnf["jwk"])
+	key, err := keyFromJWKBytes(jwkBytes)
+	if err != nil {
+		return failf(failKeyBinding, codeAutSignatureInvalid, "cnf.jwk: %v", err)
+	}
+	if header.Alg != key.alg.String() {
+		return failf(failKeyBinding, codeAutSignatureInvalid,
+			"key-binding JWT alg %q does not match holder key algorithm %q", header.Alg, key.alg.String())
+	}
+	payload, err := jws.Verify([]byte(kbJWT), jws.WithKey(key.alg, key.pub))
+	if err != nil {
+		return failf(failKeyBinding, codeAutSignatureInvalid, "key-binding JWT signature verification failed: %v", err)
+	}
+
+	var c struct {
+		Iat    int64           `json:"iat"`
+		Aud    json.RawMessage `json:"aud"`
+		Nonce  string          `json:"nonce"`
+		SDHash string          `json:"sd_hash"`
+	}
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return failf(failKeyBinding, codeAutSignatureInvalid, "key-binding JWT payload: %v", err)
+	}
+	want := base64.RawURLEncoding.EncodeToString(hash([]byte(presented)))
+	if subtle.ConstantTimeCompare([]byte(c.SDHash), []byte(want)) != 1 {
+		return failf(failKeyBinding, codeAutSignatureInvalid, "key-binding JWT sd_hash does not match the presented SD-JWT")
+	}
+	if kb.audience == "" || !slices.Contains(stringOrList(c.Aud), kb.audience) {
+		return failf(failKeyBinding, codeAutUnauthorizedAction,
+			"key-binding JWT aud %s is not this node (%q)", string(c.Aud), kb.audience)
+	}
+	if kb.nonce == "" || c.Nonce != kb.nonce {
+		return failf(failKeyBinding, codeAutUnauthorizedAction,
+			"key-binding JWT nonce %q does not match the message id %q", c.Nonce, kb.nonce)
+	}
+	age := v.now().Sub(time.Unix(c.Iat, 0))
+	if c.Iat == 0 || age > v.cfg.KeyBindingMaxAge || age < -v.cfg.KeyBindingMaxAge {
+		return failf(failKeyBinding, codeAutKeyExpiredOrRevoked,
+			"key-binding JWT iat=%d is not within %s of now", c.Iat, v.cfg.KeyBindingMaxAge)
+	}
+	return nil
+}
+
+// checkTokenStatus applies a Token Status List reference
+// (status.status_list.{idx,uri}) the way checkRevocation applies a
+// credentialStatus entry. Like the StatusList2021 path, the list token's own
+// signature is not verified: its URI comes from the issuer-signed payload.
+func (v *verifier) checkTokenStatus(ctx context.Context, status any) error {
+	st, _ := status.(map[string]any)
+	ref, ok := st["status_list"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	uri, _ := ref["uri"].(string)
+	idx, err := toInt(ref["idx"])
+	if err != nil || uri == "" || idx < 0 {
+		return failf(failStructure, codeSchInvalidJSON, "status.status_list needs idx and uri")
+	}
+	revoked, err := v.tokenStatusRevoked(ctx, uri, idx)
+	if err != nil {
+		if v.cfg.FailOpen {
+			return nil
+		}
+		return failf(failResolution, resolutionCode(err), "revocation check: %v", err)
+	}
+	if revoked {
+		return failf(failRevoked, codeAutKeyExpiredOrRevoked, "credential revoked via %s", uri)
+	}
+	return nil
+}
+
+// tokenStatusRevoked fetches a Token Status List JWT and reports whether the
+// status at idx is anything other than VALID (0).
+func (v *verifier) tokenStatusRevoked(ctx context.Context, uri string, idx int) (bool, error) {
+	body, err := v.fetch(ctx, uri)
+	if err != nil {
+		return false, err
+	}
+	payload, err := jwtPayload(string(bytes.TrimSpace(body)))
+	if err != nil {
+		return false, fmt.Errorf("status list token: %w", err)
+	}
+	var tok struct {
+		StatusList struct {
+			Bits int    `json:"bits"`
+			Lst  string `json:"lst"`
+		} `json:"status_list"`
+	}
+	if err := json.Unmarshal(payload, &tok); err != nil {
+		return false, fmt.Errorf("parse status list token: %w", err)
+	}
+	bits := tok.StatusList.Bits
+	if bits != 1 && bits != 2 && bits != 4 && bits != 8 {
+		return false, fmt.Errorf("status list has invalid bits %d", bits)
+	}
+	compressed, err := base64.RawURLEncoding.DecodeString(tok.StatusList.Lst)
+	if err != nil {
+		return false, fmt.Errorf("decode status list: %w", err)
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return false, fmt.Errorf("inflate status list: %w", err)
+	}
+	defer zr.Close()
+	list, err := io.ReadAll(io.LimitReader(zr, maxStatusListSize))
+	if err != nil {
+		return false, fmt.Errorf("inflate status list: %w", err)
+	}
+	pos := idx * bits
+	if pos/8 >= len(list) {
+		return false, fmt.Errorf("status list index %d out of range", idx)
+	}
+	// Statuses are packed from the least significant bit of each byte.
+	status := (list[pos/8] >> (pos % 8)) & (1<<bits - 1)
+	return status != 0, nil
+}
@@ -0,0 +1,356 @@
+package vcvalidator
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v3/jwa"
+	"github.com/lestrrat-go/jwx/v3/jwk"
+	"github.com/lestrrat-go/jwx/v3/jws"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+)
+
+// holderPub/holderPriv is the key the test SD-JWTs bind to via cnf.jwk.
+var holderPub, holderPriv, _ = ed25519.GenerateKey(nil)
+
+// signJWS signs claims as a compact JWS with the given typ and kid.
+func signJWS(t *testing.T, priv ed25519.PrivateKey, typ, kid string, claims map[string]any) string {
+	t.Helper()
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("marshal claims: %v", err)
+	}
+	hdr := jws.NewHeaders()
+	_ = hdr.Set(jws.TypeKey, typ)
+	if kid != "" {
+		_ = hdr.Set(jws.KeyIDKey, kid)
+	}
+	signed, err := jws.Sign(payload, jws.WithKey(jwa.EdDSA(), priv, jws.WithProtectedHeaders(hdr)))
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return string(signed)
+}
+
+// makeDisclosure encodes a disclosure and returns it with its digest.
+func makeDisclosure(t *testing.T, parts ...any) (string, string) {
+	t.Helper()
+	b, err := json.Marshal(parts)
+	if err != nil {
+		t.Fatalf("marshal disclosure: %v", err)
+	}
+	enc := base64.RawURLEncoding.EncodeToString(b)
+	sum := sha256.Sum256([]byte(enc))
+	return enc, base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// sdJWTFixture is an SD-JWT VC issued by the spec key about
+// did:web:bap.example.com, with sub, a name and one of two nationalities
+// selectively disclosable.
+type sdJWTFixture struct {
+	claims      map[string]any // issuer-signed payload
+	disclosures []string       // sub, given_name, nationality "IN"
+}
+
+func newSDJWTFixture(t *testing.T) *sdJWTFixture {
+	t.Helper()
+	sub, subDigest := makeDisclosure(t, "salt-1", "sub", "did:web:bap.example.com")
+	name, nameDigest := makeDisclosure(t, "salt-2", "given_name", "Asha")
+	nat, natDigest := makeDisclosure(t, "salt-3", "IN")
+	_, hiddenDigest := makeDisclosure(t, "salt-4", "US")
+	holder, err := jwk.Import(holderPub)
+	if err != nil {
+		t.Fatalf("import holder key: %v", err)
+	}
+	now := fixedNow()
+	return &sdJWTFixture{
+		claims: map[string]any{
+			"iss":           specKeyDID,
+			"vct":           "GSTCredential",
+			"iat":           now.Add(-time.Hour).Unix(),
+			"nbf":           now.Add(-time.Hour).Unix(),
+			"exp":           now.Add(24 * time.Hour).Unix(),
+			"_sd_alg":       "sha-256",
+			"_sd":           []any{subDigest, nameDigest, "decoy-digest-that-matches-nothing"},
+			"nationalities": []any{map[string]any{"...": natDigest}, map[string]any{"...": hiddenDigest}},
+			"cnf":           map[string]any{"jwk": holder},
+		},
+		disclosures: []string{sub, name, nat},
+	}
+}
+
+// issue returns the SD-JWT serialisation presenting the given disclosures,
+// ending in "~" (no key-binding JWT yet).
+func (f *sdJWTFixture) issue(t *testing.T, disclosures ...string) string {
+	t.Helper()
+	token := signJWS(t, specKey(t), typDCSDJWT, specKeyVM, f.claims)
+	return token + "~" + strings.Join(append(disclosures, ""), "~")
+}
+
+// bind appends a key-binding JWT over presented.
+func bind(t *testing.T, presented string, overrides map[string]any) string {
+	t.Helper()
+	sum := sha256.Sum256([]byte(presented))
+	claims := map[string]any{
+		"iat":     fixedNow().Unix(),
+		"aud":     "bpp.example.com",
+		"nonce":   "m-1",
+		"sd_hash": base64.RawURLEncoding.EncodeToString(sum[:]),
+	}
+	for k, v := range overrides {
+		claims[k] = v
+	}
+	return presented + signJWS(t, holderPriv, typKBJWT, "", claims)
+}
+
+var testKeyBinding = keyBinding{audience: "bpp.example.com", nonce: "m-1"}
+
+func TestDiscloseSpecExample(t *testing.T) {
+	// Disclosure and digest from the SD-JWT specification.
+	const disc = "WyJfMjZiYzRMVC1hYzZxMktJNmNCVzVlcyIsICJmYW1pbHlfbmFtZSIsICJNw7ZiaXVzIl0"
+	hash, _ := sdHash("sha-256")
+	payload := map[string]any{"_sd": []any{"X9yH0Ajrdm1Oij4tWso9UzzKJvPoDxwmuEcO3XAdRC0"}, "_sd_alg": "sha-256"}
+	claims, err := disclose(payload, []string{disc}, hash)
+	if err != nil {
+		t.Fatalf("disclose: %v", err)
+	}
+	if claims["family_name"] != "Möbius" || len(claims) != 1 {
+		t.Fatalf("claims = %v", claims)
+	}
+}
+
+func TestSDJWTVerify(t *testing.T) {
+	f := newSDJWTFixture(t)
+	v := testVerifier(nil)
+
+	t.Run("disclosed claims only", func(t *testing.T) {
+		token := f.issue(t, f.disclosures[0], f.disclosures[2])
+		c, err := v.verifySDJWT(context.Background(), token, testKeyBinding)
+		if err != nil {
+			t.Fatalf("verifySDJWT: %v", err)
+		}
+		if c.issuer != specKeyDID || len(c.types) != 1 || c.types[0] != "GSTCredential" {
+			t.Fatalf("claims = %+v", c)
+		}
+		if len(c.subjects) != 1 || c.subjects[0] != "did:web:bap.example.com" {
+			t.Fatalf("subjects = %v", c.subjects)
+		}
+
+		hash, _ := sdHash("sha-256")
+		claims, err := disclose(f.claims, []string{f.disclosures[2]}, hash)
+		if err != nil {
+			t.Fatalf("disclose: %v", err)
+		}
+		if _, ok := claims["given_name"]; ok {
+			t.Fatalf("undisclosed claim leaked: %v", claims)
+		}
+		if nat := claims["nationalities"].([]any); len(nat) != 1 || nat[0] != "IN" {
+			t.Fatalf("nationalities = %v", claims["nationalities"])
+		}
+	})
+	t.Run("subject withheld", func(t *testing.T) {
+		c, err := v.verifySDJWT(context.Background(), f.issue(t, f.disclosures[1]), testKeyBinding)
+		if err != nil {
+			t.Fatalf("verifySDJWT: %v", err)
+		}
+		if len(c.subjects) != 0 {
+			t.Fatalf("undisclosed sub must not be a subject, got %v", c.subjects)
+		}
+	})
+
+	cases := []struct {
+		name  string
+		token func() string
+		class failClass
+		code  string
+	}{
+		{"forged disclosure", func() string {
+			forged, _ := makeDisclosure(t, "salt-1", "sub", "did:web:evil.example.com")
+			return f.issue(t, forged)
+		}, failProof, codeAutSignatureInvalid},
+		{"disclosure presented twice", func() string {
+			return f.issue(t, f.disclosures[0], f.disclosures[0])
+		}, failProof, codeAutSignatureInvalid},
+		{"tampered issuer JWT", func() string {
+			token := f.issue(t, f.disclosures[0])
+			parts := strings.SplitN(token, ".", 3)
+			tampered, _ := json.Marshal(map[string]any{"iss": specKeyDID, "vct": "GSTCredential", "_sd": f.claims["_sd"]})
+			return parts[0] + "." + base64.RawURLEncoding.EncodeToString(tampered) + "." + parts[2]
+		}, failProof, codeAutSignatureInvalid},
+		{"issuer is not the signer", func() string {
+			f := newSDJWTFixture(t)
+			f.claims["iss"] = "did:key:z6MkpTHR8VNsBxYAAWHut2Geadd9jSwuBV8xRoAnwWsdvktH"
+			return f.issue(t)
+		}, failIssuer, codeAutUnauthorizedAction},
+		{"https issuer", func() string {
+			f := newSDJWTFixture(t)
+			f.claims["iss"] = "https://issuer.example.com"
+			return f.issue(t)
+		}, failResolution, codeAutKeyNotFound},
+		{"expired", func() string {
+			f := newSDJWTFixture(t)
+			f.claims["exp"] = fixedNow().Add(-time.Minute).Unix()
+			return f.issue(t)
+		}, failExpired, codeAutKeyExpiredOrRevoked},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := v.verifySDJWT(context.Background(), tc.token(), testKeyBinding)
+			assertClassCode(t, err, tc.class, tc.code)
+		})
+	}
+}
+
+func TestSDJWTKeyBinding(t *testing.T) {
+	f := newSDJWTFixture(t)
+	presented := f.issue(t, f.disclosures[0])
+
+	// A key-binding JWT made for a presentation of different disclosures.
+	other := f.issue(t, f.disclosures[1])
+	foreignKB := bind(t, other, nil)[len(other):]
+
+	v := testVerifier(nil)
+	if _, err := v.verifySDJWT(context.Background(), bind(t, presented, nil), testKeyBinding); err != nil {
+		t.Fatalf("valid key binding: %v", err)
+	}
+
+	cases := []struct {
+		name  string
+		token string
+		code  string
+	}{
+		{"wrong audience", bind(t, presented, map[string]any{"aud": "bpp.other.com"}), codeAutUnauthorizedAction},
+		{"wrong nonce", bind(t, presented, map[string]any{"nonce": "m-2"}), codeAutUnauthorizedAction},
+		{"stale iat", bind(t, presented, map[string]any{"iat": fixedNow().Add(-time.Hour).Unix()}), codeAutKeyExpiredOrRevoked},
+		{"sd_hash over other disclosures", presented + foreignKB, codeAutSignatureInvalid},
+		{"signed by another key", func() string {
+			_, other, _ := ed25519.GenerateKey(nil)
+			sum := sha256.Sum256([]byte(presented))
+			return presented + signJWS(t, other, typKBJWT, "", map[string]any{
+				"iat": fixedNow().Unix(), "aud": "bpp.example.com", "nonce": "m-1",
+				"sd_hash": base64.RawURLEncoding.EncodeToString(sum[:]),
+			})
+		}(), codeAutSignatureInvalid},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := v.verifySDJWT(context.Background(), tc.token, testKeyBinding)
+			assertClassCode(t, err, failKeyBinding, tc.code)
+		})
+	}
+
+	t.Run("required", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Actions = []string{"confirm"}
+		cfg.RequireKeyBinding = true
+		_, err := testVerifier(cfg).verifySDJWT(context.Background(), presented, testKeyBinding)
+		assertClassCode(t, err, failKeyBinding, codeAutSignatureMissing)
+	})
+}
+
+func TestSDJWTTokenStatusList(t *testing.T) {
+	// Two-bit statuses: idx 0 VALID, idx 1 INVALID, idx 2 SUSPENDED.
+	var zbuf bytes.Buffer
+	zw := zlib.NewWriter(&zbuf)
+	_, _ = zw.Write([]byte{0b00_10_01_00})
+	_ = zw.Close()
+	statusToken := signJWS(t, specKey(t), "statuslist+jwt", "", map[string]any{
+		"sub":         "https://status.example.com/lists/1",
+		"status_list": map[string]any{"bits": 2, "lst": base64.RawURLEncoding.EncodeToString(zbuf.Bytes())},
+	})
+
+	for idx, wantRevoked := range []bool{false, true, true} {
+		f := newSDJWTFixture(t)
+		f.claims["status"] = map[string]any{"status_list": map[string]any{"idx": idx, "uri": "https://status.example.com/lists/1"}}
+		v := testVerifier(nil)
+		v.fetch = func(_ context.Context, url string) ([]byte, error) {
+			if url != "https://status.example.com/lists/1" {
+				t.Fatalf("unexpected fetch %s", url)
+			}
+			return []byte(statusToken), nil
+		}
+		_, err := v.verifySDJWT(context.Background(), f.issue(t), testKeyBinding)
+		if wantRevoked {
+			assertClassCode(t, err, failRevoked, codeAutKeyExpiredOrRevoked)
+		} else if err != nil {
+			t.Fatalf("idx %d: expected valid, got %v", idx, err)
+		}
+	}
+}
+
+func TestStepSDJWT(t *testing.T) {
+	f := newSDJWTFixture(t)
+	token := bind(t, f.issue(t, f.disclosures[0]), nil)
+
+	body := func(credential any) []byte {
+		return vcBytes(t, map[string]any{
+			"context": map[string]any{"action": "confirm", "message_id": "m-1", "bap_id": "bap.example.com"},
+			"message": map[string]any{"contract": map[string]any{"participants": []any{
+				map[string]any{"participantAttributes": credential},
+			}}},
+		})
+	}
+	run := func(s *step, b []byte) error {
+		ctx := stepCtx("/bpp/receiver/confirm", b)
+		ctx.SubID = "bpp.example.com"
+		return s.Run(ctx)
+	}
+
+	if got := extractCredentials(body(token)); len(got) != 1 {
+		t.Fatalf("expected the bare SD-JWT to be extracted, got %d", len(got))
+	}
+	enveloped := map[string]any{"type": "EnvelopedVerifiableCredential", "id": "data:application/dc+sd-jwt," + token}
+	if got := extractCredentials(body(enveloped)); len(got) != 1 {
+		t.Fatalf("expected the enveloped SD-JWT to be extracted, got %d", len(got))
+	}
+	if got := extractCredentials(body("eyJhbGciOiJub25lIn0.e30.~not-an-sd-jwt")); len(got) != 0 {
+		t.Fatalf("a string that is not an SD-JWT VC must be ignored, got %d", len(got))
+	}
+
+	if err := run(testStep(), body(enveloped)); err != nil {
+		t.Fatalf("valid SD-JWT VC must pass, got: %v", err)
+	}
+
+	policy := &model.CredentialTrustPolicy{
+		RequiredCredentials: map[string][]model.RequiredCredential{
+			"confirm": {{Type: "GSTCredential", Subject: model.CredentialSubjectBAP}},
+		},
+	}
+	if err := run(trustStep(policy), body(token)); err != nil {
+		t.Fatalf("SD-JWT VC disclosing its subject must satisfy the policy, got: %v", err)
+	}
+	withheld := bind(t, f.issue(t, f.disclosures[1]), nil)
+	assertNack(t, run(trustStep(policy), body(withheld)), http.StatusForbidden, failMissingCredential)
+
+	untrusted := trustStep(&model.CredentialTrustPolicy{Issuers: map[string][]string{"GSTCredential": {"did:web:gst.example.gov"}}})
+	assertNack(t, run(untrusted, body(token)), http.StatusUnauthorized, failUntrustedIssuer)
+}
+
+func TestKeyBindingConfig(t *testing.T) {
+	st, err := New(map[string]string{
+		"actions":            "confirm",
+		"requireKeyBinding":  "true",
+		"keyBindingAudience": "bpp.example.com",
+		"keyBindingMaxAge":   "90",
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	cfg := st.(*step).cfg
+	if !cfg.RequireKeyBinding || cfg.KeyBindingAudience != "bpp.example.com" || cfg.KeyBindingMaxAge != 90*time.Second {
+		t.Fatalf("config = %+v", cfg)
+	}
+	if _, err := New(map[string]string{"actions": "confirm", "keyBindingMaxAge": "soon"}); err == nil {
+		t.Fatal("expected an invalid keyBindingMaxAge to be rejected")
+	}
+}
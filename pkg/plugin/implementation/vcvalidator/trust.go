@@ -0,0 +1,257 @@
+// trust.go applies the network's credential trust policy on top of proof
+// verification: which issuers may issue which credential types, which
+// credentials an action must carry, and whose credentials they must be.
+package vcvalidator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+	"gopkg.in/yaml.v3"
+)
+
+// trustStore supplies the trust policy for a request: the policy read from
+// trustFile, or the credentialTrust section of the network manifest. A nil
+// trustStore means no policy is configured.
+type trustStore struct {
+	static    *model.CredentialTrustPolicy
+	networkID string // fixed network for manifest lookups; "" = the request's
+	loader    definition.ManifestLoader
+	now       func() time.Time
+
+	mu        sync.Mutex
+	manifests map[string]cachedManifest // network ID -> last parsed manifest
+}
+
+// cachedManifest avoids re-parsing the manifest YAML on every request;
+// ManifestLoader already caches the document itself.
+type cachedManifest struct {
+	digest   string
+	manifest *model.NetworkManifest
+}
+
+// newTrustStore builds the trust store for cfg, reading trustFile if set.
+func newTrustStore(cfg *Config) (*trustStore, error) {
+	switch {
+	case cfg.TrustFile != "":
+		data, err := os.ReadFile(cfg.TrustFile)
+		if err != nil {
+			return nil, fmt.Errorf("trustFile: %w", err)
+		}
+		var policy model.CredentialTrustPolicy
+		if err := yaml.Unmarshal(data, &policy); err != nil {
+			return nil, fmt.Errorf("trustFile %s: %w", cfg.TrustFile, err)
+		}
+		if err := policy.Validate(); err != nil {
+			return nil, fmt.Errorf("trustFile %s: %w", cfg.TrustFile, err)
+		}
+		return &trustStore{static: &policy}, nil
+	case cfg.TrustFromManifest:
+		return &trustStore{
+			networkID: cfg.TrustNetworkID,
+			now:       time.Now,
+			manifests: map[string]cachedManifest{},
+		}, nil
+	}
+	return nil, nil
+}
+
+// SetManifestLoader receives the handler's ManifestLoader. It implements
+// definition.ManifestAwareStep.
+func (s *step) SetManifestLoader(loader definition.ManifestLoader) error {
+	if s.trust == nil || s.trust.static != nil {
+		return nil
+	}
+	if loader == nil && s.cfg.Enabled {
+		return fmt.Errorf("validateVC: trustFromManifest requires the ManifestLoader plugin to be configured")
+	}
+	s.trust.loader = loader
+	return nil
+}
+
+var _ definition.ManifestAwareStep = (*step)(nil)
+
+// policy returns the trust policy that applies to a request on networkID,
+// or nil when there is none.
+func (t *trustStore) policy(ctx context.Context, networkID string) (*model.CredentialTrustPolicy, error) {
+	if t == nil {
+		return nil, nil
+	}
+	if t.static != nil {
+		return t.static, nil
+	}
+	if t.networkID != "" {
+		networkID = t.networkID
+	}
+	if networkID == "" {
+		return nil, fmt.Errorf("request context carries no network id to load the trust policy for")
+	}
+	if t.loader == nil {
+		return nil, fmt.Errorf("no ManifestLoader configured")
+	}
+	doc, err := t.loader.GetByNetworkID(ctx, networkID)
+	if err != nil {
+		return nil, fmt.Errorf("load manifest for network %q: %w", networkID, err)
+	}
+
+	t.mu.Lock()
+	cached, ok := t.manifests[networkID]
+	t.mu.Unlock()
+	if !ok || cached.digest != doc.Digest || doc.Digest == "" {
+		manifest, err := model.ParseNetworkManifest(doc.Content)
+		if err != nil {
+			return nil, fmt.Errorf("parse manifest for network %q: %w", networkID, err)
+		}
+		cached = cachedManifest{digest: doc.Digest, manifest: manifest}
+		t.mu.Lock()
+		t.manifests[networkID] = cached
+		t.mu.Unlock()
+	}
+	// Validated on every use: the manifest's governance window can close
+	// while its parsed form sits in the cache.
+	if err := cached.manifest.Validate(networkID, t.now().UTC()); err != nil {
+		return nil, err
+	}
+	return cached.manifest.CredentialTrust, nil
+}
+
+// credentialClaims is what the trust checks read from a credential.
+type credentialClaims struct {
+	types    []string
+	issuer   string
+	subjects []string // credentialSubject ids
+}
+
+func parseClaims(raw json.RawMessage) (*credentialClaims, error) {
+	var c struct {
+		credential
+		CredentialSubject json.RawMessage `json:"credentialSubject"`
+	}
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, failf(failStructure, codeSchInvalidJSON, "cannot parse credential: %v", err)
+	}
+	issuer, err := c.issuerDID()
+	if err != nil {
+		return nil, err
+	}
+	claims := &credentialClaims{issuer: base(issuer), types: stringOrList(c.Type)}
+
+	var subjects []struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(c.CredentialSubject, &subjects); err != nil {
+		subjects = subjects[:0]
+		var one struct {
+			ID string `json:"id"`
+		}
+		if json.Unmarshal(c.CredentialSubject, &one) == nil {
+			subjects = append(subjects, one)
+		}
+	}
+	for _, s := range subjects {
+		if s.ID != "" {
+			claims.subjects = append(claims.subjects, s.ID)
+		}
+	}
+	return claims, nil
+}
+
+// stringOrList decodes a JSON string or array of strings.
+func stringOrList(raw json.RawMessage) []string {
+	var list []string
+	if json.Unmarshal(raw, &list) == nil {
+		return list
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil && s != "" {
+		return []string{s}
+	}
+	return nil
+}
+
+// checkIssuer rejects a credential whose issuer is not trusted for one of
+// its types.
+func checkIssuer(policy *model.CredentialTrustPolicy, c *credentialClaims) error {
+	if policy == nil || len(policy.Issuers) == 0 {
+		return nil
+	}
+	for _, t := range c.types {
+		allowed, ok := policy.Issuers[t]
+		if !ok {
+			if allowed, ok = policy.Issuers["*"]; !ok {
+				continue
+			}
+		}
+		if !slices.ContainsFunc(allowed, func(did string) bool { return base(did) == c.issuer }) {
+			return failf(failUntrustedIssuer, codeAutUnauthorizedAction,
+				"issuer %q is not trusted to issue %s", c.issuer, t)
+		}
+	}
+	return nil
+}
+
+// checkRequired rejects the request unless every credential the policy
+// requires for action is present, about the participant it names.
+func checkRequired(policy *model.CredentialTrustPolicy, action string, claims []*credentialClaims, subjects map[string]string) error {
+	if policy == nil {
+		return nil
+	}
+	for _, req := range policy.RequiredCredentials[action] {
+		want := ""
+		if req.Subject != "" {
+			if want = subjects[req.Subject]; want == "" {
+				return failf(failMissingCredential, codeAutUnauthorizedAction,
+					"%s requires a %s about the %s, which is not known for this request", action, req.Type, req.Subject)
+			}
+		}
+		found := slices.ContainsFunc(claims, func(c *credentialClaims) bool {
+			return slices.Contains(c.types, req.Type) && (want == "" || c.isAbout(want))
+		})
+		if !found {
+			if want != "" {
+				return failf(failMissingCredential, codeAutUnauthorizedAction,
+					"%s requires a %s about %s (%s)", action, req.Type, req.Subject, want)
+			}
+			return failf(failMissingCredential, codeAutUnauthorizedAction, "%s requires a %s", action, req.Type)
+		}
+	}
+	return nil
+}
+
+// isAbout reports whether the credential's subject is the given subscriber,
+// named either by its subscriber ID or by its did:web DID.
+func (c *credentialClaims) isAbout(subscriberID string) bool {
+	did := "did:web:" + strings.ReplaceAll(subscriberID, ":", "%3A")
+	return slices.ContainsFunc(c.subjects, func(id string) bool {
+		return id == subscriberID || base(id) == did
+	})
+}
+
+// requestSubjects returns the participants a credential can be bound to —
+// the verified signer and the context's bap_id and bpp_id — along with the
+// request's network and message ids.
+func requestSubjects(ctx *model.StepContext) (subjects map[string]string, networkID, messageID string) {
+	var env struct {
+		Context map[string]any `json:"context"`
+	}
+	_ = json.Unmarshal(ctx.Body, &env)
+	messageID = ctx.MessageID
+	for _, k := range []string{"message_id", "messageId"} {
+		if id, ok := env.Context[k].(string); ok && messageID == "" {
+			messageID = id
+		}
+	}
+	return map[string]string{
+		model.CredentialSubjectSigner: ctx.SignerID,
+		model.CredentialSubjectBAP:    model.ResolveSubscriberID(env.Context, model.RoleBAP),
+		model.CredentialSubjectBPP:    model.ResolveSubscriberID(env.Context, model.RoleBPP),
+	}, model.ResolveNetworkID(env.Context), messageID
+}
@@ -0,0 +1,311 @@
+package vcvalidator
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+)
+
+// trustVC returns a credential of the given type about subject, issued and
+// signed (eddsa-jcs-2022) by the spec key.
+func trustVC(t *testing.T, credType, subject string) map[string]any {
+	t.Helper()
+	vc := dataIntegrityVC(suiteEddsaJCS2022)
+	vc["type"] = []any{"VerifiableCredential", credType}
+	vc["credentialSubject"] = map[string]any{"id": subject}
+	signDataIntegrity(t, testVerifier(nil), specKey(t), vc, suiteEddsaJCS2022)
+	return vc
+}
+
+// trustStep builds the Step gating "confirm" under the given static policy.
+func trustStep(policy *model.CredentialTrustPolicy) *step {
+	s := testStep()
+	s.trust = &trustStore{static: policy}
+	return s
+}
+
+// trustBody is becknBody with bap_id, bpp_id and network_id in the context.
+func trustBody(t *testing.T, vcs ...map[string]any) []byte {
+	t.Helper()
+	participants := make([]any, len(vcs))
+	for i, vc := range vcs {
+		participants[i] = map[string]any{"participantAttributes": vc}
+	}
+	return vcBytes(t, map[string]any{
+		"context": map[string]any{
+			"action":     "confirm",
+			"bap_id":     "bap.example.com",
+			"bpp_id":     "bpp.example.com",
+			"network_id": "nfh.global/testnet",
+		},
+		"message": map[string]any{"contract": map[string]any{"participants": participants}},
+	})
+}
+
+// assertNack asserts err is a NACK with the given HTTP status and failure class.
+func assertNack(t *testing.T, err error, status int, class failClass) {
+	t.Helper()
+	var coded *model.CodedErr
+	if !errors.As(err, &coded) {
+		t.Fatalf("expected *model.CodedErr, got %T: %v", err, err)
+	}
+	if got := coded.HTTPStatus(); got != status {
+		t.Fatalf("HTTPStatus() = %d, want %d (%v)", got, status, err)
+	}
+	if !strings.Contains(err.Error(), string(class)) {
+		t.Fatalf("expected failure class %s, got: %v", class, err)
+	}
+}
+
+func TestTrustedIssuers(t *testing.T) {
+	tests := []struct {
+		name    string
+		issuers map[string][]string
+		wantErr bool
+	}{
+		{name: "listed issuer", issuers: map[string][]string{"GSTCredential": {specKeyDID}}},
+		{name: "issuer listed with a fragment", issuers: map[string][]string{"GSTCredential": {specKeyVM}}},
+		{name: "other issuer", issuers: map[string][]string{"GSTCredential": {"did:web:gst.example.gov"}}, wantErr: true},
+		{name: "wildcard applies to unlisted type", issuers: map[string][]string{"*": {"did:web:gst.example.gov"}}, wantErr: true},
+		{name: "own entry overrides wildcard", issuers: map[string][]string{"*": {"did:web:gst.example.gov"}, "GSTCredential": {specKeyDID}, "VerifiableCredential": {specKeyDID}}},
+		{name: "unlisted type is unconstrained", issuers: map[string][]string{"FSSAICredential": {"did:web:fssai.example.gov"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := trustStep(&model.CredentialTrustPolicy{Issuers: tt.issuers})
+			err := s.Run(stepCtx("/bpp/receiver/confirm", trustBody(t, trustVC(t, "GSTCredential", "did:web:bap.example.com"))))
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("expected pass, got: %v", err)
+				}
+				return
+			}
+			assertNack(t, err, http.StatusUnauthorized, failUntrustedIssuer)
+		})
+	}
+}
+
+func TestRequiredCredentials(t *testing.T) {
+	policy := &model.CredentialTrustPolicy{
+		RequiredCredentials: map[string][]model.RequiredCredential{
+			"confirm": {{Type: "GSTCredential", Subject: model.CredentialSubjectBAP}},
+		},
+	}
+	tests := []struct {
+		name    string
+		vcs     []map[string]any
+		wantErr bool
+	}{
+		{name: "no credentials", wantErr: true},
+		{name: "wrong type", vcs: []map[string]any{trustVC(t, "FSSAICredential", "did:web:bap.example.com")}, wantErr: true},
+		{name: "about the bpp", vcs: []map[string]any{trustVC(t, "GSTCredential", "did:web:bpp.example.com")}, wantErr: true},
+		{name: "about the bap by did:web", vcs: []map[string]any{trustVC(t, "GSTCredential", "did:web:bap.example.com")}},
+		{name: "about the bap by subscriber id", vcs: []map[string]any{trustVC(t, "GSTCredential", "bap.example.com")}},
+		{name: "among other credentials", vcs: []map[string]any{
+			trustVC(t, "FSSAICredential", "did:web:bpp.example.com"),
+			trustVC(t, "GSTCredential", "did:web:bap.example.com"),
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := trustStep(policy).Run(stepCtx("/bpp/receiver/confirm", trustBody(t, tt.vcs...)))
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("expected pass, got: %v", err)
+				}
+				return
+			}
+			assertNack(t, err, http.StatusForbidden, failMissingCredential)
+		})
+	}
+
+	t.Run("other actions are unaffected", func(t *testing.T) {
+		s := trustStep(policy)
+		s.cfg.Actions = []string{"confirm", "init"}
+		body := []byte(`{"context":{"action":"init"},"message":{}}`)
+		if err := s.Run(stepCtx("/bpp/receiver/init", body)); err != nil {
+			t.Fatalf("init requires no credentials, got: %v", err)
+		}
+	})
+}
+
+func TestBindSubject(t *testing.T) {
+	vc := trustVC(t, "GSTCredential", "did:web:bap.example.com")
+	run := func(signer string) error {
+		s := testStep()
+		s.cfg.BindSubject = true
+		ctx := stepCtx("/bpp/receiver/confirm", trustBody(t, vc))
+		ctx.SignerID = signer
+		return s.Run(ctx)
+	}
+	if err := run("bap.example.com"); err != nil {
+		t.Fatalf("credential about the signer must pass, got: %v", err)
+	}
+	assertNack(t, run("bpp.example.com"), http.StatusForbidden, failSubjectMismatch)
+	err := run("")
+	assertNack(t, err, http.StatusForbidden, failSubjectMismatch)
+	if !strings.Contains(err.Error(), "validateSign") {
+		t.Fatalf("expected a hint to run validateSign, got: %v", err)
+	}
+}
+
+// fakeManifestLoader serves one network manifest from memory.
+type fakeManifestLoader struct {
+	content []byte
+	err     error
+	calls   []string
+}
+
+func (f *fakeManifestLoader) GetByNetworkID(_ context.Context, networkID string) (*model.ManifestDocument, error) {
+	f.calls = append(f.calls, networkID)
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &model.ManifestDocument{NetworkID: networkID, Content: f.content, Digest: "sha256:test"}, nil
+}
+
+func (f *fakeManifestLoader) GetByMetadata(context.Context, model.ManifestMetadata) (*model.ManifestDocument, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *fakeManifestLoader) GetBySubscriberID(context.Context, string) (*model.ManifestDocument, error) {
+	return nil, errors.New("not implemented")
+}
+
+const trustManifest = `manifestVersion: "1.0"
+manifestType: network-manifest
+networkId: nfh.global/testnet
+releaseId: "2026.06"
+publisher:
+  role: NFO
+  domain: nfh.global
+policies:
+  type: rego
+  source: file
+  file:
+    id: network-policy
+    url: https://example.com/policy.rego
+    policyQueryPath: data.policy.result
+governance:
+  effectiveFrom: "2026-01-01T00:00:00Z"
+  signed: false
+credentialTrust:
+  issuers:
+    GSTCredential: ["did:web:gst.example.gov"]
+`
+
+func TestTrustFromManifest(t *testing.T) {
+	manifestStep := func(t *testing.T, loader *fakeManifestLoader) *step {
+		t.Helper()
+		s := testStep()
+		s.cfg.TrustFromManifest = true
+		s.trust, _ = newTrustStore(s.cfg)
+		s.trust.now = fixedNow
+		if err := s.SetManifestLoader(loader); err != nil {
+			t.Fatalf("SetManifestLoader: %v", err)
+		}
+		return s
+	}
+	body := trustBody(t, trustVC(t, "GSTCredential", "did:web:bap.example.com"))
+
+	t.Run("policy from the request's network", func(t *testing.T) {
+		loader := &fakeManifestLoader{content: []byte(trustManifest)}
+		s := manifestStep(t, loader)
+		assertNack(t, s.Run(stepCtx("/bpp/receiver/confirm", body)), http.StatusUnauthorized, failUntrustedIssuer)
+		assertNack(t, s.Run(stepCtx("/bpp/receiver/confirm", body)), http.StatusUnauthorized, failUntrustedIssuer)
+		if len(loader.calls) != 2 || loader.calls[0] != "nfh.global/testnet" {
+			t.Fatalf("manifest lookups = %v", loader.calls)
+		}
+		if len(s.trust.manifests) != 1 {
+			t.Fatalf("expected the parsed manifest to be cached")
+		}
+	})
+	t.Run("manifest without credentialTrust imposes nothing", func(t *testing.T) {
+		content := trustManifest[:strings.Index(trustManifest, "credentialTrust:")]
+		s := manifestStep(t, &fakeManifestLoader{content: []byte(content)})
+		if err := s.Run(stepCtx("/bpp/receiver/confirm", body)); err != nil {
+			t.Fatalf("expected pass, got: %v", err)
+		}
+	})
+	t.Run("pinned network", func(t *testing.T) {
+		loader := &fakeManifestLoader{content: []byte(strings.ReplaceAll(trustManifest, "nfh.global/testnet", "nfh.global/pinned"))}
+		s := manifestStep(t, loader)
+		s.trust.networkID = "nfh.global/pinned"
+		assertNack(t, s.Run(stepCtx("/bpp/receiver/confirm", body)), http.StatusUnauthorized, failUntrustedIssuer)
+		if loader.calls[0] != "nfh.global/pinned" {
+			t.Fatalf("manifest lookups = %v", loader.calls)
+		}
+	})
+	t.Run("expired manifest", func(t *testing.T) {
+		content := strings.Replace(trustManifest, "  signed: false\n",
+			"  signed: false\n  effectiveUntil: \"2026-02-01T00:00:00Z\"\n", 1)
+		s := manifestStep(t, &fakeManifestLoader{content: []byte(content)})
+		assertNack(t, s.Run(stepCtx("/bpp/receiver/confirm", body)), http.StatusServiceUnavailable, failTrustUnavailable)
+	})
+	t.Run("loader failure fails closed", func(t *testing.T) {
+		s := manifestStep(t, &fakeManifestLoader{err: errors.New("registry down")})
+		assertNack(t, s.Run(stepCtx("/bpp/receiver/confirm", body)), http.StatusServiceUnavailable, failTrustUnavailable)
+	})
+	t.Run("no ManifestLoader configured", func(t *testing.T) {
+		s := testStep()
+		s.cfg.TrustFromManifest = true
+		s.trust, _ = newTrustStore(s.cfg)
+		if err := s.SetManifestLoader(nil); err == nil || !strings.Contains(err.Error(), "ManifestLoader") {
+			t.Fatalf("expected a ManifestLoader error, got: %v", err)
+		}
+	})
+}
+
+func TestTrustConfig(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "trust.yaml")
+	if err := os.WriteFile(good, []byte(`issuers:
+  GSTCredential: [did:web:gst.example.gov]
+requiredCredentials:
+  confirm:
+    - type: GSTCredential
+      subject: bap_id
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	bad := filepath.Join(dir, "bad.yaml")
+	if err := os.WriteFile(bad, []byte("issuers:\n  GSTCredential: [gst.example.gov]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := New(map[string]string{"actions": "confirm", "trustFile": good, "bindSubject": "true"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	s := st.(*step)
+	if !s.cfg.BindSubject || s.trust.static.RequiredCredentials["confirm"][0].Subject != model.CredentialSubjectBAP {
+		t.Fatalf("trust policy not loaded: %+v", s.trust.static)
+	}
+	if err := s.SetManifestLoader(nil); err != nil {
+		t.Fatalf("a trustFile step needs no ManifestLoader, got: %v", err)
+	}
+
+	for name, cfg := range map[string]map[string]string{
+		"invalid policy":     {"actions": "confirm", "trustFile": bad},
+		"missing file":       {"actions": "confirm", "trustFile": filepath.Join(dir, "nope.yaml")},
+		"mutually exclusive": {"actions": "confirm", "trustFile": good, "trustFromManifest": "true"},
+	} {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("%s: expected a config error", name)
+		}
+	}
+
+	st, err = New(map[string]string{"actions": "confirm", "trustFromManifest": "true", "trustNetworkId": "nfh.global/testnet"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if got := st.(*step).trust.networkID; got != "nfh.global/testnet" {
+		t.Fatalf("trustNetworkId = %q", got)
+	}
+}
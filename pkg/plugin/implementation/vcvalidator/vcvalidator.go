@@ -9,17 +9,29 @@
 // returns an error, which the handler pipeline turns into the standard
 // signed beckn NACK — the request never reaches routing.
 //
-// The package is organised in two files:
+// The package is organised as:
 //
 //   - vcvalidator.go — the plugin surface: the Step, its Config, and
 //     credential extraction from the request body.
 //   - verify.go — the verification engine: proof/JWT checks, DID
 //     resolution (did:key / did:jwk / did:web), and revocation.
+//   - trust.go — the credential trust policy: trusted issuers per
+//     credential type, required credentials per action, subject binding.
+//   - fetchcache.go — caching of DID documents and revocation lists in
+//     the handler's Cache, with background refresh; metrics.go holds its
+//     instruments.
+//   - sdjwt.go — SD-JWT VCs: selective disclosures, key binding and
+//     Token Status List revocation.
+//   - dataintegrity.go — Ed25519 Data Integrity proofs (eddsa-rdfc-2022,
+//     eddsa-jcs-2022, Ed25519Signature2020) and the bundled contexts.
+//   - jsonld.go, rdf.go, rdfc.go — offline JSON-LD expansion, RDF
+//     conversion and RDFC-1.0 canonicalisation for those proofs.
 package vcvalidator
 
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strconv"
 	"strings"
 	"time"
@@ -36,8 +48,10 @@ import (
 // step validates embedded Verifiable Credentials as part of the module's
 // processing pipeline. It implements definition.Step.
 type step struct {
-	cfg *Config
-	v   *verifier
+	cfg    *Config
+	v      *verifier
+	trust  *trustStore
+	origin originFetcher // uncached fetches, for SetCache
 }
 
 // New builds the validateVC Step from its YAML config map.
@@ -48,15 +62,24 @@ func New(cfg map[string]string) (definition.Step, error) {
 	}
 
 	client := newHTTPClient(config)
-	v := newVerifier(config, httpFetcher(client))
+	origin := httpOriginFetcher(client)
+	v := newVerifier(config, origin.bodyOnly())
 	v.statusGet = httpStatusFetcher(client)
+	if v.contexts, err = newContextStore(config.JSONLDContexts); err != nil {
+		return nil, fmt.Errorf("validateVC: config: %w", err)
+	}
+	trust, err := newTrustStore(config)
+	if err != nil {
+		return nil, fmt.Errorf("validateVC: config: %w", err)
+	}
 
-	return &step{cfg: config, v: v}, nil
+	return &step{cfg: config, v: v, trust: trust, origin: origin}, nil
 }
 
-// Run verifies every credential embedded in the request body. Requests for
-// non-gated actions, or without embedded credentials, pass through untouched.
-// A verification failure is returned as an error so the handler rejects the
+// Run verifies every credential embedded in the request body, then applies
+// the trust policy, if one is configured. Requests for non-gated actions, or
+// without embedded credentials when the policy requires none, pass through
+// untouched. A failure is returned as an error so the handler rejects the
 // request through its standard signed-NACK path.
 func (s *step) Run(ctx *model.StepContext) error {
 	if !s.cfg.Enabled {
@@ -68,8 +91,16 @@ func (s *step) Run(ctx *model.StepContext) error {
 		return nil
 	}
 
+	subjects, networkID, messageID := requestSubjects(ctx)
+	policy, err := s.trust.policy(ctx, networkID)
+	if err != nil {
+		ve := failf(failTrustUnavailable, codeNetDownstreamUnavailable, "%v", err)
+		log.Errorf(ctx, ve, "validateVC: action=%s rejected", action)
+		return nackErr(ve)
+	}
+
 	creds := extractCredentials(ctx.Body)
-	if len(creds) == 0 {
+	if len(creds) == 0 && (policy == nil || len(policy.RequiredCredentials[action]) == 0) {
 		if s.cfg.DebugLogging {
 			log.Debugf(ctx, "validateVC: action=%s: no embedded credentials, passing through", action)
 		}
@@ -87,18 +118,67 @@ func (s *step) Run(ctx *model.StepContext) error {
 		return nackErr(ve)
 	}
 
+	kb := keyBinding{audience: s.cfg.KeyBindingAudience, nonce: messageID}
+	if kb.audience == "" {
+		kb.audience = ctx.SubID
+	}
+	claims := make([]*credentialClaims, 0, len(creds))
 	for i, raw := range creds {
-		if err := s.v.verify(ctx, raw); err != nil {
+		c, err := s.checkCredential(ctx, raw, policy, subjects, kb)
+		if err != nil {
 			ve := asVCError(err)
 			log.Errorf(ctx, ve, "validateVC: action=%s credential[%d] rejected", action, i)
 			return nackErr(ve)
 		}
+		claims = append(claims, c)
+	}
+	if err := checkRequired(policy, action, claims, subjects); err != nil {
+		ve := asVCError(err)
+		log.Errorf(ctx, ve, "validateVC: action=%s rejected", action)
+		return nackErr(ve)
 	}
 
 	log.Infof(ctx, "validateVC: action=%s: %d credential(s) verified OK", action, len(creds))
 	return nil
 }
 
+// checkCredential verifies one credential — a JSON credential or an SD-JWT
+// VC — and applies the per-credential trust checks: a trusted issuer for its
+// type and, with BindSubject, a credentialSubject that is the signing
+// subscriber.
+func (s *step) checkCredential(ctx *model.StepContext, raw json.RawMessage, policy *model.CredentialTrustPolicy, subjects map[string]string, kb keyBinding) (*credentialClaims, error) {
+	var c *credentialClaims
+	if token, ok := sdJWTOf(raw); ok {
+		var err error
+		if c, err = s.v.verifySDJWT(ctx, token, kb); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := s.v.verify(ctx, raw); err != nil {
+			return nil, err
+		}
+		var err error
+		if c, err = parseClaims(raw); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkIssuer(policy, c); err != nil {
+		return nil, err
+	}
+	if s.cfg.BindSubject {
+		signer := subjects[model.CredentialSubjectSigner]
+		if signer == "" {
+			return nil, failf(failSubjectMismatch, codeAutUnauthorizedAction,
+				"bindSubject needs the request signer; run validateSign before validateVC")
+		}
+		if !c.isAbout(signer) {
+			return nil, failf(failSubjectMismatch, codeAutUnauthorizedAction,
+				"credentialSubject %v is not the signing subscriber %q", c.subjects, signer)
+		}
+	}
+	return c, nil
+}
+
 // nackErr wraps a credential failure in the model error type the handler's
 // NACK mapping understands: a structurally broken credential is a Bad
 // Request, while every other failure (proof, issuer, expiry, revocation,
@@ -114,9 +194,19 @@ func (s *step) Run(ctx *model.StepContext) error {
 // status. model.NewCodedErr takes a status directly, so remapping a class to
 // 502 or 504 is a local change now. Left to #870/#884, since it changes the
 // status this plugin puts on the wire.
+//
+// The trust policy classes are the exception: a missing required credential
+// or a subject mismatch means the credentials are authentic but do not
+// authorise this request, which is a 403, and an unavailable trust policy is
+// a 503 — the request may be fine, the network's policy could not be read.
 func nackErr(ve *vcError) error {
-	if ve.class == failStructure {
+	switch ve.class {
+	case failStructure:
 		return model.NewBadReqErr(ve.code, ve)
+	case failMissingCredential, failSubjectMismatch:
+		return model.NewCodedErr(http.StatusForbidden, ve.code, ve)
+	case failTrustUnavailable:
+		return model.NewCodedErr(http.StatusServiceUnavailable, ve.code, ve)
 	}
 	return model.NewSignValidationErr(ve.code, ve)
 }
@@ -171,13 +261,51 @@ type Config struct {
 	CheckRevocation bool
 
 	// RequireProof rejects credentials whose proof cannot be cryptographically
-	// verified by this plugin (e.g. JSON-LD Data Integrity proofs such as
-	// Ed25519Signature2020 that require RDF canonicalization, which this
-	// plugin does not perform). When false such proofs are skipped with a
-	// warning and the remaining checks (expiry/revocation) still run.
-	// Default: true.
+	// verified by this plugin (Data Integrity proofs other than the Ed25519
+	// suites: eddsa-rdfc-2022, eddsa-jcs-2022, Ed25519Signature2020). When
+	// false such proofs are skipped and the remaining checks
+	// (expiry/revocation) still run. Default: true.
 	RequireProof bool
 
+	// JSONLDContexts maps JSON-LD context URLs to local files, for contexts
+	// beyond the W3C ones bundled with the plugin (or to override those).
+	// Contexts are never fetched over the network, so a Data Integrity
+	// credential referencing an unmapped context fails verification.
+	JSONLDContexts map[string]string
+
+	// TrustFile is a YAML (or JSON) credential trust policy: the trusted
+	// issuer DIDs per credential type and the credentials each action must
+	// carry (see model.CredentialTrustPolicy). Mutually exclusive with
+	// TrustFromManifest. Default: none — any issuer is accepted.
+	TrustFile string
+
+	// TrustFromManifest reads the trust policy from the credentialTrust
+	// section of the network manifest, through the handler's ManifestLoader.
+	// A manifest without that section imposes no policy. Default: false.
+	TrustFromManifest bool
+
+	// TrustNetworkID pins the network whose manifest supplies the trust
+	// policy. Default: the network id in the request context.
+	TrustNetworkID string
+
+	// BindSubject requires every credential's credentialSubject.id to be
+	// the subscriber that signed the request (its subscriber ID or did:web
+	// DID), so a participant cannot present someone else's credential.
+	// Needs validateSign earlier in the pipeline. Default: false.
+	BindSubject bool
+
+	// RequireKeyBinding rejects SD-JWT VCs presented without a key-binding
+	// JWT. Default: false — the key-binding JWT is checked when present.
+	RequireKeyBinding bool
+
+	// KeyBindingAudience is the aud an SD-JWT key-binding JWT must carry.
+	// Default: the handler's own subscriber ID.
+	KeyBindingAudience string
+
+	// KeyBindingMaxAge bounds how far a key-binding JWT's iat may lie from
+	// now, in either direction. Default: 5m.
+	KeyBindingMaxAge time.Duration
+
 	// FailOpen controls behaviour on transient network errors while
 	// resolving a did:web document or fetching a revocation list. When true
 	// such errors are logged and the credential is allowed through; when
@@ -188,6 +316,15 @@ type Config struct {
 	// Default: 10s.
 	HTTPTimeout time.Duration
 
+	// CacheTTL is how long a fetched DID document or revocation list is
+	// kept in the handler's Cache when its response carries no
+	// Cache-Control or Expires header. 0 disables caching. Default: 5m.
+	CacheTTL time.Duration
+
+	// CacheMaxTTL caps the lifetime of a cached fetch, whatever its headers
+	// say. Default: 1h.
+	CacheMaxTTL time.Duration
+
 	// MaxCredentials caps how many embedded credentials a single request may
 	// carry. Each credential can cost up to two HTTP fetches (did:web
 	// resolution + revocation), so the cap bounds the per-request work; a
@@ -218,7 +355,10 @@ func DefaultConfig() *Config {
 		RequireProof:         true,
 		FailOpen:             false,
 		HTTPTimeout:          10 * time.Second,
+		CacheTTL:             5 * time.Minute,
+		CacheMaxTTL:          time.Hour,
 		MaxCredentials:       10,
+		KeyBindingMaxAge:     5 * time.Minute,
 		AllowPrivateNetworks: false,
 		DebugLogging:         false,
 	}
@@ -246,6 +386,15 @@ func splitCSV(v string) []string {
 	return out
 }
 
+// parseDuration accepts whole seconds ("10") or a Go duration ("1m30s").
+func parseDuration(v string) (time.Duration, error) {
+	v = strings.TrimSpace(v)
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second, nil
+	}
+	return time.ParseDuration(v)
+}
+
 // ParseConfig parses the plugin configuration map supplied by beckn-onix.
 func ParseConfig(cfg map[string]string) (*Config, error) {
 	config := DefaultConfig()
@@ -279,18 +428,80 @@ func ParseConfig(cfg map[string]string) (*Config, error) {
 		config.RequireProof = parseBool(v, config.RequireProof)
 	}
 
+	if v, ok := cfg["jsonldContexts"]; ok && strings.TrimSpace(v) != "" {
+		config.JSONLDContexts = map[string]string{}
+		for _, entry := range splitCSV(v) {
+			url, path, ok := strings.Cut(entry, "=")
+			url, path = strings.TrimSpace(url), strings.TrimSpace(path)
+			if !ok || url == "" || path == "" {
+				return nil, fmt.Errorf("validateVC: invalid jsonldContexts entry %q (want url=path)", entry)
+			}
+			config.JSONLDContexts[url] = path
+		}
+	}
+
+	if v, ok := cfg["trustFile"]; ok {
+		config.TrustFile = strings.TrimSpace(v)
+	}
+
+	if v, ok := cfg["trustFromManifest"]; ok {
+		config.TrustFromManifest = parseBool(v, config.TrustFromManifest)
+	}
+
+	if v, ok := cfg["trustNetworkId"]; ok {
+		config.TrustNetworkID = strings.TrimSpace(v)
+	}
+
+	if config.TrustFile != "" && config.TrustFromManifest {
+		return nil, fmt.Errorf("validateVC: trustFile and trustFromManifest are mutually exclusive")
+	}
+
+	if v, ok := cfg["bindSubject"]; ok {
+		config.BindSubject = parseBool(v, config.BindSubject)
+	}
+
+	if v, ok := cfg["requireKeyBinding"]; ok {
+		config.RequireKeyBinding = parseBool(v, config.RequireKeyBinding)
+	}
+
+	if v, ok := cfg["keyBindingAudience"]; ok {
+		config.KeyBindingAudience = strings.TrimSpace(v)
+	}
+
+	if v, ok := cfg["keyBindingMaxAge"]; ok && strings.TrimSpace(v) != "" {
+		d, err := parseDuration(v)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("validateVC: invalid keyBindingMaxAge %q", v)
+		}
+		config.KeyBindingMaxAge = d
+	}
+
 	if v, ok := cfg["failOpen"]; ok {
 		config.FailOpen = parseBool(v, config.FailOpen)
 	}
 
 	if v, ok := cfg["httpTimeout"]; ok && strings.TrimSpace(v) != "" {
-		if secs, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
-			config.HTTPTimeout = time.Duration(secs) * time.Second
-		} else if d, err2 := time.ParseDuration(strings.TrimSpace(v)); err2 == nil {
-			config.HTTPTimeout = d
-		} else {
+		d, err := parseDuration(v)
+		if err != nil {
 			return nil, fmt.Errorf("validateVC: invalid httpTimeout %q", v)
 		}
+		config.HTTPTimeout = d
+	}
+
+	if v, ok := cfg["cacheTTL"]; ok && strings.TrimSpace(v) != "" {
+		d, err := parseDuration(v)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("validateVC: invalid cacheTTL %q", v)
+		}
+		config.CacheTTL = d
+	}
+
+	if v, ok := cfg["cacheMaxTTL"]; ok && strings.TrimSpace(v) != "" {
+		d, err := parseDuration(v)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("validateVC: invalid cacheMaxTTL %q", v)
+		}
+		config.CacheMaxTTL = d
 	}
 
 	if v, ok := cfg["maxCredentials"]; ok && strings.TrimSpace(v) != "" {
@@ -369,7 +580,8 @@ func extractAction(urlPath string, body []byte) string {
 // Verifiable Credential. A credential is recognised as a JSON object that
 // carries both a "proof" and a "credentialSubject" — the combination beckn
 // uses only for VCs (e.g. participantAttributes holding a
-// MeterDataRequestCredential).
+// MeterDataRequestCredential) — or as a string holding an SD-JWT VC, which
+// is returned as a JSON string.
 func extractCredentials(body []byte) []json.RawMessage {
 	var root any
 	if err := json.Unmarshal(body, &root); err != nil {
@@ -398,6 +610,14 @@ func walkCredentials(node any, out *[]json.RawMessage) {
 		for _, v := range n {
 			walkCredentials(v, out)
 		}
+	case string:
+		// An SD-JWT VC travels as a string, bare or as the data: URL id of
+		// an EnvelopedVerifiableCredential.
+		if token, ok := asSDJWT(n); ok {
+			if b, err := json.Marshal(token); err == nil {
+				*out = append(*out, b)
+			}
+		}
 	}
 }
 
@@ -295,12 +295,13 @@ func TestDEDINotRevoked(t *testing.T) {
 	}
 }
 
-func TestDataIntegrityProofRejectedWhenRequired(t *testing.T) {
+func TestUnsupportedDataIntegrityProofRejectedWhenRequired(t *testing.T) {
 	vc := map[string]any{
 		"issuer":            "did:web:issuer.example.org",
 		"credentialSubject": map[string]any{"id": "x"},
 		"proof": map[string]any{
-			"type":               "Ed25519Signature2020",
+			"type":               "DataIntegrityProof",
+			"cryptosuite":        "ecdsa-rdfc-2019",
 			"proofValue":         "z58DAdFfa9Skq...",
 			"verificationMethod": "did:web:issuer.example.org#key-1",
 		},
@@ -400,8 +401,8 @@ func TestProofPresentButEmpty(t *testing.T) {
 }
 
 // TestDataIntegrityProofWithoutVerificationMethod asserts that a JSON-LD Data
-// Integrity proof carrying no verificationMethod is rejected even when
-// RequireProof is false. With requireProof disabled the signature itself is
+// Integrity proof of an unsupported cryptosuite carrying no verificationMethod
+// is rejected even when RequireProof is false. With requireProof disabled the signature itself is
 // deliberately not checked, so the resolvable verificationMethod is the only
 // remaining evidence about the credential; absent it there is nothing left to
 // verify, which is the same situation as the no-proof and empty-proof cases
@@ -415,8 +416,9 @@ func TestDataIntegrityProofWithoutVerificationMethod(t *testing.T) {
 		"issuer":            "did:web:issuer.example.org",
 		"credentialSubject": map[string]any{"id": "x"},
 		"proof": map[string]any{
-			"type":       "Ed25519Signature2020",
-			"proofValue": "z58DAdFfa9Skq...",
+			"type":        "DataIntegrityProof",
+			"cryptosuite": "ecdsa-rdfc-2019",
+			"proofValue":  "z58DAdFfa9Skq...",
 		},
 	}
 	v := testVerifier(cfg)
@@ -46,6 +46,13 @@ const (
 	failRevoked    failClass = "CREDENTIAL_REVOKED"
 	failResolution failClass = "DID_RESOLUTION_FAILED"
 	failIssuer     failClass = "ISSUER_MISMATCH"
+	failKeyBinding failClass = "INVALID_KEY_BINDING"
+
+	// Trust policy failures (trust.go).
+	failUntrustedIssuer   failClass = "UNTRUSTED_ISSUER"
+	failMissingCredential failClass = "MISSING_REQUIRED_CREDENTIAL"
+	failSubjectMismatch   failClass = "SUBJECT_MISMATCH"
+	failTrustUnavailable  failClass = "TRUST_POLICY_UNAVAILABLE"
 )
 
 // Beckn v2.0.0 ErrorCode values this package classifies its failures onto.
@@ -139,8 +146,11 @@ type proof struct {
 	Type               string `json:"type"`
 	JWT                string `json:"jwt"`
 	ProofValue         string `json:"proofValue"`
+	Cryptosuite        string `json:"cryptosuite"`
+	ProofPurpose       string `json:"proofPurpose"`
 	VerificationMethod string `json:"verificationMethod"`
 	Created            string `json:"created"`
+	Expires            string `json:"expires"`
 }
 
 // issuerDID extracts the issuer DID, whether issuer is a bare string or an
@@ -171,6 +181,7 @@ type verifier struct {
 	cfg       *Config
 	fetch     fetcher
 	statusGet statusFetcher
+	contexts  *contextStore
 	now       func() time.Time
 }
 
@@ -179,6 +190,7 @@ func newVerifier(cfg *Config, fetch fetcher) *verifier {
 		cfg:       cfg,
 		fetch:     fetch,
 		statusGet: httpStatusFetcher(http.DefaultClient),
+		contexts:  bundledContexts,
 		now:       time.Now,
 	}
 }
@@ -210,15 +222,18 @@ func (v *verifier) verify(ctx context.Context, raw json.RawMessage) error {
 		if err := v.verifyJWTProof(ctx, &cred, issuer); err != nil {
 			return err
 		}
+	} else if suite := dataIntegritySuite(cred.Proof); cred.Proof.ProofValue != "" && suite != "" {
+		if err := v.verifyDataIntegrityProof(ctx, raw, &cred, issuer, suite); err != nil {
+			return err
+		}
 	} else if cred.Proof.ProofValue != "" {
-		// JSON-LD Data Integrity proof (e.g. Ed25519Signature2020). Verifying
-		// it requires RDF canonicalisation (URDNA2015), which this plugin does
-		// not implement.
+		// Data Integrity proof with a type or cryptosuite this plugin cannot
+		// verify (e.g. ecdsa-rdfc-2019, bbs-2023).
 		if v.cfg.RequireProof {
 			return failf(failProof, codeAutSignatureInvalid,
-				"proof type %q requires JSON-LD canonicalisation which is not supported; "+
+				"proof type %q (cryptosuite %q) is not supported; "+
 					"set requireProof=false to accept on expiry/revocation only",
-				cred.Proof.Type)
+				cred.Proof.Type, cred.Proof.Cryptosuite)
 		}
 		// Best-effort: confirm the verification method DID resolves. Without a
 		// verificationMethod there is nothing at all left to check — the same
@@ -253,10 +268,27 @@ func (v *verifier) verify(ctx context.Context, raw json.RawMessage) error {
 // verifyJWTProof verifies a VC-JWT (proof.jwt) signature against the issuer's
 // resolved DID key and enforces that the signer is the issuer.
 func (v *verifier) verifyJWTProof(ctx context.Context, cred *credential, issuer string) error {
-	token := cred.Proof.JWT
+	payload, err := v.verifyIssuerJWS(ctx, cred.Proof.JWT, issuer)
+	if err != nil || payload == nil {
+		return err
+	}
+
+	// Validate JWT temporal claims (nbf/exp) too.
+	if v.cfg.CheckExpiry {
+		if err := v.checkJWTClaims(payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyIssuerJWS verifies a compact JWS signed by issuer and returns its
+// payload. A nil payload with a nil error means the issuer key could not be
+// resolved over the network and FailOpen let the credential through.
+func (v *verifier) verifyIssuerJWS(ctx context.Context, token, issuer string) ([]byte, error) {
 	header, err := decodeJWTHeader(token)
 	if err != nil {
-		return failf(failProof, codeAutSignatureInvalid, "%v", err)
+		return nil, failf(failProof, codeAutSignatureInvalid, "%v", err)
 	}
 
 	// The signing key DID comes from the JWT `kid` (its controller). It MUST
@@ -267,36 +299,29 @@ func (v *verifier) verifyJWTProof(ctx context.Context, cred *credential, issuer
 		signerDID = issuer
 	}
 	if base(signerDID) != base(issuer) {
-		return failf(failIssuer, codeAutUnauthorizedAction,
+		return nil, failf(failIssuer, codeAutUnauthorizedAction,
 			"proof signer %q does not match issuer %q", signerDID, issuer)
 	}
 
 	key, err := resolveDID(ctx, header.Kid, header.Alg, v.cfg, v.fetch)
 	if err != nil {
 		if isNetErr(err) && v.cfg.FailOpen {
-			return nil
+			return nil, nil
 		}
-		return failf(failResolution, resolutionCode(err), "resolve %q: %v", header.Kid, err)
+		return nil, failf(failResolution, resolutionCode(err), "resolve %q: %v", header.Kid, err)
 	}
 
 	// Alg-confusion protection: the header alg must match the resolved key.
 	if header.Alg != key.alg.String() {
-		return failf(failProof, codeAutSignatureInvalid,
+		return nil, failf(failProof, codeAutSignatureInvalid,
 			"header alg %q does not match issuer key algorithm %q", header.Alg, key.alg.String())
 	}
 
 	payload, err := jws.Verify([]byte(token), jws.WithKey(key.alg, key.pub))
 	if err != nil {
-		return failf(failProof, codeAutSignatureInvalid, "signature verification failed: %v", err)
+		return nil, failf(failProof, codeAutSignatureInvalid, "signature verification failed: %v", err)
 	}
-
-	// Validate JWT temporal claims (nbf/exp) too.
-	if v.cfg.CheckExpiry {
-		if err := v.checkJWTClaims(payload); err != nil {
-			return err
-		}
-	}
-	return nil
+	return payload, nil
 }
 
 type jwtHeader struct {
@@ -1026,25 +1051,42 @@ type fetcher func(ctx context.Context, url string) ([]byte, error)
 // httpFetcher returns a fetcher backed by an http.Client with the configured
 // timeout.
 func httpFetcher(client *http.Client) fetcher {
-	return func(ctx context.Context, url string) ([]byte, error) {
+	return httpOriginFetcher(client).bodyOnly()
+}
+
+// originFetcher is a fetcher that also returns the response headers, which
+// fetchCache reads the cache lifetime from.
+type originFetcher func(ctx context.Context, url string) ([]byte, http.Header, error)
+
+// httpOriginFetcher returns an originFetcher backed by an http.Client.
+func httpOriginFetcher(client *http.Client) originFetcher {
+	return func(ctx context.Context, url string) ([]byte, http.Header, error) {
 		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		req.Header.Set("Accept", "application/json, application/did+json, */*")
 		resp, err := client.Do(req)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		defer resp.Body.Close()
 		body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-			return nil, fmt.Errorf("http %d for %s", resp.StatusCode, url)
+			return nil, nil, fmt.Errorf("http %d for %s", resp.StatusCode, url)
 		}
-		return body, nil
+		return body, resp.Header, nil
+	}
+}
+
+// bodyOnly drops the headers.
+func (f originFetcher) bodyOnly() fetcher {
+	return func(ctx context.Context, url string) ([]byte, error) {
+		body, _, err := f(ctx, url)
+		return body, err
 	}
 }
 
@@ -280,6 +280,26 @@ func ProcessAuditPayload(ctx context.Context, body []byte) []byte {
 	return out
 }
 
+// MaskPayload applies the PII masking rules (maskRules and pathOverrides) to
+// body, without selective-mode field dropping, for callers that show whole
+// payloads outside the audit log. Unlike ProcessAuditPayload it returns an
+// error instead of the original body when body is not a JSON object, so an
+// unmaskable payload (for example one truncated on storage) can be withheld.
+// With no audit config loaded, body is returned unchanged.
+func MaskPayload(body []byte) ([]byte, error) {
+	cfg := GetCompiledConfig()
+	if cfg == nil || len(body) == 0 {
+		return body, nil
+	}
+
+	var root map[string]interface{}
+	if err := json.Unmarshal(body, &root); err != nil {
+		return nil, fmt.Errorf("payload is not a JSON object: %w", err)
+	}
+	walkMap(root, "", cfg, nil)
+	return json.Marshal(root)
+}
+
 // extractAction reads context.action from the unmarshalled root without
 // allocating a new map — just a single type assertion per level.
 func extractAction(root map[string]interface{}) string {
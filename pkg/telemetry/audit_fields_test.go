@@ -513,3 +513,57 @@ func TestApplyMask_NonStringValue(t *testing.T) {
 	assert.Equal(t, "[REDACTED]", applyMask(42, p))
 	assert.Equal(t, "[REDACTED]", applyMask(true, p))
 }
+
+// ── MaskPayload ──────────────────────────────────────────────────────────────
+
+func TestMaskPayload_MasksWithoutDroppingFields(t *testing.T) {
+	resetConfig(t)
+	url := serveYAML(t, `
+mode: selective
+patterns:
+  email:
+    maskType: replace
+    mask: "***@***.***"
+maskRules:
+  - keys: [email]
+    pattern: email
+selectedFields:
+  default:
+    - context.transactionId
+`)
+	require.NoError(t, LoadAuditConfig(context.Background(), url))
+
+	masked, err := MaskPayload([]byte(`{"context":{"transactionId":"txn-1","domain":"retail"},"message":{"buyer":{"email":"secret@example.com","name":"Alice"}}}`))
+	require.NoError(t, err)
+	out := unmarshalJSON(t, masked)
+	buyer := out["message"].(map[string]interface{})["buyer"].(map[string]interface{})
+
+	assert.Equal(t, "***@***.***", buyer["email"])
+	assert.Equal(t, "Alice", buyer["name"], "selectedFields must not drop fields")
+	assert.Equal(t, "retail", out["context"].(map[string]interface{})["domain"])
+}
+
+func TestMaskPayload_InvalidJSONIsAnError(t *testing.T) {
+	resetConfig(t)
+	url := serveYAML(t, `
+mode: full
+patterns:
+  email:
+    mask: "x"
+maskRules:
+  - keys: [email]
+    pattern: email
+`)
+	require.NoError(t, LoadAuditConfig(context.Background(), url))
+
+	_, err := MaskPayload([]byte(`{"message":{"email":"secret@exa`))
+	assert.Error(t, err)
+}
+
+func TestMaskPayload_NoConfigPassThrough(t *testing.T) {
+	resetConfig(t)
+	body := []byte(`{"email":"a@b.c"}`)
+	masked, err := MaskPayload(body)
+	require.NoError(t, err)
+	assert.Equal(t, body, masked)
+}
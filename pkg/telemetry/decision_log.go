@@ -0,0 +1,151 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	logger "github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/global"
+)
+
+// decisionEventName marks policy decision records so a collector can route
+// them apart from the message audit records sharing the same logger.
+const decisionEventName = "policy.decision"
+
+// EmitDecisionLogs emits a policy decision record through the same OTel logs
+// pipeline as EmitAuditLogs. record is the already-serialised decision; callers
+// redact it with PayloadRedactor, since the decision quotes the evaluated
+// payload rather than carrying it.
+func EmitDecisionLogs(ctx context.Context, record []byte, attrs ...log.KeyValue) {
+	if !LogsEnabled() {
+		logger.Debugf(ctx, "audit logs disabled, skipping decision log emit")
+		return
+	}
+
+	r := log.Record{}
+	r.SetEventName(decisionEventName)
+	r.SetBody(log.StringValue(string(record)))
+	r.SetTimestamp(time.Now())
+	r.SetObservedTimestamp(time.Now())
+	r.SetSeverity(log.SeverityInfo)
+
+	txnID, _ := ctx.Value(model.ContextKeyTxnID).(string)
+	msgID, _ := ctx.Value(model.ContextKeyMsgID).(string)
+	parentID, _ := ctx.Value(model.ContextKeyParentID).(string)
+
+	r.AddAttributes(
+		log.String("log_uuid", uuid.New().String()),
+		log.String("transaction_id", txnID),
+		log.String("message_id", msgID),
+		log.String("parent_id", parentID),
+	)
+	if len(attrs) > 0 {
+		r.AddAttributes(attrs...)
+	}
+
+	global.GetLoggerProvider().Logger(auditLoggerName).Emit(ctx, r)
+}
+
+// PayloadRedactor returns a Replacer that rewrites, in free text, every value
+// the audit config masks in any of payloads — for example a policy violation
+// message or evaluation trace that quotes the buyer's phone number. Each
+// value is replaced with its masked form, however short: a short value ("IN",
+// "1") may also mask unrelated text, which is preferred to leaking it.
+// Without an audit config the Replacer leaves text unchanged; payloads that
+// are not JSON objects contribute nothing.
+func PayloadRedactor(ctx context.Context, payloads ...[]byte) *strings.Replacer {
+	cfg := GetCompiledConfig()
+	if cfg == nil {
+		return strings.NewReplacer()
+	}
+	masked := make(map[string]string)
+	for _, payload := range payloads {
+		if len(payload) == 0 {
+			continue
+		}
+		var root map[string]interface{}
+		if err := json.Unmarshal(payload, &root); err != nil {
+			logger.Debugf(ctx, "audit: payload is not a JSON object, nothing to redact")
+			continue
+		}
+		collectMaskedMap(root, "", cfg, masked)
+	}
+
+	// Longest first, so a value that contains another is replaced whole.
+	olds := make([]string, 0, len(masked))
+	for old := range masked {
+		olds = append(olds, old)
+	}
+	sort.Slice(olds, func(i, j int) bool {
+		if len(olds[i]) != len(olds[j]) {
+			return len(olds[i]) > len(olds[j])
+		}
+		return olds[i] < olds[j]
+	})
+	pairs := make([]string, 0, 2*len(olds))
+	for _, old := range olds {
+		pairs = append(pairs, old, masked[old])
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// collectMaskedMap mirrors walkMap's key-name and path-override matching,
+// recording the original and masked form of every value that would be masked.
+func collectMaskedMap(node map[string]interface{}, prefix string, cfg *CompiledConfig, masked map[string]string) {
+	for key, val := range node {
+		fullPath := prefix + key
+		pattern, ok := cfg.keyToPattern[key]
+		if !ok {
+			pattern, ok = cfg.pathOverrides[fullPath]
+		}
+		if ok {
+			collectMaskedLeaves(val, pattern, masked)
+			continue
+		}
+		switch child := val.(type) {
+		case map[string]interface{}:
+			collectMaskedMap(child, fullPath+".", cfg, masked)
+		case []interface{}:
+			for _, elem := range child {
+				if m, ok := elem.(map[string]interface{}); ok {
+					collectMaskedMap(m, fullPath+".", cfg, masked)
+				}
+			}
+		}
+	}
+}
+
+// collectMaskedLeaves records every scalar under a masked value: masking an
+// object replaces it whole, so any of its fields may surface in text.
+func collectMaskedLeaves(val interface{}, pattern *CompiledPattern, masked map[string]string) {
+	var s string
+	switch v := val.(type) {
+	case map[string]interface{}:
+		for _, child := range v {
+			collectMaskedLeaves(child, pattern, masked)
+		}
+		return
+	case []interface{}:
+		for _, child := range v {
+			collectMaskedLeaves(child, pattern, masked)
+		}
+		return
+	case string:
+		s = v
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return
+	}
+	if s == "" {
+		return // an empty old string would match between every rune
+	}
+	masked[s], _ = applyMask(s, pattern).(string)
+}
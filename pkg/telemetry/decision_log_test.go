@@ -0,0 +1,91 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/log"
+)
+
+func withCompiledConfig(t *testing.T, cfg *CompiledConfig) {
+	t.Helper()
+	compiledCfgMu.Lock()
+	prev := compiledCfg
+	compiledCfg = cfg
+	compiledCfgMu.Unlock()
+	t.Cleanup(func() {
+		compiledCfgMu.Lock()
+		compiledCfg = prev
+		compiledCfgMu.Unlock()
+	})
+}
+
+func TestPayloadRedactor(t *testing.T) {
+	ctx := context.Background()
+	payload := []byte(`{
+		"context": {"action": "confirm", "bap_id": "bap.example.com"},
+		"message": {"order": {
+			"billing": {"phone": "9876543210", "email": "a@b"},
+			"fulfillments": [{"end": {"contact": {"phone": "9123456789"}}}],
+			"payment": {"params": {"bank_account_number": 12345678}}
+		}}
+	}`)
+
+	t.Run("no config", func(t *testing.T) {
+		withCompiledConfig(t, nil)
+		assert.Equal(t, "phone 9876543210", PayloadRedactor(ctx, payload).Replace("phone 9876543210"))
+	})
+
+	withCompiledConfig(t, &CompiledConfig{
+		mode: "full",
+		keyToPattern: map[string]*CompiledPattern{
+			"phone": {MaskType: "last4"},
+			"email": {MaskType: "replace", Mask: "[EMAIL]"},
+		},
+		pathOverrides: map[string]*CompiledPattern{
+			"message.order.payment.params": {MaskType: "replace"},
+		},
+	})
+	r := PayloadRedactor(ctx, payload)
+
+	assert.Equal(t, `phone "******3210" blocked`, r.Replace(`phone "9876543210" blocked`))
+	assert.Equal(t, "contact ******6789", r.Replace("contact 9123456789"), "values in arrays are redacted")
+	assert.Equal(t, "account [MASKED]", r.Replace("account 12345678"), "numbers under a masked object are redacted")
+	assert.Equal(t, "email [EMAIL]", r.Replace("email a@b"), "short values are redacted too")
+	assert.Equal(t, "bap bap.example.com", r.Replace("bap bap.example.com"), "unmasked values are left alone")
+
+	assert.Equal(t, "x", PayloadRedactor(ctx, []byte("not json")).Replace("x"))
+}
+
+func TestEmitDecisionLogs(t *testing.T) {
+	ctx := context.Background()
+	provider, exporter, err := NewTestProviderWithLogs(ctx)
+	require.NoError(t, err)
+	defer provider.Shutdown(ctx)
+
+	EmitDecisionLogs(ctx, []byte(`{"result":"deny"}`), log.String("policy.decision", "deny"))
+
+	records := exporter.Records()
+	require.Len(t, records, 1)
+	assert.Equal(t, "policy.decision", records[0].EventName())
+	assert.Equal(t, `{"result":"deny"}`, records[0].Body().AsString())
+
+	var hasDecision, hasLogUUID bool
+	records[0].WalkAttributes(func(kv log.KeyValue) bool {
+		switch kv.Key {
+		case "policy.decision":
+			hasDecision = true
+		case "log_uuid":
+			hasLogUUID = true
+		}
+		return true
+	})
+	assert.True(t, hasDecision, "caller attributes should be attached")
+	assert.True(t, hasLogUUID, "decision record should include log_uuid")
+
+	SetLogsEnabled(false)
+	EmitDecisionLogs(ctx, []byte(`{}`))
+	assert.Len(t, exporter.Records(), 1, "nothing is emitted with logs disabled")
+}
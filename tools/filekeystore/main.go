@@ -0,0 +1,217 @@
+// Command filekeystore manages the passphrase-encrypted keystore file read by
+// the filekeystore key manager plugin. Keysets are stored under the
+// subscriber ID, which is the key the adapter's sign step looks them up by.
+//
+// The passphrase is read from --passphrase-file when set, otherwise from the
+// environment variable named by --passphrase-env (ONIX_KEYSTORE_PASSPHRASE by
+// default) — the same resolution the plugin uses.
+//
+// Usage:
+//
+//	filekeystore create --path <file> --subscriber <id>   create the store (if needed) and generate a keyset
+//	filekeystore list   --path <file>                     list stored keysets (public data only)
+//	filekeystore rotate --path <file> --subscriber <id>   replace a subscriber's keyset with a new one
+//	filekeystore export --path <file> [--subscriber <id>] print public keys as JSON for registry onboarding
+//
+// Run create/rotate while the adapter is stopped; the plugin reads the file
+// once at startup.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/implementation/filekeystore"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: filekeystore <create|list|rotate|export> [flags]")
+		os.Exit(1)
+	}
+
+	switch os.Args[1] {
+	case "create":
+		runCreate(os.Args[2:])
+	case "list":
+		runList(os.Args[2:])
+	case "rotate":
+		runRotate(os.Args[2:])
+	case "export":
+		runExport(os.Args[2:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q — expected create, list, rotate or export\n", os.Args[1])
+		os.Exit(1)
+	}
+}
+
+// storeFlags are the flags shared by every subcommand.
+type storeFlags struct {
+	path           *string
+	passphraseFile *string
+	passphraseEnv  *string
+}
+
+func addStoreFlags(fs *flag.FlagSet) storeFlags {
+	return storeFlags{
+		path:           fs.String("path", "", "keystore file"),
+		passphraseFile: fs.String("passphrase-file", "", "file containing the keystore passphrase (e.g. a mounted secret)"),
+		passphraseEnv:  fs.String("passphrase-env", filekeystore.DefaultPassphraseEnv, "environment variable holding the keystore passphrase"),
+	}
+}
+
+func (f storeFlags) open(cmd string, create bool) *filekeystore.Store {
+	if *f.path == "" {
+		fmt.Fprintf(os.Stderr, "%s: --path is required\n", cmd)
+		os.Exit(1)
+	}
+	passphrase, err := filekeystore.ReadPassphrase(*f.passphraseFile, *f.passphraseEnv)
+	if err != nil {
+		fatalf("read passphrase: %v", err)
+	}
+	store, err := filekeystore.OpenStore(*f.path, passphrase, create)
+	if err != nil {
+		fatalf("open keystore: %v", err)
+	}
+	return store
+}
+
+func runCreate(args []string) {
+	fs := flag.NewFlagSet("create", flag.ExitOnError)
+	sf := addStoreFlags(fs)
+	subscriberID := fs.String("subscriber", "", "subscriber ID to generate a keyset for")
+	fs.Parse(args)
+
+	if *subscriberID == "" {
+		fmt.Fprintln(os.Stderr, "create: --subscriber is required")
+		os.Exit(1)
+	}
+
+	store := sf.open("create", true)
+	if _, ok := store.Get(*subscriberID); ok {
+		fatalf("keyset for %s already exists — use rotate to replace it", *subscriberID)
+	}
+	ks := generate(*subscriberID)
+	if err := store.Put(*subscriberID, ks); err != nil {
+		fatalf("store keyset: %v", err)
+	}
+
+	fmt.Printf("keyset created for %s in %s\n", *subscriberID, *sf.path)
+	printPublic(ks)
+}
+
+func runList(args []string) {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	sf := addStoreFlags(fs)
+	fs.Parse(args)
+
+	store := sf.open("list", false)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SUBSCRIBER ID\tUNIQUE KEY ID\tSIGNING PUBLIC KEY")
+	for _, id := range store.IDs() {
+		ks, _ := store.Get(id)
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", id, ks.UniqueKeyID, ks.SigningPublic)
+	}
+	tw.Flush()
+}
+
+func runRotate(args []string) {
+	fs := flag.NewFlagSet("rotate", flag.ExitOnError)
+	sf := addStoreFlags(fs)
+	subscriberID := fs.String("subscriber", "", "subscriber ID whose keyset is replaced")
+	fs.Parse(args)
+
+	if *subscriberID == "" {
+		fmt.Fprintln(os.Stderr, "rotate: --subscriber is required")
+		os.Exit(1)
+	}
+
+	store := sf.open("rotate", false)
+	old, ok := store.Get(*subscriberID)
+	if !ok {
+		fatalf("no keyset for %s — use create first", *subscriberID)
+	}
+	ks := generate(*subscriberID)
+	if err := store.Put(*subscriberID, ks); err != nil {
+		fatalf("store keyset: %v", err)
+	}
+
+	fmt.Printf("keyset rotated for %s (previous unique key id %s)\n", *subscriberID, old.UniqueKeyID)
+	fmt.Println("register the new public keys before restarting the adapter:")
+	printPublic(ks)
+}
+
+// publicKeys is the export format: the fields a registry subscription needs.
+type publicKeys struct {
+	SubscriberID     string `json:"subscriber_id"`
+	UniqueKeyID      string `json:"unique_key_id"`
+	SigningPublicKey string `json:"signing_public_key"`
+	EncrPublicKey    string `json:"encr_public_key"`
+}
+
+func runExport(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	sf := addStoreFlags(fs)
+	subscriberID := fs.String("subscriber", "", "export only this subscriber's keys (default: all)")
+	fs.Parse(args)
+
+	store := sf.open("export", false)
+	ids := store.IDs()
+	if *subscriberID != "" {
+		ids = []string{*subscriberID}
+	}
+
+	out := make([]publicKeys, 0, len(ids))
+	for _, id := range ids {
+		ks, ok := store.Get(id)
+		if !ok {
+			fatalf("no keyset for %s", id)
+		}
+		out = append(out, toPublic(id, ks))
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		fatalf("encode public keys: %v", err)
+	}
+}
+
+func generate(subscriberID string) *model.Keyset {
+	ks, err := filekeystore.GenerateKeyset()
+	if err != nil {
+		fatalf("generate keyset: %v", err)
+	}
+	ks.SubscriberID = subscriberID
+	return ks
+}
+
+func toPublic(id string, ks *model.Keyset) publicKeys {
+	subscriberID := ks.SubscriberID
+	if subscriberID == "" {
+		subscriberID = id
+	}
+	return publicKeys{
+		SubscriberID:     subscriberID,
+		UniqueKeyID:      ks.UniqueKeyID,
+		SigningPublicKey: ks.SigningPublic,
+		EncrPublicKey:    ks.EncrPublic,
+	}
+}
+
+func printPublic(ks *model.Keyset) {
+	data, err := json.MarshalIndent(toPublic(ks.SubscriberID, ks), "", "  ")
+	if err != nil {
+		fatalf("encode public keys: %v", err)
+	}
+	fmt.Println(string(data))
+}
+
+func fatalf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "error: "+format+"\n", args...)
+	os.Exit(1)
+}
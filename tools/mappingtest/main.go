@@ -0,0 +1,73 @@
+// Command mappingtest runs reqmapper mappings over fixture payloads and
+// checks each maps to the expected output, so mapping changes can be tested
+// and reviewed before they reach an adapter.
+//
+// Fixtures are .json files in a directory per role and action under --cases,
+// sharing a name:
+//
+//	cases/bap/search/by_category.input.json
+//	cases/bap/search/by_category.expected.json
+//
+// A fixture may add <name>.response.json and <name>.expected-response.json
+// to test the action's response mapping too. An expected string value of
+// "<any>" matches any value, for fields such as $uuid().
+//
+// Usage:
+//
+//	mappingtest --mappings <mappings.yaml> --cases <dir> [-v]
+//
+// Each failing fixture is listed with one line per difference, at the JSON
+// Pointer it was found at. The exit status is 0 when every fixture passes, 1
+// when any fails and 2 when the mappings or fixtures cannot be loaded.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/beckn-one/beckn-onix/pkg/plugin/implementation/reqmapper"
+)
+
+func main() {
+	mappingsFile := flag.String("mappings", "", "mappings file (the plugin's mappingsFile)")
+	casesDir := flag.String("cases", "", "directory with <role>/<action>/ sub-directories of fixtures")
+	verbose := flag.Bool("v", false, "list passing fixtures too")
+	flag.Parse()
+
+	if *mappingsFile == "" || *casesDir == "" {
+		fmt.Fprintln(os.Stderr, "mappingtest: --mappings and --cases are required")
+		os.Exit(2)
+	}
+
+	results, err := reqmapper.RunMappingTests(context.Background(), *mappingsFile, *casesDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(2)
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.Passed() {
+			if *verbose {
+				fmt.Printf("PASS  %s\n", r.Name)
+			}
+			continue
+		}
+		failed++
+		if r.Err != nil {
+			fmt.Printf("FAIL  %s  %v\n", r.Name, r.Err)
+			continue
+		}
+		fmt.Printf("FAIL  %s\n", r.Name)
+		for _, d := range r.Diffs {
+			fmt.Printf("      %s\n", d)
+		}
+	}
+
+	fmt.Printf("%d passed, %d failed\n", len(results)-failed, failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,101 @@
+// Command policytest runs sample Beckn payloads against a network policy
+// config and checks each gets the expected allow/deny decision, so a network
+// facilitator can test policies before publishing them.
+//
+// Payloads are .json files under two sub-directories of --cases named for
+// the expected decision:
+//
+//	cases/allow/search.json
+//	cases/deny/confirm_without_billing.json
+//
+// Each payload is evaluated by the policy checkPolicy would pick for it: the
+// one keyed by its context.networkId, else the default policy. A payload's
+// input.onix is read from a file beside it with an .onix.json extension
+// (cases/deny/confirm.onix.json for cases/deny/confirm.json), and is empty
+// without one.
+//
+// Usage:
+//
+//	policytest --config <networkPolicyConfig> --cases <dir> [--explain notes|fails|full] [--set key=value ...] [-v]
+//
+// --set adds a runtime config value (data.config in Rego), as the plugin's
+// own config does. The exit status is 0 when every case passes, 1 when any
+// fails and 2 when the policies or cases cannot be loaded.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/beckn-one/beckn-onix/pkg/plugin/implementation/opapolicychecker"
+)
+
+// setFlags collects repeated --set key=value flags.
+type setFlags map[string]string
+
+func (s setFlags) String() string { return "" }
+
+func (s setFlags) Set(v string) error {
+	key, value, ok := strings.Cut(v, "=")
+	if !ok || strings.TrimSpace(key) == "" {
+		return fmt.Errorf("expected key=value, got %q", v)
+	}
+	s[strings.TrimSpace(key)] = value
+	return nil
+}
+
+func main() {
+	runtimeConfig := setFlags{}
+	configFile := flag.String("config", "", "network policy config (the plugin's networkPolicyConfig file)")
+	casesDir := flag.String("cases", "", "directory with allow/ and deny/ sub-directories of .json payloads")
+	explain := flag.String("explain", "off", "explanation printed for failing cases: off, notes, fails or full")
+	verbose := flag.Bool("v", false, "list passing cases too")
+	flag.Var(runtimeConfig, "set", "runtime config value key=value, available to policies as data.config (repeatable)")
+	flag.Parse()
+
+	if *configFile == "" || *casesDir == "" {
+		fmt.Fprintln(os.Stderr, "policytest: --config and --cases are required")
+		os.Exit(2)
+	}
+
+	cfg := map[string]string{"networkPolicyConfig": *configFile}
+	for k, v := range runtimeConfig {
+		cfg[k] = v
+	}
+
+	results, err := opapolicychecker.RunPolicyTests(context.Background(), cfg, *casesDir, *explain)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(2)
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.Passed() {
+			if *verbose {
+				fmt.Printf("PASS  %s  (%s, policy %s)\n", r.Name, r.Got, policyName(r))
+			}
+			continue
+		}
+		failed++
+		fmt.Printf("FAIL  %s  %s  (policy %s, revision %s)\n", r.Name, r.Summary(), policyName(r), r.Revision)
+		for _, line := range r.Explanation {
+			fmt.Printf("      %s\n", line)
+		}
+	}
+
+	fmt.Printf("%d passed, %d failed\n", len(results)-failed, failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func policyName(r opapolicychecker.PolicyTestResult) string {
+	if r.Policy == "" {
+		return "none"
+	}
+	return r.Policy
+}
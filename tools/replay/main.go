@@ -0,0 +1,162 @@
+// Command replay re-submits stored Beckn requests to an ONIX module through
+// a transactionHistory module, for reproducing incidents. The history module
+// reads the request from its PayloadStore, refreshes context.timestamp,
+// re-signs it when it has a Signer, and posts it to one of its configured
+// replay targets, so payloads never leave the adapter unmasked.
+//
+// Usage:
+//
+//	replay --api <history module URL> --target <name> --message <messageId> [--new-message-id]
+//	replay --api <history module URL> --target <name> --transaction <transactionId> [--actions a,b] [--new-message-id]
+//
+// With --transaction, every stored request of the transaction (optionally
+// only those whose action is in --actions) is replayed in the order it was
+// stored, stopping at the first one the history module cannot replay.
+//
+// The bearer token, one of the module's history replay tokens, is read from
+// --token or, by default, the ONIX_HISTORY_REPLAY_TOKEN environment variable. The exit status is 0 when every
+// replayed request is answered with a 2xx status, 1 when any is not, and 2
+// when a request cannot be replayed at all.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+type historyEntry struct {
+	MessageID string `json:"messageId"`
+	Action    string `json:"action"`
+	Kind      string `json:"kind"`
+}
+
+type replayResult struct {
+	OriginalMessageID string          `json:"originalMessageId"`
+	MessageID         string          `json:"messageId"`
+	StatusCode        int             `json:"statusCode"`
+	Response          json.RawMessage `json:"response"`
+}
+
+type client struct {
+	api   string
+	token string
+	http  *http.Client
+}
+
+func main() {
+	api := flag.String("api", "", "URL of the transactionHistory module, e.g. http://localhost:8081/history/")
+	token := flag.String("token", os.Getenv("ONIX_HISTORY_REPLAY_TOKEN"), "replay bearer token (default $ONIX_HISTORY_REPLAY_TOKEN)")
+	target := flag.String("target", "", "replay target name, from the module's history.replayTargets")
+	messageID := flag.String("message", "", "message ID of the request to replay")
+	transactionID := flag.String("transaction", "", "transaction ID whose requests to replay")
+	actions := flag.String("actions", "", "with --transaction, comma-separated actions to replay (default all)")
+	newMessageID := flag.Bool("new-message-id", false, "give each replayed request a fresh message ID")
+	flag.Parse()
+
+	if *api == "" || *target == "" || *token == "" || (*messageID == "") == (*transactionID == "") {
+		fmt.Fprintln(os.Stderr, "replay: --api, --target, a token and exactly one of --message or --transaction are required")
+		os.Exit(2)
+	}
+	c := &client{api: strings.TrimSuffix(*api, "/") + "/", token: *token, http: &http.Client{Timeout: time.Minute}}
+
+	ids := []string{*messageID}
+	if *transactionID != "" {
+		var err error
+		if ids, err = c.transactionRequests(*transactionID, *actions); err != nil {
+			fatalf("%v", err)
+		}
+		if len(ids) == 0 {
+			fatalf("transaction %s has no stored requests to replay", *transactionID)
+		}
+	}
+
+	failed := 0
+	for _, id := range ids {
+		r, err := c.replay(id, *target, *newMessageID)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		fmt.Printf("%s -> %s  HTTP %d  %s\n", r.OriginalMessageID, r.MessageID, r.StatusCode, r.Response)
+		if r.StatusCode < 200 || r.StatusCode > 299 {
+			failed++
+		}
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// transactionRequests returns the message IDs of the transaction's stored
+// requests, in stored order, keeping only the given actions when set.
+func (c *client) transactionRequests(transactionID, actions string) ([]string, error) {
+	var resp struct {
+		Messages []historyEntry `json:"messages"`
+	}
+	if err := c.do(http.MethodGet, "transactions/"+url.PathEscape(transactionID), nil, &resp); err != nil {
+		return nil, err
+	}
+	keep := map[string]bool{}
+	for _, a := range strings.Split(actions, ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			keep[a] = true
+		}
+	}
+	var ids []string
+	for _, e := range resp.Messages {
+		if e.Kind == "request" && (len(keep) == 0 || keep[e.Action]) {
+			ids = append(ids, e.MessageID)
+		}
+	}
+	return ids, nil
+}
+
+func (c *client) replay(messageID, target string, newMessageID bool) (*replayResult, error) {
+	body, _ := json.Marshal(map[string]any{"target": target, "newMessageId": newMessageID})
+	var r replayResult
+	if err := c.do(http.MethodPost, "messages/"+url.PathEscape(messageID)+"/replay", body, &r); err != nil {
+		return nil, fmt.Errorf("replaying %s: %w", messageID, err)
+	}
+	return &r, nil
+}
+
+// do calls the history API and decodes a 200 response into out.
+func (c *client) do(method, path string, body []byte, out any) error {
+	req, err := http.NewRequest(method, c.api+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &e) == nil && e.Error != "" {
+			return fmt.Errorf("%s %s: HTTP %d: %s", method, path, resp.StatusCode, e.Error)
+		}
+		return fmt.Errorf("%s %s: HTTP %d", method, path, resp.StatusCode)
+	}
+	return json.Unmarshal(data, out)
+}
+
+func fatalf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "error: "+format+"\n", args...)
+	os.Exit(2)
+}
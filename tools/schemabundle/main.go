@@ -0,0 +1,74 @@
+// Command schemabundle packs a schema repository into an offline bundle for
+// schemav2validator's "bundle" source type: the core spec, any auxiliary
+// specs and the domain attributes.yaml schemas in one reproducible archive.
+//
+// Usage:
+//
+//	schemabundle --core <spec> [--aux <spec> ...] [--schemas <dir>] [--version <v>] --out <bundle.tar.gz>
+//
+// --schemas is a directory laid out as <Type>/<version>/attributes.yaml,
+// e.g. the schema/ directory of a protocol-specifications checkout. Files a
+// spec reaches through relative $refs are packed beside it; a $ref to a URL
+// fails the build, since a bundle never reaches the network. The same
+// inputs always produce the same bytes, so a bundle can be rebuilt and its
+// signature checked. Sign the result with the beckn key before publishing:
+//
+//	sign sign --key beckn_private.key --input bundle.tar.gz --output bundle.tar.gz.sig
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/beckn-one/beckn-onix/pkg/plugin/implementation/schemav2validator"
+)
+
+// listFlags collects repeated string flags.
+type listFlags []string
+
+func (l *listFlags) String() string { return strings.Join(*l, ",") }
+
+func (l *listFlags) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+func main() {
+	var aux listFlags
+	core := flag.String("core", "", "core OpenAPI spec (e.g. beckn.yaml)")
+	flag.Var(&aux, "aux", "auxiliary OpenAPI spec (repeatable)")
+	schemas := flag.String("schemas", "", "directory of <Type>/<version>/attributes.yaml domain schemas")
+	version := flag.String("version", "", "version label recorded in the bundle manifest")
+	out := flag.String("out", "", "output bundle file (.tar.gz)")
+	flag.Parse()
+
+	if *core == "" || *out == "" {
+		fmt.Fprintln(os.Stderr, "schemabundle: --core and --out are required")
+		os.Exit(1)
+	}
+
+	var buf bytes.Buffer
+	n, err := schemav2validator.BuildBundle(&buf, schemav2validator.BundleSource{
+		Version:    *version,
+		Core:       *core,
+		Auxiliary:  aux,
+		SchemasDir: *schemas,
+	})
+	if err != nil {
+		fatalf("build bundle: %v", err)
+	}
+	if err := os.WriteFile(*out, buf.Bytes(), 0644); err != nil {
+		fatalf("write bundle: %v", err)
+	}
+
+	fmt.Printf("bundled %s + %d auxiliary spec(s) + %d domain schema file(s) → %s\n", *core, len(aux), n, *out)
+	fmt.Printf("sign it before publishing: sign sign --key <priv> --input %s --output %s.sig\n", *out, *out)
+}
+
+func fatalf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "error: "+format+"\n", args...)
+	os.Exit(1)
+}